@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+)
+
+// mib is the unit of data in which alt-DA prices are given.
+const mib = 1 << 20
+
+// altDA is an alternative data availability layer with the price in ETH at
+// which it stores a MiB of data.
+type altDA struct {
+	Name  string
+	Price float64 // ETH per MiB
+}
+
+// parseAltDA parses a comma-separated list of name=price pairs, e.g.
+// "celestia=0.0004,eigenda=0.15USD", into alt-DA layers in the given order.
+// Prices are per MiB, in ETH unless followed by USD, which ethUSD converts.
+func parseAltDA(list string, ethUSD float64) ([]altDA, error) {
+	var layers []altDA
+	for _, pair := range strings.Split(list, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, price, ok := strings.Cut(pair, "=")
+		name, price = strings.TrimSpace(name), strings.TrimSpace(price)
+		if !ok || name == "" || price == "" {
+			return nil, fmt.Errorf("%q is not name=price", pair)
+		}
+		amount, unit := price, ""
+		if i := strings.IndexFunc(price, unicode.IsLetter); i > 0 {
+			amount, unit = strings.TrimSpace(price[:i]), price[i:]
+		}
+		v, err := strconv.ParseFloat(amount, 64)
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("invalid price %q of %s", price, name)
+		}
+		switch strings.ToUpper(unit) {
+		case "", "ETH":
+		case "USD":
+			if ethUSD <= 0 {
+				return nil, fmt.Errorf("the USD price of %s needs -eth-usd", name)
+			}
+			v /= ethUSD
+		default:
+			return nil, fmt.Errorf("unknown currency %q of %s, want ETH or USD", unit, name)
+		}
+		for _, l := range layers {
+			if l.Name == name {
+				return nil, fmt.Errorf("%s is priced twice", name)
+			}
+		}
+		layers = append(layers, altDA{Name: name, Price: v})
+	}
+	return layers, nil
+}
+
+// cost returns what storing size bytes costs on l, in ETH.
+func (l altDA) cost(size uint64) *big.Float {
+	c := new(big.Float).Mul(new(big.Float).SetUint64(size), big.NewFloat(l.Price))
+	return c.Quo(c, new(big.Float).SetUint64(mib))
+}
+
+// altDAColumns returns what the data posted in every bucket would have cost on
+// every layer.
+func altDAColumns(layers []altDA, results map[string]*aggregate.Result) []output.Column {
+	var columns []output.Column
+	for _, l := range layers {
+		c := output.Column{Header: l.Name + " Cost(ETH)", Values: make(map[string]string, len(results))}
+		for k, r := range results {
+			c.Values[k] = l.cost(r.PostedBytes).String()
+		}
+		columns = append(columns, c)
+	}
+	return columns
+}
+
+// printAltDASummary prints what the data posted over the whole report would
+// have cost on every layer, against its L1 cost.
+func printAltDASummary(w io.Writer, layers []altDA, total *aggregate.Result) {
+	fmt.Fprintf(w, "Alt-DA: %d bytes posted for %s ETH on L1\n", total.PostedBytes, total.BlobDependent(total.Cost))
+	for _, l := range layers {
+		cost := l.cost(total.PostedBytes)
+		fmt.Fprintf(w, "  %s: %s ETH, saving %s ETH\n", l.Name, cost.String(), total.BlobDependent(new(big.Float).Sub(total.Cost, cost)))
+	}
+}
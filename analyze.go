@@ -0,0 +1,869 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"math/big"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/alert"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/anomaly"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/budget"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/email"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/fetch"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/heatmap"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/input"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/nonce"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/tracker"
+)
+
+// analysis is a run of analyze or scan: its options, the settings they
+// resolve to and, after tracker.Run, the report and what the steps after
+// the run add to it.
+type analysis struct {
+	*analyzeOptions
+	console
+	fs   *flag.FlagSet
+	scan bool
+
+	// Set by resolve.
+	csvFormat     output.Format
+	notifier      *alert.Notifier
+	customColumns []customColumn
+	location      *time.Location
+	from, to      time.Time // of -from and -to, zero when unset
+	monthly       *budget.Budget
+	currencies    []string
+	layers        []altDA
+	detector      anomaly.Detector
+	detect        bool
+	givenLabels   map[string]string
+	senders       []common.Address
+	recipients    []common.Address
+	allowed       []common.Address
+	roles         map[common.Address]string
+	roleNames     []string
+	deposits      map[common.Address]bool
+	chain         fetch.SystemConfig
+	queryParams   map[string]string
+	blobSchedules []fetch.BlobSchedule
+	oracleWindow  int
+	selectorNames map[string]string
+	comma         rune
+
+	// Set by the run and the steps after it.
+	report      tracker.Report
+	base        string // path of the report without extension
+	ext         string
+	outPath     string
+	artifacts   []string // files written, for -upload
+	heat        *heatmap.Heatmap
+	nonces      *nonce.Tracker
+	nonceReport nonce.Report
+	epochs      []epochCost
+	eras        map[string]string
+	extra       []output.Column
+	activity    map[string]fetch.Activity
+	vaults      map[string]fetch.Revenue
+	received    map[string]*big.Int
+	markets     map[string]fetch.BlobMarket
+	benched     []*benchmark
+	fiatPrices  map[string]map[string]float64
+	tonPrices   map[string]float64
+	anomalies   map[string][]anomaly.Finding
+}
+
+// resolve checks the options of a and parses them into the settings of the
+// run.
+func (a *analysis) resolve() error {
+	var err error
+	if a.scan {
+		if a.address == "" && a.toAddress == "" {
+			return errors.New("scan needs -address or -to-address")
+		}
+		if a.fromBlock == 0 && a.fromDate == "" {
+			return errors.New("scan needs -from-block or -from-date")
+		}
+	}
+
+	switch a.format {
+	case "csv", "json", "jsonl", "parquet", "xlsx", "markdown", "html":
+	default:
+		return fmt.Errorf("unknown format %q", a.format)
+	}
+	a.csvFormat = output.DefaultFormat
+	if err := a.csvFormat.ParseUnits(a.unitList); err != nil {
+		return fmt.Errorf("-units: %w", err)
+	}
+	if a.precision >= 0 {
+		a.csvFormat.Decimals = a.precision
+	}
+	if a.perTx && a.format != "jsonl" && a.format != "parquet" && a.format != "csv" {
+		return errors.New("-per-tx needs -format csv, jsonl or parquet")
+	}
+	if a.feeRecipient != "" && a.systemConfig == "" {
+		return errors.New("-fee-recipient needs -system-config")
+	}
+	if a.feeRecipient != "" && !common.IsHexAddress(a.feeRecipient) {
+		return fmt.Errorf("-fee-recipient: invalid address %q", a.feeRecipient)
+	}
+	if a.revenue && a.l2RPC == "" {
+		return errors.New("-revenue needs -l2-rpc")
+	}
+	if a.frames && !a.perTx {
+		return errors.New("-frames needs -per-tx")
+	}
+	if a.offline && a.cachePath == "" {
+		return errors.New("-offline needs -cache")
+	}
+	if a.sheetID != "" && a.sheetCredentials == "" {
+		return errors.New("-sheet-id needs -sheet-credentials")
+	}
+	if a.appendRuns && (a.scan || a.address != "" || a.toAddress != "" || a.systemConfig != "") && a.name == "" && a.statePath == "" {
+		return errors.New("-append of a scan needs -name, as the default name changes with the scanned blocks")
+	}
+	if a.sinkKind != "" && a.dsn == "" {
+		return errors.New("-sink needs -dsn")
+	}
+	if err := loadSinkPlugins(a.sinkPlugins); err != nil {
+		return err
+	}
+	a.notifier, err = loadAlerts(a.fs)
+	if err != nil {
+		return err
+	}
+	if a.notifier != nil {
+		a.notifier.Logger = a.log
+	}
+	a.customColumns, err = loadColumns(a.fs)
+	if err != nil {
+		return err
+	}
+	a.location, err = time.LoadLocation(a.timezone)
+	if err != nil {
+		return fmt.Errorf("-timezone: %w", err)
+	}
+	if a.fromDay != "" {
+		if a.from, err = time.ParseInLocation(time.DateOnly, a.fromDay, a.location); err != nil {
+			return fmt.Errorf("-from: %w", err)
+		}
+	}
+	if a.toDay != "" {
+		if a.to, err = time.ParseInLocation(time.DateOnly, a.toDay, a.location); err != nil {
+			return fmt.Errorf("-to: %w", err)
+		}
+		a.to = a.to.AddDate(0, 0, 1)
+	}
+	if !a.from.IsZero() && !a.to.IsZero() && !a.from.Before(a.to) {
+		return errors.New("-from is after -to")
+	}
+	if a.monthlyBudget != "" {
+		if a.granularity != "day" {
+			return errors.New("-monthly-budget needs -granularity day")
+		}
+		b, err := budget.Parse(a.monthlyBudget, a.ethUSD)
+		if err != nil {
+			return fmt.Errorf("-monthly-budget: %w", err)
+		}
+		a.monthly = &b
+	}
+	a.currencies = parseFiat(a.fiatList, a.usd)
+	a.layers, err = parseAltDA(a.altDASpec, a.ethUSD)
+	if err != nil {
+		return fmt.Errorf("-alt-da: %w", err)
+	}
+	a.detector = anomaly.Detector{Window: a.anomalyWindow, Sigma: a.anomalySigma, Percent: a.anomalyPercent}
+	a.detect = a.anomalySigma > 0 || a.anomalyPercent > 0
+	if a.detect && a.granularity != "day" {
+		return errors.New("-anomaly-sigma and -anomaly-percent need -granularity day")
+	}
+	// Buckets without transactions and fee withdrawals have no block of ours
+	// to be keyed by.
+	if a.granularity == "block" && a.fillGapsFlag {
+		return errors.New("-fill-gaps cannot be combined with -granularity block")
+	}
+	if a.granularity == "block" && a.feeRecipient != "" {
+		return errors.New("-fee-recipient cannot be combined with -granularity block")
+	}
+	if (a.pushGatewayURL != "" || a.remoteWriteURL != "") && a.granularity != "day" {
+		return errors.New("-push-gateway and -remote-write need -granularity day")
+	}
+	if a.anomalyAlerts && (!a.detect || a.notifier == nil) {
+		return errors.New("-anomaly-alerts needs -anomaly-sigma or -anomaly-percent and an alerts config section")
+	}
+	if a.perSender && !a.bySender {
+		return errors.New("-per-sender needs -by-sender")
+	}
+	var allowedLabels map[string]string
+	if a.address, a.givenLabels, err = splitLabels(a.address); err != nil {
+		return fmt.Errorf("-address: %w", err)
+	}
+	if a.expectedSenders, allowedLabels, err = splitLabels(a.expectedSenders); err != nil {
+		return fmt.Errorf("-expected-senders: %w", err)
+	}
+	maps.Copy(a.givenLabels, allowedLabels)
+	a.senders, err = fetch.ParseAddresses(a.address)
+	if err != nil {
+		return err
+	}
+	a.recipients, err = fetch.ParseAddresses(a.toAddress)
+	if err != nil {
+		return err
+	}
+	a.allowed, err = fetch.ParseAddresses(a.expectedSenders)
+	if err != nil {
+		return fmt.Errorf("-expected-senders: %w", err)
+	}
+	if a.expectedSenders != "" && len(a.allowed) == 0 {
+		return errors.New("-expected-senders needs at least one address")
+	}
+	a.roles, a.roleNames, err = parseRoles(a.rolesSpec)
+	if err != nil {
+		return fmt.Errorf("-roles: %w", err)
+	}
+	bridges, err := fetch.ParseAddresses(a.bridgeList)
+	if err != nil {
+		return fmt.Errorf("-bridge-contracts: %w", err)
+	}
+	if a.systemConfig != "" {
+		if a.senders, a.chain, err = discoverSenders(a.rpcURLs, a.systemConfig, a.requestTimeout, a.senders, a.log); err != nil {
+			return fmt.Errorf("-system-config: %w", err)
+		}
+		a.roles, a.roleNames = systemConfigRoles(a.chain, a.roles, a.roleNames)
+		for _, contract := range bridgeContracts(a.chain) {
+			if !slices.Contains(bridges, contract) {
+				bridges = append(bridges, contract)
+			}
+		}
+	}
+	a.deposits, a.roles, a.roleNames = depositRoles(bridges, a.roles, a.roleNames)
+	if a.disputes {
+		a.roles, a.roleNames = disputeRoles(a.roles, a.roleNames)
+	}
+	if a.address != "" && len(a.senders) == 0 {
+		return errors.New("-address needs at least one address")
+	}
+	if a.runwayOn {
+		switch {
+		case len(a.senders) == 0:
+			return errors.New("-runway needs -address or -system-config")
+		case a.granularity != "day":
+			return errors.New("-runway needs -granularity day")
+		case a.runwayWindow < 1:
+			return errors.New("-runway-window must be at least 1")
+		}
+	}
+	if a.runwayAlert > 0 && (!a.runwayOn || a.notifier == nil) {
+		return errors.New("-runway-alert needs -runway and an alerts config section")
+	}
+	if a.useEtherscan && (len(a.senders) == 0 || len(a.recipients) > 0) {
+		return errors.New("-etherscan lists transactions by sender only; use -address without -to-address")
+	}
+	if a.useBigQuery && len(a.senders) == 0 && (!a.scan || len(a.recipients) == 0) {
+		return errors.New("-bigquery needs -address, or scan with -to-address")
+	}
+	if a.useBigQuery && a.fromDate == "" {
+		return errors.New("-bigquery needs -from-date, which limits the data the query reads")
+	}
+	if a.useBigQuery && a.useEtherscan {
+		return errors.New("-bigquery and -etherscan cannot be combined")
+	}
+	if a.duneQuery != 0 && (a.scan || len(a.senders) > 0 || len(a.recipients) > 0) {
+		return errors.New("-dune-query cannot be combined with scan, -address or -system-config")
+	}
+	if a.duneQuery != 0 && a.duneKey == "" {
+		return errors.New("-dune-query needs -dune-key")
+	}
+	a.queryParams, err = parseDuneParams(a.duneParams)
+	if err != nil {
+		return fmt.Errorf("-dune-params: %w", err)
+	}
+	if a.overpayment && (a.oracleBlocks <= 0 || a.oraclePercentile < 0 || a.oraclePercentile > 100) {
+		return errors.New("-overpayment needs a positive -oracle-blocks and an -oracle-percentile between 0 and 100")
+	}
+	if a.tipMarket && a.tipMarketThreshold <= 0 {
+		return errors.New("-tip-market-threshold must be positive")
+	}
+	if a.progressFile != "" && a.progressInterval <= 0 {
+		return errors.New("-progress-interval must be positive")
+	}
+	a.blobSchedules, err = parseBlobSchedules(a.blobScheduleList)
+	if err != nil {
+		return fmt.Errorf("-blob-schedule: %w", err)
+	}
+	if a.overpayment {
+		a.oracleWindow = a.oracleBlocks
+	}
+	if a.methods {
+		if a.selectorNames, err = methodNames(a.methodNamesPath); err != nil {
+			return fmt.Errorf("-method-names: %w", err)
+		}
+	}
+	a.comma, err = input.ParseDelimiter(a.delimiter)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// writeCompanions writes the files that accompany the report, such as the
+// skipped and failed rows and the -nonces, -heatmap, -per-sender and
+// -channels tables, and decides the report path of an interrupted run.
+func (a *analysis) writeCompanions() error {
+	if a.report.OutOfRange > 0 {
+		a.log.Info("left out transactions outside -from and -to", "transactions", a.report.OutOfRange)
+	}
+	if len(a.report.Skipped) > 0 {
+		if a.skippedPath == "" {
+			a.skippedPath = filepath.Join(filepath.Dir(a.outPath), "skipped-rows.csv")
+		}
+		if err := output.WriteSkipped(a.skippedPath, a.report.Skipped); err != nil {
+			return err
+		}
+		a.artifacts = append(a.artifacts, a.skippedPath)
+		a.log.Warn("skipped invalid or duplicate rows", "rows", len(a.report.Skipped), "report", a.skippedPath)
+	}
+	if len(a.report.Unexpected) > 0 {
+		if a.unexpectedPath == "" {
+			a.unexpectedPath = filepath.Join(filepath.Dir(a.base), "unexpected-senders.csv")
+		}
+		if err := output.WriteUnexpected(a.unexpectedPath, a.report.Unexpected); err != nil {
+			return fmt.Errorf("-unexpected-senders: %w", err)
+		}
+		a.artifacts = append(a.artifacts, a.unexpectedPath)
+		cost := new(big.Int)
+		for _, tx := range a.report.Unexpected {
+			cost.Add(cost, tx.Cost)
+		}
+		a.log.Warn("left out transactions of unexpected senders", "transactions", len(a.report.Unexpected),
+			"costWei", cost, "report", a.unexpectedPath)
+	}
+	if a.report.WasInterrupted() && !(a.perTx && a.format == "jsonl") && a.outPath != "-" {
+		a.outPath = a.base + ".partial." + a.ext
+		a.log.Warn("interrupted; writing a partial report, rerun with -resume to finish",
+			"remaining", a.report.Interrupted, "inputUnread", a.report.Unread, "checkpoint", a.report.CheckpointPath, "report", a.outPath)
+	}
+	if a.nonces != nil {
+		// The transactions replaced by others of the same nonce have no
+		// receipt, but are not failures.
+		for _, failure := range a.report.Failures {
+			a.nonces.AddUnmined(failure.Row)
+		}
+		a.nonceReport = a.nonces.Report()
+		a.report.Failures = slices.DeleteFunc(a.report.Failures, func(failure fetch.Result) bool {
+			return a.nonceReport.Replaced(failure.Row.Hash)
+		})
+		path := a.base + ".nonces.csv"
+		if err := a.nonceReport.WriteCSV(path); err != nil {
+			return fmt.Errorf("-nonces: %w", err)
+		}
+		a.artifacts = append(a.artifacts, path)
+		a.log.Info("nonces written", "path", path)
+	}
+	if failures := a.report.Failures; len(failures) > 0 {
+		if a.failedPath == "" {
+			a.failedPath = filepath.Join(filepath.Dir(a.outPath), "failed-transactions.csv")
+		}
+		if err := output.WriteFailures(a.failedPath, failures); err != nil {
+			return err
+		}
+		a.artifacts = append(a.artifacts, a.failedPath)
+		for _, failure := range failures {
+			a.log.Warn("transaction failed", "tx", failure.Row.Hash, "line", failure.Row.Line, "err", failure.Err)
+		}
+		a.log.Warn("transactions failed", "failed", len(failures), "total", a.report.Rows, "maxAttempts", a.maxAttempts, "report", a.failedPath)
+	}
+
+	if a.report.IndexerResolved > 0 {
+		a.log.Info("receipts fetched from the indexer", "receipts", a.report.IndexerResolved, "url", strings.Join(endpointHosts(a.etherscanURL), ","))
+	}
+	if a.trustCSV {
+		a.log.Info("trust-csv", "resolvedFromCSV", a.report.CSVResolved, "rows", a.report.Rows,
+			"verified", a.report.Verified, "mismatched", a.report.Mismatched)
+	}
+
+	if a.heat != nil {
+		path := a.base + ".heatmap.csv"
+		if err := a.heat.WriteCSV(path); err != nil {
+			return fmt.Errorf("-heatmap: %w", err)
+		}
+		a.artifacts = append(a.artifacts, path)
+		a.log.Info("heatmap written", "path", path)
+	}
+	if a.perSender {
+		paths, err := writeSenderReports(a.base, senderLabels(a.chain, a.givenLabels), a.report.Senders)
+		a.artifacts = append(a.artifacts, paths...)
+		if err != nil {
+			return fmt.Errorf("-per-sender: %w", err)
+		}
+		a.log.Info("sender reports written", "senders", len(paths))
+	}
+	if a.channels {
+		path := a.base + ".channels.csv"
+		if err := writeChannels(path, a.report.Channels); err != nil {
+			return fmt.Errorf("-channels: %w", err)
+		}
+		a.artifacts = append(a.artifacts, path)
+		a.epochs = epochCosts(a.report.Channels)
+		path = a.base + ".epochs.csv"
+		if err := writeEpochs(path, a.epochs); err != nil {
+			return fmt.Errorf("-channels: %w", err)
+		}
+		a.artifacts = append(a.artifacts, path)
+		a.log.Info("channels written", "channels", len(a.report.Channels), "epochs", len(a.epochs), "path", path)
+	}
+	return nil
+}
+
+// addColumns adds the columns of the options to the csv and markdown
+// reports, reading what they need after the run, such as L2 activity, fee
+// withdrawals, blob markets, benchmarks and prices. cfg is the configuration
+// of the run, which the benchmarks scan like.
+func (a *analysis) addColumns(cfg tracker.Config) error {
+	var err error
+	if a.splitEras {
+		if a.eras, err = bucketEras(a.report.ChainID, a.granularity, a.location, a.report.Dates, a.report.Results); err != nil {
+			return fmt.Errorf("-eras: %w", err)
+		}
+	}
+	if a.simpleMean {
+		a.extra = meanColumns(a.report.Results)
+	}
+	if a.eras != nil {
+		a.extra = append(a.extra, eraColumn(a.eras))
+	}
+	a.extra = append(a.extra, cumulativeColumn(a.report.Dates, a.report.Results))
+	if a.tips {
+		a.extra = append(a.extra, feeColumns(a.report.Results)...)
+	}
+	if a.inclusion {
+		a.extra = append(a.extra, inclusionColumns(a.report.Results)...)
+	}
+	if a.beaconURL != "" {
+		a.extra = append(a.extra, utilizationColumn(a.report.Results))
+	}
+	if a.roles != nil {
+		a.extra = append(a.extra, roleColumns(a.roleNames, a.report.Results)...)
+	}
+	if a.methods {
+		a.extra = append(a.extra, methodColumns(a.selectorNames, a.report.Results)...)
+	}
+	if a.bySender {
+		a.extra = append(a.extra, senderColumns(senderLabels(a.chain, a.givenLabels), a.report.Results)...)
+	}
+	if a.l2RPC != "" {
+		pool, err := fetch.Dial(a.l2RPC)
+		if err != nil {
+			return fmt.Errorf("-l2-rpc: %w", err)
+		}
+		pool.Timeout = a.requestTimeout
+		pool.Logger = a.log
+		l2 := &fetch.Scanner{
+			RPC:         pool,
+			Retry:       fetch.RetryPolicy{MaxAttempts: a.maxAttempts, BaseDelay: a.retryDelay, MaxDelay: a.retryMaxDelay},
+			Concurrency: a.concurrency,
+			BatchSize:   a.batchSize,
+			Logger:      a.log,
+		}
+		// The run is over, but reading a long period can still be interrupted.
+		l2ctx, stopL2 := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		a.activity, err = l2Activity(l2ctx, l2, a.granularity, a.location, a.report.Dates, a.log)
+		if err == nil && a.revenue {
+			a.vaults, err = l2Revenue(l2ctx, l2, a.granularity, a.location, a.report.Dates)
+		}
+		stopL2()
+		pool.Close()
+		if err != nil {
+			return fmt.Errorf("-l2-rpc: %w", err)
+		}
+		a.extra = append(a.extra, l2Columns(a.report.Results, a.activity)...)
+		if a.vaults != nil {
+			a.extra = append(a.extra, revenueColumns(a.report.Results, a.vaults)...)
+		}
+	}
+	if a.feeRecipient != "" {
+		pool, err := fetch.Dial(a.rpcURLs)
+		if err != nil {
+			return err
+		}
+		pool.Timeout = a.requestTimeout
+		pool.Logger = a.log
+		pool.SetRateLimit(a.rps)
+		l1 := &fetch.Scanner{
+			RPC:       pool,
+			Retry:     fetch.RetryPolicy{MaxAttempts: a.maxAttempts, BaseDelay: a.retryDelay, MaxDelay: a.retryMaxDelay},
+			BatchSize: a.batchSize,
+			Logger:    a.log,
+		}
+		l1ctx, stopL1 := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		a.received, err = feeWithdrawals(l1ctx, l1, a.chain, common.HexToAddress(a.feeRecipient), a.granularity, a.location, a.report.Dates, a.log)
+		stopL1()
+		pool.Close()
+		if err != nil {
+			return fmt.Errorf("-fee-recipient: %w", err)
+		}
+		a.extra = append(a.extra, withdrawalColumns(a.report.Results, a.received)...)
+	}
+	if a.blobMarket || a.timing {
+		pool, err := fetch.Dial(a.rpcURLs)
+		if err != nil {
+			return err
+		}
+		pool.Timeout = a.requestTimeout
+		pool.Logger = a.log
+		pool.SetRateLimit(a.rps)
+		l1 := &fetch.Scanner{
+			RPC:         pool,
+			Retry:       fetch.RetryPolicy{MaxAttempts: a.maxAttempts, BaseDelay: a.retryDelay, MaxDelay: a.retryMaxDelay},
+			Concurrency: a.concurrency,
+			BatchSize:   a.batchSize,
+			Logger:      a.log,
+		}
+		mctx, stopMarket := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		a.markets, err = blobMarkets(mctx, l1, a.granularity, a.location, a.report.Dates, a.log)
+		stopMarket()
+		pool.Close()
+		if err != nil {
+			if !a.blobMarket {
+				return fmt.Errorf("-timing: %w", err)
+			}
+			return fmt.Errorf("-blob-market: %w", err)
+		}
+		if a.blobMarket {
+			a.extra = append(a.extra, blobMarketColumns(a.report.Results, a.markets)...)
+		}
+		if a.timing {
+			a.extra = append(a.extra, timingColumns(a.report.Results, a.markets)...)
+		}
+	}
+	if a.benchmarks != "" {
+		if a.benched, err = parseBenchmarks(a.benchmarks, a.report.Network); err != nil {
+			return fmt.Errorf("-benchmark: %w", err)
+		}
+		bctx, stopBench := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		for _, b := range a.benched {
+			a.log.Info("scanning benchmark", "chain", b.Name, "inbox", b.Inbox)
+			if err = b.run(bctx, cfg, a.report.Dates); err != nil {
+				err = fmt.Errorf("%s: %w", b.Name, err)
+				break
+			}
+		}
+		stopBench()
+		if err != nil {
+			return fmt.Errorf("-benchmark: %w", err)
+		}
+		a.extra = append(a.extra, benchmarkColumns(a.report.Results, a.benched)...)
+	}
+	a.fiatPrices = make(map[string]map[string]float64, len(a.currencies))
+	if len(a.currencies) > 0 || a.ton {
+		if a.priceCache == "" {
+			a.priceCache = filepath.Join(a.outDir, "prices.json")
+		}
+		prices := &fetch.PriceClient{
+			BaseURL:   a.priceAPI,
+			APIKey:    a.priceAPIKey,
+			CachePath: a.priceCache,
+			Retry:     fetch.RetryPolicy{MaxAttempts: a.maxAttempts, BaseDelay: a.retryDelay, MaxDelay: a.retryMaxDelay},
+			HTTP:      &http.Client{Timeout: a.requestTimeout},
+		}
+		pctx, stopPrices := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		for _, currency := range a.currencies {
+			a.fiatPrices[currency], err = bucketPrices(pctx, prices, "ethereum", currency, a.granularity, a.location, a.report.Dates)
+			if err != nil {
+				break
+			}
+			a.extra = append(a.extra, fiatColumn(currency, a.report.Results, a.fiatPrices[currency]))
+		}
+		if err == nil && a.ton {
+			a.tonPrices, err = tonPerEth(pctx, prices, a.tonCoin, a.granularity, a.location, a.report.Dates)
+			if err == nil {
+				a.extra = append(a.extra, fiatColumn("ton", a.report.Results, a.tonPrices))
+			}
+		}
+		stopPrices()
+		if err != nil {
+			return fmt.Errorf("-price-api: %w", err)
+		}
+	}
+	if a.whatIf {
+		a.extra = append(a.extra, whatIfColumns(a.report.Results)...)
+	}
+	if a.overpayment {
+		a.extra = append(a.extra, overpaymentColumns(a.report.Results)...)
+	}
+	if a.calldataFloor {
+		a.extra = append(a.extra, calldataFloorColumns(a.report.Results)...)
+	}
+	if a.blobSchedules != nil {
+		a.extra = append(a.extra, blobScheduleColumns(a.report.Results, a.blobSchedules)...)
+	}
+	if a.tipMarket {
+		a.extra = append(a.extra, tipMarketColumns(a.report.Results, a.tipMarketThreshold)...)
+	}
+	if a.layers != nil || a.compression || a.efficiency {
+		a.extra = append(a.extra, postedColumn(a.report.Results))
+	}
+	if a.compression {
+		a.extra = append(a.extra, compressionColumns(a.report.Results)...)
+	}
+	if a.efficiency {
+		a.extra = append(a.extra, efficiencyColumns(a.report.Results)...)
+	}
+	if a.layers != nil {
+		a.extra = append(a.extra, altDAColumns(a.layers, a.report.Results)...)
+	}
+	if a.scalars {
+		a.extra = append(a.extra, scalarColumns(a.report.Results)...)
+	}
+	if a.granularity == "day" {
+		a.extra = append(a.extra, trendColumns(a.report.Dates, a.report.Results, a.eras)...)
+	}
+	if a.monthly != nil {
+		a.extra = append(a.extra, budgetColumns(*a.monthly, a.monthly.Track(a.report.Dates, a.report.Results))...)
+	}
+	if a.detect {
+		a.anomalies = a.detector.Detect(a.report.Dates, a.report.Results)
+		a.extra = append(a.extra, anomalyColumn(a.anomalies, a.log))
+	}
+	if len(a.customColumns) > 0 {
+		custom, err := customColumnValues(a.customColumns, a.report.Dates, a.report.Results, a.extra)
+		if err != nil {
+			return err
+		}
+		a.extra = append(a.extra, custom...)
+	}
+	return nil
+}
+
+// printSummaries prints the summary of the report and those of the options
+// to w, or the markdown report to stdout.
+func (a *analysis) printSummaries(w io.Writer) error {
+	if a.format == "markdown" && a.outPath != "-" {
+		if err := output.PrintMarkdown(a.stdout, a.granularity, a.report.Dates, a.report.Results, a.report.Total, a.extra...); err != nil {
+			return err
+		}
+	} else if a.format != "markdown" {
+		output.PrintSummary(w, a.report.Dates, a.report.Results, a.report.Total)
+	}
+	if a.activity != nil {
+		printL2Summary(w, a.report.Total, a.activity)
+	}
+	if a.vaults != nil {
+		printRevenueSummary(w, a.report.Total, a.vaults)
+	}
+	if a.feeRecipient != "" {
+		printWithdrawalSummary(w, a.report.Total, a.received)
+	}
+	for _, currency := range a.currencies {
+		printFiatSummary(w, currency, a.report.Results, a.report.Total, a.fiatPrices[currency])
+	}
+	if a.ton {
+		printFiatSummary(w, "ton", a.report.Results, a.report.Total, a.tonPrices)
+	}
+	if a.bySender {
+		printSenderSummary(w, senderLabels(a.chain, a.givenLabels), a.report.Total)
+	}
+	if a.whatIf {
+		printWhatIfSummary(w, a.report.Total)
+	}
+	if a.overpayment {
+		printOverpaymentSummary(w, a.report.Total)
+	}
+	if a.calldataFloor {
+		printCalldataFloorSummary(w, a.report.Total)
+	}
+	if a.blobSchedules != nil {
+		printBlobScheduleSummary(w, a.report.Total, a.blobSchedules)
+	}
+	if a.eras != nil {
+		printEraSummary(w, a.report.Dates, a.report.Results, a.eras)
+	}
+	if a.blobMarket {
+		printBlobMarketSummary(w, a.report.Dates, a.report.Results, a.markets)
+	}
+	if a.timing {
+		printTimingSummary(w, a.report.Dates, a.report.Results, a.markets)
+	}
+	if a.tipMarket {
+		printTipMarketSummary(w, a.report.Dates, a.report.Results, a.report.Total, a.tipMarketThreshold)
+	}
+	if a.inclusion {
+		printInclusionSummary(w, a.report.Total)
+	}
+	if a.heat != nil {
+		printHeatmapSummary(w, a.heat)
+	}
+	if a.nonces != nil {
+		printNonceSummary(w, a.nonceReport)
+	}
+	if a.channels {
+		printChannelSummary(w, a.report.Channels, a.epochs)
+	}
+	if a.compression {
+		printCompressionSummary(w, a.report.Total)
+	}
+	if a.efficiency {
+		printEfficiencySummary(w, a.report.Total)
+	}
+	if a.layers != nil {
+		printAltDASummary(w, a.layers, a.report.Total)
+	}
+	if a.scalars {
+		printScalarSummary(w, a.report.Total)
+	}
+	if a.benched != nil {
+		printBenchmarkSummary(w, a.report.Total, a.benched)
+	}
+	if a.deposits != nil {
+		printRoleSummary(w, "Deposits", aggregate.DepositRole, a.report.Total)
+	}
+	if a.disputes {
+		printRoleSummary(w, "Disputes", aggregate.DisputeRole, a.report.Total)
+	}
+	return nil
+}
+
+// writeReport writes the report in -format to target, with its metadata.
+func (a *analysis) writeReport(target string) error {
+	var err error
+	meta := output.Metadata{
+		SchemaVersion: output.SchemaVersion,
+		ToolVersion:   toolVersion(),
+		GeneratedAt:   time.Now().UTC().Truncate(time.Second),
+		Name:          a.report.Name,
+		Format:        a.format,
+		Granularity:   a.granularity,
+		ChainID:       a.report.ChainID,
+		Network:       a.report.Network,
+		RPC:           endpointHosts(a.rpcURLs),
+		Args:          flagArgs(a.fs),
+		Transactions:  a.report.Rows,
+		Failed:        len(a.report.Failures),
+		FromIndexer:   int(a.report.IndexerResolved),
+		Interrupted:   a.report.Interrupted,
+	}
+	if !a.scan && len(a.senders) == 0 && a.duneQuery == 0 {
+		if meta.Inputs, err = inputFiles(a.inputSpec); err != nil {
+			return fmt.Errorf("metadata: %w", err)
+		}
+	}
+	switch a.format {
+	case "json":
+		err = output.WriteJSON(target, &meta, a.granularity, a.report.Dates, a.report.Results, a.report.Total)
+	case "jsonl":
+		if !a.perTx {
+			err = writeJSONL(target, a.report)
+		}
+	case "parquet":
+		err = output.WriteParquet(target, a.report.Dates, a.report.Results)
+	case "xlsx":
+		err = output.WriteXLSX(target, a.granularity, a.report.Dates, a.report.Results, a.report.Total)
+	case "markdown":
+		err = output.WriteMarkdown(target, a.granularity, a.report.Dates, a.report.Results, a.report.Total, a.extra...)
+	case "html":
+		err = output.WriteHTML(target, "L1 costs of "+a.report.Name, a.granularity, a.report.Dates, a.report.Results, a.report.Total)
+	default:
+		err = output.WriteCSVFormat(target, a.csvFormat, a.report.Dates, a.report.Results, a.report.Total, a.extra...)
+	}
+	if err != nil {
+		return err
+	}
+	if a.outPath == "-" {
+		if err := copyToStdout(a.stdout, target); err != nil {
+			return err
+		}
+	} else {
+		a.artifacts = append(a.artifacts, a.outPath)
+		// The JSON report carries its metadata; the others have it next to
+		// them.
+		if a.format != "json" {
+			metaPath := strings.TrimSuffix(a.outPath, filepath.Ext(a.outPath)) + ".meta.json"
+			if err := output.WriteMetadata(metaPath, meta); err != nil {
+				return err
+			}
+			a.artifacts = append(a.artifacts, metaPath)
+		}
+	}
+	return nil
+}
+
+// deliver sends the report of a complete run to the webhook, email, alerts,
+// sheet and metrics destinations of the options, and records its runway.
+func (a *analysis) deliver(mailer *email.Mailer, summary, target string, rw *runway, today string) error {
+	if a.webhookURL != "" {
+		if err := postReport(a.webhookURL, a.webhookSecret, a.granularity, a.report, a.log); err != nil {
+			return fmt.Errorf("-webhook: %w", err)
+		}
+	}
+	if mailer != nil {
+		if err := mailReport(mailer, a.granularity, a.report, summary, target, a.log); err != nil {
+			return fmt.Errorf("-email-to: %w", err)
+		}
+	}
+	// Alert thresholds are daily, so weekly reports are not checked.
+	if a.notifier != nil && a.granularity == "day" {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		err := a.notifier.Check(ctx, a.report.Name, a.report.Dates, a.report.Results)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("alerts: %w", err)
+		}
+	}
+	if a.anomalyAlerts && len(a.report.Dates) > 0 {
+		if err := alertAnomalies(a.notifier, a.report, a.anomalies, a.log); err != nil {
+			return fmt.Errorf("-anomaly-alerts: %w", err)
+		}
+	}
+	if a.monthly != nil && len(a.report.Dates) > 0 {
+		if err := checkBudget(*a.monthly, a.notifier, a.report, filepath.Join(a.outDir, "budget-alerts.json"), a.log); err != nil {
+			return fmt.Errorf("-monthly-budget: %w", err)
+		}
+	}
+	if rw != nil {
+		path := filepath.Join(a.outDir, "runway.csv")
+		if err := appendRunway(path, *rw); err != nil {
+			return fmt.Errorf("-runway: %w", err)
+		}
+		a.log.Info("runway recorded", "path", path, "runwayDays", rw.runwayText())
+		if a.runwayAlert > 0 {
+			if err := checkRunway(a.notifier, a.report.Name, *rw, a.runwayAlert, today, filepath.Join(a.outDir, "runway-alerts.json"), a.log); err != nil {
+				return fmt.Errorf("-runway-alert: %w", err)
+			}
+		}
+	}
+	if a.sheetID != "" {
+		if err := updateSheet(a.sheetCredentials, a.sheetID, a.sheetName, a.report, a.log); err != nil {
+			return fmt.Errorf("-sheet-id: %w", err)
+		}
+	}
+	if a.pushGatewayURL != "" || a.remoteWriteURL != "" {
+		first, _, _ := strings.Cut(a.address, ",")
+		labels := reportLabels{chain: strings.TrimSuffix(a.report.Name, ".csv"), batcher: strings.ToLower(strings.TrimSpace(first))}
+		if a.pushGatewayURL != "" {
+			if err := pushGateway(a.pushGatewayURL, labels, a.location, a.report, a.log); err != nil {
+				return fmt.Errorf("-push-gateway: %w", err)
+			}
+		}
+		if a.remoteWriteURL != "" {
+			if err := remoteWrite(a.remoteWriteURL, labels, a.location, a.report, a.log); err != nil {
+				return fmt.Errorf("-remote-write: %w", err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/alert"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/anomaly"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/tracker"
+)
+
+// anomalyColumn returns the report column listing the findings of each day,
+// and logs them.
+func anomalyColumn(findings map[string][]anomaly.Finding, log *slog.Logger) output.Column {
+	c := output.Column{Header: "Anomalies", Values: make(map[string]string, len(findings))}
+	days := make([]string, 0, len(findings))
+	for day, fs := range findings {
+		c.Values[day] = joinFindings(fs)
+		days = append(days, day)
+	}
+	slices.Sort(days)
+	for _, day := range days {
+		log.Warn("anomaly", "day", day, "findings", c.Values[day])
+	}
+	return c
+}
+
+func joinFindings(findings []anomaly.Finding) string {
+	s := make([]string, len(findings))
+	for i, f := range findings {
+		s[i] = f.String()
+	}
+	return strings.Join(s, "; ")
+}
+
+// alertAnomalies announces the findings of the last day of report. Earlier
+// days were announced by the runs that ended with them.
+func alertAnomalies(notifier *alert.Notifier, report tracker.Report, findings map[string][]anomaly.Finding, log *slog.Logger) error {
+	day := report.Dates[len(report.Dates)-1]
+	if len(findings[day]) == 0 {
+		return nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "L1 costs of %s look anomalous on %s:\n", report.Name, day)
+	for _, f := range findings[day] {
+		fmt.Fprintf(&b, "- %s\n", f)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	if err := notifier.Send(ctx, b.String()); err != nil {
+		return err
+	}
+	log.Info("anomaly alert sent", "day", day)
+	return nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/sink"
+)
+
+// openStore opens the database given to serve -db: a Postgres URL, or else
+// the path of a SQLite file.
+func openStore(db string) (*sink.Store, error) {
+	if strings.HasPrefix(db, "postgres://") || strings.HasPrefix(db, "postgresql://") {
+		return sink.OpenStore("postgres", db)
+	}
+	return sink.OpenStore("sqlite", db)
+}
+
+// apiHandler serves the data of a sink database as JSON under /api/v1/.
+// Days and the summary are objects of the JSON Lines report and transactions
+// those of -per-tx.
+func apiHandler(store *sink.Store) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/daily", func(w http.ResponseWriter, r *http.Request) {
+		dates, results, err := store.Days(r.Context(), r.URL.Query().Get("from"), r.URL.Query().Get("to"))
+		if err != nil {
+			apiError(w, err)
+			return
+		}
+		days := make([]json.RawMessage, 0, len(dates))
+		for _, k := range dates {
+			day, err := output.EncodeBucket(k, results[k])
+			if err != nil {
+				apiError(w, err)
+				return
+			}
+			days = append(days, day)
+		}
+		writeJSON(w, map[string]any{"days": days})
+	})
+	mux.HandleFunc("/api/v1/summary", func(w http.ResponseWriter, r *http.Request) {
+		dates, results, err := store.Days(r.Context(), r.URL.Query().Get("from"), r.URL.Query().Get("to"))
+		if err != nil {
+			apiError(w, err)
+			return
+		}
+		period, total := summarize(dates, results)
+		summary, err := output.EncodeBucket(period, total)
+		if err != nil {
+			apiError(w, err)
+			return
+		}
+		writeJSON(w, json.RawMessage(summary))
+	})
+	mux.HandleFunc("/api/v1/tx/", func(w http.ResponseWriter, r *http.Request) {
+		hash, ok := parseTxHash(strings.TrimPrefix(r.URL.Path, "/api/v1/tx/"))
+		if !ok {
+			http.Error(w, `{"error":"invalid transaction hash"}`, http.StatusBadRequest)
+			return
+		}
+		tx, err := store.Tx(r.Context(), hash)
+		if err != nil {
+			apiError(w, err)
+			return
+		}
+		data, err := output.EncodeTx(tx)
+		if err != nil {
+			apiError(w, err)
+			return
+		}
+		writeJSON(w, json.RawMessage(data))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// summarize combines the days of a summary into one bucket, keyed by the
+// interval of the days it covers, e.g. 2024-07-01/2024-07-31.
+func summarize(dates []string, results map[string]*aggregate.Result) (string, *aggregate.Result) {
+	if len(dates) == 0 {
+		return "", aggregate.NewResult()
+	}
+	return dates[0] + "/" + dates[len(dates)-1], aggregate.Total(results)
+}
+
+// parseTxHash parses a 0x-prefixed transaction hash.
+func parseTxHash(s string) (common.Hash, bool) {
+	if len(s) != 2+2*common.HashLength || !strings.HasPrefix(s, "0x") {
+		return common.Hash{}, false
+	}
+	b, err := hexutil.Decode(s)
+	if err != nil {
+		return common.Hash{}, false
+	}
+	return common.BytesToHash(b), true
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("writing response", "err", err)
+	}
+}
+
+// apiError reports err as a JSON object, as a 404 for an unknown transaction
+// and a 400 for an invalid day.
+func apiError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, sink.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, sink.ErrInvalidDay):
+		status = http.StatusBadRequest
+	}
+	if status == http.StatusInternalServerError {
+		slog.Error("api request failed", "err", err)
+	}
+	w.WriteHeader(status)
+	writeJSON(w, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// backfillProgress is the progress file of backfill: the UTC days whose
+// chunks were scanned and counted into the -append state.
+type backfillProgress struct {
+	From    string `json:"from"`
+	Through string `json:"through"`
+}
+
+// runBackfill runs the backfill command: it scans the UTC days -from to -to
+// (default: yesterday) chunkDays at a time, each chunk a scan -append run
+// adding to the same report, and records the days done in a .backfill file
+// next to the report. A later run skips those days, so that an interrupted
+// or failed backfill is continued by running it again. The days of a chunk
+// reaching today are not recorded, as they are not over yet. The chunks log
+// and print to con.
+func runBackfill(fs *flag.FlagSet, args []string, outDir, outFile string, chunkDays int, con console) error {
+	for _, name := range []string{"from-block", "to-block", "from-date", "to-date", "resume"} {
+		if isSet(fs, name) {
+			return fmt.Errorf("-%s cannot be combined with backfill, which scans -from to -to and continues from its progress file", name)
+		}
+	}
+	if chunkDays < 1 {
+		return errors.New("-chunk-days must be at least 1")
+	}
+	address, toAddress := fs.Lookup("address").Value.String(), fs.Lookup("to-address").Value.String()
+	if address == "" && toAddress == "" {
+		return errors.New("backfill needs -address or -to-address")
+	}
+	fromValue := fs.Lookup("from").Value.String()
+	if fromValue == "" {
+		return errors.New("backfill needs -from")
+	}
+	from, err := time.Parse(time.DateOnly, fromValue)
+	if err != nil {
+		return fmt.Errorf("-from: %w", err)
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	to := today.AddDate(0, 0, -1)
+	if v := fs.Lookup("to").Value.String(); v != "" {
+		if to, err = time.Parse(time.DateOnly, v); err != nil {
+			return fmt.Errorf("-to: %w", err)
+		}
+	}
+	if to.Before(from) {
+		return errors.New("-to is before -from")
+	}
+
+	name := fs.Lookup("name").Value.String()
+	if name == "" {
+		first, _, _ := strings.Cut(address, ",")
+		if first == "" {
+			first, _, _ = strings.Cut(toAddress, ",")
+		}
+		name = "backfill-" + strings.ToLower(strings.TrimSpace(first))
+	}
+	progressPath := reportBase(outDir, outFile, name) + ".backfill"
+	progress, err := loadBackfillProgress(progressPath)
+	if err != nil {
+		return err
+	}
+	var doneFrom, doneThrough time.Time
+	if progress.From != "" {
+		if doneFrom, err = time.Parse(time.DateOnly, progress.From); err != nil {
+			return fmt.Errorf("%s: %w", progressPath, err)
+		}
+		if doneThrough, err = time.Parse(time.DateOnly, progress.Through); err != nil {
+			return fmt.Errorf("%s: %w", progressPath, err)
+		}
+		con.log.Info("continuing backfill", "progress", progressPath, "done", progress.From+" to "+progress.Through)
+	}
+	done := func(day time.Time) bool {
+		return progress.From != "" && !day.Before(doneFrom) && !day.After(doneThrough)
+	}
+
+	if fs.Lookup("dry-run").Value.String() == "true" {
+		// A dry run estimates the days left as one scan, which may
+		// include days done after a gap.
+		start := from
+		if done(start) {
+			start = doneThrough.AddDate(0, 0, 1)
+		}
+		if start.After(to) {
+			fmt.Fprintf(con.stdout, "Backfill %s: the days %s to %s are done\n", name, from.Format(time.DateOnly), to.Format(time.DateOnly))
+			return nil
+		}
+		return runAnalyze("scan", append(args[:len(args):len(args)],
+			"-from-date="+start.Format(time.DateOnly), "-to-date="+to.Format(time.DateOnly),
+			"-from=", "-to=", "-name="+name), con, nil)
+	}
+	for start := from; !start.After(to); {
+		if done(start) {
+			start = doneThrough.AddDate(0, 0, 1)
+			continue
+		}
+		end := start.AddDate(0, 0, chunkDays-1)
+		if end.After(to) {
+			end = to
+		}
+		// Stop short of the days already done.
+		if progress.From != "" && start.Before(doneFrom) && !end.Before(doneFrom) {
+			end = doneFrom.AddDate(0, 0, -1)
+		}
+		con.log.Info("backfilling", "name", name, "from", start.Format(time.DateOnly), "to", end.Format(time.DateOnly))
+		// The flags given last take precedence. -from and -to are cleared,
+		// the report covering every chunk so far.
+		chunkArgs := append(args[:len(args):len(args)],
+			"-from-date="+start.Format(time.DateOnly), "-to-date="+end.Format(time.DateOnly),
+			"-from=", "-to=", "-append", "-name="+name)
+		if err := runAnalyze("scan", chunkArgs, con, nil); err != nil {
+			return fmt.Errorf("backfill %s to %s: %w; run it again to continue", start.Format(time.DateOnly), end.Format(time.DateOnly), err)
+		}
+		// The days from -from to end are now counted, those of this run
+		// and those done before. They are recorded when they join the days
+		// done; until then, as after a gap, a new run scans them again, the
+		// state skipping the transactions already counted.
+		joined := progress.From == "" ||
+			!from.After(doneThrough.AddDate(0, 0, 1)) && !end.Before(doneFrom.AddDate(0, 0, -1))
+		if end.Before(today) && joined {
+			if progress.From == "" || from.Before(doneFrom) {
+				doneFrom = from
+			}
+			if progress.From == "" || end.After(doneThrough) {
+				doneThrough = end
+			}
+			progress = backfillProgress{From: doneFrom.Format(time.DateOnly), Through: doneThrough.Format(time.DateOnly)}
+			if err := saveBackfillProgress(progressPath, progress); err != nil {
+				return err
+			}
+		}
+		start = end.AddDate(0, 0, 1)
+	}
+	con.log.Info("backfill complete", "name", name, "from", from.Format(time.DateOnly), "to", to.Format(time.DateOnly), "progress", progressPath)
+	return nil
+}
+
+// isSet reports whether the flag name of fs was set, on the command line or
+// by the -config file.
+func isSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) { set = set || f.Name == name })
+	return set
+}
+
+// loadBackfillProgress reads the progress file at path, empty if missing.
+func loadBackfillProgress(path string) (backfillProgress, error) {
+	var progress backfillProgress
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return progress, nil
+	}
+	if err != nil {
+		return progress, err
+	}
+	if err := json.Unmarshal(data, &progress); err != nil {
+		return progress, fmt.Errorf("%s: %w", path, err)
+	}
+	if (progress.From == "") != (progress.Through == "") {
+		return progress, fmt.Errorf("%s: incomplete progress", path)
+	}
+	return progress, nil
+}
+
+// saveBackfillProgress writes progress to path, replacing the file only once
+// written so that an interruption leaves the previous progress.
+func saveBackfillProgress(path string, progress backfillProgress) error {
+	data, err := json.MarshalIndent(progress, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
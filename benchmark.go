@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/tracker"
+)
+
+// benchmarkInboxes are the batch inboxes of public OP Stack chains by L1
+// network. Their addresses follow the OP Stack convention of 0xff00…
+// followed by the L2 chain ID.
+var benchmarkInboxes = map[string]map[string]common.Address{
+	"mainnet": {
+		"optimism": common.HexToAddress("0xff00000000000000000000000000000000000010"),
+		"base":     common.HexToAddress("0xff00000000000000000000000000000000008453"),
+	},
+	"sepolia": {
+		"optimism": common.HexToAddress("0xff00000000000000000000000000000011155420"),
+		"base":     common.HexToAddress("0xff00000000000000000000000000000000084532"),
+	},
+}
+
+// benchmark is a chain whose batcher costs are compared with ours.
+type benchmark struct {
+	Name  string
+	Inbox common.Address
+
+	Results map[string]*aggregate.Result
+	Total   *aggregate.Result
+}
+
+// parseBenchmarks parses a comma-separated list of chains, either known
+// names such as optimism or name=inbox pairs, on the L1 network.
+func parseBenchmarks(list, network string) ([]*benchmark, error) {
+	var benchmarks []*benchmark
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, inbox, custom := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		if custom {
+			inbox = strings.TrimSpace(inbox)
+			if !common.IsHexAddress(inbox) {
+				return nil, fmt.Errorf("%s: invalid inbox address %q", name, inbox)
+			}
+			benchmarks = append(benchmarks, &benchmark{Name: name, Inbox: common.HexToAddress(inbox)})
+			continue
+		}
+		address, ok := benchmarkInboxes[network][strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("no known batch inbox of %s on %s; give it as %s=<inbox address>", name, network, name)
+		}
+		benchmarks = append(benchmarks, &benchmark{Name: name, Inbox: address})
+	}
+	return benchmarks, nil
+}
+
+// run scans the transactions to the inbox of b over the buckets of dates,
+// with the connection settings of cfg, and aggregates them into the same
+// buckets.
+func (b *benchmark) run(ctx context.Context, cfg tracker.Config, dates []string) error {
+	if len(dates) == 0 {
+		return nil
+	}
+	from, _, err := bucketRange(dates[0], cfg.Granularity, cfg.Location)
+	if err != nil {
+		return err
+	}
+	_, to, err := bucketRange(dates[len(dates)-1], cfg.Granularity, cfg.Location)
+	if err != nil {
+		return err
+	}
+	report, err := tracker.Run(ctx, tracker.Config{
+		RPC:              cfg.RPC,
+		Scan:             true,
+		Recipients:       []common.Address{b.Inbox},
+		FromDate:         from.UTC().Format(time.DateOnly),
+		ToDate:           to.Add(-time.Nanosecond).UTC().Format(time.DateOnly),
+		Granularity:      cfg.Granularity,
+		Location:         cfg.Location,
+		From:             from,
+		To:               to,
+		Concurrency:      cfg.Concurrency,
+		BatchSize:        cfg.BatchSize,
+		BlockReceiptsMin: cfg.BlockReceiptsMin,
+		Retry:            cfg.Retry,
+		RequestTimeout:   cfg.RequestTimeout,
+		CachePath:        cfg.CachePath,
+		Beacon:           cfg.Beacon,
+		DataSizes:        true,
+	})
+	if err != nil {
+		return err
+	}
+	if report.WasInterrupted() {
+		return errors.New("interrupted")
+	}
+	b.Results, b.Total = report.Results, report.Total
+	return nil
+}
+
+// relativeCost returns our cost per byte as a percentage of theirs, and false
+// when either posted nothing.
+func relativeCost(ours, theirs *aggregate.Result) (*big.Float, bool) {
+	if ours == nil || theirs == nil {
+		return nil, false
+	}
+	a, ok := costPerByte(ours)
+	if !ok {
+		return nil, false
+	}
+	b, ok := costPerByte(theirs)
+	if !ok || b.Sign() == 0 {
+		return nil, false
+	}
+	ratio := new(big.Float).Quo(a, b)
+	return ratio.Mul(ratio, big.NewFloat(100)), true
+}
+
+// relativeText formats the percentage v of the costs of ours and theirs,
+// unavailable when either misses a blob gas price.
+func relativeText(ours, theirs *aggregate.Result, v *big.Float) string {
+	if text := ours.BlobDependent(v); text == aggregate.Unavailable {
+		return text
+	}
+	return theirs.BlobDependent(v)
+}
+
+// benchmarkColumns returns the cost per byte of every benchmark in every
+// bucket and ours as a percentage of it.
+func benchmarkColumns(results map[string]*aggregate.Result, benchmarks []*benchmark) []output.Column {
+	var columns []output.Column
+	for _, b := range benchmarks {
+		theirs := output.Column{Header: b.Name + " Cost per Byte(wei)", Values: make(map[string]string, len(results))}
+		relative := output.Column{Header: "Cost per Byte vs " + b.Name + "(%)", Values: make(map[string]string, len(results))}
+		for k, r := range results {
+			t := b.Results[k]
+			if t == nil {
+				continue
+			}
+			if v, ok := costPerByte(t); ok {
+				theirs.Values[k] = t.BlobDependent(v)
+			}
+			if v, ok := relativeCost(r, t); ok {
+				relative.Values[k] = relativeText(r, t, v)
+			}
+		}
+		columns = append(columns, theirs, relative)
+	}
+	return columns
+}
+
+// printBenchmarkSummary prints our cost per byte over the whole report next
+// to that of every benchmark.
+func printBenchmarkSummary(w io.Writer, total *aggregate.Result, benchmarks []*benchmark) {
+	ours, ok := costPerByte(total)
+	if !ok {
+		fmt.Fprintln(w, "Benchmark: nothing posted")
+		return
+	}
+	fmt.Fprintf(w, "Benchmark: %s wei per byte", total.BlobDependent(ours))
+	for _, b := range benchmarks {
+		relative, ok := relativeCost(total, b.Total)
+		if !ok {
+			fmt.Fprintf(w, ", %s posted nothing", b.Name)
+			continue
+		}
+		theirs, _ := costPerByte(b.Total)
+		fmt.Fprintf(w, ", %s%% of %s (%s wei)", relativeText(total, b.Total, relative), b.Name, b.Total.BlobDependent(theirs))
+	}
+	fmt.Fprintln(w)
+}
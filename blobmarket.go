@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/fetch"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+)
+
+// blobMarkets reads the blob base fee of the network that the scanner s of
+// an L1 RPC finds over the time of every bucket of dates, in the zone loc.
+func blobMarkets(ctx context.Context, s *fetch.Scanner, granularity string, loc *time.Location, dates []string, log *slog.Logger) (map[string]fetch.BlobMarket, error) {
+	markets := make(map[string]fetch.BlobMarket, len(dates))
+	for _, k := range dates {
+		start, end, err := bucketRange(k, granularity, loc)
+		if err != nil {
+			return nil, err
+		}
+		if markets[k], err = s.BlobMarket(ctx, start, end); err != nil {
+			return nil, fmt.Errorf("bucket %s: %w", k, err)
+		}
+		log.Debug("read blob market", "bucket", k, "blocks", markets[k].Blocks)
+	}
+	return markets, nil
+}
+
+// blobMarketColumns returns the blob base fee of the network at the start and
+// end of every bucket, its lowest, highest and average, and the average blob
+// gas price we paid relative to that average. Buckets without post-Cancun
+// blocks are left empty.
+func blobMarketColumns(results map[string]*aggregate.Result, markets map[string]fetch.BlobMarket) []output.Column {
+	start := output.Column{Header: "Blob Base Fee Start(Gwei)", Values: make(map[string]string, len(results))}
+	end := output.Column{Header: "Blob Base Fee End(Gwei)", Values: make(map[string]string, len(results))}
+	low := output.Column{Header: "Blob Base Fee Min(Gwei)", Values: make(map[string]string, len(results))}
+	high := output.Column{Header: "Blob Base Fee Max(Gwei)", Values: make(map[string]string, len(results))}
+	avg := output.Column{Header: "Blob Base Fee Avg(Gwei)", Values: make(map[string]string, len(results))}
+	paid := output.Column{Header: "Blob Price vs Market(%)", Values: make(map[string]string, len(results))}
+	for k, r := range results {
+		m, ok := markets[k]
+		if !ok || m.Blocks == 0 {
+			continue
+		}
+		start.Values[k], end.Values[k] = gweiString(m.Start), gweiString(m.End)
+		low.Values[k], high.Values[k], avg.Values[k] = gweiString(m.Min), gweiString(m.Max), gweiString(m.Avg())
+		if pct, ok := paidVsMarket(r, m); ok {
+			paid.Values[k] = strconv.FormatFloat(pct, 'f', 2, 64)
+		}
+	}
+	return []output.Column{start, end, low, high, avg, paid}
+}
+
+// paidVsMarket returns the average blob gas price of r as a percentage of the
+// average blob base fee of m, false without blob transactions or fee.
+func paidVsMarket(r *aggregate.Result, m fetch.BlobMarket) (float64, bool) {
+	if r.BlobTxCount == 0 || r.AvgBlobGasPrice == nil || m.Blocks == 0 || m.Sum.Sign() == 0 {
+		return 0, false
+	}
+	paid, _ := r.AvgBlobGasPrice.Float64()
+	market, _ := gwei(m.Avg()).Float64()
+	if market == 0 {
+		return 0, false
+	}
+	return 100 * paid / market, true
+}
+
+// printBlobMarketSummary prints how the blob base fee of the network moved
+// over the report, from the start of the first bucket to the end of the last,
+// next to the change of the average blob gas price we paid, so that a change
+// in cost can be told apart as market-driven or usage-driven.
+func printBlobMarketSummary(w io.Writer, dates []string, results map[string]*aggregate.Result, markets map[string]fetch.BlobMarket) {
+	var (
+		first, last *fetch.BlobMarket
+		low, high   *big.Int
+		firstK      string
+		lastK       string
+	)
+	for _, k := range dates {
+		m, ok := markets[k]
+		if !ok || m.Blocks == 0 {
+			continue
+		}
+		if first == nil {
+			first, firstK = &m, k
+		}
+		last, lastK = &m, k
+		if low == nil || m.Min.Cmp(low) < 0 {
+			low = m.Min
+		}
+		if high == nil || m.Max.Cmp(high) > 0 {
+			high = m.Max
+		}
+	}
+	if first == nil {
+		fmt.Fprintln(w, "Blob market: no post-Cancun block")
+		return
+	}
+	fmt.Fprintf(w, "Blob market: base fee %s Gwei at the start, %s Gwei at the end (low %s, high %s); average %s Gwei in %s, %s Gwei in %s",
+		gweiString(first.Start), gweiString(last.End), gweiString(low), gweiString(high),
+		gweiString(first.Avg()), firstK, gweiString(last.Avg()), lastK)
+	if firstK != lastK {
+		if change, ok := percentChange(gwei(first.Avg()), gwei(last.Avg())); ok {
+			fmt.Fprintf(w, " (%+.1f%%)", change)
+		}
+		from, to := results[firstK], results[lastK]
+		if from != nil && to != nil && from.BlobTxCount > 0 && to.BlobTxCount > 0 {
+			if change, ok := percentChange(from.AvgBlobGasPrice, to.AvgBlobGasPrice); ok {
+				fmt.Fprintf(w, "; our average blob gas price %+.1f%%", change)
+			}
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+// percentChange returns the change from a to b in percent, false when a is
+// zero.
+func percentChange(a, b *big.Float) (float64, bool) {
+	if a == nil || b == nil || a.Sign() == 0 {
+		return 0, false
+	}
+	change, _ := new(big.Float).Quo(new(big.Float).Sub(b, a), a).Float64()
+	return 100 * change, true
+}
+
+// gwei converts wei to Gwei.
+func gwei(wei *big.Int) *big.Float {
+	return new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e9))
+}
+
+// gweiString formats wei in Gwei.
+func gweiString(wei *big.Int) string {
+	return gwei(wei).Text('f', 9)
+}
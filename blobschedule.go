@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/fetch"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+)
+
+// parseBlobSchedules parses a comma-separated list of blob schedules, e.g.
+// 6/9,9/12.
+func parseBlobSchedules(list string) ([]fetch.BlobSchedule, error) {
+	var schedules []fetch.BlobSchedule
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(list, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		s, err := fetch.ParseBlobSchedule(item)
+		if err != nil {
+			return nil, err
+		}
+		if seen[s.Name] {
+			return nil, fmt.Errorf("blob schedule %s given twice", s.Name)
+		}
+		seen[s.Name] = true
+		schedules = append(schedules, s)
+	}
+	return schedules, nil
+}
+
+// blobScheduleColumns returns, for every bucket, the blob fees paid by its
+// replayed blob transactions and what they would have paid under every
+// schedule. Buckets without replayed transactions are left empty.
+func blobScheduleColumns(results map[string]*aggregate.Result, schedules []fetch.BlobSchedule) []output.Column {
+	actual := output.Column{Header: "Replayed Blob Cost(ETH)", Values: make(map[string]string, len(results))}
+	actualWei := output.Column{Header: "Replayed Blob Cost(wei)", Values: make(map[string]string, len(results))}
+	columns := make([]output.Column, len(schedules))
+	weiColumns := make([]output.Column, len(schedules))
+	for i, s := range schedules {
+		columns[i] = output.Column{Header: "Blob Cost at " + s.Name + "(ETH)", Values: make(map[string]string, len(results))}
+		weiColumns[i] = output.Column{Header: "Blob Cost at " + s.Name + "(wei)", Values: make(map[string]string, len(results))}
+	}
+	for k, r := range results {
+		if r.ScheduleTxCount == 0 {
+			continue
+		}
+		actual.Values[k], actualWei.Values[k] = r.ScheduleActualCost.String(), r.ScheduleActualCostWei.String()
+		for i, s := range schedules {
+			if cost := r.ScheduleBlobCostWei[s.Name]; cost != nil {
+				columns[i].Values[k], weiColumns[i].Values[k] = ether(cost).String(), cost.String()
+			}
+		}
+	}
+	columns = append([]output.Column{actual}, columns...)
+	return append(append(columns, actualWei), weiColumns...)
+}
+
+// printBlobScheduleSummary prints what the replayed blob transactions of the
+// whole report would have paid in blob fees under every schedule.
+func printBlobScheduleSummary(w io.Writer, total *aggregate.Result, schedules []fetch.BlobSchedule) {
+	if total.ScheduleTxCount == 0 {
+		fmt.Fprintln(w, "Blob schedules: no blob transaction replayed")
+		return
+	}
+	fmt.Fprintf(w, "Blob schedules: %d blob txs paid %s ETH in blob fees", total.ScheduleTxCount, total.ScheduleActualCost.String())
+	for _, s := range schedules {
+		cost := total.ScheduleBlobCost[s.Name]
+		if cost == nil {
+			continue
+		}
+		fmt.Fprintf(w, "; at %s (fraction %d) %s ETH", s.Name, s.Fraction, cost.String())
+		if actual := total.ScheduleActualCostWei; actual.Sign() > 0 {
+			change, _ := new(big.Rat).SetFrac(new(big.Int).Sub(total.ScheduleBlobCostWei[s.Name], actual), actual).Float64()
+			fmt.Fprintf(w, " (%+.1f%%)", 100*change)
+		}
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/alert"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/budget"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/tracker"
+)
+
+// budgetColumns returns the report columns of the month-to-date spends.
+func budgetColumns(b budget.Budget, spends map[string]budget.Spend) []output.Column {
+	cost := output.Column{Header: "Month-to-date Cost(ETH)", Values: make(map[string]string, len(spends))}
+	used := output.Column{Header: "Budget Used(%)", Values: make(map[string]string, len(spends))}
+	remaining := output.Column{Header: "Budget Remaining(" + b.Unit() + ")", Values: make(map[string]string, len(spends))}
+	for day, s := range spends {
+		cost.Values[day] = strconv.FormatFloat(s.Eth, 'g', 10, 64)
+		used.Values[day] = strconv.FormatFloat(100*s.Used, 'f', 2, 64)
+		remaining.Values[day] = strconv.FormatFloat(s.Remaining, 'g', 10, 64)
+	}
+	return []output.Column{cost, used, remaining}
+}
+
+// checkBudget announces through notifier the budget levels that the last
+// month of report reaches, unless the state at statePath says they were
+// announced already.
+func checkBudget(b budget.Budget, notifier *alert.Notifier, report tracker.Report, statePath string, log *slog.Logger) error {
+	day := report.Dates[len(report.Dates)-1]
+	spend := b.Track(report.Dates, report.Results)[day]
+	level := budget.Level(spend.Used)
+	log.Info("monthly budget", "month", spend.Month, "spentEth", spend.Eth, "used", fmt.Sprintf("%.1f%%", 100*spend.Used), "remaining", spend.Remaining, "unit", b.Unit())
+	if level == 0 || notifier == nil {
+		return nil
+	}
+	state, err := budget.LoadState(statePath)
+	if err != nil {
+		return err
+	}
+	if level <= state[spend.Month] {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	if err := notifier.Send(ctx, b.Message(report.Name, day, spend, level)); err != nil {
+		return fmt.Errorf("alerts: %w", err)
+	}
+	log.Info("budget alert sent", "month", spend.Month, "level", level)
+	state[spend.Month] = level
+	return state.Save(statePath)
+}
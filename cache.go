@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/fetch"
+)
+
+// runCache exports the receipt cache to a portable archive, or imports one,
+// so that reports can be regenerated with -offline where there is no RPC.
+func runCache(args []string) error {
+	if len(args) == 0 || (args[0] != "export" && args[0] != "import") {
+		fmt.Fprintf(os.Stderr, "Usage: %[1]s cache export [flags]\n       %[1]s cache import [flags] archive ...\n", os.Args[0])
+		if len(args) > 0 && args[0] != "-h" && args[0] != "-help" {
+			return fmt.Errorf("unknown cache command %q", args[0])
+		}
+		return flag.ErrHelp
+	}
+	cmd, args := args[0], args[1:]
+	fs := flag.NewFlagSet("cache "+cmd, flag.ContinueOnError)
+	cachePath := fs.String("cache", os.Getenv("RECEIPT_CACHE"), "path of the receipt cache (env RECEIPT_CACHE)")
+	outPath := fs.String("o", "receipts.jsonl.gz", "path of the exported archive, - for stdout")
+	fs.Usage = func() {
+		if cmd == "export" {
+			fmt.Fprintf(fs.Output(), "Usage: %s cache export [flags]\n\nFlags:\n", os.Args[0])
+		} else {
+			fmt.Fprintf(fs.Output(), "Usage: %s cache import [flags] archive ...\n\nAn archive of - is read from stdin.\n\nFlags:\n", os.Args[0])
+		}
+		fs.PrintDefaults()
+	}
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if *cachePath == "" {
+		return errors.New("-cache is required")
+	}
+
+	switch cmd {
+	case "export":
+		if _, err := os.Stat(*cachePath); err != nil {
+			return fmt.Errorf("-cache: %w", err)
+		}
+		return exportCache(*cachePath, *outPath)
+	default:
+		if fs.NArg() == 0 {
+			return errors.New("cache import takes at least one archive")
+		}
+		return importCache(*cachePath, fs.Args())
+	}
+}
+
+// exportCache writes the receipts of the cache at cachePath to outPath.
+func exportCache(cachePath, outPath string) error {
+	cache, err := fetch.OpenCache(cachePath)
+	if err != nil {
+		return err
+	}
+	defer cache.Close()
+
+	var w io.Writer = os.Stdout
+	var outFile *os.File
+	if outPath != "-" {
+		if outFile, err = os.Create(outPath); err != nil {
+			return err
+		}
+		defer outFile.Close()
+		w = outFile
+	}
+	n, err := cache.Export(w)
+	if err != nil {
+		return err
+	}
+	if outFile != nil {
+		if err := outFile.Close(); err != nil {
+			return err
+		}
+	}
+	chainID, _ := cache.ChainID()
+	slog.Info("cache exported", "receipts", n, "chainId", chainID, "output", outPath)
+	return nil
+}
+
+// importCache adds the receipts of the archives to the cache at cachePath,
+// creating it if needed.
+func importCache(cachePath string, archives []string) error {
+	cache, err := fetch.OpenCache(cachePath)
+	if err != nil {
+		return err
+	}
+	defer cache.Close()
+
+	for _, path := range archives {
+		var r io.Reader = os.Stdin
+		if path != "-" {
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			r = f
+		}
+		n, err := cache.Import(r)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		slog.Info("cache imported", "archive", path, "receipts", n, "cache", cachePath)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+)
+
+// calldataPricing returns the rule that priced the calldata of the
+// transactions of r: EIP-2028, EIP-7623 or both for a bucket spanning
+// Prague, or "" without floor gas.
+func calldataPricing(r *aggregate.Result) string {
+	switch r.FloorActiveTxCount {
+	case 0:
+		if r.FloorTxCount == 0 {
+			return ""
+		}
+		return "EIP-2028"
+	case r.FloorTxCount:
+		return "EIP-7623"
+	}
+	return "EIP-2028+EIP-7623"
+}
+
+// calldataFloorColumns returns, for every bucket, the rule that priced its
+// calldata, the number of transactions charged the EIP-7623 floor and what
+// the bucket would have cost had EIP-7623 applied to all of them.
+func calldataFloorColumns(results map[string]*aggregate.Result) []output.Column {
+	rule := output.Column{Header: "Calldata Pricing", Values: make(map[string]string, len(results))}
+	txs := output.Column{Header: "Floor-Priced Txs", Values: make(map[string]string, len(results))}
+	cost := output.Column{Header: "Cost Under EIP-7623(ETH)", Values: make(map[string]string, len(results))}
+	costWei := output.Column{Header: "Cost Under EIP-7623(wei)", Values: make(map[string]string, len(results))}
+	for k, r := range results {
+		if r.FloorTxCount == 0 {
+			continue
+		}
+		rule.Values[k] = calldataPricing(r)
+		txs.Values[k] = strconv.FormatUint(r.FloorPricedTxCount, 10)
+		floored := new(big.Int).Add(r.CostWei, r.FloorExtraCostWei)
+		cost.Values[k], costWei.Values[k] = r.BlobDependent(ether(floored)), r.BlobDependentWei(floored)
+	}
+	return []output.Column{rule, txs, cost, costWei}
+}
+
+// printCalldataFloorSummary prints how the calldata of the transactions was
+// priced over the whole report, and what EIP-7623 would have added to the
+// transactions before it.
+func printCalldataFloorSummary(w io.Writer, total *aggregate.Result) {
+	if total.FloorTxCount == 0 {
+		fmt.Fprintln(w, "Calldata pricing: no calldata transaction")
+		return
+	}
+	fmt.Fprintf(w, "Calldata pricing: %d of %d calldata txs under EIP-7623, %d of them charged the floor",
+		total.FloorActiveTxCount, total.FloorTxCount, total.FloorPricedTxCount)
+	if before := total.FloorTxCount - total.FloorActiveTxCount; before > 0 {
+		fmt.Fprintf(w, "; the floor would have added %s ETH to the %d txs before it, %s ETH in all",
+			total.FloorExtraCost.String(), before, total.BlobDependent(ether(new(big.Int).Add(total.CostWei, total.FloorExtraCostWei))))
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"slices"
+	"strconv"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/batch"
+)
+
+// epochCost is the L1 cost of the L2 blocks of an epoch, those whose L1
+// origin is the same L1 block.
+type epochCost struct {
+	Epoch    uint64
+	Blocks   uint64
+	Channels int
+	Cost     *big.Int
+}
+
+// epochCosts splits the cost of every channel between the epochs of its L2
+// blocks, by their number of blocks, and sums it per epoch in epoch order.
+// Channels without decoded blocks are left out.
+func epochCosts(channels []batch.Channel) []epochCost {
+	byEpoch := make(map[uint64]*epochCost)
+	for _, ch := range channels {
+		if ch.Blocks == 0 || len(ch.Epochs) == 0 {
+			continue
+		}
+		epochs := make([]uint64, 0, len(ch.Epochs))
+		for epoch := range ch.Epochs {
+			epochs = append(epochs, epoch)
+		}
+		slices.Sort(epochs)
+		left := new(big.Int).Set(ch.Cost)
+		for i, epoch := range epochs {
+			blocks := ch.Epochs[epoch]
+			// The last epoch takes the remainder of the division.
+			share := new(big.Int).Set(left)
+			if i < len(epochs)-1 {
+				share.Mul(ch.Cost, new(big.Int).SetUint64(blocks))
+				share.Quo(share, new(big.Int).SetUint64(ch.Blocks))
+			}
+			left.Sub(left, share)
+			e := byEpoch[epoch]
+			if e == nil {
+				e = &epochCost{Epoch: epoch, Cost: new(big.Int)}
+				byEpoch[epoch] = e
+			}
+			e.Blocks += blocks
+			e.Channels++
+			e.Cost.Add(e.Cost, share)
+		}
+	}
+	costs := make([]epochCost, 0, len(byEpoch))
+	for _, e := range byEpoch {
+		costs = append(costs, *e)
+	}
+	slices.SortFunc(costs, func(a, b epochCost) int {
+		return cmp.Compare(a.Epoch, b.Epoch)
+	})
+	return costs
+}
+
+// perBlock returns cost in ETH divided by blocks, empty without blocks.
+func perBlock(cost *big.Int, blocks uint64) string {
+	if blocks == 0 {
+		return ""
+	}
+	return new(big.Float).Quo(ether(cost), new(big.Float).SetUint64(blocks)).Text('f', 18)
+}
+
+// writeChannels writes one row per channel to path: the L1 blocks and
+// transactions that carried it, the epochs and L2 blocks of its batches and
+// its cost.
+func writeChannels(path string, channels []batch.Channel) error {
+	outFile, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+
+	writer := csv.NewWriter(outFile)
+	header := []string{"Channel", "First L1 Block", "Last L1 Block", "L1 Txs", "First Epoch", "Last Epoch", "Epochs", "L2 Blocks", "L2 Txs", "Channel Bytes", "Cost(wei)", "Cost(ETH)", "Cost per L2 Block(ETH)"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, ch := range channels {
+		record := []string{
+			hex.EncodeToString(ch.ID[:]),
+			strconv.FormatUint(ch.FirstBlock, 10),
+			strconv.FormatUint(ch.LastBlock, 10),
+			strconv.FormatUint(ch.Txs, 10),
+			"", "",
+			strconv.Itoa(len(ch.Epochs)),
+			strconv.FormatUint(ch.Blocks, 10),
+			strconv.FormatUint(ch.Counts.Txs, 10),
+			strconv.FormatUint(ch.ChannelBytes, 10),
+			ch.Cost.String(),
+			ether(ch.Cost).Text('f', 18),
+			perBlock(ch.Cost, ch.Blocks),
+		}
+		if len(ch.Epochs) > 0 {
+			first, last := ^uint64(0), uint64(0)
+			for epoch := range ch.Epochs {
+				first, last = min(first, epoch), max(last, epoch)
+			}
+			record[4], record[5] = strconv.FormatUint(first, 10), strconv.FormatUint(last, 10)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return outFile.Close()
+}
+
+// writeEpochs writes the cost of every epoch to path, one row per L1 origin.
+func writeEpochs(path string, costs []epochCost) error {
+	outFile, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+
+	writer := csv.NewWriter(outFile)
+	header := []string{"Epoch", "L2 Blocks", "Channels", "Cost(wei)", "Cost(ETH)", "Cost per L2 Block(ETH)"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, e := range costs {
+		record := []string{
+			strconv.FormatUint(e.Epoch, 10),
+			strconv.FormatUint(e.Blocks, 10),
+			strconv.Itoa(e.Channels),
+			e.Cost.String(),
+			ether(e.Cost).Text('f', 18),
+			perBlock(e.Cost, e.Blocks),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return outFile.Close()
+}
+
+// printChannelSummary prints the number of channels and epochs of the report
+// and their average cost.
+func printChannelSummary(w io.Writer, channels []batch.Channel, costs []epochCost) {
+	total, blocks := new(big.Int), uint64(0)
+	for _, ch := range channels {
+		total.Add(total, ch.Cost)
+		blocks += ch.Blocks
+	}
+	fmt.Fprintf(w, "Channels: %d completed, %s ETH", len(channels), ether(total).String())
+	if len(channels) > 0 {
+		avg := new(big.Int).Quo(total, big.NewInt(int64(len(channels))))
+		fmt.Fprintf(w, " (%s ETH per channel)", ether(avg).String())
+	}
+	if len(costs) > 0 {
+		fmt.Fprintf(w, "; %d epochs, %d L2 blocks, %s ETH per L2 block", len(costs), blocks, perBlock(total, blocks))
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+)
+
+// columnField is a value of a bucket that column expressions refer to by
+// name. blob marks the values that include blob fees, unavailable when a blob
+// gas price is missing.
+type columnField struct {
+	value func(r *aggregate.Result) float64
+	blob  bool
+}
+
+// floatOf returns x as a float64, 0 when nil.
+func floatOf(x *big.Float) float64 {
+	if x == nil {
+		return 0
+	}
+	f, _ := x.Float64()
+	return f
+}
+
+// columnFields are the values column expressions can use, by name.
+var columnFields = map[string]columnField{
+	"cost":               {func(r *aggregate.Result) float64 { return floatOf(r.Cost) }, true},
+	"calldata_cost":      {func(r *aggregate.Result) float64 { return floatOf(r.CalldataCost) }, false},
+	"blob_cost":          {func(r *aggregate.Result) float64 { return floatOf(r.BlobCost) }, true},
+	"reverted_cost":      {func(r *aggregate.Result) float64 { return floatOf(r.RevertedCost) }, true},
+	"successful_cost":    {func(r *aggregate.Result) float64 { return floatOf(r.SuccessfulCost()) }, true},
+	"base_fee_cost":      {func(r *aggregate.Result) float64 { return floatOf(r.BaseFeeCost) }, false},
+	"priority_fee_cost":  {func(r *aggregate.Result) float64 { return floatOf(r.PriorityFeeCost) }, false},
+	"tx_count":           {func(r *aggregate.Result) float64 { return float64(r.TxCount) }, false},
+	"blob_tx_count":      {func(r *aggregate.Result) float64 { return float64(r.BlobTxCount) }, false},
+	"reverted_txs":       {func(r *aggregate.Result) float64 { return float64(r.RevertedTxCount) }, false},
+	"successful_txs":     {func(r *aggregate.Result) float64 { return float64(r.SuccessfulTxCount()) }, false},
+	"blobs":              {func(r *aggregate.Result) float64 { return float64(r.Blobs()) }, false},
+	"calldata_gas":       {func(r *aggregate.Result) float64 { return float64(r.TotalCalldataGasUsed) }, false},
+	"blob_gas":           {func(r *aggregate.Result) float64 { return float64(r.TotalBlobGasUsed) }, false},
+	"total_gas":          {func(r *aggregate.Result) float64 { return float64(r.TotalGasUsed) }, false},
+	"calldata_gas_price": {func(r *aggregate.Result) float64 { return floatOf(r.AvgCallDataGasPrice) }, false},
+	"blob_gas_price":     {func(r *aggregate.Result) float64 { return floatOf(r.AvgBlobGasPrice) }, true},
+	"blended_gas_price":  {func(r *aggregate.Result) float64 { return floatOf(r.BlendedGasPrice) }, true},
+}
+
+// columnExpr is a parsed column expression.
+type columnExpr interface {
+	// eval returns the value of the expression for bucket, and false when a
+	// value it uses is unavailable.
+	eval(env *columnEnv, bucket string) (float64, bool)
+}
+
+// columnEnv holds the values column expressions read.
+type columnEnv struct {
+	results map[string]*aggregate.Result
+	columns map[string]map[string]string // values by bucket, by column header
+}
+
+type (
+	numberExpr float64
+	fieldExpr  string // a name of columnFields
+	columnRef  string // the header of another column
+	negExpr    struct{ x columnExpr }
+	binaryExpr struct {
+		op   byte
+		x, y columnExpr
+	}
+)
+
+func (e numberExpr) eval(*columnEnv, string) (float64, bool) { return float64(e), true }
+
+func (e fieldExpr) eval(env *columnEnv, bucket string) (float64, bool) {
+	r := env.results[bucket]
+	f := columnFields[string(e)]
+	if f.blob && r.BlobPriceMissing > 0 {
+		return 0, false
+	}
+	return f.value(r), true
+}
+
+func (e columnRef) eval(env *columnEnv, bucket string) (float64, bool) {
+	text := env.columns[string(e)][bucket]
+	if text == aggregate.Unavailable {
+		return 0, false
+	}
+	// Empty values, e.g. of buckets without such transactions, leave the
+	// result empty.
+	v, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return math.NaN(), true
+	}
+	return v, true
+}
+
+// columnRefs returns the headers of the columns expr refers to.
+func columnRefs(expr columnExpr) []string {
+	switch e := expr.(type) {
+	case columnRef:
+		return []string{string(e)}
+	case negExpr:
+		return columnRefs(e.x)
+	case binaryExpr:
+		return append(columnRefs(e.x), columnRefs(e.y)...)
+	}
+	return nil
+}
+
+func (e negExpr) eval(env *columnEnv, bucket string) (float64, bool) {
+	x, ok := e.x.eval(env, bucket)
+	return -x, ok
+}
+
+func (e binaryExpr) eval(env *columnEnv, bucket string) (float64, bool) {
+	x, ok := e.x.eval(env, bucket)
+	if !ok {
+		return 0, false
+	}
+	y, ok := e.y.eval(env, bucket)
+	if !ok {
+		return 0, false
+	}
+	switch e.op {
+	case '+':
+		return x + y, true
+	case '-':
+		return x - y, true
+	case '*':
+		return x * y, true
+	default:
+		return x / y, true
+	}
+}
+
+// customColumn is a column defined in the config as name = expression.
+type customColumn struct {
+	name string
+	expr columnExpr
+}
+
+// parseCustomColumn parses a column definition such as "cost_per_tx = cost /
+// tx_count". Expressions combine numbers, the names of columnFields, the
+// names of the columns defined before and the headers of other report
+// columns in brackets, e.g. [Priority Tips(ETH)], with + - * / and
+// parentheses.
+func parseCustomColumn(def string, defined map[string]bool) (customColumn, error) {
+	name, text, ok := strings.Cut(def, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return customColumn{}, fmt.Errorf("column %q: want name = expression", def)
+	}
+	p := &exprParser{text: text, defined: defined}
+	expr, err := p.sum()
+	if err == nil {
+		if p.skipSpace(); p.pos < len(p.text) {
+			err = fmt.Errorf("unexpected %q", p.text[p.pos:])
+		}
+	}
+	if err != nil {
+		return customColumn{}, fmt.Errorf("column %s: %w", name, err)
+	}
+	return customColumn{name: name, expr: expr}, nil
+}
+
+// exprParser parses an expression by recursive descent.
+type exprParser struct {
+	text    string
+	pos     int
+	defined map[string]bool
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.text) && unicode.IsSpace(rune(p.text[p.pos])) {
+		p.pos++
+	}
+}
+
+// sum parses terms separated by + and -.
+func (p *exprParser) sum() (columnExpr, error) {
+	x, err := p.product()
+	for err == nil {
+		if p.skipSpace(); p.pos == len(p.text) || (p.text[p.pos] != '+' && p.text[p.pos] != '-') {
+			break
+		}
+		op := p.text[p.pos]
+		p.pos++
+		var y columnExpr
+		if y, err = p.product(); err == nil {
+			x = binaryExpr{op, x, y}
+		}
+	}
+	return x, err
+}
+
+// product parses factors separated by * and /.
+func (p *exprParser) product() (columnExpr, error) {
+	x, err := p.factor()
+	for err == nil {
+		if p.skipSpace(); p.pos == len(p.text) || (p.text[p.pos] != '*' && p.text[p.pos] != '/') {
+			break
+		}
+		op := p.text[p.pos]
+		p.pos++
+		var y columnExpr
+		if y, err = p.factor(); err == nil {
+			x = binaryExpr{op, x, y}
+		}
+	}
+	return x, err
+}
+
+// factor parses a number, a name, a bracketed header, a negation or a
+// parenthesized expression.
+func (p *exprParser) factor() (columnExpr, error) {
+	p.skipSpace()
+	if p.pos == len(p.text) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	switch c := p.text[p.pos]; {
+	case c == '-':
+		p.pos++
+		x, err := p.factor()
+		return negExpr{x}, err
+	case c == '(':
+		p.pos++
+		x, err := p.sum()
+		if err != nil {
+			return nil, err
+		}
+		if p.skipSpace(); p.pos == len(p.text) || p.text[p.pos] != ')' {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return x, nil
+	case c == '[':
+		end := strings.IndexByte(p.text[p.pos:], ']')
+		if end < 0 {
+			return nil, fmt.Errorf("missing ]")
+		}
+		header := p.text[p.pos+1 : p.pos+end]
+		p.pos += end + 1
+		return columnRef(header), nil
+	case c == '.' || unicode.IsDigit(rune(c)):
+		start := p.pos
+		for p.pos < len(p.text) && (p.text[p.pos] == '.' || unicode.IsDigit(rune(p.text[p.pos]))) {
+			p.pos++
+		}
+		v, err := strconv.ParseFloat(p.text[start:p.pos], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", p.text[start:p.pos])
+		}
+		return numberExpr(v), nil
+	case c == '_' || unicode.IsLetter(rune(c)):
+		start := p.pos
+		for p.pos < len(p.text) && (p.text[p.pos] == '_' || unicode.IsLetter(rune(p.text[p.pos])) || unicode.IsDigit(rune(p.text[p.pos]))) {
+			p.pos++
+		}
+		name := p.text[start:p.pos]
+		if p.defined[name] {
+			return columnRef(name), nil
+		}
+		if _, ok := columnFields[name]; !ok {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+		return fieldExpr(name), nil
+	default:
+		return nil, fmt.Errorf("unexpected %q", p.text[p.pos:])
+	}
+}
+
+// loadColumns returns the custom columns of the columns section of the
+// -config file of fs, a list of name = expression definitions.
+func loadColumns(fs *flag.FlagSet) ([]customColumn, error) {
+	path := fs.Lookup("config").Value.String()
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Columns []string `yaml:"columns" toml:"columns"`
+	}
+	if strings.ToLower(filepath.Ext(path)) == ".toml" {
+		err = toml.Unmarshal(data, &file)
+	} else {
+		err = yaml.Unmarshal(data, &file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: columns: %w", path, err)
+	}
+	defined := make(map[string]bool, len(file.Columns))
+	columns := make([]customColumn, 0, len(file.Columns))
+	for _, def := range file.Columns {
+		c, err := parseCustomColumn(def, defined)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if _, ok := columnFields[c.name]; ok || defined[c.name] {
+			return nil, fmt.Errorf("%s: column %s defined twice", path, c.name)
+		}
+		defined[c.name] = true
+		columns = append(columns, c)
+	}
+	return columns, nil
+}
+
+// customColumnValues returns the columns of defs, evaluated for every bucket
+// after the extra columns, which bracketed headers refer to. Divisions by
+// zero leave the value empty, and values using unavailable ones are
+// unavailable.
+func customColumnValues(defs []customColumn, dates []string, results map[string]*aggregate.Result, extra []output.Column) ([]output.Column, error) {
+	env := &columnEnv{results: results, columns: make(map[string]map[string]string, len(extra)+len(defs))}
+	for _, c := range extra {
+		env.columns[c.Header] = c.Values
+	}
+	columns := make([]output.Column, 0, len(defs))
+	for _, def := range defs {
+		for _, header := range columnRefs(def.expr) {
+			if _, ok := env.columns[header]; !ok {
+				return nil, fmt.Errorf("column %s: the report has no column %q", def.name, header)
+			}
+		}
+		c := output.Column{Header: def.name, Values: make(map[string]string, len(dates))}
+		for _, day := range dates {
+			switch v, ok := def.expr.eval(env, day); {
+			case !ok:
+				c.Values[day] = aggregate.Unavailable
+			case math.IsNaN(v) || math.IsInf(v, 0):
+			default:
+				c.Values[day] = formatNumber(v)
+			}
+		}
+		env.columns[def.name] = c.Values
+		columns = append(columns, c)
+	}
+	return columns, nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+)
+
+// columnDates are the buckets of columnResults: the first with four
+// transactions, the second with none and a blob transaction whose blob gas
+// price is missing.
+var columnDates = []string{"2024-07-01", "2024-07-02"}
+
+func columnResults() map[string]*aggregate.Result {
+	first := aggregate.NewResult()
+	first.TxCount = 4
+	first.Cost, first.CalldataCost = big.NewFloat(0.5), big.NewFloat(0.25)
+	second := aggregate.NewResult()
+	second.BlobPriceMissing = 1
+	second.CalldataCost = big.NewFloat(0.125)
+	return map[string]*aggregate.Result{columnDates[0]: first, columnDates[1]: second}
+}
+
+// columnExtra are report columns that bracketed headers refer to, one of
+// them with an unavailable and an empty value.
+var columnExtra = []output.Column{
+	{Header: "Priority Tips(ETH)", Values: map[string]string{columnDates[0]: "0.1", columnDates[1]: aggregate.Unavailable}},
+	{Header: "Txs Above 2x Market", Values: map[string]string{columnDates[0]: "3"}},
+}
+
+// evalColumns parses defs like loadColumns and evaluates them over
+// columnResults.
+func evalColumns(defs ...string) ([]output.Column, error) {
+	defined := make(map[string]bool)
+	var columns []customColumn
+	for _, def := range defs {
+		c, err := parseCustomColumn(def, defined)
+		if err != nil {
+			return nil, err
+		}
+		defined[c.name] = true
+		columns = append(columns, c)
+	}
+	return customColumnValues(columns, columnDates, columnResults(), columnExtra)
+}
+
+func TestCustomColumns(t *testing.T) {
+	tests := []struct {
+		name string
+		defs []string
+		want [2]string // values of the last column in both buckets
+	}{
+		{"precedence", []string{"x = 1 + 2 * 3"}, [2]string{"7", "7"}},
+		{"parentheses", []string{"x = (1 + 2) * 3"}, [2]string{"9", "9"}},
+		{"left to right", []string{"x = 10 - 4 - 3 + 8 / 4 / 2"}, [2]string{"4", "4"}},
+		{"unary minus", []string{"x = -2 * 3 - -1"}, [2]string{"-5", "-5"}},
+		{"negated group", []string{"x = -(1 + 2) * -tx_count"}, [2]string{"12", "0"}},
+		{"fields", []string{"x = calldata_cost / 0.25 + tx_count"}, [2]string{"5", "0.5"}},
+		{"header", []string{"x = [Priority Tips(ETH)] * 10"}, [2]string{"1", aggregate.Unavailable}},
+		{"empty header value", []string{"x = [Txs Above 2x Market] + 1"}, [2]string{"4", ""}},
+		{"earlier column", []string{"double = tx_count * 2", "x = double + 1"}, [2]string{"9", "1"}},
+		{"earlier column by header", []string{"double = tx_count * 2", "x = [double] * double"}, [2]string{"64", "0"}},
+		{"division by zero", []string{"x = calldata_cost / tx_count"}, [2]string{"0.0625", ""}},
+		{"zero by zero", []string{"x = tx_count / tx_count"}, [2]string{"1", ""}},
+		{"unavailable blob value", []string{"x = cost / tx_count"}, [2]string{"0.125", aggregate.Unavailable}},
+		{"unavailable through a column", []string{"c = cost", "x = 1 + c * 0"}, [2]string{"1", aggregate.Unavailable}},
+		{"unavailable before division by zero", []string{"x = cost / 0"}, [2]string{"", aggregate.Unavailable}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columns, err := evalColumns(tt.defs...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			last := columns[len(columns)-1]
+			for i, day := range columnDates {
+				if got := last.Values[day]; got != tt.want[i] {
+					t.Errorf("%s: got %q, want %q", day, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCustomColumnErrors(t *testing.T) {
+	tests := []struct {
+		def, err string
+	}{
+		{"x = gas_per_tx * 2", `unknown field "gas_per_tx"`},
+		{"x = 1 2", `unexpected "2"`},
+		{"x = cost ) + 1", `unexpected ") + 1"`},
+		{"x = (1 + 2", "missing )"},
+		{"x = [Priority Tips(ETH) * 2", "missing ]"},
+		{"x = 1 +", "unexpected end of expression"},
+		{"x = 1.2.3", `"1.2.3" is not a number`},
+		{"x = 1 % 2", `unexpected "% 2"`},
+		{"x", "want name = expression"},
+		{" = 1", "want name = expression"},
+		{"x = [No Such Column] + 1", `the report has no column "No Such Column"`},
+		{"x = y + 1", `unknown field "y"`},
+	}
+	for _, tt := range tests {
+		_, err := evalColumns(tt.def)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%q: got error %v, want %q", tt.def, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/tracker"
+)
+
+// rollupReport is the report of a rollup of a comparison.
+type rollupReport struct {
+	name   string
+	report tracker.Report
+}
+
+// runRollups runs cmd once per rollup of the comma-separated list, each
+// named after the rollup unless its section names it, and writes the
+// comparison of their reports next to them, printing it to con.
+func runRollups(cmd string, fs *flag.FlagSet, args []string, list string, con console) error {
+	var (
+		reports     []rollupReport
+		granularity string
+	)
+	err := runSections(fs, args, "rollups", "rollups", list, con.log, func(name string, args []string) error {
+		return runAnalyze(cmd, append([]string{"-name=" + name}, args...), con, func(report tracker.Report, g string) {
+			reports = append(reports, rollupReport{name, report})
+			if granularity == "" {
+				granularity = g
+			} else if g != granularity {
+				granularity = "mixed"
+			}
+		})
+	})
+	if err != nil {
+		return err
+	}
+	if granularity == "mixed" {
+		return errors.New("-rollups: the rollups must share a -granularity to be compared")
+	}
+
+	names := make([]string, len(reports))
+	for i, r := range reports {
+		names[i] = r.name
+	}
+	base := filepath.Join(fs.Lookup("out").Value.String(), "comparison-"+strings.Join(names, "-"))
+	keys := comparisonKeys(reports)
+	if err := writeComparison(base+".csv", keys, reports); err != nil {
+		return err
+	}
+	series := make([]output.Series, len(reports))
+	for i, r := range reports {
+		series[i] = output.Series{Name: r.name, Values: make([]float64, len(keys))}
+		for j, k := range keys {
+			series[i].Values[j] = math.NaN()
+			if result := r.report.Results[k]; result != nil {
+				if v, err := strconv.ParseFloat(result.BlobDependent(result.Cost), 64); err == nil {
+					series[i].Values[j] = v
+				}
+			}
+		}
+	}
+	if len(keys) > 0 {
+		if err := output.WriteChart(base+".cost.png", "Total cost", "ETH", keys, series...); err != nil {
+			return err
+		}
+	}
+	printComparison(con.stdout, reports)
+	fmt.Fprintln(con.stdout, "comparison:", base+".csv", base+".cost.png")
+	return nil
+}
+
+// comparisonKeys returns the buckets of any of the reports, in order.
+func comparisonKeys(reports []rollupReport) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, r := range reports {
+		for _, k := range r.report.Dates {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// comparisonMetrics are the values compared side by side, every metric with a
+// column per rollup.
+var comparisonMetrics = []struct {
+	header string
+	value  func(*aggregate.Result) string
+}{
+	{"Cost(ETH)", func(r *aggregate.Result) string { return r.BlobDependent(r.Cost) }},
+	{"Cost per Byte(wei)", func(r *aggregate.Result) string {
+		if v, ok := costPerByte(r); ok {
+			return r.BlobDependent(v)
+		}
+		return ""
+	}},
+	{"Blob Count", func(r *aggregate.Result) string { return strconv.FormatUint(r.Blobs(), 10) }},
+	{"Blob Gas Used", func(r *aggregate.Result) string { return strconv.FormatUint(r.TotalBlobGasUsed, 10) }},
+}
+
+// writeComparison writes the metrics of the reports in every bucket of keys
+// to path, followed by their totals. Buckets missing from a report are
+// empty.
+func writeComparison(path string, keys []string, reports []rollupReport) error {
+	outFile, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+
+	writer := csv.NewWriter(outFile)
+	header := []string{"DateTime"}
+	for _, m := range comparisonMetrics {
+		for _, r := range reports {
+			header = append(header, r.name+" "+m.header)
+		}
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	record := func(key string, result func(tracker.Report) *aggregate.Result) []string {
+		values := []string{key}
+		for _, m := range comparisonMetrics {
+			for _, r := range reports {
+				v := ""
+				if res := result(r.report); res != nil {
+					v = m.value(res)
+				}
+				values = append(values, v)
+			}
+		}
+		return values
+	}
+	for _, k := range keys {
+		if err := writer.Write(record(k, func(r tracker.Report) *aggregate.Result { return r.Results[k] })); err != nil {
+			return err
+		}
+	}
+	if err := writer.Write(record("Total", func(r tracker.Report) *aggregate.Result { return r.Total })); err != nil {
+		return err
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return outFile.Close()
+}
+
+// printComparison prints the totals of the reports side by side to out.
+func printComparison(out io.Writer, reports []rollupReport) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(w, "Rollup\t")
+	for _, m := range comparisonMetrics {
+		fmt.Fprint(w, m.header+"\t")
+	}
+	fmt.Fprintln(w)
+	for _, r := range reports {
+		fmt.Fprint(w, r.name+"\t")
+		for _, m := range comparisonMetrics {
+			v := ""
+			if r.report.Total != nil {
+				v = m.value(r.report.Total)
+			}
+			fmt.Fprint(w, v+"\t")
+		}
+		fmt.Fprintln(w)
+	}
+	w.Flush()
+}
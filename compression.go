@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+)
+
+// postedColumn returns the size of the data posted in every bucket.
+func postedColumn(results map[string]*aggregate.Result) output.Column {
+	posted := output.Column{Header: "Posted Bytes", Values: make(map[string]string, len(results))}
+	for k, r := range results {
+		posted.Values[k] = strconv.FormatUint(r.PostedBytes, 10)
+	}
+	return posted
+}
+
+// compressionRatio returns the size of the batches of the channels completed
+// in r over their compressed size, and false without decoded channels.
+func compressionRatio(r *aggregate.Result) (float64, bool) {
+	if r.ChannelBytes == 0 {
+		return 0, false
+	}
+	return float64(r.RawBytes) / float64(r.ChannelBytes), true
+}
+
+// costPerByte returns the cost of r per byte posted, in wei, and false when
+// nothing was posted.
+func costPerByte(r *aggregate.Result) (*big.Float, bool) {
+	if r.PostedBytes == 0 {
+		return nil, false
+	}
+	wei := new(big.Float).Mul(r.Cost, big.NewFloat(1e18))
+	return wei.Quo(wei, new(big.Float).SetUint64(r.PostedBytes)), true
+}
+
+// compressionColumns returns the decompressed size of the batches completed
+// in every bucket, their compression ratio and the cost per byte posted.
+func compressionColumns(results map[string]*aggregate.Result) []output.Column {
+	raw := output.Column{Header: "Uncompressed Bytes", Values: make(map[string]string, len(results))}
+	ratio := output.Column{Header: "Compression Ratio", Values: make(map[string]string, len(results))}
+	perByte := output.Column{Header: "Cost per Byte(wei)", Values: make(map[string]string, len(results))}
+	for k, r := range results {
+		raw.Values[k] = strconv.FormatUint(r.RawBytes, 10)
+		if v, ok := compressionRatio(r); ok {
+			ratio.Values[k] = strconv.FormatFloat(v, 'f', 3, 64)
+		}
+		if v, ok := costPerByte(r); ok {
+			perByte.Values[k] = r.BlobDependent(v)
+		}
+	}
+	return []output.Column{raw, ratio, perByte}
+}
+
+// printCompressionSummary prints the bytes posted over the whole report, their
+// compression and their cost per byte.
+func printCompressionSummary(w io.Writer, total *aggregate.Result) {
+	fmt.Fprintf(w, "Compression: %d bytes posted", total.PostedBytes)
+	if v, ok := compressionRatio(total); ok {
+		fmt.Fprintf(w, ", channels of %d bytes decompressing to %d (ratio %.3f)", total.ChannelBytes, total.RawBytes, v)
+	}
+	if v, ok := costPerByte(total); ok {
+		fmt.Fprintf(w, ", %s wei per byte", total.BlobDependent(v))
+	}
+	fmt.Fprintln(w)
+}
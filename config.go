@@ -0,0 +1,194 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/alert"
+)
+
+// flagEnv maps flags to the environment variable they fall back to. A set
+// variable takes precedence over the config file.
+var flagEnv = map[string]string{
+	"input":         "FILE_NAME",
+	"rpc":           "L1_RPC",
+	"ws":            "L1_WS",
+	"beacon":        "L1_BEACON",
+	"l2-rpc":        "L2_RPC",
+	"concurrency":   "CONCURRENCY",
+	"batch-size":    "BATCH_SIZE",
+	"cache":         "RECEIPT_CACHE",
+	"etherscan-key": "ETHERSCAN_API_KEY",
+	"price-api-key": "COINGECKO_API_KEY",
+}
+
+// parseArgs parses the command line of a command, as parseFlags, and installs
+// the logger of its -log-level and -log-format flags as the default one.
+func parseArgs(fs *flag.FlagSet, args []string) error {
+	logger, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+// parseFlags parses the command line of a command and returns the logger of
+// its -log-level and -log-format flags. Flags that are neither on the command
+// line nor set through their environment variable take their value from the
+// -config file, if any.
+func parseFlags(fs *flag.FlagSet, args []string) (*slog.Logger, error) {
+	configPath := fs.String("config", os.Getenv("TRACKER_CONFIG"), "YAML or TOML file of flag values, overridden by the command line and env (env TRACKER_CONFIG)")
+	logLevel := fs.String("log-level", "info", "minimum level of log messages: debug, info, warn or error")
+	logFormat := fs.String("log-format", "text", "format of log messages on stderr: text or json")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if *configPath != "" {
+		if err := applyConfig(fs, *configPath); err != nil {
+			return nil, err
+		}
+	}
+	return newLogger(os.Stderr, *logLevel, *logFormat)
+}
+
+// applyConfig sets the flags of fs named in the config file at path.
+func applyConfig(fs *flag.FlagSet, configPath string) error {
+	values, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	for name, value := range values {
+		if fs.Lookup(name) == nil || explicit[name] || os.Getenv(flagEnv[name]) != "" {
+			// Keys of other commands are ignored, so that one file can
+			// configure all of them.
+			continue
+		}
+		if err := fs.Set(name, value); err != nil {
+			return fmt.Errorf("%s: %s: %w", configPath, name, err)
+		}
+	}
+	return nil
+}
+
+// loadConfig reads a config file, YAML unless it has a .toml extension, into
+// flag values keyed by flag name. Lists, e.g. of RPC endpoints or addresses,
+// become comma-separated values.
+func loadConfig(path string) (map[string]string, error) {
+	raw, err := readConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	// The alerts section is read by loadAlerts, the columns section by
+	// loadColumns, the networks, rollups and jobs sections by loadSections.
+	delete(raw, "alerts")
+	delete(raw, "columns")
+	delete(raw, "jobs")
+	delete(raw, "networks")
+	delete(raw, "rollups")
+	values, err := flagValues(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return values, nil
+}
+
+// loadSections returns the flag values of every entry of a section of the
+// config file at path, such as the networks section, keyed by entry name.
+func loadSections(path, section string) (map[string]map[string]string, error) {
+	raw, err := readConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	entries, ok := raw[section].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: no %s section", path, section)
+	}
+	sections := make(map[string]map[string]string, len(entries))
+	for name, value := range entries {
+		settings, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: %s: %s: not a section of settings", path, section, name)
+		}
+		if sections[name], err = flagValues(settings); err != nil {
+			return nil, fmt.Errorf("%s: %s: %s: %w", path, section, name, err)
+		}
+	}
+	return sections, nil
+}
+
+// readConfig reads the settings of the config file at path.
+func readConfig(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]any
+	if strings.ToLower(filepath.Ext(path)) == ".toml" {
+		err = toml.Unmarshal(data, &raw)
+	} else {
+		err = yaml.Unmarshal(data, &raw)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return raw, nil
+}
+
+// flagValues converts settings into flag values keyed by flag name.
+func flagValues(raw map[string]any) (map[string]string, error) {
+	values := make(map[string]string, len(raw))
+	for name, value := range raw {
+		switch v := value.(type) {
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			values[name] = strings.Join(items, ",")
+		case map[string]any:
+			return nil, fmt.Errorf("%s: nested settings are not supported", name)
+		default:
+			values[name] = fmt.Sprint(v)
+		}
+	}
+	return values, nil
+}
+
+// loadAlerts returns the notifier of the alerts section of the -config file
+// of fs, or nil if there is no such section or it sends nothing.
+func loadAlerts(fs *flag.FlagSet) (*alert.Notifier, error) {
+	path := fs.Lookup("config").Value.String()
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Alerts alert.Config `yaml:"alerts" toml:"alerts"`
+	}
+	if strings.ToLower(filepath.Ext(path)) == ".toml" {
+		err = toml.Unmarshal(data, &file)
+	} else {
+		err = yaml.Unmarshal(data, &file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: alerts: %w", path, err)
+	}
+	if !file.Alerts.Enabled() {
+		return nil, nil
+	}
+	return alert.New(file.Alerts), nil
+}
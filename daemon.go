@@ -0,0 +1,345 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/alert"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/dashboard"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/fetch"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/sink"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/tracker"
+)
+
+// runDaemon follows the chain head and stores the transactions of the
+// configured addresses as they land, keeping the daily aggregates of the sink
+// current.
+func runDaemon(args []string) error {
+	fs := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	rpcURLs := fs.String("rpc", os.Getenv("L1_RPC"), "comma-separated L1 JSON-RPC endpoints, used in turn on failures (env L1_RPC)")
+	wsURL := fs.String("ws", os.Getenv("L1_WS"), "L1 WebSocket endpoint to subscribe to new heads; without it the head is polled (env L1_WS)")
+	pollInterval := fs.Duration("poll-interval", 12*time.Second, "how often to poll the head without -ws")
+	address := fs.String("address", "", "follow the transactions sent by these comma-separated addresses, e.g. the batcher and the proposer")
+	toAddress := fs.String("to-address", "", "only follow transactions sent to these comma-separated addresses")
+	systemConfig := fs.String("system-config", "", "OP Stack SystemConfig contract whose batcher and proposer are followed like -address, read at startup")
+	fromBlock := fs.Uint64("from-block", 0, "first block to scan when there is no saved state (default: the head)")
+	confirmations := fs.Uint64("confirmations", 2, "blocks built on a block before it is scanned, against reorgs")
+	statePath := fs.String("state", "./outputs/daemon.state", "file keeping the last scanned block, to catch up after a restart")
+	sinkKind := fs.String("sink", "", "database storing the transactions and daily aggregates: "+sinkKindsHelp()+", or a kind of -sink-plugin")
+	sinkPlugins := fs.String("sink-plugin", "", "comma-separated Go plugins (.so) registering more kinds of -sink")
+	dsn := fs.String("dsn", os.Getenv("TRACKER_DSN"), "database of -sink: the path of the SQLite file, a Postgres connection URL, a ClickHouse HTTP URL, Kafka brokers or the path of the csv or jsonl file (env TRACKER_DSN)")
+	concurrency := fs.Int("concurrency", envInt("CONCURRENCY", 8), "number of receipts fetched in parallel (env CONCURRENCY)")
+	batchSize := fs.Int("batch-size", envInt("BATCH_SIZE", 50), "receipts and blocks requested per JSON-RPC batch call (env BATCH_SIZE)")
+	blockReceiptsMin := fs.Int("block-receipts-min", 3, "fetch a whole block's receipts with eth_getBlockReceipts when at least this many transactions share it (0 disables)")
+	rps := fs.Float64("rps", 0, "maximum L1 RPC calls per second, a batch counting as one, lowered while the provider answers 429 Too Many Requests (0 disables)")
+	otlpEndpoint := fs.String("otlp-endpoint", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "OTLP/HTTP collector receiving traces and metrics of the RPC calls, retries, cache lookups and processed transactions, e.g. http://localhost:4318 (env OTEL_EXPORTER_OTLP_ENDPOINT)")
+	otlpInterval := fs.Duration("otlp-interval", 15*time.Second, "how often to export the metrics to -otlp-endpoint")
+	requestTimeout := fs.Duration("request-timeout", time.Minute, "timeout of a single RPC request, after which it is retried (0 disables)")
+	maxAttempts := fs.Int("max-attempts", 5, "attempts per RPC request before giving up until the next head")
+	retryDelay := fs.Duration("retry-delay", 500*time.Millisecond, "initial delay between RPC retries, doubled on every attempt")
+	retryMaxDelay := fs.Duration("retry-max-delay", 30*time.Second, "upper bound of the delay between RPC retries and resubscriptions")
+	tui := fs.Bool("tui", false, "draw a terminal dashboard of the running cost of the day, the latest transaction, the blob base fee and the hourly costs of the last 24 hours, with the log below it")
+	healthAddr := fs.String("health-addr", "", "serve /healthz and /readyz on this address, e.g. :8081, for the probes of Kubernetes")
+	streamAddr := fs.String("stream-addr", "", "serve /stream on this address, e.g. :8082: server-sent events of the stored transactions and of the daily aggregates they change, for live dashboards")
+	readyMaxLag := fs.Uint64("ready-max-lag", 64, "blocks the last stored block may be behind the head before /readyz fails")
+	readyMaxHeadAge := fs.Duration("ready-max-head-age", 2*time.Minute, "time without a new head, i.e. without an answer of the RPC endpoints, before /readyz fails")
+	schedule := fs.String("schedule", "", "cron schedule, in UTC, of the backfills that re-scan the previous day and finalize its aggregate, e.g. \"0 1 * * *\"")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s daemon -address senders -sink kind -dsn database [flags]\n\nFlags:\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	if *sinkKind == "" || *dsn == "" {
+		return errors.New("daemon needs -sink and -dsn")
+	}
+	if err := loadSinkPlugins(*sinkPlugins); err != nil {
+		return err
+	}
+	senders, err := fetch.ParseAddresses(*address)
+	if err != nil {
+		return err
+	}
+	recipients, err := fetch.ParseAddresses(*toAddress)
+	if err != nil {
+		return err
+	}
+	if *systemConfig != "" {
+		if senders, _, err = discoverSenders(*rpcURLs, *systemConfig, *requestTimeout, senders, slog.Default()); err != nil {
+			return fmt.Errorf("-system-config: %w", err)
+		}
+	}
+	notifier, err := loadAlerts(fs)
+	if err != nil {
+		return err
+	}
+	var sched cron.Schedule
+	if *schedule != "" {
+		if sched, err = cron.ParseStandard(*schedule); err != nil {
+			return fmt.Errorf("-schedule: %w", err)
+		}
+	}
+	db, err := sink.Open(*sinkKind, *dsn)
+	if err != nil {
+		return err
+	}
+
+	stopTelemetry, err := startTelemetry(*otlpEndpoint, "batcher-gas-tracker-daemon", *otlpInterval)
+	if err != nil {
+		return err
+	}
+	defer stopTelemetry()
+
+	// SIGINT or SIGTERM stops following; the blocks scanned so far are
+	// stored and saved in the state.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// The dashboard takes over the terminal, so the log goes below it; q
+	// stops the daemon like SIGINT.
+	var dash *dashboard.Dashboard
+	dashDone := make(chan struct{})
+	if *tui {
+		title := "batcher-gas-tracker daemon"
+		if len(senders) > 0 {
+			title += fmt.Sprintf(", from %v", senders)
+		}
+		if len(recipients) > 0 {
+			title += fmt.Sprintf(", to %v", recipients)
+		}
+		dash = dashboard.New(title)
+		if err := setupLogger(dash, fs.Lookup("log-level").Value.String(), fs.Lookup("log-format").Value.String()); err != nil {
+			return err
+		}
+		go func() {
+			defer close(dashDone)
+			if err := dash.Run(ctx, stop); err != nil {
+				slog.Error("dashboard failed", "err", err)
+			}
+		}()
+	} else {
+		close(dashDone)
+	}
+
+	health := newDaemonHealth(*readyMaxLag, *readyMaxHeadAge)
+	if *healthAddr != "" {
+		lis, err := net.Listen("tcp", *healthAddr)
+		if err != nil {
+			return fmt.Errorf("-health-addr: %w", err)
+		}
+		server := &http.Server{Handler: health.handler(), ReadHeaderTimeout: 10 * time.Second}
+		defer server.Close()
+		slog.Info("serving health", "addr", *healthAddr, "paths", "/healthz, /readyz")
+		go func() {
+			if err := server.Serve(lis); !errors.Is(err, http.ErrServerClosed) {
+				slog.Error("health server failed", "err", err)
+			}
+		}()
+	}
+
+	var hub *streamHub
+	if *streamAddr != "" {
+		lis, err := net.Listen("tcp", *streamAddr)
+		if err != nil {
+			return fmt.Errorf("-stream-addr: %w", err)
+		}
+		hub = newStreamHub()
+		server := &http.Server{Handler: hub.handler(), ReadHeaderTimeout: 10 * time.Second}
+		defer server.Close()
+		slog.Info("serving stream", "addr", *streamAddr, "path", "/stream")
+		go func() {
+			if err := server.Serve(lis); !errors.Is(err, http.ErrServerClosed) {
+				slog.Error("stream server failed", "err", err)
+			}
+		}()
+	}
+
+	retry := fetch.RetryPolicy{MaxAttempts: *maxAttempts, BaseDelay: *retryDelay, MaxDelay: *retryMaxDelay}
+	// The follower and the backfills write to db from their own goroutines.
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	if sched != nil {
+		cfg := tracker.Config{
+			RPC:              *rpcURLs,
+			Scan:             true,
+			Senders:          senders,
+			Recipients:       recipients,
+			Granularity:      "day",
+			Concurrency:      *concurrency,
+			BatchSize:        *batchSize,
+			BlockReceiptsMin: *blockReceiptsMin,
+			Retry:            retry,
+			RequestTimeout:   *requestTimeout,
+			RPS:              *rps,
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				next := sched.Next(time.Now().UTC())
+				slog.Info("next backfill", "at", next)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Until(next)):
+				}
+				day := next.AddDate(0, 0, -1).Format(time.DateOnly)
+				if err := backfill(ctx, cfg, day, db, &mu, notifier, hub); err != nil && ctx.Err() == nil {
+					slog.Error("backfill failed", "day", day, "err", err)
+				}
+			}
+		}()
+	}
+
+	var sinkErr error
+	// changed are the days of the transactions since the last blocks, whose
+	// aggregates go to the stream.
+	changed := make(map[string]bool)
+	err = tracker.Follow(ctx, tracker.FollowConfig{
+		RPC:              *rpcURLs,
+		WS:               *wsURL,
+		PollInterval:     *pollInterval,
+		Senders:          senders,
+		Recipients:       recipients,
+		FromBlock:        *fromBlock,
+		Confirmations:    *confirmations,
+		Concurrency:      *concurrency,
+		BatchSize:        *batchSize,
+		BlockReceiptsMin: *blockReceiptsMin,
+		Retry:            retry,
+		RequestTimeout:   *requestTimeout,
+		RPS:              *rps,
+		StatePath:        *statePath,
+		OnTx: func(tx aggregate.Tx) {
+			mu.Lock()
+			defer mu.Unlock()
+			if sinkErr == nil {
+				sinkErr = db.WriteTx(tx)
+			}
+			if dash != nil {
+				dash.Tx(tx)
+			}
+			if hub != nil && sinkErr == nil {
+				hub.Tx(tx)
+				changed[tx.Bucket] = true
+			}
+		},
+		OnBlocks: func(number uint64, dates []string, results map[string]*aggregate.Result) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if sinkErr != nil {
+				return fmt.Errorf("-sink %s: %w", *sinkKind, sinkErr)
+			}
+			if f, ok := db.(sink.Flusher); ok {
+				if err := f.Flush(); err != nil {
+					return fmt.Errorf("-sink %s: %w", *sinkKind, err)
+				}
+			}
+			health.Block(number)
+			if hub != nil {
+				for _, day := range dates {
+					if changed[day] {
+						hub.Day(day, results[day], false)
+					}
+				}
+				clear(changed)
+			}
+			if len(dates) > 0 {
+				day := dates[len(dates)-1]
+				r := results[day]
+				slog.Debug("day so far", "block", number, "day", day, "transactions", r.TxCount,
+					"cost", r.BlobDependent(r.Cost), "avgBlobGasPrice", r.BlobDependent(r.AvgBlobGasPrice))
+			}
+			return nil
+		},
+		OnHead: func(number uint64, header fetch.Header) {
+			health.Head(number)
+			if dash != nil {
+				dash.Head(number, header)
+			}
+		},
+		OnError: health.Error,
+	})
+	// Follow returns early on errors; the backfills and the dashboard stop
+	// with ctx either way.
+	stop()
+	wg.Wait()
+	<-dashDone
+	if closeErr := db.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("-sink %s: %w", *sinkKind, closeErr)
+	}
+	return err
+}
+
+// backfill re-scans day once it is over and publishes its complete aggregate,
+// which notifier, if set, checks against the alert thresholds and hub, if
+// set, streams. The follower
+// stores the same transactions, which the sink upserts, but a daemon that was
+// down or started late misses some of them.
+func backfill(ctx context.Context, cfg tracker.Config, day string, db sink.Sink, mu *sync.Mutex, notifier *alert.Notifier, hub *streamHub) error {
+	var sinkErr error
+	cfg.FromDate, cfg.ToDate = day, day
+	cfg.OnTx = func(tx aggregate.Tx) {
+		mu.Lock()
+		defer mu.Unlock()
+		if sinkErr == nil {
+			sinkErr = db.WriteTx(tx)
+		}
+	}
+	report, err := tracker.Run(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	if len(report.Failures) > 0 || report.WasInterrupted() {
+		return fmt.Errorf("%d transactions failed and %d were not fetched, so the day is not finalized", len(report.Failures), report.Interrupted)
+	}
+
+	if err := finalize(db, mu, sinkErr, report); err != nil {
+		return err
+	}
+	slog.Info("day finalized", "day", day, "transactions", report.Rows)
+	if hub != nil {
+		for _, k := range report.Dates {
+			hub.Day(k, report.Results[k], true)
+		}
+	}
+	if notifier != nil {
+		if err := notifier.Check(ctx, report.Name, report.Dates, report.Results); err != nil {
+			return fmt.Errorf("alerts: %w", err)
+		}
+	}
+	return nil
+}
+
+// finalize publishes the buckets of a backfill and flushes db.
+func finalize(db sink.Sink, mu *sync.Mutex, sinkErr error, report tracker.Report) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if sinkErr != nil {
+		return sinkErr
+	}
+	if w, ok := db.(sink.BucketWriter); ok {
+		if err := w.WriteBuckets(report.Dates, report.Results); err != nil {
+			return err
+		}
+	}
+	if f, ok := db.(sink.Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
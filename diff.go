@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// diffColumns are the report columns compared by diff. The total row sums
+// those that add up across buckets.
+var diffColumns = []string{
+	"Total Cost(ETH)",
+	"Total Calldata Gas Used",
+	"Total Blob Gas Used",
+	"Avg Calldata gas price(Gwei)",
+	"Avg Blob Gas Price(Gwei)",
+}
+
+// runDiff compares two reports written by analyze bucket by bucket, e.g.
+// before and after a change of the fee config or a move to blobs.
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	csvPath := fs.String("csv", "", "also write the comparison to this CSV file")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s diff [flags] old.csv new.csv\n\nFlags:\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return errors.New("diff takes two reports")
+	}
+	old, err := readReportValues(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	updated, err := readReportValues(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	header := []string{"DateTime"}
+	for _, column := range diffColumns {
+		header = append(header, column+" Old", column+" New", column+" Delta", column+" Delta(%)")
+	}
+	records := [][]string{header}
+	for _, k := range diffKeys(old, updated) {
+		records = append(records, diffRecord(k, old[k], updated[k]))
+	}
+	records = append(records, diffRecord("Total", old.total(), updated.total()))
+
+	if *csvPath != "" {
+		if err := writeRecords(*csvPath, records); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("%s -> %s\n", fs.Arg(0), fs.Arg(1))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	for _, record := range records {
+		fmt.Fprintln(w, strings.Join(record, "\t")+"\t")
+	}
+	return w.Flush()
+}
+
+// bucketValues are the values of diffColumns in a bucket of a report, NaN when
+// missing or unavailable.
+type bucketValues []float64
+
+// reportValues are the buckets of a report, keyed by bucket.
+type reportValues map[string]bucketValues
+
+// readReportValues reads the values of diffColumns of the report at path.
+func readReportValues(path string) (reportValues, error) {
+	records, err := readReport(path)
+	if err != nil {
+		return nil, err
+	}
+	indexes := make([]int, len(diffColumns))
+	for i, column := range diffColumns {
+		indexes[i] = -1
+		for j, header := range records[0] {
+			if header == column {
+				indexes[i] = j
+			}
+		}
+	}
+	values := make(reportValues, len(records)-1)
+	for _, record := range records[1:] {
+		v := make(bucketValues, len(diffColumns))
+		for i, j := range indexes {
+			v[i] = math.NaN()
+			if j >= 0 && j < len(record) {
+				if f, err := strconv.ParseFloat(record[j], 64); err == nil {
+					v[i] = f
+				}
+			}
+		}
+		values[record[0]] = v
+	}
+	return values, nil
+}
+
+// total returns the sums of the summed columns over the buckets of r, NaN for
+// the other columns or when a bucket lacks a value.
+func (r reportValues) total() bucketValues {
+	total := make(bucketValues, len(diffColumns))
+	for i, column := range diffColumns {
+		if !summedColumns[column] {
+			total[i] = math.NaN()
+			continue
+		}
+		for _, v := range r {
+			total[i] += v[i]
+		}
+	}
+	return total
+}
+
+// diffKeys returns the buckets of either report, in order.
+func diffKeys(a, b reportValues) []string {
+	var keys []string
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// diffRecord returns the old and new values of bucket key and their
+// differences. Values missing from either side are empty.
+func diffRecord(key string, old, updated bucketValues) []string {
+	value := func(v bucketValues, i int) float64 {
+		if v == nil {
+			return math.NaN()
+		}
+		return v[i]
+	}
+	format := func(f float64, verb byte, prec int) string {
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return ""
+		}
+		return strconv.FormatFloat(f, verb, prec, 64)
+	}
+	record := []string{key}
+	for i := range diffColumns {
+		a, b := value(old, i), value(updated, i)
+		percent := math.NaN()
+		if a != 0 {
+			percent = 100 * (b - a) / a
+		}
+		record = append(record, format(a, 'g', 10), format(b, 'g', 10), format(b-a, 'g', 10), format(percent, 'f', 2))
+	}
+	return record
+}
+
+// writeRecords writes records to a CSV file at path.
+func writeRecords(path string, records [][]string) error {
+	outFile, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+	writer := csv.NewWriter(outFile)
+	if err := writer.WriteAll(records); err != nil {
+		return err
+	}
+	return outFile.Close()
+}
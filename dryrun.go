@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/tracker"
+)
+
+// dryRunSkippedShown is the number of skipped rows a dry run lists.
+const dryRunSkippedShown = 10
+
+// printDryRun prints what the run of report would fetch, and how long it
+// would take, from the estimate of a dry run.
+func printDryRun(w io.Writer, report tracker.Report, concurrency int, rps float64) {
+	d := report.DryRun
+	network := "no RPC"
+	if report.ChainID != 0 {
+		network = fmt.Sprintf("%s (chain %d)", report.Network, report.ChainID)
+	}
+	fmt.Fprintf(w, "Dry run on %s, nothing fetched\n", network)
+	switch d.Scan {
+	case "blocks":
+		fmt.Fprintf(w, "Scan: blocks %d-%d (%d blocks) in %d batches; the transactions found are fetched afterwards\n",
+			d.FromBlock, d.ToBlock, d.ToBlock-d.FromBlock+1, d.ScanBatches)
+	case "":
+		p := d.Fetch
+		fmt.Fprintf(w, "Input: %s, %d transactions", report.Name, p.Rows)
+		if report.OutOfRange > 0 {
+			fmt.Fprintf(w, ", %d more outside -from and -to", report.OutOfRange)
+		}
+		fmt.Fprintf(w, ", %d invalid or duplicate rows skipped\n", len(report.Skipped))
+		for i, s := range report.Skipped {
+			if i == dryRunSkippedShown {
+				fmt.Fprintf(w, "  ... and %d more\n", len(report.Skipped)-i)
+				break
+			}
+			fmt.Fprintf(w, "  %s:%d %q: %s\n", s.File, s.Line, s.Value, s.Reason)
+		}
+		fmt.Fprintf(w, "Receipts: %d from the cache, %d from the CSV, %d to fetch in %d batches\n",
+			p.Cached, p.CSV, p.Fetched, p.ReceiptBatches)
+		if p.TransactionBatches > 0 || p.HeaderBatches > 0 || p.FeeHistoryCalls > 0 {
+			fmt.Fprintf(w, "Up to %d batches of transactions, %d of block headers and %d fee history calls\n",
+				p.TransactionBatches, p.HeaderBatches, p.FeeHistoryCalls)
+		}
+	default:
+		fmt.Fprintf(w, "Scan: the transactions are listed through %s when the run starts\n", d.Scan)
+	}
+	if d.Latency == 0 {
+		return
+	}
+	limit := "no rate limit"
+	if rps > 0 {
+		limit = fmt.Sprintf("-rps %g", rps)
+	}
+	fmt.Fprintf(w, "RPC: about %d requests at %s each, concurrency %d and %s: about %s\n",
+		d.Calls(), d.Latency.Round(time.Microsecond), concurrency, limit, d.Duration.Round(time.Second))
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseDuneParams parses a comma-separated list of name=value pairs, e.g.
+// "batcher=0x<batcher address>,since=2024-06-01", into the parameters of a
+// Dune query.
+func parseDuneParams(list string) (map[string]string, error) {
+	params := make(map[string]string)
+	for _, pair := range strings.Split(list, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%q is not name=value", pair)
+		}
+		params[name] = strings.TrimSpace(value)
+	}
+	return params, nil
+}
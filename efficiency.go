@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+)
+
+// bytesPerMB is the size of the megabyte of the cost of data availability.
+const bytesPerMB = 1 << 20
+
+// gasPerL2Tx returns the L1 gas, calldata and blob, that r used per L2
+// transaction of the channels completed in it, and false without any.
+func gasPerL2Tx(r *aggregate.Result) (float64, bool) {
+	if r.L2Txs == 0 {
+		return 0, false
+	}
+	return float64(r.TotalCalldataGasUsed+r.TotalBlobGasUsed) / float64(r.L2Txs), true
+}
+
+// gasPerPostedByte returns the L1 gas that r used per byte posted, and false
+// when nothing was posted.
+func gasPerPostedByte(r *aggregate.Result) (float64, bool) {
+	if r.PostedBytes == 0 {
+		return 0, false
+	}
+	return float64(r.TotalCalldataGasUsed+r.TotalBlobGasUsed) / float64(r.PostedBytes), true
+}
+
+// ethPerMB returns the cost of r in ETH per MiB posted, and false when nothing
+// was posted.
+func ethPerMB(r *aggregate.Result) (*big.Float, bool) {
+	if r.PostedBytes == 0 {
+		return nil, false
+	}
+	eth := new(big.Float).Mul(r.Cost, big.NewFloat(bytesPerMB))
+	return eth.Quo(eth, new(big.Float).SetUint64(r.PostedBytes)), true
+}
+
+// efficiencyColumns returns the L2 transactions of the channels completed in
+// every bucket, the L1 gas per L2 transaction and per byte posted, and the
+// cost per MiB posted, which compare across periods of different activity.
+func efficiencyColumns(results map[string]*aggregate.Result) []output.Column {
+	txs := output.Column{Header: "L2 Txs", Values: make(map[string]string, len(results))}
+	perTx := output.Column{Header: "L1 Gas per L2 Tx", Values: make(map[string]string, len(results))}
+	perByte := output.Column{Header: "L1 Gas per Posted Byte", Values: make(map[string]string, len(results))}
+	perMB := output.Column{Header: "ETH per MB", Values: make(map[string]string, len(results))}
+	for k, r := range results {
+		txs.Values[k] = strconv.FormatUint(r.L2Txs, 10)
+		if v, ok := gasPerL2Tx(r); ok {
+			perTx.Values[k] = strconv.FormatFloat(v, 'f', 2, 64)
+		}
+		if v, ok := gasPerPostedByte(r); ok {
+			perByte.Values[k] = strconv.FormatFloat(v, 'f', 3, 64)
+		}
+		if v, ok := ethPerMB(r); ok {
+			perMB.Values[k] = r.BlobDependent(v)
+		}
+	}
+	return []output.Column{txs, perTx, perByte, perMB}
+}
+
+// printEfficiencySummary prints the normalized metrics of the whole report.
+func printEfficiencySummary(w io.Writer, total *aggregate.Result) {
+	fmt.Fprintf(w, "Efficiency: %d L2 transactions", total.L2Txs)
+	if v, ok := gasPerL2Tx(total); ok {
+		fmt.Fprintf(w, ", %.2f L1 gas per L2 transaction", v)
+	}
+	if v, ok := gasPerPostedByte(total); ok {
+		fmt.Fprintf(w, ", %.3f L1 gas per byte posted", v)
+	}
+	if v, ok := ethPerMB(total); ok {
+		fmt.Fprintf(w, ", %s ETH per MB", total.BlobDependent(v))
+	}
+	fmt.Fprintln(w)
+}
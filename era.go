@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/fetch"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+)
+
+// The eras of a bucket: before blobs, across the activation of Cancun, and
+// after.
+const (
+	eraCalldata   = "calldata"
+	eraTransition = "transition"
+	eraBlob       = "blob"
+)
+
+// bucketEras returns the era of every bucket of dates, in the zone loc, by the
+// activation of Cancun on the chain chainID. On chains without a known
+// activation, the first bucket with a blob transaction starts the blob era.
+// Daily reports also get the era of the days without transactions between
+// their buckets, so that their trends keep to an era across such gaps.
+func bucketEras(chainID uint64, granularity string, loc *time.Location, dates []string, results map[string]*aggregate.Result) (map[string]string, error) {
+	eras := make(map[string]string, len(dates))
+	cancun, ok := fetch.CancunTime(chainID)
+	era := eraCalldata
+	for _, k := range eraBuckets(granularity, dates) {
+		if !ok {
+			if r := results[k]; r != nil && r.BlobTxCount > 0 {
+				era = eraBlob
+			}
+			eras[k] = era
+			continue
+		}
+		start, end, err := bucketRange(k, granularity, loc)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case !end.After(cancun):
+			eras[k] = eraCalldata
+		case !start.Before(cancun):
+			eras[k] = eraBlob
+		default:
+			eras[k] = eraTransition
+		}
+	}
+	return eras, nil
+}
+
+// eraBuckets returns the buckets that bucketEras gives an era: dates, and for
+// a daily report every day from its first to its last.
+func eraBuckets(granularity string, dates []string) []string {
+	if granularity != "day" || len(dates) == 0 {
+		return dates
+	}
+	first, err := time.Parse(time.DateOnly, dates[0])
+	if err != nil {
+		return dates
+	}
+	last, err := time.Parse(time.DateOnly, dates[len(dates)-1])
+	if err != nil {
+		return dates
+	}
+	var days []string
+	for t := first; !t.After(last); t = t.AddDate(0, 0, 1) {
+		days = append(days, t.Format(time.DateOnly))
+	}
+	return days
+}
+
+// eraColumn returns the era of every bucket.
+func eraColumn(eras map[string]string) output.Column {
+	return output.Column{Header: "Era", Values: eras}
+}
+
+// printEraSummary prints the totals and averages of every era of the report
+// on their own, so that the costs of calldata and blob batches compare
+// without being averaged together.
+func printEraSummary(w io.Writer, dates []string, results map[string]*aggregate.Result, eras map[string]string) {
+	buckets := make(map[string]int)
+	for _, k := range dates {
+		buckets[eras[k]]++
+	}
+	_, merged := aggregate.Rollup(results, func(bucket string) string { return eras[bucket] })
+	for _, era := range []string{eraCalldata, eraTransition, eraBlob} {
+		r := merged[era]
+		if r == nil {
+			continue
+		}
+		avg := new(big.Float).Quo(r.Cost, big.NewFloat(float64(buckets[era])))
+		fmt.Fprintf(w, "Era %s: %d buckets, %d txs (%d blob), cost %s ETH, %s ETH per bucket, blended gas price %s Gwei\n",
+			era, buckets[era], r.TxCount, r.BlobTxCount, r.BlobDependent(r.Cost),
+			r.BlobDependent(avg), r.BlobDependent(r.BlendedGasPrice))
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/fetch"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+)
+
+// parseFiat parses a comma-separated list of fiat currencies into their
+// lowercase codes, in order and without duplicates, starting with usd when
+// usd is set.
+func parseFiat(list string, usd bool) []string {
+	var currencies []string
+	if usd {
+		currencies = append(currencies, "usd")
+	}
+	for _, c := range strings.Split(list, ",") {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if c != "" && !slices.Contains(currencies, c) {
+			currencies = append(currencies, c)
+		}
+	}
+	return currencies
+}
+
+// bucketPrices returns the price of coin in currency for every bucket of
+// dates, in the zone loc: the average of the daily closes of the UTC days
+// that the bucket spans. Buckets without a close are left out.
+func bucketPrices(ctx context.Context, c *fetch.PriceClient, coin, currency, granularity string, loc *time.Location, dates []string) (map[string]float64, error) {
+	if len(dates) == 0 {
+		return nil, nil
+	}
+	from, _, err := bucketRange(dates[0], granularity, loc)
+	if err != nil {
+		return nil, err
+	}
+	_, to, err := bucketRange(dates[len(dates)-1], granularity, loc)
+	if err != nil {
+		return nil, err
+	}
+	closes, err := c.DailyCloses(ctx, coin, currency, from, to.Add(-time.Nanosecond))
+	if err != nil {
+		return nil, err
+	}
+	prices := make(map[string]float64, len(dates))
+	for _, k := range dates {
+		start, end, err := bucketRange(k, granularity, loc)
+		if err != nil {
+			return nil, err
+		}
+		var sum float64
+		var n int
+		for day := start.UTC().Truncate(24 * time.Hour); day.Before(end); day = day.AddDate(0, 0, 1) {
+			if p, ok := closes[day.Format(time.DateOnly)]; ok {
+				sum += p
+				n++
+			}
+		}
+		if n > 0 {
+			prices[k] = sum / float64(n)
+		}
+	}
+	return prices, nil
+}
+
+// tonPerEth returns the TON that an ETH bought in every bucket of dates, from
+// the daily ETH price of coin, the id of TON at the price API.
+func tonPerEth(ctx context.Context, c *fetch.PriceClient, coin, granularity string, loc *time.Location, dates []string) (map[string]float64, error) {
+	prices, err := bucketPrices(ctx, c, coin, "eth", granularity, loc, dates)
+	if err != nil {
+		return nil, err
+	}
+	for k, p := range prices {
+		if p <= 0 {
+			delete(prices, k)
+			continue
+		}
+		prices[k] = 1 / p
+	}
+	return prices, nil
+}
+
+// convertedCosts returns the cost of every bucket of results at its price,
+// and their sum, which is nil when a bucket has no price.
+func convertedCosts(results map[string]*aggregate.Result, prices map[string]float64) (map[string]*big.Float, *big.Float) {
+	costs := make(map[string]*big.Float, len(results))
+	total := new(big.Float)
+	for k, r := range results {
+		p, ok := prices[k]
+		if !ok {
+			total = nil
+			continue
+		}
+		costs[k] = new(big.Float).Mul(r.Cost, big.NewFloat(p))
+		if total != nil {
+			total.Add(total, costs[k])
+		}
+	}
+	return costs, total
+}
+
+// fiatColumn returns the cost of every bucket converted at its price into
+// unit, a fiat currency or TON, empty for buckets without a price.
+func fiatColumn(unit string, results map[string]*aggregate.Result, prices map[string]float64) output.Column {
+	c := output.Column{Header: "Total Cost (" + strings.ToUpper(unit) + ")", Values: make(map[string]string, len(results))}
+	costs, _ := convertedCosts(results, prices)
+	for k, cost := range costs {
+		c.Values[k] = results[k].BlobDependent(cost)
+	}
+	return c
+}
+
+// printFiatSummary prints the cost of the report converted at the price of
+// every bucket into unit.
+func printFiatSummary(w io.Writer, unit string, results map[string]*aggregate.Result, total *aggregate.Result, prices map[string]float64) {
+	_, sum := convertedCosts(results, prices)
+	if sum == nil {
+		fmt.Fprintf(w, "Total cost: %s %s, some buckets have no price\n", aggregate.Unavailable, strings.ToUpper(unit))
+		return
+	}
+	fmt.Fprintf(w, "Total cost: %s %s\n", total.BlobDependent(sum), strings.ToUpper(unit))
+}
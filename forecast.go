@@ -0,0 +1,211 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/forecast"
+)
+
+// runForecast projects the posting cost of the coming days from the gas
+// prices and the gas usage of a daily report written by analyze.
+func runForecast(args []string) error {
+	fs := flag.NewFlagSet("forecast", flag.ContinueOnError)
+	outDir := fs.String("out", "./outputs", "directory searched for the latest report when no report file is given")
+	window := fs.Int("window", 28, "number of trailing days of the report that are fitted")
+	alpha := fs.Float64("alpha", 0.3, "weight of the latest day in the moving average of the gas prices, between 0 and 1")
+	seasonal := fs.Bool("seasonal", false, "scale the projected prices by day of the week, as they varied over -window")
+	horizon := fs.Int("horizon", 30, "number of days projected")
+	calldataGas := fs.Uint64("calldata-gas", 0, "daily calldata gas used (default: the average over -window)")
+	blobGas := fs.Uint64("blob-gas", 0, "daily blob gas used (default: the average over -window)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s forecast [flags] [output.csv]\n\nFlags:\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if *window < 1 {
+		return errors.New("-window must be at least 1")
+	}
+	if *horizon < 1 {
+		return errors.New("-horizon must be at least 1")
+	}
+
+	var path string
+	switch fs.NArg() {
+	case 0:
+		matches, err := filepath.Glob(filepath.Join(*outDir, "output-*.csv"))
+		if err != nil {
+			return err
+		}
+		if len(matches) == 0 {
+			return fmt.Errorf("no reports in %s", *outDir)
+		}
+		// The latest report is the one the next days follow.
+		slices.SortFunc(matches, func(a, b string) int { return modTime(a).Compare(modTime(b)) })
+		path = matches[len(matches)-1]
+	case 1:
+		path = fs.Arg(0)
+	default:
+		return errors.New("forecast takes a single report")
+	}
+	records, err := readReport(path)
+	if err != nil {
+		return err
+	}
+	history, err := dailyHistory(path, records)
+	if err != nil {
+		return err
+	}
+	if len(history) > *window {
+		history = history[len(history)-*window:]
+	}
+
+	model := forecast.Model{Alpha: *alpha, Seasonal: *seasonal}
+	calldata, err := model.Fit(history.prices(func(d historyDay) float64 { return d.calldataPrice }))
+	if err != nil {
+		return fmt.Errorf("calldata gas price: %w", err)
+	}
+	blob, err := model.Fit(history.prices(func(d historyDay) float64 { return d.blobPrice }))
+	if errors.Is(err, forecast.ErrNoHistory) {
+		// A chain posting calldata only.
+		blob, err = forecast.Fit{Factors: calldata.Factors}, nil
+	}
+	if err != nil {
+		return fmt.Errorf("blob gas price: %w", err)
+	}
+	if *calldataGas == 0 {
+		*calldataGas = history.meanGas(func(d historyDay) uint64 { return d.calldataGas })
+	}
+	if *blobGas == 0 {
+		*blobGas = history.meanGas(func(d historyDay) uint64 { return d.blobGas })
+	}
+
+	fmt.Printf("%s: %d days fitted, %d calldata gas and %d blob gas per day\n", path, len(history), *calldataGas, *blobGas)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "Date\tCalldata gas price(Gwei)\tBlob gas price(Gwei)\tCost(ETH)\tCumulative Cost(ETH)\t")
+	last := history[len(history)-1].date
+	var total, week float64
+	for i := 1; i <= *horizon; i++ {
+		day := last.AddDate(0, 0, i)
+		c, b := calldata.Price(day), blob.Price(day)
+		cost := (c*float64(*calldataGas) + b*float64(*blobGas)) / 1e9
+		total += cost
+		if i <= 7 {
+			week = total
+		}
+		fmt.Fprintf(w, "%s\t%.4g\t%.4g\t%.6f\t%.6f\t\n", day.Format(time.DateOnly), c, b, cost, total)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("Projected cost: %.6f ETH over the next %d days, %.6f ETH over the next %d\n", week, min(7, *horizon), total, *horizon)
+	return nil
+}
+
+// modTime returns the modification time of the file at path, zero if it
+// cannot be read.
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// historyDay is a day of a report: its average gas prices in Gwei, NaN when
+// unavailable, and its gas used.
+type historyDay struct {
+	date          time.Time
+	calldataPrice float64
+	blobPrice     float64
+	calldataGas   uint64
+	blobGas       uint64
+}
+
+type history []historyDay
+
+// dailyHistory reads the days of the report at path from its records, oldest
+// first.
+func dailyHistory(path string, records [][]string) (history, error) {
+	columns := make(map[string]int)
+	for i, header := range records[0] {
+		columns[header] = i
+	}
+	for _, header := range []string{"Avg Calldata gas price(Gwei)", "Avg Blob Gas Price(Gwei)", "Total Calldata Gas Used", "Total Blob Gas Used"} {
+		if _, ok := columns[header]; !ok {
+			return nil, fmt.Errorf("%s: no %q column", path, header)
+		}
+	}
+	value := func(record []string, header string) string {
+		if i := columns[header]; i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+	price := func(record []string, header string) float64 {
+		v, err := strconv.ParseFloat(value(record, header), 64)
+		if err != nil {
+			return math.NaN()
+		}
+		return v
+	}
+	gas := func(record []string, header string) uint64 {
+		v, _ := strconv.ParseUint(value(record, header), 10, 64)
+		return v
+	}
+	var days history
+	for _, record := range records[1:] {
+		date, err := time.Parse(time.DateOnly, record[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s: forecast needs a report of -granularity day, not bucket %q", path, record[0])
+		}
+		d := historyDay{
+			date:          date,
+			calldataPrice: price(record, "Avg Calldata gas price(Gwei)"),
+			blobPrice:     price(record, "Avg Blob Gas Price(Gwei)"),
+			calldataGas:   gas(record, "Total Calldata Gas Used"),
+			blobGas:       gas(record, "Total Blob Gas Used"),
+		}
+		// Days without blobs have no blob gas price to fit.
+		if d.blobGas == 0 {
+			d.blobPrice = math.NaN()
+		}
+		days = append(days, d)
+	}
+	if len(days) == 0 {
+		return nil, fmt.Errorf("%s: no days", path)
+	}
+	// Older reports are not sorted.
+	slices.SortFunc(days, func(a, b historyDay) int { return a.date.Compare(b.date) })
+	return days, nil
+}
+
+// prices returns the prices that price picks from the days.
+func (h history) prices(price func(historyDay) float64) []forecast.Day {
+	days := make([]forecast.Day, len(h))
+	for i, d := range h {
+		days[i] = forecast.Day{Date: d.date, Price: price(d)}
+	}
+	return days
+}
+
+// meanGas returns the average over the days of the gas that gas picks. Days
+// missing from the report count as days without gas.
+func (h history) meanGas(gas func(historyDay) uint64) uint64 {
+	var sum uint64
+	for _, d := range h {
+		sum += gas(d)
+	}
+	days := uint64(h[len(h)-1].date.Sub(h[0].date)/(24*time.Hour)) + 1
+	return sum / days
+}
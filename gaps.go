@@ -0,0 +1,50 @@
+package main
+
+import (
+	"time"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/tracker"
+)
+
+// fillGaps adds empty buckets to report for the buckets without transactions
+// between its first and last ones, extended to from and to when they are not
+// zero, so that the report has a continuous time axis. It returns the number
+// of buckets added.
+func fillGaps(report *tracker.Report, granularity string, loc *time.Location, from, to time.Time) (int, error) {
+	if len(report.Dates) == 0 && (from.IsZero() || to.IsZero()) {
+		return 0, nil
+	}
+	start, end := from, to
+	if len(report.Dates) > 0 {
+		first, _, err := bucketRange(report.Dates[0], granularity, loc)
+		if err != nil {
+			return 0, err
+		}
+		_, last, err := bucketRange(report.Dates[len(report.Dates)-1], granularity, loc)
+		if err != nil {
+			return 0, err
+		}
+		if start.IsZero() || first.Before(start) {
+			start = first
+		}
+		if end.IsZero() || last.After(end) {
+			end = last
+		}
+	}
+	added := 0
+	for t := start; t.Before(end); {
+		k := aggregate.BucketKey(t.In(loc), granularity)
+		if report.Results[k] == nil {
+			report.Results[k] = aggregate.NewResult()
+			added++
+		}
+		_, next, err := bucketRange(k, granularity, loc)
+		if err != nil {
+			return 0, err
+		}
+		t = next
+	}
+	report.Dates = aggregate.SortedKeys(report.Results)
+	return added, nil
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/sink"
+)
+
+// uint64Type holds gas amounts and counts, which overflow the 32-bit Int of
+// GraphQL over a few weeks.
+var uint64Type = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "Uint64",
+	Description: "An unsigned 64-bit integer.",
+	Serialize: func(value any) any {
+		if f, ok := value.(float64); ok && f >= 0 && f <= math.MaxUint64 {
+			return uint64(f)
+		}
+		return nil
+	},
+	ParseValue:   func(value any) any { return nil },
+	ParseLiteral: func(value ast.Value) any { return nil },
+})
+
+type fieldDef struct {
+	name string
+	typ  graphql.Output
+}
+
+// objectType returns an object whose fields are those of the JSON objects of
+// the REST API, resolved from them by name.
+func objectType(name string, fields ...fieldDef) *graphql.Object {
+	f := make(graphql.Fields, len(fields))
+	for _, field := range fields {
+		f[field.name] = &graphql.Field{Type: field.typ}
+	}
+	return graphql.NewObject(graphql.ObjectConfig{Name: name, Fields: f})
+}
+
+// newGraphQLSchema returns the schema of the /graphql endpoint, with the
+// queries of the REST API:
+//
+//	days(from: String, to: String): [Day!]!
+//	summary(from: String, to: String): Day!
+//	transaction(hash: String!): Transaction
+//
+// Values that depend on a missing blob gas price are null.
+func newGraphQLSchema(store *sink.Store) (graphql.Schema, error) {
+	str, float := graphql.String, graphql.Float
+	nonNull := func(t graphql.Output) graphql.Output { return graphql.NewNonNull(t) }
+	day := objectType("Day",
+		fieldDef{"bucket", nonNull(str)},
+		fieldDef{"costWei", str}, fieldDef{"costEth", float},
+		fieldDef{"calldataCostWei", nonNull(str)}, fieldDef{"calldataCostEth", nonNull(float)},
+		fieldDef{"blobCostWei", str}, fieldDef{"blobCostEth", float},
+		fieldDef{"avgCalldataGasPriceWei", nonNull(str)}, fieldDef{"avgCalldataGasPriceGwei", nonNull(float)},
+		fieldDef{"avgBlobGasPriceWei", str}, fieldDef{"avgBlobGasPriceGwei", float},
+		fieldDef{"blendedGasPriceWei", str}, fieldDef{"blendedGasPriceGwei", float},
+		fieldDef{"calldataGasUsed", nonNull(uint64Type)}, fieldDef{"blobGasUsed", nonNull(uint64Type)},
+		fieldDef{"gasUsed", nonNull(uint64Type)}, fieldDef{"txCount", nonNull(uint64Type)},
+		fieldDef{"blobPriceMissing", nonNull(uint64Type)},
+	)
+	transaction := objectType("Transaction",
+		fieldDef{"hash", nonNull(str)}, fieldDef{"time", nonNull(str)},
+		fieldDef{"block", nonNull(uint64Type)}, fieldDef{"bucket", nonNull(str)},
+		fieldDef{"type", nonNull(graphql.Int)}, fieldDef{"gasUsed", nonNull(uint64Type)},
+		fieldDef{"gasPriceWei", nonNull(str)}, fieldDef{"blobGasUsed", nonNull(uint64Type)},
+		fieldDef{"blobGasPriceWei", str}, fieldDef{"costWei", nonNull(str)},
+		fieldDef{"costEth", nonNull(float)}, fieldDef{"calldataCostWei", nonNull(str)},
+		fieldDef{"blobCostWei", nonNull(str)},
+	)
+
+	dayRange := graphql.FieldConfigArgument{
+		"from": &graphql.ArgumentConfig{Type: str, Description: "first day, YYYY-MM-DD"},
+		"to":   &graphql.ArgumentConfig{Type: str, Description: "last day, YYYY-MM-DD"},
+	}
+	days := func(p graphql.ResolveParams) ([]string, map[string]*aggregate.Result, error) {
+		from, _ := p.Args["from"].(string)
+		to, _ := p.Args["to"].(string)
+		return store.Days(p.Context, from, to)
+	}
+	query := graphql.NewObject(graphql.ObjectConfig{Name: "Query", Fields: graphql.Fields{
+		"days": &graphql.Field{
+			Type: nonNull(graphql.NewList(nonNull(day))),
+			Args: dayRange,
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				dates, results, err := days(p)
+				if err != nil {
+					return nil, err
+				}
+				list := make([]any, 0, len(dates))
+				for _, k := range dates {
+					v, err := jsonObject(output.EncodeBucket(k, results[k]))
+					if err != nil {
+						return nil, err
+					}
+					list = append(list, v)
+				}
+				return list, nil
+			},
+		},
+		"summary": &graphql.Field{
+			Type: nonNull(day),
+			Args: dayRange,
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				dates, results, err := days(p)
+				if err != nil {
+					return nil, err
+				}
+				period, total := summarize(dates, results)
+				return jsonObject(output.EncodeBucket(period, total))
+			},
+		},
+		"transaction": &graphql.Field{
+			Type: transaction,
+			Args: graphql.FieldConfigArgument{
+				"hash": &graphql.ArgumentConfig{Type: graphql.NewNonNull(str)},
+			},
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				hash, ok := parseTxHash(p.Args["hash"].(string))
+				if !ok {
+					return nil, errors.New("invalid transaction hash")
+				}
+				tx, err := store.Tx(p.Context, hash)
+				if errors.Is(err, sink.ErrNotFound) {
+					return nil, nil
+				}
+				if err != nil {
+					return nil, err
+				}
+				return jsonObject(output.EncodeTx(tx))
+			},
+		},
+	}})
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
+
+// jsonObject decodes an object of the REST API for the default resolvers.
+func jsonObject(data []byte, err error) (map[string]any, error) {
+	if err != nil {
+		return nil, err
+	}
+	var v map[string]any
+	return v, json.Unmarshal(data, &v)
+}
+
+// graphqlHandler executes the queries of GET requests, in the query
+// parameters, and of POST requests, in a JSON body, as is customary.
+func graphqlHandler(schema graphql.Schema) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query         string         `json:"query"`
+			OperationName string         `json:"operationName"`
+			Variables     map[string]any `json:"variables"`
+		}
+		switch r.Method {
+		case http.MethodGet:
+			q := r.URL.Query()
+			req.Query, req.OperationName = q.Get("query"), q.Get("operationName")
+			if v := q.Get("variables"); v != "" {
+				if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+					http.Error(w, "invalid variables: "+err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
+		case http.MethodPost:
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+				http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		result := graphql.Do(graphql.Params{
+			Schema:         schema,
+			RequestString:  req.Query,
+			OperationName:  req.OperationName,
+			VariableValues: req.Variables,
+			Context:        r.Context(),
+		})
+		w.Header().Set("Content-Type", "application/json")
+		writeJSON(w, result)
+	}
+}
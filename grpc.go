@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/gastrackerpb"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/sink"
+)
+
+// watchInterval is how often WatchDays looks for updated days.
+const watchInterval = 5 * time.Second
+
+// grpcServer implements gastrackerpb.GasTrackerServer over the same store as
+// the REST API.
+type grpcServer struct {
+	gastrackerpb.UnimplementedGasTrackerServer
+	store *sink.Store
+}
+
+// newGRPCServer returns a server of the GasTracker service, with reflection
+// so that tools like grpcurl can list it.
+func newGRPCServer(store *sink.Store) *grpc.Server {
+	server := grpc.NewServer()
+	gastrackerpb.RegisterGasTrackerServer(server, &grpcServer{store: store})
+	reflection.Register(server)
+	return server
+}
+
+func (s *grpcServer) ListDays(ctx context.Context, req *gastrackerpb.ListDaysRequest) (*gastrackerpb.ListDaysResponse, error) {
+	dates, results, err := s.store.Days(ctx, req.From, req.To)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	resp := &gastrackerpb.ListDaysResponse{Days: make([]*gastrackerpb.Bucket, 0, len(dates))}
+	for _, k := range dates {
+		day, err := bucketMessage(k, results[k])
+		if err != nil {
+			return nil, grpcError(err)
+		}
+		resp.Days = append(resp.Days, day)
+	}
+	return resp, nil
+}
+
+func (s *grpcServer) GetSummary(ctx context.Context, req *gastrackerpb.GetSummaryRequest) (*gastrackerpb.Bucket, error) {
+	dates, results, err := s.store.Days(ctx, req.From, req.To)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	period, total := summarize(dates, results)
+	summary, err := bucketMessage(period, total)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return summary, nil
+}
+
+func (s *grpcServer) GetTransaction(ctx context.Context, req *gastrackerpb.GetTransactionRequest) (*gastrackerpb.Transaction, error) {
+	hash, ok := parseTxHash(req.Hash)
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, "invalid transaction hash")
+	}
+	tx, err := s.store.Tx(ctx, hash)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	data, err := output.EncodeTx(tx)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	msg := new(gastrackerpb.Transaction)
+	if err := protojson.Unmarshal(data, msg); err != nil {
+		return nil, grpcError(err)
+	}
+	return msg, nil
+}
+
+// WatchDays polls the store for days written since they were last sent.
+// Runs rewrite the aggregate of every day they touch, so a day
+// is sent again on each of its updates.
+func (s *grpcServer) WatchDays(req *gastrackerpb.WatchDaysRequest, stream gastrackerpb.GasTracker_WatchDaysServer) error {
+	ctx := stream.Context()
+	sent := make(map[string]time.Time)
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		updated, err := s.store.UpdatedAt(ctx, req.From)
+		if err != nil {
+			return grpcError(err)
+		}
+		var first, last string
+		for day, t := range updated {
+			if t.Equal(sent[day]) {
+				continue
+			}
+			if first == "" || day < first {
+				first = day
+			}
+			if day > last {
+				last = day
+			}
+		}
+		if first != "" {
+			dates, results, err := s.store.Days(ctx, first, last)
+			if err != nil {
+				return grpcError(err)
+			}
+			for _, k := range dates {
+				if t, ok := updated[k]; !ok || t.Equal(sent[k]) {
+					continue
+				}
+				day, err := bucketMessage(k, results[k])
+				if err != nil {
+					return grpcError(err)
+				}
+				if err := stream.Send(day); err != nil {
+					return err
+				}
+				sent[k] = updated[k]
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// bucketMessage converts a bucket through its JSON Lines object, which the
+// message mirrors, so that both APIs return the same values.
+func bucketMessage(key string, r *aggregate.Result) (*gastrackerpb.Bucket, error) {
+	data, err := output.EncodeBucket(key, r)
+	if err != nil {
+		return nil, err
+	}
+	msg := new(gastrackerpb.Bucket)
+	if err := protojson.Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// grpcError maps the errors of the store to status codes, like apiError.
+func grpcError(err error) error {
+	switch {
+	case errors.Is(err, sink.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, sink.ErrInvalidDay):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	}
+	slog.Error("grpc request failed", "err", err)
+	return status.Error(codes.Internal, err.Error())
+}
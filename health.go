@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// daemonHealth follows the progress of the daemon for its /healthz and
+// /readyz endpoints.
+type daemonHealth struct {
+	maxLag     uint64        // blocks behind the head before not ready
+	maxHeadAge time.Duration // time without a new head before not ready
+
+	mu      sync.Mutex
+	started time.Time
+	head    uint64
+	headAt  time.Time
+	block   uint64
+	blockAt time.Time
+	err     error
+	errAt   time.Time
+}
+
+// healthStatus is the JSON body of /healthz and /readyz.
+type healthStatus struct {
+	Status        string     `json:"status"`
+	Reasons       []string   `json:"reasons,omitempty"`
+	Uptime        string     `json:"uptime"`
+	Head          *uint64    `json:"head"`
+	HeadAt        *time.Time `json:"headAt"`
+	LastBlock     *uint64    `json:"lastBlock"`
+	LastBlockAt   *time.Time `json:"lastBlockAt"`
+	LagBlocks     *uint64    `json:"lagBlocks"`
+	LastError     string     `json:"lastError,omitempty"`
+	LastErrorAt   *time.Time `json:"lastErrorAt,omitempty"`
+	RPCConnection string     `json:"rpc"`
+}
+
+func newDaemonHealth(maxLag uint64, maxHeadAge time.Duration) *daemonHealth {
+	return &daemonHealth{maxLag: maxLag, maxHeadAge: maxHeadAge, started: time.Now()}
+}
+
+// Head records a new head, which the RPC endpoints answered.
+func (h *daemonHealth) Head(number uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.head, h.headAt = number, time.Now()
+}
+
+// Block records that the blocks up to number were scanned and stored.
+func (h *daemonHealth) Block(number uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.block, h.blockAt = number, time.Now()
+}
+
+// Error records a failed scan or header.
+func (h *daemonHealth) Error(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.err, h.errAt = err, time.Now()
+}
+
+// status returns the state of the daemon at now, and whether it is ready: a
+// head arrived within maxHeadAge, and the blocks up to maxLag blocks behind it
+// are stored.
+func (h *daemonHealth) status(now time.Time) (healthStatus, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := healthStatus{Uptime: now.Sub(h.started).Round(time.Second).String(), RPCConnection: "ok"}
+	// The body is encoded after the lock is released, so it points to copies.
+	head, headAt, block, blockAt, errAt := h.head, h.headAt, h.block, h.blockAt, h.errAt
+	if !headAt.IsZero() {
+		s.Head, s.HeadAt = &head, &headAt
+	}
+	if !blockAt.IsZero() {
+		s.LastBlock, s.LastBlockAt = &block, &blockAt
+	}
+	if s.Head != nil && s.LastBlock != nil {
+		lag := head - min(block, head)
+		s.LagBlocks = &lag
+	}
+	if h.err != nil {
+		s.LastError, s.LastErrorAt = h.err.Error(), &errAt
+	}
+
+	since := h.headAt
+	if since.IsZero() {
+		since = h.started
+	}
+	if age := now.Sub(since); age > h.maxHeadAge {
+		s.RPCConnection = "stale"
+		s.Reasons = append(s.Reasons, fmt.Sprintf("no new head for %s", age.Round(time.Second)))
+	}
+	switch {
+	case s.LastBlock == nil:
+		s.Reasons = append(s.Reasons, "no block scanned yet")
+	case s.LagBlocks != nil && *s.LagBlocks > h.maxLag:
+		s.Reasons = append(s.Reasons, fmt.Sprintf("%d blocks behind the head", *s.LagBlocks))
+	}
+	s.Status = "ok"
+	if len(s.Reasons) > 0 {
+		s.Status = "not ready"
+	}
+	return s, len(s.Reasons) == 0
+}
+
+// handler serves /healthz, which answers 200 while the daemon runs, and
+// /readyz, which answers 503 while it is not ready. Both describe the state
+// in JSON.
+func (h *daemonHealth) handler() http.Handler {
+	mux := http.NewServeMux()
+	write := func(w http.ResponseWriter, code int, s healthStatus) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(s)
+	}
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		s, _ := h.status(time.Now())
+		s.Status, s.Reasons = "ok", nil
+		write(w, http.StatusOK, s)
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		s, ready := h.status(time.Now())
+		code := http.StatusOK
+		if !ready {
+			code = http.StatusServiceUnavailable
+		}
+		write(w, code, s)
+	})
+	return mux
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/heatmap"
+)
+
+// printHeatmapSummary prints the hours of the week with the lowest average
+// calldata and blob gas prices.
+func printHeatmapSummary(w io.Writer, h *heatmap.Heatmap) {
+	calldata, blob, calldataOK, blobOK := h.Cheapest()
+	if !calldataOK {
+		fmt.Fprintln(w, "Cheapest hours: no transactions")
+		return
+	}
+	fmt.Fprintf(w, "Cheapest hours: calldata %s", calldata)
+	if blobOK {
+		fmt.Fprintf(w, ", blob %s", blob)
+	}
+	fmt.Fprintln(w)
+}
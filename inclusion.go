@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+)
+
+// inclusionColumns returns the average and 95th percentile inclusion delay of
+// every bucket and its correlation with the priority tip paid, empty for
+// buckets without submission times.
+func inclusionColumns(results map[string]*aggregate.Result) []output.Column {
+	mean := output.Column{Header: "Avg Inclusion Delay(s)", Values: make(map[string]string, len(results))}
+	p95 := output.Column{Header: "P95 Inclusion Delay(s)", Values: make(map[string]string, len(results))}
+	corr := output.Column{Header: "Delay-Tip Correlation", Values: make(map[string]string, len(results))}
+	for k, r := range results {
+		if v, ok := r.InclusionDelays.Mean(); ok {
+			mean.Values[k] = strconv.FormatFloat(v, 'f', 1, 64)
+		}
+		if v, ok := r.InclusionDelays.Quantile(95); ok {
+			p95.Values[k] = strconv.FormatFloat(v, 'f', 1, 64)
+		}
+		if v, ok := r.DelayTip.Correlation(); ok {
+			corr.Values[k] = strconv.FormatFloat(v, 'f', 3, 64)
+		}
+	}
+	return []output.Column{mean, p95, corr}
+}
+
+// printInclusionSummary prints the inclusion delays over the whole report and
+// their correlation with the priority tip.
+func printInclusionSummary(w io.Writer, total *aggregate.Result) {
+	mean, ok := total.InclusionDelays.Mean()
+	if !ok {
+		fmt.Fprintln(w, "Inclusion delay: no submission times in the input")
+		return
+	}
+	p95, _ := total.InclusionDelays.Quantile(95)
+	fmt.Fprintf(w, "Inclusion delay: %d txs, average %.1fs, p95 %.1fs", total.InclusionDelays.Count, mean, p95)
+	if v, ok := total.DelayTip.Correlation(); ok {
+		fmt.Fprintf(w, ", correlation with the tip %.3f", v)
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// job is an entry of the jobs section of the config file.
+type job struct {
+	name  string
+	cmd   string   // analyze, scan or backfill
+	args  []string // flags of the entry, before the command line
+	cache string   // receipt cache the job opens, if any
+}
+
+// jobResult is the outcome of a job.
+type jobResult struct {
+	err      error
+	duration time.Duration
+}
+
+// runJobs runs the jobs of the jobs section of the -config file
+// concurrently, each with the top-level settings overridden by its own and
+// writing its reports to a directory of its own. Jobs sharing a receipt
+// cache run one after another, since a cache is opened by one run at a time.
+// Every job logs with a job attribute, and its summary is printed in one
+// block once it is done.
+func runJobs(args []string) error {
+	fs := flag.NewFlagSet("jobs", flag.ContinueOnError)
+	outDir := fs.String("out", "./outputs", "directory holding the directories of the jobs, named after them, for the jobs without an out setting")
+	only := fs.String("only", "", "comma-separated jobs to run (default: all of them)")
+	parallel := fs.Int("parallel", 0, "jobs running at the same time (0: all of them)")
+	otlpEndpoint := fs.String("otlp-endpoint", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "OTLP/HTTP collector receiving the traces and metrics of all the jobs (env OTEL_EXPORTER_OTLP_ENDPOINT)")
+	otlpInterval := fs.Duration("otlp-interval", 15*time.Second, "how often to export the metrics to -otlp-endpoint")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s jobs -config file [flags] [-- flags of every job]\n\nFlags:\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	path := fs.Lookup("config").Value.String()
+	if path == "" {
+		return errors.New("jobs needs a -config file with a jobs section")
+	}
+	jobs, err := loadJobs(path, *only, *outDir, fs.Args())
+	if err != nil {
+		return err
+	}
+
+	// The telemetry is global to the process, so that the jobs share it.
+	stopTelemetry, err := startTelemetry(*otlpEndpoint, "batcher-gas-tracker", *otlpInterval)
+	if err != nil {
+		return err
+	}
+	defer stopTelemetry()
+
+	// Jobs of one cache form a group, run in order.
+	var groups [][]job
+	byCache := make(map[string]int)
+	for _, j := range jobs {
+		if i, ok := byCache[j.cache]; ok && j.cache != "" {
+			groups[i] = append(groups[i], j)
+			continue
+		}
+		byCache[j.cache] = len(groups)
+		groups = append(groups, []job{j})
+	}
+	slots := len(jobs)
+	if *parallel > 0 {
+		slots = *parallel
+	}
+	slog.Info("running jobs", "jobs", jobNames(jobs), "parallel", slots)
+	sem := make(chan struct{}, slots)
+	results := make(map[string]jobResult, len(jobs))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, group := range groups {
+		wg.Add(1)
+		go func(group []job) {
+			defer wg.Done()
+			for _, j := range group {
+				sem <- struct{}{}
+				slog.Info("running job", "job", j.name, "command", j.cmd)
+				start := time.Now()
+				var out bytes.Buffer
+				err := runAnalyze(j.cmd, j.args, console{job: j.name, stdout: &out}, nil)
+				<-sem
+				if errors.Is(err, flag.ErrHelp) {
+					err = errors.New("invalid settings")
+				}
+				mu.Lock()
+				results[j.name] = jobResult{err: err, duration: time.Since(start)}
+				if out.Len() > 0 {
+					fmt.Printf("job %s:\n%s", j.name, out.Bytes())
+				}
+				mu.Unlock()
+			}
+		}(group)
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, j := range jobs {
+		r := results[j.name]
+		if r.err != nil {
+			fmt.Printf("job %s: failed after %s: %v\n", j.name, r.duration.Round(time.Second), r.err)
+			errs = append(errs, fmt.Errorf("job %s: %w", j.name, r.err))
+			continue
+		}
+		fmt.Printf("job %s: done in %s\n", j.name, r.duration.Round(time.Second))
+	}
+	return errors.Join(errs...)
+}
+
+// loadJobs returns the jobs of the jobs section of the config file at path,
+// the comma-separated only or all of them, in the order of their names. The
+// arguments of a job are its settings, its -out under outDir unless it sets
+// one, and extra, which override them.
+func loadJobs(path, only, outDir string, extra []string) ([]job, error) {
+	sections, err := loadSections(path, "jobs")
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	if only != "" {
+		if names, err = sectionNames(path, "jobs", only, sections); err != nil {
+			return nil, fmt.Errorf("-only: %w", err)
+		}
+	} else {
+		for name := range sections {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%s: no jobs to run", path)
+	}
+	top, err := loadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]job, 0, len(names))
+	for _, name := range names {
+		values := sections[name]
+		j := job{name: name, cmd: "analyze"}
+		if cmd, ok := values["command"]; ok {
+			switch cmd {
+			case "analyze", "scan", "backfill":
+				j.cmd = cmd
+			default:
+				return nil, fmt.Errorf("%s: jobs: %s: command %q is not analyze, scan or backfill", path, name, cmd)
+			}
+			delete(values, "command")
+		}
+		if _, ok := values["out"]; !ok {
+			values["out"] = filepath.Join(outDir, name)
+		}
+		// The cache of a job is its own setting, or else the one of the
+		// environment or the top of the file, as in applyConfig.
+		j.cache = values["cache"]
+		if _, ok := values["cache"]; !ok {
+			if j.cache = os.Getenv(flagEnv["cache"]); j.cache == "" {
+				j.cache = top["cache"]
+			}
+		}
+		if j.cache != "" {
+			j.cache = filepath.Clean(j.cache)
+		}
+
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		// The top-level settings apply through -config, beneath those of
+		// the job. The jobs share the telemetry of the jobs command.
+		j.args = []string{"-config=" + path}
+		for _, key := range keys {
+			j.args = append(j.args, "-"+key+"="+values[key])
+		}
+		j.args = append(append(j.args, "-otlp-endpoint="), extra...)
+		jobs = append(jobs, j)
+	}
+	return jobs, nil
+}
+
+// jobNames returns the names of jobs, for messages.
+func jobNames(jobs []job) string {
+	names := make([]string, len(jobs))
+	for i, j := range jobs {
+		names[i] = j.name
+	}
+	return strings.Join(names, ",")
+}
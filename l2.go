@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/fetch"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+)
+
+// slotDuration is the time between two L1 blocks since the Merge.
+const slotDuration = 12 * time.Second
+
+// l2Activity sums the L2 blocks that the scanner s of an L2 RPC finds over
+// the time of every bucket of dates, in the zone loc.
+func l2Activity(ctx context.Context, s *fetch.Scanner, granularity string, loc *time.Location, dates []string, log *slog.Logger) (map[string]fetch.Activity, error) {
+	activity := make(map[string]fetch.Activity, len(dates))
+	for _, k := range dates {
+		start, end, err := bucketRange(k, granularity, loc)
+		if err != nil {
+			return nil, err
+		}
+		if activity[k], err = s.Activity(ctx, start, end); err != nil {
+			return nil, fmt.Errorf("bucket %s: %w", k, err)
+		}
+		log.Debug("read L2 activity", "bucket", k, "blocks", activity[k].Blocks, "txs", activity[k].Txs)
+	}
+	return activity, nil
+}
+
+// l2Revenue reads the fees that the fee vaults of the L2 chain of the scanner
+// s collected over the time of every bucket of dates, in the zone loc.
+func l2Revenue(ctx context.Context, s *fetch.Scanner, granularity string, loc *time.Location, dates []string) (map[string]fetch.Revenue, error) {
+	revenue := make(map[string]fetch.Revenue, len(dates))
+	for _, k := range dates {
+		start, end, err := bucketRange(k, granularity, loc)
+		if err != nil {
+			return nil, err
+		}
+		if revenue[k], err = s.Revenue(ctx, start, end); err != nil {
+			return nil, fmt.Errorf("bucket %s: %w", k, err)
+		}
+	}
+	return revenue, nil
+}
+
+// bucketRange returns the start of the bucket with key k and the start of the
+// next one. Bucket keys are wall-clock times of loc. A per-block bucket spans
+// the slot of its block.
+func bucketRange(k, granularity string, loc *time.Location) (time.Time, time.Time, error) {
+	start, err := aggregate.BucketStart(k)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if granularity == "block" {
+		start = time.Date(start.Year(), start.Month(), start.Day(), start.Hour(), start.Minute(), start.Second(), 0, loc)
+		return start, start.Add(slotDuration), nil
+	}
+	start = time.Date(start.Year(), start.Month(), start.Day(), start.Hour(), 0, 0, 0, loc)
+	switch granularity {
+	case "hour":
+		return start, start.Add(time.Hour), nil
+	case "week":
+		return start, start.AddDate(0, 0, 7), nil
+	case "month":
+		return start, start.AddDate(0, 1, 0), nil
+	}
+	return start, start.AddDate(0, 0, 1), nil
+}
+
+// l2Columns returns the L2 transactions and gas of every bucket and the L1
+// cost amortized over them.
+func l2Columns(results map[string]*aggregate.Result, activity map[string]fetch.Activity) []output.Column {
+	txs := output.Column{Header: "L2 Txs", Values: make(map[string]string, len(results))}
+	gas := output.Column{Header: "L2 Gas Used", Values: make(map[string]string, len(results))}
+	perTx := output.Column{Header: "L1 Cost per L2 Tx(ETH)", Values: make(map[string]string, len(results))}
+	perGas := output.Column{Header: "L1 Cost per L2 Gas(Gwei)", Values: make(map[string]string, len(results))}
+	for k, r := range results {
+		a, ok := activity[k]
+		if !ok {
+			continue
+		}
+		txs.Values[k], gas.Values[k] = strconv.FormatUint(a.Txs, 10), strconv.FormatUint(a.GasUsed, 10)
+		perTx.Values[k], perGas.Values[k] = amortized(r, a)
+	}
+	return []output.Column{txs, gas, perTx, perGas}
+}
+
+// amortized returns the L1 cost of r per L2 transaction in ETH and per L2
+// gas in Gwei, empty without L2 transactions or gas.
+func amortized(r *aggregate.Result, a fetch.Activity) (perTx, perGas string) {
+	if a.Txs > 0 {
+		perTx = r.BlobDependent(new(big.Float).Quo(r.Cost, new(big.Float).SetUint64(a.Txs)))
+	}
+	if a.GasUsed > 0 {
+		gwei := new(big.Float).Mul(r.Cost, big.NewFloat(1e9))
+		perGas = r.BlobDependent(gwei.Quo(gwei, new(big.Float).SetUint64(a.GasUsed)))
+	}
+	return perTx, perGas
+}
+
+// printL2Summary prints the L1 cost amortized over the L2 activity of all
+// buckets.
+func printL2Summary(w io.Writer, total *aggregate.Result, activity map[string]fetch.Activity) {
+	var sum fetch.Activity
+	for _, a := range activity {
+		sum.Blocks += a.Blocks
+		sum.Txs += a.Txs
+		sum.GasUsed += a.GasUsed
+	}
+	fmt.Fprintf(w, "L2: %d txs, %d gas over %d blocks", sum.Txs, sum.GasUsed, sum.Blocks)
+	if perTx, perGas := amortized(total, sum); perTx != "" && perGas != "" {
+		fmt.Fprintf(w, "; L1 cost %s ETH per L2 tx, %s Gwei per L2 gas", perTx, perGas)
+	}
+	fmt.Fprintln(w)
+}
+
+// revenueColumns returns the L2 fee revenue of every bucket by fee and the
+// net margin over its L1 cost.
+func revenueColumns(results map[string]*aggregate.Result, revenue map[string]fetch.Revenue) []output.Column {
+	base := output.Column{Header: "L2 Base Fee Revenue(ETH)", Values: make(map[string]string, len(results))}
+	l1 := output.Column{Header: "L2 L1 Fee Revenue(ETH)", Values: make(map[string]string, len(results))}
+	priority := output.Column{Header: "L2 Priority Fee Revenue(ETH)", Values: make(map[string]string, len(results))}
+	total := output.Column{Header: "L2 Revenue(ETH)", Values: make(map[string]string, len(results))}
+	margin := output.Column{Header: "Net Margin(ETH)", Values: make(map[string]string, len(results))}
+	for k, r := range results {
+		v, ok := revenue[k]
+		if !ok {
+			continue
+		}
+		base.Values[k], l1.Values[k], priority.Values[k] = ether(v.BaseFee).String(), ether(v.L1Fee).String(), ether(v.PriorityFee).String()
+		total.Values[k] = ether(v.Total()).String()
+		margin.Values[k] = r.BlobDependent(netMargin(r, v))
+	}
+	return []output.Column{base, l1, priority, total, margin}
+}
+
+// netMargin returns the L2 revenue v less the L1 cost of r, in ETH.
+func netMargin(r *aggregate.Result, v fetch.Revenue) *big.Float {
+	return new(big.Float).Sub(ether(v.Total()), r.Cost)
+}
+
+// printRevenueSummary prints the L2 fee revenue of all buckets and the net
+// margin over the L1 cost.
+func printRevenueSummary(w io.Writer, total *aggregate.Result, revenue map[string]fetch.Revenue) {
+	sum := fetch.Revenue{BaseFee: new(big.Int), L1Fee: new(big.Int), PriorityFee: new(big.Int)}
+	for _, v := range revenue {
+		sum.BaseFee.Add(sum.BaseFee, v.BaseFee)
+		sum.L1Fee.Add(sum.L1Fee, v.L1Fee)
+		sum.PriorityFee.Add(sum.PriorityFee, v.PriorityFee)
+	}
+	fmt.Fprintf(w, "L2 revenue: %s ETH (base fee %s, L1 fee %s, priority fee %s), net margin %s ETH\n",
+		ether(sum.Total()).String(), ether(sum.BaseFee).String(), ether(sum.L1Fee).String(), ether(sum.PriorityFee).String(), total.BlobDependent(netMargin(total, sum)))
+}
+
+// ether converts wei to ETH.
+func ether(wei *big.Int) *big.Float {
+	return new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(params.Ether))
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// newLogger returns a slog logger writing to w at the given level and format.
+func newLogger(w io.Writer, level, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid -log-level %q", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid -log-format %q", format)
+	}
+}
+
+// setupLogger installs the default slog logger writing to w at the given level
+// and format.
+func setupLogger(w io.Writer, level, format string) error {
+	logger, err := newLogger(w, level, format)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+// console is where a run of runAnalyze logs and prints its summary. The
+// zero value is the process: the default logger, which parse sets up, and
+// os.Stdout. Every job of runJobs has a console of its own, so that the jobs
+// running together neither replace each other's logger nor interleave their
+// summaries.
+type console struct {
+	job    string       // name of the job, added to its messages
+	stdout io.Writer    // nil for os.Stdout
+	log    *slog.Logger // set by parse
+}
+
+// parse parses args into fs, as parseArgs, and sets up the logger of c from
+// the -log-level and -log-format flags. Only the console of the process
+// installs it as the default logger.
+func (c *console) parse(fs *flag.FlagSet, args []string) error {
+	logger, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if c.job == "" {
+		slog.SetDefault(logger)
+	} else {
+		logger = logger.With("job", c.job)
+	}
+	c.log = logger
+	if c.stdout == nil {
+		c.stdout = os.Stdout
+	}
+	return nil
+}
@@ -1,178 +1,931 @@
 package main
 
 import (
+	"bytes"
 	"context"
-	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"math/big"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"syscall"
 	"time"
+	_ "time/tzdata" // -timezone works without a zoneinfo database
 
-	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/ethereum/go-ethereum/params"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/email"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/fetch"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/heatmap"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/input"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/nonce"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/sheets"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/sink"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/tracker"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/upload"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/webhook"
 )
 
-type Result struct {
-	Cost                 *big.Float // ETH
-	AvgCallDataGasPrice  *big.Float // Gwei
-	AvgBlobGasPrice      *big.Float // Gwei
-	TotalCalldataGasUsed uint64
-	TotalBlobGasUsed     uint64
-	TotalGasUsed         uint64
-	TxCount              uint64
-}
+const usage = `Usage:
+  %[1]s [analyze] [flags]      aggregate the costs of -input (or -address)
+  %[1]s scan [flags]           aggregate the transactions of a block range
+  %[1]s backfill [flags]       scan the days -from to -to in chunks, resumably
+  %[1]s report [flags] files   print previously written reports
+  %[1]s forecast [flags] file  project the posting cost of the coming days
+  %[1]s simulate [flags] file  replay the posted data under other submission policies
+  %[1]s diff [flags] old new   compare two reports bucket by bucket
+  %[1]s merge [flags] files    combine reports into one series
+  %[1]s serve [flags]          serve the report directory over HTTP
+  %[1]s daemon [flags]         follow new blocks into a -sink database
+  %[1]s query [flags] [query]  answer a question about a -sink database
+  %[1]s cache export|import    move the receipt cache to another machine
+  %[1]s jobs [flags]           run the jobs of the -config file concurrently
+
+Run "%[1]s <command> -h" for the flags of a command.
+`
 
 func main() {
-	l1RPC := os.Getenv("L1_RPC")
-	fileName := os.Getenv("FILE_NAME")
+	// Without a command, the arguments are analyze flags as in earlier
+	// versions.
+	cmd, args := "analyze", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
+	}
 
-	client, err := ethclient.Dial(l1RPC)
+	var err error
+	switch cmd {
+	case "analyze", "scan", "backfill":
+		err = runAnalyze(cmd, args, console{}, nil)
+	case "report":
+		err = runReport(args)
+	case "forecast":
+		err = runForecast(args)
+	case "simulate":
+		err = runSimulate(args)
+	case "diff":
+		err = runDiff(args)
+	case "merge":
+		err = runMerge(args)
+	case "serve":
+		err = runServe(args)
+	case "daemon":
+		err = runDaemon(args)
+	case "query":
+		err = runQuery(args)
+	case "cache":
+		err = runCache(args)
+	case "jobs":
+		err = runJobs(args)
+	case "help":
+		fmt.Fprintf(os.Stderr, usage, os.Args[0])
+	default:
+		fmt.Fprintf(os.Stderr, usage, os.Args[0])
+		err = fmt.Errorf("unknown command %q", cmd)
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
-		log.Fatal(err)
+		slog.Error(err.Error())
+		os.Exit(1)
 	}
+}
 
-	file, err := os.Open(fileName)
-	if err != nil {
-		log.Fatal(err)
+// analyzeOptions holds the flags of the analyze, scan and backfill commands,
+// as registered by newAnalyzeFlags.
+type analyzeOptions struct {
+	inputSpec          string
+	rpcURLs            string
+	networks           string
+	rollups            string
+	name               string
+	outDir             string
+	address            string
+	toAddress          string
+	systemConfig       string
+	fromBlock          uint64
+	toBlock            uint64
+	fromDate           string
+	toDate             string
+	chunkDays          int
+	useEtherscan       bool
+	etherscanKey       string
+	etherscanURL       string
+	etherscanRPS       float64
+	indexerFallback    bool
+	useBigQuery        bool
+	bigQueryProject    string
+	bigQueryTable      string
+	duneQuery          int
+	duneKey            string
+	duneURL            string
+	duneParams         string
+	duneExecute        bool
+	inputFmt           string
+	streamInput        bool
+	trustCSV           bool
+	trustSample        int
+	txHashCol          string
+	dateTimeCol        string
+	submittedCol       string
+	delimiter          string
+	timeFormat         string
+	skippedPath        string
+	granularity        string
+	fromDay            string
+	toDay              string
+	fillGapsFlag       bool
+	timezone           string
+	simpleMean         bool
+	tips               bool
+	beaconURL          string
+	l2RPC              string
+	feeRecipient       string
+	revenue            bool
+	methods            bool
+	perSender          bool
+	bySender           bool
+	expectedSenders    string
+	unexpectedPath     string
+	methodNamesPath    string
+	disputes           bool
+	bridgeList         string
+	rolesSpec          string
+	monthlyBudget      string
+	runwayOn           bool
+	runwayWindow       int
+	runwayAlert        float64
+	ethUSD             float64
+	usd                bool
+	fiatList           string
+	ton                bool
+	tonCoin            string
+	priceAPI           string
+	priceAPIKey        string
+	priceCache         string
+	altDASpec          string
+	anomalySigma       float64
+	anomalyPercent     float64
+	anomalyWindow      int
+	anomalyAlerts      bool
+	unitList           string
+	precision          int
+	format             string
+	sinkKind           string
+	sinkPlugins        string
+	uploadTo           string
+	webhookURL         string
+	webhookSecret      string
+	emailTo            string
+	emailFrom          string
+	smtpAddr           string
+	smtpUser           string
+	smtpPassword       string
+	sheetID            string
+	sheetName          string
+	sheetCredentials   string
+	dsn                string
+	frames             bool
+	whatIf             bool
+	overpayment        bool
+	oracleBlocks       int
+	oraclePercentile   float64
+	tipMarket          bool
+	tipMarketThreshold float64
+	calldataFloor      bool
+	blobScheduleList   string
+	timing             bool
+	blobMarket         bool
+	noncesOut          bool
+	heatmapOut         bool
+	inclusion          bool
+	benchmarks         string
+	compression        bool
+	splitEras          bool
+	efficiency         bool
+	channels           bool
+	scalars            bool
+	spillDir           string
+	perTx              bool
+	concurrency        int
+	batchSize          int
+	blockReceiptsMin   int
+	maxFailureRate     float64
+	failedPath         string
+	rps                float64
+	pushGatewayURL     string
+	remoteWriteURL     string
+	otlpEndpoint       string
+	otlpInterval       time.Duration
+	requestTimeout     time.Duration
+	runTimeout         time.Duration
+	maxAttempts        int
+	retryDelay         time.Duration
+	retryMaxDelay      time.Duration
+	cachePath          string
+	dryRun             bool
+	offline            bool
+	checkpointPath     string
+	checkpointEvery    int
+	resume             bool
+	appendRuns         bool
+	statePath          string
+	progressFile       string
+	progressLog        time.Duration
+	progressInterval   time.Duration
+}
+
+// newAnalyzeFlags returns the flag set of cmd, one of analyze, scan and
+// backfill, and the options it parses into.
+func newAnalyzeFlags(cmd string) (*flag.FlagSet, *analyzeOptions) {
+	fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	o := &analyzeOptions{}
+	fs.StringVar(&o.inputSpec, "input", os.Getenv("FILE_NAME"), "input files: a comma-separated list of paths and glob patterns, - for stdin (env FILE_NAME)")
+	fs.StringVar(&o.rpcURLs, "rpc", os.Getenv("L1_RPC"), "comma-separated L1 JSON-RPC endpoints, used in turn on failures (env L1_RPC)")
+	fs.StringVar(&o.networks, "networks", "", "comma-separated networks of the networks section of the -config file, e.g. mainnet,sepolia,holesky: runs once per network with its settings, writing separate reports")
+	fs.StringVar(&o.rollups, "rollups", "", "comma-separated rollups of the rollups section of the -config file: runs once per rollup with its settings, then writes a comparison of their costs, costs per byte and blob usage")
+	fs.StringVar(&o.name, "name", "", "name of the report files, output-<name>.<format> (default: derived from the input)")
+	fs.StringVar(&o.outDir, "out", "./outputs", "directory of the report and its companion files, created if missing; or the path of the report file, recognized by its extension, or - to write the report to stdout")
+	fs.StringVar(&o.address, "address", "", "scan blocks for transactions sent by these comma-separated addresses instead of reading -input; an address=label entry names the sender in -by-sender columns and -per-sender reports")
+	fs.StringVar(&o.toAddress, "to-address", "", "with scan, only match transactions sent to these comma-separated addresses")
+	fs.StringVar(&o.systemConfig, "system-config", "", "OP Stack SystemConfig contract whose batcher and proposer are scanned like -address, with the batch inbox and output oracle or dispute game factory as -roles")
+	fs.Uint64Var(&o.fromBlock, "from-block", 0, "first block to scan")
+	fs.Uint64Var(&o.toBlock, "to-block", 0, "last block to scan (default: latest)")
+	fs.StringVar(&o.fromDate, "from-date", "", "first day (YYYY-MM-DD, UTC) to scan; overrides -from-block")
+	fs.StringVar(&o.toDate, "to-date", "", "last day (YYYY-MM-DD, UTC) to scan; overrides -to-block")
+	fs.IntVar(&o.chunkDays, "chunk-days", 7, "with backfill, number of days scanned by every chunk")
+	fs.BoolVar(&o.useEtherscan, "etherscan", false, "list -address transactions through the Etherscan API instead of scanning blocks")
+	fs.StringVar(&o.etherscanKey, "etherscan-key", os.Getenv("ETHERSCAN_API_KEY"), "Etherscan API key (env ETHERSCAN_API_KEY)")
+	fs.StringVar(&o.etherscanURL, "etherscan-url", "https://api.etherscan.io/api", "Etherscan-compatible API endpoint, e.g. https://api-sepolia.etherscan.io/api")
+	fs.Float64Var(&o.etherscanRPS, "etherscan-rps", 5, "maximum Etherscan requests per second")
+	fs.BoolVar(&o.indexerFallback, "indexer-fallback", false, "fetch the receipts that the RPC endpoints do not have, such as old receipts of a pruned node, through the proxy module of the Etherscan-compatible -etherscan-url")
+	fs.BoolVar(&o.useBigQuery, "bigquery", false, "list -address or -to-address transactions by querying the BigQuery public Ethereum dataset instead of scanning blocks; needs -from-date")
+	fs.StringVar(&o.bigQueryProject, "bigquery-project", os.Getenv("GOOGLE_CLOUD_PROJECT"), "Google Cloud project running and billed for the -bigquery query (env GOOGLE_CLOUD_PROJECT, default: the project of the credentials)")
+	fs.StringVar(&o.bigQueryTable, "bigquery-table", fetch.BigQueryTable, "BigQuery table of transactions with the schema of the crypto_ethereum dataset")
+	fs.IntVar(&o.duneQuery, "dune-query", 0, "read the transactions from the results of this saved Dune query instead of -input; it returns a hash or tx_hash column, and optionally block_number, block_time, from and to")
+	fs.StringVar(&o.duneKey, "dune-key", os.Getenv("DUNE_API_KEY"), "Dune API key (env DUNE_API_KEY)")
+	fs.StringVar(&o.duneURL, "dune-url", "https://api.dune.com/api/v1", "Dune API endpoint")
+	fs.StringVar(&o.duneParams, "dune-params", "", "comma-separated name=value parameters of -dune-query; the query is then executed again")
+	fs.BoolVar(&o.duneExecute, "dune-execute", false, "execute -dune-query again instead of reading its latest results, which costs Dune credits")
+	fs.StringVar(&o.inputFmt, "input-format", "", "format of the input files: csv, hashes, json or jsonl (default: from the extension); -input - reads stdin")
+	fs.BoolVar(&o.streamInput, "stream", false, "fetch the transactions of the input as they are read instead of reading it all first, for inputs of millions of rows; the progress total then grows as the input is read")
+	fs.BoolVar(&o.trustCSV, "trust-csv", false, "use the CSV gas used/gas price/fee columns when present instead of fetching receipts; gas prices are in wei unless their header says Gwei, and fees in wei unless it says ETH")
+	fs.IntVar(&o.trustSample, "trust-csv-sample", 0, "number of CSV-resolved rows to cross-check against RPC receipts")
+	fs.StringVar(&o.txHashCol, "txhash-col", "", "name of the transaction hash column to use when the CSV has several (e.g. L1 and L2 hashes)")
+	fs.StringVar(&o.dateTimeCol, "datetime-col", "", "name of the CSV datetime column (default: detected from the headers, block timestamps if none)")
+	fs.StringVar(&o.submittedCol, "submitted-col", "", "name of the CSV column of the time the transactions were first seen in the mempool (default: detected from the headers, e.g. First Seen)")
+	fs.StringVar(&o.delimiter, "delimiter", "", "CSV field separator, e.g. ; or tab (default: sniffed from the header line)")
+	fs.StringVar(&o.timeFormat, "time-format", "", "format of the CSV datetime column: a Go layout such as 01/02/2006, unix or unixms (default: detected from the first row)")
+	fs.StringVar(&o.skippedPath, "skipped-rows", "", "where to write the rows rejected as invalid or duplicate (default: skipped-rows.csv next to the output)")
+	fs.StringVar(&o.granularity, "granularity", "day", "bucket size of the report: block (one bucket per L1 block with transactions, e.g. 2024-07-03 15:04:23 #20223456), hour (e.g. 2024-07-03 15:00), day, week (ISO 8601, e.g. 2024-W11) or month (e.g. 2024-07)")
+	fs.StringVar(&o.fromDay, "from", "", "only report the transactions from this day on (YYYY-MM-DD, in -timezone)")
+	fs.StringVar(&o.toDay, "to", "", "only report the transactions up to this day (YYYY-MM-DD, in -timezone)")
+	fs.BoolVar(&o.fillGapsFlag, "fill-gaps", false, "add empty buckets for the hours, days, weeks or months without transactions between the first and last ones, or -from and -to, so that the report has a continuous time axis")
+	fs.StringVar(&o.timezone, "timezone", "UTC", "IANA time zone whose days and hours delimit the buckets, e.g. Asia/Seoul")
+	fs.BoolVar(&o.simpleMean, "simple-mean", false, "add the simple means of the calldata and blob gas prices over the transactions to csv and markdown reports, next to the gas-weighted averages")
+	fs.BoolVar(&o.tips, "tips", false, "fetch the base fee of the block of every transaction to split the execution fee into base fee burnt and priority tips, added as columns to csv and markdown reports")
+	fs.StringVar(&o.beaconURL, "beacon", os.Getenv("L1_BEACON"), "beacon node REST API URL from which to read the blobs of blob transactions and add their utilization to csv and markdown reports (env L1_BEACON)")
+	fs.StringVar(&o.l2RPC, "l2-rpc", os.Getenv("L2_RPC"), "comma-separated L2 JSON-RPC endpoints from which to read the L2 transactions and gas of every bucket and add the L1 cost per L2 transaction and gas to csv and markdown reports (env L2_RPC)")
+	fs.StringVar(&o.feeRecipient, "fee-recipient", "", "with -system-config, L1 recipient of the fee vault withdrawals, whose received amounts are added to csv and markdown reports next to the costs")
+	fs.BoolVar(&o.revenue, "revenue", false, "with -l2-rpc, read the fees that the OP Stack fee vaults collected and add L2 revenue and net margin columns to csv and markdown reports; needs an archive L2 node")
+	fs.BoolVar(&o.methods, "methods", false, "split the transaction count and cost of csv and markdown reports by the method the transactions call, fetching the transactions when the input lacks it")
+	fs.BoolVar(&o.perSender, "per-sender", false, "with -by-sender, also write the report of every sender on its own to a CSV file next to the report, named by the label of the sender or its address")
+	fs.BoolVar(&o.bySender, "by-sender", false, "split the transaction count and cost of csv and markdown reports by the sender of the transactions, such as rotated batcher keys, the proposer and the challenger, and print the cost of every sender; fetches the transactions when the input lacks their sender")
+	fs.StringVar(&o.expectedSenders, "expected-senders", "", "comma-separated allowlist of the batcher, proposer or other addresses expected to send the transactions, labeled like -address; the transactions of other senders are left out of the report and listed in -unexpected-senders; fetches the transactions when the input lacks their sender")
+	fs.StringVar(&o.unexpectedPath, "unexpected-senders", "", "where to write the transactions of senders not in -expected-senders (default: unexpected-senders.csv next to the output)")
+	fs.StringVar(&o.methodNamesPath, "method-names", "", "with -methods, file naming method selectors: one signature, e.g. proposeL2Output(bytes32,uint256,bytes32,uint256), or selector and name per line")
+	fs.BoolVar(&o.disputes, "disputes", false, "split the transactions that play dispute games, such as the moves, steps and resolutions of a challenge and the bond claims, into a dispute role of csv and markdown reports, whatever their recipient; fetches the transactions when the input lacks their method")
+	fs.StringVar(&o.bridgeList, "bridge-contracts", "", "comma-separated L1 bridge contracts, such as the OptimismPortal and the L1StandardBridge: the deposits sent to them split into a deposit role of csv and markdown reports and their other calls into a bridge role; -system-config names them")
+	fs.StringVar(&o.rolesSpec, "roles", "", "comma-separated address=role pairs, e.g. 0xff00...0010=batch-inbox,0x9b3c...=output-oracle: splits the transaction count and cost of csv and markdown reports by the role of the recipient")
+	fs.StringVar(&o.monthlyBudget, "monthly-budget", "", "monthly budget of the L1 costs in ETH or USD, e.g. 10 or \"30000 USD\": adds month-to-date columns to csv and markdown reports and alerts at 50, 80 and 100% of it")
+	fs.BoolVar(&o.runwayOn, "runway", false, "read the L1 balance of the -address senders after the run, print how many days it lasts at the average daily cost of the last -runway-window complete days and record it in runway.csv in -out")
+	fs.IntVar(&o.runwayWindow, "runway-window", 7, "complete days of the report that -runway averages")
+	fs.Float64Var(&o.runwayAlert, "runway-alert", 0, "with -runway, alert through the alerts config section when the runway is below this many days, once a day")
+	fs.Float64Var(&o.ethUSD, "eth-usd", 0, "ETH price in USD at which the costs are compared with a USD -monthly-budget or -alt-da price")
+	fs.BoolVar(&o.usd, "usd", false, "add a Total Cost (USD) column to csv and markdown reports at the daily ETH/USD close of -price-api")
+	fs.StringVar(&o.fiatList, "fiat", "", "comma-separated fiat currencies, e.g. krw,eur, whose Total Cost column is added to csv and markdown reports at the daily ETH close of -price-api, like -usd")
+	fs.BoolVar(&o.ton, "ton", false, "add a Total Cost (TON) column to csv and markdown reports at the daily TON/ETH close of -price-api")
+	fs.StringVar(&o.tonCoin, "ton-coin", "tokamak-network", "id of TON at -price-api")
+	fs.StringVar(&o.priceAPI, "price-api", "https://api.coingecko.com/api/v3", "CoinGecko-compatible API of the daily prices of -usd, -fiat and -ton")
+	fs.StringVar(&o.priceAPIKey, "price-api-key", os.Getenv("COINGECKO_API_KEY"), "API key of -price-api (env COINGECKO_API_KEY)")
+	fs.StringVar(&o.priceCache, "price-cache", "", "JSON file caching the daily prices of past days (default: prices.json in -out)")
+	fs.StringVar(&o.altDASpec, "alt-da", "", "comma-separated name=price pairs of alt-DA layers, e.g. celestia=0.0004,eigenda=0.15USD, priced per MiB in ETH or USD: measures the data posted and adds what it would have cost on every layer to csv and markdown reports; without -beacon, blobs count as full")
+	fs.Float64Var(&o.anomalySigma, "anomaly-sigma", 0, "flag days whose cost or average gas prices deviate from the mean of the -anomaly-window preceding days by more than this many standard deviations (0 disables)")
+	fs.Float64Var(&o.anomalyPercent, "anomaly-percent", 0, "flag days whose cost or average gas prices deviate from the mean of the -anomaly-window preceding days by more than this percentage (0 disables)")
+	fs.IntVar(&o.anomalyWindow, "anomaly-window", 14, "number of preceding days forming the baseline of -anomaly-sigma and -anomaly-percent")
+	fs.BoolVar(&o.anomalyAlerts, "anomaly-alerts", false, "send the anomalies of the last day of the report to the channels of the alerts config section")
+	fs.StringVar(&o.unitList, "units", "", "units of the amounts of csv reports as comma-separated column=unit pairs, e.g. cost=gwei,gas-price=wei; the columns are cost, in eth (default) or gwei, and gas-price, in eth, gwei (default) or wei")
+	fs.IntVar(&o.precision, "precision", -1, "digits after the decimal point of the amounts of csv reports, in fixed notation (default: ten significant digits, which may be in scientific notation)")
+	fs.StringVar(&o.format, "format", "csv", "report format: csv, json, jsonl (JSON Lines, one object per bucket), parquet, xlsx, markdown (also printed instead of the summary) or html (with charts)")
+	fs.StringVar(&o.sinkKind, "sink", "", "also store every transaction and the daily aggregates in a database, Dune tables or a file: "+sinkKindsHelp()+", or a kind of -sink-plugin")
+	fs.StringVar(&o.sinkPlugins, "sink-plugin", "", "comma-separated Go plugins (.so) registering more kinds of -sink")
+	fs.StringVar(&o.uploadTo, "upload", "", "after the run, upload the report and its companion files to s3://bucket/prefix or gs://bucket/prefix under <prefix>/<date>/<time>/")
+	fs.StringVar(&o.webhookURL, "webhook", "", "after a complete run, POST the JSON report to this URL")
+	fs.StringVar(&o.webhookSecret, "webhook-secret", os.Getenv("TRACKER_WEBHOOK_SECRET"), "HMAC-SHA256 key signing the -webhook deliveries (env TRACKER_WEBHOOK_SECRET)")
+	fs.StringVar(&o.emailTo, "email-to", "", "after a complete run, email the summary and the report file to these comma-separated addresses through -smtp")
+	fs.StringVar(&o.emailFrom, "email-from", "", "sender of the -email-to messages (default: -smtp-user)")
+	fs.StringVar(&o.smtpAddr, "smtp", os.Getenv("SMTP_ADDR"), "host:port of the SMTP server sending -email-to; port 465 uses TLS, other ports STARTTLS when the server offers it (env SMTP_ADDR)")
+	fs.StringVar(&o.smtpUser, "smtp-user", os.Getenv("SMTP_USER"), "user name authenticating to -smtp (env SMTP_USER)")
+	fs.StringVar(&o.smtpPassword, "smtp-password", os.Getenv("SMTP_PASSWORD"), "password of -smtp-user (env SMTP_PASSWORD)")
+	fs.StringVar(&o.sheetID, "sheet-id", "", "also write the per-bucket report to this Google spreadsheet, updating the rows of known buckets and appending the others")
+	fs.StringVar(&o.sheetName, "sheet-name", "Daily", "tab of -sheet-id")
+	fs.StringVar(&o.sheetCredentials, "sheet-credentials", os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"), "JSON key of the service account writing to -sheet-id (env GOOGLE_APPLICATION_CREDENTIALS)")
+	fs.StringVar(&o.dsn, "dsn", os.Getenv("TRACKER_DSN"), "database of -sink: the path of the SQLite file, a Postgres connection URL, a ClickHouse HTTP URL, Kafka brokers, the Dune namespace and table prefix or the path of the csv or jsonl file (env TRACKER_DSN)")
+	fs.BoolVar(&o.frames, "frames", false, "with -per-tx, decode the batcher frames of the transactions to add the L2 blocks and transactions of every submission; blob transactions need -beacon")
+	fs.BoolVar(&o.whatIf, "what-if", false, "price the data of every blob transaction as calldata, and of every calldata batch in blobs, and add the savings to csv and markdown reports; without -beacon, blobs are assumed full")
+	fs.BoolVar(&o.overpayment, "overpayment", false, "compare the priority tip of every transaction with the one a fee oracle would have suggested from the blocks before it, and add the overpayment to csv and markdown reports; fetches the base fees like -tips and the fee history of the blocks")
+	fs.IntVar(&o.oracleBlocks, "oracle-blocks", 20, "number of blocks before a transaction whose tips -overpayment samples")
+	fs.Float64Var(&o.oraclePercentile, "oracle-percentile", 60, "percentile of the tips of every sampled block that -overpayment takes, like the gas price oracle of geth")
+	fs.BoolVar(&o.tipMarket, "tip-market", false, "compare the priority tip of every transaction with the median tip of its block, adding the distribution of the ratio to csv and markdown reports and flagging the buckets that paid far above the market; fetches the base fees like -tips and the fee history of the blocks")
+	fs.Float64Var(&o.tipMarketThreshold, "tip-market-threshold", 2, "ratio to the block median tip from which -tip-market counts a tip as far above the market, and flags the buckets whose median ratio reaches it")
+	fs.BoolVar(&o.calldataFloor, "calldata-floor", false, "fetch the calldata of every calldata transaction to compute its EIP-7623 floor gas, adding the rule that priced every bucket, the transactions charged the floor and the cost had EIP-7623 applied to the earlier ones to csv and markdown reports, so that costs compare across Prague")
+	fs.StringVar(&o.blobScheduleList, "blob-schedule", "", "comma-separated alternative blob schedules, target/max blobs per block such as 6/9, optionally with the update fraction of the blob base fee as target/max/fraction: replays the blob gas used by every block over the range of the blob transactions to add what their blob fees would have been to csv and markdown reports; fetches every block header of the range")
+	fs.BoolVar(&o.timing, "timing", false, "price the transactions of every bucket at its lowest L1 base fee and blob base fee, plus the priority tips paid when -tips knows them, and add that cost and the timing inefficiency, how much more the bucket cost, to csv and markdown reports; fetches every block header of the period, like -blob-market")
+	fs.BoolVar(&o.blobMarket, "blob-market", false, "read the blob base fee of every L1 block over the time of every bucket and add the network's blob base fee at its start and end, its lowest, highest and average, and our average blob gas price relative to it to csv and markdown reports, to tell market-driven cost changes from usage-driven ones; fetches every block header of the period")
+	fs.BoolVar(&o.noncesOut, "nonces", false, "also follow the nonces of every sender and write the gaps in their sequence and the transactions replaced before being mined, with the extra cost of their replacements, to a .nonces.csv file next to the report; fetches the transactions when the input lacks their sender or nonce")
+	fs.BoolVar(&o.heatmapOut, "heatmap", false, "also write the average calldata and blob gas prices by hour of the day and day of the week, in -timezone, to a .heatmap.csv file next to the report and print the cheapest hours")
+	fs.BoolVar(&o.inclusion, "inclusion", false, "add the average and p95 delay from the mempool submission time of the input to the block, and its correlation with the priority tip, to csv and markdown reports; fetches the base fees like -tips")
+	fs.StringVar(&o.benchmarks, "benchmark", "", "comma-separated public OP Stack chains, optimism or base, or name=inbox pairs, whose batch inboxes are scanned over the period of the report to compare their cost per byte with ours in csv and markdown reports; blob transactions need -beacon")
+	fs.BoolVar(&o.compression, "compression", false, "measure the data posted and decompress the batcher channels to add bytes posted, compression ratio and cost per byte to csv and markdown reports; blob transactions need -beacon")
+	fs.BoolVar(&o.splitEras, "eras", false, "add the era of every bucket, calldata, transition or blob by the activation of Cancun on the chain, keep the rolling averages and day-over-day change of daily reports within an era and print the totals of every era")
+	fs.BoolVar(&o.efficiency, "efficiency", false, "measure the data posted and decode the batcher channels to add the L2 transactions, L1 gas per L2 transaction, L1 gas per posted byte and ETH per MB posted to csv and markdown reports; blob transactions need -beacon")
+	fs.BoolVar(&o.channels, "channels", false, "decode the batcher frames of the transactions and write the L1 cost of every channel they complete to a .channels.csv file, and that cost split between the L1 origin epochs of its L2 blocks to an .epochs.csv file, next to the report; blob transactions need -beacon")
+	fs.BoolVar(&o.scalars, "scalars", false, "decode the batcher frames of the transactions and add the Ecotone baseFeeScalar and blobBaseFeeScalar at which L1 fees break even to csv and markdown reports; blob transactions need -beacon")
+	fs.StringVar(&o.spillDir, "spill-dir", "", "directory of the temporary files in which the -per-tx parquet table buffers its row groups and -blob-schedule keeps the blob transactions to replay (default: the system temporary directory)")
+	fs.BoolVar(&o.perTx, "per-tx", false, "write one row per transaction as it is processed: with -format jsonl instead of the buckets, with -format csv or parquet to an additional .transactions.csv or .transactions.parquet table")
+	fs.IntVar(&o.concurrency, "concurrency", envInt("CONCURRENCY", 8), "number of receipts fetched in parallel (env CONCURRENCY)")
+	fs.IntVar(&o.batchSize, "batch-size", envInt("BATCH_SIZE", 50), "receipts requested per JSON-RPC batch call (env BATCH_SIZE)")
+	fs.IntVar(&o.blockReceiptsMin, "block-receipts-min", 3, "fetch a whole block's receipts with eth_getBlockReceipts when at least this many transactions share it (0 disables)")
+	fs.Float64Var(&o.maxFailureRate, "max-failure-rate", 0, "fraction of failed transactions (0-1) tolerated before exiting with an error; the report is written either way")
+	fs.StringVar(&o.failedPath, "failed-rows", "", "where to write the transactions whose receipt could not be fetched (default: failed-transactions.csv next to the output)")
+	fs.Float64Var(&o.rps, "rps", 0, "maximum L1 RPC calls per second, a batch counting as one, lowered while the provider answers 429 Too Many Requests (0 disables)")
+	fs.StringVar(&o.pushGatewayURL, "push-gateway", "", "after a daily run, push the /metrics gauges of the last day of the report to this Prometheus Pushgateway, e.g. http://localhost:9091, labeled by chain, the report name, and batcher, the first -address")
+	fs.StringVar(&o.remoteWriteURL, "remote-write", "", "after a daily run, send the /metrics gauges of every day of the report, timestamped at the start of the day, to this Prometheus remote-write endpoint, e.g. http://localhost:9090/api/v1/write")
+	fs.StringVar(&o.otlpEndpoint, "otlp-endpoint", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "OTLP/HTTP collector receiving traces and metrics of the RPC calls, retries, cache lookups and processed transactions, e.g. http://localhost:4318 (env OTEL_EXPORTER_OTLP_ENDPOINT)")
+	fs.DurationVar(&o.otlpInterval, "otlp-interval", 15*time.Second, "how often to export the metrics to -otlp-endpoint")
+	fs.DurationVar(&o.requestTimeout, "request-timeout", time.Minute, "timeout of a single RPC or Etherscan request, after which it is retried (0 disables)")
+	fs.DurationVar(&o.runTimeout, "run-timeout", 0, "stop fetching after this long and write a partial report, as on SIGINT (0 disables)")
+	fs.IntVar(&o.maxAttempts, "max-attempts", 5, "attempts per RPC request before giving up on a transaction")
+	fs.DurationVar(&o.retryDelay, "retry-delay", 500*time.Millisecond, "initial delay between RPC retries, doubled on every attempt")
+	fs.DurationVar(&o.retryMaxDelay, "retry-max-delay", 30*time.Second, "upper bound of the delay between RPC retries")
+	fs.StringVar(&o.cachePath, "cache", os.Getenv("RECEIPT_CACHE"), "path of an on-disk receipt cache reused across runs (env RECEIPT_CACHE)")
+	fs.BoolVar(&o.dryRun, "dry-run", false, "read and check the input, or resolve the block range of a scan, check the RPC endpoints and their chain, estimate the RPC requests and the duration of the run, and exit without fetching or writing anything")
+	fs.BoolVar(&o.offline, "offline", false, "resolve every receipt from -cache, e.g. one imported with the cache command, without RPC requests; the transactions it lacks fail")
+	fs.StringVar(&o.checkpointPath, "checkpoint", "", "checkpoint file for -resume (default: the output path with a .checkpoint suffix)")
+	fs.IntVar(&o.checkpointEvery, "checkpoint-every", 500, "save a checkpoint after this many processed transactions (0 disables)")
+	fs.BoolVar(&o.resume, "resume", false, "continue an interrupted run from its checkpoint")
+	fs.BoolVar(&o.appendRuns, "append", false, "add the transactions to the report of the previous -append runs of the same name instead of replacing it, skipping those already counted, e.g. for daily incremental runs")
+	fs.StringVar(&o.statePath, "state", "", "state file of -append, holding the counted transactions and the per-bucket sums (default: the output path with a .state suffix)")
+	fs.StringVar(&o.progressFile, "progress-file", "", "periodically write progress as JSON to this file")
+	fs.DurationVar(&o.progressLog, "progress-log", 10*time.Second, "log processed and failed counts, RPC rate and ETA at this interval (0 disables)")
+	fs.DurationVar(&o.progressInterval, "progress-interval", 5*time.Second, "how often to update the progress file")
+	fs.Usage = func() {
+		switch cmd {
+		case "backfill":
+			fmt.Fprintf(fs.Output(), "Usage: %s backfill -address senders -from YYYY-MM-DD [-to YYYY-MM-DD] [flags]\n\nFlags:\n", os.Args[0])
+		case "scan":
+			fmt.Fprintf(fs.Output(), "Usage: %s scan -from-block N -to-block M [-address senders] [-to-address recipients] [flags]\n\nFlags:\n", os.Args[0])
+		default:
+			fmt.Fprintf(fs.Output(), "Usage: %s analyze -input file.csv -rpc url [flags]\n\nFlags:\n", os.Args[0])
+		}
+		fs.PrintDefaults()
 	}
-	defer file.Close()
+	return fs, o
+}
 
-	reader := csv.NewReader(file)
+// runAnalyze runs the analyze, scan and backfill commands. "scan" aggregates
+// the transactions of a block range without any input file; analyze reads
+// -input or lists the transactions of -address; backfill runs scan over a
+// long period in chunks. The run logs and prints its summary to con.
+// onReport, if set, is called with the report of a complete run and its
+// granularity.
+func runAnalyze(cmd string, args []string, con console, onReport func(tracker.Report, string)) error {
+	fs, o := newAnalyzeFlags(cmd)
+	if err := con.parse(fs, args); err != nil {
+		return err
+	}
+	if o.networks != "" && o.rollups != "" {
+		return errors.New("-networks and -rollups cannot be combined")
+	}
+	outFile := ""
+	o.outDir, outFile = splitOut(o.outDir)
+	if outFile != "" && (o.networks != "" || o.rollups != "") {
+		return errors.New("-out must be a directory with -networks and -rollups, which write several reports")
+	}
+	if err := os.MkdirAll(o.outDir, 0o755); err != nil {
+		return fmt.Errorf("-out: %w", err)
+	}
+	if o.networks != "" {
+		return runSections(fs, args, "networks", "networks", o.networks, con.log, func(_ string, args []string) error {
+			return runAnalyze(cmd, args, con, nil)
+		})
+	}
+	if o.rollups != "" {
+		return runRollups(cmd, fs, args, o.rollups, con)
+	}
+	if cmd == "backfill" {
+		return runBackfill(fs, args, o.outDir, outFile, o.chunkDays, con)
+	}
 
-	headers, err := reader.Read()
+	a := &analysis{analyzeOptions: o, console: con, fs: fs, scan: cmd == "scan"}
+	err := a.resolve()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	var dateTimeIndex, txHashIndex int
-	for i, header := range headers {
-		if header == "DateTime (UTC)" {
-			dateTimeIndex = i
-		} else if header == "Transaction Hash" {
-			txHashIndex = i
+	// The destination is checked before a long run rather than after it.
+	start := time.Now()
+	var dest *upload.Destination
+	if a.uploadTo != "" {
+		if dest, err = upload.Open(context.Background(), a.uploadTo); err != nil {
+			return fmt.Errorf("-upload: %w", err)
+		}
+	}
+	var mailer *email.Mailer
+	if a.emailTo != "" {
+		if a.smtpAddr == "" {
+			return errors.New("-email-to needs -smtp")
+		}
+		if mailer, err = email.New(a.smtpAddr, a.smtpUser, a.smtpPassword, a.emailFrom, a.emailTo); err != nil {
+			return fmt.Errorf("-email-to: %w", err)
 		}
 	}
 
-	results := make(map[string]*Result)
-
-	records, err := reader.ReadAll()
+	stopTelemetry, err := startTelemetry(a.otlpEndpoint, "batcher-gas-tracker", a.otlpInterval)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+	defer stopTelemetry()
 
-	for _, record := range records {
-		dateTime, err := time.Parse("2006-01-02 15:04:05", record[dateTimeIndex])
-		if err != nil {
-			log.Fatal(err)
-		}
-		date := dateTime.Format("2006-01-02")
+	// The first SIGINT or SIGTERM stops fetching and writes a partial report
+	// and a checkpoint; a second one exits immediately.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	if a.runTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.runTimeout)
+		defer cancel()
+	}
 
-		if results[date] == nil {
-			results[date] = &Result{
-				Cost:                new(big.Float).SetFloat64(0),
-				AvgCallDataGasPrice: new(big.Float).SetUint64(0),
-				AvgBlobGasPrice:     new(big.Float).SetUint64(0),
+	// With -per-tx, transactions are streamed to the report while fetching.
+	// A resumed run appends to the lines of the interrupted one.
+	var (
+		stream    txWriter
+		streamErr error
+	)
+	defer func() {
+		if stream != nil {
+			stream.Close()
+		}
+	}()
+	onStart := func(name string, rows int) error {
+		if !a.perTx {
+			return nil
+		}
+		base := reportBase(a.outDir, outFile, name)
+		var err error
+		switch a.format {
+		case "parquet":
+			if a.resume {
+				a.log.Warn("the transactions table of a resumed run only holds the transactions fetched by it")
 			}
+			stream, err = output.CreateParquetTx(base+".transactions.parquet", a.spillDir)
+			a.artifacts = append(a.artifacts, base+".transactions.parquet")
+		case "csv":
+			stream, err = output.CreateCSVTx(base+".transactions.csv", a.resume)
+			a.artifacts = append(a.artifacts, base+".transactions.csv")
+		default:
+			stream, err = output.CreateJSONL(base+".jsonl", a.resume)
 		}
+		return err
+	}
+	// Upserts make the database safe to feed with repeated or overlapping
+	// runs.
+	var (
+		db      sink.Sink
+		sinkErr error
+	)
+	if a.sinkKind != "" && !a.dryRun {
+		if db, err = sink.Open(a.sinkKind, a.dsn); err != nil {
+			return err
+		}
+		defer func() {
+			if db != nil {
+				db.Close()
+			}
+		}()
+	}
+	if a.heatmapOut {
+		a.heat = &heatmap.Heatmap{Location: a.location}
+		if a.resume {
+			a.log.Warn("-heatmap only covers the transactions processed after resuming")
+		}
+	}
+	if a.noncesOut {
+		a.nonces = &nonce.Tracker{}
+		if a.resume {
+			a.log.Warn("-nonces only covers the transactions processed after resuming")
+		}
+	}
+	if a.perSender && (a.resume || a.appendRuns) {
+		a.log.Warn("-per-sender only covers the transactions processed by this run")
+	}
+	onTx := func(tx aggregate.Tx) {
+		if a.heat != nil {
+			a.heat.Add(tx)
+		}
+		if a.nonces != nil {
+			a.nonces.Add(tx)
+		}
+		if stream != nil && streamErr == nil {
+			streamErr = stream.WriteTx(tx)
+		}
+		if db != nil && sinkErr == nil {
+			sinkErr = db.WriteTx(tx)
+		}
+	}
 
-		txHash := common.HexToHash(record[txHashIndex])
+	cfg := tracker.Config{
+		RPC:              a.rpcURLs,
+		Name:             a.name,
+		Input:            a.inputSpec,
+		InputFormat:      a.inputFmt,
+		Stream:           a.streamInput,
+		CSV:              input.CSVOptions{TxHashCol: a.txHashCol, DateTimeCol: a.dateTimeCol, SubmittedCol: a.submittedCol, TimeFormat: a.timeFormat, Delimiter: a.comma},
+		Scan:             a.scan,
+		Senders:          a.senders,
+		Recipients:       a.recipients,
+		FromBlock:        a.fromBlock,
+		ToBlock:          a.toBlock,
+		FromDate:         a.fromDate,
+		ToDate:           a.toDate,
+		Etherscan:        a.useEtherscan,
+		EtherscanURL:     a.etherscanURL,
+		EtherscanKey:     a.etherscanKey,
+		EtherscanRPS:     a.etherscanRPS,
+		IndexerFallback:  a.indexerFallback,
+		BigQuery:         a.useBigQuery,
+		BigQueryProject:  a.bigQueryProject,
+		BigQueryTable:    a.bigQueryTable,
+		DuneQuery:        a.duneQuery,
+		DuneURL:          a.duneURL,
+		DuneKey:          a.duneKey,
+		DuneParams:       a.queryParams,
+		DuneExecute:      a.duneExecute,
+		Granularity:      a.granularity,
+		Location:         a.location,
+		From:             a.from,
+		To:               a.to,
+		Concurrency:      a.concurrency,
+		BatchSize:        a.batchSize,
+		BlockReceiptsMin: a.blockReceiptsMin,
+		Retry:            fetch.RetryPolicy{MaxAttempts: a.maxAttempts, BaseDelay: a.retryDelay, MaxDelay: a.retryMaxDelay},
+		RequestTimeout:   a.requestTimeout,
+		RPS:              a.rps,
+		CachePath:        a.cachePath,
+		Offline:          a.offline,
+		DryRun:           a.dryRun,
+		TrustCSV:         a.trustCSV,
+		TrustCSVSample:   a.trustSample,
+		BaseFees:         a.tips || a.inclusion || a.overpayment || a.tipMarket,
+		Beacon:           a.beaconURL,
+		Roles:            a.roles,
+		Deposits:         a.deposits,
+		Disputes:         a.disputes,
+		Methods:          a.methods,
+		BySender:         a.bySender,
+		PerSender:        a.perSender,
+		ExpectedSenders:  a.allowed,
+		Nonces:           a.noncesOut,
+		Frames:           a.frames || a.scalars || a.whatIf || a.compression || a.channels || a.efficiency,
+		Channels:         a.channels,
+		WhatIf:           a.whatIf,
+		OracleBlocks:     a.oracleWindow,
+		OraclePercentile: a.oraclePercentile,
+		MarketTips:       a.tipMarket,
+		CalldataFloor:    a.calldataFloor,
+		BlobSchedules:    a.blobSchedules,
+		SpillDir:         a.spillDir,
+		DataSizes:        a.layers != nil || a.compression || a.efficiency || a.benchmarks != "" || onReport != nil,
+		OutDir:           a.outDir,
+		CheckpointPath:   a.checkpointPath,
+		CheckpointEvery:  a.checkpointEvery,
+		Resume:           a.resume,
+		Append:           a.appendRuns,
+		StatePath:        a.statePath,
+		ProgressFile:     a.progressFile,
+		ProgressInterval: a.progressInterval,
+		ProgressLog:      a.progressLog,
+		Logger:           a.log,
+		OnStart:          onStart,
+		OnTx:             onTx,
+	}
+	a.report, err = tracker.Run(ctx, cfg)
+	stop()
+	if err != nil {
+		return err
+	}
+	if streamErr != nil {
+		return streamErr
+	}
+	if a.report.DryRun != nil {
+		printDryRun(a.stdout, a.report, a.concurrency, a.rps)
+		return nil
+	}
+	if a.fillGapsFlag {
+		added, err := fillGaps(&a.report, a.granularity, a.location, a.from, a.to)
+		if err != nil {
+			return fmt.Errorf("-fill-gaps: %w", err)
+		}
+		if added > 0 {
+			a.log.Info("filled buckets without transactions", "buckets", added)
+		}
+	}
+	if db != nil {
+		if w, ok := db.(sink.BucketWriter); ok && !a.report.WasInterrupted() {
+			sinkErr = errors.Join(sinkErr, w.WriteBuckets(a.report.Dates, a.report.Results))
+		}
+		err := db.Close()
+		db = nil
+		if err = errors.Join(sinkErr, err); err != nil {
+			return fmt.Errorf("-sink %s: %w", a.sinkKind, err)
+		}
+		a.log.Info("sink updated", "sink", a.sinkKind)
+	}
 
-		receipt, err := client.TransactionReceipt(context.Background(), txHash)
+	// The report is named after the input, with the extension of -format,
+	// unless -out names it. Its companion files are named after it.
+	a.base = reportBase(a.outDir, outFile, a.report.Name)
+	a.ext = a.format
+	if a.ext == "markdown" {
+		a.ext = "md"
+	}
+	a.outPath = a.base + "." + a.ext
+	if outFile != "" {
+		a.outPath = outFile
+	}
+	// With the report on stdout, the summary goes to stderr.
+	summary := a.stdout
+	if a.outPath == "-" {
+		summary = os.Stderr
+	}
+	// The summary is also the body of the -email-to message.
+	var summaryText bytes.Buffer
+	if mailer != nil {
+		summary = io.MultiWriter(summary, &summaryText)
+	}
+	if err := a.writeCompanions(); err != nil {
+		return err
+	}
+	if err := a.addColumns(cfg); err != nil {
+		return err
+	}
+	if err := a.printSummaries(summary); err != nil {
+		return err
+	}
+	var rw *runway
+	today := time.Now().In(a.location).Format(time.DateOnly)
+	if a.runwayOn {
+		r, err := readRunway(a.rpcURLs, a.requestTimeout, a.senders, a.report.Dates, a.report.Results, a.runwayWindow, today)
 		if err != nil {
-			log.Fatal(err)
+			return fmt.Errorf("-runway: %w", err)
 		}
-		results[date].TxCount += 1
+		rw = &r
+		printRunwaySummary(summary, r)
+	}
+	if len(a.report.Dates) > 0 {
+		a.log.Info("coverage", "from", a.report.Dates[0], "to", a.report.Dates[len(a.report.Dates)-1], "buckets", len(a.report.Dates))
+	}
 
-		costWei := calcCost(receipt)
-		costEth := weiToEther(costWei)
+	if stream != nil {
+		err := stream.Close()
+		stream = nil
+		if err != nil {
+			return err
+		}
+	}
+	// A report to stdout is written to a temporary file first, as the
+	// writers of some formats need a file.
+	target := a.outPath
+	if a.outPath == "-" {
+		tmp, err := os.CreateTemp(a.outDir, "output-*."+a.ext)
+		if err != nil {
+			return err
+		}
+		tmp.Close()
+		target = tmp.Name()
+		defer os.Remove(target)
+	}
+	if err := a.writeReport(target); err != nil {
+		return err
+	}
+	if dest != nil {
+		keys, err := dest.UploadRun(context.Background(), start, a.artifacts)
+		if err != nil {
+			return fmt.Errorf("-upload: %w", err)
+		}
+		a.log.Info("uploaded", "to", a.uploadTo, "keys", keys)
+	}
+	if a.report.WasInterrupted() {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("-run-timeout of %v reached", a.runTimeout)
+		}
+		return errors.New("interrupted")
+	}
+	if onReport != nil {
+		onReport(a.report, a.granularity)
+	}
+	if err := a.deliver(mailer, summaryText.String(), target, rw, today); err != nil {
+		return err
+	}
+	if len(a.report.Failures) > 0 {
+		if rate := float64(len(a.report.Failures)) / float64(a.report.Rows); rate > a.maxFailureRate {
+			return fmt.Errorf("failure rate %.2f%% exceeds -max-failure-rate %.2f%%; the report is incomplete", 100*rate, 100*a.maxFailureRate)
+		}
+		a.log.Warn("the report leaves out the failed transactions", "failed", len(a.report.Failures))
+		return nil
+	}
+	// A complete report supersedes the partial one of an interrupted run.
+	os.Remove(a.base + ".partial." + a.ext)
+	os.Remove(a.base + ".partial.meta.json")
+	return nil
+}
 
-		results[date].Cost.Add(results[date].Cost, costEth)
+// txWriter receives the transactions streamed with -per-tx.
+type txWriter interface {
+	WriteTx(aggregate.Tx) error
+	Close() error
+}
 
-		callDataGasPrice := receipt.EffectiveGasPrice
-		results[date].AvgCallDataGasPrice.Add(
-			results[date].AvgCallDataGasPrice,
-			weiToGwei(callDataGasPrice),
-		)
+// webhookPayload is the body of the "report" event: the JSON report of the
+// run and what it covered.
+type webhookPayload struct {
+	Input  string          `json:"input"`
+	Rows   int             `json:"rows"`
+	Failed int             `json:"failed"`
+	Report json.RawMessage `json:"report"`
+}
 
-		results[date].TotalCalldataGasUsed += receipt.GasUsed
+// postReport delivers the report of a run to a webhook.
+func postReport(url, secret, granularity string, report tracker.Report, log *slog.Logger) error {
+	data, err := output.EncodeJSON(granularity, report.Dates, report.Results, report.Total)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(webhookPayload{Input: report.Name, Rows: report.Rows, Failed: len(report.Failures), Report: data})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	if err := webhook.New(url, secret).Post(ctx, "report", body); err != nil {
+		return err
+	}
+	log.Info("webhook delivered", "event", "report")
+	return nil
+}
 
-		if receipt.Type == types.BlobTxType {
-			blobGasPrice := receipt.BlobGasPrice
-			results[date].AvgBlobGasPrice.Add(
-				results[date].AvgBlobGasPrice,
-				weiToGwei(blobGasPrice),
-			)
-			results[date].TotalBlobGasUsed += receipt.BlobGasUsed
-		}
+// mailReport emails the summary of a run with its report file attached.
+func mailReport(mailer *email.Mailer, granularity string, report tracker.Report, summary, path string, log *slog.Logger) error {
+	period := map[string]string{"block": "Per-block", "hour": "Hourly", "day": "Daily", "week": "Weekly", "month": "Monthly"}[granularity]
+	subject := fmt.Sprintf("%s L1 costs of %s", period, report.Name)
+	if len(report.Dates) > 0 {
+		subject += fmt.Sprintf(", %s to %s", report.Dates[0], report.Dates[len(report.Dates)-1])
+	}
+	if err := mailer.Send(subject, summary, path); err != nil {
+		return err
+	}
+	log.Info("report emailed", "to", len(mailer.To))
+	return nil
+}
 
+// updateSheet writes the buckets of report to a tab of a Google spreadsheet.
+func updateSheet(credentials, spreadsheetID, sheet string, report tracker.Report, log *slog.Logger) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	client, err := sheets.NewClient(ctx, credentials, spreadsheetID, sheet)
+	if err != nil {
+		return err
+	}
+	updated, appended, err := client.Upsert(ctx, report.Dates, report.Results)
+	if err != nil {
+		return err
 	}
+	log.Info("sheet updated", "sheet", sheet, "updated", updated, "appended", appended)
+	return nil
+}
 
-	for k, v := range results {
-		v.AvgCallDataGasPrice.Quo(v.AvgCallDataGasPrice, new(big.Float).SetUint64(v.TxCount))
-		v.AvgBlobGasPrice.Quo(v.AvgBlobGasPrice, new(big.Float).SetUint64(v.TxCount))
-		v.TotalGasUsed = v.TotalCalldataGasUsed + v.TotalBlobGasUsed
+// splitOut splits the -out value out into the directory of the report and
+// its companion files and, when out names the report, its path: a path with
+// an extension, or - for stdout, whose companion files go to ./outputs.
+func splitOut(out string) (dir, file string) {
+	switch {
+	case out == "-":
+		return "./outputs", out
+	case filepath.Ext(out) != "":
+		return filepath.Dir(out), out
+	}
+	return out, ""
+}
 
-		fmt.Printf("%s: %v\n", k, v)
+// reportBase returns the path of the report named name, without its
+// extension, from which its companion files are named: in dir unless -out
+// names the report file, file.
+func reportBase(dir, file, name string) string {
+	if file != "" && file != "-" {
+		return strings.TrimSuffix(file, filepath.Ext(file))
 	}
+	return filepath.Join(dir, "output-"+strings.TrimSuffix(name, ".csv"))
+}
 
-	outFile, err := os.Create(fmt.Sprintf("./outputs/output-%s", fileName))
+// copyToStdout copies the file at path to stdout.
+func copyToStdout(stdout io.Writer, path string) error {
+	f, err := os.Open(path)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	defer outFile.Close()
-
-	writer := csv.NewWriter(outFile)
-	defer writer.Flush()
+	defer f.Close()
+	_, err = io.Copy(stdout, f)
+	return err
+}
 
-	header := []string{
-		"DateTime",
-		"Total Cost(ETH)",
-		"Avg Calldata gas price(Gwei)",
-		"Avg Blob Gas Price(Gwei)",
-		"Total Calldata Gas Used",
-		"Total Blob Gas Used",
-		"Total Gas Used(calldata + blob)",
-		"Transaction Count",
+// writeJSONL writes one JSON Lines object per bucket of report to path.
+func writeJSONL(path string, report tracker.Report) error {
+	w, err := output.CreateJSONL(path, false)
+	if err != nil {
+		return err
 	}
-	if err := writer.Write(header); err != nil {
-		log.Fatal(err)
+	defer w.Close()
+	if err := w.WriteBuckets(report.Dates, report.Results); err != nil {
+		return err
 	}
-	for k, v := range results {
-		record := []string{
-			k,
-			v.Cost.String(),
-			v.AvgCallDataGasPrice.String(),
-			v.AvgBlobGasPrice.String(),
-			strconv.FormatUint(v.TotalCalldataGasUsed, 10),
-			strconv.FormatUint(v.TotalBlobGasUsed, 10),
-			strconv.FormatUint(v.TotalGasUsed, 10),
-			strconv.FormatUint(v.TxCount, 10),
-		}
-		if err := writer.Write(record); err != nil {
-			log.Fatal(err)
-		}
+	return w.Close()
+}
+
+// meanColumns returns the report columns of the simple mean gas prices.
+func meanColumns(results map[string]*aggregate.Result) []output.Column {
+	calldata := output.Column{Header: "Mean Calldata Gas Price(Gwei)", Values: make(map[string]string, len(results))}
+	blob := output.Column{Header: "Mean Blob Gas Price(Gwei)", Values: make(map[string]string, len(results))}
+	for k, r := range results {
+		calldata.Values[k] = r.MeanCallDataGasPrice.String()
+		blob.Values[k] = r.BlobDependent(r.MeanBlobGasPrice)
 	}
+	return []output.Column{calldata, blob}
 }
 
-func weiToEther(wei *big.Int) *big.Float {
-	return new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(params.Ether))
+// feeColumns returns the report columns splitting the execution fee into base
+// fee and priority tips, unavailable for buckets with transactions whose base
+// fee is unknown.
+func feeColumns(results map[string]*aggregate.Result) []output.Column {
+	base := output.Column{Header: "Base Fee Burnt(ETH)", Values: make(map[string]string, len(results))}
+	tip := output.Column{Header: "Priority Tips(ETH)", Values: make(map[string]string, len(results))}
+	baseWei := output.Column{Header: "Base Fee Burnt(wei)", Values: make(map[string]string, len(results))}
+	tipWei := output.Column{Header: "Priority Tips(wei)", Values: make(map[string]string, len(results))}
+	share := output.Column{Header: "Tip Share(%)", Values: make(map[string]string, len(results))}
+	for k, r := range results {
+		if r.BaseFeeMissing > 0 {
+			base.Values[k], tip.Values[k], share.Values[k] = aggregate.Unavailable, aggregate.Unavailable, aggregate.Unavailable
+			baseWei.Values[k], tipWei.Values[k] = aggregate.Unavailable, aggregate.Unavailable
+			continue
+		}
+		base.Values[k], tip.Values[k] = r.BaseFeeCost.String(), r.PriorityFeeCost.String()
+		baseWei.Values[k], tipWei.Values[k] = r.BaseFeeCostWei.String(), r.PriorityFeeCostWei.String()
+		if r.CalldataCostWei.Sign() > 0 {
+			pct, _ := new(big.Rat).SetFrac(r.PriorityFeeCostWei, r.CalldataCostWei).Float64()
+			share.Values[k] = strconv.FormatFloat(100*pct, 'f', 2, 64)
+		}
+	}
+	return []output.Column{base, tip, baseWei, tipWei, share}
 }
 
-func weiToGwei(wei *big.Int) *big.Float {
-	return new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(params.GWei))
+// utilizationColumn gives the share of the capacity of the blobs read from the
+// beacon node that their data fills, empty for buckets without such blobs.
+func utilizationColumn(results map[string]*aggregate.Result) output.Column {
+	c := output.Column{Header: "Blob Utilization(%)", Values: make(map[string]string, len(results))}
+	for k, r := range results {
+		if pct, ok := r.BlobUtilization(); ok {
+			c.Values[k] = strconv.FormatFloat(pct, 'f', 2, 64)
+		}
+	}
+	return c
 }
 
-func calcCost(r *types.Receipt) *big.Int {
-	total := new(big.Int).Mul(r.EffectiveGasPrice, new(big.Int).SetUint64(r.GasUsed))
-	if r.Type == types.BlobTxType {
-		total.Add(
-			total,
-			new(big.Int).Mul(r.BlobGasPrice, new(big.Int).SetUint64(r.BlobGasUsed)),
-		)
+// envInt returns the integer value of the environment variable key, or def
+// when it is unset or malformed.
+func envInt(key string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return v
 	}
-	return total
+	return def
 }
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"slices"
+	"strconv"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+)
+
+// row is a bucket of a report, its values keyed by column header.
+type row map[string]string
+
+// number returns the value of column in r, and false when it is missing,
+// empty or unavailable.
+func (r row) number(column string) (float64, bool) {
+	v, err := strconv.ParseFloat(r[column], 64)
+	return v, err == nil
+}
+
+// mergeRule combines the values of a column in the rows of a bucket found in
+// several reports, "" when it cannot.
+type mergeRule func(column string, rows []row) string
+
+// formatNumber formats a merged value like the reports do.
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(v, 'g', 10, 64)
+}
+
+// sumRule adds the values up.
+func sumRule(column string, rows []row) string {
+	var sum float64
+	for _, r := range rows {
+		v, ok := r.number(column)
+		if !ok {
+			return ""
+		}
+		sum += v
+	}
+	return formatNumber(sum)
+}
+
+// weiRule adds the exact amounts in wei up.
+func weiRule(column string, rows []row) string {
+	sum := new(big.Int)
+	for _, r := range rows {
+		v, ok := new(big.Int).SetString(r[column], 10)
+		if !ok {
+			return ""
+		}
+		sum.Add(sum, v)
+	}
+	return sum.String()
+}
+
+// weightedRule averages the values weighted by the column weight, such as
+// the gas prices by the gas used.
+func weightedRule(weight string) mergeRule {
+	return func(column string, rows []row) string {
+		var sum, total float64
+		for _, r := range rows {
+			w, ok := r.number(weight)
+			if !ok {
+				return ""
+			}
+			if w == 0 {
+				continue
+			}
+			v, ok := r.number(column)
+			if !ok {
+				return ""
+			}
+			sum += v * w
+			total += w
+		}
+		if total == 0 {
+			return ""
+		}
+		return formatNumber(sum / total)
+	}
+}
+
+// blobsPerTxRule recomputes the blobs per blob transaction from the blob
+// counts, the number of blob transactions being the blobs over the ratio.
+func blobsPerTxRule(column string, rows []row) string {
+	var blobs, txs float64
+	for _, r := range rows {
+		n, ok := r.number("Blob Count")
+		if !ok {
+			return ""
+		}
+		if n == 0 {
+			continue
+		}
+		v, ok := r.number(column)
+		if !ok || v == 0 {
+			return ""
+		}
+		blobs += n
+		txs += n / v
+	}
+	if txs == 0 {
+		return "0"
+	}
+	return formatNumber(blobs / txs)
+}
+
+// mergeRules are the rules of the columns that can be merged. The other
+// columns, such as the percentiles, are left empty in merged buckets.
+var mergeRules = map[string]mergeRule{
+	"Total Cost(ETH)":                 sumRule,
+	"Total Calldata Gas Used":         sumRule,
+	"Total Blob Gas Used":             sumRule,
+	"Total Gas Used(calldata + blob)": sumRule,
+	"Transaction Count":               sumRule,
+	"Blob Count":                      sumRule,
+	"Successful Txs":                  sumRule,
+	"Reverted Txs":                    sumRule,
+	"Successful Cost(ETH)":            sumRule,
+	"Reverted Cost(ETH)":              sumRule,
+	"Calldata Cost(ETH)":              sumRule,
+	"Blob Cost(ETH)":                  sumRule,
+	"Total Cost(wei)":                 weiRule,
+	"Calldata Cost(wei)":              weiRule,
+	"Blob Cost(wei)":                  weiRule,
+	"Reverted Cost(wei)":              weiRule,
+	"Avg Calldata gas price(Gwei)":    weightedRule("Total Calldata Gas Used"),
+	"Avg Blob Gas Price(Gwei)":        weightedRule("Total Blob Gas Used"),
+	"Blended Gas Price(Gwei)":         weightedRule("Total Gas Used(calldata + blob)"),
+	"Cost per Blob(ETH)":              weightedRule("Blob Count"),
+	"Blobs per Blob Tx":               blobsPerTxRule,
+}
+
+// mergeExtremes are the columns of the extreme transactions, of which merged
+// buckets keep the lowest or highest and its transaction.
+var mergeExtremes = []struct {
+	value, tx string
+	lowest    bool
+}{
+	{"Min Gas Price(Gwei)", "Min Gas Price Tx", true},
+	{"Max Gas Price(Gwei)", "Max Gas Price Tx", false},
+	{"Min Tx Cost(ETH)", "Min Tx Cost Tx", true},
+	{"Max Tx Cost(ETH)", "Max Tx Cost Tx", false},
+}
+
+// combineRows merges rows into a bucket named key. A value unavailable in
+// any of the rows is unavailable in the merged one.
+func combineRows(key string, header []string, rows []row) row {
+	merged := row{header[0]: key}
+	for _, column := range header[1:] {
+		rule, ok := mergeRules[column]
+		if !ok {
+			continue
+		}
+		merged[column] = rule(column, rows)
+		for _, r := range rows {
+			if r[column] == aggregate.Unavailable {
+				merged[column] = aggregate.Unavailable
+			}
+		}
+	}
+	for _, e := range mergeExtremes {
+		var best row
+		for _, r := range rows {
+			v, ok := r.number(e.value)
+			if !ok {
+				continue
+			}
+			if b, _ := best.number(e.value); best == nil || e.lowest && v < b || !e.lowest && v > b {
+				best = r
+			}
+		}
+		if best != nil {
+			merged[e.value], merged[e.tx] = best[e.value], best[e.tx]
+		}
+	}
+	return merged
+}
+
+// runMerge combines daily reports written by analyze into one continuous
+// series. Buckets found in several reports are merged: by default they are
+// taken to hold distinct transactions, whose sums and weighted averages are
+// recomputed.
+func runMerge(args []string) error {
+	fs := flag.NewFlagSet("merge", flag.ContinueOnError)
+	outPath := fs.String("output", "./outputs/output-merged.csv", "path of the merged report")
+	overlap := fs.String("overlap", "sum", "how buckets found in several reports are merged: sum their transactions, or keep the latest, the one of the last report given")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s merge [flags] output.csv ...\n\nFlags:\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if *overlap != "sum" && *overlap != "latest" {
+		return fmt.Errorf("unknown -overlap %q", *overlap)
+	}
+	if fs.NArg() < 2 {
+		return errors.New("merge takes at least two reports")
+	}
+
+	var header []string
+	buckets := make(map[string][]row)
+	for _, path := range fs.Args() {
+		records, err := readReport(path)
+		if err != nil {
+			return err
+		}
+		if header == nil {
+			header = records[0]
+		} else if records[0][0] != header[0] {
+			return fmt.Errorf("%s: buckets by %q, not %q", path, records[0][0], header[0])
+		}
+		for _, column := range records[0] {
+			if !slices.Contains(header, column) {
+				header = append(header, column)
+			}
+		}
+		for _, record := range records[1:] {
+			r := make(row, len(record))
+			for i, value := range record {
+				if i < len(records[0]) {
+					r[records[0][i]] = value
+				}
+			}
+			if *overlap == "latest" {
+				buckets[record[0]] = []row{r}
+			} else {
+				buckets[record[0]] = append(buckets[record[0]], r)
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(buckets))
+	for k := range buckets {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	var (
+		merged     []row
+		cumulative = 0.0
+		overlaps   int
+	)
+	for _, k := range keys {
+		r := buckets[k][0]
+		if len(buckets[k]) > 1 {
+			r = combineRows(k, header, buckets[k])
+			overlaps++
+		}
+		// The running total follows the merged series.
+		if slices.Contains(header, "Cumulative Cost(ETH)") {
+			if cost, ok := r.number("Total Cost(ETH)"); ok && !math.IsNaN(cumulative) {
+				cumulative += cost
+				r["Cumulative Cost(ETH)"] = formatNumber(cumulative)
+			} else {
+				cumulative = math.NaN()
+				r["Cumulative Cost(ETH)"] = ""
+			}
+		}
+		merged = append(merged, r)
+	}
+	total := combineRows("Total", header, merged)
+	// Unlike the sums, the running total has no total.
+	delete(total, "Cumulative Cost(ETH)")
+
+	records := [][]string{header}
+	for _, r := range append(merged, total) {
+		record := make([]string, len(header))
+		for i, column := range header {
+			record[i] = r[column]
+		}
+		records = append(records, record)
+	}
+	if err := writeRecords(*outPath, records); err != nil {
+		return err
+	}
+	fmt.Printf("%s: %d buckets from %d reports, %d merged\n", *outPath, len(merged), fs.NArg(), overlaps)
+	return nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"runtime/debug"
+	"strings"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/input"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+)
+
+// secretFlag matches the flags whose values are left out of the metadata.
+var secretFlag = regexp.MustCompile(`key|secret|password|token|credentials|dsn`)
+
+// toolVersion returns the module version of the binary. A development build
+// without one is identified by the commit it was built from, when known.
+func toolVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	version := info.Main.Version
+	var revision, modified string
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value
+		}
+	}
+	if (version == "" || version == "(devel)") && revision != "" {
+		version += " " + revision[:min(len(revision), 12)]
+		if modified == "true" {
+			version += "-dirty"
+		}
+	}
+	return version
+}
+
+// flagArgs returns the flags set on fs, from the command line or the config
+// file, as -name=value, leaving out the values of secrets and reducing URLs
+// to their scheme and host.
+func flagArgs(fs *flag.FlagSet) []string {
+	args := []string{}
+	fs.Visit(func(f *flag.Flag) {
+		value := f.Value.String()
+		if secretFlag.MatchString(f.Name) {
+			value = "redacted"
+		} else if strings.Contains(value, "://") {
+			value = strings.Join(endpointHosts(value), ",")
+		}
+		args = append(args, "-"+f.Name+"="+value)
+	})
+	return args
+}
+
+// endpointHosts returns the scheme and host of the URLs of a comma-separated
+// list, leaving out the credentials, path and query that often hold an API
+// key.
+func endpointHosts(list string) []string {
+	var hosts []string
+	for _, raw := range strings.Split(list, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		if u, err := url.Parse(raw); err == nil && u.Host != "" {
+			raw = u.Scheme + "://" + u.Host
+		}
+		hosts = append(hosts, raw)
+	}
+	return hosts
+}
+
+// inputFiles returns the input files of spec with the SHA-256 of their
+// content; stdin has none.
+func inputFiles(spec string) ([]output.InputFile, error) {
+	files, err := input.Files(spec)
+	if err != nil {
+		return nil, err
+	}
+	inputs := make([]output.InputFile, len(files))
+	for i, path := range files {
+		inputs[i].Path = path
+		if path == "-" {
+			continue
+		}
+		if inputs[i].SHA256, err = hashFile(path); err != nil {
+			return nil, err
+		}
+	}
+	return inputs, nil
+}
+
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+)
+
+// knownMethods are the signatures of the methods that batchers and proposers
+// call, named without a -method-names file.
+var knownMethods = []string{
+	"proposeL2Output(bytes32,uint256,bytes32,uint256)",
+	"create(uint32,bytes32,bytes)",
+	"deleteL2Outputs(uint256)",
+}
+
+// methodNames returns the names of method selectors: those of knownMethods and
+// of the file at path, if any. Every line of the file is a signature such as
+// proposeL2Output(bytes32,uint256,bytes32,uint256), or a selector followed by
+// a name; blank lines and lines starting with # are skipped.
+func methodNames(path string) (map[string]string, error) {
+	names := make(map[string]string)
+	for _, signature := range knownMethods {
+		names[selector(signature)] = signature[:strings.Index(signature, "(")]
+	}
+	if path == "" {
+		return names, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		switch {
+		case len(fields) == 1 && strings.Contains(text, "("):
+			names[selector(text)] = text[:strings.Index(text, "(")]
+		case len(fields) == 2:
+			b, err := hexutil.Decode(fields[0])
+			if err != nil || len(b) != 4 {
+				return nil, fmt.Errorf("%s:%d: invalid selector %q", path, line, fields[0])
+			}
+			names[hexutil.Encode(b)] = fields[1]
+		default:
+			return nil, fmt.Errorf("%s:%d: expected a signature or a selector and a name", path, line)
+		}
+	}
+	return names, scanner.Err()
+}
+
+// byCost returns the keys of the shares that shares picks from the results,
+// the most expensive over the report first.
+func byCost(results map[string]*aggregate.Result, shares func(*aggregate.Result) map[string]*aggregate.Share) []string {
+	totals := make(map[string]*big.Int)
+	for _, r := range results {
+		for key, share := range shares(r) {
+			if totals[key] == nil {
+				totals[key] = new(big.Int)
+			}
+			totals[key].Add(totals[key], share.CostWei)
+		}
+	}
+	keys := make([]string, 0, len(totals))
+	for key := range totals {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if c := totals[keys[i]].Cmp(totals[keys[j]]); c != 0 {
+			return c > 0
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// selector returns the hex encoded selector of a method signature.
+func selector(signature string) string {
+	return hexutil.Encode(crypto.Keccak256([]byte(signature))[:4])
+}
+
+// methodColumns returns the report columns of the transaction count and the
+// cost of every method, the most expensive over the report first, labelled
+// with the names of their selectors when known.
+func methodColumns(names map[string]string, results map[string]*aggregate.Result) []output.Column {
+	var columns []output.Column
+	for _, method := range byCost(results, func(r *aggregate.Result) map[string]*aggregate.Share { return r.Methods }) {
+		method := method
+		label := method
+		if name, ok := names[strings.ToLower(method)]; ok {
+			label = name
+		}
+		columns = append(columns, shareColumns(label, results, func(r *aggregate.Result) *aggregate.Share { return r.Methods[method] })...)
+	}
+	return columns
+}
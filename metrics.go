@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gauges are the /metrics gauges and the report column of their value.
+var gauges = []struct {
+	name, help, column string
+}{
+	{"l1_daily_cost_eth", "L1 cost of the batcher transactions of the day, in ETH.", "Total Cost(ETH)"},
+	{"l1_avg_calldata_gas_price_gwei", "Average calldata gas price of the day, in Gwei.", "Avg Calldata gas price(Gwei)"},
+	{"l1_avg_blob_gas_price_gwei", "Average blob gas price of the day, in Gwei.", "Avg Blob Gas Price(Gwei)"},
+	{"l1_blended_gas_price_gwei", "Cost of the day divided by its calldata and blob gas used, in Gwei.", "Blended Gas Price(Gwei)"},
+	{"l1_total_calldata_gas_used", "Calldata gas used by the batcher transactions of the day.", "Total Calldata Gas Used"},
+	{"l1_total_blob_gas_used", "Blob gas used by the batcher transactions of the day.", "Total Blob Gas Used"},
+	{"l1_daily_tx_count", "Number of batcher transactions of the day.", "Transaction Count"},
+}
+
+// labelEscaper escapes label values for the Prometheus text format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// reportLabels are the chain and batcher address of a report.
+type reportLabels struct {
+	chain, batcher string
+}
+
+// parseMetricsLabels parses comma-separated report=chain:batcher entries,
+// where report is the name of a report file without output- and .csv.
+func parseMetricsLabels(spec string) (map[string]reportLabels, error) {
+	labels := make(map[string]reportLabels)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		report, value, ok := strings.Cut(entry, "=")
+		chain, batcher, ok2 := strings.Cut(value, ":")
+		if !ok || !ok2 || report == "" || chain == "" {
+			return nil, fmt.Errorf("-metrics-labels: %q is not report=chain:batcher", entry)
+		}
+		labels[report] = reportLabels{chain: chain, batcher: strings.ToLower(batcher)}
+	}
+	return labels, nil
+}
+
+// metricsHandler exposes the latest day of the daily reports in dir in the
+// Prometheus text format. The reports are read on every scrape, so the
+// metrics follow the runs writing them. A chain and batcher reported by
+// several files, e.g. one per month, take the latest day of any of them.
+func metricsHandler(dir string, labels map[string]reportLabels) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		files, err := filepath.Glob(filepath.Join(dir, "output-*.csv"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		type latest struct {
+			day    string
+			header []string
+			record []string
+		}
+		days := make(map[reportLabels]*latest)
+		for _, file := range files {
+			name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(file), "output-"), ".csv")
+			if strings.HasSuffix(name, ".partial") {
+				continue
+			}
+			records, err := readReport(file)
+			if err != nil {
+				slog.Warn("skipping report", "file", file, "err", err)
+				continue
+			}
+			if len(records) < 2 || len(records[len(records)-1][0]) != len(time.DateOnly) {
+				// Empty, or not daily.
+				continue
+			}
+			l, ok := labels[name]
+			if !ok {
+				l = reportLabels{chain: name}
+			}
+			record := records[len(records)-1]
+			if d := days[l]; d == nil || record[0] > d.day {
+				days[l] = &latest{day: record[0], header: records[0], record: record}
+			}
+		}
+
+		keys := make([]reportLabels, 0, len(days))
+		for l := range days {
+			keys = append(keys, l)
+		}
+		slices.SortFunc(keys, func(a, b reportLabels) int {
+			return strings.Compare(a.chain+":"+a.batcher, b.chain+":"+b.batcher)
+		})
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		writeGauge := func(name, help string, value func(d *latest) (float64, bool)) {
+			fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+			for _, l := range keys {
+				if v, ok := value(days[l]); ok {
+					fmt.Fprintf(w, "%s{chain=\"%s\",batcher=\"%s\"} %s\n", name, labelEscaper.Replace(l.chain), labelEscaper.Replace(l.batcher), strconv.FormatFloat(v, 'g', -1, 64))
+				}
+			}
+		}
+		for _, g := range gauges {
+			writeGauge(g.name, g.help, func(d *latest) (float64, bool) {
+				// Values unavailable for lack of a blob gas price, and
+				// columns missing from older reports, have no sample.
+				i := slices.Index(d.header, g.column)
+				if i < 0 || i >= len(d.record) {
+					return 0, false
+				}
+				v, err := strconv.ParseFloat(d.record[i], 64)
+				return v, err == nil
+			})
+		}
+		writeGauge("l1_report_day_timestamp_seconds", "Start of the latest day of the reports, as a Unix timestamp.", func(d *latest) (float64, bool) {
+			t, err := time.Parse(time.DateOnly, d.day)
+			return float64(t.Unix()), err == nil
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// runSections runs analyze once per entry of the comma-separated list, with
+// args and the flag values of the entry in the section of the -config file
+// of fs; the command line takes precedence over both. run is called with the
+// name and the arguments of every entry, which disable flagName, the flag of
+// the list, so that the entry runs on its own.
+func runSections(fs *flag.FlagSet, args []string, flagName, section, list string, log *slog.Logger, run func(name string, args []string) error) error {
+	path := fs.Lookup("config").Value.String()
+	if path == "" {
+		return fmt.Errorf("-%s needs a -config file with a %s section", flagName, section)
+	}
+	sections, err := loadSections(path, section)
+	if err != nil {
+		return fmt.Errorf("-%s: %w", flagName, err)
+	}
+	names, err := sectionNames(path, section, list, sections)
+	if err != nil {
+		return fmt.Errorf("-%s: %w", flagName, err)
+	}
+
+	var errs []error
+	for _, name := range names {
+		values := sections[name]
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			// As in the rest of the file, settings of other commands are
+			// ignored.
+			if fs.Lookup(key) != nil {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		// Flags given first are overridden by args.
+		var entryArgs []string
+		for _, key := range keys {
+			entryArgs = append(entryArgs, "-"+key+"="+values[key])
+		}
+		entryArgs = append(append(entryArgs, args...), "-"+flagName+"=")
+		log.Info("running "+strings.TrimSuffix(section, "s"), "name", name)
+		if err := run(name, entryArgs); err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %w", strings.TrimSuffix(section, "s"), name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sectionNames returns the names of the comma-separated list, all of which
+// must be entries of sections.
+func sectionNames(path, section, list string, sections map[string]map[string]string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := sections[name]; !ok {
+			return nil, fmt.Errorf("%s: no %s in the %s section", path, name, section)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/nonce"
+)
+
+// printNonceSummary prints the gaps in the nonces of the senders and the
+// replaced transactions with the extra cost of their replacements.
+func printNonceSummary(w io.Writer, r nonce.Report) {
+	fmt.Fprintf(w, "Nonces: %d senders, %d gaps (%d nonces missing), %d replacements", r.Senders, len(r.Gaps), r.Missing, len(r.Replacements))
+	if len(r.Replacements) > 0 {
+		fmt.Fprintf(w, ", extra cost %s ETH", ether(r.ExtraCost).String())
+		if r.Unpriced > 0 {
+			fmt.Fprintf(w, " (%d without the gas price of the replaced transactions)", r.Unpriced)
+		}
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+)
+
+// overpaymentColumns returns, for every bucket, the average tip a fee oracle
+// would have suggested and the one paid, the number of transactions that
+// paid more and what they paid above the oracle's tip. Buckets without oracle
+// tips are left empty.
+func overpaymentColumns(results map[string]*aggregate.Result) []output.Column {
+	oracle := output.Column{Header: "Avg Oracle Tip(Gwei)", Values: make(map[string]string, len(results))}
+	paid := output.Column{Header: "Avg Paid Tip(Gwei)", Values: make(map[string]string, len(results))}
+	txs := output.Column{Header: "Overpaid Txs", Values: make(map[string]string, len(results))}
+	cost := output.Column{Header: "Overpayment(ETH)", Values: make(map[string]string, len(results))}
+	costWei := output.Column{Header: "Overpayment(wei)", Values: make(map[string]string, len(results))}
+	for k, r := range results {
+		if r.OracleTxCount == 0 {
+			continue
+		}
+		n := float64(r.OracleTxCount)
+		oracle.Values[k] = strconv.FormatFloat(r.OracleTipSum/n, 'f', 3, 64)
+		paid.Values[k] = strconv.FormatFloat(r.PaidTipSum/n, 'f', 3, 64)
+		txs.Values[k] = strconv.FormatUint(r.OverpaidTxCount, 10)
+		cost.Values[k], costWei.Values[k] = r.Overpayment.String(), r.OverpaymentWei.String()
+	}
+	return []output.Column{oracle, paid, txs, cost, costWei}
+}
+
+// printOverpaymentSummary prints what the transactions paid above the tips a
+// fee oracle would have suggested over the whole report.
+func printOverpaymentSummary(w io.Writer, total *aggregate.Result) {
+	if total.OracleTxCount == 0 {
+		fmt.Fprintln(w, "Overpayment: no transaction with an oracle tip")
+		return
+	}
+	n := float64(total.OracleTxCount)
+	fmt.Fprintf(w, "Overpayment: %d of %d txs paid more than the oracle tip, %s ETH above it; average tip %.3f Gwei against %.3f Gwei suggested",
+		total.OverpaidTxCount, total.OracleTxCount, total.Overpayment.String(), total.PaidTipSum/n, total.OracleTipSum/n)
+	if total.CalldataCost.Sign() > 0 {
+		share, _ := new(big.Float).Quo(total.Overpayment, total.CalldataCost).Float64()
+		fmt.Fprintf(w, ", %.2f%% of the execution fees", 100*share)
+	}
+	fmt.Fprintln(w)
+}
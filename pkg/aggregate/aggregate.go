@@ -0,0 +1,1112 @@
+// Package aggregate sums transaction costs and gas usage into daily or
+// weekly buckets.
+package aggregate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/input"
+)
+
+// Result holds the totals and averages of one bucket.
+type Result struct {
+	Cost         *big.Float // ETH
+	CalldataCost *big.Float // ETH
+	BlobCost     *big.Float // ETH
+	// CostWei, CalldataCostWei, BlobCostWei and RevertedCostWei are the
+	// exact sums in wei from which Finalize and Rollup derive Cost,
+	// CalldataCost, BlobCost and RevertedCost, so that these do not drift
+	// with the rounding of every transaction. The other costs in ETH are
+	// derived likewise from the fields of the same name ending in Wei.
+	CostWei         *big.Int
+	CalldataCostWei *big.Int
+	BlobCostWei     *big.Int
+	RevertedCostWei *big.Int
+	// BaseFeeCost and PriorityFeeCost split the calldata cost, the execution
+	// fee, into the base fee burnt and the priority tip, over the
+	// transactions whose block base fee is known. BaseFeeMissing counts the
+	// others.
+	BaseFeeCost        *big.Float // ETH
+	PriorityFeeCost    *big.Float // ETH
+	BaseFeeCostWei     *big.Int
+	PriorityFeeCostWei *big.Int
+	// BlobTxCost is the cost of the blob transactions, execution fee
+	// included, for the cost per blob.
+	BlobTxCost    *big.Float // ETH
+	BlobTxCostWei *big.Int
+	// RevertedCost is the cost of the transactions that reverted, status 0,
+	// which burn gas without their data being accepted.
+	RevertedCost        *big.Float // ETH
+	AvgCallDataGasPrice *big.Float // Gwei, calldata cost per unit of calldata gas
+	AvgBlobGasPrice     *big.Float // Gwei, blob cost per unit of blob gas
+	// MeanCallDataGasPrice and MeanBlobGasPrice are the simple means of the
+	// prices over the transactions, for comparison with the gas-weighted
+	// averages. The blob mean counts every transaction of the bucket.
+	MeanCallDataGasPrice *big.Float // Gwei
+	MeanBlobGasPrice     *big.Float // Gwei
+	BlendedGasPrice      *big.Float // Gwei, total cost per unit of calldata + blob gas
+	// CalldataGasPrices and BlobGasPrices sketch the prices of the
+	// transactions in Gwei, from which their Percentiles are taken.
+	CalldataGasPrices Sketch
+	BlobGasPrices     Sketch
+	// InclusionDelays sketches the seconds from the submission of the
+	// transactions to the mempool, when the input has it, to their block.
+	// DelayTip pairs them with the priority tips in Gwei of the
+	// transactions whose base fee is known.
+	InclusionDelays Sketch
+	DelayTip        *Moments `json:",omitempty"`
+	// TipRatios sketches the priority tips of the transactions whose base
+	// fee and block median tip are known, as multiples of that median.
+	TipRatios Sketch
+	// MinGasPriceTx and MaxGasPriceTx are the transactions with the lowest
+	// and highest calldata gas price, MinCostTx and MaxCostTx those with the
+	// lowest and highest cost. They are nil in results read back from a
+	// database.
+	MinGasPriceTx *Extreme `json:",omitempty"`
+	MaxGasPriceTx *Extreme `json:",omitempty"`
+	MinCostTx     *Extreme `json:",omitempty"`
+	MaxCostTx     *Extreme `json:",omitempty"`
+	// Roles splits the transactions by the role of their recipient when the
+	// aggregator has Roles, with those sent elsewhere under OtherRole.
+	Roles map[string]*Share `json:",omitempty"`
+	// Methods splits the transactions by the method they call, the hex
+	// selector or name of their row, with NoMethod for those without one.
+	// Only when the aggregator has Methods set.
+	Methods map[string]*Share `json:",omitempty"`
+	// Senders splits the transactions by their sender, the hex address or
+	// UnknownSender. Only when the aggregator has Senders set.
+	Senders              map[string]*Share `json:",omitempty"`
+	TotalCalldataGasUsed uint64
+	TotalBlobGasUsed     uint64
+	TotalGasUsed         uint64
+	TxCount              uint64
+	BlobTxCount          uint64
+	RevertedTxCount      uint64
+	// MeasuredBlobs is the number of blobs read from a beacon node and
+	// BlobPayloadBytes the bytes of data they carry.
+	MeasuredBlobs    uint64 `json:",omitempty"`
+	BlobPayloadBytes uint64 `json:",omitempty"`
+	// FrameBytes is the size of the channel data of the batcher frames
+	// decoded from the transactions, and FrameCalldataGas and FrameBlobGas
+	// the gas used by the transactions that submitted them.
+	FrameBytes       uint64 `json:",omitempty"`
+	FrameCalldataGas uint64 `json:",omitempty"`
+	FrameBlobGas     uint64 `json:",omitempty"`
+	// PostedBytes is the size of the data the transactions posted, when the
+	// fetcher measured it.
+	PostedBytes uint64 `json:",omitempty"`
+	// ChannelBytes is the compressed size of the channels that the
+	// transactions completed and RawBytes the size of their batches.
+	ChannelBytes uint64 `json:",omitempty"`
+	RawBytes     uint64 `json:",omitempty"`
+	// L2Blocks and L2Txs count the L2 blocks and transactions of the
+	// batches of those channels.
+	L2Blocks uint64 `json:",omitempty"`
+	L2Txs    uint64 `json:",omitempty"`
+	// WhatIfTxCount is the number of transactions whose data was priced in
+	// the other posting mode, WhatIfCost what they would have cost so in ETH
+	// and WhatIfActualCost what they did cost.
+	WhatIfTxCount       uint64     `json:",omitempty"`
+	WhatIfCost          *big.Float // ETH
+	WhatIfActualCost    *big.Float // ETH
+	WhatIfCostWei       *big.Int
+	WhatIfActualCostWei *big.Int
+	// OracleTxCount is the number of transactions with a known base fee for
+	// which the fetcher computed the tip a fee oracle would have suggested,
+	// OverpaidTxCount those of them that paid a higher tip and Overpayment
+	// what they paid above the oracle's tip in ETH. OracleTipSum and
+	// PaidTipSum sum the suggested and paid tips in Gwei.
+	OracleTxCount   uint64     `json:",omitempty"`
+	OverpaidTxCount uint64     `json:",omitempty"`
+	Overpayment     *big.Float // ETH
+	OracleTipSum    float64    `json:",omitempty"`
+	PaidTipSum      float64    `json:",omitempty"`
+	OverpaymentWei  *big.Int
+	// FloorTxCount is the number of calldata transactions whose EIP-7623
+	// floor gas the fetcher computed, FloorActiveTxCount those of blocks
+	// enforcing it and FloorPricedTxCount those of them charged the floor.
+	// FloorExtraCost is what the floor would have added to the cost of the
+	// others in ETH, so that the costs of both eras compare.
+	FloorTxCount       uint64     `json:",omitempty"`
+	FloorActiveTxCount uint64     `json:",omitempty"`
+	FloorPricedTxCount uint64     `json:",omitempty"`
+	FloorExtraCost     *big.Float // ETH
+	FloorExtraCostWei  *big.Int
+	// ScheduleTxCount is the number of blob transactions whose blob fees
+	// were replayed under alternative blob schedules, ScheduleActualCost the
+	// blob fees they paid and ScheduleBlobCost those they would have paid
+	// under every schedule, by its name, in ETH.
+	ScheduleTxCount       uint64                `json:",omitempty"`
+	ScheduleActualCost    *big.Float            // ETH
+	ScheduleBlobCost      map[string]*big.Float `json:",omitempty"`
+	ScheduleActualCostWei *big.Int
+	ScheduleBlobCostWei   map[string]*big.Int `json:",omitempty"`
+	// BlobPriceMissing counts blob transactions whose receipt had no blob
+	// gas price. Columns that depend on it are reported as Unavailable.
+	BlobPriceMissing uint64
+	BaseFeeMissing   uint64
+}
+
+// Moments sums pairs of values, and their squares and products, to correlate
+// them without keeping them.
+type Moments struct {
+	N, X, Y, XX, YY, XY float64
+}
+
+// Add adds the pair x, y.
+func (m *Moments) Add(x, y float64) {
+	m.N++
+	m.X += x
+	m.Y += y
+	m.XX += x * x
+	m.YY += y * y
+	m.XY += x * y
+}
+
+// mergeMoments adds the pairs of v to *m, allocating it if needed.
+func mergeMoments(m **Moments, v *Moments) {
+	if v == nil {
+		return
+	}
+	if *m == nil {
+		*m = new(Moments)
+	}
+	(*m).N += v.N
+	(*m).X += v.X
+	(*m).Y += v.Y
+	(*m).XX += v.XX
+	(*m).YY += v.YY
+	(*m).XY += v.XY
+}
+
+// Correlation returns the Pearson correlation coefficient of the pairs, and
+// false with fewer than two pairs or when either value does not vary.
+func (m *Moments) Correlation() (float64, bool) {
+	if m == nil || m.N < 2 {
+		return 0, false
+	}
+	cov := m.N*m.XY - m.X*m.Y
+	vx, vy := m.N*m.XX-m.X*m.X, m.N*m.YY-m.Y*m.Y
+	if vx <= 0 || vy <= 0 {
+		return 0, false
+	}
+	return cov / math.Sqrt(vx*vy), true
+}
+
+// Extreme is the transaction of a bucket with the lowest or highest value of
+// something, a gas price in Gwei or a cost in ETH. Of transactions with equal
+// values, the one with the lowest hash, then the lowest index in its block, is
+// the extreme, whatever the order they were added in.
+type Extreme struct {
+	Hash  common.Hash
+	Index uint
+	Value float64
+}
+
+// precedes reports whether e wins a tie with x, their values being equal.
+func (e *Extreme) precedes(x *Extreme) bool {
+	if c := bytes.Compare(e.Hash[:], x.Hash[:]); c != 0 {
+		return c < 0
+	}
+	return e.Index < x.Index
+}
+
+// OtherRole is the role of the transactions sent to none of the addresses of
+// the roles of an aggregator, or whose recipient is unknown.
+const OtherRole = "other"
+
+// NoMethod is the method of the transactions whose input is too short for a
+// selector, such as blob transactions.
+const NoMethod = "none"
+
+// UnknownSender is the sender of the transactions whose sender is unknown.
+const UnknownSender = "unknown"
+
+// Share is the part of a bucket of the transactions of one kind, such as
+// those sent to the batch inbox or those calling one method. Cost is derived
+// from CostWei, as those of Result.
+type Share struct {
+	TxCount uint64
+	Cost    *big.Float // ETH
+	CostWei *big.Int
+}
+
+// addShare adds txCount transactions costing cost wei in total to the share
+// of key in shares.
+func addShare(shares *map[string]*Share, key string, txCount uint64, cost *big.Int) {
+	if *shares == nil {
+		*shares = make(map[string]*Share)
+	}
+	v := (*shares)[key]
+	if v == nil {
+		v = &Share{Cost: new(big.Float), CostWei: new(big.Int)}
+		(*shares)[key] = v
+	}
+	v.TxCount += txCount
+	v.CostWei.Add(v.CostWei, cost)
+}
+
+// mergeShares adds the roles, methods and senders of v to r.
+func (r *Result) mergeShares(v *Result) {
+	for role, share := range v.Roles {
+		addShare(&r.Roles, role, share.TxCount, share.CostWei)
+	}
+	for method, share := range v.Methods {
+		addShare(&r.Methods, method, share.TxCount, share.CostWei)
+	}
+	for sender, share := range v.Senders {
+		addShare(&r.Senders, sender, share.TxCount, share.CostWei)
+	}
+}
+
+// addOracle adds the oracle tips and overpayment of v to r.
+func (r *Result) addOracle(v *Result) {
+	r.OracleTxCount += v.OracleTxCount
+	r.OverpaidTxCount += v.OverpaidTxCount
+	r.OverpaymentWei.Add(r.OverpaymentWei, v.OverpaymentWei)
+	r.OracleTipSum += v.OracleTipSum
+	r.PaidTipSum += v.PaidTipSum
+}
+
+// addFloor adds the EIP-7623 floor counts and extra cost of v to r.
+func (r *Result) addFloor(v *Result) {
+	r.FloorTxCount += v.FloorTxCount
+	r.FloorActiveTxCount += v.FloorActiveTxCount
+	r.FloorPricedTxCount += v.FloorPricedTxCount
+	r.FloorExtraCostWei.Add(r.FloorExtraCostWei, v.FloorExtraCostWei)
+}
+
+// addSchedules adds the blob fees of v replayed under alternative blob
+// schedules to r.
+func (r *Result) addSchedules(v *Result) {
+	r.ScheduleTxCount += v.ScheduleTxCount
+	r.ScheduleActualCostWei.Add(r.ScheduleActualCostWei, v.ScheduleActualCostWei)
+	r.mergeScheduleCosts(v)
+}
+
+// mergeScheduleCosts adds the blob fees of v under every schedule to r.
+func (r *Result) mergeScheduleCosts(v *Result) {
+	for name, cost := range v.ScheduleBlobCostWei {
+		if r.ScheduleBlobCostWei == nil {
+			r.ScheduleBlobCostWei = make(map[string]*big.Int)
+		}
+		if r.ScheduleBlobCostWei[name] == nil {
+			r.ScheduleBlobCostWei[name] = new(big.Int)
+		}
+		r.ScheduleBlobCostWei[name].Add(r.ScheduleBlobCostWei[name], cost)
+	}
+}
+
+// mergeSketches adds the prices, inclusion delays and tip ratios of v to r.
+func (r *Result) mergeSketches(v *Result) {
+	r.CalldataGasPrices.Merge(&v.CalldataGasPrices)
+	r.BlobGasPrices.Merge(&v.BlobGasPrices)
+	r.InclusionDelays.Merge(&v.InclusionDelays)
+	r.TipRatios.Merge(&v.TipRatios)
+}
+
+// sortSketches sorts the values the sketches of r keep, for their quantiles.
+func (r *Result) sortSketches() {
+	r.CalldataGasPrices.sort()
+	r.BlobGasPrices.sort()
+	r.InclusionDelays.sort()
+	r.TipRatios.sort()
+}
+
+// track records the transaction hash as the new minimum or maximum in r if
+// value beats the current one.
+func (r *Result) track(hash common.Hash, index uint, gasPrice, cost float64) {
+	lower(&r.MinGasPriceTx, &Extreme{hash, index, gasPrice})
+	higher(&r.MaxGasPriceTx, &Extreme{hash, index, gasPrice})
+	lower(&r.MinCostTx, &Extreme{hash, index, cost})
+	higher(&r.MaxCostTx, &Extreme{hash, index, cost})
+}
+
+// mergeExtremes merges the extremes of v into r.
+func (r *Result) mergeExtremes(v *Result) {
+	lower(&r.MinGasPriceTx, v.MinGasPriceTx)
+	higher(&r.MaxGasPriceTx, v.MaxGasPriceTx)
+	lower(&r.MinCostTx, v.MinCostTx)
+	higher(&r.MaxCostTx, v.MaxCostTx)
+}
+
+func lower(cur **Extreme, e *Extreme) {
+	if e != nil && (*cur == nil || e.Value < (*cur).Value || e.Value == (*cur).Value && e.precedes(*cur)) {
+		*cur = e
+	}
+}
+
+func higher(cur **Extreme, e *Extreme) {
+	if e != nil && (*cur == nil || e.Value > (*cur).Value || e.Value == (*cur).Value && e.precedes(*cur)) {
+		*cur = e
+	}
+}
+
+// Aggregator accumulates receipts into per-bucket results. It is not safe for
+// concurrent use; receipts are added in input order by a single goroutine.
+type Aggregator struct {
+	Granularity string
+	// Location is the time zone whose days and hours delimit the buckets;
+	// nil means UTC.
+	Location *time.Location
+	// Roles maps recipient addresses to the role by which the results split
+	// the transactions, none when nil.
+	Roles map[common.Address]string
+	// Deposits are the L1 bridge contracts, such as the OptimismPortal and
+	// the L1StandardBridge. With Roles, the deposits sent to them count under
+	// DepositRole.
+	Deposits map[common.Address]bool
+	// Disputes counts the transactions that play dispute games, to any
+	// recipient, under DisputeRole. Only with Roles.
+	Disputes bool
+	// Methods splits the results by the method of the transactions.
+	Methods bool
+	// Senders splits the results by the sender of the transactions.
+	Senders bool
+	// Logger receives the warnings about the receipts; nil for
+	// slog.Default().
+	Logger           *slog.Logger
+	Results          map[string]*Result
+	missingBlobPrice sync.Once
+}
+
+// New returns an aggregator bucketing by granularity, "block", "hour",
+// "day", "week" or "month".
+func New(granularity string) *Aggregator {
+	return &Aggregator{
+		Granularity: granularity,
+		Results:     make(map[string]*Result),
+	}
+}
+
+// Add adds the cost of receipt to the bucket of row.
+func (a *Aggregator) Add(row input.Row, receipt *types.Receipt) {
+	date := a.bucket(row.Time, blockNumber(row, receipt))
+
+	if a.Results[date] == nil {
+		a.Results[date] = NewResult()
+	}
+	result := a.Results[date]
+
+	result.TxCount += 1
+
+	costWei := Cost(receipt)
+	blobCostWei := calcBlobCost(receipt)
+	calldataCostWei := new(big.Int).Sub(costWei, blobCostWei)
+
+	result.CostWei.Add(result.CostWei, costWei)
+	result.CalldataCostWei.Add(result.CalldataCostWei, calldataCostWei)
+	result.BlobCostWei.Add(result.BlobCostWei, blobCostWei)
+	if row.BaseFee != nil {
+		burnt := new(big.Int).Mul(row.BaseFee, new(big.Int).SetUint64(receipt.GasUsed))
+		result.BaseFeeCostWei.Add(result.BaseFeeCostWei, burnt)
+		result.PriorityFeeCostWei.Add(result.PriorityFeeCostWei, burnt.Sub(calldataCostWei, burnt))
+	} else {
+		result.BaseFeeMissing++
+	}
+	// Submissions timed after their block, e.g. by a skewed clock, are
+	// left out.
+	if !row.Submitted.IsZero() && !row.Time.Before(row.Submitted) {
+		delay := row.Time.Sub(row.Submitted).Seconds()
+		result.InclusionDelays.Add(delay)
+		if row.BaseFee != nil {
+			tip := new(big.Int).Sub(receipt.EffectiveGasPrice, row.BaseFee)
+			if result.DelayTip == nil {
+				result.DelayTip = new(Moments)
+			}
+			result.DelayTip.Add(delay, gwei(tip))
+		}
+	}
+	result.PostedBytes += row.PostedBytes
+	result.ChannelBytes += row.ChannelBytes
+	result.RawBytes += row.RawBytes
+	result.L2Blocks += row.L2Blocks
+	result.L2Txs += row.L2Txs
+	if row.WhatIfCost != nil {
+		result.WhatIfTxCount++
+		result.WhatIfCostWei.Add(result.WhatIfCostWei, row.WhatIfCost)
+		result.WhatIfActualCostWei.Add(result.WhatIfActualCostWei, costWei)
+	}
+	if row.MarketTip != nil && row.MarketTip.Sign() > 0 && row.BaseFee != nil {
+		tip := new(big.Int).Sub(receipt.EffectiveGasPrice, row.BaseFee)
+		ratio, _ := new(big.Rat).SetFrac(tip, row.MarketTip).Float64()
+		result.TipRatios.Add(ratio)
+	}
+	if row.OracleTip != nil && row.BaseFee != nil {
+		tip := new(big.Int).Sub(receipt.EffectiveGasPrice, row.BaseFee)
+		result.OracleTxCount++
+		result.OracleTipSum += gwei(row.OracleTip)
+		result.PaidTipSum += gwei(tip)
+		if over := tip.Sub(tip, row.OracleTip); over.Sign() > 0 {
+			result.OverpaidTxCount++
+			over.Mul(over, new(big.Int).SetUint64(receipt.GasUsed))
+			result.OverpaymentWei.Add(result.OverpaymentWei, over)
+		}
+	}
+	if row.FloorGas > 0 {
+		result.FloorTxCount++
+		switch {
+		case row.FloorActive:
+			result.FloorActiveTxCount++
+			if receipt.GasUsed <= row.FloorGas {
+				result.FloorPricedTxCount++
+			}
+		case row.FloorGas > receipt.GasUsed:
+			extra := new(big.Int).SetUint64(row.FloorGas - receipt.GasUsed)
+			result.FloorExtraCostWei.Add(result.FloorExtraCostWei, extra.Mul(extra, receipt.EffectiveGasPrice))
+		}
+	}
+	if row.FrameBytes > 0 {
+		result.FrameBytes += row.FrameBytes
+		result.FrameCalldataGas += receipt.GasUsed
+		result.FrameBlobGas += receipt.BlobGasUsed
+	}
+
+	// The means are summed until Finalize.
+	callDataGasPrice := receipt.EffectiveGasPrice
+	result.MeanCallDataGasPrice.Add(
+		result.MeanCallDataGasPrice,
+		weiToGwei(callDataGasPrice),
+	)
+	result.CalldataGasPrices.Add(gwei(callDataGasPrice))
+	cost, _ := weiToEther(costWei).Float64()
+	result.track(row.Hash, receipt.TransactionIndex, gwei(callDataGasPrice), cost)
+
+	result.TotalCalldataGasUsed += receipt.GasUsed
+
+	if a.Roles != nil {
+		role := OtherRole
+		if row.To != nil {
+			if r, ok := a.Roles[*row.To]; ok {
+				role = r
+			}
+			if a.Deposits[*row.To] && IsDeposit(row.Method) {
+				role = DepositRole
+			}
+		}
+		if a.Disputes && IsDisputeCall(row.Method) {
+			role = DisputeRole
+		}
+		addShare(&result.Roles, role, 1, costWei)
+	}
+	if a.Methods {
+		method := row.Method
+		if method == "" {
+			method = NoMethod
+		}
+		addShare(&result.Methods, method, 1, costWei)
+	}
+	if a.Senders {
+		sender := UnknownSender
+		if row.From != nil {
+			sender = row.From.Hex()
+		}
+		addShare(&result.Senders, sender, 1, costWei)
+	}
+
+	if receipt.Status == types.ReceiptStatusFailed {
+		result.RevertedTxCount++
+		result.RevertedCostWei.Add(result.RevertedCostWei, costWei)
+	}
+
+	if receipt.Type == types.BlobTxType {
+		result.BlobTxCount++
+		result.BlobTxCostWei.Add(result.BlobTxCostWei, costWei)
+		if blobGasPrice := receipt.BlobGasPrice; blobGasPrice != nil {
+			result.MeanBlobGasPrice.Add(
+				result.MeanBlobGasPrice,
+				weiToGwei(blobGasPrice),
+			)
+			result.BlobGasPrices.Add(gwei(blobGasPrice))
+		} else {
+			a.missingBlobPrice.Do(func() {
+				log := a.Logger
+				if log == nil {
+					log = slog.Default()
+				}
+				log.Warn("receipt has no blob gas price; blob cost and price columns will be reported as "+Unavailable, "tx", row.Hash)
+			})
+			result.BlobPriceMissing++
+		}
+		result.TotalBlobGasUsed += receipt.BlobGasUsed
+		result.MeasuredBlobs += row.MeasuredBlobs
+		result.BlobPayloadBytes += row.BlobPayloadBytes
+	}
+}
+
+// Tx is the cost breakdown of a single transaction. Amounts are in wei.
+type Tx struct {
+	Hash         common.Hash
+	From         *common.Address // nil when the input and fetcher lack it
+	Nonce        *uint64         // nil when the input and fetcher lack it
+	Time         time.Time
+	Block        uint64
+	Bucket       string
+	Type         uint8
+	GasUsed      uint64
+	GasPrice     *big.Int // effective gas price
+	BlobGasUsed  uint64
+	BlobGasPrice *big.Int // nil unless a blob transaction with a known price
+	Cost         *big.Int
+	CalldataCost *big.Int
+	BlobCost     *big.Int
+	// L2Blocks and L2Txs count the L2 blocks and transactions of the channels
+	// that the transaction completes; nil unless it carries batcher frames.
+	L2Blocks *uint64
+	L2Txs    *uint64
+	// CalldataPricing is the rule that priced the calldata of the
+	// transaction, eip-2028 before Prague and eip-7623-standard or
+	// eip-7623-floor since; empty unless its floor gas is known.
+	CalldataPricing string
+}
+
+// AddBlobSchedules adds the blob fees of a blob transaction of time t in
+// block, actual what it paid and costs what it would have paid under every
+// alternative blob schedule by name, in wei, to its bucket.
+func (a *Aggregator) AddBlobSchedules(t time.Time, block uint64, actual *big.Int, costs map[string]*big.Int) {
+	date := a.bucket(t, block)
+	if _, ok := a.Results[date]; !ok {
+		a.Results[date] = NewResult()
+	}
+	result := a.Results[date]
+	result.ScheduleTxCount++
+	result.ScheduleActualCostWei.Add(result.ScheduleActualCostWei, actual)
+	if result.ScheduleBlobCostWei == nil {
+		result.ScheduleBlobCostWei = make(map[string]*big.Int, len(costs))
+	}
+	for name, cost := range costs {
+		if result.ScheduleBlobCostWei[name] == nil {
+			result.ScheduleBlobCostWei[name] = new(big.Int)
+		}
+		result.ScheduleBlobCostWei[name].Add(result.ScheduleBlobCostWei[name], cost)
+	}
+}
+
+// NewTx returns the cost breakdown of receipt, bucketed like Add would.
+func (a *Aggregator) NewTx(row input.Row, receipt *types.Receipt) Tx {
+	tx := Tx{
+		Hash:     row.Hash,
+		From:     row.From,
+		Nonce:    row.Nonce,
+		Time:     row.Time,
+		Bucket:   a.bucket(row.Time, blockNumber(row, receipt)),
+		Type:     receipt.Type,
+		GasUsed:  receipt.GasUsed,
+		GasPrice: receipt.EffectiveGasPrice,
+		Cost:     Cost(receipt),
+		BlobCost: calcBlobCost(receipt),
+	}
+	if receipt.BlockNumber != nil {
+		tx.Block = receipt.BlockNumber.Uint64()
+	}
+	if receipt.Type == types.BlobTxType {
+		tx.BlobGasUsed = receipt.BlobGasUsed
+		tx.BlobGasPrice = receipt.BlobGasPrice
+	}
+	tx.CalldataCost = new(big.Int).Sub(tx.Cost, tx.BlobCost)
+	if row.FloorGas > 0 {
+		switch {
+		case !row.FloorActive:
+			tx.CalldataPricing = "eip-2028"
+		case receipt.GasUsed <= row.FloorGas:
+			tx.CalldataPricing = "eip-7623-floor"
+		default:
+			tx.CalldataPricing = "eip-7623-standard"
+		}
+	}
+	return tx
+}
+
+// Finalize turns the accumulated sums into averages and means and returns the sorted
+// bucket keys together with the grand total over all buckets.
+func (a *Aggregator) Finalize() ([]string, *Result) {
+	dates := SortedKeys(a.Results)
+
+	total := NewResult()
+	for _, k := range dates {
+		v := a.Results[k]
+		// The means are still sums, which add up into those of the total.
+		total.MeanCallDataGasPrice.Add(total.MeanCallDataGasPrice, v.MeanCallDataGasPrice)
+		total.MeanBlobGasPrice.Add(total.MeanBlobGasPrice, v.MeanBlobGasPrice)
+		v.MeanCallDataGasPrice.Quo(v.MeanCallDataGasPrice, new(big.Float).SetUint64(v.TxCount))
+		v.MeanBlobGasPrice.Quo(v.MeanBlobGasPrice, new(big.Float).SetUint64(v.TxCount))
+		v.deriveCosts()
+		v.AvgCallDataGasPrice = blendedGasPrice(v.CalldataCost, v.TotalCalldataGasUsed)
+		v.AvgBlobGasPrice = blendedGasPrice(v.BlobCost, v.TotalBlobGasUsed)
+		v.TotalGasUsed = v.TotalCalldataGasUsed + v.TotalBlobGasUsed
+		v.BlendedGasPrice = blendedGasPrice(v.Cost, v.TotalGasUsed)
+		v.sortSketches()
+
+		total.CostWei.Add(total.CostWei, v.CostWei)
+		total.CalldataCostWei.Add(total.CalldataCostWei, v.CalldataCostWei)
+		total.BlobCostWei.Add(total.BlobCostWei, v.BlobCostWei)
+		total.BaseFeeCostWei.Add(total.BaseFeeCostWei, v.BaseFeeCostWei)
+		total.PriorityFeeCostWei.Add(total.PriorityFeeCostWei, v.PriorityFeeCostWei)
+		total.BaseFeeMissing += v.BaseFeeMissing
+		total.TotalCalldataGasUsed += v.TotalCalldataGasUsed
+		total.TotalBlobGasUsed += v.TotalBlobGasUsed
+		total.TotalGasUsed += v.TotalGasUsed
+		total.TxCount += v.TxCount
+		total.BlobTxCount += v.BlobTxCount
+		total.BlobTxCostWei.Add(total.BlobTxCostWei, v.BlobTxCostWei)
+		total.RevertedTxCount += v.RevertedTxCount
+		total.RevertedCostWei.Add(total.RevertedCostWei, v.RevertedCostWei)
+		total.MeasuredBlobs += v.MeasuredBlobs
+		total.BlobPayloadBytes += v.BlobPayloadBytes
+		total.FrameBytes += v.FrameBytes
+		total.FrameCalldataGas += v.FrameCalldataGas
+		total.FrameBlobGas += v.FrameBlobGas
+		total.PostedBytes += v.PostedBytes
+		total.ChannelBytes += v.ChannelBytes
+		total.RawBytes += v.RawBytes
+		total.L2Blocks += v.L2Blocks
+		total.L2Txs += v.L2Txs
+		total.WhatIfTxCount += v.WhatIfTxCount
+		total.WhatIfCostWei.Add(total.WhatIfCostWei, v.WhatIfCostWei)
+		total.WhatIfActualCostWei.Add(total.WhatIfActualCostWei, v.WhatIfActualCostWei)
+		total.addOracle(v)
+		total.addFloor(v)
+		total.addSchedules(v)
+		total.BlobPriceMissing += v.BlobPriceMissing
+		total.mergeSketches(v)
+		mergeMoments(&total.DelayTip, v.DelayTip)
+		total.mergeExtremes(v)
+		total.mergeShares(v)
+	}
+	if total.TxCount > 0 {
+		total.MeanCallDataGasPrice.Quo(total.MeanCallDataGasPrice, new(big.Float).SetUint64(total.TxCount))
+		total.MeanBlobGasPrice.Quo(total.MeanBlobGasPrice, new(big.Float).SetUint64(total.TxCount))
+	}
+	total.sortSketches()
+	total.deriveCosts()
+	total.AvgCallDataGasPrice = blendedGasPrice(total.CalldataCost, total.TotalCalldataGasUsed)
+	total.AvgBlobGasPrice = blendedGasPrice(total.BlobCost, total.TotalBlobGasUsed)
+	total.BlendedGasPrice = blendedGasPrice(total.Cost, total.TotalGasUsed)
+	return dates, total
+}
+
+// Snapshot returns finalized copies of the results accumulated so far, with
+// their sorted keys, and leaves the aggregator to accumulate further.
+func (a *Aggregator) Snapshot() ([]string, map[string]*Result) {
+	c := &Aggregator{Granularity: a.Granularity, Location: a.Location, Results: make(map[string]*Result, len(a.Results))}
+	for k, v := range a.Results {
+		r := *v
+		r.CostWei = new(big.Int).Set(v.CostWei)
+		r.CalldataCostWei = new(big.Int).Set(v.CalldataCostWei)
+		r.BlobCostWei = new(big.Int).Set(v.BlobCostWei)
+		r.RevertedCostWei = new(big.Int).Set(v.RevertedCostWei)
+		r.BaseFeeCostWei = new(big.Int).Set(v.BaseFeeCostWei)
+		r.PriorityFeeCostWei = new(big.Int).Set(v.PriorityFeeCostWei)
+		r.BlobTxCostWei = new(big.Int).Set(v.BlobTxCostWei)
+		r.WhatIfCostWei = new(big.Int).Set(v.WhatIfCostWei)
+		r.WhatIfActualCostWei = new(big.Int).Set(v.WhatIfActualCostWei)
+		r.OverpaymentWei = new(big.Int).Set(v.OverpaymentWei)
+		r.FloorExtraCostWei = new(big.Int).Set(v.FloorExtraCostWei)
+		r.ScheduleActualCostWei = new(big.Int).Set(v.ScheduleActualCostWei)
+		r.AvgCallDataGasPrice = new(big.Float).Set(v.AvgCallDataGasPrice)
+		r.AvgBlobGasPrice = new(big.Float).Set(v.AvgBlobGasPrice)
+		r.MeanCallDataGasPrice = new(big.Float).Set(v.MeanCallDataGasPrice)
+		r.MeanBlobGasPrice = new(big.Float).Set(v.MeanBlobGasPrice)
+		r.CalldataGasPrices = v.CalldataGasPrices.Clone()
+		r.BlobGasPrices = v.BlobGasPrices.Clone()
+		r.InclusionDelays = v.InclusionDelays.Clone()
+		r.TipRatios = v.TipRatios.Clone()
+		r.DelayTip = nil
+		mergeMoments(&r.DelayTip, v.DelayTip)
+		r.BlendedGasPrice = new(big.Float).Set(v.BlendedGasPrice)
+		r.Roles, r.Methods, r.Senders = nil, nil, nil
+		r.mergeShares(v)
+		r.ScheduleBlobCostWei = nil
+		r.mergeScheduleCosts(v)
+		c.Results[k] = &r
+	}
+	dates, _ := c.Finalize()
+	return dates, c.Results
+}
+
+// Rollup merges finalized buckets into coarser ones, keyed by key(bucket), and
+// returns the sorted new keys. Averages are weighted by gas and means by
+// transaction count.
+func Rollup(results map[string]*Result, key func(bucket string) string) ([]string, map[string]*Result) {
+	merged := make(map[string]*Result)
+	for _, k := range SortedKeys(results) {
+		v := results[k]
+		r := merged[key(k)]
+		if r == nil {
+			r = NewResult()
+			merged[key(k)] = r
+		}
+		count := new(big.Float).SetUint64(v.TxCount)
+		r.CostWei.Add(r.CostWei, v.CostWei)
+		r.CalldataCostWei.Add(r.CalldataCostWei, v.CalldataCostWei)
+		r.BlobCostWei.Add(r.BlobCostWei, v.BlobCostWei)
+		r.BaseFeeCostWei.Add(r.BaseFeeCostWei, v.BaseFeeCostWei)
+		r.PriorityFeeCostWei.Add(r.PriorityFeeCostWei, v.PriorityFeeCostWei)
+		r.BaseFeeMissing += v.BaseFeeMissing
+		r.MeanCallDataGasPrice.Add(r.MeanCallDataGasPrice, new(big.Float).Mul(v.MeanCallDataGasPrice, count))
+		r.MeanBlobGasPrice.Add(r.MeanBlobGasPrice, new(big.Float).Mul(v.MeanBlobGasPrice, count))
+		r.mergeSketches(v)
+		mergeMoments(&r.DelayTip, v.DelayTip)
+		r.mergeExtremes(v)
+		r.mergeShares(v)
+		r.TotalCalldataGasUsed += v.TotalCalldataGasUsed
+		r.TotalBlobGasUsed += v.TotalBlobGasUsed
+		r.TotalGasUsed += v.TotalGasUsed
+		r.TxCount += v.TxCount
+		r.BlobTxCount += v.BlobTxCount
+		r.BlobTxCostWei.Add(r.BlobTxCostWei, v.BlobTxCostWei)
+		r.RevertedTxCount += v.RevertedTxCount
+		r.RevertedCostWei.Add(r.RevertedCostWei, v.RevertedCostWei)
+		r.MeasuredBlobs += v.MeasuredBlobs
+		r.BlobPayloadBytes += v.BlobPayloadBytes
+		r.FrameBytes += v.FrameBytes
+		r.FrameCalldataGas += v.FrameCalldataGas
+		r.FrameBlobGas += v.FrameBlobGas
+		r.PostedBytes += v.PostedBytes
+		r.ChannelBytes += v.ChannelBytes
+		r.RawBytes += v.RawBytes
+		r.L2Blocks += v.L2Blocks
+		r.L2Txs += v.L2Txs
+		r.WhatIfTxCount += v.WhatIfTxCount
+		r.WhatIfCostWei.Add(r.WhatIfCostWei, v.WhatIfCostWei)
+		r.WhatIfActualCostWei.Add(r.WhatIfActualCostWei, v.WhatIfActualCostWei)
+		r.addOracle(v)
+		r.addFloor(v)
+		r.addSchedules(v)
+		r.BlobPriceMissing += v.BlobPriceMissing
+	}
+	for _, r := range merged {
+		r.sortSketches()
+		if r.TxCount > 0 {
+			r.MeanCallDataGasPrice.Quo(r.MeanCallDataGasPrice, new(big.Float).SetUint64(r.TxCount))
+			r.MeanBlobGasPrice.Quo(r.MeanBlobGasPrice, new(big.Float).SetUint64(r.TxCount))
+		}
+		r.deriveCosts()
+		r.AvgCallDataGasPrice = blendedGasPrice(r.CalldataCost, r.TotalCalldataGasUsed)
+		r.AvgBlobGasPrice = blendedGasPrice(r.BlobCost, r.TotalBlobGasUsed)
+		r.BlendedGasPrice = blendedGasPrice(r.Cost, r.TotalGasUsed)
+	}
+	return SortedKeys(merged), merged
+}
+
+// Total returns the total over finalized buckets, as Finalize does, for
+// results without it such as those read back from a database.
+func Total(results map[string]*Result) *Result {
+	_, all := Rollup(results, func(string) string { return "" })
+	if all[""] == nil {
+		return NewResult()
+	}
+	return all[""]
+}
+
+// BucketStart returns the start of the bucket with the given key: its first
+// day, its hour for hourly keys, or the time of the block for per-block keys.
+func BucketStart(key string) (time.Time, error) {
+	if t, _, ok := strings.Cut(key, " #"); ok {
+		return time.Parse(blockLayout, t)
+	}
+	var year, week int
+	if _, err := fmt.Sscanf(key, "%04d-W%02d", &year, &week); err == nil {
+		// ISO week 1 is the week with January 4th in it.
+		jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+		monday := jan4.AddDate(0, 0, -(int(jan4.Weekday())+6)%7)
+		return monday.AddDate(0, 0, 7*(week-1)), nil
+	}
+	switch len(key) {
+	case len(hourLayout):
+		return time.Parse(hourLayout, key)
+	case len(monthLayout):
+		return time.Parse(monthLayout, key)
+	}
+	return time.Parse("2006-01-02", key)
+}
+
+// Layouts of hourly and monthly bucket keys, and of the time of per-block
+// keys.
+const (
+	hourLayout  = "2006-01-02 15:00"
+	monthLayout = "2006-01"
+	blockLayout = "2006-01-02 15:04:05"
+)
+
+// bucket returns the key of the bucket of t, in block for per-block buckets.
+func (a *Aggregator) bucket(t time.Time, block uint64) string {
+	if a.Location != nil {
+		t = t.In(a.Location)
+	}
+	if a.Granularity == "block" {
+		return BlockKey(t, block)
+	}
+	return BucketKey(t, a.Granularity)
+}
+
+// blockNumber returns the block of receipt, or that of row when the receipt
+// lacks it.
+func blockNumber(row input.Row, receipt *types.Receipt) uint64 {
+	if receipt.BlockNumber != nil {
+		return receipt.BlockNumber.Uint64()
+	}
+	return row.Block
+}
+
+// BlockKey returns the per-block report key of the block mined at t, in the
+// zone of t: its time and number, e.g. "2024-07-03 15:04:23 #20223456", so
+// that the keys sort by block and parse as times like those of the other
+// granularities.
+func BlockKey(t time.Time, block uint64) string {
+	return fmt.Sprintf("%s #%d", t.Format(blockLayout), block)
+}
+
+// BucketKey returns the report key of t, in the zone of t. Weekly keys use the ISO 8601 week
+// date with its week-numbering year, so they sort correctly across New Year.
+// Hourly keys are the day and the hour, e.g. "2024-07-03 15:00", and monthly
+// keys the calendar month, e.g. "2024-07".
+func BucketKey(t time.Time, granularity string) string {
+	switch granularity {
+	case "week":
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%04d-W%02d", year, week)
+	case "hour":
+		return t.Format(hourLayout)
+	case "month":
+		return t.Format(monthLayout)
+	}
+	return t.Format("2006-01-02")
+}
+
+// SortedKeys returns the bucket keys in ascending order so that repeated runs
+// over the same input produce byte-identical output.
+func SortedKeys(results map[string]*Result) []string {
+	keys := make([]string, 0, len(results))
+	for k := range results {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Unavailable marks values that cannot be computed from the data the RPC
+// returned, so that they are not mistaken for zeros.
+const Unavailable = "n/a"
+
+// BlobDependent formats a value that includes blob fees, or reports it as
+// Unavailable when any blob transaction in the bucket lacked a blob gas price.
+func (r *Result) BlobDependent(value *big.Float) string {
+	if r.BlobPriceMissing > 0 {
+		return Unavailable
+	}
+	return value.String()
+}
+
+// BlobDependentWei formats an amount in wei as BlobDependent does.
+func (r *Result) BlobDependentWei(value *big.Int) string {
+	if r.BlobPriceMissing > 0 {
+		return Unavailable
+	}
+	return value.String()
+}
+
+// Blobs returns the number of blobs posted. Every blob uses the same amount of
+// blob gas, so that it is the number of blob versioned hashes of the
+// transactions without fetching them.
+func (r *Result) Blobs() uint64 {
+	return r.TotalBlobGasUsed / params.BlobTxBlobGasPerBlob
+}
+
+// BlobsPerTx returns the average number of blobs per blob transaction.
+func (r *Result) BlobsPerTx() float64 {
+	if r.BlobTxCount == 0 {
+		return 0
+	}
+	return float64(r.Blobs()) / float64(r.BlobTxCount)
+}
+
+// CostPerBlob returns the average cost of the blob transactions per blob, in
+// ETH, or nil without blobs.
+func (r *Result) CostPerBlob() *big.Float {
+	if r.Blobs() == 0 {
+		return nil
+	}
+	return new(big.Float).Quo(r.BlobTxCost, new(big.Float).SetUint64(r.Blobs()))
+}
+
+// SuccessfulTxCount returns the number of transactions that did not revert.
+func (r *Result) SuccessfulTxCount() uint64 {
+	return r.TxCount - r.RevertedTxCount
+}
+
+// SuccessfulCost returns the cost of the transactions that did not revert, in
+// ETH.
+func (r *Result) SuccessfulCost() *big.Float {
+	return new(big.Float).Sub(r.Cost, r.RevertedCost)
+}
+
+// BlobUtilization returns the share of the capacity of the measured blobs
+// filled with data, in percent, and false when no blob was measured.
+func (r *Result) BlobUtilization() (float64, bool) {
+	if r.MeasuredBlobs == 0 {
+		return 0, false
+	}
+	capacity := r.MeasuredBlobs * params.BlobTxFieldElementsPerBlob * params.BlobTxBytesPerFieldElement
+	return 100 * float64(r.BlobPayloadBytes) / float64(capacity), true
+}
+
+// NewResult returns an empty result.
+func NewResult() *Result {
+	return &Result{
+		Cost:                  new(big.Float).SetFloat64(0),
+		CalldataCost:          new(big.Float).SetFloat64(0),
+		BlobCost:              new(big.Float).SetFloat64(0),
+		CostWei:               new(big.Int),
+		CalldataCostWei:       new(big.Int),
+		BlobCostWei:           new(big.Int),
+		RevertedCostWei:       new(big.Int),
+		BaseFeeCostWei:        new(big.Int),
+		PriorityFeeCostWei:    new(big.Int),
+		BlobTxCostWei:         new(big.Int),
+		WhatIfCostWei:         new(big.Int),
+		WhatIfActualCostWei:   new(big.Int),
+		OverpaymentWei:        new(big.Int),
+		FloorExtraCostWei:     new(big.Int),
+		ScheduleActualCostWei: new(big.Int),
+		BaseFeeCost:           new(big.Float).SetFloat64(0),
+		PriorityFeeCost:       new(big.Float).SetFloat64(0),
+		BlobTxCost:            new(big.Float).SetFloat64(0),
+		RevertedCost:          new(big.Float).SetFloat64(0),
+		WhatIfCost:            new(big.Float).SetFloat64(0),
+		WhatIfActualCost:      new(big.Float).SetFloat64(0),
+		Overpayment:           new(big.Float).SetFloat64(0),
+		FloorExtraCost:        new(big.Float).SetFloat64(0),
+		ScheduleActualCost:    new(big.Float).SetFloat64(0),
+		AvgCallDataGasPrice:   new(big.Float).SetUint64(0),
+		AvgBlobGasPrice:       new(big.Float).SetUint64(0),
+		MeanCallDataGasPrice:  new(big.Float).SetUint64(0),
+		MeanBlobGasPrice:      new(big.Float).SetUint64(0),
+		BlendedGasPrice:       new(big.Float).SetUint64(0),
+	}
+}
+
+// Percentiles are the percentiles of the gas prices reported per bucket.
+var Percentiles = []int{50, 90, 99}
+
+// Percentile returns the p-th percentile of sorted prices by nearest rank,
+// or false when there are none, e.g. for results read back from a database.
+func Percentile(prices []float64, p int) (float64, bool) {
+	if len(prices) == 0 {
+		return 0, false
+	}
+	rank := (p*len(prices) + 99) / 100 // ceil(p% of n)
+	return prices[max(rank, 1)-1], true
+}
+
+// blendedGasPrice returns the cost in Gwei per unit of gas, weighting calldata
+// and blob gas by the amounts actually consumed.
+func blendedGasPrice(costEth *big.Float, gasUsed uint64) *big.Float {
+	if gasUsed == 0 {
+		return new(big.Float).SetUint64(0)
+	}
+	gwei := new(big.Float).Mul(costEth, big.NewFloat(params.Ether/params.GWei))
+	return gwei.Quo(gwei, new(big.Float).SetUint64(gasUsed))
+}
+
+// deriveCosts sets the ETH costs of r from its exact sums in wei.
+func (r *Result) deriveCosts() {
+	r.Cost = weiToEther(r.CostWei)
+	r.CalldataCost = weiToEther(r.CalldataCostWei)
+	r.BlobCost = weiToEther(r.BlobCostWei)
+	r.RevertedCost = weiToEther(r.RevertedCostWei)
+	r.BaseFeeCost = weiToEther(r.BaseFeeCostWei)
+	r.PriorityFeeCost = weiToEther(r.PriorityFeeCostWei)
+	r.BlobTxCost = weiToEther(r.BlobTxCostWei)
+	r.WhatIfCost = weiToEther(r.WhatIfCostWei)
+	r.WhatIfActualCost = weiToEther(r.WhatIfActualCostWei)
+	r.Overpayment = weiToEther(r.OverpaymentWei)
+	r.FloorExtraCost = weiToEther(r.FloorExtraCostWei)
+	r.ScheduleActualCost = weiToEther(r.ScheduleActualCostWei)
+	r.ScheduleBlobCost = nil
+	for name, cost := range r.ScheduleBlobCostWei {
+		if r.ScheduleBlobCost == nil {
+			r.ScheduleBlobCost = make(map[string]*big.Float, len(r.ScheduleBlobCostWei))
+		}
+		r.ScheduleBlobCost[name] = weiToEther(cost)
+	}
+	for _, shares := range []map[string]*Share{r.Roles, r.Methods, r.Senders} {
+		for _, share := range shares {
+			share.Cost = weiToEther(share.CostWei)
+		}
+	}
+}
+
+// Wei returns the ETH amount eth in wei, rounded to the nearest integer, for
+// results that only carry amounts in ETH.
+func Wei(eth *big.Float) *big.Int {
+	wei := new(big.Float).SetPrec(256).Mul(eth, big.NewFloat(params.Ether))
+	n, _ := wei.Add(wei, big.NewFloat(0.5)).Int(nil)
+	return n
+}
+
+func weiToEther(wei *big.Int) *big.Float {
+	return new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(params.Ether))
+}
+
+func gwei(wei *big.Int) float64 {
+	f, _ := weiToGwei(wei).Float64()
+	return f
+}
+
+func weiToGwei(wei *big.Int) *big.Float {
+	return new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(params.GWei))
+}
+
+// calcBlobCost returns the blob fee of r, or zero when the receipt carries no
+// blob gas price.
+func calcBlobCost(r *types.Receipt) *big.Int {
+	if r.Type != types.BlobTxType || r.BlobGasPrice == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Mul(r.BlobGasPrice, new(big.Int).SetUint64(r.BlobGasUsed))
+}
+
+// ErrTxType reports a receipt of a transaction type whose cost is not known,
+// e.g. a deposit transaction of an L2.
+var ErrTxType = errors.New("unsupported transaction type")
+
+// Check returns an error when the cost of r cannot be computed: it must be of
+// a legacy, access-list, dynamic-fee or blob transaction and have an
+// effective gas price.
+func Check(r *types.Receipt) error {
+	switch r.Type {
+	case types.LegacyTxType, types.AccessListTxType, types.DynamicFeeTxType, types.BlobTxType:
+	default:
+		return fmt.Errorf("%w %d", ErrTxType, r.Type)
+	}
+	if r.EffectiveGasPrice == nil {
+		return errors.New("receipt without an effective gas price")
+	}
+	return nil
+}
+
+// Cost returns the L1 cost of r in wei, its execution fee and blob fee. Legacy
+// and access-list transactions pay their gas price per gas, dynamic-fee and
+// blob transactions the base fee plus their effective tip, both of which the
+// receipt has as its effective gas price; blob transactions pay their blob gas
+// on top.
+func Cost(r *types.Receipt) *big.Int {
+	total := new(big.Int).Mul(r.EffectiveGasPrice, new(big.Int).SetUint64(r.GasUsed))
+	if r.Type != types.BlobTxType {
+		return total
+	}
+	return total.Add(total, calcBlobCost(r))
+}
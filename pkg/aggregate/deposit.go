@@ -0,0 +1,53 @@
+package aggregate
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// DepositRole is the role of the deposits sent to the bridge contracts of an
+// aggregator, whatever the role of the contract.
+const DepositRole = "deposit"
+
+// depositMethods are the methods of the OptimismPortal, the L1StandardBridge,
+// the L1CrossDomainMessenger and the L1ERC721Bridge that deposit to L2.
+var depositMethods = []string{
+	"depositTransaction(address,uint256,uint64,bool,bytes)",
+	"depositETH(uint32,bytes)",
+	"depositETHTo(address,uint32,bytes)",
+	"depositERC20(address,address,uint256,uint32,bytes)",
+	"depositERC20To(address,address,address,uint256,uint32,bytes)",
+	"bridgeETH(uint32,bytes)",
+	"bridgeETHTo(address,uint32,bytes)",
+	"bridgeERC20(address,address,uint256,uint32,bytes)",
+	"bridgeERC20To(address,address,address,uint256,uint32,bytes)",
+	"sendMessage(address,bytes,uint32)",
+	"bridgeERC721(address,address,uint256,uint32,bytes)",
+	"bridgeERC721To(address,address,address,uint256,uint32,bytes)",
+}
+
+// depositSelectors are the selectors of depositMethods.
+var depositSelectors = func() map[string]bool {
+	selectors := make(map[string]bool, len(depositMethods))
+	for _, signature := range depositMethods {
+		selectors[hexutil.Encode(crypto.Keccak256([]byte(signature))[:4])] = true
+	}
+	return selectors
+}()
+
+// IsDeposit reports whether a transaction to a bridge contract calling method,
+// a selector or the method name of an Etherscan export, deposits to L2. A
+// transaction without input sends ETH to the contract, which deposits it.
+func IsDeposit(method string) bool {
+	if method == "" || method == NoMethod {
+		return true
+	}
+	if depositSelectors[strings.ToLower(method)] {
+		return true
+	}
+	// Etherscan names methods in words, e.g. "Deposit ETH To" or "Transfer".
+	name := strings.ToLower(strings.ReplaceAll(method, " ", ""))
+	return name == "transfer" || strings.HasPrefix(name, "deposit") || strings.HasPrefix(name, "bridge") || name == "sendmessage"
+}
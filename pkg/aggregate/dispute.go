@@ -0,0 +1,66 @@
+package aggregate
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// DisputeRole is the role of the transactions that play dispute games, such
+// as the moves of a challenge, whatever the role of their recipient.
+const DisputeRole = "dispute"
+
+// disputeMethods are the methods of the FaultDisputeGame, with those of its
+// versions, of the PreimageOracle that its steps read, and of the
+// DelayedWETH that holds the bonds.
+var disputeMethods = []string{
+	"move(bytes32,uint256,bytes32,bool)",
+	"attack(uint256,bytes32)",
+	"attack(bytes32,uint256,bytes32)",
+	"defend(uint256,bytes32)",
+	"defend(bytes32,uint256,bytes32)",
+	"step(uint256,bool,bytes,bytes)",
+	"addLocalData(uint256,uint256,uint256)",
+	"resolve()",
+	"resolveClaim(uint256)",
+	"resolveClaim(uint256,uint256)",
+	"claimCredit(address)",
+	"challengeRootL2Block((bytes32,bytes32,bytes32,bytes32),bytes)",
+	"loadLocalData(uint256,bytes32,bytes32,uint256,uint256)",
+	"loadKeccak256PreimagePart(uint256,bytes)",
+	"loadSha256PreimagePart(uint256,bytes)",
+	"loadBlobPreimagePart(uint256,uint256,bytes,bytes,uint256)",
+	"loadPrecompilePreimagePart(uint256,address,uint64,bytes)",
+	"unlock(address,uint256)",
+	"withdraw(address,uint256)",
+}
+
+// disputeSelectors are the selectors of disputeMethods.
+var disputeSelectors = func() map[string]bool {
+	selectors := make(map[string]bool, len(disputeMethods))
+	for _, signature := range disputeMethods {
+		selectors[hexutil.Encode(crypto.Keccak256([]byte(signature))[:4])] = true
+	}
+	return selectors
+}()
+
+// disputeNames are the names of disputeMethods in Etherscan exports, in
+// lower case without spaces. withdraw is left out, too common elsewhere.
+var disputeNames = map[string]bool{
+	"move": true, "attack": true, "defend": true, "step": true, "addlocaldata": true,
+	"resolve": true, "resolveclaim": true, "claimcredit": true, "challengerootl2block": true,
+	"unlock": true,
+}
+
+// IsDisputeCall reports whether a transaction calling method, a selector or
+// the method name of an Etherscan export, plays a dispute game. The creation
+// of games through the DisputeGameFactory is not one: the proposer creates a
+// game for every output it proposes.
+func IsDisputeCall(method string) bool {
+	if disputeSelectors[strings.ToLower(method)] {
+		return true
+	}
+	name := strings.ToLower(strings.ReplaceAll(method, " ", ""))
+	return disputeNames[name] || strings.HasPrefix(name, "load") && strings.HasSuffix(name, "preimagepart")
+}
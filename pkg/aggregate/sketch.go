@@ -0,0 +1,226 @@
+package aggregate
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"slices"
+	"sort"
+)
+
+// sketchExact is the number of values a Sketch keeps as they are, past which
+// it counts them in bins.
+const sketchExact = 512
+
+// sketchGamma is the ratio between the bounds of the bins of a Sketch, so
+// that the middle of a bin is within 1% of the values counted in it.
+const sketchGamma = 1.01 / 0.99
+
+// sketchBins caps the bins of a Sketch. Past it the lowest bins are folded
+// into each other, which leaves a ratio of 10^8 between the lowest and the
+// highest values within 1%.
+const sketchBins = 1024
+
+// Sketch summarizes non-negative values, such as the gas prices of the
+// transactions of a bucket, in bounded memory. It keeps the first
+// sketchExact values, whose quantiles are exact, and then counts the values
+// in logarithmic bins, whose quantiles are within 1%. The JSON of checkpoints
+// written when the values were kept in full is read back into a Sketch.
+type Sketch struct {
+	Count  uint64
+	Sum    float64
+	Values []float64      `json:",omitempty"` // while no more than sketchExact
+	Zeros  uint64         `json:",omitempty"` // values of zero once binned
+	Bins   map[int]uint64 `json:",omitempty"` // counts by bin once binned
+}
+
+// binned reports whether s counts its values in bins.
+func (s *Sketch) binned() bool {
+	return s.Count > uint64(len(s.Values))
+}
+
+// Add adds the value x to s.
+func (s *Sketch) Add(x float64) {
+	binned := s.binned()
+	s.Count++
+	s.Sum += x
+	if binned {
+		s.insert(x, 1)
+		s.fold()
+		return
+	}
+	s.Values = append(s.Values, x)
+	if len(s.Values) > sketchExact {
+		s.bin()
+	}
+}
+
+// Merge adds the values of o to s.
+func (s *Sketch) Merge(o *Sketch) {
+	if !s.binned() && !o.binned() && len(s.Values)+len(o.Values) <= sketchExact {
+		s.Count += o.Count
+		s.Sum += o.Sum
+		s.Values = append(s.Values, o.Values...)
+		return
+	}
+	s.bin()
+	s.Count += o.Count
+	s.Sum += o.Sum
+	for _, x := range o.Values {
+		s.insert(x, 1)
+	}
+	s.Zeros += o.Zeros
+	for i, n := range o.Bins {
+		s.addBin(i, n)
+	}
+	s.fold()
+}
+
+// bin moves the values kept by s into its bins.
+func (s *Sketch) bin() {
+	for _, x := range s.Values {
+		s.insert(x, 1)
+	}
+	s.Values = nil
+	s.fold()
+}
+
+// insert counts n values x in their bin, whose upper bound is the first
+// power of sketchGamma not below x.
+func (s *Sketch) insert(x float64, n uint64) {
+	if x <= 0 {
+		s.Zeros += n
+		return
+	}
+	s.addBin(int(math.Ceil(math.Log(x)/math.Log(sketchGamma))), n)
+}
+
+// addBin counts n values in bin i.
+func (s *Sketch) addBin(i int, n uint64) {
+	if s.Bins == nil {
+		s.Bins = make(map[int]uint64)
+	}
+	s.Bins[i] += n
+}
+
+// fold folds the lowest bins of s into the next ones until there are no more
+// than sketchBins.
+func (s *Sketch) fold() {
+	if len(s.Bins) <= sketchBins {
+		return
+	}
+	keys := s.binKeys()
+	for _, i := range keys[:len(keys)-sketchBins] {
+		s.Bins[keys[len(keys)-sketchBins]] += s.Bins[i]
+		delete(s.Bins, i)
+	}
+}
+
+// binKeys returns the bins of s in ascending order.
+func (s *Sketch) binKeys() []int {
+	keys := make([]int, 0, len(s.Bins))
+	for i := range s.Bins {
+		keys = append(keys, i)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// binValue returns the value standing for those of bin i, the middle of its
+// bounds.
+func binValue(i int) float64 {
+	return 2 * math.Pow(sketchGamma, float64(i)) / (sketchGamma + 1)
+}
+
+// Quantile returns the p-th percentile of the values of s by nearest rank,
+// or false without any, e.g. for results read back from a database.
+func (s *Sketch) Quantile(p int) (float64, bool) {
+	if !s.binned() {
+		values := s.Values
+		if !sort.Float64sAreSorted(values) {
+			values = slices.Clone(values)
+			sort.Float64s(values)
+		}
+		return Percentile(values, p)
+	}
+	rank := max((uint64(p)*s.Count+99)/100, 1) // ceil(p% of n)
+	if rank <= s.Zeros {
+		return 0, true
+	}
+	seen := s.Zeros
+	keys := s.binKeys()
+	for _, i := range keys {
+		if seen += s.Bins[i]; seen >= rank {
+			return binValue(i), true
+		}
+	}
+	return binValue(keys[len(keys)-1]), true
+}
+
+// Mean returns the mean of the values of s, or false without any.
+func (s *Sketch) Mean() (float64, bool) {
+	if s.Count == 0 {
+		return 0, false
+	}
+	return s.Sum / float64(s.Count), true
+}
+
+// Above returns the number of values of s at or above threshold, those of the
+// bins whose middle is.
+func (s *Sketch) Above(threshold float64) uint64 {
+	if !s.binned() {
+		var n uint64
+		for _, x := range s.Values {
+			if x >= threshold {
+				n++
+			}
+		}
+		return n
+	}
+	var n uint64
+	if threshold <= 0 {
+		n = s.Zeros
+	}
+	for i, count := range s.Bins {
+		if binValue(i) >= threshold {
+			n += count
+		}
+	}
+	return n
+}
+
+// sort sorts the values kept by s, for Quantile.
+func (s *Sketch) sort() {
+	sort.Float64s(s.Values)
+}
+
+// Clone returns a copy of s that does not share its values or bins.
+func (s *Sketch) Clone() Sketch {
+	c := *s
+	c.Values = slices.Clone(s.Values)
+	if s.Bins != nil {
+		c.Bins = make(map[int]uint64, len(s.Bins))
+		for i, n := range s.Bins {
+			c.Bins[i] = n
+		}
+	}
+	return c
+}
+
+// UnmarshalJSON reads a Sketch, or the array of all the values that
+// checkpoints held before sketches.
+func (s *Sketch) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		var values []float64
+		if err := json.Unmarshal(data, &values); err != nil {
+			return err
+		}
+		*s = Sketch{}
+		for _, x := range values {
+			s.Add(x)
+		}
+		return nil
+	}
+	type plain Sketch
+	return json.Unmarshal(data, (*plain)(s))
+}
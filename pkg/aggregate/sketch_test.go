@@ -0,0 +1,119 @@
+package aggregate_test
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+)
+
+// nearestRank returns the p-th percentile of sorted values by nearest rank.
+func nearestRank(sorted []float64, p int) float64 {
+	v, _ := aggregate.Percentile(sorted, p)
+	return v
+}
+
+func TestSketchQuantiles(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range []int{1, 100, 512, 513, 100_000} {
+		var s aggregate.Sketch
+		values := make([]float64, n)
+		for i := range values {
+			// Gas prices in Gwei from 0.001 to 1000, and some zeros.
+			if rng.Intn(50) > 0 {
+				values[i] = math.Pow(10, rng.Float64()*6-3)
+			}
+			s.Add(values[i])
+		}
+		sort.Float64s(values)
+		for _, p := range []int{1, 50, 90, 99, 100} {
+			got, ok := s.Quantile(p)
+			want := nearestRank(values, p)
+			if !ok {
+				t.Fatalf("n=%d p%d: no quantile", n, p)
+			}
+			if n <= 512 && got != want || math.Abs(got-want) > 0.01*want {
+				t.Errorf("n=%d p%d: got %g, want %g", n, p, got, want)
+			}
+		}
+		if mean, _ := s.Mean(); s.Count != uint64(n) || math.Abs(mean*float64(n)-sum(values)) > 1e-6*sum(values) {
+			t.Errorf("n=%d: count %d and mean %g, want %d and %g", n, s.Count, mean, n, sum(values)/float64(n))
+		}
+	}
+}
+
+func sum(values []float64) float64 {
+	var total float64
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+// TestSketchMerge merges sketches below and past the values they keep, as
+// Finalize merges the buckets into the total.
+func TestSketchMerge(t *testing.T) {
+	var parts [3]aggregate.Sketch
+	var all aggregate.Sketch
+	for i := 1; i <= 1000; i++ {
+		x := float64(i)
+		parts[i%3].Add(x)
+		all.Add(x)
+	}
+	var small aggregate.Sketch
+	small.Add(5)
+	var merged aggregate.Sketch
+	merged.Merge(&small)
+	if v, _ := merged.Quantile(50); v != 5 || merged.Count != 1 {
+		t.Errorf("merged exact sketch: p50 %g of %d values, want 5 of 1", v, merged.Count)
+	}
+	merged = aggregate.Sketch{}
+	for i := range parts {
+		merged.Merge(&parts[i])
+	}
+	for _, p := range []int{10, 50, 99} {
+		got, _ := merged.Quantile(p)
+		want, _ := all.Quantile(p)
+		if got != want {
+			t.Errorf("p%d of the merged sketches %g, want %g", p, got, want)
+		}
+	}
+	if got, want := merged.Above(900), uint64(101); math.Abs(float64(got)-float64(want)) > 2 {
+		t.Errorf("%d values above 900, want about %d", got, want)
+	}
+}
+
+// TestSketchJSON reads sketches back from checkpoints, including those
+// written with every value.
+func TestSketchJSON(t *testing.T) {
+	var s aggregate.Sketch
+	for i := 0; i < 600; i++ {
+		s.Add(float64(i % 7))
+	}
+	data, err := json.Marshal(&s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back aggregate.Sketch
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []int{10, 50, 90} {
+		got, _ := back.Quantile(p)
+		want, _ := s.Quantile(p)
+		if got != want {
+			t.Errorf("p%d read back %g, want %g", p, got, want)
+		}
+	}
+
+	var legacy aggregate.Sketch
+	if err := json.Unmarshal([]byte("[1.5, 3, 2]"), &legacy); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := legacy.Quantile(50); v != 2 || legacy.Count != 3 {
+		t.Errorf("legacy array: p50 %g of %d values, want 2 of 3", v, legacy.Count)
+	}
+}
@@ -0,0 +1,172 @@
+// Package alert notifies chat channels, Slack, Discord or Telegram, when the
+// costs of a day cross configured thresholds.
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/webhook"
+)
+
+// telegramAPI is the base URL of the Telegram Bot API.
+const telegramAPI = "https://api.telegram.org"
+
+// maxDays bounds the days listed in one message, so that a run over a long
+// period does not post a wall of text.
+const maxDays = 10
+
+// Thresholds are the daily values above which an alert is sent. Zero
+// disables a threshold.
+type Thresholds struct {
+	DailyCostEth            float64 `yaml:"daily-cost-eth" toml:"daily-cost-eth"`
+	AvgBlobGasPriceGwei     float64 `yaml:"avg-blob-gas-price-gwei" toml:"avg-blob-gas-price-gwei"`
+	AvgCalldataGasPriceGwei float64 `yaml:"avg-calldata-gas-price-gwei" toml:"avg-calldata-gas-price-gwei"`
+}
+
+// Telegram identifies the bot posting the alerts and the chat receiving them.
+type Telegram struct {
+	Token  string `yaml:"token" toml:"token"`
+	ChatID string `yaml:"chat-id" toml:"chat-id"`
+}
+
+// Config is the alerts section of the config file.
+type Config struct {
+	Thresholds Thresholds `yaml:"thresholds" toml:"thresholds"`
+	// ReportURL is linked from the messages; "{day}" in it is replaced by the
+	// last day that crossed a threshold.
+	ReportURL string `yaml:"report-url" toml:"report-url"`
+	// Slack and Discord are incoming webhook URLs.
+	Slack    string   `yaml:"slack" toml:"slack"`
+	Discord  string   `yaml:"discord" toml:"discord"`
+	Telegram Telegram `yaml:"telegram" toml:"telegram"`
+}
+
+// Enabled reports whether cfg has a channel to send to.
+func (cfg Config) Enabled() bool {
+	return cfg.Slack != "" || cfg.Discord != "" || cfg.Telegram.Token != ""
+}
+
+// Crossed returns a description of every threshold that r, the result of a
+// day, is above. Blob gas prices that are unavailable are not compared.
+func (t Thresholds) Crossed(r *aggregate.Result) []string {
+	var crossed []string
+	check := func(name string, value *big.Float, limit float64, unit string) {
+		if v, _ := value.Float64(); limit > 0 && v > limit {
+			crossed = append(crossed, fmt.Sprintf("%s %.6g %s > %g %s", name, v, unit, limit, unit))
+		}
+	}
+	if r.BlobPriceMissing == 0 {
+		check("daily cost", r.Cost, t.DailyCostEth, "ETH")
+		check("average blob gas price", r.AvgBlobGasPrice, t.AvgBlobGasPriceGwei, "gwei")
+	}
+	check("average calldata gas price", r.AvgCallDataGasPrice, t.AvgCalldataGasPriceGwei, "gwei")
+	return crossed
+}
+
+// Notifier sends the alerts of a Config.
+type Notifier struct {
+	cfg Config
+	// Logger receives the alerts sent; nil for slog.Default().
+	Logger *slog.Logger
+}
+
+// New returns a notifier of cfg.
+func New(cfg Config) *Notifier {
+	return &Notifier{cfg: cfg}
+}
+
+// Check sends one message listing the days among dates whose results cross a
+// threshold, if any, to every configured channel. name says what the results
+// cover, e.g. the report name.
+func (n *Notifier) Check(ctx context.Context, name string, dates []string, results map[string]*aggregate.Result) error {
+	var b strings.Builder
+	var last string
+	listed := 0
+	for _, day := range dates {
+		r := results[day]
+		crossed := n.cfg.Thresholds.Crossed(r)
+		if len(crossed) == 0 {
+			continue
+		}
+		last = day
+		if listed++; listed > maxDays {
+			continue
+		}
+		cost, blobPrice := aggregate.Unavailable, aggregate.Unavailable
+		if r.BlobPriceMissing == 0 {
+			cost, blobPrice = r.Cost.Text('f', 6), r.AvgBlobGasPrice.Text('g', 4)
+		}
+		fmt.Fprintf(&b, "\n%s: %s ETH over %d transactions, calldata %s gwei, blob %s gwei\n",
+			day, cost, r.TxCount, r.AvgCallDataGasPrice.Text('g', 4), blobPrice)
+		for _, c := range crossed {
+			fmt.Fprintf(&b, "- %s\n", c)
+		}
+	}
+	if listed == 0 {
+		return nil
+	}
+	if listed > maxDays {
+		fmt.Fprintf(&b, "\n... and %d more days\n", listed-maxDays)
+	}
+	if n.cfg.ReportURL != "" {
+		fmt.Fprintf(&b, "\nReport: %s\n", strings.ReplaceAll(n.cfg.ReportURL, "{day}", last))
+	}
+	text := fmt.Sprintf("L1 costs of %s crossed their thresholds on %d days\n%s", name, listed, b.String())
+	if listed == 1 {
+		text = fmt.Sprintf("L1 costs of %s crossed their thresholds on %s\n%s", name, last, b.String())
+	}
+	if err := n.Send(ctx, text); err != nil {
+		return err
+	}
+	log := n.Logger
+	if log == nil {
+		log = slog.Default()
+	}
+	log.Info("alert sent", "days", listed, "last", last)
+	return nil
+}
+
+// Send posts text to every configured channel, trying all of them before
+// returning the first error.
+func (n *Notifier) Send(ctx context.Context, text string) error {
+	var firstErr error
+	// secret is masked in errors, which may quote the URL.
+	post := func(channel, url, secret string, payload any) {
+		body, err := json.Marshal(payload)
+		if err == nil {
+			err = webhook.New(url, "").Post(ctx, "alert", body)
+		}
+		if err != nil && firstErr == nil {
+			msg := err.Error()
+			if secret != "" {
+				msg = strings.ReplaceAll(msg, secret, "***")
+			}
+			firstErr = fmt.Errorf("%s: %s", channel, msg)
+		}
+	}
+	if n.cfg.Slack != "" {
+		post("slack", n.cfg.Slack, n.cfg.Slack, map[string]string{"text": text})
+	}
+	if n.cfg.Discord != "" {
+		// Discord rejects messages over 2000 characters.
+		content := text
+		if len(content) > 2000 {
+			content = content[:1997] + "..."
+		}
+		post("discord", n.cfg.Discord, n.cfg.Discord, map[string]string{"content": content})
+	}
+	if t := n.cfg.Telegram; t.Token != "" {
+		post("telegram", telegramAPI+"/bot"+t.Token+"/sendMessage", t.Token, map[string]any{
+			"chat_id":                  t.ChatID,
+			"text":                     text,
+			"disable_web_page_preview": true,
+		})
+	}
+	return firstErr
+}
@@ -0,0 +1,120 @@
+// Package anomaly flags the days whose cost or gas prices stray from their
+// trailing baseline, such as fee-market spikes or a batcher posting far more
+// or less than usual.
+package anomaly
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+)
+
+// Detector flags the values of a day that deviate from the mean of the same
+// values over the Window preceding days by more than Sigma standard
+// deviations or Percent percent of the mean. Zero disables a criterion.
+type Detector struct {
+	Window  int
+	Sigma   float64
+	Percent float64
+}
+
+// Finding is a value of a day that deviates from its baseline.
+type Finding struct {
+	Metric string
+	Value  float64
+	Mean   float64
+	// Sigmas is the deviation in standard deviations of the baseline, 0 if
+	// the baseline does not vary. Percent is the deviation in percent of its
+	// mean.
+	Sigmas  float64
+	Percent float64
+}
+
+func (f Finding) String() string {
+	s := fmt.Sprintf("%s %.4g vs %.4g (%+.0f%%", f.Metric, f.Value, f.Mean, f.Percent)
+	if f.Sigmas != 0 {
+		s += fmt.Sprintf(", %+.1fσ", f.Sigmas)
+	}
+	return s + ")"
+}
+
+// metric is a value of the daily results that is checked.
+type metric struct {
+	name string
+	// blob tells that the value is unavailable when blob gas prices are
+	// missing.
+	blob  bool
+	value func(*aggregate.Result) *big.Float
+}
+
+var metrics = []metric{
+	{"cost", true, func(r *aggregate.Result) *big.Float { return r.Cost }},
+	{"calldata gas price", false, func(r *aggregate.Result) *big.Float { return r.AvgCallDataGasPrice }},
+	{"blob gas price", true, func(r *aggregate.Result) *big.Float { return r.AvgBlobGasPrice }},
+}
+
+// Detect returns the findings of the days of dates, keyed by day. A day is
+// only checked when at least half of the Window days before it have results,
+// so that the start of a report or a gap in it does not count as a baseline.
+func (d Detector) Detect(dates []string, results map[string]*aggregate.Result) map[string][]Finding {
+	findings := make(map[string][]Finding)
+	if d.Window <= 0 || (d.Sigma <= 0 && d.Percent <= 0) {
+		return findings
+	}
+	for _, day := range dates {
+		t, err := time.Parse(time.DateOnly, day)
+		if err != nil {
+			continue
+		}
+		r := results[day]
+		for _, m := range metrics {
+			if m.blob && r.BlobPriceMissing > 0 {
+				continue
+			}
+			var baseline []float64
+			for i := 1; i <= d.Window; i++ {
+				prev := results[t.AddDate(0, 0, -i).Format(time.DateOnly)]
+				if prev == nil || (m.blob && prev.BlobPriceMissing > 0) {
+					continue
+				}
+				v, _ := m.value(prev).Float64()
+				baseline = append(baseline, v)
+			}
+			if len(baseline) == 0 || 2*len(baseline) < d.Window {
+				continue
+			}
+			v, _ := m.value(r).Float64()
+			if f, ok := d.check(m.name, v, baseline); ok {
+				findings[day] = append(findings[day], f)
+			}
+		}
+	}
+	return findings
+}
+
+// check compares v with its baseline.
+func (d Detector) check(name string, v float64, baseline []float64) (Finding, bool) {
+	var mean, variance float64
+	for _, b := range baseline {
+		mean += b
+	}
+	mean /= float64(len(baseline))
+	for _, b := range baseline {
+		variance += (b - mean) * (b - mean)
+	}
+	std := math.Sqrt(variance / float64(len(baseline)))
+
+	f := Finding{Metric: name, Value: v, Mean: mean}
+	if std > 0 {
+		f.Sigmas = (v - mean) / std
+	}
+	if mean > 0 {
+		f.Percent = 100 * (v - mean) / mean
+	}
+	flagged := (d.Sigma > 0 && math.Abs(f.Sigmas) > d.Sigma) ||
+		(d.Percent > 0 && mean > 0 && math.Abs(f.Percent) > d.Percent)
+	return f, flagged
+}
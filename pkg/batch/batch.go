@@ -0,0 +1,430 @@
+// Package batch decodes the data that OP Stack batchers submit to L1, in
+// calldata or blobs, into frames and channels, and counts the L2 blocks and
+// transactions of the batches that the channels carry.
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/andybalholm/brotli"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	// derivationVersion0 is the first byte of batcher data carrying frames.
+	derivationVersion0 = 0
+	// maxFrameLen is the largest frame data the derivation accepts.
+	maxFrameLen = 1_000_000
+	// maxRLPBytes bounds the decompressed size of a channel, as the Fjord
+	// derivation does.
+	maxRLPBytes = 100_000_000
+	// channelVersionBrotli prefixes channels compressed with brotli.
+	channelVersionBrotli = 1
+
+	singularBatchType = 0
+	spanBatchType     = 1
+
+	// blobRounds are the rounds of four field elements in a blob, each of
+	// which encodes 127 bytes.
+	blobRounds = 1024
+	// maxBlobData is the most data a blob can encode.
+	maxBlobData = (4*31+3)*blobRounds - 4
+)
+
+// ErrNotBatcherData reports data that does not start with the derivation
+// version of frames, e.g. the calldata of an output proposal.
+var ErrNotBatcherData = errors.New("not batcher data")
+
+// Frame is a piece of a channel.
+type Frame struct {
+	Channel [16]byte
+	Number  uint16
+	Data    []byte
+	Last    bool
+}
+
+// ParseFrames parses batcher data, the calldata of a batcher transaction or
+// the data of one of its blobs, into its frames.
+func ParseFrames(data []byte) ([]Frame, error) {
+	if len(data) == 0 || data[0] != derivationVersion0 {
+		return nil, ErrNotBatcherData
+	}
+	data = data[1:]
+	var frames []Frame
+	for len(data) > 0 {
+		// channel id, frame number, frame data length, frame data, is last
+		if len(data) < 16+2+4 {
+			return nil, fmt.Errorf("frame %d: truncated header", len(frames))
+		}
+		var f Frame
+		copy(f.Channel[:], data)
+		f.Number = binary.BigEndian.Uint16(data[16:])
+		n := binary.BigEndian.Uint32(data[18:])
+		data = data[22:]
+		if n > maxFrameLen || uint64(len(data)) < uint64(n)+1 {
+			return nil, fmt.Errorf("frame %d: invalid data length %d", len(frames), n)
+		}
+		f.Data = data[:n]
+		switch data[n] {
+		case 0:
+		case 1:
+			f.Last = true
+		default:
+			return nil, fmt.Errorf("frame %d: invalid is_last byte %d", len(frames), data[n])
+		}
+		data = data[n+1:]
+		frames = append(frames, f)
+	}
+	return frames, nil
+}
+
+// BlobData returns the data that an OP Stack batcher encoded into blob.
+func BlobData(blob []byte) ([]byte, error) {
+	if len(blob) != 4096*32 {
+		return nil, fmt.Errorf("blob of %d bytes", len(blob))
+	}
+	if blob[1] != 0 {
+		return nil, fmt.Errorf("unknown blob encoding version %d", blob[1])
+	}
+	length := int(blob[2])<<16 | int(blob[3])<<8 | int(blob[4])
+	if length > maxBlobData {
+		return nil, fmt.Errorf("blob data length %d exceeds %d", length, maxBlobData)
+	}
+	// Every field element holds 31 bytes of data after a byte whose low six
+	// bits are reassembled, four at a time, into three more bytes. The first
+	// field element starts with the version and the length instead.
+	output := make([]byte, maxBlobData+4)
+	copy(output, blob[5:32])
+	opos, ipos := 28, 32
+	var encoded [4]byte
+	for round := 0; round < blobRounds && (round == 0 || opos < length); round++ {
+		j := 0
+		if round == 0 {
+			encoded[0], j = blob[0], 1
+		}
+		for ; j < 4; j++ {
+			if blob[ipos]&0b1100_0000 != 0 {
+				return nil, fmt.Errorf("field element %d: invalid high bits", ipos/32)
+			}
+			encoded[j] = blob[ipos]
+			copy(output[opos:], blob[ipos+1:ipos+32])
+			opos, ipos = opos+32, ipos+32
+		}
+		opos--
+		output[opos-96] = encoded[0]&0b0011_1111 | (encoded[1]&0b0011_0000)<<2
+		output[opos-64] = encoded[1]&0b0000_1111 | (encoded[3]&0b0000_1111)<<4
+		output[opos-32] = encoded[2]&0b0011_1111 | (encoded[3]&0b0011_0000)<<2
+	}
+	return output[:length], nil
+}
+
+// Counts are the L2 blocks and transactions of the batches of channels, and
+// the size of the channels before and after decompression.
+type Counts struct {
+	Blocks uint64
+	Txs    uint64
+	// ChannelBytes is the compressed size of the channels and RawBytes the
+	// size of the batches they decompress to.
+	ChannelBytes uint64
+	RawBytes     uint64
+	// Epochs counts the L2 blocks by their L1 origin, the epoch they belong
+	// to.
+	Epochs map[uint64]uint64
+}
+
+// Add adds n to c.
+func (c *Counts) Add(n Counts) {
+	c.Blocks += n.Blocks
+	c.Txs += n.Txs
+	c.ChannelBytes += n.ChannelBytes
+	c.RawBytes += n.RawBytes
+	for epoch, blocks := range n.Epochs {
+		c.addEpoch(epoch, blocks)
+	}
+}
+
+// addEpoch counts blocks L2 blocks of epoch.
+func (c *Counts) addEpoch(epoch, blocks uint64) {
+	if c.Epochs == nil {
+		c.Epochs = make(map[uint64]uint64)
+	}
+	c.Epochs[epoch] += blocks
+}
+
+// Channel is a channel completed by the frames added to a Bank, with the L1
+// transactions that carried its frames and their cost.
+type Channel struct {
+	ID [16]byte
+	// FirstBlock and LastBlock are the L1 blocks of the transactions that
+	// carried its first and last frames.
+	FirstBlock uint64
+	LastBlock  uint64
+	Txs        uint64 // transactions carrying its frames
+	// Cost is its share of the cost of those transactions in wei, split
+	// between the channels of a transaction by the size of their frames.
+	Cost *big.Int
+	Counts
+}
+
+// channel collects the frames of a channel until it is complete.
+type channel struct {
+	opened uint64 // L1 block of the first frame
+	frames map[uint16][]byte
+	last   int // number of the last frame, -1 while unknown
+	latest uint64
+	txs    uint64
+	cost   *big.Int
+}
+
+func (c *channel) complete() bool {
+	if c.last < 0 {
+		return false
+	}
+	for i := 0; i <= c.last; i++ {
+		if _, ok := c.frames[uint16(i)]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Bank reassembles channels from the frames of the batcher transactions,
+// which must be added in L1 order.
+type Bank struct {
+	// Timeout is the number of L1 blocks after which a channel still
+	// missing frames is dropped.
+	Timeout uint64
+
+	channels map[[16]byte]*channel
+}
+
+// NewBank returns a Bank with the channel timeout of the derivation before
+// Granite, the longest one.
+func NewBank() *Bank {
+	return &Bank{Timeout: 300, channels: make(map[[16]byte]*channel)}
+}
+
+// Add adds the frames of the transaction included in L1 block at cost wei,
+// nil if unknown, and returns the channels it completes. Channels that fail
+// to decode are left out and reported by the error.
+func (b *Bank) Add(block uint64, cost *big.Int, frames []Frame) ([]Channel, error) {
+	for id, c := range b.channels {
+		if block > c.opened+b.Timeout {
+			delete(b.channels, id)
+		}
+	}
+	shares := costShares(cost, frames)
+	carried := make(map[[16]byte]bool)
+	var completed []Channel
+	var errs []error
+	for i, f := range frames {
+		c := b.channels[f.Channel]
+		if c == nil {
+			c = &channel{opened: block, frames: make(map[uint16][]byte), last: -1, cost: new(big.Int)}
+			b.channels[f.Channel] = c
+		}
+		// Repeated frames are paid for all the same.
+		c.cost.Add(c.cost, shares[i])
+		if !carried[f.Channel] {
+			carried[f.Channel] = true
+			c.txs++
+		}
+		c.latest = block
+		if _, ok := c.frames[f.Number]; ok || c.last >= 0 && int(f.Number) > c.last {
+			continue
+		}
+		if f.Last {
+			c.last = int(f.Number)
+		}
+		c.frames[f.Number] = f.Data
+		if !c.complete() {
+			continue
+		}
+		delete(b.channels, f.Channel)
+		var data []byte
+		for i := 0; i <= c.last; i++ {
+			data = append(data, c.frames[uint16(i)]...)
+		}
+		n, err := channelCounts(data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("channel %x: %w", f.Channel, err))
+			continue
+		}
+		completed = append(completed, Channel{
+			ID:         f.Channel,
+			FirstBlock: c.opened,
+			LastBlock:  c.latest,
+			Txs:        c.txs,
+			Cost:       c.cost,
+			Counts:     n,
+		})
+	}
+	return completed, errors.Join(errs...)
+}
+
+// costShares splits cost between frames by the size of their data, the
+// remainder of the division going to the last one.
+func costShares(cost *big.Int, frames []Frame) []*big.Int {
+	shares := make([]*big.Int, len(frames))
+	var total int64
+	for _, f := range frames {
+		total += int64(len(f.Data))
+	}
+	if cost == nil {
+		cost = new(big.Int)
+	}
+	left := new(big.Int).Set(cost)
+	for i, f := range frames {
+		switch {
+		case i == len(frames)-1:
+			shares[i] = new(big.Int).Set(left)
+		case total == 0:
+			shares[i] = new(big.Int).Div(cost, big.NewInt(int64(len(frames))))
+		default:
+			shares[i] = new(big.Int).Mul(cost, big.NewInt(int64(len(f.Data))))
+			shares[i].Div(shares[i], big.NewInt(total))
+		}
+		left.Sub(left, shares[i])
+	}
+	return shares
+}
+
+// channelCounts decompresses the data of a channel and counts the blocks and
+// transactions of its batches and their size.
+func channelCounts(data []byte) (Counts, error) {
+	if len(data) == 0 {
+		return Counts{}, errors.New("empty channel")
+	}
+	var r io.Reader
+	switch {
+	case data[0]&0x0F == 8 || data[0]&0x0F == 15:
+		z, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return Counts{}, err
+		}
+		defer z.Close()
+		r = z
+	case data[0] == channelVersionBrotli:
+		r = brotli.NewReader(bytes.NewReader(data[1:]))
+	default:
+		return Counts{}, fmt.Errorf("unknown compression of channel starting with %#x", data[0])
+	}
+	counts := Counts{ChannelBytes: uint64(len(data))}
+	raw := &countingReader{r: io.LimitReader(r, maxRLPBytes)}
+	stream := rlp.NewStream(bufio.NewReader(raw), maxRLPBytes)
+	for {
+		b, err := stream.Bytes()
+		if errors.Is(err, io.EOF) {
+			counts.RawBytes = raw.n
+			return counts, nil
+		}
+		if err != nil {
+			return counts, err
+		}
+		if len(b) == 0 {
+			return counts, errors.New("empty batch")
+		}
+		n, err := batchCounts(b[0], b[1:])
+		if err != nil {
+			return counts, err
+		}
+		counts.Add(n)
+	}
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n uint64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += uint64(n)
+	return n, err
+}
+
+// singularBatch is a batch of a single L2 block.
+type singularBatch struct {
+	ParentHash   common.Hash
+	EpochNum     uint64
+	EpochHash    common.Hash
+	Timestamp    uint64
+	Transactions [][]byte
+}
+
+// batchCounts counts the blocks and transactions of a batch of type typ.
+func batchCounts(typ byte, data []byte) (Counts, error) {
+	switch typ {
+	case singularBatchType:
+		var batch singularBatch
+		if err := rlp.DecodeBytes(data, &batch); err != nil {
+			return Counts{}, fmt.Errorf("singular batch: %w", err)
+		}
+		return Counts{Blocks: 1, Txs: uint64(len(batch.Transactions)), Epochs: map[uint64]uint64{batch.EpochNum: 1}}, nil
+	case spanBatchType:
+		counts, err := spanBatchCounts(bytes.NewReader(data))
+		if err != nil {
+			return Counts{}, fmt.Errorf("span batch: %w", err)
+		}
+		return counts, nil
+	default:
+		return Counts{}, fmt.Errorf("unknown batch type %d", typ)
+	}
+}
+
+// spanBatchCounts reads the block and transaction counts of a span batch:
+// rel_timestamp, l1_origin_num, parent_check and l1_origin_check, then
+// block_count, origin_bits and block_tx_counts. l1_origin_num is the epoch of
+// the last block, and the origin bit of every other block tells whether the
+// epoch advanced from the block before.
+func spanBatchCounts(r *bytes.Reader) (Counts, error) {
+	if _, err := binary.ReadUvarint(r); err != nil {
+		return Counts{}, err
+	}
+	origin, err := binary.ReadUvarint(r)
+	if err != nil {
+		return Counts{}, err
+	}
+	if _, err := r.Seek(20+20, io.SeekCurrent); err != nil {
+		return Counts{}, err
+	}
+	blocks, err := binary.ReadUvarint(r)
+	if err != nil {
+		return Counts{}, err
+	}
+	if blocks == 0 || blocks > uint64(r.Len()) {
+		return Counts{}, fmt.Errorf("invalid block count %d", blocks)
+	}
+	bits := make([]byte, (blocks+7)/8)
+	if _, err := io.ReadFull(r, bits); err != nil {
+		return Counts{}, err
+	}
+	originBits := new(big.Int).SetBytes(bits)
+	counts := Counts{Blocks: blocks}
+	for i := blocks - 1; ; i-- {
+		counts.addEpoch(origin, 1)
+		if i == 0 {
+			break
+		}
+		if originBits.Bit(int(i)) == 1 && origin > 0 {
+			origin--
+		}
+	}
+	for i := uint64(0); i < blocks; i++ {
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return Counts{}, err
+		}
+		counts.Txs += n
+	}
+	return counts, nil
+}
@@ -0,0 +1,158 @@
+// Package budget tracks the month-to-date L1 costs against a monthly budget
+// and decides when its consumption is worth an alert.
+package budget
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+)
+
+// Levels are the fractions of the budget whose consumption is announced.
+var Levels = []float64{0.5, 0.8, 1}
+
+// Budget is a monthly spending limit, in ETH or in USD. USD budgets are
+// compared with the costs at a fixed ETH price.
+type Budget struct {
+	Amount float64
+	USD    bool
+	EthUSD float64 // price of 1 ETH in USD, for USD budgets
+}
+
+// Parse parses a budget such as "10", "10 ETH" or "30000 USD". ethUSD is the
+// ETH price that USD budgets need.
+func Parse(s string, ethUSD float64) (Budget, error) {
+	amount, unit, _ := strings.Cut(strings.TrimSpace(s), " ")
+	if unit == "" {
+		// Also accept "10ETH" and "30000USD".
+		i := strings.IndexFunc(amount, unicode.IsLetter)
+		if i > 0 {
+			amount, unit = amount[:i], amount[i:]
+		}
+	}
+	var b Budget
+	var err error
+	if b.Amount, err = strconv.ParseFloat(amount, 64); err != nil || b.Amount <= 0 {
+		return Budget{}, fmt.Errorf("invalid budget %q", s)
+	}
+	switch strings.ToUpper(strings.TrimSpace(unit)) {
+	case "", "ETH":
+	case "USD":
+		if ethUSD <= 0 {
+			return Budget{}, errors.New("a USD budget needs the ETH price in USD")
+		}
+		b.USD, b.EthUSD = true, ethUSD
+	default:
+		return Budget{}, fmt.Errorf("unknown budget currency %q, want ETH or USD", unit)
+	}
+	return b, nil
+}
+
+// Unit is the currency of the budget, ETH or USD.
+func (b Budget) Unit() string {
+	if b.USD {
+		return "USD"
+	}
+	return "ETH"
+}
+
+// convert returns an amount of ETH in the currency of the budget.
+func (b Budget) convert(eth float64) float64 {
+	if b.USD {
+		return eth * b.EthUSD
+	}
+	return eth
+}
+
+// Spend is the month-to-date spend at the end of a day.
+type Spend struct {
+	Month string  // YYYY-MM
+	Eth   float64 // cost since the first day of the month
+	// Used is the fraction of the budget spent and Remaining what is left of
+	// it in its currency, negative once overspent.
+	Used      float64
+	Remaining float64
+}
+
+// Track returns the month-to-date spend at every day of dates, the days of a
+// daily report in order, summing the costs of the earlier days of the same
+// month. Months the report starts in the middle of therefore only count from
+// its first day. Days with unavailable blob fees add their other costs.
+func (b Budget) Track(dates []string, results map[string]*aggregate.Result) map[string]Spend {
+	spends := make(map[string]Spend, len(dates))
+	var month string
+	var eth float64
+	for _, day := range dates {
+		if len(day) < len("2006-01") {
+			continue
+		}
+		if day[:7] != month {
+			month, eth = day[:7], 0
+		}
+		cost, _ := results[day].Cost.Float64()
+		eth += cost
+		spent := b.convert(eth)
+		spends[day] = Spend{Month: month, Eth: eth, Used: spent / b.Amount, Remaining: b.Amount - spent}
+	}
+	return spends
+}
+
+// Level returns the highest of Levels that used reaches, or 0.
+func Level(used float64) float64 {
+	var level float64
+	for _, l := range Levels {
+		if used >= l {
+			level = l
+		}
+	}
+	return level
+}
+
+// Message describes the spend of day, which reached level.
+func (b Budget) Message(name, day string, s Spend, level float64) string {
+	spent := fmt.Sprintf("%.6g %s", b.convert(s.Eth), b.Unit())
+	if b.USD {
+		spent += fmt.Sprintf(" (%.6g ETH at %g USD/ETH)", s.Eth, b.EthUSD)
+	}
+	verb := "reached"
+	if level >= 1 {
+		verb = "exhausted"
+	}
+	return fmt.Sprintf("L1 costs of %s %s %.0f%% of the %s budget: %s of %g %s spent by %s, %.6g %s remaining\n",
+		name, verb, 100*level, s.Month, spent, b.Amount, b.Unit(), day, s.Remaining, b.Unit())
+}
+
+// State records the highest level announced per month, so that each level
+// is announced once however often the month is reported.
+type State map[string]float64
+
+// LoadState reads the state at path; a missing file is an empty state.
+func LoadState(path string) (State, error) {
+	state := make(State)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return state, nil
+}
+
+// Save writes the state to path.
+func (s State) Save(path string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
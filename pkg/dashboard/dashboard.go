@@ -0,0 +1,267 @@
+// Package dashboard draws the terminal dashboard of the daemon: the running
+// cost of the day, the latest transaction, the blob base fee of the chain
+// head and the hourly costs of the last 24 hours.
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/fetch"
+)
+
+// logLines is the number of log lines kept below the dashboard.
+const logLines = 8
+
+// sparks are the bars of the sparkline, from the lowest.
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// Dashboard is a running dashboard. Its methods may be called from any
+// goroutine, before and during Run.
+type Dashboard struct {
+	program *tea.Program
+	done    atomic.Bool
+}
+
+// New returns a dashboard titled title, e.g. the followed addresses.
+func New(title string) *Dashboard {
+	m := &model{title: title, hours: make(map[time.Time]*hour)}
+	return &Dashboard{program: tea.NewProgram(m, tea.WithAltScreen())}
+}
+
+// Run draws the dashboard until ctx is done or the user quits with q or
+// Ctrl+C, in which case quit is called.
+func (d *Dashboard) Run(ctx context.Context, quit func()) error {
+	go func() {
+		<-ctx.Done()
+		d.program.Quit()
+	}()
+	final, err := d.program.Run()
+	d.done.Store(true)
+	if m, ok := final.(*model); ok && m.quit {
+		quit()
+	}
+	return err
+}
+
+// Tx adds a stored transaction.
+func (d *Dashboard) Tx(tx aggregate.Tx) {
+	d.send(txMsg(tx))
+}
+
+// Head shows the header of a new chain head.
+func (d *Dashboard) Head(number uint64, header fetch.Header) {
+	d.send(headMsg{number, header})
+}
+
+// Write shows the lines of p below the dashboard, so that the dashboard can
+// be the output of the logger. Once the dashboard is closed, they go to
+// stderr.
+func (d *Dashboard) Write(p []byte) (int, error) {
+	if d.done.Load() {
+		return os.Stderr.Write(p)
+	}
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		d.send(logMsg(line))
+	}
+	return len(p), nil
+}
+
+// send delivers msg to the model, dropping it once the dashboard is closed.
+func (d *Dashboard) send(msg tea.Msg) {
+	if !d.done.Load() {
+		d.program.Send(msg)
+	}
+}
+
+type (
+	txMsg   aggregate.Tx
+	headMsg struct {
+		number uint64
+		header fetch.Header
+	}
+	logMsg  string
+	tickMsg time.Time
+)
+
+// hour sums the transactions of an hour.
+type hour struct {
+	cost *big.Int
+	txs  int
+}
+
+type model struct {
+	title  string
+	width  int
+	hours  map[time.Time]*hour // by the start of the hour, UTC
+	last   *aggregate.Tx
+	head   *headMsg
+	logs   []string
+	now    time.Time
+	quit   bool
+	stored int
+}
+
+func (m *model) Init() tea.Cmd {
+	m.now = time.Now()
+	return tick()
+}
+
+// tick refreshes the relative times and the current hour every second.
+func tick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c":
+			m.quit = true
+			return m, tea.Quit
+		}
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+	case tickMsg:
+		m.now = time.Time(msg)
+		// Only the last two days are shown.
+		for start := range m.hours {
+			if m.now.Sub(start) > 48*time.Hour {
+				delete(m.hours, start)
+			}
+		}
+		return m, tick()
+	case txMsg:
+		tx := aggregate.Tx(msg)
+		start := tx.Time.UTC().Truncate(time.Hour)
+		h := m.hours[start]
+		if h == nil {
+			h = &hour{cost: new(big.Int)}
+			m.hours[start] = h
+		}
+		h.cost.Add(h.cost, tx.Cost)
+		h.txs++
+		m.stored++
+		if m.last == nil || tx.Block >= m.last.Block {
+			m.last = &tx
+		}
+	case headMsg:
+		m.head = &msg
+	case logMsg:
+		m.logs = append(m.logs, string(msg))
+		if len(m.logs) > logLines {
+			m.logs = m.logs[len(m.logs)-logLines:]
+		}
+	}
+	return m, nil
+}
+
+func (m *model) View() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s  (q to quit)\n\n", m.title)
+
+	now := m.now.UTC()
+	today := now.Truncate(24 * time.Hour)
+	dayCost, dayTxs := new(big.Int), 0
+	for start, h := range m.hours {
+		if !start.Before(today) {
+			dayCost.Add(dayCost, h.cost)
+			dayTxs += h.txs
+		}
+	}
+	fmt.Fprintf(&b, "Today (UTC)      %s ETH in %d txs\n", ether(dayCost), dayTxs)
+
+	if m.head != nil {
+		fmt.Fprintf(&b, "Head             block %d, %s ago\n", m.head.number, ago(now, m.head.header.Time))
+		if excess := m.head.header.ExcessBlobGas; excess != nil {
+			fmt.Fprintf(&b, "Blob base fee    %s Gwei\n", gwei(fetch.BlobBaseFee(*excess, m.head.header.Time)))
+		} else {
+			fmt.Fprintf(&b, "Blob base fee    -\n")
+		}
+		if m.head.header.BaseFee != nil {
+			fmt.Fprintf(&b, "Base fee         %s Gwei\n", gwei(m.head.header.BaseFee))
+		}
+	} else {
+		fmt.Fprintf(&b, "Head             waiting for the next block\n")
+	}
+
+	if tx := m.last; tx != nil {
+		kind := "calldata"
+		if tx.BlobGasUsed > 0 {
+			kind = fmt.Sprintf("%d blobs", tx.BlobGasUsed/params.BlobTxBlobGasPerBlob)
+		}
+		fmt.Fprintf(&b, "Latest tx        %s\n", tx.Hash.Hex())
+		fmt.Fprintf(&b, "                 block %d, %s ago, %s, %s ETH\n", tx.Block, ago(now, tx.Time), kind, ether(tx.Cost))
+	} else {
+		fmt.Fprintf(&b, "Latest tx        none since start\n")
+	}
+
+	// The last 24 hours, the current one included.
+	costs := make([]*big.Int, 24)
+	peak := new(big.Int)
+	current := now.Truncate(time.Hour)
+	for i := range costs {
+		costs[i] = new(big.Int)
+		if h := m.hours[current.Add(time.Duration(i-23)*time.Hour)]; h != nil {
+			costs[i] = h.cost
+		}
+		if costs[i].Cmp(peak) > 0 {
+			peak = costs[i]
+		}
+	}
+	fmt.Fprintf(&b, "\nLast 24 hours    peak %s ETH/h, %d txs stored since start\n", ether(peak), m.stored)
+	fmt.Fprintf(&b, "                 %s\n", sparkline(costs, peak))
+	fmt.Fprintf(&b, "                 %-21s now\n", "-24h")
+
+	if len(m.logs) > 0 {
+		b.WriteString("\n")
+		for _, line := range m.logs {
+			if m.width > 0 && len(line) > m.width {
+				line = line[:m.width]
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String()
+}
+
+// sparkline draws one bar per value, scaled to peak. Hours without any cost
+// are left blank.
+func sparkline(values []*big.Int, peak *big.Int) string {
+	var b strings.Builder
+	for _, v := range values {
+		if v.Sign() == 0 {
+			b.WriteRune(' ')
+			continue
+		}
+		level := new(big.Int).Mul(v, big.NewInt(int64(len(sparks)-1)))
+		level.Quo(level, peak)
+		b.WriteRune(sparks[level.Int64()])
+	}
+	return b.String()
+}
+
+// ago formats the time elapsed since t, in whole seconds.
+func ago(now, t time.Time) string {
+	if t.IsZero() {
+		return "?"
+	}
+	return max(now.Sub(t), 0).Truncate(time.Second).String()
+}
+
+func ether(wei *big.Int) string {
+	return new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(params.Ether)).Text('f', 6)
+}
+
+func gwei(wei *big.Int) string {
+	return new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(params.GWei)).Text('f', 3)
+}
@@ -0,0 +1,197 @@
+// Package email delivers reports by SMTP, with a summary in the body and the
+// report files attached, for readers who do not follow the report directory.
+package email
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// timeout bounds a whole delivery.
+const timeout = 2 * time.Minute
+
+// Mailer sends messages through an SMTP server.
+type Mailer struct {
+	// Addr is the host:port of the server. Port 465 is spoken over TLS;
+	// on other ports the connection is upgraded with STARTTLS when the server
+	// offers it.
+	Addr     string
+	Username string // authenticates with PLAIN if not empty
+	Password string
+	From     *mail.Address
+	To       []*mail.Address
+}
+
+// New returns a mailer sending from from, or from username if empty, to the
+// comma-separated addresses of to.
+func New(addr, username, password, from, to string) (*Mailer, error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return nil, fmt.Errorf("SMTP server %q: want host:port", addr)
+	}
+	if from == "" {
+		from = username
+	}
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("sender %q: %w", from, err)
+	}
+	recipients, err := mail.ParseAddressList(to)
+	if err != nil {
+		return nil, fmt.Errorf("recipients %q: %w", to, err)
+	}
+	return &Mailer{Addr: addr, Username: username, Password: password, From: sender, To: recipients}, nil
+}
+
+// Send sends a message with the plain text body and the files at paths
+// attached.
+func (m *Mailer) Send(subject, body string, paths ...string) error {
+	msg, err := m.message(subject, body, paths)
+	if err != nil {
+		return err
+	}
+	host, port, _ := net.SplitHostPort(m.Addr)
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	var conn net.Conn
+	if port == "465" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", m.Addr, &tls.Config{ServerName: host})
+	} else {
+		conn, err = dialer.Dial("tcp", m.Addr)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok && port != "465" {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if m.Username != "" {
+		// PlainAuth refuses to send the password over a connection that is
+		// neither encrypted nor to localhost.
+		if err := c.Auth(smtp.PlainAuth("", m.Username, m.Password, host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(m.From.Address); err != nil {
+		return err
+	}
+	for _, to := range m.To {
+		if err := c.Rcpt(to.Address); err != nil {
+			return fmt.Errorf("%s: %w", to.Address, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// message encodes a multipart message of the text body followed by the
+// attachments.
+func (m *Mailer) message(subject, body string, paths []string) ([]byte, error) {
+	var buf bytes.Buffer
+	parts := multipart.NewWriter(&buf)
+	to := make([]string, len(m.To))
+	for i, addr := range m.To {
+		to[i] = addr.String()
+	}
+	fmt.Fprintf(&buf, "From: %s\r\n", m.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", messageID(), domain(m.From.Address))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", parts.Boundary())
+
+	text, err := parts.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	qp := quotedprintable.NewWriter(text)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		name := filepath.Base(path)
+		contentType, params, err := mime.ParseMediaType(mime.TypeByExtension(filepath.Ext(name)))
+		if err != nil {
+			contentType, params = "application/octet-stream", map[string]string{}
+		}
+		params["name"] = name
+		part, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(contentType, params)},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": name})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoded := base64.StdEncoding.EncodeToString(data)
+		for len(encoded) > 76 {
+			fmt.Fprintf(part, "%s\r\n", encoded[:76])
+			encoded = encoded[76:]
+		}
+		fmt.Fprintf(part, "%s\r\n", encoded)
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// messageID returns a random identifier for the Message-ID header.
+func messageID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprint(time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// domain returns the domain of an email address.
+func domain(address string) string {
+	if i := strings.LastIndexByte(address, '@'); i >= 0 {
+		return address[i+1:]
+	}
+	return "localhost"
+}
@@ -0,0 +1,117 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Activity sums the blocks of a chain over a time range.
+type Activity struct {
+	Blocks uint64
+	// Txs leaves out the L1 attributes deposit that opens every OP Stack
+	// block.
+	Txs     uint64
+	GasUsed uint64
+}
+
+// activityBlock is the part of an eth_getBlockByNumber response summed into
+// an Activity.
+type activityBlock struct {
+	GasUsed      hexutil.Uint64 `json:"gasUsed"`
+	Transactions []common.Hash  `json:"transactions"`
+}
+
+// Activity sums the blocks mined from from until before to, up to the chain
+// head.
+func (s *Scanner) Activity(ctx context.Context, from, to time.Time) (Activity, error) {
+	latest, err := s.LatestBlock(ctx)
+	if err != nil {
+		return Activity{}, err
+	}
+	first, err := s.blockAt(ctx, from, latest)
+	if err != nil {
+		return Activity{}, err
+	}
+	end, err := s.blockAt(ctx, to, latest)
+	if err != nil {
+		return Activity{}, err
+	}
+
+	var (
+		mu       sync.Mutex
+		activity Activity
+		firstErr error
+	)
+	type chunk struct{ from, to uint64 }
+	chunks := make(chan chunk)
+	var wg sync.WaitGroup
+	for i := 0; i < max(s.Concurrency, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range chunks {
+				blocks, err := s.activityBlocks(ctx, c.from, c.to)
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				for _, block := range blocks {
+					activity.Blocks++
+					activity.GasUsed += uint64(block.GasUsed)
+					if n := len(block.Transactions); n > 0 {
+						activity.Txs += uint64(n - 1)
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	size := uint64(max(s.BatchSize, 1))
+	for start := first; start < end; start += size {
+		chunks <- chunk{start, min(start+size, end) - 1}
+	}
+	close(chunks)
+	wg.Wait()
+	if firstErr != nil {
+		return Activity{}, firstErr
+	}
+	return activity, nil
+}
+
+// activityBlocks fetches blocks from..to without their transactions in one
+// batch call.
+func (s *Scanner) activityBlocks(ctx context.Context, from, to uint64) ([]*activityBlock, error) {
+	blocks := make([]*activityBlock, to-from+1)
+	batch := make([]rpc.BatchElem, len(blocks))
+	for i := range batch {
+		batch[i] = rpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []any{hexutil.EncodeUint64(from + uint64(i)), false},
+			Result: &blocks[i],
+		}
+	}
+	err := s.Retry.do(ctx, func() error {
+		return s.RPC.call(ctx, "batch eth_getBlockByNumber", func(ctx context.Context, client *ethclient.Client) error {
+			if err := client.Client().BatchCallContext(ctx, batch); err != nil {
+				return err
+			}
+			for i := range batch {
+				if batch[i].Error != nil {
+					return fmt.Errorf("block %d: %w", from+uint64(i), batch[i].Error)
+				}
+				if blocks[i] == nil {
+					return fmt.Errorf("block %d not found", from+uint64(i))
+				}
+			}
+			return nil
+		})
+	})
+	return blocks, err
+}
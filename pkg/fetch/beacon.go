@@ -0,0 +1,139 @@
+package fetch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+)
+
+const (
+	// secondsPerSlot is the slot time of the beacon chain.
+	secondsPerSlot = 12
+	// maxBlobPayload is the largest payload the OP Stack blob encoding fits
+	// into a blob.
+	maxBlobPayload = 130044
+)
+
+// BeaconClient fetches blob sidecars from a beacon node REST API.
+type BeaconClient struct {
+	BaseURL string
+	Retry   RetryPolicy
+	HTTP    *http.Client
+
+	genesisOnce sync.Once
+	genesis     time.Time
+	genesisErr  error
+}
+
+// Sidecar is a blob with the versioned hash of its KZG commitment.
+type Sidecar struct {
+	VersionedHash common.Hash
+	Blob          []byte
+}
+
+// get performs one API call, retrying failed requests. A 404, returned for
+// slots without a block and for sidecars the node has pruned, fails with
+// ethereum.NotFound.
+func (c *BeaconClient) get(ctx context.Context, path string, result any) error {
+	return c.Retry.do(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(c.BaseURL, "/")+path, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Accept", "application/json")
+		resp, err := c.HTTP.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		switch resp.StatusCode {
+		case http.StatusOK:
+		case http.StatusNotFound:
+			return fmt.Errorf("beacon %s: %w", path, ethereum.NotFound)
+		default:
+			return fmt.Errorf("beacon %s: %s", path, resp.Status)
+		}
+		return json.NewDecoder(resp.Body).Decode(result)
+	})
+}
+
+// slot returns the slot of the block with the given time.
+func (c *BeaconClient) slot(ctx context.Context, blockTime time.Time) (uint64, error) {
+	c.genesisOnce.Do(func() {
+		var body struct {
+			Data struct {
+				GenesisTime string `json:"genesis_time"`
+			} `json:"data"`
+		}
+		if c.genesisErr = c.get(ctx, "/eth/v1/beacon/genesis", &body); c.genesisErr != nil {
+			return
+		}
+		var seconds int64
+		seconds, c.genesisErr = strconv.ParseInt(body.Data.GenesisTime, 10, 64)
+		c.genesis = time.Unix(seconds, 0)
+	})
+	if c.genesisErr != nil {
+		return 0, c.genesisErr
+	}
+	if blockTime.Before(c.genesis) {
+		return 0, fmt.Errorf("block time %s is before the beacon genesis", blockTime)
+	}
+	return uint64(blockTime.Sub(c.genesis) / (secondsPerSlot * time.Second)), nil
+}
+
+// Sidecars returns the blobs of the block with the given time.
+func (c *BeaconClient) Sidecars(ctx context.Context, blockTime time.Time) ([]Sidecar, error) {
+	slot, err := c.slot(ctx, blockTime)
+	if err != nil {
+		return nil, err
+	}
+	var body struct {
+		Data []struct {
+			Blob          hexutil.Bytes `json:"blob"`
+			KZGCommitment hexutil.Bytes `json:"kzg_commitment"`
+		} `json:"data"`
+	}
+	if err := c.get(ctx, fmt.Sprintf("/eth/v1/beacon/blob_sidecars/%d", slot), &body); err != nil {
+		return nil, err
+	}
+	sidecars := make([]Sidecar, len(body.Data))
+	for i, d := range body.Data {
+		var commitment kzg4844.Commitment
+		if len(d.KZGCommitment) != len(commitment) {
+			return nil, fmt.Errorf("beacon slot %d: malformed KZG commitment", slot)
+		}
+		copy(commitment[:], d.KZGCommitment)
+		sidecars[i] = Sidecar{
+			VersionedHash: kzg4844.CalcBlobHashV1(sha256.New(), &commitment),
+			Blob:          d.Blob,
+		}
+	}
+	return sidecars, nil
+}
+
+// BlobPayload returns the number of bytes of data a blob carries. Blobs in the
+// OP Stack encoding state their length; for other blobs it is the length up to
+// the last non-zero byte.
+func BlobPayload(blob []byte) int {
+	if len(blob) >= 5 && blob[1] == 0 {
+		if n := int(blob[2])<<16 | int(blob[3])<<8 | int(blob[4]); n <= maxBlobPayload {
+			return n
+		}
+	}
+	n := len(blob)
+	for n > 0 && blob[n-1] == 0 {
+		n--
+	}
+	return n
+}
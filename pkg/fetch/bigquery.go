@@ -0,0 +1,296 @@
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/input"
+)
+
+const (
+	bigQueryBaseURL = "https://bigquery.googleapis.com/bigquery/v2"
+	bigQueryScope   = "https://www.googleapis.com/auth/bigquery"
+	// BigQueryTable is the transactions table of the public Ethereum mainnet
+	// dataset.
+	BigQueryTable = "bigquery-public-data.crypto_ethereum.transactions"
+)
+
+// bigQueryWait is how long a request waits for the query to complete before
+// the job is polled again.
+const bigQueryWait = 30 * time.Second
+
+// BigQueryClient lists transactions by querying a BigQuery table with the
+// schema of the crypto_ethereum dataset.
+type BigQueryClient struct {
+	BaseURL string
+	// Project is the Google Cloud project the queries run and are billed in.
+	Project string
+	// Table is the transactions table, BigQueryTable if empty.
+	Table string
+	Retry RetryPolicy
+	HTTP  *http.Client // authenticated
+}
+
+// NewBigQueryClient returns a client authenticated with the application
+// default credentials, e.g. the service account key of
+// GOOGLE_APPLICATION_CREDENTIALS, running the queries in project, or in the
+// project of the credentials if empty.
+func NewBigQueryClient(ctx context.Context, project, table string, timeout time.Duration) (*BigQueryClient, error) {
+	creds, err := google.FindDefaultCredentials(ctx, bigQueryScope)
+	if err != nil {
+		return nil, fmt.Errorf("bigquery: %w", err)
+	}
+	if project == "" {
+		project = creds.ProjectID
+	}
+	if project == "" {
+		return nil, errors.New("bigquery: no project to run the query in")
+	}
+	base := &http.Client{Timeout: timeout}
+	return &BigQueryClient{
+		BaseURL: bigQueryBaseURL,
+		Project: project,
+		Table:   table,
+		HTTP:    oauth2.NewClient(context.WithValue(ctx, oauth2.HTTPClient, base), creds.TokenSource),
+	}, nil
+}
+
+type bigQueryParameter struct {
+	Name           string             `json:"name"`
+	ParameterType  bigQueryParamType  `json:"parameterType"`
+	ParameterValue bigQueryParamValue `json:"parameterValue"`
+}
+
+type bigQueryParamType struct {
+	Type      string             `json:"type"`
+	ArrayType *bigQueryParamType `json:"arrayType,omitempty"`
+}
+
+type bigQueryParamValue struct {
+	Value       string               `json:"value,omitempty"`
+	ArrayValues []bigQueryParamValue `json:"arrayValues,omitempty"`
+}
+
+type bigQueryResponse struct {
+	JobComplete  bool `json:"jobComplete"`
+	JobReference struct {
+		JobID    string `json:"jobId"`
+		Location string `json:"location"`
+	} `json:"jobReference"`
+	Schema struct {
+		Fields []struct {
+			Name string `json:"name"`
+		} `json:"fields"`
+	} `json:"schema"`
+	Rows []struct {
+		F []struct {
+			V *string `json:"v"`
+		} `json:"f"`
+	} `json:"rows"`
+	PageToken           string `json:"pageToken"`
+	TotalBytesProcessed string `json:"totalBytesProcessed"`
+}
+
+// call performs one API call, retrying failed requests, and decodes the JSON
+// response into result.
+func (c *BigQueryClient) call(ctx context.Context, method, path string, body any, result any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	return c.Retry.do(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err := c.HTTP.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return fmt.Errorf("bigquery: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+		}
+		return json.NewDecoder(resp.Body).Decode(result)
+	})
+}
+
+// query runs a standard SQL query and returns its rows, each as a map of
+// column name to value, null values left out, and the bytes it processed.
+func (c *BigQueryClient) query(ctx context.Context, sql string, params []bigQueryParameter) ([]map[string]string, int64, error) {
+	request := map[string]any{
+		"query":           sql,
+		"useLegacySql":    false,
+		"parameterMode":   "NAMED",
+		"queryParameters": params,
+		"timeoutMs":       bigQueryWait.Milliseconds(),
+	}
+	var resp bigQueryResponse
+	if err := c.call(ctx, http.MethodPost, "/projects/"+url.PathEscape(c.Project)+"/queries", request, &resp); err != nil {
+		return nil, 0, err
+	}
+	var (
+		rows      []map[string]string
+		processed int64
+	)
+	for {
+		if resp.JobComplete {
+			if processed == 0 {
+				processed, _ = strconv.ParseInt(resp.TotalBytesProcessed, 10, 64)
+			}
+			for _, row := range resp.Rows {
+				values := make(map[string]string, len(row.F))
+				for i, cell := range row.F {
+					if cell.V != nil && i < len(resp.Schema.Fields) {
+						values[resp.Schema.Fields[i].Name] = *cell.V
+					}
+				}
+				rows = append(rows, values)
+			}
+			if resp.PageToken == "" {
+				return rows, processed, nil
+			}
+		}
+		job := resp.JobReference
+		params := url.Values{"timeoutMs": {strconv.FormatInt(bigQueryWait.Milliseconds(), 10)}}
+		if job.Location != "" {
+			params.Set("location", job.Location)
+		}
+		if resp.JobComplete {
+			params.Set("pageToken", resp.PageToken)
+		}
+		schema := resp.Schema
+		resp = bigQueryResponse{}
+		path := "/projects/" + url.PathEscape(c.Project) + "/queries/" + url.PathEscape(job.JobID) + "?" + params.Encode()
+		if err := c.call(ctx, http.MethodGet, path, nil, &resp); err != nil {
+			return nil, 0, err
+		}
+		// Keep the schema of the previous page for a page without it.
+		if len(resp.Schema.Fields) == 0 {
+			resp.Schema = schema
+		}
+	}
+}
+
+// BigQueryInput lists the transactions sent by senders to recipients in the
+// UTC days fromDate to toDate (inclusive, toDate open-ended if empty), ordered
+// by block, from the transactions table of c. An empty list matches any
+// address. The days restrict the partitions that are read, and so the cost
+// of the query. It returns the rows and the bytes the query processed.
+func BigQueryInput(ctx context.Context, c *BigQueryClient, senders, recipients []common.Address, fromDate, toDate string) ([]input.Row, int64, error) {
+	table := c.Table
+	if table == "" {
+		table = BigQueryTable
+	}
+	params := []bigQueryParameter{bigQueryValue("from_date", "DATE", fromDate)}
+	where := []string{"block_timestamp >= TIMESTAMP(@from_date)"}
+	if toDate != "" {
+		params = append(params, bigQueryValue("to_date", "DATE", toDate))
+		where = append(where, "block_timestamp < TIMESTAMP(DATE_ADD(@to_date, INTERVAL 1 DAY))")
+	}
+	if len(senders) > 0 {
+		params = append(params, bigQueryAddresses("senders", senders))
+		where = append(where, "from_address IN UNNEST(@senders)")
+	}
+	if len(recipients) > 0 {
+		params = append(params, bigQueryAddresses("recipients", recipients))
+		where = append(where, "to_address IN UNNEST(@recipients)")
+	}
+	sql := fmt.Sprintf("SELECT `hash`, block_number, block_timestamp, from_address, to_address, nonce\n"+
+		"FROM `%s`\nWHERE %s\nORDER BY block_number, transaction_index", table, strings.Join(where, "\n  AND "))
+
+	results, processed, err := c.query(ctx, sql, params)
+	if err != nil {
+		return nil, 0, err
+	}
+	rows := make([]input.Row, 0, len(results))
+	for i, result := range results {
+		row, err := bigQueryRow(result)
+		if err != nil {
+			return nil, 0, fmt.Errorf("bigquery: row %d: %w", i+1, err)
+		}
+		row.Index = len(rows)
+		row.Line = i + 1
+		rows = append(rows, row)
+	}
+	return rows, processed, nil
+}
+
+// bigQueryRow converts a row of the transactions table to an input row.
+func bigQueryRow(result map[string]string) (input.Row, error) {
+	var row input.Row
+	hash := result["hash"]
+	if len(hash) != 66 || !strings.HasPrefix(hash, "0x") {
+		return row, fmt.Errorf("invalid transaction hash %q", hash)
+	}
+	row.Hash = common.HexToHash(hash)
+	block, err := strconv.ParseUint(result["block_number"], 10, 64)
+	if err != nil {
+		return row, fmt.Errorf("block number %q: %w", result["block_number"], err)
+	}
+	row.Block = block
+	// Timestamps are returned as floating point seconds, e.g. 1.717372824E9.
+	seconds, err := strconv.ParseFloat(result["block_timestamp"], 64)
+	if err != nil {
+		return row, fmt.Errorf("block timestamp %q: %w", result["block_timestamp"], err)
+	}
+	row.Time = time.Unix(int64(math.Round(seconds)), 0).UTC()
+	from := common.HexToAddress(result["from_address"])
+	row.From = &from
+	// Contract creations have no recipient.
+	if value, ok := result["to_address"]; ok && common.IsHexAddress(value) {
+		to := common.HexToAddress(value)
+		row.To = &to
+	}
+	if value, ok := result["nonce"]; ok {
+		nonce, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return row, fmt.Errorf("nonce %q: %w", value, err)
+		}
+		row.Nonce = &nonce
+	}
+	return row, nil
+}
+
+// bigQueryValue returns a scalar query parameter.
+func bigQueryValue(name, typ, value string) bigQueryParameter {
+	return bigQueryParameter{
+		Name:           name,
+		ParameterType:  bigQueryParamType{Type: typ},
+		ParameterValue: bigQueryParamValue{Value: value},
+	}
+}
+
+// bigQueryAddresses returns an array parameter of addresses, lowercase like
+// those of the crypto_ethereum tables.
+func bigQueryAddresses(name string, addresses []common.Address) bigQueryParameter {
+	p := bigQueryParameter{
+		Name:          name,
+		ParameterType: bigQueryParamType{Type: "ARRAY", ArrayType: &bigQueryParamType{Type: "STRING"}},
+	}
+	for _, address := range addresses {
+		p.ParameterValue.ArrayValues = append(p.ParameterValue.ArrayValues, bigQueryParamValue{Value: strings.ToLower(address.Hex())})
+	}
+	return p
+}
@@ -0,0 +1,46 @@
+package fetch
+
+import (
+	"math/big"
+	"time"
+)
+
+// blobFeeFractions are the blob base fee update fractions of Ethereum mainnet
+// from the activation time of the fork that set them, latest first.
+var blobFeeFractions = []struct {
+	since    time.Time
+	fraction int64
+}{
+	{time.Unix(1767747671, 0), 11684671}, // BPO2
+	{time.Unix(1765290071, 0), 8346193},  // BPO1
+	{time.Unix(1746612311, 0), 5007716},  // Prague
+	{time.Unix(1710338135, 0), 3338477},  // Cancun
+}
+
+// BlobBaseFee returns the blob gas price in wei of a mainnet block with the
+// given time and excess blob gas, as EIP-4844 derives it. It lets the price
+// be computed when a receipt lacks its blobGasPrice.
+func BlobBaseFee(excessBlobGas uint64, blockTime time.Time) *big.Int {
+	fraction := blobFeeFractions[len(blobFeeFractions)-1].fraction
+	for _, f := range blobFeeFractions {
+		if !blockTime.Before(f.since) {
+			fraction = f.fraction
+			break
+		}
+	}
+	return fakeExponential(big.NewInt(1), new(big.Int).SetUint64(excessBlobGas), big.NewInt(fraction))
+}
+
+// fakeExponential approximates factor * e ** (numerator / denominator) using
+// Taylor expansion, as specified by EIP-4844.
+func fakeExponential(factor, numerator, denominator *big.Int) *big.Int {
+	output := new(big.Int)
+	accum := new(big.Int).Mul(factor, denominator)
+	for i := int64(1); accum.Sign() > 0; i++ {
+		output.Add(output, accum)
+		accum.Mul(accum, numerator)
+		accum.Div(accum, denominator)
+		accum.Div(accum, big.NewInt(i))
+	}
+	return output.Div(output, denominator)
+}
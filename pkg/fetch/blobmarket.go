@@ -0,0 +1,115 @@
+package fetch
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// BlobMarket is the blob base fee of a chain over a time range, in wei: at
+// its first and last blocks, its lowest and highest, and the sum over its
+// Blocks for the average.
+type BlobMarket struct {
+	Blocks   uint64
+	Start    *big.Int
+	End      *big.Int
+	Min, Max *big.Int
+	Sum      *big.Int
+	// MinBaseFee is the lowest base fee of the blocks of the range, those
+	// before Cancun included, nil before London.
+	MinBaseFee *big.Int
+}
+
+// Avg returns the average blob base fee of the blocks of m, nil without
+// blocks.
+func (m BlobMarket) Avg() *big.Int {
+	if m.Blocks == 0 {
+		return nil
+	}
+	return new(big.Int).Div(m.Sum, new(big.Int).SetUint64(m.Blocks))
+}
+
+// BlobMarket reads the blob base fee of the blocks mined from from until
+// before to, up to the chain head, derived from their excess blob gas. Blocks
+// before Cancun are left out.
+func (s *Scanner) BlobMarket(ctx context.Context, from, to time.Time) (BlobMarket, error) {
+	latest, err := s.LatestBlock(ctx)
+	if err != nil {
+		return BlobMarket{}, err
+	}
+	first, err := s.blockAt(ctx, from, latest)
+	if err != nil {
+		return BlobMarket{}, err
+	}
+	end, err := s.blockAt(ctx, to, latest)
+	if err != nil {
+		return BlobMarket{}, err
+	}
+
+	var (
+		mu                   sync.Mutex
+		market               = BlobMarket{Sum: new(big.Int)}
+		firstBlock, endBlock uint64
+		firstErr             error
+	)
+	type chunk struct{ from, to uint64 }
+	chunks := make(chan chunk)
+	var wg sync.WaitGroup
+	for i := 0; i < max(s.Concurrency, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range chunks {
+				numbers := make([]uint64, 0, c.to-c.from+1)
+				for n := c.from; n <= c.to; n++ {
+					numbers = append(numbers, n)
+				}
+				var headers []Header
+				err := s.Retry.do(ctx, func() error {
+					var err error
+					headers, err = s.RPC.BlockHeaders(ctx, numbers)
+					return err
+				})
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				for i, h := range headers {
+					if h.BaseFee != nil && (market.MinBaseFee == nil || h.BaseFee.Cmp(market.MinBaseFee) < 0) {
+						market.MinBaseFee = h.BaseFee
+					}
+					if h.ExcessBlobGas == nil {
+						continue
+					}
+					fee := BlobBaseFee(*h.ExcessBlobGas, h.Time)
+					if market.Blocks == 0 || numbers[i] < firstBlock {
+						firstBlock, market.Start = numbers[i], fee
+					}
+					if market.Blocks == 0 || numbers[i] > endBlock {
+						endBlock, market.End = numbers[i], fee
+					}
+					if market.Min == nil || fee.Cmp(market.Min) < 0 {
+						market.Min = fee
+					}
+					if market.Max == nil || fee.Cmp(market.Max) > 0 {
+						market.Max = fee
+					}
+					market.Sum.Add(market.Sum, fee)
+					market.Blocks++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	size := uint64(max(s.BatchSize, 1))
+	for start := first; start < end; start += size {
+		chunks <- chunk{start, min(start+size, end) - 1}
+	}
+	close(chunks)
+	wg.Wait()
+	if firstErr != nil {
+		return BlobMarket{}, firstErr
+	}
+	return market, nil
+}
@@ -0,0 +1,183 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// BlobSchedule is a number of blobs per block targeted and allowed, with the
+// update fraction of the blob base fee, like those that Cancun (3/6) and
+// Prague (6/9) set.
+type BlobSchedule struct {
+	Name     string // target/max, e.g. 6/9
+	Target   uint64
+	Max      uint64
+	Fraction uint64
+}
+
+// ParseBlobSchedule parses a blob schedule written target/max, e.g. 6/9, or
+// target/max/fraction. Without a fraction, it takes the one of mainnet for the
+// same target and ratio of max to target: that of Cancun per target blob when
+// max is twice the target, that of Prague otherwise.
+func ParseBlobSchedule(s string) (BlobSchedule, error) {
+	parts := strings.Split(strings.TrimSpace(s), "/")
+	if len(parts) != 2 && len(parts) != 3 {
+		return BlobSchedule{}, fmt.Errorf("blob schedule %q: want target/max or target/max/fraction", s)
+	}
+	values := make([]uint64, len(parts))
+	for i, part := range parts {
+		v, err := strconv.ParseUint(part, 10, 64)
+		if err != nil || v == 0 {
+			return BlobSchedule{}, fmt.Errorf("blob schedule %q: %q is not a positive integer", s, part)
+		}
+		values[i] = v
+	}
+	schedule := BlobSchedule{Name: parts[0] + "/" + parts[1], Target: values[0], Max: values[1]}
+	if schedule.Max < schedule.Target {
+		return BlobSchedule{}, fmt.Errorf("blob schedule %q: the maximum is below the target", s)
+	}
+	switch {
+	case len(values) == 3:
+		schedule.Fraction = values[2]
+	case schedule.Max == 2*schedule.Target:
+		schedule.Fraction = schedule.Target * 3338477 / 3
+	default:
+		schedule.Fraction = schedule.Target * 5007716 / 6
+	}
+	return schedule, nil
+}
+
+// BlobReplay replays the blob gas used by the blocks of a range under
+// alternative blob schedules, to estimate the blob base fees they would have
+// had.
+type BlobReplay struct {
+	Source      ReceiptSource
+	Retry       RetryPolicy
+	Concurrency int
+	BatchSize   int // headers per request
+	// Logger receives the progress of the replay; nil for slog.Default().
+	Logger *slog.Logger
+}
+
+// Start returns a replay under schedules of the blocks from from to to,
+// whose blob base fees Fees returns as it goes. The replay starts from the
+// excess blob gas of block from and assumes the same demand: every block uses
+// the blob gas it did, capped at the maximum of the schedule.
+func (r *BlobReplay) Start(schedules []BlobSchedule, from, to uint64) *BlobReplayer {
+	return &BlobReplayer{replay: r, schedules: schedules, from: from, to: to, next: from}
+}
+
+// BlobReplayer is a replay of blocks under blob schedules, which holds the
+// headers of one window of blocks at a time.
+type BlobReplayer struct {
+	replay    *BlobReplay
+	schedules []BlobSchedule
+	from, to  uint64
+	next      uint64     // first block not replayed
+	excess    []uint64   // of block next under every schedule
+	headers   []Header   // fetched from block next on
+	fees      []*big.Int // of block next-1
+}
+
+// Fees returns the blob base fee of block under every schedule, in the order
+// of the schedules. Blocks must be asked for in ascending order; the same
+// block may be asked for again.
+func (p *BlobReplayer) Fees(ctx context.Context, block uint64) ([]*big.Int, error) {
+	if block < p.from || block > p.to || block+1 < p.next {
+		return nil, fmt.Errorf("block %d is outside the replay, at block %d of %d..%d", block, p.next, p.from, p.to)
+	}
+	for p.next <= block {
+		if len(p.headers) == 0 {
+			size := uint64(max(p.replay.BatchSize, 1))
+			end := min(p.next+size*uint64(max(p.replay.Concurrency, 1))-1, p.to)
+			if p.next > p.from {
+				logger(p.replay.Logger).Info("blob schedule replay progress", "replayed", p.next-p.from, "blocks", p.to-p.from+1)
+			}
+			var err error
+			if p.headers, err = p.replay.headers(ctx, p.next, end, size); err != nil {
+				return nil, err
+			}
+		}
+		h := p.headers[0]
+		p.headers = p.headers[1:]
+		if p.excess == nil {
+			if h.ExcessBlobGas == nil {
+				return nil, fmt.Errorf("block %d predates Cancun", p.next)
+			}
+			p.excess = make([]uint64, len(p.schedules))
+			for j := range p.excess {
+				p.excess[j] = *h.ExcessBlobGas
+			}
+		}
+		if p.next == block {
+			p.fees = make([]*big.Int, len(p.schedules))
+			for j, s := range p.schedules {
+				p.fees[j] = fakeExponential(big.NewInt(1), new(big.Int).SetUint64(p.excess[j]), new(big.Int).SetUint64(s.Fraction))
+			}
+		}
+		var used uint64
+		if h.BlobGasUsed != nil {
+			used = *h.BlobGasUsed
+		}
+		for j, s := range p.schedules {
+			p.excess[j] = nextExcessBlobGas(p.excess[j], min(used, s.Max*params.BlobTxBlobGasPerBlob), s.Target*params.BlobTxBlobGasPerBlob)
+		}
+		p.next++
+	}
+	return p.fees, nil
+}
+
+// nextExcessBlobGas returns the excess blob gas of the block after one with
+// excess and used blob gas, as EIP-4844 derives it.
+func nextExcessBlobGas(excess, used, target uint64) uint64 {
+	if excess+used < target {
+		return 0
+	}
+	return excess + used - target
+}
+
+// headers fetches the headers of blocks from..to, size per request and the
+// requests in parallel.
+func (r *BlobReplay) headers(ctx context.Context, from, to, size uint64) ([]Header, error) {
+	headers := make([]Header, to-from+1)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	for start := from; start <= to; start += size {
+		end := min(start+size-1, to)
+		numbers := make([]uint64, 0, end-start+1)
+		for n := start; n <= end; n++ {
+			numbers = append(numbers, n)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var fetched []Header
+			err := r.Retry.do(ctx, func() error {
+				var err error
+				fetched, err = r.Source.BlockHeaders(ctx, numbers)
+				return err
+			})
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+				return
+			}
+			copy(headers[numbers[0]-from:], fetched)
+		}()
+	}
+	wg.Wait()
+	return headers, firstErr
+}
@@ -0,0 +1,237 @@
+package fetch
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	receiptsBucket = []byte("receipts")
+	metaBucket     = []byte("meta")
+	chainIDKey     = []byte("chainId")
+)
+
+// Cache persists receipts fetched from the RPC, keyed by transaction
+// hash, so that later runs over the same transactions skip the network. It is
+// safe for concurrent use.
+type Cache struct {
+	db *bolt.DB
+}
+
+func OpenCache(path string) (*Cache, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(receiptsBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(metaBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Cache{db: db}, nil
+}
+
+// get returns the cached receipt of hash, or nil when it is not cached.
+func (c *Cache) get(hash common.Hash) (*types.Receipt, error) {
+	var data []byte
+	err := c.db.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket(receiptsBucket).Get(hash.Bytes()); v != nil {
+			data = append([]byte(nil), v...)
+		}
+		return nil
+	})
+	if err != nil || data == nil {
+		return nil, err
+	}
+	receipt := new(types.Receipt)
+	if err := json.Unmarshal(data, receipt); err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// put stores receipt. Concurrent puts are batched into a single transaction.
+func (c *Cache) put(hash common.Hash, receipt *types.Receipt) error {
+	data, err := json.Marshal(receipt)
+	if err != nil {
+		return err
+	}
+	return c.db.Batch(func(tx *bolt.Tx) error {
+		return tx.Bucket(receiptsBucket).Put(hash.Bytes(), data)
+	})
+}
+
+// ChainID returns the chain ID of the cached receipts, or 0 when no run
+// recorded it.
+func (c *Cache) ChainID() (uint64, error) {
+	var id uint64
+	err := c.db.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket(metaBucket).Get(chainIDKey); len(v) == 8 {
+			id = binary.BigEndian.Uint64(v)
+		}
+		return nil
+	})
+	return id, err
+}
+
+// SetChainID records the chain ID of the cached receipts, failing when the
+// cache already holds those of another chain.
+func (c *Cache) SetChainID(id uint64) error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(metaBucket)
+		if v := b.Get(chainIDKey); len(v) == 8 {
+			if cached := binary.BigEndian.Uint64(v); cached != id {
+				return fmt.Errorf("the cache holds receipts of chain %d, not %d", cached, id)
+			}
+			return nil
+		}
+		return b.Put(chainIDKey, binary.BigEndian.AppendUint64(nil, id))
+	})
+}
+
+// archiveHeader is the first line of an exported cache.
+type archiveHeader struct {
+	Version  int    `json:"version"`
+	ChainID  uint64 `json:"chainId,omitempty"`
+	Receipts int    `json:"receipts"`
+}
+
+// archiveEntry is a line of an exported cache after the header.
+type archiveEntry struct {
+	Hash    common.Hash     `json:"hash"`
+	Receipt json.RawMessage `json:"receipt"`
+}
+
+// Export writes the cached receipts to w as a gzipped archive of JSON lines,
+// a header and then one receipt per line, that Import reads back into another
+// cache. It returns the number of receipts written.
+func (c *Cache) Export(w io.Writer) (int, error) {
+	chainID, err := c.ChainID()
+	if err != nil {
+		return 0, err
+	}
+	zw := gzip.NewWriter(w)
+	enc := json.NewEncoder(zw)
+	n := 0
+	err = c.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(receiptsBucket)
+		header := archiveHeader{Version: 1, ChainID: chainID, Receipts: b.Stats().KeyN}
+		if err := enc.Encode(header); err != nil {
+			return err
+		}
+		return b.ForEach(func(k, v []byte) error {
+			n++
+			return enc.Encode(archiveEntry{Hash: common.BytesToHash(k), Receipt: v})
+		})
+	})
+	if err != nil {
+		return n, err
+	}
+	return n, zw.Close()
+}
+
+// Import adds the receipts of an archive written by Export to the cache,
+// replacing those it already holds, and returns their number. The archive
+// must be of the chain of the cache, if both record one.
+func (c *Cache) Import(r io.Reader) (int, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return 0, fmt.Errorf("not a cache archive: %w", err)
+	}
+	defer zr.Close()
+	dec := json.NewDecoder(bufio.NewReader(zr))
+	var header archiveHeader
+	if err := dec.Decode(&header); err != nil {
+		return 0, fmt.Errorf("not a cache archive: %w", err)
+	}
+	if header.Version != 1 {
+		return 0, fmt.Errorf("unsupported cache archive version %d", header.Version)
+	}
+	if header.ChainID != 0 {
+		if err := c.SetChainID(header.ChainID); err != nil {
+			return 0, err
+		}
+	}
+
+	// Receipts are written in transactions of a few thousand, rather than
+	// one per receipt or one for the whole archive.
+	const perTx = 5000
+	n := 0
+	entries := make([]archiveEntry, 0, perTx)
+	flush := func() error {
+		err := c.db.Update(func(tx *bolt.Tx) error {
+			b := tx.Bucket(receiptsBucket)
+			for _, e := range entries {
+				if err := b.Put(e.Hash.Bytes(), e.Receipt); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		n += len(entries)
+		entries = entries[:0]
+		return err
+	}
+	for {
+		var e archiveEntry
+		if err := dec.Decode(&e); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return n, fmt.Errorf("receipt %d: %w", n+len(entries)+1, err)
+		}
+		var receipt types.Receipt
+		if err := json.Unmarshal(e.Receipt, &receipt); err != nil {
+			return n, fmt.Errorf("receipt %s: %w", e.Hash, err)
+		}
+		if entries = append(entries, e); len(entries) == perTx {
+			if err := flush(); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, flush()
+}
+
+// ErrOffline is returned by Offline for whatever the cache lacks.
+var ErrOffline = errors.New("not in the receipt cache, and running offline")
+
+// Offline is a ReceiptSource without a node, for runs that resolve every
+// receipt from the cache.
+type Offline struct{}
+
+func (Offline) TransactionReceipts(ctx context.Context, hashes []common.Hash) ([]*types.Receipt, []error) {
+	errs := make([]error, len(hashes))
+	for i := range errs {
+		errs[i] = ErrOffline
+	}
+	return make([]*types.Receipt, len(hashes)), errs
+}
+
+func (Offline) BlockReceipts(ctx context.Context, number uint64) ([]*types.Receipt, error) {
+	return nil, ErrOffline
+}
+
+func (Offline) BlockHeaders(ctx context.Context, numbers []uint64) ([]Header, error) {
+	return nil, ErrOffline
+}
+
+func (c *Cache) Close() error {
+	return c.db.Close()
+}
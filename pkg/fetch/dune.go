@@ -0,0 +1,240 @@
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/input"
+)
+
+// dunePageSize is the number of result rows requested per page.
+const dunePageSize = 10000
+
+// dunePollInterval is the delay between two checks of a running execution.
+const dunePollInterval = 2 * time.Second
+
+// duneTimeLayout is the layout of the timestamps in Dune results.
+const duneTimeLayout = "2006-01-02 15:04:05.000 MST"
+
+// Names of the result columns read by DuneInput, in order of preference.
+var (
+	duneHashColumns  = []string{"hash", "tx_hash", "transaction_hash"}
+	duneBlockColumns = []string{"block_number", "block"}
+	duneTimeColumns  = []string{"block_time", "time", "timestamp"}
+	duneFromColumns  = []string{"from", "from_address"}
+	duneToColumns    = []string{"to", "to_address"}
+)
+
+// DuneClient reads the results of saved queries through the Dune API.
+type DuneClient struct {
+	BaseURL string // e.g. https://api.dune.com/api/v1
+	APIKey  string
+	Retry   RetryPolicy
+	HTTP    *http.Client
+	// Logger receives the messages of the client; nil for slog.Default().
+	Logger *slog.Logger
+}
+
+type duneExecution struct {
+	ExecutionID string `json:"execution_id"`
+	State       string `json:"state"`
+	Error       *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type duneResults struct {
+	duneExecution
+	Result struct {
+		Rows []map[string]any `json:"rows"`
+	} `json:"result"`
+	NextOffset *int `json:"next_offset"`
+}
+
+// call performs one API call, retrying failed requests, and decodes the JSON
+// response into result.
+func (c *DuneClient) call(ctx context.Context, method, path string, body any, result any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	return c.Retry.do(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.BaseURL, "/")+path, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("X-Dune-API-Key", c.APIKey)
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err := c.HTTP.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			var failure struct {
+				Error string `json:"error"`
+			}
+			json.NewDecoder(resp.Body).Decode(&failure)
+			if failure.Error != "" {
+				return fmt.Errorf("dune: %s: %s", resp.Status, failure.Error)
+			}
+			return fmt.Errorf("dune: %s", resp.Status)
+		}
+		dec := json.NewDecoder(resp.Body)
+		dec.UseNumber()
+		return dec.Decode(result)
+	})
+}
+
+// execute runs query with params and waits for it to complete, returning the
+// ID of the execution.
+func (c *DuneClient) execute(ctx context.Context, query int, params map[string]string) (string, error) {
+	body := map[string]any{}
+	if len(params) > 0 {
+		body["query_parameters"] = params
+	}
+	var exec duneExecution
+	if err := c.call(ctx, http.MethodPost, fmt.Sprintf("/query/%d/execute", query), body, &exec); err != nil {
+		return "", err
+	}
+	logger(c.Logger).Info("dune query executing", "query", query, "execution", exec.ExecutionID)
+	for {
+		switch exec.State {
+		case "QUERY_STATE_COMPLETED":
+			return exec.ExecutionID, nil
+		case "QUERY_STATE_FAILED", "QUERY_STATE_CANCELLED", "QUERY_STATE_EXPIRED":
+			if exec.Error != nil {
+				return "", fmt.Errorf("dune: execution %s: %s", exec.ExecutionID, exec.Error.Message)
+			}
+			return "", fmt.Errorf("dune: execution %s: %s", exec.ExecutionID, exec.State)
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(dunePollInterval):
+		}
+		if err := c.call(ctx, http.MethodGet, "/execution/"+exec.ExecutionID+"/status", nil, &exec); err != nil {
+			return "", err
+		}
+	}
+}
+
+// results returns the rows at path, the results of an execution or the
+// latest results of a query, reading every page.
+func (c *DuneClient) results(ctx context.Context, path string) ([]map[string]any, error) {
+	var rows []map[string]any
+	for offset := 0; ; {
+		var page duneResults
+		params := url.Values{"limit": {strconv.Itoa(dunePageSize)}, "offset": {strconv.Itoa(offset)}}
+		if err := c.call(ctx, http.MethodGet, path+"?"+params.Encode(), nil, &page); err != nil {
+			return nil, err
+		}
+		if page.State != "" && page.State != "QUERY_STATE_COMPLETED" {
+			return nil, fmt.Errorf("dune: results of %s: %s", path, page.State)
+		}
+		rows = append(rows, page.Result.Rows...)
+		if page.NextOffset == nil || *page.NextOffset <= offset {
+			return rows, nil
+		}
+		offset = *page.NextOffset
+	}
+}
+
+// DuneInput reads the transactions listed by the results of a saved Dune
+// query, so that the population can be defined in SQL. The query returns one
+// row per transaction with its hash in a hash, tx_hash or transaction_hash
+// column and, optionally, block_number, block_time, from and to columns,
+// which spare the corresponding RPC calls. Without params and unless execute
+// is set, the latest results of the query are read without running it again,
+// which costs no Dune credits.
+func DuneInput(ctx context.Context, c *DuneClient, query int, params map[string]string, execute bool) ([]input.Row, error) {
+	path := fmt.Sprintf("/query/%d/results", query)
+	if execute || len(params) > 0 {
+		id, err := c.execute(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+		path = "/execution/" + id + "/results"
+	}
+	results, err := c.results(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]input.Row, 0, len(results))
+	for i, result := range results {
+		row, err := duneRow(result)
+		if err != nil {
+			return nil, fmt.Errorf("dune: row %d: %w", i+1, err)
+		}
+		row.Index = len(rows)
+		row.Line = i + 1
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// duneRow converts a result row to an input row.
+func duneRow(result map[string]any) (input.Row, error) {
+	var row input.Row
+	hash, ok := duneColumn(result, duneHashColumns)
+	if !ok {
+		return row, fmt.Errorf("no %s column", strings.Join(duneHashColumns, ", "))
+	}
+	if len(hash) != 66 || !strings.HasPrefix(hash, "0x") {
+		return row, fmt.Errorf("invalid transaction hash %q", hash)
+	}
+	row.Hash = common.HexToHash(hash)
+	if block, ok := duneColumn(result, duneBlockColumns); ok {
+		n, err := strconv.ParseUint(block, 10, 64)
+		if err != nil {
+			return row, fmt.Errorf("block number %q: %w", block, err)
+		}
+		row.Block = n
+	}
+	if value, ok := duneColumn(result, duneTimeColumns); ok {
+		t, err := time.Parse(duneTimeLayout, value)
+		if err != nil {
+			if t, err = time.Parse(time.RFC3339, value); err != nil {
+				return row, fmt.Errorf("block time %q: %w", value, err)
+			}
+		}
+		row.Time = t.UTC()
+	}
+	if value, ok := duneColumn(result, duneFromColumns); ok && common.IsHexAddress(value) {
+		from := common.HexToAddress(value)
+		row.From = &from
+	}
+	// Contract creations have no recipient.
+	if value, ok := duneColumn(result, duneToColumns); ok && common.IsHexAddress(value) {
+		to := common.HexToAddress(value)
+		row.To = &to
+	}
+	return row, nil
+}
+
+// duneColumn returns the value of the first of names in result that is not
+// null, as text.
+func duneColumn(result map[string]any, names []string) (string, bool) {
+	for _, name := range names {
+		if value, ok := result[name]; ok && value != nil {
+			return fmt.Sprint(value), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,269 @@
+package fetch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/input"
+)
+
+// etherscanPageSize is the maximum number of records Etherscan returns per
+// page. It also caps page*offset at 10000, so long histories are paged by
+// moving the start block forward instead of the page number.
+const etherscanPageSize = 1000
+
+// EtherscanClient lists transactions through the Etherscan account API.
+type EtherscanClient struct {
+	BaseURL  string
+	APIKey   string
+	Interval time.Duration // minimum delay between requests
+	Retry    RetryPolicy
+	HTTP     *http.Client
+	mu       sync.Mutex
+	last     time.Time
+}
+
+// etherscanResponse is the body of an API response. The proxy module answers
+// like a JSON-RPC endpoint, with a result or an error and no status, unless
+// the request is rejected before reaching it.
+type etherscanResponse struct {
+	Status  string          `json:"status"`
+	Message string          `json:"message"`
+	Result  json.RawMessage `json:"result"`
+	Error   *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type etherscanTx struct {
+	BlockNumber string `json:"blockNumber"`
+	TimeStamp   string `json:"timeStamp"`
+	Hash        string `json:"hash"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	MethodID    string `json:"methodId"`
+}
+
+// errRateLimited is returned for responses rejected by the Etherscan rate
+// limiter; it is retried like any transient RPC error.
+var errRateLimited = errors.New("etherscan: rate limit reached")
+
+// get performs one API call, honouring the request interval and retrying
+// rate-limited or failed requests.
+func (c *EtherscanClient) get(ctx context.Context, params url.Values, result any) error {
+	params.Set("apikey", c.APIKey)
+	return c.Retry.do(ctx, func() error {
+		if err := c.pace(ctx); err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"?"+params.Encode(), nil)
+		if err != nil {
+			return err
+		}
+		resp, err := c.HTTP.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("etherscan: %s", resp.Status)
+		}
+		var body etherscanResponse
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			return err
+		}
+		if body.Error != nil {
+			return fmt.Errorf("etherscan: %s", body.Error.Message)
+		}
+		if params.Get("module") == "proxy" && body.Status == "" {
+			return json.Unmarshal(body.Result, result)
+		}
+		if body.Status != "1" {
+			var msg string
+			json.Unmarshal(body.Result, &msg)
+			switch {
+			case strings.Contains(strings.ToLower(msg), "rate limit"):
+				return errRateLimited
+			case body.Message == "No transactions found":
+				return json.Unmarshal([]byte("[]"), result)
+			}
+			return fmt.Errorf("etherscan: %s: %s", body.Message, msg)
+		}
+		return json.Unmarshal(body.Result, result)
+	})
+}
+
+// pace waits until Interval has passed since the previous request, so that
+// concurrent callers share the rate.
+func (c *EtherscanClient) pace(ctx context.Context) error {
+	c.mu.Lock()
+	now := time.Now()
+	next := c.last.Add(c.Interval)
+	if next.Before(now) {
+		next = now
+	}
+	c.last = next
+	c.mu.Unlock()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Until(next)):
+		return nil
+	}
+}
+
+// Receipt fetches the receipt of the transaction hash through the proxy
+// module of the API, failing with ethereum.NotFound when it does not exist.
+func (c *EtherscanClient) Receipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	var receipt *types.Receipt
+	err := c.get(ctx, url.Values{
+		"module": {"proxy"},
+		"action": {"eth_getTransactionReceipt"},
+		"txhash": {hash.Hex()},
+	}, &receipt)
+	if err != nil {
+		return nil, err
+	}
+	if receipt == nil {
+		return nil, ethereum.NotFound
+	}
+	return receipt, nil
+}
+
+// blockAt returns the first block mined at or after t.
+func (c *EtherscanClient) blockAt(ctx context.Context, t time.Time) (uint64, error) {
+	var block string
+	err := c.get(ctx, url.Values{
+		"module":    {"block"},
+		"action":    {"getblocknobytime"},
+		"timestamp": {strconv.FormatInt(t.Unix(), 10)},
+		"closest":   {"after"},
+	}, &block)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(block, 10, 64)
+}
+
+// transactions lists the transactions sent by address in blocks from..to
+// (inclusive), oldest first.
+func (c *EtherscanClient) transactions(ctx context.Context, address common.Address, from, to uint64) ([]input.Row, error) {
+	var rows []input.Row
+	seen := make(map[common.Hash]bool)
+	for start := from; start <= to; {
+		var page []etherscanTx
+		err := c.get(ctx, url.Values{
+			"module":     {"account"},
+			"action":     {"txlist"},
+			"address":    {address.Hex()},
+			"startblock": {strconv.FormatUint(start, 10)},
+			"endblock":   {strconv.FormatUint(to, 10)},
+			"page":       {"1"},
+			"offset":     {strconv.Itoa(etherscanPageSize)},
+			"sort":       {"asc"},
+		}, &page)
+		if err != nil {
+			return nil, err
+		}
+		var lastBlock uint64
+		for _, tx := range page {
+			block, err := strconv.ParseUint(tx.BlockNumber, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("etherscan: block number %q: %w", tx.BlockNumber, err)
+			}
+			timestamp, err := strconv.ParseInt(tx.TimeStamp, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("etherscan: timestamp %q: %w", tx.TimeStamp, err)
+			}
+			lastBlock = block
+			hash := common.HexToHash(tx.Hash)
+			if seen[hash] || common.HexToAddress(tx.From) != address {
+				continue
+			}
+			seen[hash] = true
+			row := input.Row{
+				Index: len(rows),
+				Hash:  hash,
+				Time:  time.Unix(timestamp, 0).UTC(),
+				Block: block,
+				From:  &address,
+			}
+			if len(tx.MethodID) == 10 {
+				row.Method = strings.ToLower(tx.MethodID)
+			}
+			// Contract creations have no recipient.
+			if common.IsHexAddress(tx.To) {
+				to := common.HexToAddress(tx.To)
+				row.To = &to
+			}
+			rows = append(rows, row)
+		}
+		if len(page) < etherscanPageSize {
+			break
+		}
+		// The last block of a full page may continue on the next one; start
+		// there again and rely on seen to skip the duplicates.
+		if lastBlock == start {
+			return nil, fmt.Errorf("etherscan: more than %d transactions in block %d", etherscanPageSize, start)
+		}
+		start = lastBlock
+	}
+	return rows, nil
+}
+
+// EtherscanInput lists the transactions of every sender in the block or date
+// range through Etherscan, ordered by block.
+func EtherscanInput(ctx context.Context, c *EtherscanClient, senders []common.Address, fromBlock, toBlock uint64, fromDate, toDate string) ([]input.Row, uint64, uint64, error) {
+	if fromDate != "" {
+		t, err := time.Parse(time.DateOnly, fromDate)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		if fromBlock, err = c.blockAt(ctx, t); err != nil {
+			return nil, 0, 0, err
+		}
+	}
+	if toDate != "" {
+		t, err := time.Parse(time.DateOnly, toDate)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		next, err := c.blockAt(ctx, t.AddDate(0, 0, 1))
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		toBlock = next - 1
+	}
+	if toBlock == 0 {
+		toBlock = math.MaxInt32
+	}
+
+	var rows []input.Row
+	for _, sender := range senders {
+		senderRows, err := c.transactions(ctx, sender, fromBlock, toBlock)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		rows = append(rows, senderRows...)
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Block < rows[j].Block })
+	for i := range rows {
+		rows[i].Index = i
+	}
+	return rows, fromBlock, toBlock, nil
+}
@@ -0,0 +1,775 @@
+// Package fetch resolves transaction receipts from L1 JSON-RPC endpoints and
+// discovers transactions by scanning blocks or through Etherscan.
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/batch"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/input"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/telemetry"
+)
+
+// Fetcher resolves the receipts of input rows, either from the CSV when
+// TrustCSV is set, from the receipt cache or from Source.
+type Fetcher struct {
+	Source ReceiptSource
+	Retry  RetryPolicy
+	Cache  *Cache // optional
+	// BlockReceiptsMin is the number of pending transactions sharing a block
+	// from which the whole block's receipts are fetched at once.
+	BlockReceiptsMin int
+	TrustCSV         bool
+	// BaseFees sets the BaseFee of every row to the one of its receipt's
+	// block, which costs a header request per block.
+	BaseFees bool
+	// Beacon, if set, serves the blobs of blob transactions, whose payload
+	// sizes are set on their rows. Source must implement TransactionSource.
+	Beacon *BeaconClient
+	// Recipients sets the To of rows that have none to the recipient of their
+	// transaction. Source must implement TransactionSource.
+	Recipients bool
+	// Senders sets the From of rows that have none to the sender of their
+	// transaction. Source must implement TransactionSource.
+	Senders bool
+	// Methods sets the Method of rows that have none to the selector of their
+	// transaction. Source must implement TransactionSource.
+	Methods bool
+	// Nonces sets the Nonce of rows that have none to the nonce of their
+	// transaction. Source must implement TransactionSource.
+	Nonces bool
+	// Frames parses the batcher data of every transaction, its calldata or
+	// with a Beacon client its blobs, into frames that TakeFrames returns.
+	// Source must implement TransactionSource.
+	Frames bool
+	// WhatIf sets the WhatIfCost of blob transactions, and with Frames of
+	// calldata batcher transactions.
+	WhatIf bool
+	// OracleBlocks, if not zero, sets the OracleTip of every row to the
+	// priority tip that a fee oracle would have suggested for its block: the
+	// median over the OracleBlocks blocks before it of their
+	// OraclePercentile-th percentile tip. Source must implement
+	// FeeHistorySource.
+	OracleBlocks     int
+	OraclePercentile float64
+	// MarketTips sets the MarketTip of every row to the median tip of its
+	// block. Source must implement FeeHistorySource.
+	MarketTips bool
+	// CalldataFloor sets the FloorGas and FloorActive of the rows of calldata
+	// transactions, fetching their transactions. Source must implement
+	// TransactionSource.
+	CalldataFloor bool
+	// DataSizes sets the PostedBytes of every row, fetching the transactions
+	// of calldata rows. Blobs that a Beacon client does not measure count as
+	// full. Source must implement TransactionSource.
+	DataSizes bool
+	// SampleStride and SampleLimit select the CSV-resolved rows verified
+	// against the RPC: every SampleStride-th row, up to SampleLimit rows.
+	SampleStride int
+	SampleLimit  int
+	// Logger receives the messages of the fetcher; nil for slog.Default().
+	Logger *slog.Logger
+
+	noBlockReceipts  atomic.Bool // eth_getBlockReceipts is not supported
+	headers          sync.Map    // block number -> Header
+	frames           sync.Map    // tx hash -> []batch.Frame
+	derivedBlobPrice sync.Once
+	unmeasuredBlobs  sync.Once
+	blockTips        sync.Map // block number -> *big.Int, nil for empty blocks
+	oracleFailed     sync.Once
+	medianTips       sync.Map // block number -> *big.Int
+	marketFailed     sync.Once
+
+	// Indexer, if set, serves the receipts that Source does not have, such
+	// as the old receipts of a node that no longer indexes its early
+	// transactions. IndexerResolved counts them.
+	Indexer         *EtherscanClient
+	IndexerResolved atomic.Int64
+
+	CSVResolved atomic.Int64
+	Verified    atomic.Int64
+	Mismatched  atomic.Int64
+}
+
+// Receipts resolves the receipts of rows. Rows that need the RPC are requested
+// together in a single JSON-RPC batch. Rows without a timestamp get the one of
+// the block their receipt belongs to, and with BaseFees its base fee. Blob
+// receipts without a blob gas price get the one derived from the excess blob
+// gas of their block. With Recipients, Senders, Methods and Nonces, rows
+// without a recipient, sender, method or nonce get the one of their
+// transaction, and with a Beacon client the payload sizes of the blobs of blob
+// transactions are set on their rows. With Frames, the frames of the rows are
+// kept for TakeFrames. With WhatIf, rows get the cost of their data in the
+// other posting mode, and with DataSizes its size. With CalldataFloor,
+// calldata rows get their EIP-7623 floor gas. With OracleBlocks, rows get
+// the tip a fee oracle would have suggested, and with MarketTips the median tip
+// of their block.
+func (f *Fetcher) Receipts(ctx context.Context, rows []input.Row) ([]*types.Receipt, []error) {
+	ctx, span := telemetry.Tracer().Start(ctx, "fetch.Receipts", trace.WithAttributes(attribute.Int("rows", len(rows))))
+	defer span.End()
+	receipts, errs := f.resolve(ctx, rows)
+	if err := f.blockHeaders(ctx, rows, receipts, errs); err != nil {
+		for i := range rows {
+			if errs[i] == nil && f.needsHeader(rows[i], receipts[i]) {
+				errs[i] = err
+			}
+		}
+	}
+	if f.Recipients || f.Senders || f.Methods || f.Nonces || f.Frames || f.DataSizes || f.CalldataFloor || f.Beacon != nil {
+		txs := f.transactions(ctx, rows, receipts, errs)
+		if f.Beacon != nil {
+			f.blobPayloads(ctx, rows, receipts, errs, txs)
+		}
+		if f.DataSizes {
+			f.dataSizes(rows, receipts, errs, txs)
+		}
+		if f.CalldataFloor {
+			f.calldataFloors(rows, receipts, errs, txs)
+		}
+	}
+	if f.WhatIf {
+		f.whatIf(rows, receipts, errs)
+	}
+	if f.OracleBlocks > 0 {
+		f.oracleTips(ctx, rows, receipts, errs)
+	}
+	if f.MarketTips {
+		f.marketTips(ctx, rows, receipts, errs)
+	}
+	return receipts, errs
+}
+
+// needsTransaction reports whether row needs its transaction.
+func (f *Fetcher) needsTransaction(row input.Row, receipt *types.Receipt) bool {
+	return f.needsFields(row) || f.Frames || (f.DataSizes || f.CalldataFloor) && receipt.Type != types.BlobTxType ||
+		f.Beacon != nil && receipt.Type == types.BlobTxType
+}
+
+// needsFields reports whether row lacks a recipient, sender, method or nonce
+// it needs.
+func (f *Fetcher) needsFields(row input.Row) bool {
+	return f.Recipients && row.To == nil || f.Senders && row.From == nil || f.Methods && row.Method == "" ||
+		f.Nonces && row.Nonce == nil
+}
+
+// transactions fetches the transactions of the rows that need them in one
+// batch, sets the recipient and method of the rows without them and returns
+// them by row. Failing to fetch them fails the rows that need those.
+func (f *Fetcher) transactions(ctx context.Context, rows []input.Row, receipts []*types.Receipt, errs []error) map[int]Transaction {
+	var needed []int
+	for i := range rows {
+		if errs[i] == nil && f.needsTransaction(rows[i], receipts[i]) {
+			needed = append(needed, i)
+		}
+	}
+	if len(needed) == 0 {
+		return nil
+	}
+	source, ok := f.Source.(TransactionSource)
+	if !ok {
+		err := errors.New("the receipt source serves no transactions")
+		f.transactionsFailed(rows, needed, errs, err)
+		return nil
+	}
+	hashes := make([]common.Hash, len(needed))
+	for j, i := range needed {
+		hashes[j] = rows[i].Hash
+	}
+	var fetched []Transaction
+	err := f.Retry.do(ctx, func() error {
+		var err error
+		fetched, err = source.Transactions(ctx, hashes)
+		return err
+	})
+	if err != nil {
+		f.transactionsFailed(rows, needed, errs, err)
+		return nil
+	}
+	txs := make(map[int]Transaction, len(needed))
+	for j, i := range needed {
+		txs[i] = fetched[j]
+		if rows[i].To == nil {
+			rows[i].To = fetched[j].To
+		}
+		if rows[i].From == nil {
+			from := fetched[j].From
+			rows[i].From = &from
+		}
+		if rows[i].Method == "" {
+			rows[i].Method = Selector(fetched[j].Input)
+		}
+		if rows[i].Nonce == nil {
+			nonce := fetched[j].Nonce
+			rows[i].Nonce = &nonce
+		}
+		if f.Frames && receipts[i].Type != types.BlobTxType {
+			rows[i].FrameBytes = f.keepFrames(rows[i].Hash, fetched[j].Input)
+		}
+	}
+	return txs
+}
+
+// keepFrames keeps the frames of the batcher data of the transaction hash for
+// TakeFrames and returns the size of their channel data. Data that holds no
+// frames is ignored.
+func (f *Fetcher) keepFrames(hash common.Hash, data ...[]byte) uint64 {
+	var frames []batch.Frame
+	var size uint64
+	for _, d := range data {
+		fr, err := batch.ParseFrames(d)
+		if err != nil {
+			if !errors.Is(err, batch.ErrNotBatcherData) {
+				logger(f.Logger).Debug("invalid batcher data", "tx", hash, "err", err)
+			}
+			continue
+		}
+		for _, frame := range fr {
+			size += uint64(len(frame.Data))
+		}
+		frames = append(frames, fr...)
+	}
+	if len(frames) > 0 {
+		f.frames.Store(hash, frames)
+	}
+	return size
+}
+
+// TakeFrames returns and forgets the frames of the transaction hash, or nil
+// if it carries none.
+func (f *Fetcher) TakeFrames(hash common.Hash) []batch.Frame {
+	v, ok := f.frames.LoadAndDelete(hash)
+	if !ok {
+		return nil
+	}
+	return v.([]batch.Frame)
+}
+
+// transactionsFailed fails the rows of needed that lack a recipient or method
+// with err, and warns that the blobs of the others are not measured.
+func (f *Fetcher) transactionsFailed(rows []input.Row, needed []int, errs []error, err error) {
+	for _, i := range needed {
+		if f.needsFields(rows[i]) {
+			errs[i] = err
+		} else {
+			f.unmeasured(rows[i].Hash, err)
+		}
+	}
+}
+
+// blobPayloads measures the blobs of the blob transactions among rows, whose
+// versioned hashes are in txs. A transaction whose blobs cannot be read, e.g.
+// because the beacon node has pruned them, is left unmeasured rather than
+// failed.
+func (f *Fetcher) blobPayloads(ctx context.Context, rows []input.Row, receipts []*types.Receipt, errs []error, txs map[int]Transaction) {
+	// Transactions of the same block share its sidecars. The slot is found
+	// from the block time of the header, which the input time may round.
+	blocks := make(map[uint64][]Sidecar)
+	for i := range rows {
+		tx, ok := txs[i]
+		if !ok || errs[i] != nil || receipts[i].Type != types.BlobTxType || receipts[i].BlockNumber == nil {
+			continue
+		}
+		number := receipts[i].BlockNumber.Uint64()
+		sidecars, ok := blocks[number]
+		if !ok {
+			v, _ := f.headers.Load(number)
+			var err error
+			if sidecars, err = f.Beacon.Sidecars(ctx, v.(Header).Time); err != nil {
+				f.unmeasured(rows[i].Hash, err)
+				continue
+			}
+			blocks[number] = sidecars
+		}
+		var measured, payload uint64
+		var data [][]byte
+		for _, hash := range tx.BlobHashes {
+			for _, s := range sidecars {
+				if s.VersionedHash == hash {
+					measured++
+					payload += uint64(BlobPayload(s.Blob))
+					if f.Frames {
+						if d, err := batch.BlobData(s.Blob); err == nil {
+							data = append(data, d)
+						}
+					}
+					break
+				}
+			}
+		}
+		if f.Frames {
+			rows[i].FrameBytes = f.keepFrames(rows[i].Hash, data...)
+		}
+		if measured < uint64(len(tx.BlobHashes)) {
+			f.unmeasured(rows[i].Hash, errors.New("blob sidecar not found"))
+			continue
+		}
+		rows[i].MeasuredBlobs, rows[i].BlobPayloadBytes = measured, payload
+	}
+}
+
+// dataSizes sets the PostedBytes of rows: the calldata of their transaction
+// in txs, or the payload of their blobs, with the unmeasured ones counting as
+// full.
+func (f *Fetcher) dataSizes(rows []input.Row, receipts []*types.Receipt, errs []error, txs map[int]Transaction) {
+	for i := range rows {
+		if errs[i] != nil {
+			continue
+		}
+		if receipts[i].Type != types.BlobTxType {
+			if tx, ok := txs[i]; ok {
+				rows[i].PostedBytes = uint64(len(tx.Input))
+			}
+			continue
+		}
+		blobs := receipts[i].BlobGasUsed / params.BlobTxBlobGasPerBlob
+		rows[i].PostedBytes = rows[i].BlobPayloadBytes + (blobs-rows[i].MeasuredBlobs)*maxBlobPayload
+	}
+}
+
+// unmeasured warns, once per run, that the blobs of a transaction could not
+// be measured.
+func (f *Fetcher) unmeasured(hash common.Hash, err error) {
+	f.unmeasuredBlobs.Do(func() {
+		logger(f.Logger).Warn("blobs not measured; blob utilization covers the measured transactions only", "tx", hash, "err", err)
+	})
+}
+
+// needsHeader reports whether row needs the header of the block of receipt.
+func (f *Fetcher) needsHeader(row input.Row, receipt *types.Receipt) bool {
+	return receipt.BlockNumber != nil && (row.Time.IsZero() || f.BaseFees || f.WhatIf || f.CalldataFloor || missingBlobPrice(receipt) ||
+		f.Beacon != nil && receipt.Type == types.BlobTxType)
+}
+
+func missingBlobPrice(receipt *types.Receipt) bool {
+	return receipt.Type == types.BlobTxType && receipt.BlobGasPrice == nil
+}
+
+func (f *Fetcher) resolve(ctx context.Context, rows []input.Row) ([]*types.Receipt, []error) {
+	receipts := make([]*types.Receipt, len(rows))
+	errs := make([]error, len(rows))
+
+	var missing []int
+	for i, row := range rows {
+		receipts[i], errs[i] = f.local(ctx, row)
+		if receipts[i] == nil && errs[i] == nil {
+			missing = append(missing, i)
+		}
+	}
+	requested := slices.Clone(missing)
+	missing = f.blockReceipts(ctx, rows, missing, receipts)
+	if len(missing) == 0 {
+		f.store(rows, requested, receipts, errs)
+		return receipts, errs
+	}
+
+	err := f.Retry.do(ctx, func() error {
+		hashes := make([]common.Hash, len(missing))
+		for j, i := range missing {
+			hashes[j] = rows[i].Hash
+		}
+		fetched, fetchErrs := f.Source.TransactionReceipts(ctx, hashes)
+		// Keep only the rows worth asking for again; the first of their
+		// errors decides whether they are retried.
+		var retry []int
+		var retryErr error
+		for j, i := range missing {
+			switch err := fetchErrs[j]; {
+			case err == nil:
+				receipts[i] = fetched[j]
+			case retryable(err):
+				retry = append(retry, i)
+				if retryErr == nil {
+					retryErr = err
+				}
+			default:
+				errs[i] = err
+			}
+		}
+		missing = retry
+		return retryErr
+	})
+	for _, i := range missing {
+		errs[i] = err
+	}
+	if f.Indexer != nil {
+		f.fromIndexer(ctx, rows, requested, receipts, errs)
+	}
+
+	f.store(rows, requested, receipts, errs)
+	return receipts, errs
+}
+
+// fromIndexer fetches from the Indexer the receipts of the requested rows that
+// Source lacks, keeping the error of Source for those the Indexer lacks too.
+func (f *Fetcher) fromIndexer(ctx context.Context, rows []input.Row, requested []int, receipts []*types.Receipt, errs []error) {
+	for _, i := range requested {
+		if !unindexed(errs[i]) {
+			continue
+		}
+		receipt, err := f.Indexer.Receipt(ctx, rows[i].Hash)
+		if err != nil {
+			logger(f.Logger).Debug("indexer has no receipt", "tx", rows[i].Hash, "err", err)
+			continue
+		}
+		receipts[i], errs[i] = receipt, nil
+		f.IndexerResolved.Add(1)
+		logger(f.Logger).Debug("receipt from indexer", "tx", rows[i].Hash)
+	}
+}
+
+// unindexed reports whether err is how a node answers for a transaction it no
+// longer or not yet indexes: as if it did not exist, or saying so.
+func unindexed(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ethereum.NotFound) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "indexing is in progress") || strings.Contains(msg, "pruned")
+}
+
+// blockHeaders sets the time of rows that have none to the timestamp of their
+// receipt's block, and with BaseFees the base fee of every row. Headers are
+// fetched in one batch and remembered for later rows of the same block.
+func (f *Fetcher) blockHeaders(ctx context.Context, rows []input.Row, receipts []*types.Receipt, errs []error) error {
+	var numbers []uint64
+	for i := range rows {
+		if errs[i] == nil && f.needsHeader(rows[i], receipts[i]) {
+			number := receipts[i].BlockNumber.Uint64()
+			if _, ok := f.headers.Load(number); !ok && !slices.Contains(numbers, number) {
+				numbers = append(numbers, number)
+			}
+		}
+	}
+	if len(numbers) > 0 {
+		var headers []Header
+		err := f.Retry.do(ctx, func() error {
+			var err error
+			headers, err = f.Source.BlockHeaders(ctx, numbers)
+			if err == nil && len(headers) != len(numbers) {
+				err = fmt.Errorf("got %d block headers for %d blocks", len(headers), len(numbers))
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		for i, number := range numbers {
+			f.headers.Store(number, headers[i])
+		}
+	}
+	for i := range rows {
+		if errs[i] == nil && f.needsHeader(rows[i], receipts[i]) {
+			v, _ := f.headers.Load(receipts[i].BlockNumber.Uint64())
+			h := v.(Header)
+			if rows[i].Time.IsZero() {
+				rows[i].Time = h.Time
+			}
+			if f.BaseFees {
+				rows[i].BaseFee = h.BaseFee
+			}
+			if missingBlobPrice(receipts[i]) && h.ExcessBlobGas != nil {
+				f.derivedBlobPrice.Do(func() {
+					logger(f.Logger).Warn("receipt has no blob gas price; deriving it from the excess blob gas of its block", "tx", rows[i].Hash)
+				})
+				// A copy, so that the cached receipt stays as fetched.
+				r := *receipts[i]
+				r.BlobGasPrice = BlobBaseFee(*h.ExcessBlobGas, h.Time)
+				receipts[i] = &r
+			}
+		}
+	}
+	return nil
+}
+
+// blockReceipts fetches the receipts of whole blocks that contain at least
+// blockReceiptsMin of the missing rows, fills them into receipts and returns
+// the rows that are still missing. It falls back to per-transaction requests
+// for good once the RPC turns out not to support eth_getBlockReceipts.
+func (f *Fetcher) blockReceipts(ctx context.Context, rows []input.Row, missing []int, receipts []*types.Receipt) []int {
+	if f.BlockReceiptsMin <= 0 || f.noBlockReceipts.Load() {
+		return missing
+	}
+	byBlock := make(map[uint64][]int)
+	var blocks []uint64
+	for _, i := range missing {
+		if block := rows[i].Block; block != 0 {
+			if byBlock[block] == nil {
+				blocks = append(blocks, block)
+			}
+			byBlock[block] = append(byBlock[block], i)
+		}
+	}
+
+	found := make(map[int]bool)
+	for _, block := range blocks {
+		if len(byBlock[block]) < f.BlockReceiptsMin {
+			continue
+		}
+		var blockReceipts []*types.Receipt
+		err := f.Retry.do(ctx, func() error {
+			var err error
+			blockReceipts, err = f.Source.BlockReceipts(ctx, block)
+			return err
+		})
+		if methodUnsupported(err) {
+			if f.noBlockReceipts.CompareAndSwap(false, true) {
+				logger(f.Logger).Info("eth_getBlockReceipts is not supported, fetching receipts per transaction")
+			}
+			break
+		}
+		if err != nil {
+			logger(f.Logger).Warn("block receipts failed, fetching receipts per transaction", "block", block, "err", err)
+			continue
+		}
+		byHash := make(map[common.Hash]*types.Receipt, len(blockReceipts))
+		for _, receipt := range blockReceipts {
+			byHash[receipt.TxHash] = receipt
+		}
+		for _, i := range byBlock[block] {
+			if receipt := byHash[rows[i].Hash]; receipt != nil {
+				receipts[i] = receipt
+				found[i] = true
+			}
+		}
+	}
+	return slices.DeleteFunc(missing, func(i int) bool { return found[i] })
+}
+
+// store adds the receipts fetched for the requested rows to the cache.
+func (f *Fetcher) store(rows []input.Row, requested []int, receipts []*types.Receipt, errs []error) {
+	if f.Cache == nil {
+		return
+	}
+	for _, i := range requested {
+		if errs[i] == nil {
+			if err := f.Cache.put(rows[i].Hash, receipts[i]); err != nil {
+				logger(f.Logger).Warn("cache write failed", "tx", rows[i].Hash, "err", err)
+			}
+		}
+	}
+}
+
+// local resolves the receipt of row without fetching it, returning nil when
+// it has to come from the RPC.
+func (f *Fetcher) local(ctx context.Context, row input.Row) (*types.Receipt, error) {
+	if f.TrustCSV {
+		if receipt := row.CSVReceipt; receipt != nil {
+			f.CSVResolved.Add(1)
+			if f.SampleStride > 0 && row.Index%f.SampleStride == 0 && row.Index/f.SampleStride < f.SampleLimit {
+				if err := f.verify(ctx, row, receipt); err != nil {
+					return nil, err
+				}
+			}
+			return receipt, nil
+		}
+	}
+	if f.Cache != nil {
+		receipt, err := f.Cache.get(row.Hash)
+		if err != nil {
+			logger(f.Logger).Warn("cache read failed", "tx", row.Hash, "err", err)
+		}
+		cacheLookups.Add(ctx, 1, metric.WithAttributes(attribute.Bool("hit", receipt != nil)))
+		return receipt, nil
+	}
+	return nil, nil
+}
+
+// fetchReceipt fetches the receipt of row from Source, retrying transient
+// failures.
+func (f *Fetcher) fetchReceipt(ctx context.Context, row input.Row) (*types.Receipt, error) {
+	var receipt *types.Receipt
+	err := f.Retry.do(ctx, func() error {
+		receipts, errs := f.Source.TransactionReceipts(ctx, []common.Hash{row.Hash})
+		receipt = receipts[0]
+		return errs[0]
+	})
+	return receipt, err
+}
+
+// verify compares a CSV-built receipt with the one served by Source.
+func (f *Fetcher) verify(ctx context.Context, row input.Row, receipt *types.Receipt) error {
+	rpcReceipt, err := f.fetchReceipt(ctx, row)
+	if err != nil {
+		return err
+	}
+	f.Verified.Add(1)
+	if !sameGasData(receipt, rpcReceipt) {
+		f.Mismatched.Add(1)
+		logger(f.Logger).Warn("trust-csv: CSV values differ from receipt", "tx", row.Hash,
+			"csvGasUsed", receipt.GasUsed, "gasUsed", rpcReceipt.GasUsed,
+			"csvGasPrice", receipt.EffectiveGasPrice, "gasPrice", rpcReceipt.EffectiveGasPrice)
+	}
+	return nil
+}
+
+// sameGasData reports whether two receipts agree on every field used for cost
+// calculation.
+func sameGasData(a, b *types.Receipt) bool {
+	if a.GasUsed != b.GasUsed || a.EffectiveGasPrice.Cmp(b.EffectiveGasPrice) != 0 {
+		return false
+	}
+	if a.Type == types.BlobTxType || b.Type == types.BlobTxType {
+		return a.Type == b.Type && a.BlobGasUsed == b.BlobGasUsed &&
+			a.BlobGasPrice != nil && b.BlobGasPrice != nil && a.BlobGasPrice.Cmp(b.BlobGasPrice) == 0
+	}
+	return true
+}
+
+// Result is the outcome of fetching the receipt of one row.
+type Result struct {
+	pos     int
+	last    bool // of the rows of a dispatched batch
+	Row     input.Row
+	Receipt *types.Receipt
+	Err     error
+}
+
+// All resolves the receipts of rows in batches of batchSize with the given
+// number of workers and passes them to handle in input order, so that
+// aggregation is deterministic regardless of which request finishes first.
+func All(
+	ctx context.Context,
+	rows []input.Row,
+	concurrency int,
+	batchSize int,
+	fetch func(context.Context, []input.Row) ([]*types.Receipt, []error),
+	handle func(input.Row, *types.Receipt, error),
+) {
+	ch := make(chan input.Row)
+	go func() {
+		defer close(ch)
+		for _, row := range rows {
+			ch <- row
+		}
+	}()
+	Stream(ctx, ch, concurrency, batchSize, fetch, handle)
+}
+
+// Stream is All for the rows received from a channel until it is closed, so
+// that they are fetched as they are read rather than held in memory. At most
+// twice as many batches as workers are dispatched ahead of the first one not
+// yet handled, which bounds the results held back for input order when a
+// batch is slow. Rows received once ctx is done are passed to handle as
+// cancelled.
+func Stream(
+	ctx context.Context,
+	rows <-chan input.Row,
+	concurrency int,
+	batchSize int,
+	fetch func(context.Context, []input.Row) ([]*types.Receipt, []error),
+	handle func(input.Row, *types.Receipt, error),
+) {
+	type job struct {
+		pos  int
+		rows []input.Row
+	}
+	workers := max(concurrency, 1)
+	jobs := make(chan job)
+	results := make(chan Result)
+	// A slot of window is taken by every dispatched batch until its last row
+	// is handled.
+	window := make(chan struct{}, 2*workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				receipts, errs := fetch(ctx, j.rows)
+				for i, row := range j.rows {
+					results <- Result{pos: j.pos + i, last: i == len(j.rows)-1, Row: row, Receipt: receipts[i], Err: errs[i]}
+				}
+			}
+		}()
+	}
+	go func() {
+		size := max(batchSize, 1)
+		var (
+			batch     []input.Row
+			pos       int
+			cancelled bool
+		)
+		enqueue := func() {
+			j := job{pos: pos - len(batch), rows: batch}
+			batch = nil
+			select {
+			case window <- struct{}{}:
+				select {
+				case jobs <- j:
+					return
+				case <-ctx.Done():
+					<-window
+				}
+			case <-ctx.Done():
+			}
+			// Rows that were not queued are reported as cancelled.
+			cancelled = true
+			for i, row := range j.rows {
+				results <- Result{pos: j.pos + i, Row: row, Err: ctx.Err()}
+			}
+		}
+		for row := range rows {
+			if cancelled {
+				results <- Result{pos: pos, Row: row, Err: ctx.Err()}
+				pos++
+				continue
+			}
+			batch = append(batch, row)
+			pos++
+			if len(batch) == size {
+				enqueue()
+			}
+		}
+		if len(batch) > 0 {
+			enqueue()
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	pending := make(map[int]Result)
+	next := 0
+	for res := range results {
+		pending[res.pos] = res
+		for {
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			handle(r.Row, r.Receipt, r.Err)
+			if r.last {
+				<-window
+			}
+			next++
+		}
+	}
+}
+
+// logger returns l, or the default logger when l is nil.
+func logger(l *slog.Logger) *slog.Logger {
+	if l == nil {
+		return slog.Default()
+	}
+	return l
+}
@@ -0,0 +1,167 @@
+package fetch_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/fetch"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/fetch/fetchtest"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/input"
+)
+
+func hash(b byte) common.Hash { return common.BytesToHash([]byte{b}) }
+
+func receipt(b byte, block uint64, index uint) *types.Receipt {
+	return &types.Receipt{
+		Type:              types.DynamicFeeTxType,
+		Status:            types.ReceiptStatusSuccessful,
+		TxHash:            hash(b),
+		BlockNumber:       new(big.Int).SetUint64(block),
+		TransactionIndex:  index,
+		GasUsed:           21_000,
+		EffectiveGasPrice: big.NewInt(1_000_000_000),
+	}
+}
+
+// TestReceiptsFromFixture resolves rows against a fixture: two sharing a block
+// through its block receipts, one per transaction, one the source does not
+// know and one whose requests keep failing.
+func TestReceiptsFromFixture(t *testing.T) {
+	block100 := time.Date(2024, 7, 3, 10, 0, 0, 0, time.UTC)
+	block101 := block100.Add(12 * time.Second)
+	given := time.Date(2024, 7, 3, 9, 59, 0, 0, time.UTC)
+	unavailable := errors.New("503 Service Unavailable")
+
+	source := fetchtest.NewFixture()
+	source.Add(receipt(0x01, 100, 0), block100)
+	source.Add(receipt(0x02, 100, 1), block100)
+	source.Add(receipt(0x03, 101, 0), block101)
+	source.Add(receipt(0x05, 101, 1), block101)
+	source.Errors[hash(0x05)] = unavailable
+
+	f := &fetch.Fetcher{
+		Source:           source,
+		Retry:            fetch.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		BlockReceiptsMin: 2,
+	}
+	rows := []input.Row{
+		{Hash: hash(0x01), Block: 100},
+		{Hash: hash(0x02), Block: 100, Time: given},
+		{Hash: hash(0x03)},
+		{Hash: hash(0x04)},
+		{Hash: hash(0x05)},
+	}
+	receipts, errs := f.Receipts(context.Background(), rows)
+
+	want := []struct {
+		time time.Time
+		err  error
+	}{
+		{time: block100},
+		{time: given},
+		{time: block101},
+		{err: ethereum.NotFound},
+		{err: unavailable},
+	}
+	for i, w := range want {
+		row := rows[i]
+		if w.err != nil {
+			if !errors.Is(errs[i], w.err) || receipts[i] != nil {
+				t.Errorf("row %d: got receipt %v, error %v; want error %v", i, receipts[i], errs[i], w.err)
+			}
+			continue
+		}
+		if errs[i] != nil {
+			t.Errorf("row %d: %v", i, errs[i])
+			continue
+		}
+		if receipts[i].TxHash != row.Hash {
+			t.Errorf("row %d: got the receipt of %s", i, receipts[i].TxHash)
+		}
+		if !row.Time.Equal(w.time) {
+			t.Errorf("row %d: time %v, want %v", i, row.Time, w.time)
+		}
+	}
+}
+
+// shortHeaders is a source that answers one block header short.
+type shortHeaders struct{ *fetchtest.Fixture }
+
+func (s shortHeaders) BlockHeaders(ctx context.Context, numbers []uint64) ([]fetch.Header, error) {
+	headers, err := s.Fixture.BlockHeaders(ctx, numbers)
+	return headers[:len(headers)-1], err
+}
+
+// TestShortBlockHeaders checks that the rows whose block header is missing
+// from a short answer fail rather than the fetcher panicking.
+func TestShortBlockHeaders(t *testing.T) {
+	source := fetchtest.NewFixture()
+	source.Add(receipt(0x01, 100, 0), time.Unix(1720000000, 0))
+	source.Add(receipt(0x02, 101, 0), time.Unix(1720000012, 0))
+	f := &fetch.Fetcher{Source: shortHeaders{source}, Retry: fetch.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}}
+	rows := []input.Row{{Hash: hash(0x01)}, {Hash: hash(0x02)}}
+	_, errs := f.Receipts(context.Background(), rows)
+	for i, err := range errs {
+		if err == nil {
+			t.Errorf("row %d: no error", i)
+		}
+	}
+}
+
+// TestStreamWindow checks that a slow first batch holds back the dispatch of
+// more than twice as many batches as workers, and that the rows are handled
+// in input order once it completes.
+func TestStreamWindow(t *testing.T) {
+	const concurrency, n = 2, 100
+	release := make(chan struct{})
+	var calls atomic.Int32
+	fetchRows := func(ctx context.Context, rows []input.Row) ([]*types.Receipt, []error) {
+		calls.Add(1)
+		if rows[0].Index == 0 {
+			<-release
+		}
+		receipts := make([]*types.Receipt, len(rows))
+		for i, row := range rows {
+			receipts[i] = receipt(byte(row.Index), uint64(row.Index), 0)
+		}
+		return receipts, make([]error, len(rows))
+	}
+	rows := make([]input.Row, n)
+	for i := range rows {
+		rows[i] = input.Row{Index: i, Hash: hash(byte(i))}
+	}
+
+	done := make(chan struct{})
+	var handled []int
+	go func() {
+		defer close(done)
+		fetch.All(context.Background(), rows, concurrency, 1, fetchRows, func(row input.Row, _ *types.Receipt, err error) {
+			if err != nil {
+				t.Errorf("row %d: %v", row.Index, err)
+			}
+			handled = append(handled, row.Index)
+		})
+	}()
+	time.Sleep(50 * time.Millisecond)
+	if got := calls.Load(); got > 2*concurrency {
+		t.Errorf("%d batches dispatched behind a slow one, want at most %d", got, 2*concurrency)
+	}
+	close(release)
+	<-done
+	if len(handled) != n {
+		t.Fatalf("handled %d rows, want %d", len(handled), n)
+	}
+	for i, index := range handled {
+		if index != i {
+			t.Fatalf("row %d handled at position %d", index, i)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Package fetchsim provides a receipt source backed by go-ethereum's
+// simulated chain. It is apart from fetchtest because binaries and tests
+// linking it with Go 1.23 or later need -ldflags=-checklinkname=0, for a
+// go-ethereum dependency.
+package fetchsim
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/fetch"
+)
+
+var _ fetch.ReceiptSource = (*Source)(nil)
+
+// Source serves receipts from a go-ethereum simulated backend, so that
+// transactions sent to it, including blob transactions, are priced by a real
+// EVM.
+type Source struct {
+	Client simulated.Client
+}
+
+// New returns a source reading from backend.
+func New(backend *simulated.Backend) *Source {
+	return &Source{Client: backend.Client()}
+}
+
+func (s *Source) TransactionReceipts(ctx context.Context, hashes []common.Hash) ([]*types.Receipt, []error) {
+	receipts := make([]*types.Receipt, len(hashes))
+	errs := make([]error, len(hashes))
+	for i, hash := range hashes {
+		receipts[i], errs[i] = s.Client.TransactionReceipt(ctx, hash)
+	}
+	return receipts, errs
+}
+
+func (s *Source) BlockReceipts(ctx context.Context, number uint64) ([]*types.Receipt, error) {
+	block, err := s.Client.BlockByNumber(ctx, new(big.Int).SetUint64(number))
+	if err != nil {
+		return nil, err
+	}
+	receipts := make([]*types.Receipt, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		if receipts[i], err = s.Client.TransactionReceipt(ctx, tx.Hash()); err != nil {
+			return nil, err
+		}
+	}
+	return receipts, nil
+}
+
+func (s *Source) BlockHeaders(ctx context.Context, numbers []uint64) ([]fetch.Header, error) {
+	headers := make([]fetch.Header, len(numbers))
+	for i, number := range numbers {
+		header, err := s.Client.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+		if err != nil {
+			return nil, err
+		}
+		headers[i] = fetch.Header{Time: time.Unix(int64(header.Time), 0).UTC(), BaseFee: header.BaseFee, ExcessBlobGas: header.ExcessBlobGas, BlobGasUsed: header.BlobGasUsed}
+	}
+	return headers, nil
+}
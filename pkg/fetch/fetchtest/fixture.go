@@ -0,0 +1,135 @@
+// Package fetchtest provides a receipt source that works without a live L1
+// node, for tests and offline runs of the fetch and aggregate code.
+package fetchtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/fetch"
+)
+
+var _ fetch.ReceiptSource = (*Fixture)(nil)
+
+// Fixture serves receipts and block headers held in memory, typically recorded
+// from a real node and loaded with LoadFixture. Errors makes the receipts of
+// chosen transactions fail, e.g. to exercise retries.
+type Fixture struct {
+	mu       sync.Mutex
+	receipts map[common.Hash]*types.Receipt
+	headers  map[uint64]fetch.Header
+	Errors   map[common.Hash]error
+}
+
+// fixtureFile is the JSON layout read by LoadFixture: receipts as returned by
+// eth_getTransactionReceipt, the unix timestamps of their blocks and,
+// optionally, the base fees of the blocks.
+type fixtureFile struct {
+	Blocks   map[string]int64        `json:"blocks"`
+	BaseFees map[string]*hexutil.Big `json:"baseFees"`
+	Receipts []*types.Receipt        `json:"receipts"`
+}
+
+// NewFixture returns an empty fixture.
+func NewFixture() *Fixture {
+	return &Fixture{
+		receipts: make(map[common.Hash]*types.Receipt),
+		headers:  make(map[uint64]fetch.Header),
+		Errors:   make(map[common.Hash]error),
+	}
+}
+
+// LoadFixture reads a fixture from a JSON file such as
+//
+//	{"blocks": {"20000000": 1717372800}, "baseFees": {"20000000": "0x12a05f200"},
+//	 "receipts": [{"transactionHash": ...}]}
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file fixtureFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	f := NewFixture()
+	for number, unix := range file.Blocks {
+		n, err := strconv.ParseUint(number, 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: block %q: %w", path, number, err)
+		}
+		f.headers[n] = fetch.Header{Time: time.Unix(unix, 0).UTC(), BaseFee: (*big.Int)(file.BaseFees[number])}
+	}
+	for _, receipt := range file.Receipts {
+		f.receipts[receipt.TxHash] = receipt
+	}
+	return f, nil
+}
+
+// Add adds a receipt, mined in a block with the given time.
+func (f *Fixture) Add(receipt *types.Receipt, blockTime time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.receipts[receipt.TxHash] = receipt
+	if receipt.BlockNumber != nil {
+		number := receipt.BlockNumber.Uint64()
+		f.headers[number] = fetch.Header{Time: blockTime.UTC(), BaseFee: f.headers[number].BaseFee}
+	}
+}
+
+func (f *Fixture) TransactionReceipts(ctx context.Context, hashes []common.Hash) ([]*types.Receipt, []error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	receipts := make([]*types.Receipt, len(hashes))
+	errs := make([]error, len(hashes))
+	for i, hash := range hashes {
+		switch {
+		case f.Errors[hash] != nil:
+			errs[i] = f.Errors[hash]
+		case f.receipts[hash] != nil:
+			receipts[i] = f.receipts[hash]
+		default:
+			errs[i] = ethereum.NotFound
+		}
+	}
+	return receipts, errs
+}
+
+func (f *Fixture) BlockReceipts(ctx context.Context, number uint64) ([]*types.Receipt, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var receipts []*types.Receipt
+	for _, receipt := range f.receipts {
+		if receipt.BlockNumber != nil && receipt.BlockNumber.Uint64() == number {
+			receipts = append(receipts, receipt)
+		}
+	}
+	slices.SortFunc(receipts, func(a, b *types.Receipt) int { return int(a.TransactionIndex) - int(b.TransactionIndex) })
+	return receipts, nil
+}
+
+func (f *Fixture) BlockHeaders(ctx context.Context, numbers []uint64) ([]fetch.Header, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	headers := make([]fetch.Header, len(numbers))
+	for i, number := range numbers {
+		h, ok := f.headers[number]
+		if !ok {
+			return nil, fmt.Errorf("block %d not found", number)
+		}
+		headers[i] = h
+	}
+	return headers, nil
+}
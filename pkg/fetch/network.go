@@ -0,0 +1,70 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// networks names the L1 chains that OP Stack chains settle on.
+var networks = map[uint64]string{
+	1:        "mainnet",
+	11155111: "sepolia",
+	17000:    "holesky",
+}
+
+// cancunTimes are the activation times of Cancun, which brought blob
+// transactions, on the networks.
+var cancunTimes = map[uint64]time.Time{
+	1:        time.Unix(1710338135, 0),
+	11155111: time.Unix(1706655072, 0),
+	17000:    time.Unix(1707305664, 0),
+}
+
+// CancunTime returns when Cancun activated on the chain with ID id, and false
+// for chains without a known activation.
+func CancunTime(id uint64) (time.Time, bool) {
+	t, ok := cancunTimes[id]
+	return t, ok
+}
+
+// NetworkName returns the name of the chain with ID id, e.g. "sepolia", or
+// "chain-<id>" for chains without a known name.
+func NetworkName(id uint64) string {
+	if name, ok := networks[id]; ok {
+		return name
+	}
+	return fmt.Sprintf("chain-%d", id)
+}
+
+// ChainID returns the chain ID of the current endpoint.
+func (p *Pool) ChainID(ctx context.Context) (uint64, error) {
+	var id *big.Int
+	err := p.call(ctx, "eth_chainId", func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		id, err = client.ChainID(ctx)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("chain id: %w", err)
+	}
+	return id.Uint64(), nil
+}
+
+// Balance returns the balance in wei of address at the latest block.
+func (p *Pool) Balance(ctx context.Context, address common.Address) (*big.Int, error) {
+	var balance *big.Int
+	err := p.call(ctx, "eth_getBalance", func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		balance, err = client.BalanceAt(ctx, address, nil)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("balance of %s: %w", address, err)
+	}
+	return balance, nil
+}
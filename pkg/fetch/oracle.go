@@ -0,0 +1,134 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"slices"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/input"
+)
+
+// maxFeeHistoryBlocks is the number of blocks requested per eth_feeHistory
+// call, the most that geth serves.
+const maxFeeHistoryBlocks = 1024
+
+// oracleTips sets the OracleTip of the rows: the median of the tips of the
+// OracleBlocks blocks before theirs, as a fee oracle sampling the recent
+// blocks would have suggested when the transaction was sent. The tips of the
+// blocks are fetched once and remembered for later rows. Rows whose blocks'
+// tips cannot be fetched are left without an oracle tip rather than failed.
+func (f *Fetcher) oracleTips(ctx context.Context, rows []input.Row, receipts []*types.Receipt, errs []error) {
+	window := uint64(f.OracleBlocks)
+	var needed []uint64
+	for i := range rows {
+		if errs[i] != nil || receipts[i].BlockNumber == nil {
+			continue
+		}
+		block := receipts[i].BlockNumber.Uint64()
+		for b := block - min(window, block); b < block; b++ {
+			needed = append(needed, b)
+		}
+	}
+	if err := f.loadBlockTips(ctx, &f.blockTips, needed, f.OraclePercentile); err != nil {
+		f.oracleFailed.Do(func() {
+			logger(f.Logger).Warn("fee history unavailable; the overpayment covers the transactions with an oracle tip only", "err", err)
+		})
+	}
+
+	for i := range rows {
+		if errs[i] != nil || receipts[i].BlockNumber == nil {
+			continue
+		}
+		block := receipts[i].BlockNumber.Uint64()
+		var sample []*big.Int
+		complete := true
+		for b := block - min(window, block); b < block; b++ {
+			v, ok := f.blockTips.Load(b)
+			if !ok {
+				complete = false
+				break
+			}
+			// Empty blocks say nothing about the price of inclusion.
+			if tip := v.(*big.Int); tip != nil {
+				sample = append(sample, tip)
+			}
+		}
+		if !complete || len(sample) == 0 {
+			continue
+		}
+		slices.SortFunc(sample, func(a, b *big.Int) int { return a.Cmp(b) })
+		rows[i].OracleTip = sample[len(sample)/2]
+	}
+}
+
+// marketTips sets the MarketTip of the rows to the median tip of their block.
+// Rows whose block's tips cannot be fetched are left without one.
+func (f *Fetcher) marketTips(ctx context.Context, rows []input.Row, receipts []*types.Receipt, errs []error) {
+	var needed []uint64
+	for i := range rows {
+		if errs[i] == nil && receipts[i].BlockNumber != nil {
+			needed = append(needed, receipts[i].BlockNumber.Uint64())
+		}
+	}
+	if err := f.loadBlockTips(ctx, &f.medianTips, needed, 50); err != nil {
+		f.marketFailed.Do(func() {
+			logger(f.Logger).Warn("fee history unavailable; the tips against the market cover the transactions with a known block median tip only", "err", err)
+		})
+	}
+	for i := range rows {
+		if errs[i] != nil || receipts[i].BlockNumber == nil {
+			continue
+		}
+		if v, ok := f.medianTips.Load(receipts[i].BlockNumber.Uint64()); ok {
+			rows[i].MarketTip = v.(*big.Int)
+		}
+	}
+}
+
+// loadBlockTips stores the percentile-th percentile tip of the blocks that
+// cache lacks into it, fetching runs of consecutive blocks together. It
+// returns the last error, after trying every run.
+func (f *Fetcher) loadBlockTips(ctx context.Context, cache *sync.Map, blocks []uint64, percentile float64) error {
+	var needed []uint64
+	for _, b := range blocks {
+		if _, ok := cache.Load(b); !ok {
+			needed = append(needed, b)
+		}
+	}
+	if len(needed) == 0 {
+		return nil
+	}
+	source, ok := f.Source.(FeeHistorySource)
+	if !ok {
+		return errors.New("the receipt source serves no fee history")
+	}
+	slices.Sort(needed)
+	needed = slices.Compact(needed)
+	var lastErr error
+	for len(needed) > 0 {
+		n := 1
+		for n < len(needed) && n < maxFeeHistoryBlocks && needed[n] == needed[n-1]+1 {
+			n++
+		}
+		from, to := needed[0], needed[n-1]
+		needed = needed[n:]
+		var tips []*big.Int
+		err := f.Retry.do(ctx, func() error {
+			var err error
+			tips, err = source.BlockTips(ctx, from, to, percentile)
+			return err
+		})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		for j, tip := range tips {
+			cache.Store(from+uint64(j), tip)
+		}
+	}
+	return lastErr
+}
@@ -0,0 +1,72 @@
+package fetch
+
+import (
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/input"
+)
+
+// Plan estimates the requests a Fetcher makes to resolve rows, for a dry
+// run. The receipts are counted exactly; the other requests depend on the
+// receipts, so that their numbers are upper bounds.
+type Plan struct {
+	Rows   int // rows to resolve
+	CSV    int // resolved from the CSV with TrustCSV
+	Cached int // resolved from the receipt cache
+	// Fetched are the receipts requested from Source, in ReceiptBatches
+	// batches.
+	Fetched        int
+	ReceiptBatches int
+	// TransactionBatches, HeaderBatches and FeeHistoryCalls are the most
+	// batches of transactions and block headers, and eth_feeHistory calls,
+	// that the options of the Fetcher need.
+	TransactionBatches int
+	HeaderBatches      int
+	FeeHistoryCalls    int
+}
+
+// Calls returns the estimated number of RPC requests of p, a batch counting
+// as one.
+func (p Plan) Calls() int {
+	return p.ReceiptBatches + p.TransactionBatches + p.HeaderBatches + p.FeeHistoryCalls
+}
+
+// Plan estimates the requests that resolving rows in batches of batchSize
+// makes, looking the receipts up in the cache without fetching anything.
+func (f *Fetcher) Plan(rows []input.Row, batchSize int) (Plan, error) {
+	batchSize = max(batchSize, 1)
+	batches := func(n int) int { return (n + batchSize - 1) / batchSize }
+	p := Plan{Rows: len(rows)}
+	var transactions int
+	for _, row := range rows {
+		switch {
+		case f.TrustCSV && row.CSVReceipt != nil:
+			p.CSV++
+		case f.Cache != nil:
+			receipt, err := f.Cache.get(row.Hash)
+			if err != nil {
+				return p, err
+			}
+			if receipt != nil {
+				p.Cached++
+			} else {
+				p.Fetched++
+			}
+		default:
+			p.Fetched++
+		}
+		if f.needsFields(row) || f.Frames || f.DataSizes || f.CalldataFloor || f.Beacon != nil {
+			transactions++
+		}
+	}
+	p.ReceiptBatches = batches(p.Fetched)
+	p.TransactionBatches = batches(transactions)
+	if f.BaseFees || f.WhatIf || f.CalldataFloor || f.Beacon != nil {
+		p.HeaderBatches = batches(len(rows))
+	}
+	if f.OracleBlocks > 0 {
+		p.FeeHistoryCalls += len(rows)
+	}
+	if f.MarketTips {
+		p.FeeHistoryCalls += len(rows)
+	}
+	return p, nil
+}
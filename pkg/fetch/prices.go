@@ -0,0 +1,175 @@
+package fetch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// PriceClient reads daily closing prices from a CoinGecko-compatible API,
+// keeping those of past days in a local cache so that re-runs do not request
+// them again.
+type PriceClient struct {
+	// BaseURL is the root of the API, e.g. https://api.coingecko.com/api/v3.
+	BaseURL string
+	// APIKey, if set, is sent as a CoinGecko demo key, or as a pro key to
+	// the pro API.
+	APIKey string
+	// CachePath is the JSON file of the cached prices; empty disables the
+	// cache.
+	CachePath string
+	Retry     RetryPolicy
+	HTTP      *http.Client
+
+	cache map[string]map[string]float64 // coin/currency -> day -> price
+}
+
+// DailyCloses returns the closing price of coin in currency, CoinGecko ids
+// such as "ethereum" and "usd", of every UTC day from from to to inclusive,
+// keyed by YYYY-MM-DD. The close of a day is the first price quoted from its
+// end on; the current day has the latest price instead.
+func (c *PriceClient) DailyCloses(ctx context.Context, coin, currency string, from, to time.Time) (map[string]float64, error) {
+	if err := c.loadCache(); err != nil {
+		return nil, err
+	}
+	key := coin + "/" + currency
+	cached := c.cache[key]
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	first, last := from.UTC().Truncate(24*time.Hour), to.UTC().Truncate(24*time.Hour)
+	if last.After(today) {
+		last = today
+	}
+
+	closes := make(map[string]float64)
+	var missing []time.Time
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		if p, ok := cached[day.Format(time.DateOnly)]; ok {
+			closes[day.Format(time.DateOnly)] = p
+		} else {
+			missing = append(missing, day)
+		}
+	}
+	if len(missing) == 0 {
+		return closes, nil
+	}
+
+	quotes, err := c.marketChart(ctx, coin, currency, missing[0], missing[len(missing)-1].AddDate(0, 0, 2))
+	if err != nil {
+		return nil, err
+	}
+	if cached == nil {
+		cached = make(map[string]float64)
+		c.cache[key] = cached
+	}
+	for _, day := range missing {
+		p, ok := closeOf(quotes, day)
+		if !ok {
+			continue
+		}
+		closes[day.Format(time.DateOnly)] = p
+		// The current day has no close yet.
+		if day.Before(today) {
+			cached[day.Format(time.DateOnly)] = p
+		}
+	}
+	return closes, c.saveCache()
+}
+
+// quote is a price at a time, in milliseconds.
+type quote [2]float64
+
+// closeOf returns the close of day from quotes sorted by time: the first
+// quote from the end of the day on, or failing that the last one of the day.
+func closeOf(quotes []quote, day time.Time) (float64, bool) {
+	start, end := float64(day.UnixMilli()), float64(day.AddDate(0, 0, 1).UnixMilli())
+	var p float64
+	found := false
+	for _, q := range quotes {
+		if q[0] >= end {
+			return q[1], true
+		}
+		if q[0] >= start {
+			p, found = q[1], true
+		}
+	}
+	return p, found
+}
+
+// marketChart requests the prices of coin in currency from from until to.
+func (c *PriceClient) marketChart(ctx context.Context, coin, currency string, from, to time.Time) ([]quote, error) {
+	q := url.Values{}
+	q.Set("vs_currency", currency)
+	q.Set("from", fmt.Sprint(from.Unix()))
+	q.Set("to", fmt.Sprint(to.Unix()))
+	endpoint := fmt.Sprintf("%s/coins/%s/market_chart/range?%s", strings.TrimSuffix(c.BaseURL, "/"), url.PathEscape(coin), q.Encode())
+	var body struct {
+		Prices []quote `json:"prices"`
+	}
+	err := c.Retry.do(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Accept", "application/json")
+		if c.APIKey != "" {
+			header := "x-cg-demo-api-key"
+			if strings.Contains(c.BaseURL, "pro-api") {
+				header = "x-cg-pro-api-key"
+			}
+			req.Header.Set(header, c.APIKey)
+		}
+		resp, err := c.HTTP.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("prices of %s in %s: %s", coin, currency, resp.Status)
+		}
+		return json.NewDecoder(resp.Body).Decode(&body)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return body.Prices, nil
+}
+
+// loadCache reads the cache file once, if any.
+func (c *PriceClient) loadCache() error {
+	if c.cache != nil {
+		return nil
+	}
+	c.cache = make(map[string]map[string]float64)
+	if c.CachePath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(c.CachePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &c.cache); err != nil {
+		return fmt.Errorf("price cache %s: %w", c.CachePath, err)
+	}
+	return nil
+}
+
+// saveCache writes the cache file, if any.
+func (c *PriceClient) saveCache() error {
+	if c.CachePath == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(c.cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.CachePath, data, 0o644)
+}
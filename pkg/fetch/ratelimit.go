@@ -0,0 +1,79 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/time/rate"
+)
+
+// rateLimiter is a token bucket spacing the calls of a Pool to at most max
+// per second, in bursts of up to a second of calls. A rate-limited response
+// halves the rate, down to a sixteenth of max, and every successful call then
+// wins back a hundredth of max, so that the rate settles just under what the
+// provider tolerates. The responses within a second of lowering the rate, to
+// calls already in flight, do not lower it again.
+type rateLimiter struct {
+	mu      sync.Mutex
+	limiter *rate.Limiter
+	max     rate.Limit
+	lowered time.Time
+}
+
+func newRateLimiter(rps float64) *rateLimiter {
+	return &rateLimiter{
+		limiter: rate.NewLimiter(rate.Limit(rps), max(int(rps), 1)),
+		max:     rate.Limit(rps),
+	}
+}
+
+// wait blocks until a call may be made or ctx is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	return l.limiter.Wait(ctx)
+}
+
+// observe adapts the rate to the outcome err of a call, telling log when it
+// slows down.
+func (l *rateLimiter) observe(err error, log *slog.Logger) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	current := l.limiter.Limit()
+	switch {
+	case rateLimited(err):
+		if time.Since(l.lowered) < time.Second {
+			return
+		}
+		if lowered := max(current/2, l.max/16); lowered < current {
+			l.limiter.SetLimit(lowered)
+			l.lowered = time.Now()
+			log.Warn("rpc rate limited, slowing down", "rps", float64(lowered), "err", err)
+		}
+	case err == nil && current < l.max:
+		l.limiter.SetLimit(min(current+l.max/100, l.max))
+	}
+}
+
+// rateLimited reports whether err is the provider refusing a call for
+// exceeding its rate limit: an HTTP 429, or the JSON-RPC errors that some
+// providers return instead.
+func rateLimited(err error) bool {
+	if err == nil {
+		return false
+	}
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == http.StatusTooManyRequests {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "rate limit") || strings.Contains(msg, "rate exceeded") || strings.Contains(msg, "too many requests")
+}
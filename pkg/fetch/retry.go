@@ -0,0 +1,70 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// RetryPolicy retries failed RPC calls with exponential backoff and jitter.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// do calls op until it succeeds, returns a permanent error, the attempts are
+// exhausted or ctx is done. The last error is returned.
+func (p RetryPolicy) do(ctx context.Context, op func() error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = op(); err == nil || !retryable(err) || attempt >= p.MaxAttempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(p.Backoff(attempt)):
+		}
+		rpcRetries.Add(ctx, 1)
+	}
+}
+
+// Backoff returns the delay before the next attempt: the base delay doubled
+// per attempt, capped at MaxDelay, with up to half of it randomized so that
+// concurrent workers do not retry in lockstep.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	delay := p.BaseDelay << (attempt - 1)
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// retryable reports whether err may go away on a later attempt. A receipt the
+// node does not know about will not appear by asking again, nor will a method
+// it does not implement.
+func retryable(err error) bool {
+	return !errors.Is(err, ethereum.NotFound) && !errors.Is(err, ErrOffline) && !errors.Is(err, context.Canceled) && !methodUnsupported(err)
+}
+
+// methodUnsupported reports whether err says that the RPC does not implement
+// the requested method. Providers disagree on the error code, so the message
+// is checked as well.
+func methodUnsupported(err error) bool {
+	if err == nil {
+		return false
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == -32601 {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "does not exist") || strings.Contains(msg, "not supported") ||
+		strings.Contains(msg, "unsupported method") || strings.Contains(msg, "method not found")
+}
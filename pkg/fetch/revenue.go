@@ -0,0 +1,106 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Fee vault predeploys of the OP Stack, to which L2 transactions pay their
+// fees.
+var (
+	SequencerFeeVault = common.HexToAddress("0x4200000000000000000000000000000000000011")
+	BaseFeeVault      = common.HexToAddress("0x4200000000000000000000000000000000000019")
+	L1FeeVault        = common.HexToAddress("0x420000000000000000000000000000000000001A")
+)
+
+// Revenue holds the fees in wei that an OP Stack chain collected.
+type Revenue struct {
+	BaseFee *big.Int
+	// L1Fee is the L1 data fee that the chain charges to cover its L1 costs.
+	L1Fee       *big.Int
+	PriorityFee *big.Int
+}
+
+// Total returns the sum of the fees of r.
+func (r Revenue) Total() *big.Int {
+	total := new(big.Int).Add(r.BaseFee, r.L1Fee)
+	return total.Add(total, r.PriorityFee)
+}
+
+// Revenue returns the fees that the fee vaults of the chain collected in the
+// blocks mined from from until before to, up to the chain head: the growth
+// of their balance and of what they withdrew. It reads the state of past
+// blocks, which needs an archive node.
+func (s *Scanner) Revenue(ctx context.Context, from, to time.Time) (Revenue, error) {
+	latest, err := s.LatestBlock(ctx)
+	if err != nil {
+		return Revenue{}, err
+	}
+	first, err := s.blockAt(ctx, from, latest)
+	if err != nil {
+		return Revenue{}, err
+	}
+	end, err := s.blockAt(ctx, to, latest)
+	if err != nil {
+		return Revenue{}, err
+	}
+	var revenue Revenue
+	for _, v := range []struct {
+		vault common.Address
+		dst   **big.Int
+	}{
+		{BaseFeeVault, &revenue.BaseFee},
+		{L1FeeVault, &revenue.L1Fee},
+		{SequencerFeeVault, &revenue.PriorityFee},
+	} {
+		// The fees of block n are in the state after it; the genesis
+		// holds none.
+		before, after := new(big.Int), new(big.Int)
+		if first > 0 {
+			if before, err = s.collected(ctx, v.vault, first-1); err != nil {
+				return Revenue{}, err
+			}
+		}
+		if end > first {
+			if after, err = s.collected(ctx, v.vault, end-1); err != nil {
+				return Revenue{}, err
+			}
+		} else {
+			after = before
+		}
+		*v.dst = after.Sub(after, before)
+	}
+	return revenue, nil
+}
+
+// collected returns the fees that vault collected up to block: its balance
+// plus its totalProcessed, the sum of its withdrawals.
+func (s *Scanner) collected(ctx context.Context, vault common.Address, block uint64) (*big.Int, error) {
+	number := new(big.Int).SetUint64(block)
+	var balance *big.Int
+	var processed []byte
+	err := s.Retry.do(ctx, func() error {
+		return s.RPC.call(ctx, "eth_getBalance", func(ctx context.Context, client *ethclient.Client) error {
+			var err error
+			if balance, err = client.BalanceAt(ctx, vault, number); err != nil {
+				return err
+			}
+			processed, err = client.CallContract(ctx, ethereum.CallMsg{To: &vault, Data: crypto.Keccak256([]byte("totalProcessed()"))[:4]}, number)
+			return err
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fee vault %s at block %d: %w", vault, block, err)
+	}
+	if len(processed) < common.HashLength {
+		return nil, fmt.Errorf("fee vault %s at block %d: no totalProcessed", vault, block)
+	}
+	return balance.Add(balance, new(big.Int).SetBytes(processed[:common.HashLength])), nil
+}
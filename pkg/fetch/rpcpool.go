@@ -0,0 +1,104 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Pool holds one client per configured L1 endpoint. Calls go to the
+// current endpoint and move on to the next one when it fails, so that a flaky
+// or rate-limiting provider does not stall a long backfill.
+type Pool struct {
+	hosts   []string // of the endpoints, for the telemetry and the log messages
+	clients []*ethclient.Client
+	current atomic.Uint64
+	calls   atomic.Int64
+	limiter *rateLimiter // nil without a rate limit
+	// Timeout bounds every call; 0 means no limit.
+	Timeout time.Duration
+	// Logger receives the messages of the calls; nil for slog.Default().
+	Logger *slog.Logger
+}
+
+// Dial dials every endpoint of a comma-separated URL list.
+func Dial(rawURLs string) (*Pool, error) {
+	pool := &Pool{}
+	for _, url := range strings.Split(rawURLs, ",") {
+		url = strings.TrimSpace(url)
+		if url == "" {
+			continue
+		}
+		client, err := ethclient.Dial(url)
+		if err != nil {
+			return nil, fmt.Errorf("dial %s: %w", EndpointHost(url), err)
+		}
+		pool.hosts = append(pool.hosts, EndpointHost(url))
+		pool.clients = append(pool.clients, client)
+	}
+	if len(pool.clients) == 0 {
+		return nil, fmt.Errorf("no L1 RPC endpoint configured")
+	}
+	return pool, nil
+}
+
+// SetRateLimit limits the calls to rps per second, a batch counting as one
+// call, slowing down further while the endpoints answer that they are rate
+// limited. It must be called before the first call; 0 leaves them unlimited.
+func (p *Pool) SetRateLimit(rps float64) {
+	if rps > 0 {
+		p.limiter = newRateLimiter(rps)
+	}
+}
+
+// call runs op against the current endpoint, bounded by the request timeout
+// and spaced by the rate limit. When op fails with an error that another
+// endpoint might not return, later calls fail over to the next one. method
+// labels the debug log and the span of the call.
+func (p *Pool) call(ctx context.Context, method string, op func(context.Context, *ethclient.Client) error) error {
+	if p.limiter != nil {
+		if err := p.limiter.wait(ctx); err != nil {
+			return err
+		}
+	}
+	callCtx := ctx
+	if p.Timeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+	}
+	current := p.current.Load()
+	index := current % uint64(len(p.clients))
+	callCtx, end := startCall(callCtx, method, p.hosts[index])
+	start := time.Now()
+	p.calls.Add(1)
+	err := op(callCtx, p.clients[index])
+	end(err)
+	logger(p.Logger).Debug("rpc call", "method", method, "endpoint", p.hosts[index], "duration", time.Since(start), "err", err)
+	if p.limiter != nil {
+		p.limiter.observe(err, logger(p.Logger))
+	}
+	if err != nil && retryable(err) && len(p.clients) > 1 && ctx.Err() == nil {
+		if p.current.CompareAndSwap(current, current+1) {
+			next := (current + 1) % uint64(len(p.clients))
+			logger(p.Logger).Warn("rpc endpoint failed, switching", "endpoint", p.hosts[index], "next", p.hosts[next], "err", err)
+		}
+	}
+	return err
+}
+
+// Calls returns the number of calls made so far.
+func (p *Pool) Calls() int64 {
+	return p.calls.Load()
+}
+
+func (p *Pool) Close() {
+	for _, client := range p.clients {
+		client.Close()
+	}
+}
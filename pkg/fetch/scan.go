@@ -0,0 +1,268 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/input"
+)
+
+// scannedBlock is the part of an eth_getBlockByNumber response needed to find
+// transactions by sender.
+type scannedBlock struct {
+	Number       hexutil.Uint64 `json:"number"`
+	Timestamp    hexutil.Uint64 `json:"timestamp"`
+	Transactions []struct {
+		Hash  common.Hash     `json:"hash"`
+		From  common.Address  `json:"from"`
+		To    *common.Address `json:"to"`
+		Input hexutil.Bytes   `json:"input"`
+	} `json:"transactions"`
+}
+
+// Scanner discovers transactions by walking L1 blocks instead of reading them
+// from a CSV export.
+type Scanner struct {
+	RPC         *Pool
+	Retry       RetryPolicy
+	Concurrency int
+	BatchSize   int
+	// Logger receives the messages of the scans; nil for slog.Default().
+	Logger *slog.Logger
+}
+
+// ScanFilter selects scanned transactions. A transaction matches when its
+// sender is one of senders and its recipient one of recipients; an empty list
+// matches any address.
+type ScanFilter struct {
+	senders    map[common.Address]bool
+	recipients map[common.Address]bool
+}
+
+func NewScanFilter(senders, recipients []common.Address) ScanFilter {
+	f := ScanFilter{senders: make(map[common.Address]bool), recipients: make(map[common.Address]bool)}
+	for _, sender := range senders {
+		f.senders[sender] = true
+	}
+	for _, recipient := range recipients {
+		f.recipients[recipient] = true
+	}
+	return f
+}
+
+func (f ScanFilter) match(from common.Address, to *common.Address) bool {
+	if len(f.senders) > 0 && !f.senders[from] {
+		return false
+	}
+	if len(f.recipients) > 0 && (to == nil || !f.recipients[*to]) {
+		return false
+	}
+	return true
+}
+
+// Scan returns the transactions in blocks from..to (inclusive) that match
+// filter, ordered by block.
+func (s *Scanner) Scan(ctx context.Context, from, to uint64, filter ScanFilter) ([]input.Row, error) {
+	type chunk struct{ from, to uint64 }
+	chunks := make(chan chunk)
+	var (
+		mu       sync.Mutex
+		found    = make(map[uint64][]input.Row)
+		firstErr error
+		scanned  int
+	)
+	var wg sync.WaitGroup
+	for i := 0; i < max(s.Concurrency, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range chunks {
+				blocks, err := s.blocks(ctx, c.from, c.to)
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				for _, block := range blocks {
+					for _, tx := range block.Transactions {
+						if filter.match(tx.From, tx.To) {
+							from := tx.From
+							found[uint64(block.Number)] = append(found[uint64(block.Number)], input.Row{
+								Hash:   tx.Hash,
+								Time:   time.Unix(int64(block.Timestamp), 0).UTC(),
+								Block:  uint64(block.Number),
+								From:   &from,
+								To:     tx.To,
+								Method: Selector(tx.Input),
+							})
+						}
+					}
+				}
+				scanned += len(blocks)
+				if scanned%10000 < len(blocks) {
+					logger(s.Logger).Info("scan progress", "scanned", scanned, "blocks", to-from+1)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	size := uint64(max(s.BatchSize, 1))
+	for start := from; start <= to; start += size {
+		chunks <- chunk{start, min(start+size-1, to)}
+	}
+	close(chunks)
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	numbers := make([]uint64, 0, len(found))
+	for number := range found {
+		numbers = append(numbers, number)
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+	var rows []input.Row
+	for _, number := range numbers {
+		for _, row := range found[number] {
+			row.Index = len(rows)
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// blocks fetches blocks from..to with their transactions in one batch call.
+func (s *Scanner) blocks(ctx context.Context, from, to uint64) ([]*scannedBlock, error) {
+	blocks := make([]*scannedBlock, to-from+1)
+	batch := make([]rpc.BatchElem, len(blocks))
+	for i := range batch {
+		batch[i] = rpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []any{hexutil.EncodeUint64(from + uint64(i)), true},
+			Result: &blocks[i],
+		}
+	}
+	err := s.Retry.do(ctx, func() error {
+		return s.RPC.call(ctx, "batch eth_getBlockByNumber", func(ctx context.Context, client *ethclient.Client) error {
+			if err := client.Client().BatchCallContext(ctx, batch); err != nil {
+				return err
+			}
+			for i := range batch {
+				if batch[i].Error != nil {
+					return fmt.Errorf("block %d: %w", from+uint64(i), batch[i].Error)
+				}
+				if blocks[i] == nil {
+					return fmt.Errorf("block %d not found", from+uint64(i))
+				}
+			}
+			return nil
+		})
+	})
+	return blocks, err
+}
+
+// blockAt returns the first block whose timestamp is not before t, using a
+// binary search over block headers up to latest.
+func (s *Scanner) blockAt(ctx context.Context, t time.Time, latest uint64) (uint64, error) {
+	lo, hi := uint64(0), latest+1
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		var timestamp uint64
+		err := s.Retry.do(ctx, func() error {
+			return s.RPC.call(ctx, "eth_getBlockByNumber", func(ctx context.Context, client *ethclient.Client) error {
+				header, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(mid))
+				if err == nil {
+					timestamp = header.Time
+				}
+				return err
+			})
+		})
+		if err != nil {
+			return 0, err
+		}
+		if timestamp < uint64(t.Unix()) {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo, nil
+}
+
+// LatestBlock returns the number of the chain head.
+func (s *Scanner) LatestBlock(ctx context.Context) (uint64, error) {
+	var latest uint64
+	err := s.Retry.do(ctx, func() error {
+		return s.RPC.call(ctx, "eth_blockNumber", func(ctx context.Context, client *ethclient.Client) error {
+			var err error
+			latest, err = client.BlockNumber(ctx)
+			return err
+		})
+	})
+	return latest, err
+}
+
+// BlockRange resolves the inclusive block range to scan. Dates (YYYY-MM-DD,
+// UTC, both inclusive) take precedence over block numbers; a zero toBlock
+// means the chain head.
+func (s *Scanner) BlockRange(ctx context.Context, fromBlock, toBlock uint64, fromDate, toDate string) (uint64, uint64, error) {
+	latest, err := s.LatestBlock(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	if toBlock == 0 || toBlock > latest {
+		toBlock = latest
+	}
+	if fromDate != "" {
+		t, err := time.Parse(time.DateOnly, fromDate)
+		if err != nil {
+			return 0, 0, err
+		}
+		if fromBlock, err = s.blockAt(ctx, t, latest); err != nil {
+			return 0, 0, err
+		}
+	}
+	if toDate != "" {
+		t, err := time.Parse(time.DateOnly, toDate)
+		if err != nil {
+			return 0, 0, err
+		}
+		next, err := s.blockAt(ctx, t.AddDate(0, 0, 1), latest)
+		if err != nil {
+			return 0, 0, err
+		}
+		if next <= latest {
+			toBlock = next - 1
+		}
+	}
+	if fromBlock > toBlock {
+		return 0, 0, fmt.Errorf("empty block range %d-%d", fromBlock, toBlock)
+	}
+	return fromBlock, toBlock, nil
+}
+
+// ParseAddresses parses a comma-separated list of hex addresses.
+func ParseAddresses(list string) ([]common.Address, error) {
+	var addresses []common.Address
+	for _, address := range strings.Split(list, ",") {
+		address = strings.TrimSpace(address)
+		if address == "" {
+			continue
+		}
+		if !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("invalid address %q", address)
+		}
+		addresses = append(addresses, common.HexToAddress(address))
+	}
+	return addresses, nil
+}
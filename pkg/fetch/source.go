@@ -0,0 +1,235 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ReceiptSource serves the receipts and block headers a Fetcher needs. Pool
+// implements it over JSON-RPC; package fetchtest has implementations that run
+// without a live node.
+type ReceiptSource interface {
+	// TransactionReceipts returns the receipts of hashes, with one error per
+	// hash. A receipt that does not exist fails with ethereum.NotFound.
+	TransactionReceipts(ctx context.Context, hashes []common.Hash) ([]*types.Receipt, []error)
+	// BlockReceipts returns the receipts of every transaction of a block.
+	BlockReceipts(ctx context.Context, number uint64) ([]*types.Receipt, error)
+	// BlockHeaders returns the headers of blocks.
+	BlockHeaders(ctx context.Context, numbers []uint64) ([]Header, error)
+}
+
+// TransactionSource is implemented by sources that also serve transactions,
+// which a Fetcher needs for the recipients the input lacks and, with a Beacon
+// client, to pick the blobs of a transaction from those of its block.
+type TransactionSource interface {
+	// Transactions returns the transactions of hashes.
+	Transactions(ctx context.Context, hashes []common.Hash) ([]Transaction, error)
+}
+
+// FeeHistorySource is implemented by sources that also serve the fee history
+// of blocks, which a Fetcher needs for the tips a fee oracle would suggest.
+type FeeHistorySource interface {
+	// BlockTips returns the percentile-th percentile of the priority tips
+	// paid in each of the blocks from..to (inclusive), weighted by gas, with
+	// nil for empty blocks.
+	BlockTips(ctx context.Context, from, to uint64, percentile float64) ([]*big.Int, error)
+}
+
+// Transaction holds the fields of a transaction that a Fetcher uses.
+type Transaction struct {
+	From       common.Address
+	Nonce      uint64
+	To         *common.Address // nil for contract creations
+	Input      []byte
+	BlobHashes []common.Hash
+}
+
+// Selector returns the hex encoded method selector of a transaction input,
+// its first four bytes, or "" when it is shorter.
+func Selector(input []byte) string {
+	if len(input) < 4 {
+		return ""
+	}
+	return hexutil.Encode(input[:4])
+}
+
+// Header holds the fields of a block header that a Fetcher uses.
+type Header struct {
+	Time    time.Time
+	BaseFee *big.Int // nil before London
+	// ExcessBlobGas and BlobGasUsed are nil before Cancun.
+	ExcessBlobGas *uint64
+	BlobGasUsed   *uint64
+}
+
+// TransactionReceipts requests the receipts of hashes in a single JSON-RPC
+// batch.
+func (p *Pool) TransactionReceipts(ctx context.Context, hashes []common.Hash) ([]*types.Receipt, []error) {
+	receipts := make([]*types.Receipt, len(hashes))
+	errs := make([]error, len(hashes))
+	batch := make([]rpc.BatchElem, len(hashes))
+	for i, hash := range hashes {
+		batch[i] = rpc.BatchElem{
+			Method: "eth_getTransactionReceipt",
+			Args:   []any{hash},
+			Result: &receipts[i],
+		}
+	}
+	err := p.call(ctx, "batch eth_getTransactionReceipt", func(ctx context.Context, client *ethclient.Client) error {
+		if err := client.Client().BatchCallContext(ctx, batch); err != nil {
+			return err
+		}
+		// The first retryable element error decides whether the endpoint
+		// is switched.
+		var retryErr error
+		for i := range batch {
+			switch {
+			case batch[i].Error != nil:
+				errs[i] = batch[i].Error
+				if retryErr == nil && retryable(batch[i].Error) {
+					retryErr = batch[i].Error
+				}
+			case receipts[i] == nil:
+				errs[i] = ethereum.NotFound
+			}
+		}
+		return retryErr
+	})
+	if err != nil {
+		for i := range errs {
+			if errs[i] == nil && receipts[i] == nil {
+				errs[i] = err
+			}
+		}
+	}
+	return receipts, errs
+}
+
+// BlockReceipts requests the receipts of a block with eth_getBlockReceipts.
+func (p *Pool) BlockReceipts(ctx context.Context, number uint64) ([]*types.Receipt, error) {
+	var receipts []*types.Receipt
+	err := p.call(ctx, "eth_getBlockReceipts", func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		receipts, err = client.BlockReceipts(ctx, rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(number)))
+		return err
+	})
+	return receipts, err
+}
+
+// BlockHeaders requests the headers of blocks in a single JSON-RPC batch.
+func (p *Pool) BlockHeaders(ctx context.Context, numbers []uint64) ([]Header, error) {
+	headers := make([]*struct {
+		Timestamp     hexutil.Uint64  `json:"timestamp"`
+		BaseFeePerGas *hexutil.Big    `json:"baseFeePerGas"`
+		ExcessBlobGas *hexutil.Uint64 `json:"excessBlobGas"`
+		BlobGasUsed   *hexutil.Uint64 `json:"blobGasUsed"`
+	}, len(numbers))
+	batch := make([]rpc.BatchElem, len(numbers))
+	for i, number := range numbers {
+		batch[i] = rpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []any{hexutil.EncodeUint64(number), false},
+			Result: &headers[i],
+		}
+	}
+	err := p.call(ctx, "batch eth_getBlockByNumber", func(ctx context.Context, client *ethclient.Client) error {
+		if err := client.Client().BatchCallContext(ctx, batch); err != nil {
+			return err
+		}
+		for i := range batch {
+			if batch[i].Error != nil {
+				return batch[i].Error
+			}
+			if headers[i] == nil {
+				return fmt.Errorf("block %d not found", numbers[i])
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Header, len(numbers))
+	for i, header := range headers {
+		result[i].Time = time.Unix(int64(header.Timestamp), 0).UTC()
+		result[i].BaseFee = (*big.Int)(header.BaseFeePerGas)
+		result[i].ExcessBlobGas = (*uint64)(header.ExcessBlobGas)
+		result[i].BlobGasUsed = (*uint64)(header.BlobGasUsed)
+	}
+	return result, nil
+}
+
+// BlockTips requests the tips of blocks with eth_feeHistory.
+func (p *Pool) BlockTips(ctx context.Context, from, to uint64, percentile float64) ([]*big.Int, error) {
+	var history *ethereum.FeeHistory
+	err := p.call(ctx, "eth_feeHistory", func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		history, err = client.FeeHistory(ctx, to-from+1, new(big.Int).SetUint64(to), []float64{percentile})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Nodes return fewer blocks than asked for when they do not keep them
+	// all.
+	if history.OldestBlock == nil || history.OldestBlock.Uint64() != from || len(history.Reward) != int(to-from+1) {
+		return nil, fmt.Errorf("fee history of blocks %d-%d: got %d blocks from %v", from, to, len(history.Reward), history.OldestBlock)
+	}
+	tips := make([]*big.Int, len(history.Reward))
+	for i, reward := range history.Reward {
+		if len(reward) > 0 && i < len(history.GasUsedRatio) && history.GasUsedRatio[i] > 0 {
+			tips[i] = reward[0]
+		}
+	}
+	return tips, nil
+}
+
+// Transactions requests transactions in a single JSON-RPC batch.
+func (p *Pool) Transactions(ctx context.Context, hashes []common.Hash) ([]Transaction, error) {
+	txs := make([]*struct {
+		From                common.Address  `json:"from"`
+		Nonce               hexutil.Uint64  `json:"nonce"`
+		To                  *common.Address `json:"to"`
+		Input               hexutil.Bytes   `json:"input"`
+		BlobVersionedHashes []common.Hash   `json:"blobVersionedHashes"`
+	}, len(hashes))
+	batch := make([]rpc.BatchElem, len(hashes))
+	for i, hash := range hashes {
+		batch[i] = rpc.BatchElem{
+			Method: "eth_getTransactionByHash",
+			Args:   []any{hash},
+			Result: &txs[i],
+		}
+	}
+	err := p.call(ctx, "batch eth_getTransactionByHash", func(ctx context.Context, client *ethclient.Client) error {
+		if err := client.Client().BatchCallContext(ctx, batch); err != nil {
+			return err
+		}
+		for i := range batch {
+			if batch[i].Error != nil {
+				return batch[i].Error
+			}
+			if txs[i] == nil {
+				return fmt.Errorf("transaction %s: %w", hashes[i], ethereum.NotFound)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Transaction, len(hashes))
+	for i, tx := range txs {
+		result[i] = Transaction{From: tx.From, Nonce: uint64(tx.Nonce), To: tx.To, Input: tx.Input, BlobHashes: tx.BlobVersionedHashes}
+	}
+	return result, nil
+}
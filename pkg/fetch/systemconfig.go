@@ -0,0 +1,117 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// permissionedGameType is the dispute game type of the permissioned fault
+// proofs, whose games only the proposer may create.
+const permissionedGameType = 1
+
+// SystemConfig holds the L1 addresses of an OP Stack chain read from its
+// SystemConfig contract. Addresses that the contract version does not expose
+// are zero.
+type SystemConfig struct {
+	Batcher            common.Address
+	BatchInbox         common.Address
+	L2OutputOracle     common.Address
+	DisputeGameFactory common.Address
+	OptimismPortal     common.Address
+	L1StandardBridge   common.Address
+	// L1CrossDomainMessenger and L1ERC721Bridge are zero on versions of the
+	// SystemConfig that do not name them.
+	L1CrossDomainMessenger common.Address
+	L1ERC721Bridge         common.Address
+	// Proposer is read from the L2OutputOracle or, with fault proofs, from the
+	// permissioned dispute game. It is zero with permissionless proposals.
+	Proposer common.Address
+}
+
+// SystemConfig reads the batcher, the batch inbox and the proposer of the
+// chain whose SystemConfig contract is at address, at the latest block.
+func (p *Pool) SystemConfig(ctx context.Context, address common.Address) (SystemConfig, error) {
+	var cfg SystemConfig
+	batcherHash, err := p.callAddress(ctx, address, "batcherHash()")
+	if err != nil {
+		return cfg, fmt.Errorf("system config %s: %w", address, err)
+	}
+	if batcherHash == nil {
+		return cfg, fmt.Errorf("system config %s: no batcherHash; is it a SystemConfig contract?", address)
+	}
+	cfg.Batcher = *batcherHash
+
+	getters := []struct {
+		signature string
+		dst       *common.Address
+	}{
+		{"batchInbox()", &cfg.BatchInbox},
+		{"l2OutputOracle()", &cfg.L2OutputOracle},
+		{"disputeGameFactory()", &cfg.DisputeGameFactory},
+		{"optimismPortal()", &cfg.OptimismPortal},
+		{"l1StandardBridge()", &cfg.L1StandardBridge},
+		{"l1CrossDomainMessenger()", &cfg.L1CrossDomainMessenger},
+		{"l1ERC721Bridge()", &cfg.L1ERC721Bridge},
+	}
+	for _, g := range getters {
+		v, err := p.callAddress(ctx, address, g.signature)
+		if err != nil {
+			return cfg, fmt.Errorf("system config %s: %s: %w", address, g.signature, err)
+		}
+		if v != nil {
+			*g.dst = *v
+		}
+	}
+
+	var proposer *common.Address
+	switch {
+	case cfg.L2OutputOracle != (common.Address{}):
+		// PROPOSER() is the getter of the versions before proposer().
+		if proposer, err = p.callAddress(ctx, cfg.L2OutputOracle, "proposer()"); err == nil && proposer == nil {
+			proposer, err = p.callAddress(ctx, cfg.L2OutputOracle, "PROPOSER()")
+		}
+	case cfg.DisputeGameFactory != (common.Address{}):
+		var game *common.Address
+		game, err = p.callAddress(ctx, cfg.DisputeGameFactory, "gameImpls(uint32)", common.BigToHash(big.NewInt(permissionedGameType)).Bytes())
+		if err == nil && game != nil && *game != (common.Address{}) {
+			proposer, err = p.callAddress(ctx, *game, "proposer()")
+		}
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("system config %s: proposer: %w", address, err)
+	}
+	if proposer != nil {
+		cfg.Proposer = *proposer
+	}
+	return cfg, nil
+}
+
+// callAddress calls a getter of the contract at to that returns an address,
+// or nil when the contract does not have it.
+func (p *Pool) callAddress(ctx context.Context, to common.Address, signature string, args ...[]byte) (*common.Address, error) {
+	data := crypto.Keccak256([]byte(signature))[:4]
+	for _, arg := range args {
+		data = append(data, arg...)
+	}
+	var result []byte
+	err := p.call(ctx, "eth_call", func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		result, err = client.CallContract(ctx, ethereum.CallMsg{To: &to, Data: data}, nil)
+		return err
+	})
+	if err != nil && strings.Contains(strings.ToLower(err.Error()), "revert") {
+		return nil, nil
+	}
+	if err != nil || len(result) < common.HashLength {
+		return nil, err
+	}
+	address := common.BytesToAddress(result[:common.HashLength])
+	return &address, nil
+}
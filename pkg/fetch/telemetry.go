@@ -0,0 +1,57 @@
+package fetch
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/telemetry"
+)
+
+// The instruments of the RPC calls and the receipt cache. They record nothing
+// until telemetry.Setup; the errors only concern invalid names.
+var (
+	rpcDuration, _ = telemetry.Meter().Float64Histogram("rpc.call.duration",
+		metric.WithUnit("s"), metric.WithDescription("Duration of the L1 RPC calls, a batch counting as one call"))
+	rpcCalls, _ = telemetry.Meter().Int64Counter("rpc.calls",
+		metric.WithDescription("L1 RPC calls, a batch counting as one call"))
+	rpcRetries, _ = telemetry.Meter().Int64Counter("rpc.retries",
+		metric.WithDescription("Retries of failed L1 RPC calls"))
+	cacheLookups, _ = telemetry.Meter().Int64Counter("receipt_cache.lookups",
+		metric.WithDescription("Lookups in the receipt cache, by whether the receipt was cached"))
+)
+
+// startCall starts the span of an RPC call of method to endpoint, and returns
+// the function ending it and recording its duration and outcome err.
+func startCall(ctx context.Context, method, endpoint string) (context.Context, func(err error)) {
+	attrs := []attribute.KeyValue{
+		attribute.String("rpc.method", method),
+		attribute.String("rpc.endpoint", endpoint),
+	}
+	ctx, span := telemetry.Tracer().Start(ctx, method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	start := time.Now()
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		attrs := append(attrs, attribute.Bool("error", err != nil))
+		rpcDuration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+		rpcCalls.Add(ctx, 1, metric.WithAttributes(attrs...))
+	}
+}
+
+// EndpointHost returns the host of an endpoint URL, leaving out the path and
+// query that often hold an API key, for labels and log messages.
+func EndpointHost(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return "unknown"
+}
@@ -0,0 +1,95 @@
+package fetch
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/input"
+)
+
+const (
+	// pragueTime is when EIP-7623 raised the calldata cost of data-heavy
+	// transactions on mainnet to the floor of 10 gas per token, 40 per
+	// non-zero byte.
+	pragueTime             = 1746612311
+	floorGasPerToken       = 10
+	floorGasPerNonZeroByte = 4 * floorGasPerToken
+)
+
+// calldataGasPerByte returns the gas of a non-zero calldata byte of a
+// transaction that carries mostly data, in a block with the given time.
+func calldataGasPerByte(blockTime time.Time) uint64 {
+	if blockTime.Unix() >= pragueTime {
+		return floorGasPerNonZeroByte
+	}
+	return params.TxDataNonZeroGasEIP2028
+}
+
+// whatIf sets the WhatIfCost of the rows that carry batches: for blob
+// transactions, the cost of their data as calldata, and for calldata
+// transactions the cost of their frames in blobs, at the prices of their
+// block. Blob transactions whose data was not measured are assumed to fill
+// their blobs.
+func (f *Fetcher) whatIf(rows []input.Row, receipts []*types.Receipt, errs []error) {
+	for i := range rows {
+		receipt := receipts[i]
+		if errs[i] != nil || receipt.BlockNumber == nil || receipt.EffectiveGasPrice == nil {
+			continue
+		}
+		v, ok := f.headers.Load(receipt.BlockNumber.Uint64())
+		if !ok {
+			continue
+		}
+		h := v.(Header)
+		execution := new(big.Int).Mul(new(big.Int).SetUint64(params.TxGas), receipt.EffectiveGasPrice)
+		switch {
+		case receipt.Type == types.BlobTxType:
+			payload := rows[i].FrameBytes
+			if payload == 0 {
+				blobs := receipt.BlobGasUsed / params.BlobTxBlobGasPerBlob
+				payload = blobs * maxBlobPayload
+				if rows[i].MeasuredBlobs == blobs && blobs > 0 {
+					payload = rows[i].BlobPayloadBytes
+				}
+			}
+			gas := new(big.Int).SetUint64(payload * calldataGasPerByte(h.Time))
+			rows[i].WhatIfCost = execution.Add(execution, gas.Mul(gas, receipt.EffectiveGasPrice))
+		case rows[i].FrameBytes > 0 && h.ExcessBlobGas != nil:
+			blobs := (rows[i].FrameBytes + maxBlobPayload - 1) / maxBlobPayload
+			blobGas := new(big.Int).SetUint64(blobs * params.BlobTxBlobGasPerBlob)
+			rows[i].WhatIfCost = execution.Add(execution, blobGas.Mul(blobGas, BlobBaseFee(*h.ExcessBlobGas, h.Time)))
+		}
+	}
+}
+
+// calldataFloors sets the FloorGas of the rows of calldata transactions in
+// txs, from the tokens of their calldata, a zero byte counting one and a
+// non-zero byte four, and FloorActive for those of blocks since Prague.
+func (f *Fetcher) calldataFloors(rows []input.Row, receipts []*types.Receipt, errs []error, txs map[int]Transaction) {
+	for i := range rows {
+		if errs[i] != nil || receipts[i].Type == types.BlobTxType || receipts[i].BlockNumber == nil {
+			continue
+		}
+		tx, ok := txs[i]
+		if !ok {
+			continue
+		}
+		v, ok := f.headers.Load(receipts[i].BlockNumber.Uint64())
+		if !ok {
+			continue
+		}
+		var tokens uint64
+		for _, b := range tx.Input {
+			if b == 0 {
+				tokens++
+			} else {
+				tokens += params.TxDataNonZeroGasEIP2028 / params.TxDataZeroGas
+			}
+		}
+		rows[i].FloorGas = params.TxGas + tokens*floorGasPerToken
+		rows[i].FloorActive = v.(Header).Time.Unix() >= pragueTime
+	}
+}
@@ -0,0 +1,228 @@
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// logWindow is the number of blocks of an eth_getLogs request, which
+// providers limit.
+const logWindow = 10_000
+
+var (
+	// ethBridgeFinalized is emitted by the L1StandardBridge when it pays out
+	// ETH bridged from L2, such as the withdrawals of older fee vaults.
+	ethBridgeFinalized = crypto.Keccak256Hash([]byte("ETHBridgeFinalized(address,address,uint256,bytes)"))
+	// withdrawalFinalized is emitted by the OptimismPortal for every
+	// finalized withdrawal, such as those that newer fee vaults send
+	// through the L2ToL1MessagePasser.
+	withdrawalFinalized = crypto.Keccak256Hash([]byte("WithdrawalFinalized(bytes32,bool)"))
+
+	// Selectors of the OptimismPortal methods finalizing a withdrawal, whose
+	// first argument is the withdrawal (nonce, sender, target, value,
+	// gasLimit, data).
+	finalizeSelectors = [][]byte{
+		crypto.Keccak256([]byte("finalizeWithdrawalTransaction((uint256,address,address,uint256,uint256,bytes))"))[:4],
+		crypto.Keccak256([]byte("finalizeWithdrawalTransactionExternalProof((uint256,address,address,uint256,uint256,bytes),address)"))[:4],
+	}
+)
+
+// FeeVaults are the fee vault predeploys whose withdrawals are tracked.
+var FeeVaults = []common.Address{SequencerFeeVault, BaseFeeVault, L1FeeVault}
+
+// Withdrawal is ETH that an L2 fee vault withdrew to L1.
+type Withdrawal struct {
+	Hash   common.Hash // of the L1 transaction that finalized it
+	Time   time.Time
+	Vault  common.Address
+	Amount *big.Int
+}
+
+// FeeWithdrawals returns the withdrawals of the fee vaults to recipient
+// finalized on L1 from from until before to, up to the chain head, through
+// bridge or portal. Withdrawals finalized through the portal by a contract,
+// such as a multisig, are not recognized.
+func (s *Scanner) FeeWithdrawals(ctx context.Context, from, to time.Time, portal, bridge, recipient common.Address) ([]Withdrawal, error) {
+	latest, err := s.LatestBlock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	first, err := s.blockAt(ctx, from, latest)
+	if err != nil {
+		return nil, err
+	}
+	end, err := s.blockAt(ctx, to, latest)
+	if err != nil {
+		return nil, err
+	}
+	if end <= first {
+		return nil, nil
+	}
+
+	var withdrawals []Withdrawal
+	blocks := make(map[common.Hash]uint64)
+	if bridge != (common.Address{}) {
+		vaults := make([]common.Hash, len(FeeVaults))
+		for i, vault := range FeeVaults {
+			vaults[i] = common.BytesToHash(vault.Bytes())
+		}
+		logs, err := s.logs(ctx, first, end-1, bridge, [][]common.Hash{{ethBridgeFinalized}, vaults, {common.BytesToHash(recipient.Bytes())}})
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range logs {
+			if len(l.Data) < common.HashLength {
+				continue
+			}
+			withdrawals = append(withdrawals, Withdrawal{
+				Hash:   l.TxHash,
+				Vault:  common.BytesToAddress(l.Topics[1].Bytes()),
+				Amount: new(big.Int).SetBytes(l.Data[:common.HashLength]),
+			})
+			blocks[l.TxHash] = l.BlockNumber
+		}
+	}
+	if portal != (common.Address{}) {
+		logs, err := s.logs(ctx, first, end-1, portal, [][]common.Hash{{withdrawalFinalized}})
+		if err != nil {
+			return nil, err
+		}
+		var hashes []common.Hash
+		for _, l := range logs {
+			// The success flag is the data of the event.
+			if len(l.Data) >= common.HashLength && l.Data[common.HashLength-1] == 1 {
+				hashes = append(hashes, l.TxHash)
+				blocks[l.TxHash] = l.BlockNumber
+			}
+		}
+		size := max(s.BatchSize, 1)
+		for start := 0; start < len(hashes); start += size {
+			batch := hashes[start:min(start+size, len(hashes))]
+			var txs []Transaction
+			err := s.Retry.do(ctx, func() error {
+				var err error
+				txs, err = s.RPC.Transactions(ctx, batch)
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+			for i, tx := range txs {
+				if w, ok := feeWithdrawal(tx.Input, recipient); ok {
+					w.Hash = batch[i]
+					withdrawals = append(withdrawals, w)
+				}
+			}
+		}
+	}
+
+	if err := s.withdrawalTimes(ctx, withdrawals, blocks); err != nil {
+		return nil, err
+	}
+	return withdrawals, nil
+}
+
+// feeWithdrawal decodes the input of a transaction finalizing a withdrawal
+// through the portal, and reports whether it pays a fee vault withdrawal to
+// recipient.
+func feeWithdrawal(input []byte, recipient common.Address) (Withdrawal, bool) {
+	if len(input) < 4 {
+		return Withdrawal{}, false
+	}
+	known := false
+	for _, selector := range finalizeSelectors {
+		known = known || bytes.Equal(input[:4], selector)
+	}
+	if !known {
+		return Withdrawal{}, false
+	}
+	args := input[4:]
+	if len(args) < common.HashLength {
+		return Withdrawal{}, false
+	}
+	// The withdrawal is a dynamic tuple, found at the offset of the first
+	// argument.
+	offset := new(big.Int).SetBytes(args[:common.HashLength])
+	if !offset.IsUint64() || offset.Uint64() > uint64(len(args)) || uint64(len(args))-offset.Uint64() < 4*common.HashLength {
+		return Withdrawal{}, false
+	}
+	tuple := args[offset.Uint64():]
+	word := func(i int) []byte { return tuple[i*common.HashLength : (i+1)*common.HashLength] }
+	sender, target := common.BytesToAddress(word(1)), common.BytesToAddress(word(2))
+	isVault := false
+	for _, vault := range FeeVaults {
+		isVault = isVault || sender == vault
+	}
+	if !isVault || target != recipient {
+		return Withdrawal{}, false
+	}
+	return Withdrawal{Vault: sender, Amount: new(big.Int).SetBytes(word(3))}, true
+}
+
+// logs returns the logs of address matching topics in blocks from..to,
+// requested in windows of logWindow blocks.
+func (s *Scanner) logs(ctx context.Context, from, to uint64, address common.Address, topics [][]common.Hash) ([]types.Log, error) {
+	var logs []types.Log
+	for start := from; start <= to; start += logWindow {
+		q := ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(start),
+			ToBlock:   new(big.Int).SetUint64(min(start+logWindow-1, to)),
+			Addresses: []common.Address{address},
+			Topics:    topics,
+		}
+		var window []types.Log
+		err := s.Retry.do(ctx, func() error {
+			return s.RPC.call(ctx, "eth_getLogs", func(ctx context.Context, client *ethclient.Client) error {
+				var err error
+				window, err = client.FilterLogs(ctx, q)
+				return err
+			})
+		})
+		if err != nil {
+			return nil, fmt.Errorf("logs of %s in blocks %d-%d: %w", address, q.FromBlock, q.ToBlock, err)
+		}
+		logs = append(logs, window...)
+	}
+	return logs, nil
+}
+
+// withdrawalTimes sets the time of withdrawals to the one of their block.
+func (s *Scanner) withdrawalTimes(ctx context.Context, withdrawals []Withdrawal, blocks map[common.Hash]uint64) error {
+	var numbers []uint64
+	seen := make(map[uint64]bool)
+	for _, w := range withdrawals {
+		if n := blocks[w.Hash]; !seen[n] {
+			seen[n] = true
+			numbers = append(numbers, n)
+		}
+	}
+	if len(numbers) == 0 {
+		return nil
+	}
+	var headers []Header
+	err := s.Retry.do(ctx, func() error {
+		var err error
+		headers, err = s.RPC.BlockHeaders(ctx, numbers)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	times := make(map[uint64]time.Time, len(numbers))
+	for i, n := range numbers {
+		times[n] = headers[i].Time
+	}
+	for i := range withdrawals {
+		withdrawals[i].Time = times[blocks[withdrawals[i].Hash]]
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+// Package forecast projects the gas prices of the coming days from their
+// daily history with an exponentially weighted moving average, optionally
+// adjusted by the day of the week.
+package forecast
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// Day is the average gas price of a day, NaN when unknown.
+type Day struct {
+	Date  time.Time
+	Price float64
+}
+
+// Model fits a history of prices. Alpha, between 0 and 1, is the weight of
+// the latest day in the moving average; Seasonal scales the prices by a
+// factor per day of the week, learnt from the history.
+type Model struct {
+	Alpha    float64
+	Seasonal bool
+}
+
+// Fit is a fitted model, from which prices are projected.
+type Fit struct {
+	// Level is the moving average of the prices, without the weekday
+	// factors.
+	Level float64
+	// Factors scale Level by day of the week, 1 unless the model is
+	// seasonal.
+	Factors [7]float64
+}
+
+// ErrNoHistory is returned when no day of the history has a price.
+var ErrNoHistory = errors.New("no prices to fit")
+
+// Fit fits the model to days, oldest first. Days without a price are
+// skipped.
+func (m Model) Fit(days []Day) (Fit, error) {
+	if m.Alpha <= 0 || m.Alpha > 1 {
+		return Fit{}, errors.New("alpha must be in (0, 1]")
+	}
+	var f Fit
+	for i := range f.Factors {
+		f.Factors[i] = 1
+	}
+	var sum float64
+	var n int
+	var weekdaySum [7]float64
+	var weekdayN [7]int
+	for _, d := range days {
+		if math.IsNaN(d.Price) {
+			continue
+		}
+		sum += d.Price
+		n++
+		weekdaySum[d.Date.Weekday()] += d.Price
+		weekdayN[d.Date.Weekday()]++
+	}
+	if n == 0 {
+		return Fit{}, ErrNoHistory
+	}
+	if mean := sum / float64(n); m.Seasonal && mean > 0 {
+		for i := range f.Factors {
+			// Weekdays missing from the history keep the plain level.
+			if weekdayN[i] > 0 {
+				f.Factors[i] = weekdaySum[i] / float64(weekdayN[i]) / mean
+			}
+		}
+	}
+	first := true
+	for _, d := range days {
+		if math.IsNaN(d.Price) {
+			continue
+		}
+		p := d.Price
+		if factor := f.Factors[d.Date.Weekday()]; factor > 0 {
+			p /= factor
+		}
+		if first {
+			f.Level, first = p, false
+			continue
+		}
+		f.Level = m.Alpha*p + (1-m.Alpha)*f.Level
+	}
+	return f, nil
+}
+
+// Price returns the price projected for the day of t.
+func (f Fit) Price(t time.Time) float64 {
+	return f.Level * f.Factors[t.Weekday()]
+}
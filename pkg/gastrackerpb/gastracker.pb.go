@@ -0,0 +1,891 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: gastracker/v1/gastracker.proto
+
+// The query surface of the REST API of `serve -db`, over gRPC. Messages
+// mirror the JSON Lines objects field for field: wei amounts are decimal
+// strings, which do not lose precision, and the ETH and Gwei doubles are for
+// convenience. Values that depend on a missing blob gas price are unset.
+
+package gastrackerpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ListDaysRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	From string `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"` // YYYY-MM-DD, inclusive; empty for no bound
+	To   string `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`     // YYYY-MM-DD, inclusive; empty for no bound
+}
+
+func (x *ListDaysRequest) Reset() {
+	*x = ListDaysRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gastracker_v1_gastracker_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListDaysRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDaysRequest) ProtoMessage() {}
+
+func (x *ListDaysRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gastracker_v1_gastracker_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDaysRequest.ProtoReflect.Descriptor instead.
+func (*ListDaysRequest) Descriptor() ([]byte, []int) {
+	return file_gastracker_v1_gastracker_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ListDaysRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *ListDaysRequest) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+type ListDaysResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Days []*Bucket `protobuf:"bytes,1,rep,name=days,proto3" json:"days,omitempty"`
+}
+
+func (x *ListDaysResponse) Reset() {
+	*x = ListDaysResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gastracker_v1_gastracker_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListDaysResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDaysResponse) ProtoMessage() {}
+
+func (x *ListDaysResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gastracker_v1_gastracker_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDaysResponse.ProtoReflect.Descriptor instead.
+func (*ListDaysResponse) Descriptor() ([]byte, []int) {
+	return file_gastracker_v1_gastracker_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListDaysResponse) GetDays() []*Bucket {
+	if x != nil {
+		return x.Days
+	}
+	return nil
+}
+
+type GetSummaryRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	From string `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To   string `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+}
+
+func (x *GetSummaryRequest) Reset() {
+	*x = GetSummaryRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gastracker_v1_gastracker_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetSummaryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSummaryRequest) ProtoMessage() {}
+
+func (x *GetSummaryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gastracker_v1_gastracker_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSummaryRequest.ProtoReflect.Descriptor instead.
+func (*GetSummaryRequest) Descriptor() ([]byte, []int) {
+	return file_gastracker_v1_gastracker_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetSummaryRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *GetSummaryRequest) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+type GetTransactionRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Hash string `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"` // 0x-prefixed
+}
+
+func (x *GetTransactionRequest) Reset() {
+	*x = GetTransactionRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gastracker_v1_gastracker_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetTransactionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTransactionRequest) ProtoMessage() {}
+
+func (x *GetTransactionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gastracker_v1_gastracker_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTransactionRequest.ProtoReflect.Descriptor instead.
+func (*GetTransactionRequest) Descriptor() ([]byte, []int) {
+	return file_gastracker_v1_gastracker_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetTransactionRequest) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+type WatchDaysRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	From string `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"` // YYYY-MM-DD; empty for all the stored days
+}
+
+func (x *WatchDaysRequest) Reset() {
+	*x = WatchDaysRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gastracker_v1_gastracker_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchDaysRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchDaysRequest) ProtoMessage() {}
+
+func (x *WatchDaysRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gastracker_v1_gastracker_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchDaysRequest.ProtoReflect.Descriptor instead.
+func (*WatchDaysRequest) Descriptor() ([]byte, []int) {
+	return file_gastracker_v1_gastracker_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *WatchDaysRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+type Bucket struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Bucket                  string   `protobuf:"bytes,1,opt,name=bucket,proto3" json:"bucket,omitempty"` // YYYY-MM-DD, or first/last for a summary
+	CostWei                 *string  `protobuf:"bytes,2,opt,name=cost_wei,json=costWei,proto3,oneof" json:"cost_wei,omitempty"`
+	CostEth                 *float64 `protobuf:"fixed64,3,opt,name=cost_eth,json=costEth,proto3,oneof" json:"cost_eth,omitempty"`
+	CalldataCostWei         string   `protobuf:"bytes,4,opt,name=calldata_cost_wei,json=calldataCostWei,proto3" json:"calldata_cost_wei,omitempty"`
+	CalldataCostEth         float64  `protobuf:"fixed64,5,opt,name=calldata_cost_eth,json=calldataCostEth,proto3" json:"calldata_cost_eth,omitempty"`
+	BlobCostWei             *string  `protobuf:"bytes,6,opt,name=blob_cost_wei,json=blobCostWei,proto3,oneof" json:"blob_cost_wei,omitempty"`
+	BlobCostEth             *float64 `protobuf:"fixed64,7,opt,name=blob_cost_eth,json=blobCostEth,proto3,oneof" json:"blob_cost_eth,omitempty"`
+	AvgCalldataGasPriceWei  string   `protobuf:"bytes,8,opt,name=avg_calldata_gas_price_wei,json=avgCalldataGasPriceWei,proto3" json:"avg_calldata_gas_price_wei,omitempty"`
+	AvgCalldataGasPriceGwei float64  `protobuf:"fixed64,9,opt,name=avg_calldata_gas_price_gwei,json=avgCalldataGasPriceGwei,proto3" json:"avg_calldata_gas_price_gwei,omitempty"`
+	AvgBlobGasPriceWei      *string  `protobuf:"bytes,10,opt,name=avg_blob_gas_price_wei,json=avgBlobGasPriceWei,proto3,oneof" json:"avg_blob_gas_price_wei,omitempty"`
+	AvgBlobGasPriceGwei     *float64 `protobuf:"fixed64,11,opt,name=avg_blob_gas_price_gwei,json=avgBlobGasPriceGwei,proto3,oneof" json:"avg_blob_gas_price_gwei,omitempty"`
+	BlendedGasPriceWei      *string  `protobuf:"bytes,12,opt,name=blended_gas_price_wei,json=blendedGasPriceWei,proto3,oneof" json:"blended_gas_price_wei,omitempty"`
+	BlendedGasPriceGwei     *float64 `protobuf:"fixed64,13,opt,name=blended_gas_price_gwei,json=blendedGasPriceGwei,proto3,oneof" json:"blended_gas_price_gwei,omitempty"`
+	CalldataGasUsed         uint64   `protobuf:"varint,14,opt,name=calldata_gas_used,json=calldataGasUsed,proto3" json:"calldata_gas_used,omitempty"`
+	BlobGasUsed             uint64   `protobuf:"varint,15,opt,name=blob_gas_used,json=blobGasUsed,proto3" json:"blob_gas_used,omitempty"`
+	GasUsed                 uint64   `protobuf:"varint,16,opt,name=gas_used,json=gasUsed,proto3" json:"gas_used,omitempty"`
+	TxCount                 uint64   `protobuf:"varint,17,opt,name=tx_count,json=txCount,proto3" json:"tx_count,omitempty"`
+	BlobPriceMissing        uint64   `protobuf:"varint,18,opt,name=blob_price_missing,json=blobPriceMissing,proto3" json:"blob_price_missing,omitempty"`
+}
+
+func (x *Bucket) Reset() {
+	*x = Bucket{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gastracker_v1_gastracker_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Bucket) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Bucket) ProtoMessage() {}
+
+func (x *Bucket) ProtoReflect() protoreflect.Message {
+	mi := &file_gastracker_v1_gastracker_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Bucket.ProtoReflect.Descriptor instead.
+func (*Bucket) Descriptor() ([]byte, []int) {
+	return file_gastracker_v1_gastracker_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Bucket) GetBucket() string {
+	if x != nil {
+		return x.Bucket
+	}
+	return ""
+}
+
+func (x *Bucket) GetCostWei() string {
+	if x != nil && x.CostWei != nil {
+		return *x.CostWei
+	}
+	return ""
+}
+
+func (x *Bucket) GetCostEth() float64 {
+	if x != nil && x.CostEth != nil {
+		return *x.CostEth
+	}
+	return 0
+}
+
+func (x *Bucket) GetCalldataCostWei() string {
+	if x != nil {
+		return x.CalldataCostWei
+	}
+	return ""
+}
+
+func (x *Bucket) GetCalldataCostEth() float64 {
+	if x != nil {
+		return x.CalldataCostEth
+	}
+	return 0
+}
+
+func (x *Bucket) GetBlobCostWei() string {
+	if x != nil && x.BlobCostWei != nil {
+		return *x.BlobCostWei
+	}
+	return ""
+}
+
+func (x *Bucket) GetBlobCostEth() float64 {
+	if x != nil && x.BlobCostEth != nil {
+		return *x.BlobCostEth
+	}
+	return 0
+}
+
+func (x *Bucket) GetAvgCalldataGasPriceWei() string {
+	if x != nil {
+		return x.AvgCalldataGasPriceWei
+	}
+	return ""
+}
+
+func (x *Bucket) GetAvgCalldataGasPriceGwei() float64 {
+	if x != nil {
+		return x.AvgCalldataGasPriceGwei
+	}
+	return 0
+}
+
+func (x *Bucket) GetAvgBlobGasPriceWei() string {
+	if x != nil && x.AvgBlobGasPriceWei != nil {
+		return *x.AvgBlobGasPriceWei
+	}
+	return ""
+}
+
+func (x *Bucket) GetAvgBlobGasPriceGwei() float64 {
+	if x != nil && x.AvgBlobGasPriceGwei != nil {
+		return *x.AvgBlobGasPriceGwei
+	}
+	return 0
+}
+
+func (x *Bucket) GetBlendedGasPriceWei() string {
+	if x != nil && x.BlendedGasPriceWei != nil {
+		return *x.BlendedGasPriceWei
+	}
+	return ""
+}
+
+func (x *Bucket) GetBlendedGasPriceGwei() float64 {
+	if x != nil && x.BlendedGasPriceGwei != nil {
+		return *x.BlendedGasPriceGwei
+	}
+	return 0
+}
+
+func (x *Bucket) GetCalldataGasUsed() uint64 {
+	if x != nil {
+		return x.CalldataGasUsed
+	}
+	return 0
+}
+
+func (x *Bucket) GetBlobGasUsed() uint64 {
+	if x != nil {
+		return x.BlobGasUsed
+	}
+	return 0
+}
+
+func (x *Bucket) GetGasUsed() uint64 {
+	if x != nil {
+		return x.GasUsed
+	}
+	return 0
+}
+
+func (x *Bucket) GetTxCount() uint64 {
+	if x != nil {
+		return x.TxCount
+	}
+	return 0
+}
+
+func (x *Bucket) GetBlobPriceMissing() uint64 {
+	if x != nil {
+		return x.BlobPriceMissing
+	}
+	return 0
+}
+
+type Transaction struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Hash            string  `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	Time            string  `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"` // RFC 3339, UTC
+	Block           uint64  `protobuf:"varint,3,opt,name=block,proto3" json:"block,omitempty"`
+	Bucket          string  `protobuf:"bytes,4,opt,name=bucket,proto3" json:"bucket,omitempty"`
+	Type            uint32  `protobuf:"varint,5,opt,name=type,proto3" json:"type,omitempty"`
+	GasUsed         uint64  `protobuf:"varint,6,opt,name=gas_used,json=gasUsed,proto3" json:"gas_used,omitempty"`
+	GasPriceWei     string  `protobuf:"bytes,7,opt,name=gas_price_wei,json=gasPriceWei,proto3" json:"gas_price_wei,omitempty"`
+	BlobGasUsed     uint64  `protobuf:"varint,8,opt,name=blob_gas_used,json=blobGasUsed,proto3" json:"blob_gas_used,omitempty"`
+	BlobGasPriceWei *string `protobuf:"bytes,9,opt,name=blob_gas_price_wei,json=blobGasPriceWei,proto3,oneof" json:"blob_gas_price_wei,omitempty"` // unset unless a blob transaction with a known price
+	CostWei         string  `protobuf:"bytes,10,opt,name=cost_wei,json=costWei,proto3" json:"cost_wei,omitempty"`
+	CostEth         float64 `protobuf:"fixed64,11,opt,name=cost_eth,json=costEth,proto3" json:"cost_eth,omitempty"`
+	CalldataCostWei string  `protobuf:"bytes,12,opt,name=calldata_cost_wei,json=calldataCostWei,proto3" json:"calldata_cost_wei,omitempty"`
+	BlobCostWei     string  `protobuf:"bytes,13,opt,name=blob_cost_wei,json=blobCostWei,proto3" json:"blob_cost_wei,omitempty"`
+}
+
+func (x *Transaction) Reset() {
+	*x = Transaction{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gastracker_v1_gastracker_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Transaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transaction) ProtoMessage() {}
+
+func (x *Transaction) ProtoReflect() protoreflect.Message {
+	mi := &file_gastracker_v1_gastracker_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transaction.ProtoReflect.Descriptor instead.
+func (*Transaction) Descriptor() ([]byte, []int) {
+	return file_gastracker_v1_gastracker_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Transaction) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *Transaction) GetTime() string {
+	if x != nil {
+		return x.Time
+	}
+	return ""
+}
+
+func (x *Transaction) GetBlock() uint64 {
+	if x != nil {
+		return x.Block
+	}
+	return 0
+}
+
+func (x *Transaction) GetBucket() string {
+	if x != nil {
+		return x.Bucket
+	}
+	return ""
+}
+
+func (x *Transaction) GetType() uint32 {
+	if x != nil {
+		return x.Type
+	}
+	return 0
+}
+
+func (x *Transaction) GetGasUsed() uint64 {
+	if x != nil {
+		return x.GasUsed
+	}
+	return 0
+}
+
+func (x *Transaction) GetGasPriceWei() string {
+	if x != nil {
+		return x.GasPriceWei
+	}
+	return ""
+}
+
+func (x *Transaction) GetBlobGasUsed() uint64 {
+	if x != nil {
+		return x.BlobGasUsed
+	}
+	return 0
+}
+
+func (x *Transaction) GetBlobGasPriceWei() string {
+	if x != nil && x.BlobGasPriceWei != nil {
+		return *x.BlobGasPriceWei
+	}
+	return ""
+}
+
+func (x *Transaction) GetCostWei() string {
+	if x != nil {
+		return x.CostWei
+	}
+	return ""
+}
+
+func (x *Transaction) GetCostEth() float64 {
+	if x != nil {
+		return x.CostEth
+	}
+	return 0
+}
+
+func (x *Transaction) GetCalldataCostWei() string {
+	if x != nil {
+		return x.CalldataCostWei
+	}
+	return ""
+}
+
+func (x *Transaction) GetBlobCostWei() string {
+	if x != nil {
+		return x.BlobCostWei
+	}
+	return ""
+}
+
+var File_gastracker_v1_gastracker_proto protoreflect.FileDescriptor
+
+var file_gastracker_v1_gastracker_proto_rawDesc = []byte{
+	0x0a, 0x1e, 0x67, 0x61, 0x73, 0x74, 0x72, 0x61, 0x63, 0x6b, 0x65, 0x72, 0x2f, 0x76, 0x31, 0x2f,
+	0x67, 0x61, 0x73, 0x74, 0x72, 0x61, 0x63, 0x6b, 0x65, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x12, 0x0d, 0x67, 0x61, 0x73, 0x74, 0x72, 0x61, 0x63, 0x6b, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x22,
+	0x35, 0x0a, 0x0f, 0x4c, 0x69, 0x73, 0x74, 0x44, 0x61, 0x79, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x12, 0x0e, 0x0a, 0x02, 0x74, 0x6f, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x02, 0x74, 0x6f, 0x22, 0x3d, 0x0a, 0x10, 0x4c, 0x69, 0x73, 0x74, 0x44, 0x61,
+	0x79, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x29, 0x0a, 0x04, 0x64, 0x61,
+	0x79, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x67, 0x61, 0x73, 0x74, 0x72,
+	0x61, 0x63, 0x6b, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x52,
+	0x04, 0x64, 0x61, 0x79, 0x73, 0x22, 0x37, 0x0a, 0x11, 0x47, 0x65, 0x74, 0x53, 0x75, 0x6d, 0x6d,
+	0x61, 0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x66, 0x72,
+	0x6f, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x12, 0x0e,
+	0x0a, 0x02, 0x74, 0x6f, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x74, 0x6f, 0x22, 0x2b,
+	0x0a, 0x15, 0x47, 0x65, 0x74, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x61, 0x73, 0x68, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x68, 0x61, 0x73, 0x68, 0x22, 0x26, 0x0a, 0x10, 0x57,
+	0x61, 0x74, 0x63, 0x68, 0x44, 0x61, 0x79, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x12, 0x0a, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x66,
+	0x72, 0x6f, 0x6d, 0x22, 0xc8, 0x07, 0x0a, 0x06, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x12, 0x16,
+	0x0a, 0x06, 0x62, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x62, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x12, 0x1e, 0x0a, 0x08, 0x63, 0x6f, 0x73, 0x74, 0x5f, 0x77,
+	0x65, 0x69, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x07, 0x63, 0x6f, 0x73, 0x74,
+	0x57, 0x65, 0x69, 0x88, 0x01, 0x01, 0x12, 0x1e, 0x0a, 0x08, 0x63, 0x6f, 0x73, 0x74, 0x5f, 0x65,
+	0x74, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x48, 0x01, 0x52, 0x07, 0x63, 0x6f, 0x73, 0x74,
+	0x45, 0x74, 0x68, 0x88, 0x01, 0x01, 0x12, 0x2a, 0x0a, 0x11, 0x63, 0x61, 0x6c, 0x6c, 0x64, 0x61,
+	0x74, 0x61, 0x5f, 0x63, 0x6f, 0x73, 0x74, 0x5f, 0x77, 0x65, 0x69, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0f, 0x63, 0x61, 0x6c, 0x6c, 0x64, 0x61, 0x74, 0x61, 0x43, 0x6f, 0x73, 0x74, 0x57,
+	0x65, 0x69, 0x12, 0x2a, 0x0a, 0x11, 0x63, 0x61, 0x6c, 0x6c, 0x64, 0x61, 0x74, 0x61, 0x5f, 0x63,
+	0x6f, 0x73, 0x74, 0x5f, 0x65, 0x74, 0x68, 0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0f, 0x63,
+	0x61, 0x6c, 0x6c, 0x64, 0x61, 0x74, 0x61, 0x43, 0x6f, 0x73, 0x74, 0x45, 0x74, 0x68, 0x12, 0x27,
+	0x0a, 0x0d, 0x62, 0x6c, 0x6f, 0x62, 0x5f, 0x63, 0x6f, 0x73, 0x74, 0x5f, 0x77, 0x65, 0x69, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x09, 0x48, 0x02, 0x52, 0x0b, 0x62, 0x6c, 0x6f, 0x62, 0x43, 0x6f, 0x73,
+	0x74, 0x57, 0x65, 0x69, 0x88, 0x01, 0x01, 0x12, 0x27, 0x0a, 0x0d, 0x62, 0x6c, 0x6f, 0x62, 0x5f,
+	0x63, 0x6f, 0x73, 0x74, 0x5f, 0x65, 0x74, 0x68, 0x18, 0x07, 0x20, 0x01, 0x28, 0x01, 0x48, 0x03,
+	0x52, 0x0b, 0x62, 0x6c, 0x6f, 0x62, 0x43, 0x6f, 0x73, 0x74, 0x45, 0x74, 0x68, 0x88, 0x01, 0x01,
+	0x12, 0x3a, 0x0a, 0x1a, 0x61, 0x76, 0x67, 0x5f, 0x63, 0x61, 0x6c, 0x6c, 0x64, 0x61, 0x74, 0x61,
+	0x5f, 0x67, 0x61, 0x73, 0x5f, 0x70, 0x72, 0x69, 0x63, 0x65, 0x5f, 0x77, 0x65, 0x69, 0x18, 0x08,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x16, 0x61, 0x76, 0x67, 0x43, 0x61, 0x6c, 0x6c, 0x64, 0x61, 0x74,
+	0x61, 0x47, 0x61, 0x73, 0x50, 0x72, 0x69, 0x63, 0x65, 0x57, 0x65, 0x69, 0x12, 0x3c, 0x0a, 0x1b,
+	0x61, 0x76, 0x67, 0x5f, 0x63, 0x61, 0x6c, 0x6c, 0x64, 0x61, 0x74, 0x61, 0x5f, 0x67, 0x61, 0x73,
+	0x5f, 0x70, 0x72, 0x69, 0x63, 0x65, 0x5f, 0x67, 0x77, 0x65, 0x69, 0x18, 0x09, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x17, 0x61, 0x76, 0x67, 0x43, 0x61, 0x6c, 0x6c, 0x64, 0x61, 0x74, 0x61, 0x47, 0x61,
+	0x73, 0x50, 0x72, 0x69, 0x63, 0x65, 0x47, 0x77, 0x65, 0x69, 0x12, 0x37, 0x0a, 0x16, 0x61, 0x76,
+	0x67, 0x5f, 0x62, 0x6c, 0x6f, 0x62, 0x5f, 0x67, 0x61, 0x73, 0x5f, 0x70, 0x72, 0x69, 0x63, 0x65,
+	0x5f, 0x77, 0x65, 0x69, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x48, 0x04, 0x52, 0x12, 0x61, 0x76,
+	0x67, 0x42, 0x6c, 0x6f, 0x62, 0x47, 0x61, 0x73, 0x50, 0x72, 0x69, 0x63, 0x65, 0x57, 0x65, 0x69,
+	0x88, 0x01, 0x01, 0x12, 0x39, 0x0a, 0x17, 0x61, 0x76, 0x67, 0x5f, 0x62, 0x6c, 0x6f, 0x62, 0x5f,
+	0x67, 0x61, 0x73, 0x5f, 0x70, 0x72, 0x69, 0x63, 0x65, 0x5f, 0x67, 0x77, 0x65, 0x69, 0x18, 0x0b,
+	0x20, 0x01, 0x28, 0x01, 0x48, 0x05, 0x52, 0x13, 0x61, 0x76, 0x67, 0x42, 0x6c, 0x6f, 0x62, 0x47,
+	0x61, 0x73, 0x50, 0x72, 0x69, 0x63, 0x65, 0x47, 0x77, 0x65, 0x69, 0x88, 0x01, 0x01, 0x12, 0x36,
+	0x0a, 0x15, 0x62, 0x6c, 0x65, 0x6e, 0x64, 0x65, 0x64, 0x5f, 0x67, 0x61, 0x73, 0x5f, 0x70, 0x72,
+	0x69, 0x63, 0x65, 0x5f, 0x77, 0x65, 0x69, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x09, 0x48, 0x06, 0x52,
+	0x12, 0x62, 0x6c, 0x65, 0x6e, 0x64, 0x65, 0x64, 0x47, 0x61, 0x73, 0x50, 0x72, 0x69, 0x63, 0x65,
+	0x57, 0x65, 0x69, 0x88, 0x01, 0x01, 0x12, 0x38, 0x0a, 0x16, 0x62, 0x6c, 0x65, 0x6e, 0x64, 0x65,
+	0x64, 0x5f, 0x67, 0x61, 0x73, 0x5f, 0x70, 0x72, 0x69, 0x63, 0x65, 0x5f, 0x67, 0x77, 0x65, 0x69,
+	0x18, 0x0d, 0x20, 0x01, 0x28, 0x01, 0x48, 0x07, 0x52, 0x13, 0x62, 0x6c, 0x65, 0x6e, 0x64, 0x65,
+	0x64, 0x47, 0x61, 0x73, 0x50, 0x72, 0x69, 0x63, 0x65, 0x47, 0x77, 0x65, 0x69, 0x88, 0x01, 0x01,
+	0x12, 0x2a, 0x0a, 0x11, 0x63, 0x61, 0x6c, 0x6c, 0x64, 0x61, 0x74, 0x61, 0x5f, 0x67, 0x61, 0x73,
+	0x5f, 0x75, 0x73, 0x65, 0x64, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0f, 0x63, 0x61, 0x6c,
+	0x6c, 0x64, 0x61, 0x74, 0x61, 0x47, 0x61, 0x73, 0x55, 0x73, 0x65, 0x64, 0x12, 0x22, 0x0a, 0x0d,
+	0x62, 0x6c, 0x6f, 0x62, 0x5f, 0x67, 0x61, 0x73, 0x5f, 0x75, 0x73, 0x65, 0x64, 0x18, 0x0f, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x0b, 0x62, 0x6c, 0x6f, 0x62, 0x47, 0x61, 0x73, 0x55, 0x73, 0x65, 0x64,
+	0x12, 0x19, 0x0a, 0x08, 0x67, 0x61, 0x73, 0x5f, 0x75, 0x73, 0x65, 0x64, 0x18, 0x10, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x07, 0x67, 0x61, 0x73, 0x55, 0x73, 0x65, 0x64, 0x12, 0x19, 0x0a, 0x08, 0x74,
+	0x78, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x11, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x74,
+	0x78, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x2c, 0x0a, 0x12, 0x62, 0x6c, 0x6f, 0x62, 0x5f, 0x70,
+	0x72, 0x69, 0x63, 0x65, 0x5f, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6e, 0x67, 0x18, 0x12, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x10, 0x62, 0x6c, 0x6f, 0x62, 0x50, 0x72, 0x69, 0x63, 0x65, 0x4d, 0x69, 0x73,
+	0x73, 0x69, 0x6e, 0x67, 0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x63, 0x6f, 0x73, 0x74, 0x5f, 0x77, 0x65,
+	0x69, 0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x63, 0x6f, 0x73, 0x74, 0x5f, 0x65, 0x74, 0x68, 0x42, 0x10,
+	0x0a, 0x0e, 0x5f, 0x62, 0x6c, 0x6f, 0x62, 0x5f, 0x63, 0x6f, 0x73, 0x74, 0x5f, 0x77, 0x65, 0x69,
+	0x42, 0x10, 0x0a, 0x0e, 0x5f, 0x62, 0x6c, 0x6f, 0x62, 0x5f, 0x63, 0x6f, 0x73, 0x74, 0x5f, 0x65,
+	0x74, 0x68, 0x42, 0x19, 0x0a, 0x17, 0x5f, 0x61, 0x76, 0x67, 0x5f, 0x62, 0x6c, 0x6f, 0x62, 0x5f,
+	0x67, 0x61, 0x73, 0x5f, 0x70, 0x72, 0x69, 0x63, 0x65, 0x5f, 0x77, 0x65, 0x69, 0x42, 0x1a, 0x0a,
+	0x18, 0x5f, 0x61, 0x76, 0x67, 0x5f, 0x62, 0x6c, 0x6f, 0x62, 0x5f, 0x67, 0x61, 0x73, 0x5f, 0x70,
+	0x72, 0x69, 0x63, 0x65, 0x5f, 0x67, 0x77, 0x65, 0x69, 0x42, 0x18, 0x0a, 0x16, 0x5f, 0x62, 0x6c,
+	0x65, 0x6e, 0x64, 0x65, 0x64, 0x5f, 0x67, 0x61, 0x73, 0x5f, 0x70, 0x72, 0x69, 0x63, 0x65, 0x5f,
+	0x77, 0x65, 0x69, 0x42, 0x19, 0x0a, 0x17, 0x5f, 0x62, 0x6c, 0x65, 0x6e, 0x64, 0x65, 0x64, 0x5f,
+	0x67, 0x61, 0x73, 0x5f, 0x70, 0x72, 0x69, 0x63, 0x65, 0x5f, 0x67, 0x77, 0x65, 0x69, 0x22, 0xa9,
+	0x03, 0x0a, 0x0b, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12,
+	0x0a, 0x04, 0x68, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x68, 0x61,
+	0x73, 0x68, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x16, 0x0a, 0x06,
+	0x62, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x62, 0x75,
+	0x63, 0x6b, 0x65, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x67, 0x61, 0x73, 0x5f,
+	0x75, 0x73, 0x65, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x67, 0x61, 0x73, 0x55,
+	0x73, 0x65, 0x64, 0x12, 0x22, 0x0a, 0x0d, 0x67, 0x61, 0x73, 0x5f, 0x70, 0x72, 0x69, 0x63, 0x65,
+	0x5f, 0x77, 0x65, 0x69, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x67, 0x61, 0x73, 0x50,
+	0x72, 0x69, 0x63, 0x65, 0x57, 0x65, 0x69, 0x12, 0x22, 0x0a, 0x0d, 0x62, 0x6c, 0x6f, 0x62, 0x5f,
+	0x67, 0x61, 0x73, 0x5f, 0x75, 0x73, 0x65, 0x64, 0x18, 0x08, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0b,
+	0x62, 0x6c, 0x6f, 0x62, 0x47, 0x61, 0x73, 0x55, 0x73, 0x65, 0x64, 0x12, 0x30, 0x0a, 0x12, 0x62,
+	0x6c, 0x6f, 0x62, 0x5f, 0x67, 0x61, 0x73, 0x5f, 0x70, 0x72, 0x69, 0x63, 0x65, 0x5f, 0x77, 0x65,
+	0x69, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x0f, 0x62, 0x6c, 0x6f, 0x62, 0x47,
+	0x61, 0x73, 0x50, 0x72, 0x69, 0x63, 0x65, 0x57, 0x65, 0x69, 0x88, 0x01, 0x01, 0x12, 0x19, 0x0a,
+	0x08, 0x63, 0x6f, 0x73, 0x74, 0x5f, 0x77, 0x65, 0x69, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x63, 0x6f, 0x73, 0x74, 0x57, 0x65, 0x69, 0x12, 0x19, 0x0a, 0x08, 0x63, 0x6f, 0x73, 0x74,
+	0x5f, 0x65, 0x74, 0x68, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x01, 0x52, 0x07, 0x63, 0x6f, 0x73, 0x74,
+	0x45, 0x74, 0x68, 0x12, 0x2a, 0x0a, 0x11, 0x63, 0x61, 0x6c, 0x6c, 0x64, 0x61, 0x74, 0x61, 0x5f,
+	0x63, 0x6f, 0x73, 0x74, 0x5f, 0x77, 0x65, 0x69, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f,
+	0x63, 0x61, 0x6c, 0x6c, 0x64, 0x61, 0x74, 0x61, 0x43, 0x6f, 0x73, 0x74, 0x57, 0x65, 0x69, 0x12,
+	0x22, 0x0a, 0x0d, 0x62, 0x6c, 0x6f, 0x62, 0x5f, 0x63, 0x6f, 0x73, 0x74, 0x5f, 0x77, 0x65, 0x69,
+	0x18, 0x0d, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x62, 0x6c, 0x6f, 0x62, 0x43, 0x6f, 0x73, 0x74,
+	0x57, 0x65, 0x69, 0x42, 0x15, 0x0a, 0x13, 0x5f, 0x62, 0x6c, 0x6f, 0x62, 0x5f, 0x67, 0x61, 0x73,
+	0x5f, 0x70, 0x72, 0x69, 0x63, 0x65, 0x5f, 0x77, 0x65, 0x69, 0x32, 0xbb, 0x02, 0x0a, 0x0a, 0x47,
+	0x61, 0x73, 0x54, 0x72, 0x61, 0x63, 0x6b, 0x65, 0x72, 0x12, 0x4b, 0x0a, 0x08, 0x4c, 0x69, 0x73,
+	0x74, 0x44, 0x61, 0x79, 0x73, 0x12, 0x1e, 0x2e, 0x67, 0x61, 0x73, 0x74, 0x72, 0x61, 0x63, 0x6b,
+	0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x44, 0x61, 0x79, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x67, 0x61, 0x73, 0x74, 0x72, 0x61, 0x63, 0x6b,
+	0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x44, 0x61, 0x79, 0x73, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x45, 0x0a, 0x0a, 0x47, 0x65, 0x74, 0x53, 0x75, 0x6d,
+	0x6d, 0x61, 0x72, 0x79, 0x12, 0x20, 0x2e, 0x67, 0x61, 0x73, 0x74, 0x72, 0x61, 0x63, 0x6b, 0x65,
+	0x72, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e, 0x67, 0x61, 0x73, 0x74, 0x72, 0x61, 0x63,
+	0x6b, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x12, 0x52, 0x0a,
+	0x0e, 0x47, 0x65, 0x74, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12,
+	0x24, 0x2e, 0x67, 0x61, 0x73, 0x74, 0x72, 0x61, 0x63, 0x6b, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e,
+	0x47, 0x65, 0x74, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x67, 0x61, 0x73, 0x74, 0x72, 0x61, 0x63, 0x6b,
+	0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x12, 0x45, 0x0a, 0x09, 0x57, 0x61, 0x74, 0x63, 0x68, 0x44, 0x61, 0x79, 0x73, 0x12, 0x1f,
+	0x2e, 0x67, 0x61, 0x73, 0x74, 0x72, 0x61, 0x63, 0x6b, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x57,
+	0x61, 0x74, 0x63, 0x68, 0x44, 0x61, 0x79, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x15, 0x2e, 0x67, 0x61, 0x73, 0x74, 0x72, 0x61, 0x63, 0x6b, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e,
+	0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x30, 0x01, 0x42, 0x3d, 0x5a, 0x3b, 0x67, 0x69, 0x74, 0x68,
+	0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6f, 0x68, 0x62, 0x79, 0x65, 0x6f, 0x6e, 0x67, 0x6d,
+	0x69, 0x6e, 0x2f, 0x62, 0x61, 0x74, 0x63, 0x68, 0x65, 0x72, 0x2d, 0x67, 0x61, 0x73, 0x2d, 0x74,
+	0x72, 0x61, 0x63, 0x6b, 0x65, 0x72, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x67, 0x61, 0x73, 0x74, 0x72,
+	0x61, 0x63, 0x6b, 0x65, 0x72, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_gastracker_v1_gastracker_proto_rawDescOnce sync.Once
+	file_gastracker_v1_gastracker_proto_rawDescData = file_gastracker_v1_gastracker_proto_rawDesc
+)
+
+func file_gastracker_v1_gastracker_proto_rawDescGZIP() []byte {
+	file_gastracker_v1_gastracker_proto_rawDescOnce.Do(func() {
+		file_gastracker_v1_gastracker_proto_rawDescData = protoimpl.X.CompressGZIP(file_gastracker_v1_gastracker_proto_rawDescData)
+	})
+	return file_gastracker_v1_gastracker_proto_rawDescData
+}
+
+var file_gastracker_v1_gastracker_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_gastracker_v1_gastracker_proto_goTypes = []any{
+	(*ListDaysRequest)(nil),       // 0: gastracker.v1.ListDaysRequest
+	(*ListDaysResponse)(nil),      // 1: gastracker.v1.ListDaysResponse
+	(*GetSummaryRequest)(nil),     // 2: gastracker.v1.GetSummaryRequest
+	(*GetTransactionRequest)(nil), // 3: gastracker.v1.GetTransactionRequest
+	(*WatchDaysRequest)(nil),      // 4: gastracker.v1.WatchDaysRequest
+	(*Bucket)(nil),                // 5: gastracker.v1.Bucket
+	(*Transaction)(nil),           // 6: gastracker.v1.Transaction
+}
+var file_gastracker_v1_gastracker_proto_depIdxs = []int32{
+	5, // 0: gastracker.v1.ListDaysResponse.days:type_name -> gastracker.v1.Bucket
+	0, // 1: gastracker.v1.GasTracker.ListDays:input_type -> gastracker.v1.ListDaysRequest
+	2, // 2: gastracker.v1.GasTracker.GetSummary:input_type -> gastracker.v1.GetSummaryRequest
+	3, // 3: gastracker.v1.GasTracker.GetTransaction:input_type -> gastracker.v1.GetTransactionRequest
+	4, // 4: gastracker.v1.GasTracker.WatchDays:input_type -> gastracker.v1.WatchDaysRequest
+	1, // 5: gastracker.v1.GasTracker.ListDays:output_type -> gastracker.v1.ListDaysResponse
+	5, // 6: gastracker.v1.GasTracker.GetSummary:output_type -> gastracker.v1.Bucket
+	6, // 7: gastracker.v1.GasTracker.GetTransaction:output_type -> gastracker.v1.Transaction
+	5, // 8: gastracker.v1.GasTracker.WatchDays:output_type -> gastracker.v1.Bucket
+	5, // [5:9] is the sub-list for method output_type
+	1, // [1:5] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_gastracker_v1_gastracker_proto_init() }
+func file_gastracker_v1_gastracker_proto_init() {
+	if File_gastracker_v1_gastracker_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_gastracker_v1_gastracker_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*ListDaysRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gastracker_v1_gastracker_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*ListDaysResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gastracker_v1_gastracker_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*GetSummaryRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gastracker_v1_gastracker_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*GetTransactionRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gastracker_v1_gastracker_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*WatchDaysRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gastracker_v1_gastracker_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*Bucket); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gastracker_v1_gastracker_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*Transaction); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_gastracker_v1_gastracker_proto_msgTypes[5].OneofWrappers = []any{}
+	file_gastracker_v1_gastracker_proto_msgTypes[6].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_gastracker_v1_gastracker_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_gastracker_v1_gastracker_proto_goTypes,
+		DependencyIndexes: file_gastracker_v1_gastracker_proto_depIdxs,
+		MessageInfos:      file_gastracker_v1_gastracker_proto_msgTypes,
+	}.Build()
+	File_gastracker_v1_gastracker_proto = out.File
+	file_gastracker_v1_gastracker_proto_rawDesc = nil
+	file_gastracker_v1_gastracker_proto_goTypes = nil
+	file_gastracker_v1_gastracker_proto_depIdxs = nil
+}
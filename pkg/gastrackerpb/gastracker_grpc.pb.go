@@ -0,0 +1,269 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             (unknown)
+// source: gastracker/v1/gastracker.proto
+
+// The query surface of the REST API of `serve -db`, over gRPC. Messages
+// mirror the JSON Lines objects field for field: wei amounts are decimal
+// strings, which do not lose precision, and the ETH and Gwei doubles are for
+// convenience. Values that depend on a missing blob gas price are unset.
+
+package gastrackerpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	GasTracker_ListDays_FullMethodName       = "/gastracker.v1.GasTracker/ListDays"
+	GasTracker_GetSummary_FullMethodName     = "/gastracker.v1.GasTracker/GetSummary"
+	GasTracker_GetTransaction_FullMethodName = "/gastracker.v1.GasTracker/GetTransaction"
+	GasTracker_WatchDays_FullMethodName      = "/gastracker.v1.GasTracker/WatchDays"
+)
+
+// GasTrackerClient is the client API for GasTracker service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type GasTrackerClient interface {
+	// ListDays returns the stored days between the optional bounds.
+	ListDays(ctx context.Context, in *ListDaysRequest, opts ...grpc.CallOption) (*ListDaysResponse, error)
+	// GetSummary returns the stored days between the optional bounds combined
+	// into one bucket, whose key is the interval they cover.
+	GetSummary(ctx context.Context, in *GetSummaryRequest, opts ...grpc.CallOption) (*Bucket, error)
+	// GetTransaction returns a stored transaction, or NOT_FOUND.
+	GetTransaction(ctx context.Context, in *GetTransactionRequest, opts ...grpc.CallOption) (*Transaction, error)
+	// WatchDays sends the stored days from the given one on, then every day
+	// again whenever a run updates it.
+	WatchDays(ctx context.Context, in *WatchDaysRequest, opts ...grpc.CallOption) (GasTracker_WatchDaysClient, error)
+}
+
+type gasTrackerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGasTrackerClient(cc grpc.ClientConnInterface) GasTrackerClient {
+	return &gasTrackerClient{cc}
+}
+
+func (c *gasTrackerClient) ListDays(ctx context.Context, in *ListDaysRequest, opts ...grpc.CallOption) (*ListDaysResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListDaysResponse)
+	err := c.cc.Invoke(ctx, GasTracker_ListDays_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gasTrackerClient) GetSummary(ctx context.Context, in *GetSummaryRequest, opts ...grpc.CallOption) (*Bucket, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Bucket)
+	err := c.cc.Invoke(ctx, GasTracker_GetSummary_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gasTrackerClient) GetTransaction(ctx context.Context, in *GetTransactionRequest, opts ...grpc.CallOption) (*Transaction, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Transaction)
+	err := c.cc.Invoke(ctx, GasTracker_GetTransaction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gasTrackerClient) WatchDays(ctx context.Context, in *WatchDaysRequest, opts ...grpc.CallOption) (GasTracker_WatchDaysClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GasTracker_ServiceDesc.Streams[0], GasTracker_WatchDays_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &gasTrackerWatchDaysClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type GasTracker_WatchDaysClient interface {
+	Recv() (*Bucket, error)
+	grpc.ClientStream
+}
+
+type gasTrackerWatchDaysClient struct {
+	grpc.ClientStream
+}
+
+func (x *gasTrackerWatchDaysClient) Recv() (*Bucket, error) {
+	m := new(Bucket)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// GasTrackerServer is the server API for GasTracker service.
+// All implementations must embed UnimplementedGasTrackerServer
+// for forward compatibility
+type GasTrackerServer interface {
+	// ListDays returns the stored days between the optional bounds.
+	ListDays(context.Context, *ListDaysRequest) (*ListDaysResponse, error)
+	// GetSummary returns the stored days between the optional bounds combined
+	// into one bucket, whose key is the interval they cover.
+	GetSummary(context.Context, *GetSummaryRequest) (*Bucket, error)
+	// GetTransaction returns a stored transaction, or NOT_FOUND.
+	GetTransaction(context.Context, *GetTransactionRequest) (*Transaction, error)
+	// WatchDays sends the stored days from the given one on, then every day
+	// again whenever a run updates it.
+	WatchDays(*WatchDaysRequest, GasTracker_WatchDaysServer) error
+	mustEmbedUnimplementedGasTrackerServer()
+}
+
+// UnimplementedGasTrackerServer must be embedded to have forward compatible implementations.
+type UnimplementedGasTrackerServer struct {
+}
+
+func (UnimplementedGasTrackerServer) ListDays(context.Context, *ListDaysRequest) (*ListDaysResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDays not implemented")
+}
+func (UnimplementedGasTrackerServer) GetSummary(context.Context, *GetSummaryRequest) (*Bucket, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSummary not implemented")
+}
+func (UnimplementedGasTrackerServer) GetTransaction(context.Context, *GetTransactionRequest) (*Transaction, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTransaction not implemented")
+}
+func (UnimplementedGasTrackerServer) WatchDays(*WatchDaysRequest, GasTracker_WatchDaysServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchDays not implemented")
+}
+func (UnimplementedGasTrackerServer) mustEmbedUnimplementedGasTrackerServer() {}
+
+// UnsafeGasTrackerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GasTrackerServer will
+// result in compilation errors.
+type UnsafeGasTrackerServer interface {
+	mustEmbedUnimplementedGasTrackerServer()
+}
+
+func RegisterGasTrackerServer(s grpc.ServiceRegistrar, srv GasTrackerServer) {
+	s.RegisterService(&GasTracker_ServiceDesc, srv)
+}
+
+func _GasTracker_ListDays_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDaysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GasTrackerServer).ListDays(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GasTracker_ListDays_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GasTrackerServer).ListDays(ctx, req.(*ListDaysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GasTracker_GetSummary_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSummaryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GasTrackerServer).GetSummary(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GasTracker_GetSummary_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GasTrackerServer).GetSummary(ctx, req.(*GetSummaryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GasTracker_GetTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GasTrackerServer).GetTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GasTracker_GetTransaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GasTrackerServer).GetTransaction(ctx, req.(*GetTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GasTracker_WatchDays_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchDaysRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GasTrackerServer).WatchDays(m, &gasTrackerWatchDaysServer{ServerStream: stream})
+}
+
+type GasTracker_WatchDaysServer interface {
+	Send(*Bucket) error
+	grpc.ServerStream
+}
+
+type gasTrackerWatchDaysServer struct {
+	grpc.ServerStream
+}
+
+func (x *gasTrackerWatchDaysServer) Send(m *Bucket) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// GasTracker_ServiceDesc is the grpc.ServiceDesc for GasTracker service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var GasTracker_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gastracker.v1.GasTracker",
+	HandlerType: (*GasTrackerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListDays",
+			Handler:    _GasTracker_ListDays_Handler,
+		},
+		{
+			MethodName: "GetSummary",
+			Handler:    _GasTracker_GetSummary_Handler,
+		},
+		{
+			MethodName: "GetTransaction",
+			Handler:    _GasTracker_GetTransaction_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchDays",
+			Handler:       _GasTracker_WatchDays_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "gastracker/v1/gastracker.proto",
+}
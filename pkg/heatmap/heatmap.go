@@ -0,0 +1,146 @@
+// Package heatmap averages the gas prices that the transactions paid by hour
+// of the day and day of the week, to tell when submissions are cheapest.
+package heatmap
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+)
+
+// weekdays are the columns of the heatmap, from Monday.
+var weekdays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday}
+
+// cell sums the costs in wei and the gas of the transactions of an hour of a
+// day of the week.
+type cell struct {
+	txs          uint64
+	calldataCost *big.Int
+	calldataGas  uint64
+	blobCost     *big.Int
+	blobGas      uint64
+}
+
+// Heatmap sums the transactions by the hour and the day of the week of their
+// time in Location, UTC if nil.
+type Heatmap struct {
+	Location *time.Location
+
+	cells [7][24]cell
+}
+
+// Add adds tx to the heatmap. Blob transactions without a blob gas price
+// count towards the calldata prices only.
+func (h *Heatmap) Add(tx aggregate.Tx) {
+	loc := h.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	t := tx.Time.In(loc)
+	c := &h.cells[t.Weekday()][t.Hour()]
+	if c.calldataCost == nil {
+		c.calldataCost, c.blobCost = new(big.Int), new(big.Int)
+	}
+	c.txs++
+	c.calldataCost.Add(c.calldataCost, tx.CalldataCost)
+	c.calldataGas += tx.GasUsed
+	if tx.BlobGasPrice != nil {
+		c.blobCost.Add(c.blobCost, tx.BlobCost)
+		c.blobGas += tx.BlobGasUsed
+	}
+}
+
+// price returns the gas-weighted average price of cost over gas in Gwei, and
+// false without gas.
+func price(cost *big.Int, gas uint64) (float64, bool) {
+	if gas == 0 {
+		return 0, false
+	}
+	p := new(big.Float).Quo(new(big.Float).SetInt(cost), new(big.Float).SetUint64(gas))
+	gwei, _ := p.Quo(p, big.NewFloat(1e9)).Float64()
+	return gwei, true
+}
+
+// Slot is an hour of a day of the week and the average price paid in it.
+type Slot struct {
+	Weekday time.Weekday
+	Hour    int
+	Price   float64 // Gwei
+}
+
+func (s Slot) String() string {
+	return fmt.Sprintf("%s %02d:00 (%.4g Gwei)", s.Weekday.String()[:3], s.Hour, s.Price)
+}
+
+// Cheapest returns the hours with the lowest average calldata and blob gas
+// prices, with ok false when no transaction paid one.
+func (h *Heatmap) Cheapest() (calldata, blob Slot, calldataOK, blobOK bool) {
+	for _, d := range weekdays {
+		for hour := 0; hour < 24; hour++ {
+			c := h.cells[d][hour]
+			if c.txs == 0 {
+				continue
+			}
+			if p, ok := price(c.calldataCost, c.calldataGas); ok && (!calldataOK || p < calldata.Price) {
+				calldata, calldataOK = Slot{d, hour, p}, true
+			}
+			if p, ok := price(c.blobCost, c.blobGas); ok && (!blobOK || p < blob.Price) {
+				blob, blobOK = Slot{d, hour, p}, true
+			}
+		}
+	}
+	return calldata, blob, calldataOK, blobOK
+}
+
+// WriteCSV writes the heatmap to path as a table per price, calldata then
+// blob, of the hours by the days of the week. Hours without a price are
+// empty.
+func (h *Heatmap) WriteCSV(path string) error {
+	outFile, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+
+	writer := csv.NewWriter(outFile)
+	header := []string{"Price(Gwei)", "Hour"}
+	for _, d := range weekdays {
+		header = append(header, d.String()[:3])
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, table := range []struct {
+		name string
+		cost func(cell) (*big.Int, uint64)
+	}{
+		{"calldata", func(c cell) (*big.Int, uint64) { return c.calldataCost, c.calldataGas }},
+		{"blob", func(c cell) (*big.Int, uint64) { return c.blobCost, c.blobGas }},
+	} {
+		for hour := 0; hour < 24; hour++ {
+			record := []string{table.name, fmt.Sprintf("%02d:00", hour)}
+			for _, d := range weekdays {
+				v := ""
+				if c := h.cells[d][hour]; c.txs > 0 {
+					if p, ok := price(table.cost(c)); ok {
+						v = strconv.FormatFloat(p, 'g', 6, 64)
+					}
+				}
+				record = append(record, v)
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return outFile.Close()
+}
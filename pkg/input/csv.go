@@ -0,0 +1,527 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// csvColumns holds the indexes of the optional gas and fee columns that rich
+// exports carry. An index of -1 means the column is absent. Prices whose
+// header does not mention Gwei, and fees whose header does not mention ETH,
+// are taken as wei.
+type csvColumns struct {
+	gasUsed      int
+	gasPrice     int
+	gasPriceGwei bool
+	fee          int
+	feeEther     bool
+	blobGasUsed  int
+	blobGasPrice int
+	blobGwei     bool
+	txType       int
+	status       int
+}
+
+// CSVOptions overrides the automatic detection of CSV columns. Empty names
+// leave the column to be detected from the headers.
+type CSVOptions struct {
+	TxHashCol   string
+	DateTimeCol string
+	// SubmittedCol is the column of the time the transaction was first seen
+	// in the mempool, in any format that the datetime column may have.
+	SubmittedCol string
+	// TimeFormat is a Go time layout, "unix" or "unixms". When empty it is
+	// detected from the first row.
+	TimeFormat string
+	// Delimiter separates the fields; 0 sniffs it from the header line.
+	Delimiter rune
+	// Logger receives the messages about the input files, whatever their
+	// format; nil for slog.Default().
+	Logger *slog.Logger
+}
+
+func (o CSVOptions) logger() *slog.Logger {
+	if o.Logger == nil {
+		return slog.Default()
+	}
+	return o.Logger
+}
+
+// readCSV reads every transaction of a CSV export, passing them to emit one
+// record at a time. Etherscan's headers are recognized as well as the usual
+// variants of other exporters ("Txhash", "UnixTimestamp", "block_number",
+// ...).
+func readCSV(fileName string, opts CSVOptions, emit func(Row) error) ([]Skipped, error) {
+	file, err := openInput(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	buffered := bufio.NewReader(file)
+	delimiter := opts.Delimiter
+	if delimiter == 0 {
+		delimiter = sniffDelimiter(buffered)
+	}
+	reader := csv.NewReader(buffered)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+
+	headers, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	blockIndex, fromIndex, toIndex, methodIndex := -1, -1, -1, -1
+	for i, header := range headers {
+		switch normalizeHeader(header) {
+		case "blockno", "blocknumber", "block", "blockheight":
+			blockIndex = i
+		case "from", "fromaddress":
+			fromIndex = i
+		case "to", "toaddress":
+			toIndex = i
+		case "method", "methodid":
+			methodIndex = i
+		}
+	}
+	dateTimeIndex, err := findTimeColumn(headers, opts.DateTimeCol)
+	if err != nil {
+		return nil, err
+	}
+	if dateTimeIndex < 0 {
+		opts.logger().Info("no datetime column found; using block timestamps (set -datetime-col to choose one)", "file", fileName)
+	}
+	txHashIndex, err := findHashColumn(headers, opts.TxHashCol, opts.logger())
+	if err != nil {
+		return nil, err
+	}
+	submittedIndex, err := findSubmittedColumn(headers, opts.SubmittedCol)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		skipped         []Skipped
+		timeFormat      = opts.TimeFormat
+		submittedFormat string
+	)
+	skip := func(line int, value, reason string) {
+		skipped = append(skipped, Skipped{File: fileName, Line: line, Value: value, Reason: reason})
+	}
+	cols := findCSVColumns(headers)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			skip(parseErr.StartLine, "", parseErr.Err.Error())
+			continue
+		}
+		if err != nil {
+			return skipped, err
+		}
+		line, _ := reader.FieldPos(0)
+		if len(record) != len(headers) {
+			skip(line, strings.Join(record, string(delimiter)), fmt.Sprintf("%d fields, expected %d", len(record), len(headers)))
+			continue
+		}
+		hash, err := parseHash(record[txHashIndex])
+		if err != nil {
+			skip(line, record[txHashIndex], err.Error())
+			continue
+		}
+		var dateTime time.Time
+		if dateTimeIndex >= 0 {
+			if timeFormat == "" {
+				// Detected from the first valid row; set -time-format for
+				// formats that are not recognized.
+				if timeFormat, err = detectTimeFormat(record[dateTimeIndex]); err != nil {
+					skip(line, record[txHashIndex], err.Error())
+					continue
+				}
+			}
+			if dateTime, err = parseTime(record[dateTimeIndex], timeFormat); err != nil {
+				skip(line, record[txHashIndex], err.Error())
+				continue
+			}
+		}
+		// Rows without a valid submission time are kept without one.
+		var submitted time.Time
+		if submittedIndex >= 0 && strings.TrimSpace(record[submittedIndex]) != "" {
+			if submittedFormat == "" {
+				submittedFormat, _ = detectTimeFormat(record[submittedIndex])
+			}
+			if submittedFormat != "" {
+				submitted, _ = parseTime(record[submittedIndex], submittedFormat)
+			}
+		}
+		var block uint64
+		if blockIndex >= 0 {
+			block, _ = strconv.ParseUint(strings.TrimSpace(record[blockIndex]), 10, 64)
+		}
+		var from, to *common.Address
+		if fromIndex >= 0 && common.IsHexAddress(strings.TrimSpace(record[fromIndex])) {
+			address := common.HexToAddress(strings.TrimSpace(record[fromIndex]))
+			from = &address
+		}
+		if toIndex >= 0 && common.IsHexAddress(strings.TrimSpace(record[toIndex])) {
+			address := common.HexToAddress(strings.TrimSpace(record[toIndex]))
+			to = &address
+		}
+		var method string
+		if methodIndex >= 0 {
+			method = strings.TrimSpace(record[methodIndex])
+		}
+		row := Row{
+			Line:       line,
+			Hash:       hash,
+			Time:       dateTime,
+			Block:      block,
+			From:       from,
+			To:         to,
+			Method:     method,
+			Submitted:  submitted,
+			CSVReceipt: csvReceipt(record, cols),
+		}
+		if err := emit(row); err != nil {
+			return skipped, err
+		}
+	}
+	return skipped, nil
+}
+
+// delimiters are the field separators recognized by sniffDelimiter.
+var delimiters = []rune{',', '\t', ';', '|'}
+
+// sniffDelimiter guesses the field separator from the header line: the
+// delimiter occurring most often outside quotes, or a comma.
+func sniffDelimiter(r *bufio.Reader) rune {
+	line, _ := r.Peek(r.Size())
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	counts := make(map[rune]int)
+	quoted := false
+	for _, c := range string(line) {
+		if c == '"' {
+			quoted = !quoted
+		} else if !quoted {
+			counts[c]++
+		}
+	}
+	best := ','
+	for _, d := range delimiters {
+		if counts[d] > counts[best] {
+			best = d
+		}
+	}
+	return best
+}
+
+// ParseDelimiter parses a delimiter setting: a single character or "tab".
+// The empty string yields 0, which sniffs the delimiter.
+func ParseDelimiter(value string) (rune, error) {
+	switch value {
+	case "":
+		return 0, nil
+	case "tab", `\t`:
+		return '\t', nil
+	}
+	if r := []rune(value); len(r) == 1 && r[0] != '"' && r[0] != '\r' && r[0] != '\n' {
+		return r[0], nil
+	}
+	return 0, fmt.Errorf("invalid delimiter %q", value)
+}
+
+// timeHeaders lists the datetime column names of known exporters, most
+// specific first.
+var timeHeaders = []string{
+	"datetime(utc)", "datetime", "timestamp(utc)", "blocktimestamp", "timestamp",
+	"unixtimestamp", "blocktime", "date(utc)", "date", "time",
+}
+
+// findTimeColumn returns the index of the datetime column, the column called
+// name when it is set, or -1 when the CSV has none.
+func findTimeColumn(headers []string, name string) (int, error) {
+	if name != "" {
+		for i, header := range headers {
+			if normalizeHeader(header) == normalizeHeader(name) {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("datetime column %q not found in %q", name, headers)
+	}
+	for _, candidate := range timeHeaders {
+		for i, header := range headers {
+			if normalizeHeader(header) == candidate {
+				return i, nil
+			}
+		}
+	}
+	return -1, nil
+}
+
+// submittedHeaders lists the names of the mempool submission time columns of
+// known exporters.
+var submittedHeaders = []string{"firstseen", "firstseenat", "submitted", "submittedat", "mempooltime", "mempooltimestamp", "pendingsince"}
+
+// findSubmittedColumn returns the index of the submission time column, the
+// column called name when it is set, or -1 when the CSV has none.
+func findSubmittedColumn(headers []string, name string) (int, error) {
+	candidates := submittedHeaders
+	if name != "" {
+		candidates = []string{normalizeHeader(name)}
+	}
+	for _, candidate := range candidates {
+		for i, header := range headers {
+			if normalizeHeader(header) == candidate {
+				return i, nil
+			}
+		}
+	}
+	if name != "" {
+		return 0, fmt.Errorf("submission time column %q not found in %q", name, headers)
+	}
+	return -1, nil
+}
+
+// timeLayouts are the datetime formats recognized by detectTimeFormat, in
+// the order they are tried.
+var timeLayouts = []string{
+	"2006-01-02 15:04:05",
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"01/02/2006 15:04:05",
+	"01/02/2006 15:04",
+	"01/02/2006",
+	"Jan-02-2006 03:04:05 PM -0700",
+	"Jan-02-2006 03:04:05 PM MST",
+}
+
+// detectTimeFormat returns the layout of value: "unix" or "unixms" for
+// numeric timestamps, otherwise the first of timeLayouts that parses it.
+func detectTimeFormat(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		// Millisecond timestamps have 13 digits until the year 2286.
+		if len(value) >= 13 {
+			return "unixms", nil
+		}
+		return "unix", nil
+	}
+	for _, layout := range timeLayouts {
+		if _, err := time.Parse(layout, normalizeTime(value)); err == nil {
+			return layout, nil
+		}
+	}
+	return "", fmt.Errorf("unrecognized datetime %q", value)
+}
+
+// parseTime parses value in the given format, as returned by
+// detectTimeFormat. Datetimes without a zone are taken as UTC.
+func parseTime(value, format string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	switch format {
+	case "unix", "unixms":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid Unix timestamp %q", value)
+		}
+		if format == "unixms" {
+			return time.UnixMilli(n).UTC(), nil
+		}
+		return time.Unix(n, 0).UTC(), nil
+	}
+	t, err := time.Parse(format, normalizeTime(value))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid datetime %q for format %q", value, format)
+	}
+	return t.UTC(), nil
+}
+
+// normalizeTime rewrites Etherscan's "+UTC" zone suffix, as in
+// "Jul-03-2024 12:04:36 AM +UTC", into a parsable one.
+func normalizeTime(value string) string {
+	return strings.Replace(value, "+UTC", "UTC", 1)
+}
+
+func findHashColumn(headers []string, name string, log *slog.Logger) (int, error) {
+	var candidates []int
+	for i, header := range headers {
+		normalized := normalizeHeader(header)
+		if name != "" {
+			if normalized == normalizeHeader(name) {
+				return i, nil
+			}
+			continue
+		}
+		if strings.Contains(normalized, "hash") && !strings.Contains(normalized, "block") && !strings.Contains(normalized, "parent") {
+			candidates = append(candidates, i)
+		}
+	}
+	if name != "" {
+		return 0, fmt.Errorf("transaction hash column %q not found in %q", name, headers)
+	}
+	if len(candidates) == 0 {
+		return 0, fmt.Errorf("no transaction hash column found in %q", headers)
+	}
+	chosen := candidates[0]
+	for _, i := range candidates {
+		if normalized := normalizeHeader(headers[i]); normalized == "transactionhash" || normalized == "txhash" || strings.Contains(normalized, "l1") {
+			chosen = i
+			break
+		}
+	}
+	if len(candidates) > 1 {
+		log.Info("several hash columns; set -txhash-col to choose another", "column", headers[chosen])
+	}
+	return chosen, nil
+}
+
+// normalizeHeader lowercases a header and strips whitespace, quotes and the
+// UTF-8 byte order mark so that cosmetic differences between exports match.
+func normalizeHeader(header string) string {
+	header = strings.TrimPrefix(header, "\ufeff")
+	header = strings.NewReplacer("_", "", "-", "").Replace(strings.Trim(header, `"`))
+	return strings.ToLower(strings.Join(strings.Fields(header), ""))
+}
+
+// findCSVColumns locates the optional gas and fee columns of rich exports.
+func findCSVColumns(headers []string) csvColumns {
+	cols := csvColumns{gasUsed: -1, gasPrice: -1, fee: -1, blobGasUsed: -1, blobGasPrice: -1, txType: -1, status: -1}
+	for i, header := range headers {
+		name := normalizeHeader(header)
+		switch {
+		case strings.HasPrefix(name, "blobgasused"):
+			cols.blobGasUsed = i
+		case strings.HasPrefix(name, "blobgasprice"):
+			cols.blobGasPrice = i
+			cols.blobGwei = strings.Contains(name, "gwei")
+		case strings.HasPrefix(name, "gasused"):
+			cols.gasUsed = i
+		case strings.HasPrefix(name, "gasprice"):
+			cols.gasPrice = i
+			cols.gasPriceGwei = strings.Contains(name, "gwei")
+		case strings.Contains(name, "usd"):
+			continue
+		case strings.HasPrefix(name, "txnfee"), strings.HasPrefix(name, "transactionfee"):
+			cols.fee = i
+			cols.feeEther = strings.Contains(name, "eth")
+		case name == "txntype", name == "type", name == "transactiontype":
+			cols.txType = i
+		case name == "status", name == "txreceiptstatus":
+			cols.status = i
+		}
+	}
+	return cols
+}
+
+// csvReceipt builds a receipt from the CSV gas columns of a record. It returns
+// nil when the record lacks what is needed to compute its cost, including
+// whether it carried blobs, so that the caller falls back to RPC.
+func csvReceipt(record []string, cols csvColumns) *types.Receipt {
+	gasUsed, ok := parseColumn(record, cols.gasUsed, 0)
+	if !ok {
+		return nil
+	}
+	receipt := &types.Receipt{Type: types.DynamicFeeTxType, GasUsed: gasUsed.Uint64(), Status: csvStatus(record, cols.status)}
+
+	switch {
+	case cols.blobGasUsed >= 0 && cols.blobGasPrice >= 0:
+		// A blob transaction whose blob gas or price is blank or invalid
+		// would lose its blob cost; only rows without blob gas need no price.
+		blobGasUsed, ok := parseColumn(record, cols.blobGasUsed, 0)
+		if !ok {
+			return nil
+		}
+		if blobGasUsed.Sign() > 0 {
+			blobGasPrice, ok := parseColumn(record, cols.blobGasPrice, gweiDecimals(cols.blobGwei))
+			if !ok {
+				return nil
+			}
+			receipt.Type = types.BlobTxType
+			receipt.BlobGasUsed = blobGasUsed.Uint64()
+			receipt.BlobGasPrice = blobGasPrice
+		}
+	case cols.txType >= 0:
+		txType := strings.TrimSpace(record[cols.txType])
+		if txType == "" || txType == "3" || strings.Contains(strings.ToLower(txType), "blob") {
+			return nil
+		}
+		// Legacy and access-list transactions keep their type; the others
+		// count as dynamic-fee transactions.
+		if n, err := strconv.ParseUint(txType, 0, 8); err == nil && n <= types.AccessListTxType {
+			receipt.Type = uint8(n)
+		}
+	default:
+		return nil
+	}
+
+	if price, ok := parseColumn(record, cols.gasPrice, gweiDecimals(cols.gasPriceGwei)); ok {
+		receipt.EffectiveGasPrice = price
+		return receipt
+	}
+	decimals := 0
+	if cols.feeEther {
+		decimals = 18
+	}
+	fee, ok := parseColumn(record, cols.fee, decimals)
+	if !ok || receipt.Type == types.BlobTxType || gasUsed.Sign() == 0 {
+		return nil
+	}
+	receipt.EffectiveGasPrice = fee.Div(fee, gasUsed)
+	return receipt
+}
+
+// csvStatus returns the receipt status of a record: failed when its status
+// column reads 0 or, as in Etherscan exports, names an error, and successful
+// otherwise, including without a status column.
+func csvStatus(record []string, index int) uint64 {
+	if index < 0 || index >= len(record) {
+		return types.ReceiptStatusSuccessful
+	}
+	status := strings.ToLower(strings.TrimSpace(record[index]))
+	if status == "0" || strings.Contains(status, "error") || strings.Contains(status, "fail") {
+		return types.ReceiptStatusFailed
+	}
+	return types.ReceiptStatusSuccessful
+}
+
+// parseColumn parses a decimal column value scaled by 10^decimals into an
+// integer. It reports false when the column is absent or not a number.
+func parseColumn(record []string, index int, decimals int) (*big.Int, bool) {
+	if index < 0 || index >= len(record) {
+		return nil, false
+	}
+	value, ok := new(big.Rat).SetString(strings.ReplaceAll(strings.TrimSpace(record[index]), ",", ""))
+	if !ok || value.Sign() < 0 {
+		return nil, false
+	}
+	value.Mul(value, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)))
+	return new(big.Int).Quo(value.Num(), value.Denom()), true
+}
+
+func gweiDecimals(gwei bool) int {
+	if gwei {
+		return 9
+	}
+	return 0
+}
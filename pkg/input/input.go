@@ -0,0 +1,421 @@
+// Package input reads the transactions to analyze from CSV exports, plain
+// hash lists and JSON files.
+package input
+
+import (
+	"archive/zip"
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Row is one transaction read from the input.
+type Row struct {
+	Index int
+	Line  int // line in the input file (entry number for JSON arrays)
+	Hash  common.Hash
+	Time  time.Time // zero when the input has no timestamp
+	Block uint64    // block number when known, 0 otherwise
+	// To is the recipient of the transaction when known.
+	To *common.Address
+	// From is the sender of the transaction when known.
+	From *common.Address
+	// Method is the method the transaction calls when known: the hex selector
+	// of its input, or the name given by an Etherscan export.
+	Method string
+	// Nonce is the nonce of the transaction when known.
+	Nonce *uint64
+	// GasPrice is the gas price offered by the transaction, in wei, when the
+	// input has it, e.g. for transactions replaced before being mined.
+	GasPrice *big.Int
+	// BaseFee is the base fee per gas of the block, in wei, when the fetcher
+	// was asked for it.
+	BaseFee *big.Int
+	// MeasuredBlobs is the number of blobs of the transaction read from a
+	// beacon node, and BlobPayloadBytes the bytes of data they carry.
+	MeasuredBlobs    uint64
+	BlobPayloadBytes uint64
+	// FrameBytes is the size of the channel data of the batcher frames that
+	// the transaction submits, when the fetcher was asked for its frames.
+	FrameBytes uint64
+	// PostedBytes is the size of the data the transaction posts, its
+	// calldata or the payload of its blobs, when the fetcher was asked for
+	// it.
+	PostedBytes uint64
+	// ChannelBytes is the compressed size of the channels that the
+	// transaction completes and RawBytes the size of their batches, when
+	// their frames were decoded.
+	ChannelBytes uint64
+	RawBytes     uint64
+	// L2Blocks and L2Txs count the L2 blocks and transactions of the batches
+	// of those channels.
+	L2Blocks uint64
+	L2Txs    uint64
+	// WhatIfCost is what a batcher transaction would have cost in wei
+	// posting its data as calldata instead of blobs, or the reverse, when
+	// the fetcher was asked for it.
+	WhatIfCost *big.Int
+	// OracleTip is the priority tip per gas, in wei, that a fee oracle would
+	// have suggested for the block of the transaction, when the fetcher was
+	// asked for it.
+	OracleTip *big.Int
+	// MarketTip is the median priority tip per gas, in wei, paid in the block
+	// of the transaction, when the fetcher was asked for it.
+	MarketTip *big.Int
+	// FloorGas is the EIP-7623 floor of the gas of a calldata transaction,
+	// 21000 plus 10 per token of its calldata, and FloorActive whether its
+	// block enforced it, when the fetcher was asked for them.
+	FloorGas    uint64
+	FloorActive bool
+	// Submitted is when the transaction was first seen in the mempool, zero
+	// unless the input has it.
+	Submitted time.Time
+	// CSVReceipt is built from the gas columns of rich CSV exports and used
+	// when the CSV is trusted. It is nil when the columns are incomplete.
+	CSVReceipt *types.Receipt
+}
+
+// Files expands a comma-separated list of paths and glob patterns into
+// the files to read. "-" stands for stdin.
+func Files(spec string) ([]string, error) {
+	var files []string
+	for _, pattern := range strings.Split(spec, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if pattern == "-" || !strings.ContainsAny(pattern, "*?[") {
+			files = append(files, pattern)
+			continue
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("%s: no matching files", pattern)
+		}
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no input file configured")
+	}
+	return files, nil
+}
+
+// Skipped is an input row rejected by validation.
+type Skipped struct {
+	File   string
+	Line   int
+	Value  string
+	Reason string
+}
+
+// Read reads every file and concatenates their transactions, dropping
+// repeated hashes so that overlapping exports and duplicated rows are counted
+// once. Rejected rows are returned for the skipped rows report.
+func Read(files []string, format string, opts CSVOptions) ([]Row, []Skipped, error) {
+	var rows []Row
+	skipped, err := Stream(files, format, opts, func(row Row) error {
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return rows, skipped, nil
+}
+
+// Stream reads every file like Read but passes the transactions to emit as
+// they are read, numbered in order, instead of holding them, so that inputs
+// of any size are read with only their hashes kept in memory. It stops at the
+// first error of emit. JSON arrays are decoded one element at a time.
+func Stream(files []string, format string, opts CSVOptions, emit func(Row) error) ([]Skipped, error) {
+	var skipped []Skipped
+	type origin struct {
+		file string
+		line int
+	}
+	seen := make(map[common.Hash]origin)
+	index := 0
+	for _, fileName := range files {
+		read, duplicates := 0, 0
+		fileSkipped, err := readFile(fileName, format, opts, func(row Row) error {
+			read++
+			if first, ok := seen[row.Hash]; ok {
+				duplicates++
+				skipped = append(skipped, Skipped{
+					File:   fileName,
+					Line:   row.Line,
+					Value:  row.Hash.Hex(),
+					Reason: fmt.Sprintf("duplicate of %s:%d", first.file, first.line),
+				})
+				return nil
+			}
+			seen[row.Hash] = origin{fileName, row.Line}
+			row.Index = index
+			index++
+			return emit(row)
+		})
+		skipped = append(skipped, fileSkipped...)
+		if err != nil {
+			return skipped, err
+		}
+		if len(files) > 1 || duplicates > 0 || len(fileSkipped) > 0 {
+			opts.logger().Info("read input", "file", fileName, "transactions", read-duplicates, "duplicates", duplicates, "invalid", len(fileSkipped))
+		}
+	}
+	return skipped, nil
+}
+
+func readFile(fileName, format string, opts CSVOptions, emit func(Row) error) ([]Skipped, error) {
+	switch format := Format(fileName, format); format {
+	case "csv":
+		return readCSV(fileName, opts, emit)
+	case "hashes":
+		return readHashes(fileName, emit)
+	case "json", "jsonl":
+		return readJSON(fileName, format, emit)
+	default:
+		return nil, fmt.Errorf("unknown input format %q", format)
+	}
+}
+
+// parseHash parses a 0x-prefixed 32-byte transaction hash.
+func parseHash(value string) (common.Hash, error) {
+	value = strings.TrimSpace(value)
+	hash, err := hexutil.Decode(value)
+	if err != nil || len(hash) != common.HashLength {
+		return common.Hash{}, fmt.Errorf("invalid transaction hash %q", value)
+	}
+	return common.BytesToHash(hash), nil
+}
+
+// openInput opens fileName for reading, or stdin when it is "-". Files ending
+// in .gz are gunzipped and .zip archives, which must hold a single file, are
+// extracted on the fly.
+func openInput(fileName string) (io.ReadCloser, error) {
+	if fileName == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".gz":
+		file, err := os.Open(fileName)
+		if err != nil {
+			return nil, err
+		}
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("%s: %w", fileName, err)
+		}
+		return &archiveReader{Reader: gz, closers: []io.Closer{gz, file}}, nil
+	case ".zip":
+		archive, err := zip.OpenReader(fileName)
+		if err != nil {
+			return nil, err
+		}
+		var entries []*zip.File
+		for _, entry := range archive.File {
+			if !entry.FileInfo().IsDir() {
+				entries = append(entries, entry)
+			}
+		}
+		if len(entries) != 1 {
+			archive.Close()
+			return nil, fmt.Errorf("%s: expected a single file in the archive, found %d", fileName, len(entries))
+		}
+		entry, err := entries[0].Open()
+		if err != nil {
+			archive.Close()
+			return nil, err
+		}
+		return &archiveReader{Reader: entry, closers: []io.Closer{entry, archive}}, nil
+	}
+	return os.Open(fileName)
+}
+
+// archiveReader reads a decompressed stream and closes it along with the
+// underlying file.
+type archiveReader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r *archiveReader) Close() error {
+	var errs []error
+	for _, c := range r.closers {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// TrimCompression strips a .gz or .zip extension from fileName, so that
+// "export.csv.gz" is read as "export.csv".
+func TrimCompression(fileName string) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".gz", ".zip":
+		return strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	}
+	return fileName
+}
+
+// Format returns the format of fileName: the explicit format when set,
+// otherwise one derived from the file extension.
+func Format(fileName, format string) string {
+	if format != "" {
+		return format
+	}
+	switch strings.ToLower(filepath.Ext(TrimCompression(fileName))) {
+	case ".txt", ".hashes":
+		return "hashes"
+	case ".json":
+		return "json"
+	case ".jsonl", ".ndjson":
+		return "jsonl"
+	}
+	return "csv"
+}
+
+// readHashes reads a newline-separated list of transaction hashes. Blank lines
+// and lines starting with # are skipped. The rows carry no timestamp; it is
+// taken from the block of each receipt.
+func readHashes(fileName string, emit func(Row) error) ([]Skipped, error) {
+	file, err := openInput(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var skipped []Skipped
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		hash, err := parseHash(text)
+		if err != nil {
+			skipped = append(skipped, Skipped{File: fileName, Line: line, Value: text, Reason: err.Error()})
+			continue
+		}
+		if err := emit(Row{Line: line, Hash: hash}); err != nil {
+			return skipped, err
+		}
+	}
+	return skipped, scanner.Err()
+}
+
+// jsonTx is one transaction of a JSON or JSON Lines input. The timestamp may
+// be Unix seconds or an RFC 3339 / "2006-01-02 15:04:05" string; without it
+// the block time is used. The sender, the recipient, the time the
+// transaction was first seen in the mempool, in the same formats, the nonce
+// and the gas price in wei are optional.
+type jsonTx struct {
+	Hash      string          `json:"hash"`
+	Timestamp json.RawMessage `json:"timestamp"`
+	From      *common.Address `json:"from"`
+	To        *common.Address `json:"to"`
+	Submitted json.RawMessage `json:"submitted"`
+	Nonce     *uint64         `json:"nonce"`
+	GasPrice  *big.Int        `json:"gasPrice"`
+}
+
+// readJSON reads a JSON array (format "json") or one object per line (format
+// "jsonl") of {hash, timestamp} objects.
+func readJSON(fileName, format string, emit func(Row) error) ([]Skipped, error) {
+	file, err := openInput(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var skipped []Skipped
+	add := func(tx jsonTx, line int) error {
+		hash, err := parseHash(tx.Hash)
+		if err != nil {
+			skipped = append(skipped, Skipped{File: fileName, Line: line, Value: tx.Hash, Reason: err.Error()})
+			return nil
+		}
+		t, err := parseJSONTime(tx.Timestamp)
+		if err != nil {
+			skipped = append(skipped, Skipped{File: fileName, Line: line, Value: tx.Hash, Reason: err.Error()})
+			return nil
+		}
+		submitted, _ := parseJSONTime(tx.Submitted)
+		return emit(Row{Line: line, Hash: hash, Time: t, From: tx.From, To: tx.To, Submitted: submitted,
+			Nonce: tx.Nonce, GasPrice: tx.GasPrice})
+	}
+
+	if format == "json" {
+		// The elements of the array are decoded one at a time.
+		decoder := json.NewDecoder(file)
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fileName, err)
+		}
+		if token != json.Delim('[') {
+			return nil, fmt.Errorf("%s: expected a JSON array", fileName)
+		}
+		for entry := 1; decoder.More(); entry++ {
+			var tx jsonTx
+			if err := decoder.Decode(&tx); err != nil {
+				return skipped, fmt.Errorf("%s: %w", fileName, err)
+			}
+			if err := add(tx, entry); err != nil {
+				return skipped, err
+			}
+		}
+		return skipped, nil
+	}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var tx jsonTx
+		if err := json.Unmarshal(scanner.Bytes(), &tx); err != nil {
+			skipped = append(skipped, Skipped{File: fileName, Line: line, Value: text, Reason: err.Error()})
+			continue
+		}
+		if err := add(tx, line); err != nil {
+			return skipped, err
+		}
+	}
+	return skipped, scanner.Err()
+}
+
+func parseJSONTime(raw json.RawMessage) (time.Time, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return time.Time{}, nil
+	}
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		value = string(raw)
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.Parse("2006-01-02 15:04:05", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %s", raw)
+	}
+	return t, nil
+}
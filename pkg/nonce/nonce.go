@@ -0,0 +1,215 @@
+// Package nonce follows the nonces of the transactions of every sender to find
+// the gaps in their sequence and the transactions replaced before being mined,
+// such as the fee bumps of a stuck batcher transaction.
+package nonce
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math/big"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/input"
+)
+
+// key is a nonce of a sender.
+type key struct {
+	from  common.Address
+	nonce uint64
+}
+
+// unmined is a transaction of the input that has no receipt.
+type unmined struct {
+	hash     common.Hash
+	gasPrice *big.Int // nil unless the input has it
+}
+
+// Tracker collects the nonces of the mined transactions and of the
+// transactions of the input left without a receipt.
+type Tracker struct {
+	mined   map[key]aggregate.Tx
+	unmined map[key][]unmined
+}
+
+// Add adds a mined transaction. Transactions without a sender or a nonce are
+// ignored.
+func (t *Tracker) Add(tx aggregate.Tx) {
+	if tx.From == nil || tx.Nonce == nil {
+		return
+	}
+	if t.mined == nil {
+		t.mined = make(map[key]aggregate.Tx)
+	}
+	t.mined[key{*tx.From, *tx.Nonce}] = tx
+}
+
+// AddUnmined adds a transaction whose receipt was not found, which may have
+// been replaced by another of the same nonce. It needs the sender and the
+// nonce of the input, as the transaction itself is usually gone, and reports
+// whether row has them.
+func (t *Tracker) AddUnmined(row input.Row) bool {
+	if row.From == nil || row.Nonce == nil {
+		return false
+	}
+	if t.unmined == nil {
+		t.unmined = make(map[key][]unmined)
+	}
+	k := key{*row.From, *row.Nonce}
+	t.unmined[k] = append(t.unmined[k], unmined{row.Hash, row.GasPrice})
+	return true
+}
+
+// Gap is a range of nonces of a sender without a mined transaction in the
+// input, between two that have one.
+type Gap struct {
+	From        common.Address
+	First, Last uint64
+}
+
+// Replacement is a mined transaction that replaced others of the same nonce.
+type Replacement struct {
+	From     common.Address
+	Nonce    uint64
+	Hash     common.Hash
+	Replaced []common.Hash
+	// ExtraCost is what the replacement paid in wei above the lowest gas
+	// price offered by the transactions it replaced, for its gas used. It is
+	// nil when the input gives none of their gas prices.
+	ExtraCost *big.Int
+}
+
+// Report is the analysis of the nonces of the senders.
+type Report struct {
+	Senders      int
+	Gaps         []Gap
+	Missing      uint64 // nonces in the gaps
+	Replacements []Replacement
+	// ExtraCost sums the known extra costs of the replacements in wei, of
+	// which Unpriced lack one.
+	ExtraCost *big.Int
+	Unpriced  int
+}
+
+// Replaced reports whether hash is a transaction replaced by another.
+func (r Report) Replaced(hash common.Hash) bool {
+	for _, replacement := range r.Replacements {
+		if slices.Contains(replacement.Replaced, hash) {
+			return true
+		}
+	}
+	return false
+}
+
+// Report finds the gaps in the nonces of every sender and the replaced
+// transactions. Gaps and replacements are sorted by sender and nonce.
+func (t *Tracker) Report() Report {
+	report := Report{ExtraCost: new(big.Int)}
+	nonces := make(map[common.Address][]uint64)
+	for k := range t.mined {
+		nonces[k.from] = append(nonces[k.from], k.nonce)
+	}
+	report.Senders = len(nonces)
+	for from, list := range nonces {
+		slices.Sort(list)
+		for i := 1; i < len(list); i++ {
+			if list[i] > list[i-1]+1 {
+				report.Gaps = append(report.Gaps, Gap{From: from, First: list[i-1] + 1, Last: list[i] - 1})
+				report.Missing += list[i] - list[i-1] - 1
+			}
+		}
+	}
+	for k, txs := range t.unmined {
+		tx, ok := t.mined[k]
+		if !ok {
+			continue
+		}
+		replacement := Replacement{From: k.from, Nonce: k.nonce, Hash: tx.Hash}
+		var lowest *big.Int
+		for _, u := range txs {
+			replacement.Replaced = append(replacement.Replaced, u.hash)
+			if u.gasPrice != nil && (lowest == nil || u.gasPrice.Cmp(lowest) < 0) {
+				lowest = u.gasPrice
+			}
+		}
+		if lowest != nil && tx.GasPrice != nil {
+			extra := new(big.Int).Sub(tx.GasPrice, lowest)
+			if extra.Sign() < 0 {
+				extra.SetInt64(0)
+			}
+			replacement.ExtraCost = extra.Mul(extra, new(big.Int).SetUint64(tx.GasUsed))
+			report.ExtraCost.Add(report.ExtraCost, replacement.ExtraCost)
+		} else {
+			report.Unpriced++
+		}
+		report.Replacements = append(report.Replacements, replacement)
+	}
+	slices.SortFunc(report.Gaps, func(a, b Gap) int {
+		return compare(a.From, a.First, b.From, b.First)
+	})
+	slices.SortFunc(report.Replacements, func(a, b Replacement) int {
+		return compare(a.From, a.Nonce, b.From, b.Nonce)
+	})
+	return report
+}
+
+// compare orders nonces by sender, then nonce.
+func compare(fromA common.Address, a uint64, fromB common.Address, b uint64) int {
+	if c := bytes.Compare(fromA[:], fromB[:]); c != 0 {
+		return c
+	}
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// WriteCSV writes the gaps, then the replacements, of r to path. The replaced
+// transactions are separated by spaces, and the extra cost is empty when
+// unknown.
+func (r Report) WriteCSV(path string) error {
+	outFile, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+
+	writer := csv.NewWriter(outFile)
+	header := []string{"Kind", "From", "First Nonce", "Last Nonce", "Transaction Hash", "Replaced Transactions", "Extra Cost(wei)"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, gap := range r.Gaps {
+		record := []string{"gap", gap.From.Hex(), strconv.FormatUint(gap.First, 10), strconv.FormatUint(gap.Last, 10), "", "", ""}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	for _, replacement := range r.Replacements {
+		replaced := make([]string, len(replacement.Replaced))
+		for i, hash := range replacement.Replaced {
+			replaced[i] = hash.Hex()
+		}
+		nonce := strconv.FormatUint(replacement.Nonce, 10)
+		record := []string{"replacement", replacement.From.Hex(), nonce, nonce, replacement.Hash.Hex(), strings.Join(replaced, " "), ""}
+		if replacement.ExtraCost != nil {
+			record[6] = replacement.ExtraCost.String()
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return outFile.Close()
+}
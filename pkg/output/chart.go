@@ -0,0 +1,114 @@
+package output
+
+import (
+	"bytes"
+	"math"
+	"time"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+)
+
+// Series is a column of a report to chart. NaN values, such as those
+// unavailable for lack of a blob gas price, are left out.
+type Series struct {
+	Name   string
+	Values []float64
+}
+
+// chartSizeW and chartSizeH are the size of the rendered charts.
+const chartSizeW, chartSizeH = 8 * vg.Inch, 4 * vg.Inch
+
+// WriteChart renders series as lines over the buckets with the given keys to
+// path. The format, PNG or SVG, follows the extension of path.
+func WriteChart(path, title, unit string, keys []string, series ...Series) error {
+	p, err := newChart(title, unit, keys, series)
+	if err != nil {
+		return err
+	}
+	return p.Save(chartSizeW, chartSizeH, path)
+}
+
+// ChartPNG renders the chart of WriteChart as a PNG image.
+func ChartPNG(title, unit string, keys []string, series ...Series) ([]byte, error) {
+	p, err := newChart(title, unit, keys, series)
+	if err != nil {
+		return nil, err
+	}
+	w, err := p.WriterTo(chartSizeW, chartSizeH, "png")
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if _, err := w.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// newChart plots series as lines over the buckets with the given keys.
+func newChart(title, unit string, keys []string, series []Series) (*plot.Plot, error) {
+	p := plot.New()
+	p.Title.Text = title
+	p.Y.Label.Text = unit
+	p.Y.Min = 0
+	p.X.Tick.Marker = plot.TimeTicks{Format: "2006-01-02"}
+	p.Add(plotter.NewGrid())
+	p.Legend.Top = true
+
+	times := make([]float64, len(keys))
+	for i, k := range keys {
+		t, err := aggregate.BucketStart(k)
+		if err != nil {
+			return nil, err
+		}
+		times[i] = float64(t.Unix())
+	}
+	for i, s := range series {
+		// A line cannot span a missing value, so each run of values gets its
+		// own, all of the series' color.
+		var segment plotter.XYs
+		var first *plotter.Line
+		draw := func() error {
+			if len(segment) == 0 {
+				return nil
+			}
+			line, points, err := plotter.NewLinePoints(segment)
+			if err != nil {
+				return err
+			}
+			line.Color, points.Color = plotutil.Color(i), plotutil.Color(i)
+			points.Shape = plotutil.Shape(i)
+			p.Add(line, points)
+			if first == nil {
+				first = line
+			}
+			segment = nil
+			return nil
+		}
+		for j, v := range s.Values {
+			if math.IsNaN(v) {
+				if err := draw(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			segment = append(segment, plotter.XY{X: times[j], Y: v})
+		}
+		if err := draw(); err != nil {
+			return nil, err
+		}
+		if first != nil && len(series) > 1 {
+			p.Legend.Add(s.Name, first)
+		}
+	}
+	if len(keys) == 1 {
+		// Give a single bucket some room on either side.
+		p.X.Min, p.X.Max = times[0]-float64(12*time.Hour/time.Second), times[0]+float64(12*time.Hour/time.Second)
+	}
+	return p, nil
+}
@@ -0,0 +1,108 @@
+package output
+
+import (
+	"encoding/csv"
+	"math/big"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+)
+
+// csvTxHeader is the header of the per-transaction CSV table. Amounts are in
+// wei, as in the JSON Lines, with the cost also in ETH.
+var csvTxHeader = []string{
+	"Transaction Hash",
+	"Block",
+	"Time",
+	"Bucket",
+	"Type",
+	"Gas Used",
+	"Effective Gas Price(wei)",
+	"Blob Gas Used",
+	"Blob Gas Price(wei)",
+	"Cost(wei)",
+	"Calldata Cost(wei)",
+	"Blob Cost(wei)",
+	"Cost(ETH)",
+	"L2 Blocks",
+	"L2 Txs",
+	"Calldata Pricing",
+}
+
+// CSVTxWriter writes the per-transaction CSV table, one row per transaction.
+// Every row is flushed as soon as it is written, so that the file can be
+// tailed.
+type CSVTxWriter struct {
+	file   *os.File
+	writer *csv.Writer
+}
+
+// CreateCSVTx creates the per-transaction table at path, or appends to it when
+// appending is set, e.g. to continue the rows of a resumed run.
+func CreateCSVTx(path string, appending bool) (*CSVTxWriter, error) {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if appending {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	file, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	w := &CSVTxWriter{file: file, writer: csv.NewWriter(file)}
+	info, err := file.Stat()
+	if err == nil && info.Size() == 0 {
+		err = w.write(csvTxHeader)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// WriteTx writes the row of one transaction.
+func (w *CSVTxWriter) WriteTx(tx aggregate.Tx) error {
+	cost, _ := new(big.Float).Quo(new(big.Float).SetInt(tx.Cost), big.NewFloat(params.Ether)).Float64()
+	record := []string{
+		tx.Hash.Hex(),
+		strconv.FormatUint(tx.Block, 10),
+		tx.Time.UTC().Format(time.RFC3339),
+		tx.Bucket,
+		strconv.FormatUint(uint64(tx.Type), 10),
+		strconv.FormatUint(tx.GasUsed, 10),
+		tx.GasPrice.String(),
+		strconv.FormatUint(tx.BlobGasUsed, 10),
+		"",
+		tx.Cost.String(),
+		tx.CalldataCost.String(),
+		tx.BlobCost.String(),
+		strconv.FormatFloat(cost, 'g', 10, 64),
+		"",
+		"",
+		tx.CalldataPricing,
+	}
+	if tx.BlobGasPrice != nil {
+		record[8] = tx.BlobGasPrice.String()
+	}
+	if tx.L2Blocks != nil && tx.L2Txs != nil {
+		record[13], record[14] = strconv.FormatUint(*tx.L2Blocks, 10), strconv.FormatUint(*tx.L2Txs, 10)
+	}
+	return w.write(record)
+}
+
+func (w *CSVTxWriter) write(record []string) error {
+	if err := w.writer.Write(record); err != nil {
+		return err
+	}
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// Close closes the file.
+func (w *CSVTxWriter) Close() error {
+	return w.file.Close()
+}
@@ -0,0 +1,120 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+)
+
+// Format sets how csv reports write the costs, in ETH by default, and the gas
+// prices, in Gwei by default.
+type Format struct {
+	CostUnit     string // eth or gwei
+	GasPriceUnit string // eth, gwei or wei
+	// Decimals is the number of digits after the decimal point, in fixed
+	// notation. When negative, amounts have ten significant digits and
+	// may use scientific notation, e.g. 6.770786304e-09.
+	Decimals int
+}
+
+// DefaultFormat is the format of WriteCSV.
+var DefaultFormat = Format{CostUnit: "eth", GasPriceUnit: "gwei", Decimals: -1}
+
+// units are the sizes of the units in wei and their names in column headers.
+var units = map[string]struct {
+	wei   float64
+	label string
+}{
+	"eth":  {params.Ether, "ETH"},
+	"gwei": {params.GWei, "Gwei"},
+	"wei":  {params.Wei, "wei"},
+}
+
+// ParseUnits sets the units of f from a comma-separated list of column=unit
+// pairs, e.g. cost=gwei,gas-price=wei, where the columns are cost, in eth or
+// gwei, and gas-price, in eth, gwei or wei.
+func (f *Format) ParseUnits(list string) error {
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		column, unit, ok := strings.Cut(item, "=")
+		unit = strings.ToLower(strings.TrimSpace(unit))
+		if !ok {
+			return fmt.Errorf("%q is not column=unit", item)
+		}
+		if _, known := units[unit]; !known {
+			return fmt.Errorf("%s: unknown unit %q, want eth, gwei or wei", column, unit)
+		}
+		switch strings.TrimSpace(column) {
+		case "cost":
+			// The exact costs in wei have columns of their own.
+			if unit == "wei" {
+				return errors.New("cost: the costs are already in wei in the (wei) columns; want eth or gwei")
+			}
+			f.CostUnit = unit
+		case "gas-price":
+			f.GasPriceUnit = unit
+		default:
+			return fmt.Errorf("unknown column %q, want cost or gas-price", column)
+		}
+	}
+	return nil
+}
+
+// header returns the column header h, given in the default units, in the
+// units of f.
+func (f Format) header(h string) string {
+	return strings.NewReplacer(
+		"(ETH)", "("+units[f.CostUnit].label+")",
+		"(Gwei)", "("+units[f.GasPriceUnit].label+")",
+	).Replace(h)
+}
+
+// scale returns the factor converting amounts from unit from to unit to.
+func scale(from, to string) float64 {
+	return units[from].wei / units[to].wei
+}
+
+// text formats v, given in ETH or Gwei, in unit to.
+func (f Format) text(v *big.Float, from, to string) string {
+	x := new(big.Float).SetPrec(max(v.Prec(), 128)).Mul(v, big.NewFloat(scale(from, to)))
+	if f.Decimals < 0 {
+		return x.Text('g', 10)
+	}
+	return x.Text('f', f.Decimals)
+}
+
+// floatText formats v, given in ETH or Gwei, in unit to.
+func (f Format) floatText(v float64, from, to string) string {
+	v *= scale(from, to)
+	if f.Decimals < 0 {
+		return strconv.FormatFloat(v, 'g', 10, 64)
+	}
+	return strconv.FormatFloat(v, 'f', f.Decimals, 64)
+}
+
+// cost formats the cost v in ETH of r, unavailable when r lacks a blob gas
+// price.
+func (f Format) cost(r *aggregate.Result, v *big.Float) string {
+	if r.BlobPriceMissing > 0 {
+		return aggregate.Unavailable
+	}
+	return f.text(v, "eth", f.CostUnit)
+}
+
+// gasPrice formats the gas price v in Gwei of r, unavailable when blob is set
+// and r lacks a blob gas price.
+func (f Format) gasPrice(r *aggregate.Result, v *big.Float, blob bool) string {
+	if blob && r.BlobPriceMissing > 0 {
+		return aggregate.Unavailable
+	}
+	return f.text(v, "gwei", f.GasPriceUnit)
+}
@@ -0,0 +1,280 @@
+package output
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+)
+
+// The charts are drawn as inline SVG so that the report is a single file that
+// opens offline. Hovering a point or bar shows its value.
+const (
+	chartWidth  = 960
+	chartHeight = 320
+	chartLeft   = 80 // room for the y axis labels
+	chartRight  = 20
+	chartTop    = 20
+	chartBottom = 50 // room for the x axis labels
+	chartTicks  = 5
+	chartLabels = 12 // maximum number of x axis labels
+)
+
+// chartSeries is one line, or one layer of stacked bars, of a chart. NaN
+// values are gaps.
+type chartSeries struct {
+	Name   string
+	Color  string
+	Values []float64
+}
+
+type chart struct {
+	Title   string
+	Unit    string
+	Stacked bool // draw stacked bars instead of lines
+	Labels  []string
+	Series  []chartSeries
+}
+
+// svg renders c.
+func (c chart) svg() template.HTML {
+	plotW := float64(chartWidth - chartLeft - chartRight)
+	plotH := float64(chartHeight - chartTop - chartBottom)
+	n := len(c.Labels)
+
+	// The y axis starts at zero and ends at the largest value or stack.
+	top := 0.0
+	for i := 0; i < n; i++ {
+		stack := 0.0
+		for _, s := range c.Series {
+			if v := s.Values[i]; !math.IsNaN(v) {
+				if c.Stacked {
+					stack += v
+				} else {
+					top = math.Max(top, v)
+				}
+			}
+		}
+		top = math.Max(top, stack)
+	}
+	if top == 0 {
+		top = 1
+	}
+	x := func(i int) float64 { return chartLeft + plotW*(float64(i)+0.5)/float64(n) }
+	y := func(v float64) float64 { return chartTop + plotH*(1-v/top) }
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg viewBox="0 0 %d %d" role="img" aria-label="%s">`, chartWidth, chartHeight, template.HTMLEscapeString(c.Title))
+	for i := 0; i <= chartTicks; i++ {
+		v := top * float64(i) / chartTicks
+		fmt.Fprintf(&b, `<line class="grid" x1="%d" x2="%d" y1="%.1f" y2="%.1f"/>`, chartLeft, chartWidth-chartRight, y(v), y(v))
+		fmt.Fprintf(&b, `<text class="axis" x="%d" y="%.1f" text-anchor="end">%s</text>`, chartLeft-6, y(v)+4, formatTick(v))
+	}
+	step := (n + chartLabels - 1) / chartLabels
+	for i := 0; i < n; i += step {
+		fmt.Fprintf(&b, `<text class="axis" x="%.1f" y="%d" text-anchor="middle">%s</text>`, x(i), chartHeight-chartBottom+18, template.HTMLEscapeString(c.Labels[i]))
+	}
+
+	if c.Stacked {
+		barW := 0.7 * plotW / float64(n)
+		for i := 0; i < n; i++ {
+			base := 0.0
+			for _, s := range c.Series {
+				v := s.Values[i]
+				if math.IsNaN(v) || v == 0 {
+					continue
+				}
+				fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"><title>%s %s: %s %s</title></rect>`,
+					x(i)-barW/2, y(base+v), barW, y(base)-y(base+v), s.Color,
+					template.HTMLEscapeString(c.Labels[i]), template.HTMLEscapeString(s.Name), formatValue(v), c.Unit)
+				base += v
+			}
+		}
+	} else {
+		for _, s := range c.Series {
+			var points []string
+			flush := func() {
+				if len(points) > 1 {
+					fmt.Fprintf(&b, `<polyline fill="none" stroke="%s" stroke-width="2" points="%s"/>`, s.Color, strings.Join(points, " "))
+				}
+				points = points[:0]
+			}
+			for i, v := range s.Values {
+				if math.IsNaN(v) {
+					flush()
+					continue
+				}
+				points = append(points, fmt.Sprintf("%.1f,%.1f", x(i), y(v)))
+			}
+			flush()
+			for i, v := range s.Values {
+				if !math.IsNaN(v) {
+					fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="4" fill="%s"><title>%s %s: %s %s</title></circle>`,
+						x(i), y(v), s.Color, template.HTMLEscapeString(c.Labels[i]), template.HTMLEscapeString(s.Name), formatValue(v), c.Unit)
+				}
+			}
+		}
+	}
+	for i, s := range c.Series {
+		lx := chartLeft + 160*i
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="12" height="12" fill="%s"/><text class="legend" x="%d" y="%d">%s</text>`,
+			lx, chartHeight-16, s.Color, lx+16, chartHeight-6, template.HTMLEscapeString(s.Name))
+	}
+	b.WriteString("</svg>")
+	return template.HTML(b.String())
+}
+
+func formatTick(v float64) string {
+	return template.HTMLEscapeString(fmt.Sprintf("%.3g", v))
+}
+
+func formatValue(v float64) string {
+	return fmt.Sprintf("%.6g", v)
+}
+
+var htmlReport = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2em auto; max-width: 1000px; color: #222; }
+h2 { font-size: 1.1em; margin-top: 2em; }
+svg { width: 100%; height: auto; }
+.grid { stroke: #e5e5e5; }
+.axis, .legend { font-size: 12px; fill: #555; }
+rect:hover, circle:hover { opacity: 0.7; }
+.summary { display: flex; gap: 2em; }
+.summary div { background: #f4f7fb; padding: 0.8em 1.2em; border-radius: 6px; }
+.summary b { display: block; font-size: 1.4em; }
+table { border-collapse: collapse; width: 100%; font-size: 0.9em; }
+th, td { padding: 0.3em 0.6em; border-bottom: 1px solid #eee; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+tfoot td { font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="summary">
+<div>Total cost<b>{{.Total.Cost}} ETH</b></div>
+<div>Transactions<b>{{.Total.TxCount}}</b></div>
+<div>Blended gas price<b>{{.Total.Blended}} Gwei</b></div>
+</div>
+{{range .Charts}}<h2>{{.Title}} ({{.Unit}})</h2>
+{{.SVG}}
+{{end}}<h2>Data</h2>
+<table>
+<thead><tr><th>{{.Key}}</th><th>Cost (ETH)</th><th>Calldata (ETH)</th><th>Blob (ETH)</th><th>Avg calldata gas price (Gwei)</th><th>Avg blob gas price (Gwei)</th><th>Blended gas price (Gwei)</th><th>Gas used</th><th>Txs</th></tr></thead>
+<tbody>
+{{range .Rows}}<tr><td>{{.Key}}</td><td>{{.Cost}}</td><td>{{.Calldata}}</td><td>{{.Blob}}</td><td>{{.AvgCalldata}}</td><td>{{.AvgBlob}}</td><td>{{.Blended}}</td><td>{{.GasUsed}}</td><td>{{.TxCount}}</td></tr>
+{{end}}</tbody>
+<tfoot><tr><td>Total</td><td>{{.Total.Cost}}</td><td>{{.Total.Calldata}}</td><td>{{.Total.Blob}}</td><td>{{.Total.AvgCalldata}}</td><td>{{.Total.AvgBlob}}</td><td>{{.Total.Blended}}</td><td>{{.Total.GasUsed}}</td><td>{{.Total.TxCount}}</td></tr></tfoot>
+</table>
+</body>
+</html>
+`))
+
+type htmlChart struct {
+	Title, Unit string
+	SVG         template.HTML
+}
+
+// htmlRow is a row of the table, formatted like the Markdown report.
+type htmlRow struct {
+	Key                           string
+	Cost, Calldata, Blob          string
+	AvgCalldata, AvgBlob, Blended string
+	GasUsed, TxCount              uint64
+}
+
+func newHTMLRow(key string, r *aggregate.Result) htmlRow {
+	return htmlRow{
+		Key:         key,
+		Cost:        blobDependentText(r, r.Cost, eth),
+		Calldata:    eth(r.CalldataCost),
+		Blob:        blobDependentText(r, r.BlobCost, eth),
+		AvgCalldata: gwei(r.AvgCallDataGasPrice),
+		AvgBlob:     blobDependentText(r, r.AvgBlobGasPrice, gwei),
+		Blended:     blobDependentText(r, r.BlendedGasPrice, gwei),
+		GasUsed:     r.TotalGasUsed,
+		TxCount:     r.TxCount,
+	}
+}
+
+// eth and gwei format amounts for reading rather than further processing.
+func eth(v *big.Float) string  { return v.Text('f', 6) }
+func gwei(v *big.Float) string { return v.Text('g', 4) }
+
+// blobDependentText formats v, or reports it as aggregate.Unavailable when a
+// blob gas price of r is missing.
+func blobDependentText(r *aggregate.Result, v *big.Float, format func(*big.Float) string) string {
+	if r.BlobPriceMissing > 0 {
+		return aggregate.Unavailable
+	}
+	return format(v)
+}
+
+// WriteHTML writes the report to path as a self-contained HTML page with
+// charts of the cost, its calldata and blob shares and the gas prices,
+// followed by the table of buckets and their total.
+func WriteHTML(path, title, granularity string, dates []string, results map[string]*aggregate.Result, total *aggregate.Result) error {
+	series := func(name, color string, value func(r *aggregate.Result) *big.Float, blobDependent bool) chartSeries {
+		s := chartSeries{Name: name, Color: color, Values: make([]float64, len(dates))}
+		for i, k := range dates {
+			r := results[k]
+			if blobDependent && r.BlobPriceMissing > 0 {
+				s.Values[i] = math.NaN()
+				continue
+			}
+			s.Values[i], _ = value(r).Float64()
+		}
+		return s
+	}
+	charts := []chart{
+		{Title: "Cost", Unit: "ETH", Labels: dates, Series: []chartSeries{
+			series("Total", "#2b6cb0", func(r *aggregate.Result) *big.Float { return r.Cost }, true),
+		}},
+		{Title: "Calldata vs blob cost", Unit: "ETH", Stacked: true, Labels: dates, Series: []chartSeries{
+			series("Calldata", "#dd6b20", func(r *aggregate.Result) *big.Float { return r.CalldataCost }, false),
+			series("Blob", "#38a169", func(r *aggregate.Result) *big.Float { return r.BlobCost }, true),
+		}},
+		{Title: "Average calldata gas price", Unit: "Gwei", Labels: dates, Series: []chartSeries{
+			series("Calldata", "#dd6b20", func(r *aggregate.Result) *big.Float { return r.AvgCallDataGasPrice }, false),
+			series("Blended", "#2b6cb0", func(r *aggregate.Result) *big.Float { return r.BlendedGasPrice }, true),
+		}},
+		{Title: "Average blob gas price", Unit: "Gwei", Labels: dates, Series: []chartSeries{
+			series("Blob", "#38a169", func(r *aggregate.Result) *big.Float { return r.AvgBlobGasPrice }, true),
+		}},
+	}
+
+	key := bucketHeader(granularity)
+	data := struct {
+		Title  string
+		Key    string
+		Charts []htmlChart
+		Rows   []htmlRow
+		Total  htmlRow
+	}{Title: title, Key: key, Total: newHTMLRow("Total", total)}
+	if len(dates) > 0 {
+		for _, c := range charts {
+			data.Charts = append(data.Charts, htmlChart{c.Title, c.Unit, c.svg()})
+		}
+	}
+	for _, k := range dates {
+		data.Rows = append(data.Rows, newHTMLRow(k, results[k]))
+	}
+
+	outFile, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+	if err := htmlReport.Execute(outFile, data); err != nil {
+		return err
+	}
+	return outFile.Close()
+}
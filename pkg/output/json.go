@@ -0,0 +1,188 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+)
+
+// jsonReport is the layout of the JSON report. Amounts are given both as
+// decimal strings in wei, which do not lose precision in JSON parsers, and as
+// floats in ETH or Gwei for convenience. Values that depend on a missing blob
+// gas price are null.
+type jsonReport struct {
+	Metadata    *Metadata              `json:"metadata,omitempty"`
+	Granularity string                 `json:"granularity"`
+	Buckets     map[string]*jsonResult `json:"buckets"`
+	Total       *jsonResult            `json:"total"`
+}
+
+type jsonResult struct {
+	Bucket                  string   `json:"bucket,omitempty"` // JSON Lines only
+	CostWei                 *string  `json:"costWei"`
+	CostEth                 *float64 `json:"costEth"`
+	CalldataCostWei         string   `json:"calldataCostWei"`
+	CalldataCostEth         float64  `json:"calldataCostEth"`
+	BlobCostWei             *string  `json:"blobCostWei"`
+	BlobCostEth             *float64 `json:"blobCostEth"`
+	AvgCalldataGasPriceWei  string   `json:"avgCalldataGasPriceWei"`
+	AvgCalldataGasPriceGwei float64  `json:"avgCalldataGasPriceGwei"`
+	AvgBlobGasPriceWei      *string  `json:"avgBlobGasPriceWei"`
+	AvgBlobGasPriceGwei     *float64 `json:"avgBlobGasPriceGwei"`
+	BlendedGasPriceWei      *string  `json:"blendedGasPriceWei"`
+	BlendedGasPriceGwei     *float64 `json:"blendedGasPriceGwei"`
+	// The percentiles of the gas prices in Gwei, keyed "p50", "p90" and
+	// "p99".
+	CalldataGasPricePercentilesGwei map[string]float64 `json:"calldataGasPricePercentilesGwei"`
+	BlobGasPricePercentilesGwei     map[string]float64 `json:"blobGasPricePercentilesGwei"`
+	// The transactions with the lowest and highest gas price and cost, null
+	// when unknown.
+	MinGasPriceTx     *jsonExtreme `json:"minGasPriceTx"`
+	MaxGasPriceTx     *jsonExtreme `json:"maxGasPriceTx"`
+	MinCostTx         *jsonExtreme `json:"minCostTx"`
+	MaxCostTx         *jsonExtreme `json:"maxCostTx"`
+	CalldataGasUsed   uint64       `json:"calldataGasUsed"`
+	BlobGasUsed       uint64       `json:"blobGasUsed"`
+	GasUsed           uint64       `json:"gasUsed"`
+	TxCount           uint64       `json:"txCount"`
+	BlobTxCount       uint64       `json:"blobTxCount"`
+	BlobCount         uint64       `json:"blobCount"`
+	BlobsPerTx        float64      `json:"blobsPerTx"`
+	CostPerBlobEth    *float64     `json:"costPerBlobEth"`
+	SuccessfulTxCount uint64       `json:"successfulTxCount"`
+	RevertedTxCount   uint64       `json:"revertedTxCount"`
+	SuccessfulCostEth *float64     `json:"successfulCostEth"`
+	RevertedCostWei   *string      `json:"revertedCostWei"`
+	RevertedCostEth   *float64     `json:"revertedCostEth"`
+	BlobPriceMissing  uint64       `json:"blobPriceMissing"`
+}
+
+// jsonExtreme is an extreme transaction of a bucket with its gas price in Gwei
+// or its cost in ETH, left out when it depends on a missing blob gas price.
+type jsonExtreme struct {
+	Hash         string   `json:"hash"`
+	GasPriceGwei *float64 `json:"gasPriceGwei,omitempty"`
+	CostEth      *float64 `json:"costEth,omitempty"`
+}
+
+// WriteJSON writes the per-bucket report and the grand total to path as JSON,
+// keyed by bucket, headed by the metadata of the run if not nil.
+func WriteJSON(path string, meta *Metadata, granularity string, dates []string, results map[string]*aggregate.Result, total *aggregate.Result) error {
+	report := newJSONReport(granularity, dates, results, total)
+	report.Metadata = meta
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// EncodeJSON returns the report of WriteJSON in compact form.
+func EncodeJSON(granularity string, dates []string, results map[string]*aggregate.Result, total *aggregate.Result) ([]byte, error) {
+	return json.Marshal(newJSONReport(granularity, dates, results, total))
+}
+
+func newJSONReport(granularity string, dates []string, results map[string]*aggregate.Result, total *aggregate.Result) jsonReport {
+	report := jsonReport{
+		Granularity: granularity,
+		Buckets:     make(map[string]*jsonResult, len(dates)),
+		Total:       newJSONResult(total),
+	}
+	for _, k := range dates {
+		report.Buckets[k] = newJSONResult(results[k])
+	}
+	return report
+}
+
+func newJSONResult(r *aggregate.Result) *jsonResult {
+	calldataCostWei, calldataCostEth := weiAmount(r.CalldataCostWei, r.CalldataCost)
+	avgCalldataWei, avgCalldataGwei := amount(r.AvgCallDataGasPrice, params.GWei)
+	v := &jsonResult{
+		CalldataCostWei:         calldataCostWei,
+		CalldataCostEth:         calldataCostEth,
+		AvgCalldataGasPriceWei:  avgCalldataWei,
+		AvgCalldataGasPriceGwei: avgCalldataGwei,
+		CalldataGasUsed:         r.TotalCalldataGasUsed,
+		BlobGasUsed:             r.TotalBlobGasUsed,
+		GasUsed:                 r.TotalGasUsed,
+		TxCount:                 r.TxCount,
+		BlobTxCount:             r.BlobTxCount,
+		BlobCount:               r.Blobs(),
+		BlobsPerTx:              r.BlobsPerTx(),
+		SuccessfulTxCount:       r.SuccessfulTxCount(),
+		RevertedTxCount:         r.RevertedTxCount,
+		BlobPriceMissing:        r.BlobPriceMissing,
+	}
+	if r.BlobPriceMissing == 0 {
+		v.CostWei, v.CostEth = weiAmountPtr(r.CostWei, r.Cost)
+		v.BlobCostWei, v.BlobCostEth = weiAmountPtr(r.BlobCostWei, r.BlobCost)
+		v.AvgBlobGasPriceWei, v.AvgBlobGasPriceGwei = amountPtr(r.AvgBlobGasPrice, params.GWei)
+		v.BlendedGasPriceWei, v.BlendedGasPriceGwei = amountPtr(r.BlendedGasPrice, params.GWei)
+		if c := r.CostPerBlob(); c != nil {
+			v.CostPerBlobEth = floatPtr(c)
+		}
+		v.SuccessfulCostEth = floatPtr(r.SuccessfulCost())
+		v.RevertedCostWei, v.RevertedCostEth = weiAmountPtr(r.RevertedCostWei, r.RevertedCost)
+	}
+	v.CalldataGasPricePercentilesGwei = percentileMap(r, false)
+	v.BlobGasPricePercentilesGwei = percentileMap(r, true)
+	for i, dst := range []**jsonExtreme{&v.MinGasPriceTx, &v.MaxGasPriceTx, &v.MinCostTx, &v.MaxCostTx} {
+		e := extremes[i]
+		x := e.tx(r)
+		if x == nil {
+			continue
+		}
+		*dst = &jsonExtreme{Hash: x.Hash.Hex()}
+		if e.unit == "ETH" {
+			(*dst).CostEth = e.value(r)
+		} else {
+			(*dst).GasPriceGwei = e.value(r)
+		}
+	}
+	return v
+}
+
+// percentileMap returns the gas price percentiles of r keyed "p50" etc., or
+// nil when they are unavailable.
+func percentileMap(r *aggregate.Result, blob bool) map[string]float64 {
+	var m map[string]float64
+	for _, p := range aggregate.Percentiles {
+		if v := percentile(r, p, blob); v != nil {
+			if m == nil {
+				m = make(map[string]float64, len(aggregate.Percentiles))
+			}
+			m[fmt.Sprintf("p%d", p)] = *v
+		}
+	}
+	return m
+}
+
+// amount returns value, given in units of unit wei, as a wei string rounded to
+// an integer and as a float.
+func amount(value *big.Float, unit float64) (string, float64) {
+	wei := new(big.Float).Mul(value, big.NewFloat(unit))
+	f, _ := value.Float64()
+	return wei.Text('f', 0), f
+}
+
+// weiAmount returns the exact amount wei as a wei string and its value in
+// ETH, eth, as a float.
+func weiAmount(wei *big.Int, eth *big.Float) (string, float64) {
+	f, _ := eth.Float64()
+	return wei.String(), f
+}
+
+func weiAmountPtr(wei *big.Int, eth *big.Float) (*string, *float64) {
+	s, f := weiAmount(wei, eth)
+	return &s, &f
+}
+
+func amountPtr(value *big.Float, unit float64) (*string, *float64) {
+	wei, f := amount(value, unit)
+	return &wei, &f
+}
@@ -0,0 +1,118 @@
+package output
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+)
+
+// JSONLWriter writes a report as JSON Lines, one object per bucket or per
+// transaction. Every line is written to the file as soon as it is encoded, so
+// that other processes can tail it.
+type JSONLWriter struct {
+	file *os.File
+	enc  *json.Encoder
+}
+
+// jsonTx is the JSON Lines object of one transaction. Amounts are wei strings
+// as in the JSON report.
+type jsonTx struct {
+	Hash            common.Hash `json:"hash"`
+	Time            time.Time   `json:"time"`
+	Block           uint64      `json:"block"`
+	Bucket          string      `json:"bucket"`
+	Type            uint8       `json:"type"`
+	GasUsed         uint64      `json:"gasUsed"`
+	GasPriceWei     string      `json:"gasPriceWei"`
+	BlobGasUsed     uint64      `json:"blobGasUsed"`
+	BlobGasPriceWei *string     `json:"blobGasPriceWei"`
+	CostWei         string      `json:"costWei"`
+	CostEth         float64     `json:"costEth"`
+	CalldataCostWei string      `json:"calldataCostWei"`
+	BlobCostWei     string      `json:"blobCostWei"`
+	L2Blocks        *uint64     `json:"l2Blocks,omitempty"`
+	L2Txs           *uint64     `json:"l2Txs,omitempty"`
+	CalldataPricing string      `json:"calldataPricing,omitempty"`
+}
+
+// CreateJSONL creates the file at path, or appends to it when appending is
+// set, e.g. to continue the lines of a resumed run.
+func CreateJSONL(path string, appending bool) (*JSONLWriter, error) {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if appending {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	file, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONLWriter{file: file, enc: json.NewEncoder(file)}, nil
+}
+
+// WriteTx writes the line of one transaction.
+func (w *JSONLWriter) WriteTx(tx aggregate.Tx) error {
+	return w.enc.Encode(newJSONTx(tx))
+}
+
+// WriteBuckets writes one line per bucket, in the order of dates.
+func (w *JSONLWriter) WriteBuckets(dates []string, results map[string]*aggregate.Result) error {
+	for _, k := range dates {
+		if err := w.enc.Encode(newBucketResult(k, results[k])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *JSONLWriter) Close() error {
+	return w.file.Close()
+}
+
+// EncodeTx returns the JSON Lines object of a transaction, without the line
+// break.
+func EncodeTx(tx aggregate.Tx) ([]byte, error) {
+	return json.Marshal(newJSONTx(tx))
+}
+
+// EncodeBucket returns the JSON Lines object of a bucket, without the line
+// break.
+func EncodeBucket(key string, r *aggregate.Result) ([]byte, error) {
+	return json.Marshal(newBucketResult(key, r))
+}
+
+func newBucketResult(key string, r *aggregate.Result) *jsonResult {
+	v := newJSONResult(r)
+	v.Bucket = key
+	return v
+}
+
+func newJSONTx(tx aggregate.Tx) jsonTx {
+	v := jsonTx{
+		Hash:            tx.Hash,
+		Time:            tx.Time,
+		Block:           tx.Block,
+		Bucket:          tx.Bucket,
+		Type:            tx.Type,
+		GasUsed:         tx.GasUsed,
+		GasPriceWei:     tx.GasPrice.String(),
+		BlobGasUsed:     tx.BlobGasUsed,
+		CostWei:         tx.Cost.String(),
+		CalldataCostWei: tx.CalldataCost.String(),
+		BlobCostWei:     tx.BlobCost.String(),
+		L2Blocks:        tx.L2Blocks,
+		L2Txs:           tx.L2Txs,
+		CalldataPricing: tx.CalldataPricing,
+	}
+	v.CostEth, _ = new(big.Float).Quo(new(big.Float).SetInt(tx.Cost), big.NewFloat(params.Ether)).Float64()
+	if tx.BlobGasPrice != nil {
+		price := tx.BlobGasPrice.String()
+		v.BlobGasPriceWei = &price
+	}
+	return v
+}
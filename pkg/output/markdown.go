@@ -0,0 +1,72 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+)
+
+// PrintMarkdown prints the per-bucket report as a Markdown table, sorted by
+// bucket and ending in a bold row of total, for pasting into GitHub or
+// Notion. The extra columns are left empty in the total row.
+func PrintMarkdown(w io.Writer, granularity string, dates []string, results map[string]*aggregate.Result, total *aggregate.Result, extra ...Column) error {
+	key := bucketHeader(granularity)
+	var b strings.Builder
+	fmt.Fprintf(&b, "| %s | Cost (ETH) | Calldata (ETH) | Blob (ETH) | Avg calldata gas price (Gwei) | Avg blob gas price (Gwei) | Blended gas price (Gwei) | Gas used | Txs |", key)
+	for _, c := range extra {
+		fmt.Fprintf(&b, " %s |", c.Header)
+	}
+	b.WriteString("\n|---|---:|---:|---:|---:|---:|---:|---:|---:|" + strings.Repeat("---:|", len(extra)) + "\n")
+	for _, k := range dates {
+		writeMarkdownRow(&b, k, results[k], extra, k)
+	}
+	if len(dates) > 0 {
+		writeMarkdownRow(&b, "**Total**", total, extra, "")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// bucketHeader names the bucket column of a report of the given granularity.
+func bucketHeader(granularity string) string {
+	switch granularity {
+	case "week":
+		return "Week"
+	case "hour":
+		return "Hour"
+	case "month":
+		return "Month"
+	case "block":
+		return "Block"
+	}
+	return "Date"
+}
+
+// WriteMarkdown writes the table of PrintMarkdown to path.
+func WriteMarkdown(path, granularity string, dates []string, results map[string]*aggregate.Result, total *aggregate.Result, extra ...Column) error {
+	outFile, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+	if err := PrintMarkdown(outFile, granularity, dates, results, total, extra...); err != nil {
+		return err
+	}
+	return outFile.Close()
+}
+
+// writeMarkdownRow writes the row of r, with the values of the extra columns
+// for bucket.
+func writeMarkdownRow(b *strings.Builder, key string, r *aggregate.Result, extra []Column, bucket string) {
+	fmt.Fprintf(b, "| %s | %s | %s | %s | %s | %s | %s | %d | %d |",
+		key, blobDependentText(r, r.Cost, eth), eth(r.CalldataCost), blobDependentText(r, r.BlobCost, eth),
+		gwei(r.AvgCallDataGasPrice), blobDependentText(r, r.AvgBlobGasPrice, gwei), blobDependentText(r, r.BlendedGasPrice, gwei),
+		r.TotalGasUsed, r.TxCount)
+	for _, c := range extra {
+		fmt.Fprintf(b, " %s |", c.Values[bucket])
+	}
+	b.WriteString("\n")
+}
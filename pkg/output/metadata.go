@@ -0,0 +1,54 @@
+package output
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// SchemaVersion is the version of the layout of the reports. It is raised
+// whenever a column or field is renamed or removed or changes meaning, so
+// that consumers can detect it; new columns and fields do not raise it.
+const SchemaVersion = 1
+
+// Metadata describes the run that wrote a report, so that consumers can
+// detect format changes and reproduce the run. The JSON report carries it as
+// its "metadata" field, the other formats in a .meta.json file next to them.
+type Metadata struct {
+	SchemaVersion int       `json:"schemaVersion"`
+	ToolVersion   string    `json:"toolVersion"`
+	GeneratedAt   time.Time `json:"generatedAt"`
+	Name          string    `json:"name"`
+	Format        string    `json:"format"`
+	Granularity   string    `json:"granularity"`
+	ChainID       uint64    `json:"chainId,omitempty"`
+	Network       string    `json:"network,omitempty"`
+	// RPC lists the L1 endpoints without their path and query, which often
+	// hold an API key.
+	RPC    []string    `json:"rpc,omitempty"`
+	Inputs []InputFile `json:"inputs,omitempty"`
+	// Args are the flags of the run, with the values of secrets left out.
+	Args         []string `json:"args"`
+	Transactions int      `json:"transactions"`
+	Failed       int      `json:"failed"`
+	// FromIndexer counts the receipts fetched through an indexer for lack of
+	// them on the RPC endpoints.
+	FromIndexer int `json:"fromIndexer,omitempty"`
+	Interrupted int `json:"interrupted,omitempty"` // left for a resumed run
+}
+
+// InputFile is an input file of a run with the SHA-256 of its content, empty
+// for stdin.
+type InputFile struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// WriteMetadata writes m to path as JSON.
+func WriteMetadata(path string, m Metadata) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
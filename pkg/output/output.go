@@ -0,0 +1,341 @@
+// Package output writes aggregated reports and the companion lists of
+// skipped and failed transactions.
+package output
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/fetch"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/input"
+)
+
+// PrintSummary prints every bucket followed by the grand total to w.
+func PrintSummary(w io.Writer, dates []string, results map[string]*aggregate.Result, total *aggregate.Result) {
+	for _, k := range dates {
+		r := results[k]
+		fmt.Fprintf(w, "%s: cost %s ETH (calldata %v, blob %s), gas price %v Gwei calldata, %s Gwei blob, %d txs\n",
+			k, r.BlobDependent(r.Cost), r.CalldataCost, r.BlobDependent(r.BlobCost),
+			r.AvgCallDataGasPrice, r.BlobDependent(r.AvgBlobGasPrice), r.TxCount)
+	}
+	fmt.Fprintf(w, "Total: cost %s ETH (calldata %v, blob %s), blended gas price %s Gwei, %d txs\n",
+		total.BlobDependent(total.Cost), total.CalldataCost, total.BlobDependent(total.BlobCost),
+		total.BlobDependent(total.BlendedGasPrice), total.TxCount)
+	if total.RevertedTxCount > 0 {
+		fmt.Fprintf(w, "Reverted: %d txs, cost %s ETH\n", total.RevertedTxCount, total.BlobDependent(total.RevertedCost))
+	}
+}
+
+// Column is an additional report column, such as the remaining budget, with
+// its value per bucket.
+type Column struct {
+	Header string
+	Values map[string]string
+}
+
+// WriteCSV writes the per-bucket report to path, followed by the extra
+// columns, and the row of total, the total over all buckets, in which the
+// extra columns are left empty.
+func WriteCSV(path string, dates []string, results map[string]*aggregate.Result, total *aggregate.Result, extra ...Column) error {
+	return WriteCSVFormat(path, DefaultFormat, dates, results, total, extra...)
+}
+
+// WriteCSVFormat is WriteCSV with the amounts in format. The extra columns
+// are written as they are.
+func WriteCSVFormat(path string, format Format, dates []string, results map[string]*aggregate.Result, total *aggregate.Result, extra ...Column) error {
+	outFile, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+
+	writer := csv.NewWriter(outFile)
+
+	header := []string{
+		"DateTime",
+		"Total Cost(ETH)",
+		"Avg Calldata gas price(Gwei)",
+		"Avg Blob Gas Price(Gwei)",
+		"Total Calldata Gas Used",
+		"Total Blob Gas Used",
+		"Total Gas Used(calldata + blob)",
+		"Transaction Count",
+		"Blended Gas Price(Gwei)",
+		"Blob Count",
+		"Blobs per Blob Tx",
+		"Cost per Blob(ETH)",
+		"Successful Txs",
+		"Reverted Txs",
+		"Successful Cost(ETH)",
+		"Reverted Cost(ETH)",
+		"Total Cost(wei)",
+		"Calldata Cost(ETH)",
+		"Calldata Cost(wei)",
+		"Blob Cost(ETH)",
+		"Blob Cost(wei)",
+		"Reverted Cost(wei)",
+	}
+	for _, blob := range []bool{false, true} {
+		for _, p := range aggregate.Percentiles {
+			header = append(header, percentileName(p, blob)+" Gas Price(Gwei)")
+		}
+	}
+	for _, e := range extremes {
+		header = append(header, e.name+"("+e.unit+")", e.name+" Tx")
+	}
+	for i, h := range header {
+		header[i] = format.header(h)
+	}
+	for _, c := range extra {
+		header = append(header, c.Header)
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, k := range dates {
+		if err := writer.Write(csvRecord(format, k, results[k], extra, k)); err != nil {
+			return err
+		}
+	}
+	if len(dates) > 0 {
+		if err := writer.Write(csvRecord(format, "Total", total, extra, "")); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return outFile.Close()
+}
+
+// csvRecord returns the CSV record of v in format f, with the values of the
+// extra columns for bucket.
+func csvRecord(f Format, key string, v *aggregate.Result, extra []Column, bucket string) []string {
+	costPerBlob := ""
+	if c := v.CostPerBlob(); c != nil {
+		costPerBlob = f.cost(v, c)
+	}
+	record := []string{
+		key,
+		f.cost(v, v.Cost),
+		f.gasPrice(v, v.AvgCallDataGasPrice, false),
+		f.gasPrice(v, v.AvgBlobGasPrice, true),
+		strconv.FormatUint(v.TotalCalldataGasUsed, 10),
+		strconv.FormatUint(v.TotalBlobGasUsed, 10),
+		strconv.FormatUint(v.TotalGasUsed, 10),
+		strconv.FormatUint(v.TxCount, 10),
+		f.gasPrice(v, v.BlendedGasPrice, true),
+		strconv.FormatUint(v.Blobs(), 10),
+		strconv.FormatFloat(v.BlobsPerTx(), 'g', 10, 64),
+		costPerBlob,
+		strconv.FormatUint(v.SuccessfulTxCount(), 10),
+		strconv.FormatUint(v.RevertedTxCount, 10),
+		f.cost(v, v.SuccessfulCost()),
+		f.cost(v, v.RevertedCost),
+		blobDependentWei(v, v.CostWei),
+		f.text(v.CalldataCost, "eth", f.CostUnit),
+		v.CalldataCostWei.String(),
+		f.cost(v, v.BlobCost),
+		blobDependentWei(v, v.BlobCostWei),
+		blobDependentWei(v, v.RevertedCostWei),
+	}
+	for _, blob := range []bool{false, true} {
+		for _, p := range aggregate.Percentiles {
+			text := percentileText(v, p, blob)
+			if price := percentile(v, p, blob); price != nil {
+				text = f.floatText(*price, "gwei", f.GasPriceUnit)
+			}
+			record = append(record, text)
+		}
+	}
+	for _, e := range extremes {
+		value, hash := e.text(v)
+		if x := e.value(v); x != nil {
+			from, to := "gwei", f.GasPriceUnit
+			if e.unit == "ETH" {
+				from, to = "eth", f.CostUnit
+			}
+			value = f.floatText(*x, from, to)
+		}
+		record = append(record, value, hash)
+	}
+	for _, c := range extra {
+		record = append(record, c.Values[bucket])
+	}
+	return record
+}
+
+// blobDependentWei formats the exact amount wei like BlobDependent.
+func blobDependentWei(r *aggregate.Result, wei *big.Int) string {
+	if r.BlobPriceMissing > 0 {
+		return aggregate.Unavailable
+	}
+	return wei.String()
+}
+
+// percentileName names the p-th percentile of the calldata or blob gas
+// prices, e.g. "P90 Blob".
+func percentileName(p int, blob bool) string {
+	if blob {
+		return fmt.Sprintf("P%d Blob", p)
+	}
+	return fmt.Sprintf("P%d Calldata", p)
+}
+
+// percentile returns the p-th percentile of the calldata or blob gas prices
+// of r in Gwei, or nil when there are none, as in results read back from a
+// database, or when a blob gas price is missing.
+func percentile(r *aggregate.Result, p int, blob bool) *float64 {
+	prices := &r.CalldataGasPrices
+	if blob {
+		if r.BlobPriceMissing > 0 {
+			return nil
+		}
+		prices = &r.BlobGasPrices
+	}
+	v, ok := prices.Quantile(p)
+	if !ok {
+		return nil
+	}
+	return &v
+}
+
+// percentileText formats a percentile for a text report: empty without
+// prices and aggregate.Unavailable for blobs with a missing price.
+func percentileText(r *aggregate.Result, p int, blob bool) string {
+	if blob && r.BlobPriceMissing > 0 {
+		return aggregate.Unavailable
+	}
+	v := percentile(r, p, blob)
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'g', 10, 64)
+}
+
+// extreme describes one of the extreme transactions of a bucket.
+type extreme struct {
+	name, unit string
+	// blob tells that the value is unavailable when blob gas prices are
+	// missing.
+	blob bool
+	tx   func(r *aggregate.Result) *aggregate.Extreme
+}
+
+var extremes = []extreme{
+	{"Min Gas Price", "Gwei", false, func(r *aggregate.Result) *aggregate.Extreme { return r.MinGasPriceTx }},
+	{"Max Gas Price", "Gwei", false, func(r *aggregate.Result) *aggregate.Extreme { return r.MaxGasPriceTx }},
+	{"Min Tx Cost", "ETH", true, func(r *aggregate.Result) *aggregate.Extreme { return r.MinCostTx }},
+	{"Max Tx Cost", "ETH", true, func(r *aggregate.Result) *aggregate.Extreme { return r.MaxCostTx }},
+}
+
+// value returns the value of the extreme transaction of r, or nil when it is
+// unknown or depends on a missing blob gas price.
+func (e extreme) value(r *aggregate.Result) *float64 {
+	x := e.tx(r)
+	if x == nil || (e.blob && r.BlobPriceMissing > 0) {
+		return nil
+	}
+	return &x.Value
+}
+
+// text formats the value and hash of the extreme transaction of r for a text
+// report, both empty when it is unknown.
+func (e extreme) text(r *aggregate.Result) (value, hash string) {
+	x := e.tx(r)
+	if x == nil {
+		return "", ""
+	}
+	if v := e.value(r); v != nil {
+		return strconv.FormatFloat(*v, 'g', 10, 64), x.Hash.Hex()
+	}
+	return aggregate.Unavailable, x.Hash.Hex()
+}
+
+// WriteFailures lists the transactions whose receipt could not be fetched,
+// with the last error of each, as CSV.
+func WriteFailures(path string, failures []fetch.Result) error {
+	outFile, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+
+	writer := csv.NewWriter(outFile)
+	if err := writer.Write([]string{"Transaction Hash", "Line", "Error"}); err != nil {
+		return err
+	}
+	for _, failure := range failures {
+		record := []string{failure.Row.Hash.Hex(), strconv.Itoa(failure.Row.Line), failure.Err.Error()}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return outFile.Close()
+}
+
+// WriteUnexpected lists the transactions left out for their unexpected
+// sender, with their cost, as CSV.
+func WriteUnexpected(path string, txs []aggregate.Tx) error {
+	outFile, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+
+	writer := csv.NewWriter(outFile)
+	if err := writer.Write([]string{"Transaction Hash", "From", "Block", "Time", "Cost(wei)"}); err != nil {
+		return err
+	}
+	for _, tx := range txs {
+		from := aggregate.UnknownSender
+		if tx.From != nil {
+			from = tx.From.Hex()
+		}
+		record := []string{tx.Hash.Hex(), from, strconv.FormatUint(tx.Block, 10), tx.Time.UTC().Format(time.RFC3339), tx.Cost.String()}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return outFile.Close()
+}
+
+// WriteSkipped writes the rows rejected while reading the input, with the
+// reason of each, as CSV.
+func WriteSkipped(path string, skipped []input.Skipped) error {
+	outFile, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+
+	writer := csv.NewWriter(outFile)
+	if err := writer.Write([]string{"File", "Line", "Value", "Reason"}); err != nil {
+		return err
+	}
+	for _, row := range skipped {
+		if err := writer.Write([]string{row.File, strconv.Itoa(row.Line), row.Value, row.Reason}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return outFile.Close()
+}
@@ -0,0 +1,193 @@
+package output_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"math"
+	"math/big"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/input"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of testdata")
+
+// tx is a transaction of the report of reportTxs.
+type tx struct {
+	hash     byte
+	day      int // of July 2024
+	block    uint64
+	index    uint
+	gasPrice int64 // wei
+	gasUsed  uint64
+	blobGas  uint64 // a blob transaction when set
+	reverted bool
+}
+
+// reportTxs spread over three days, with calldata and blob transactions, a
+// reverted one, and ties for the lowest and highest gas prices and costs of
+// the second day.
+var reportTxs = []tx{
+	{hash: 0x01, day: 1, block: 100, index: 3, gasPrice: 1_500_000_000, gasUsed: 21_000},
+	{hash: 0x02, day: 1, block: 101, index: 0, gasPrice: 2_250_000_000, gasUsed: 86_000},
+	{hash: 0x03, day: 1, block: 102, index: 1, gasPrice: 1_000_000_000, gasUsed: 21_000, blobGas: 131_072},
+	{hash: 0x04, day: 2, block: 200, index: 0, gasPrice: 3_000_000_000, gasUsed: 40_000, reverted: true},
+	{hash: 0x05, day: 2, block: 201, index: 5, gasPrice: 1_750_000_000, gasUsed: 64_000},
+	{hash: 0x09, day: 2, block: 202, index: 0, gasPrice: 3_000_000_000, gasUsed: 40_000},
+	{hash: 0x0a, day: 2, block: 203, index: 4, gasPrice: 1_750_000_000, gasUsed: 64_000},
+	{hash: 0x06, day: 3, block: 300, index: 2, gasPrice: 900_000_000, gasUsed: 21_000, blobGas: 262_144},
+	{hash: 0x07, day: 3, block: 301, index: 1, gasPrice: 1_100_000_000, gasUsed: 52_000},
+	{hash: 0x08, day: 3, block: 302, index: 0, gasPrice: 1_300_000_000, gasUsed: 21_000, blobGas: 131_072},
+}
+
+func (t tx) row() input.Row {
+	return input.Row{
+		Hash:  common.BytesToHash([]byte{t.hash}),
+		Time:  time.Date(2024, 7, t.day, int(t.block%24), 0, 0, 0, time.UTC),
+		Block: t.block,
+	}
+}
+
+func (t tx) receipt() *types.Receipt {
+	r := &types.Receipt{
+		Type:              types.DynamicFeeTxType,
+		Status:            types.ReceiptStatusSuccessful,
+		TxHash:            common.BytesToHash([]byte{t.hash}),
+		BlockNumber:       new(big.Int).SetUint64(t.block),
+		TransactionIndex:  t.index,
+		GasUsed:           t.gasUsed,
+		EffectiveGasPrice: big.NewInt(t.gasPrice),
+	}
+	if t.reverted {
+		r.Status = types.ReceiptStatusFailed
+	}
+	if t.blobGas > 0 {
+		r.Type = types.BlobTxType
+		r.BlobGasUsed = t.blobGas
+		r.BlobGasPrice = big.NewInt(1)
+	}
+	return r
+}
+
+// writeReport aggregates txs in their order and returns the CSV report.
+func writeReport(t *testing.T, txs []tx) []byte {
+	t.Helper()
+	agg := aggregate.New("day")
+	for _, tx := range txs {
+		agg.Add(tx.row(), tx.receipt())
+	}
+	dates, total := agg.Finalize()
+	path := filepath.Join(t.TempDir(), "report.csv")
+	if err := output.WriteCSV(path, dates, agg.Results, total); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// TestReportIsIndependentOfInputOrder checks that the report of shuffled
+// inputs is byte-identical to the golden one.
+func TestReportIsIndependentOfInputOrder(t *testing.T) {
+	golden := filepath.Join("testdata", "report.golden.csv")
+	want := writeReport(t, reportTxs)
+	if *update {
+		if err := os.WriteFile(golden, want, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(want, data) {
+		t.Fatalf("report differs from %s:\n%s", golden, want)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		txs := append([]tx(nil), reportTxs...)
+		rng.Shuffle(len(txs), func(i, j int) { txs[i], txs[j] = txs[j], txs[i] })
+		if got := writeReport(t, txs); !bytes.Equal(got, data) {
+			t.Fatalf("report of shuffle %d differs from %s:\n%s", i, golden, got)
+		}
+	}
+}
+
+// TestJSONTotalAverages checks that the averages and means of the total of
+// the JSON report are those of all the transactions of its buckets.
+func TestJSONTotalAverages(t *testing.T) {
+	agg := aggregate.New("day")
+	for _, tx := range reportTxs {
+		agg.Add(tx.row(), tx.receipt())
+	}
+	dates, total := agg.Finalize()
+	data, err := output.EncodeJSON("day", dates, agg.Results, total)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type averages struct {
+		CalldataCostEth float64 `json:"calldataCostEth"`
+		BlobCostEth     float64 `json:"blobCostEth"`
+		AvgCalldata     float64 `json:"avgCalldataGasPriceGwei"`
+		AvgBlob         float64 `json:"avgBlobGasPriceGwei"`
+		CalldataGasUsed uint64  `json:"calldataGasUsed"`
+		BlobGasUsed     uint64  `json:"blobGasUsed"`
+		TxCount         uint64  `json:"txCount"`
+	}
+	var report struct {
+		Buckets map[string]averages `json:"buckets"`
+		Total   averages            `json:"total"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+
+	var calldataCost, blobCost, calldataGas, blobGas float64
+	for _, b := range report.Buckets {
+		calldataCost += b.CalldataCostEth
+		blobCost += b.BlobCostEth
+		calldataGas += float64(b.CalldataGasUsed)
+		blobGas += float64(b.BlobGasUsed)
+		// The average of a bucket weighs its transactions by their gas.
+		if want := b.CalldataCostEth * 1e9 / float64(b.CalldataGasUsed); !near(b.AvgCalldata, want) {
+			t.Errorf("bucket average calldata gas price %v Gwei, want %v", b.AvgCalldata, want)
+		}
+	}
+	for _, c := range []struct {
+		name      string
+		got, want float64
+	}{
+		{"calldata", report.Total.AvgCalldata, calldataCost * 1e9 / calldataGas},
+		{"blob", report.Total.AvgBlob, blobCost * 1e9 / blobGas},
+	} {
+		if c.want == 0 || !near(c.got, c.want) {
+			t.Errorf("total average %s gas price %v Gwei, want %v", c.name, c.got, c.want)
+		}
+	}
+	mean, _ := total.MeanCallDataGasPrice.Float64()
+	var sum float64
+	for _, tx := range reportTxs {
+		sum += float64(tx.gasPrice) / 1e9
+	}
+	if want := sum / float64(len(reportTxs)); !near(mean, want) {
+		t.Errorf("total mean calldata gas price %v Gwei, want %v", mean, want)
+	}
+}
+
+// near reports whether a and b are equal but for rounding.
+func near(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(math.Abs(a), math.Abs(b))
+}
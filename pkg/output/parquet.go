@@ -0,0 +1,269 @@
+package output
+
+import (
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+)
+
+// wei is an amount in wei stored as a DECIMAL(38, 0), which holds any
+// realistic amount without the rounding of a double.
+type wei = [16]byte
+
+// The schemas are spelled out because the struct tags cannot declare optional
+// decimals. Columns are ordered by name.
+var (
+	weiType          = parquet.Decimal(0, 38, parquet.FixedLenByteArrayType(16))
+	parquetDaySchema = parquet.NewSchema("daily_costs", parquet.Group{
+		"bucket":                      parquet.String(),
+		"cost_wei":                    parquet.Optional(weiType),
+		"cost_eth":                    parquet.Optional(parquet.Leaf(parquet.DoubleType)),
+		"calldata_cost_wei":           weiType,
+		"calldata_cost_eth":           parquet.Leaf(parquet.DoubleType),
+		"blob_cost_wei":               parquet.Optional(weiType),
+		"blob_cost_eth":               parquet.Optional(parquet.Leaf(parquet.DoubleType)),
+		"avg_calldata_gas_price_gwei": parquet.Leaf(parquet.DoubleType),
+		"avg_blob_gas_price_gwei":     parquet.Optional(parquet.Leaf(parquet.DoubleType)),
+		"blended_gas_price_gwei":      parquet.Optional(parquet.Leaf(parquet.DoubleType)),
+		"p50_calldata_gas_price_gwei": parquet.Optional(parquet.Leaf(parquet.DoubleType)),
+		"p90_calldata_gas_price_gwei": parquet.Optional(parquet.Leaf(parquet.DoubleType)),
+		"p99_calldata_gas_price_gwei": parquet.Optional(parquet.Leaf(parquet.DoubleType)),
+		"p50_blob_gas_price_gwei":     parquet.Optional(parquet.Leaf(parquet.DoubleType)),
+		"p90_blob_gas_price_gwei":     parquet.Optional(parquet.Leaf(parquet.DoubleType)),
+		"p99_blob_gas_price_gwei":     parquet.Optional(parquet.Leaf(parquet.DoubleType)),
+		"calldata_gas_used":           parquet.Int(64),
+		"blob_gas_used":               parquet.Int(64),
+		"gas_used":                    parquet.Int(64),
+		"tx_count":                    parquet.Int(64),
+		"blob_price_missing":          parquet.Int(64),
+		"blob_tx_count":               parquet.Int(64),
+		"blob_count":                  parquet.Int(64),
+		"blobs_per_tx":                parquet.Leaf(parquet.DoubleType),
+		"cost_per_blob_eth":           parquet.Optional(parquet.Leaf(parquet.DoubleType)),
+		"successful_tx_count":         parquet.Int(64),
+		"reverted_tx_count":           parquet.Int(64),
+		"successful_cost_eth":         parquet.Optional(parquet.Leaf(parquet.DoubleType)),
+		"reverted_cost_eth":           parquet.Optional(parquet.Leaf(parquet.DoubleType)),
+	})
+	parquetTxSchema = parquet.NewSchema("transactions", parquet.Group{
+		"hash":               parquet.String(),
+		"block":              parquet.Int(64),
+		"time":               parquet.Timestamp(parquet.Millisecond),
+		"bucket":             parquet.String(),
+		"type":               parquet.Int(32),
+		"gas_used":           parquet.Int(64),
+		"gas_price_wei":      weiType,
+		"blob_gas_used":      parquet.Int(64),
+		"blob_gas_price_wei": parquet.Optional(weiType),
+		"cost_wei":           weiType,
+		"cost_eth":           parquet.Leaf(parquet.DoubleType),
+		"calldata_cost_wei":  weiType,
+		"blob_cost_wei":      weiType,
+		"l2_blocks":          parquet.Optional(parquet.Int(64)),
+		"l2_txs":             parquet.Optional(parquet.Int(64)),
+	})
+)
+
+// parquetDay is a row of the per-bucket Parquet table. Columns that depend on
+// a missing blob gas price are null.
+type parquetDay struct {
+	Bucket              string   `parquet:"bucket"`
+	CostWei             *wei     `parquet:"cost_wei,optional"`
+	CostEth             *float64 `parquet:"cost_eth,optional"`
+	CalldataCostWei     wei      `parquet:"calldata_cost_wei"`
+	CalldataCostEth     float64  `parquet:"calldata_cost_eth"`
+	BlobCostWei         *wei     `parquet:"blob_cost_wei,optional"`
+	BlobCostEth         *float64 `parquet:"blob_cost_eth,optional"`
+	AvgCalldataGasPrice float64  `parquet:"avg_calldata_gas_price_gwei"`
+	AvgBlobGasPrice     *float64 `parquet:"avg_blob_gas_price_gwei,optional"`
+	BlendedGasPrice     *float64 `parquet:"blended_gas_price_gwei,optional"`
+	P50CalldataGasPrice *float64 `parquet:"p50_calldata_gas_price_gwei,optional"`
+	P90CalldataGasPrice *float64 `parquet:"p90_calldata_gas_price_gwei,optional"`
+	P99CalldataGasPrice *float64 `parquet:"p99_calldata_gas_price_gwei,optional"`
+	P50BlobGasPrice     *float64 `parquet:"p50_blob_gas_price_gwei,optional"`
+	P90BlobGasPrice     *float64 `parquet:"p90_blob_gas_price_gwei,optional"`
+	P99BlobGasPrice     *float64 `parquet:"p99_blob_gas_price_gwei,optional"`
+	CalldataGasUsed     int64    `parquet:"calldata_gas_used"`
+	BlobGasUsed         int64    `parquet:"blob_gas_used"`
+	GasUsed             int64    `parquet:"gas_used"`
+	TxCount             int64    `parquet:"tx_count"`
+	BlobPriceMissing    int64    `parquet:"blob_price_missing"`
+	BlobTxCount         int64    `parquet:"blob_tx_count"`
+	BlobCount           int64    `parquet:"blob_count"`
+	BlobsPerTx          float64  `parquet:"blobs_per_tx"`
+	CostPerBlob         *float64 `parquet:"cost_per_blob_eth,optional"`
+	SuccessfulTxCount   int64    `parquet:"successful_tx_count"`
+	RevertedTxCount     int64    `parquet:"reverted_tx_count"`
+	SuccessfulCost      *float64 `parquet:"successful_cost_eth,optional"`
+	RevertedCost        *float64 `parquet:"reverted_cost_eth,optional"`
+}
+
+// parquetTx is a row of the per-transaction Parquet table.
+type parquetTx struct {
+	Hash            string  `parquet:"hash"`
+	Block           int64   `parquet:"block"`
+	Time            int64   `parquet:"time"` // Unix milliseconds
+	Bucket          string  `parquet:"bucket"`
+	Type            int32   `parquet:"type"`
+	GasUsed         int64   `parquet:"gas_used"`
+	GasPriceWei     wei     `parquet:"gas_price_wei"`
+	BlobGasUsed     int64   `parquet:"blob_gas_used"`
+	BlobGasPriceWei *wei    `parquet:"blob_gas_price_wei,optional"`
+	CostWei         wei     `parquet:"cost_wei"`
+	CostEth         float64 `parquet:"cost_eth"`
+	CalldataCostWei wei     `parquet:"calldata_cost_wei"`
+	BlobCostWei     wei     `parquet:"blob_cost_wei"`
+	L2Blocks        *int64  `parquet:"l2_blocks,optional"`
+	L2Txs           *int64  `parquet:"l2_txs,optional"`
+	CalldataPricing *string `parquet:"calldata_pricing,optional"`
+}
+
+// WriteParquet writes the per-bucket report to path as a Parquet file.
+func WriteParquet(path string, dates []string, results map[string]*aggregate.Result) error {
+	rows := make([]parquetDay, 0, len(dates))
+	for _, k := range dates {
+		r := results[k]
+		calldataWei, calldataEth := weiAmount(r.CalldataCostWei, r.CalldataCost)
+		avgCalldata, _ := r.AvgCallDataGasPrice.Float64()
+		row := parquetDay{
+			Bucket:              k,
+			CalldataCostWei:     decimal(calldataWei),
+			CalldataCostEth:     calldataEth,
+			AvgCalldataGasPrice: avgCalldata,
+			CalldataGasUsed:     int64(r.TotalCalldataGasUsed),
+			BlobGasUsed:         int64(r.TotalBlobGasUsed),
+			GasUsed:             int64(r.TotalGasUsed),
+			TxCount:             int64(r.TxCount),
+			BlobPriceMissing:    int64(r.BlobPriceMissing),
+			BlobTxCount:         int64(r.BlobTxCount),
+			BlobCount:           int64(r.Blobs()),
+			BlobsPerTx:          r.BlobsPerTx(),
+			SuccessfulTxCount:   int64(r.SuccessfulTxCount()),
+			RevertedTxCount:     int64(r.RevertedTxCount),
+			P50CalldataGasPrice: percentile(r, 50, false),
+			P90CalldataGasPrice: percentile(r, 90, false),
+			P99CalldataGasPrice: percentile(r, 99, false),
+			P50BlobGasPrice:     percentile(r, 50, true),
+			P90BlobGasPrice:     percentile(r, 90, true),
+			P99BlobGasPrice:     percentile(r, 99, true),
+		}
+		if r.BlobPriceMissing == 0 {
+			row.CostWei, row.CostEth = ethAmount(r.CostWei, r.Cost)
+			row.BlobCostWei, row.BlobCostEth = ethAmount(r.BlobCostWei, r.BlobCost)
+			row.AvgBlobGasPrice = floatPtr(r.AvgBlobGasPrice)
+			row.BlendedGasPrice = floatPtr(r.BlendedGasPrice)
+			if c := r.CostPerBlob(); c != nil {
+				row.CostPerBlob = floatPtr(c)
+			}
+			row.SuccessfulCost, row.RevertedCost = floatPtr(r.SuccessfulCost()), floatPtr(r.RevertedCost)
+		}
+		rows = append(rows, row)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	w := parquet.NewGenericWriter[parquetDay](file, parquetDaySchema)
+	if _, err := w.Write(rows); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// parquetTxRowGroup is the number of rows of the row groups of the
+// per-transaction table, past which the rows buffered are written out.
+const parquetTxRowGroup = 100_000
+
+// ParquetTxWriter writes the per-transaction Parquet table. Rows are buffered
+// into row groups; the file is only readable once it is closed.
+type ParquetTxWriter struct {
+	file   *os.File
+	writer *parquet.GenericWriter[parquetTx]
+}
+
+// CreateParquetTx creates the per-transaction table at path. The pages of the
+// row group being built are buffered in temporary files in spillDir, the
+// system temporary directory when empty, rather than in memory, so that the
+// memory used does not grow with the number of transactions.
+func CreateParquetTx(path, spillDir string) (*ParquetTxWriter, error) {
+	if spillDir == "" {
+		spillDir = os.TempDir()
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	writer := parquet.NewGenericWriter[parquetTx](file, parquetTxSchema,
+		parquet.MaxRowsPerRowGroup(parquetTxRowGroup),
+		parquet.ColumnPageBuffers(parquet.NewFileBufferPool(spillDir, "transactions-*.parquet.tmp")))
+	return &ParquetTxWriter{file: file, writer: writer}, nil
+}
+
+// WriteTx adds the row of one transaction.
+func (w *ParquetTxWriter) WriteTx(tx aggregate.Tx) error {
+	row := parquetTx{
+		Hash:            tx.Hash.Hex(),
+		Block:           int64(tx.Block),
+		Time:            tx.Time.UnixMilli(),
+		Bucket:          tx.Bucket,
+		Type:            int32(tx.Type),
+		GasUsed:         int64(tx.GasUsed),
+		GasPriceWei:     decimal(tx.GasPrice.String()),
+		BlobGasUsed:     int64(tx.BlobGasUsed),
+		CostWei:         decimal(tx.Cost.String()),
+		CalldataCostWei: decimal(tx.CalldataCost.String()),
+		BlobCostWei:     decimal(tx.BlobCost.String()),
+	}
+	row.CostEth, _ = new(big.Float).Quo(new(big.Float).SetInt(tx.Cost), big.NewFloat(params.Ether)).Float64()
+	if tx.BlobGasPrice != nil {
+		price := decimal(tx.BlobGasPrice.String())
+		row.BlobGasPriceWei = &price
+	}
+	if tx.L2Blocks != nil && tx.L2Txs != nil {
+		blocks, txs := int64(*tx.L2Blocks), int64(*tx.L2Txs)
+		row.L2Blocks, row.L2Txs = &blocks, &txs
+	}
+	if tx.CalldataPricing != "" {
+		row.CalldataPricing = &tx.CalldataPricing
+	}
+	_, err := w.writer.Write([]parquetTx{row})
+	return err
+}
+
+// Close writes the footer of the table and closes the file.
+func (w *ParquetTxWriter) Close() error {
+	if err := w.writer.Close(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+// decimal encodes a non-negative integer given in decimal as the big-endian
+// unscaled value of a DECIMAL(38, 0).
+func decimal(s string) wei {
+	var b wei
+	if n, ok := new(big.Int).SetString(s, 10); ok && n.Sign() >= 0 && n.BitLen() < 128 {
+		n.FillBytes(b[:])
+	}
+	return b
+}
+
+func ethAmount(amount *big.Int, eth *big.Float) (*wei, *float64) {
+	s, f := weiAmount(amount, eth)
+	d := decimal(s)
+	return &d, &f
+}
+
+func floatPtr(value *big.Float) *float64 {
+	f, _ := value.Float64()
+	return &f
+}
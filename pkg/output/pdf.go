@@ -0,0 +1,128 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// pdfColumns are the columns of a CSV report shown in the bucket table of the
+// PDF, when the report has them; all of them would not fit on a page.
+var pdfColumns = []string{
+	"Total Cost(ETH)",
+	"Calldata Cost(ETH)",
+	"Blob Cost(ETH)",
+	"Avg Calldata gas price(Gwei)",
+	"Avg Blob Gas Price(Gwei)",
+	"Blended Gas Price(Gwei)",
+	"Transaction Count",
+	"Blob Count",
+}
+
+// Layout of the A4 landscape pages, in mm.
+const (
+	pdfMargin    = 15
+	pdfRowHeight = 6
+	pdfKeyWidth  = 40 // of the bucket column
+)
+
+// WritePDF writes a paginated PDF of a CSV report to path, for sharing with
+// readers outside engineering: title, the totals of the report, the charts,
+// PNG images such as those of ChartPNG, one per page, and the buckets as a
+// table whose header repeats on every page. records are the rows of the CSV
+// report, header first and without its total row; totals is that row, with
+// an empty value for the columns that do not add up.
+func WritePDF(path, title string, records [][]string, totals []string, charts ...[]byte) error {
+	pdf := fpdf.New("L", "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+	pdf.SetTitle(title, true)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-10)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.CellFormat(0, 5, fmt.Sprintf("%s - page %d of {nb}", title, pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	pageW, _ := pdf.GetPageSize()
+	width := pageW - 2*pdfMargin
+
+	header := records[0]
+	rows := records[1:]
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(0, 10, title, "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	period := "no buckets"
+	if len(rows) > 0 {
+		period = fmt.Sprintf("%s to %s, %d buckets", rows[0][0], rows[len(rows)-1][0], len(rows))
+	}
+	pdf.CellFormat(0, 6, period+", generated "+time.Now().UTC().Format("2006-01-02 15:04 MST"), "", 1, "L", false, 0, "")
+	pdf.Ln(6)
+
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(0, 8, "Totals", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	for i, total := range totals {
+		if i == 0 || total == "" {
+			continue
+		}
+		pdf.SetFillColor(240, 240, 240)
+		pdf.CellFormat(90, pdfRowHeight, header[i], "1", 0, "L", true, 0, "")
+		pdf.CellFormat(70, pdfRowHeight, total, "1", 1, "R", false, 0, "")
+	}
+
+	for i, chart := range charts {
+		pdf.AddPage()
+		name := fmt.Sprintf("chart%d", i)
+		info := pdf.RegisterImageOptionsReader(name, fpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(chart))
+		if pdf.Err() {
+			return pdf.Error()
+		}
+		// The image spans the page width, keeping its aspect ratio.
+		pdf.ImageOptions(name, pdfMargin, pdfMargin, width, width*info.Height()/info.Width(), false, fpdf.ImageOptions{}, 0, "")
+	}
+
+	var columns []int
+	for _, name := range pdfColumns {
+		if i := slices.Index(header, name); i >= 0 {
+			columns = append(columns, i)
+		}
+	}
+	if len(columns) > 0 && len(rows) > 0 {
+		cellW := (width - pdfKeyWidth) / float64(len(columns))
+		tableHeader := func() {
+			pdf.SetFont("Helvetica", "B", 7)
+			pdf.SetFillColor(220, 220, 220)
+			pdf.CellFormat(pdfKeyWidth, pdfRowHeight, header[0], "1", 0, "C", true, 0, "")
+			for _, i := range columns {
+				pdf.CellFormat(cellW, pdfRowHeight, header[i], "1", 0, "C", true, 0, "")
+			}
+			pdf.Ln(-1)
+			pdf.SetFont("Helvetica", "", 8)
+		}
+		pdf.AddPage()
+		pdf.SetFont("Helvetica", "B", 12)
+		pdf.CellFormat(0, 8, "Buckets", "", 1, "L", false, 0, "")
+		tableHeader()
+		_, pageH := pdf.GetPageSize()
+		for _, row := range rows {
+			if pdf.GetY()+pdfRowHeight > pageH-pdfMargin {
+				pdf.AddPage()
+				tableHeader()
+			}
+			pdf.CellFormat(pdfKeyWidth, pdfRowHeight, row[0], "1", 0, "L", false, 0, "")
+			for _, i := range columns {
+				value := ""
+				if i < len(row) {
+					value = row[i]
+				}
+				pdf.CellFormat(cellW, pdfRowHeight, value, "1", 0, "R", false, 0, "")
+			}
+			pdf.Ln(-1)
+		}
+	}
+	return pdf.OutputFileAndClose(path)
+}
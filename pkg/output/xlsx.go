@@ -0,0 +1,253 @@
+package output
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+)
+
+// xlsxColumn is a column of the report sheets. Amounts are written as numbers
+// in ETH and Gwei; wei would exceed the 15 significant digits of a spreadsheet.
+type xlsxColumn struct {
+	header string
+	width  float64
+	format string // custom number format
+	value  func(r *aggregate.Result) any
+}
+
+var xlsxColumns = append([]xlsxColumn{
+	{"Total Cost (ETH)", 18, ethFormat, func(r *aggregate.Result) any { return blobDependent(r, r.Cost) }},
+	{"Calldata Cost (ETH)", 18, ethFormat, func(r *aggregate.Result) any { return number(r.CalldataCost) }},
+	{"Blob Cost (ETH)", 18, ethFormat, func(r *aggregate.Result) any { return blobDependent(r, r.BlobCost) }},
+	{"Avg Calldata Gas Price (Gwei)", 16, gweiFormat, func(r *aggregate.Result) any { return number(r.AvgCallDataGasPrice) }},
+	{"Avg Blob Gas Price (Gwei)", 16, gweiFormat, func(r *aggregate.Result) any { return blobDependent(r, r.AvgBlobGasPrice) }},
+	{"Blended Gas Price (Gwei)", 16, gweiFormat, func(r *aggregate.Result) any { return blobDependent(r, r.BlendedGasPrice) }},
+	{"P50 Calldata Gas Price (Gwei)", 16, gweiFormat, func(r *aggregate.Result) any { return percentileCell(r, 50, false) }},
+	{"P90 Calldata Gas Price (Gwei)", 16, gweiFormat, func(r *aggregate.Result) any { return percentileCell(r, 90, false) }},
+	{"P99 Calldata Gas Price (Gwei)", 16, gweiFormat, func(r *aggregate.Result) any { return percentileCell(r, 99, false) }},
+	{"P50 Blob Gas Price (Gwei)", 16, gweiFormat, func(r *aggregate.Result) any { return percentileCell(r, 50, true) }},
+	{"P90 Blob Gas Price (Gwei)", 16, gweiFormat, func(r *aggregate.Result) any { return percentileCell(r, 90, true) }},
+	{"P99 Blob Gas Price (Gwei)", 16, gweiFormat, func(r *aggregate.Result) any { return percentileCell(r, 99, true) }},
+	{"Calldata Gas Used", 16, gasFormat, func(r *aggregate.Result) any { return r.TotalCalldataGasUsed }},
+	{"Blob Gas Used", 16, gasFormat, func(r *aggregate.Result) any { return r.TotalBlobGasUsed }},
+	{"Total Gas Used", 16, gasFormat, func(r *aggregate.Result) any { return r.TotalGasUsed }},
+	{"Transaction Count", 12, gasFormat, func(r *aggregate.Result) any { return r.TxCount }},
+	{"Blob Count", 12, gasFormat, func(r *aggregate.Result) any { return r.Blobs() }},
+	{"Blobs per Blob Tx", 12, "0.00", func(r *aggregate.Result) any { return r.BlobsPerTx() }},
+	{"Cost per Blob (ETH)", 18, ethFormat, func(r *aggregate.Result) any {
+		if c := r.CostPerBlob(); c != nil {
+			return blobDependent(r, c)
+		}
+		return nil
+	}},
+	{"Successful Txs", 12, gasFormat, func(r *aggregate.Result) any { return r.SuccessfulTxCount() }},
+	{"Reverted Txs", 12, gasFormat, func(r *aggregate.Result) any { return r.RevertedTxCount }},
+	{"Successful Cost (ETH)", 18, ethFormat, func(r *aggregate.Result) any { return blobDependent(r, r.SuccessfulCost()) }},
+	{"Reverted Cost (ETH)", 18, ethFormat, func(r *aggregate.Result) any { return blobDependent(r, r.RevertedCost) }},
+}, extremeColumns()...)
+
+// extremeColumns returns the columns of the values and hashes of the extreme
+// transactions.
+func extremeColumns() []xlsxColumn {
+	var columns []xlsxColumn
+	for _, e := range extremes {
+		e := e
+		format := gweiFormat
+		if e.unit == "ETH" {
+			format = ethFormat
+		}
+		columns = append(columns,
+			xlsxColumn{e.name + " (" + e.unit + ")", 16, format, func(r *aggregate.Result) any {
+				value, _ := e.text(r)
+				if v := e.value(r); v != nil {
+					return *v
+				}
+				return value
+			}},
+			xlsxColumn{e.name + " Tx", 68, "@", func(r *aggregate.Result) any {
+				_, hash := e.text(r)
+				return hash
+			}},
+		)
+	}
+	return columns
+}
+
+const (
+	ethFormat  = "0.000000000"
+	gweiFormat = "#,##0.000######"
+	gasFormat  = "#,##0"
+)
+
+// WriteXLSX writes the report to path as an Excel workbook with a sheet of the
+// buckets and, unless they are months, a sheet of their monthly sums, each
+// ending in the row of total. Weeks count toward the month they start in.
+func WriteXLSX(path, granularity string, dates []string, results map[string]*aggregate.Result, total *aggregate.Result) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet, keyHeader, keyFormat := "Daily", "Date", "yyyy-mm-dd"
+	switch granularity {
+	case "week":
+		sheet, keyHeader = "Weekly", "Week Starting"
+	case "hour":
+		sheet, keyHeader, keyFormat = "Hourly", "Hour", "yyyy-mm-dd hh:mm"
+	case "month":
+		sheet, keyHeader, keyFormat = "Monthly", "Month", "yyyy-mm"
+	case "block":
+		sheet, keyHeader, keyFormat = "Blocks", "Block Time", "yyyy-mm-dd hh:mm:ss"
+	}
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		return err
+	}
+	if err := writeXLSXSheet(f, sheet, keyHeader, dates, results, total, aggregate.BucketStart, keyFormat); err != nil {
+		return err
+	}
+	if granularity == "month" {
+		return f.SaveAs(path)
+	}
+
+	months, monthly := aggregate.Rollup(results, func(bucket string) string {
+		start, err := aggregate.BucketStart(bucket)
+		if err != nil {
+			return bucket
+		}
+		return start.Format("2006-01")
+	})
+	if _, err := f.NewSheet("Monthly"); err != nil {
+		return err
+	}
+	if err := writeXLSXSheet(f, "Monthly", "Month", months, monthly, total, parseMonth, "yyyy-mm"); err != nil {
+		return err
+	}
+	return f.SaveAs(path)
+}
+
+// writeXLSXSheet writes a header, one row per key and a total row to sheet.
+// Keys are converted by parse and written as dates in dateFormat.
+func writeXLSXSheet(f *excelize.File, sheet, keyHeader string, keys []string, results map[string]*aggregate.Result, total *aggregate.Result, parse func(string) (time.Time, error), dateFormat string) error {
+	header, err := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true},
+		Fill:      excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"#DDEBF7"}},
+		Alignment: &excelize.Alignment{WrapText: true, Vertical: "center"},
+	})
+	if err != nil {
+		return err
+	}
+	date, err := f.NewStyle(&excelize.Style{CustomNumFmt: &dateFormat, Alignment: &excelize.Alignment{Horizontal: "left"}})
+	if err != nil {
+		return err
+	}
+	bold, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+
+	row := []any{keyHeader}
+	for _, c := range xlsxColumns {
+		row = append(row, c.header)
+	}
+	if err := f.SetSheetRow(sheet, "A1", &row); err != nil {
+		return err
+	}
+	for i, k := range keys {
+		start, err := parse(k)
+		if err != nil {
+			return err
+		}
+		row := []any{start}
+		for _, c := range xlsxColumns {
+			row = append(row, c.value(results[k]))
+		}
+		if err := f.SetSheetRow(sheet, cell(1, i+2), &row); err != nil {
+			return err
+		}
+	}
+	totalRow := []any{"Total"}
+	for _, c := range xlsxColumns {
+		totalRow = append(totalRow, c.value(total))
+	}
+	last := len(keys) + 2
+	if err := f.SetSheetRow(sheet, cell(1, last), &totalRow); err != nil {
+		return err
+	}
+
+	lastCol := len(xlsxColumns) + 1
+	if err := f.SetRowStyle(sheet, 1, 1, header); err != nil {
+		return err
+	}
+	if err := f.SetColWidth(sheet, "A", "A", 12); err != nil {
+		return err
+	}
+	if err := f.SetCellStyle(sheet, "A2", cell(1, last), date); err != nil {
+		return err
+	}
+	for i, c := range xlsxColumns {
+		col := i + 2
+		format := c.format
+		style, err := f.NewStyle(&excelize.Style{CustomNumFmt: &format})
+		if err != nil {
+			return err
+		}
+		totalStyle, err := f.NewStyle(&excelize.Style{CustomNumFmt: &format, Font: &excelize.Font{Bold: true}})
+		if err != nil {
+			return err
+		}
+		name, _ := excelize.ColumnNumberToName(col)
+		if err := f.SetColWidth(sheet, name, name, c.width); err != nil {
+			return err
+		}
+		if err := f.SetCellStyle(sheet, cell(col, 2), cell(col, last), style); err != nil {
+			return err
+		}
+		if err := f.SetCellStyle(sheet, cell(col, last), cell(col, last), totalStyle); err != nil {
+			return err
+		}
+	}
+	if err := f.SetCellStyle(sheet, cell(1, last), cell(1, last), bold); err != nil {
+		return err
+	}
+	if err := f.AutoFilter(sheet, "A1:"+cell(lastCol, last-1), nil); err != nil {
+		return err
+	}
+	return f.SetPanes(sheet, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"})
+}
+
+func parseMonth(key string) (time.Time, error) {
+	return time.Parse("2006-01", key)
+}
+
+// number converts value to a float64 for a numeric cell.
+func number(value *big.Float) float64 {
+	f, _ := value.Float64()
+	return f
+}
+
+// blobDependent returns value as a number, or aggregate.Unavailable when a
+// blob gas price is missing, so that it is not mistaken for a zero.
+func blobDependent(r *aggregate.Result, value *big.Float) any {
+	if r.BlobPriceMissing > 0 {
+		return aggregate.Unavailable
+	}
+	return number(value)
+}
+
+// percentileCell returns a percentile as a number, an empty cell without
+// prices, or aggregate.Unavailable for blobs with a missing price.
+func percentileCell(r *aggregate.Result, p int, blob bool) any {
+	if v := percentile(r, p, blob); v != nil {
+		return *v
+	}
+	if blob && r.BlobPriceMissing > 0 {
+		return aggregate.Unavailable
+	}
+	return nil
+}
+
+func cell(col, row int) string {
+	name, _ := excelize.CoordinatesToCellName(col, row)
+	return name
+}
@@ -0,0 +1,180 @@
+// Package sheets keeps a tab of a Google Sheet up to date with the per-bucket
+// report, one row per bucket, through the Sheets API.
+package sheets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"golang.org/x/oauth2/google"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+)
+
+const (
+	defaultBaseURL = "https://sheets.googleapis.com/v4/spreadsheets"
+	scope          = "https://www.googleapis.com/auth/spreadsheets"
+)
+
+// header is the first row written to an empty tab.
+var header = []any{
+	"Date", "Total Cost(ETH)", "Calldata Cost(ETH)", "Blob Cost(ETH)",
+	"Avg Calldata Gas Price(Gwei)", "Avg Blob Gas Price(Gwei)", "Blended Gas Price(Gwei)",
+	"Total Calldata Gas Used", "Total Blob Gas Used", "Total Gas Used(calldata + blob)", "Transaction Count",
+}
+
+// Client writes to one tab of a spreadsheet.
+type Client struct {
+	SpreadsheetID string
+	Sheet         string // name of the tab
+	BaseURL       string
+	HTTP          *http.Client // authenticated
+}
+
+// NewClient returns a client authenticated as the service account whose JSON
+// key is in credentialsFile. The spreadsheet must be shared with the
+// account's e-mail address.
+func NewClient(ctx context.Context, credentialsFile, spreadsheetID, sheet string) (*Client, error) {
+	key, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, err
+	}
+	config, err := google.JWTConfigFromJSON(key, scope)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", credentialsFile, err)
+	}
+	return &Client{
+		SpreadsheetID: spreadsheetID,
+		Sheet:         sheet,
+		BaseURL:       defaultBaseURL,
+		HTTP:          config.Client(ctx),
+	}, nil
+}
+
+// Upsert writes a row per bucket: rows whose first cell is the key of a bucket
+// are overwritten and the other buckets are appended, in order. It returns the
+// number of rows updated and appended.
+func (c *Client) Upsert(ctx context.Context, dates []string, results map[string]*aggregate.Result) (updated, appended int, err error) {
+	// The keys are written as text, so they read back unchanged whatever the
+	// locale of the spreadsheet.
+	var existing struct {
+		Values [][]any `json:"values"`
+	}
+	if err := c.do(ctx, http.MethodGet, c.valuesURL(c.cellRange("A:A"), ""), nil, &existing); err != nil {
+		return 0, 0, err
+	}
+	rowOf := make(map[string]int)
+	for i, row := range existing.Values {
+		if len(row) > 0 {
+			rowOf[fmt.Sprint(row[0])] = i + 1
+		}
+	}
+
+	type valueRange struct {
+		Range  string  `json:"range"`
+		Values [][]any `json:"values"`
+	}
+	var updates []valueRange
+	var appends [][]any
+	if len(existing.Values) == 0 {
+		appends = append(appends, header)
+	}
+	for _, k := range dates {
+		values := row(k, results[k])
+		if n, ok := rowOf[k]; ok {
+			updates = append(updates, valueRange{Range: c.cellRange(fmt.Sprintf("A%d", n)), Values: [][]any{values}})
+		} else {
+			appends = append(appends, values)
+		}
+	}
+
+	if len(updates) > 0 {
+		body := map[string]any{"valueInputOption": "RAW", "data": updates}
+		if err := c.do(ctx, http.MethodPost, c.BaseURL+"/"+url.PathEscape(c.SpreadsheetID)+"/values:batchUpdate", body, nil); err != nil {
+			return 0, 0, err
+		}
+	}
+	if len(appends) > 0 {
+		query := url.Values{"valueInputOption": {"RAW"}, "insertDataOption": {"INSERT_ROWS"}}
+		body := map[string]any{"values": appends}
+		if err := c.do(ctx, http.MethodPost, c.valuesURL(c.cellRange("A1"), ":append?"+query.Encode()), body, nil); err != nil {
+			return 0, 0, err
+		}
+	}
+	return len(updates), len(dates) - len(updates), nil
+}
+
+// row returns the cells of a bucket. Amounts are numbers; values unavailable
+// for lack of a blob gas price are aggregate.Unavailable.
+func row(key string, r *aggregate.Result) []any {
+	number := func(v *big.Float) any {
+		f, _ := v.Float64()
+		return f
+	}
+	blobDependent := func(v *big.Float) any {
+		if r.BlobPriceMissing > 0 {
+			return aggregate.Unavailable
+		}
+		return number(v)
+	}
+	return []any{
+		key, blobDependent(r.Cost), number(r.CalldataCost), blobDependent(r.BlobCost),
+		number(r.AvgCallDataGasPrice), blobDependent(r.AvgBlobGasPrice), blobDependent(r.BlendedGasPrice),
+		r.TotalCalldataGasUsed, r.TotalBlobGasUsed, r.TotalGasUsed, r.TxCount,
+	}
+}
+
+// cellRange returns cells in A1 notation on the tab of c.
+func (c *Client) cellRange(cells string) string {
+	return "'" + strings.ReplaceAll(c.Sheet, "'", "''") + "'!" + cells
+}
+
+func (c *Client) valuesURL(cellRange, suffix string) string {
+	return c.BaseURL + "/" + url.PathEscape(c.SpreadsheetID) + "/values/" + url.PathEscape(cellRange) + suffix
+}
+
+// do sends body, if not nil, as JSON and decodes the response into result, if
+// not nil.
+func (c *Client) do(ctx context.Context, method, endpoint string, body, result any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		return fmt.Errorf("sheets: %s: %s", resp.Status, apiErr.Error.Message)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
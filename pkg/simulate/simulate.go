@@ -0,0 +1,384 @@
+// Package simulate replays the data a batcher posted under other submission
+// policies, e.g. posting every N minutes, only below a gas price or in larger
+// channels, and prices the submissions they would have made with historical
+// gas prices.
+package simulate
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Payload is data that was ready to be posted at Time: Bytes of calldata, or
+// of blob space when Blob is set.
+type Payload struct {
+	Time  time.Time
+	Bytes uint64
+	Blob  bool
+}
+
+// Transactions are priced like batcher transactions: the intrinsic gas, plus
+// 16 gas per byte of calldata or a full blob per 131072 bytes of blob space.
+const (
+	calldataGasPerByte = 16
+	blobBytes          = params.BlobTxBlobGasPerBlob
+)
+
+// point is the price of a gas in wei from Time on.
+type point struct {
+	time  time.Time
+	price *big.Int
+}
+
+// Prices are the historical gas and blob gas prices, each price holding from
+// its time until the next one.
+type Prices struct {
+	gas, blob []point
+	sorted    bool
+}
+
+// Add adds the gas price, and the blob gas price unless it is nil, at t.
+func (p *Prices) Add(t time.Time, gasPrice, blobGasPrice *big.Int) {
+	if gasPrice != nil {
+		p.gas = append(p.gas, point{t, gasPrice})
+	}
+	if blobGasPrice != nil {
+		p.blob = append(p.blob, point{t, blobGasPrice})
+	}
+	p.sorted = false
+}
+
+func (p *Prices) sort() {
+	if p.sorted {
+		return
+	}
+	byTime := func(a, b point) int { return a.time.Compare(b.time) }
+	slices.SortStableFunc(p.gas, byTime)
+	slices.SortStableFunc(p.blob, byTime)
+	p.sorted = true
+}
+
+// at returns the price of points at t: the latest one at or before t, or the
+// first one when t precedes them all.
+func at(points []point, t time.Time) *big.Int {
+	i, _ := slices.BinarySearchFunc(points, t, func(p point, t time.Time) int {
+		if p.time.After(t) {
+			return 1
+		}
+		return -1
+	})
+	if i == 0 {
+		return points[0].price
+	}
+	return points[i-1].price
+}
+
+// times returns the times of all the prices, in order.
+func (p *Prices) times() []time.Time {
+	p.sort()
+	times := make([]time.Time, 0, len(p.gas)+len(p.blob))
+	for _, pt := range p.gas {
+		times = append(times, pt.time)
+	}
+	for _, pt := range p.blob {
+		times = append(times, pt.time)
+	}
+	slices.SortFunc(times, time.Time.Compare)
+	return slices.Compact(times)
+}
+
+// Policy decides when the pending data is posted. Actual posts every payload
+// when it was ready, as the batcher did; Every posts the pending data at
+// fixed intervals; Below posts it once the price of its gas is at most the
+// threshold; Size posts it once at least Size bytes are pending. Data pending
+// for MaxWait is posted regardless, unless MaxWait is zero.
+type Policy struct {
+	Name    string
+	Actual  bool
+	Every   time.Duration
+	Below   *big.Int // wei
+	Size    uint64   // bytes
+	MaxWait time.Duration
+}
+
+// ParsePolicy parses a policy: actual, every=<duration>, below=<price> with
+// a wei or gwei suffix (gwei by default), or size=<bytes> or size=<n>blobs.
+func ParsePolicy(s string, maxWait time.Duration) (Policy, error) {
+	p := Policy{Name: s, MaxWait: maxWait}
+	kind, value, _ := strings.Cut(s, "=")
+	switch kind {
+	case "actual":
+		p.Actual, p.MaxWait = true, 0
+	case "every":
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return p, fmt.Errorf("policy %s: want a positive duration", s)
+		}
+		p.Every = d
+	case "below":
+		price, err := parsePrice(value)
+		if err != nil {
+			return p, fmt.Errorf("policy %s: %w", s, err)
+		}
+		p.Below = price
+	case "size":
+		n, err := parseSize(value)
+		if err != nil {
+			return p, fmt.Errorf("policy %s: %w", s, err)
+		}
+		p.Size = n
+	default:
+		return p, fmt.Errorf("unknown policy %q, want actual, every=, below= or size=", s)
+	}
+	return p, nil
+}
+
+// parsePrice parses a price in gwei, or in wei with a wei suffix.
+func parsePrice(s string) (*big.Int, error) {
+	unit := big.NewFloat(params.GWei)
+	switch {
+	case strings.HasSuffix(s, "gwei"):
+		s = strings.TrimSuffix(s, "gwei")
+	case strings.HasSuffix(s, "wei"):
+		s, unit = strings.TrimSuffix(s, "wei"), big.NewFloat(1)
+	}
+	v, ok := new(big.Float).SetString(s)
+	if !ok || v.Sign() < 0 {
+		return nil, fmt.Errorf("%q is not a price", s)
+	}
+	price, _ := v.Mul(v, unit).Int(nil)
+	return price, nil
+}
+
+// parseSize parses a number of bytes, or of blobs with a blobs suffix.
+func parseSize(s string) (uint64, error) {
+	unit := uint64(1)
+	if strings.HasSuffix(s, "blobs") {
+		s, unit = strings.TrimSuffix(s, "blobs"), blobBytes
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || n == 0 {
+		return 0, fmt.Errorf("%q is not a positive size", s)
+	}
+	return n * unit, nil
+}
+
+// Limits bound the transactions of a submission: MaxCalldata bytes of
+// calldata or MaxBlobs blobs per transaction, as the batcher's settings.
+type Limits struct {
+	MaxCalldata uint64
+	MaxBlobs    uint64
+}
+
+// Result is what the submissions of a policy would have cost.
+type Result struct {
+	Policy      string
+	Submissions uint64 // times the pending data was posted
+	Txs         uint64
+	Blobs       uint64
+	CalldataGas uint64
+	BlobGas     uint64
+	Cost        *big.Int // wei
+	// AvgDelay is the average time the data waited to be posted, weighted by
+	// its size, and MaxDelay the longest.
+	AvgDelay time.Duration
+	MaxDelay time.Duration
+}
+
+// queue is the pending data of one posting mode.
+type queue struct {
+	blob   bool
+	bytes  uint64
+	oldest time.Time
+	// payloads are the sizes and times of the pending data, for the delays.
+	payloads []Payload
+}
+
+// run is the replay of payloads under a policy.
+type run struct {
+	policy Policy
+	prices *Prices
+	limits Limits
+	result Result
+	waited float64 // sum of bytes times seconds waited
+	posted uint64
+	queues [2]*queue // calldata, then blobs
+}
+
+// ErrNoPrices is returned when the payloads of a mode have no price.
+var ErrNoPrices = errors.New("no historical price")
+
+// Run replays payloads under policy with prices. The data still pending
+// after the last payload is posted when MaxWait expires, or at the last
+// payload or price without it.
+func Run(payloads []Payload, prices *Prices, policy Policy, limits Limits) (Result, error) {
+	prices.sort()
+	payloads = slices.Clone(payloads)
+	slices.SortStableFunc(payloads, func(a, b Payload) int { return a.Time.Compare(b.Time) })
+	for _, p := range payloads {
+		if len(prices.gas) == 0 {
+			return Result{}, fmt.Errorf("%w of gas", ErrNoPrices)
+		}
+		if p.Blob && len(prices.blob) == 0 {
+			return Result{}, fmt.Errorf("%w of blob gas", ErrNoPrices)
+		}
+	}
+	r := &run{
+		policy: policy,
+		prices: prices,
+		limits: limits,
+		result: Result{Policy: policy.Name, Cost: new(big.Int)},
+		queues: [2]*queue{{blob: false}, {blob: true}},
+	}
+	if len(payloads) == 0 {
+		return r.result, nil
+	}
+
+	// The policy is checked at every payload, and at every price or
+	// interval it depends on.
+	var checks []time.Time
+	first, last := payloads[0].Time, payloads[len(payloads)-1].Time
+	switch {
+	case policy.Every > 0:
+		for t := first.Truncate(policy.Every).Add(policy.Every); !t.After(last.Add(policy.Every)); t = t.Add(policy.Every) {
+			checks = append(checks, t)
+		}
+	case policy.Below != nil:
+		for _, t := range prices.times() {
+			if t.After(first) {
+				checks = append(checks, t)
+			}
+		}
+	}
+	end := last
+	if len(checks) > 0 {
+		end = checks[len(checks)-1]
+	}
+
+	next := 0
+	for _, p := range payloads {
+		for ; next < len(checks) && checks[next].Before(p.Time); next++ {
+			r.check(checks[next], true)
+		}
+		r.expire(p.Time)
+		q := r.queues[boolIndex(p.Blob)]
+		if q.bytes == 0 {
+			q.oldest = p.Time
+		}
+		q.bytes += p.Bytes
+		q.payloads = append(q.payloads, p)
+		r.check(p.Time, false)
+	}
+	for ; next < len(checks); next++ {
+		r.check(checks[next], true)
+	}
+	for _, q := range r.queues {
+		if q.bytes == 0 {
+			continue
+		}
+		t := end
+		if policy.MaxWait > 0 {
+			t = q.oldest.Add(policy.MaxWait)
+		}
+		r.post(q, t)
+	}
+	if r.posted > 0 {
+		r.result.AvgDelay = time.Duration(r.waited / float64(r.posted) * float64(time.Second))
+	}
+	return r.result, nil
+}
+
+func boolIndex(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// expire posts the data that has waited MaxWait by t, when it expired.
+func (r *run) expire(t time.Time) {
+	if r.policy.MaxWait <= 0 {
+		return
+	}
+	for _, q := range r.queues {
+		if q.bytes > 0 && !q.oldest.Add(r.policy.MaxWait).After(t) {
+			r.post(q, q.oldest.Add(r.policy.MaxWait))
+		}
+	}
+}
+
+// check posts the pending data that the policy posts at t, which is one of
+// the checks of the policy when scheduled is set, or the time of a payload.
+func (r *run) check(t time.Time, scheduled bool) {
+	r.expire(t)
+	for _, q := range r.queues {
+		if q.bytes == 0 {
+			continue
+		}
+		switch {
+		case r.policy.Actual:
+		case r.policy.Every > 0:
+			if !scheduled {
+				continue
+			}
+		case r.policy.Below != nil:
+			if r.price(q.blob, t).Cmp(r.policy.Below) > 0 {
+				continue
+			}
+		case r.policy.Size > 0:
+			if q.bytes < r.policy.Size {
+				continue
+			}
+		}
+		r.post(q, t)
+	}
+}
+
+// price returns the price of the gas of the data of a mode at t.
+func (r *run) price(blob bool, t time.Time) *big.Int {
+	if blob {
+		return at(r.prices.blob, t)
+	}
+	return at(r.prices.gas, t)
+}
+
+// post posts the data of q at t, in as many transactions as the limits take.
+func (r *run) post(q *queue, t time.Time) {
+	gasPrice := at(r.prices.gas, t)
+	perTx := r.limits.MaxCalldata
+	if q.blob {
+		perTx = r.limits.MaxBlobs * blobBytes
+	}
+	txs := (q.bytes + perTx - 1) / perTx
+	cost := new(big.Int).Mul(new(big.Int).SetUint64(txs*params.TxGas), gasPrice)
+	r.result.CalldataGas += txs * params.TxGas
+	if q.blob {
+		blobs := (q.bytes + blobBytes - 1) / blobBytes
+		blobGas := blobs * blobBytes
+		cost.Add(cost, new(big.Int).Mul(new(big.Int).SetUint64(blobGas), at(r.prices.blob, t)))
+		r.result.Blobs += blobs
+		r.result.BlobGas += blobGas
+	} else {
+		gas := q.bytes * calldataGasPerByte
+		cost.Add(cost, new(big.Int).Mul(new(big.Int).SetUint64(gas), gasPrice))
+		r.result.CalldataGas += gas
+	}
+	r.result.Cost.Add(r.result.Cost, cost)
+	r.result.Submissions++
+	r.result.Txs += txs
+
+	for _, p := range q.payloads {
+		delay := t.Sub(p.Time)
+		r.waited += delay.Seconds() * float64(p.Bytes)
+		r.posted += p.Bytes
+		r.result.MaxDelay = max(r.result.MaxDelay, delay)
+	}
+	q.bytes, q.payloads = 0, q.payloads[:0]
+}
@@ -0,0 +1,303 @@
+package sink
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+)
+
+func init() {
+	Register("dune", func(dsn string) (Sink, error) {
+		s, err := openDune(dsn)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	})
+}
+
+// duneBatch is the number of transactions sent per insert request.
+const duneBatch = 10000
+
+// duneTime is the text format of the timestamps inserted into Dune tables.
+const duneTime = "2006-01-02 15:04:05.000"
+
+// duneTableName matches the names Dune accepts for the tables of a namespace.
+var duneTableName = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// duneColumn is a column of the schema of a Dune table.
+type duneColumn struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable,omitempty"`
+}
+
+// Schemas of the transactions and buckets tables. Dune tables only take
+// inserts, so every row carries the time it was inserted at; queries take the
+// latest row of every hash and bucket, which drops the rows repeated by
+// overlapping runs and the buckets of a day still in progress.
+var (
+	duneTxSchema = []duneColumn{
+		{Name: "hash", Type: "varchar"},
+		{Name: "block", Type: "integer"},
+		{Name: "time", Type: "timestamp"},
+		{Name: "bucket", Type: "varchar"},
+		{Name: "type", Type: "integer"},
+		{Name: "gas_used", Type: "integer"},
+		{Name: "gas_price_wei", Type: "uint256"},
+		{Name: "blob_gas_used", Type: "integer"},
+		{Name: "blob_gas_price_wei", Type: "uint256", Nullable: true},
+		{Name: "cost_wei", Type: "uint256"},
+		{Name: "calldata_cost_wei", Type: "uint256"},
+		{Name: "blob_cost_wei", Type: "uint256"},
+		{Name: "cost_eth", Type: "double"},
+		{Name: "inserted_at", Type: "timestamp"},
+	}
+	duneBucketSchema = []duneColumn{
+		{Name: "bucket", Type: "varchar"},
+		{Name: "cost_eth", Type: "double", Nullable: true},
+		{Name: "calldata_cost_eth", Type: "double"},
+		{Name: "blob_cost_eth", Type: "double", Nullable: true},
+		{Name: "avg_calldata_gas_price_gwei", Type: "double"},
+		{Name: "avg_blob_gas_price_gwei", Type: "double", Nullable: true},
+		{Name: "calldata_gas_used", Type: "integer"},
+		{Name: "blob_gas_used", Type: "integer"},
+		{Name: "tx_count", Type: "integer"},
+		{Name: "blob_tx_count", Type: "integer"},
+		{Name: "reverted_tx_count", Type: "integer"},
+		{Name: "inserted_at", Type: "timestamp"},
+	}
+)
+
+// duneSink inserts the transactions and the buckets of a run into two tables
+// of a Dune namespace through the table API of Dune, as newline-delimited
+// JSON, so that dashboards built on them stay current.
+type duneSink struct {
+	baseURL   string
+	apiKey    string
+	namespace string
+	txTable   string
+	dayTable  string
+	http      *http.Client
+	batch     bytes.Buffer
+	rows      int
+}
+
+// duneTx is a row of the transactions table. Wei amounts are written as bare
+// JSON numbers, which Dune parses into uint256 exactly.
+type duneTx struct {
+	Hash            string       `json:"hash"`
+	Block           uint64       `json:"block"`
+	Time            string       `json:"time"`
+	Bucket          string       `json:"bucket"`
+	Type            uint8        `json:"type"`
+	GasUsed         uint64       `json:"gas_used"`
+	GasPriceWei     json.Number  `json:"gas_price_wei"`
+	BlobGasUsed     uint64       `json:"blob_gas_used"`
+	BlobGasPriceWei *json.Number `json:"blob_gas_price_wei"`
+	CostWei         json.Number  `json:"cost_wei"`
+	CalldataCostWei json.Number  `json:"calldata_cost_wei"`
+	BlobCostWei     json.Number  `json:"blob_cost_wei"`
+	CostEth         float64      `json:"cost_eth"`
+	InsertedAt      string       `json:"inserted_at"`
+}
+
+// duneBucket is a row of the buckets table. The amounts that include blob
+// fees are null for buckets with blob transactions of unknown blob gas price.
+type duneBucket struct {
+	Bucket                  string   `json:"bucket"`
+	CostEth                 *float64 `json:"cost_eth"`
+	CalldataCostEth         float64  `json:"calldata_cost_eth"`
+	BlobCostEth             *float64 `json:"blob_cost_eth"`
+	AvgCalldataGasPriceGwei float64  `json:"avg_calldata_gas_price_gwei"`
+	AvgBlobGasPriceGwei     *float64 `json:"avg_blob_gas_price_gwei"`
+	CalldataGasUsed         uint64   `json:"calldata_gas_used"`
+	BlobGasUsed             uint64   `json:"blob_gas_used"`
+	TxCount                 uint64   `json:"tx_count"`
+	BlobTxCount             uint64   `json:"blob_tx_count"`
+	RevertedTxCount         uint64   `json:"reverted_tx_count"`
+	InsertedAt              string   `json:"inserted_at"`
+}
+
+// openDune creates the tables of dsn, given as
+// [dune://]namespace[/prefix][?key=...&url=...], unless they exist. The
+// tables are <prefix>_transactions and <prefix>_daily, gas_tracker_* by
+// default; the API key defaults to DUNE_API_KEY and the endpoint to
+// https://api.dune.com/api/v1.
+func openDune(dsn string) (*duneSink, error) {
+	path, rawQuery, _ := strings.Cut(strings.TrimPrefix(dsn, "dune://"), "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("dune dsn: %w", err)
+	}
+	namespace, prefix, _ := strings.Cut(strings.Trim(path, "/"), "/")
+	if namespace == "" {
+		return nil, errors.New("dune dsn: no namespace, want namespace[/prefix]")
+	}
+	if prefix == "" {
+		prefix = "gas_tracker"
+	}
+	if !duneTableName.MatchString(prefix) {
+		return nil, fmt.Errorf("dune dsn: table prefix %q is not lowercase letters, digits and underscores", prefix)
+	}
+	s := &duneSink{
+		baseURL:   "https://api.dune.com/api/v1",
+		apiKey:    query.Get("key"),
+		namespace: namespace,
+		txTable:   prefix + "_transactions",
+		dayTable:  prefix + "_daily",
+		http:      &http.Client{Timeout: time.Minute},
+	}
+	if u := query.Get("url"); u != "" {
+		s.baseURL = strings.TrimSuffix(u, "/")
+	}
+	if s.apiKey == "" {
+		s.apiKey = os.Getenv("DUNE_API_KEY")
+	}
+	if s.apiKey == "" {
+		return nil, errors.New("dune dsn: no API key, set key= or DUNE_API_KEY")
+	}
+	for _, table := range []struct {
+		name        string
+		description string
+		schema      []duneColumn
+	}{
+		{s.txTable, "L1 costs of the batcher transactions, one row per transaction and run", duneTxSchema},
+		{s.dayTable, "L1 costs of the batcher per report bucket, one row per bucket and run", duneBucketSchema},
+	} {
+		body := map[string]any{
+			"namespace":   s.namespace,
+			"table_name":  table.name,
+			"description": table.description,
+			"schema":      table.schema,
+			"is_private":  false,
+		}
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.post("/table/create", "application/json", payload); err != nil {
+			return nil, fmt.Errorf("dune table %s: %w", table.name, err)
+		}
+	}
+	return s, nil
+}
+
+// post sends body to path of the API.
+func (s *duneSink) post(path, contentType string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, s.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Dune-API-Key", s.apiKey)
+	req.Header.Set("Content-Type", contentType)
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("dune: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// insert inserts the newline-delimited JSON rows into table.
+func (s *duneSink) insert(table string, rows []byte) error {
+	return s.post("/table/"+url.PathEscape(s.namespace)+"/"+table+"/insert", "application/x-ndjson", rows)
+}
+
+func (s *duneSink) WriteTx(tx aggregate.Tx) error {
+	row := duneTx{
+		Hash:            tx.Hash.Hex(),
+		Block:           tx.Block,
+		Time:            tx.Time.UTC().Format(duneTime),
+		Bucket:          tx.Bucket,
+		Type:            tx.Type,
+		GasUsed:         tx.GasUsed,
+		GasPriceWei:     json.Number(tx.GasPrice.String()),
+		BlobGasUsed:     tx.BlobGasUsed,
+		CostWei:         json.Number(tx.Cost.String()),
+		CalldataCostWei: json.Number(tx.CalldataCost.String()),
+		BlobCostWei:     json.Number(tx.BlobCost.String()),
+		CostEth:         inUnits(new(big.Float).SetInt(tx.Cost), params.Ether),
+		InsertedAt:      time.Now().UTC().Format(duneTime),
+	}
+	if tx.BlobGasPrice != nil {
+		price := json.Number(tx.BlobGasPrice.String())
+		row.BlobGasPriceWei = &price
+	}
+	if err := json.NewEncoder(&s.batch).Encode(row); err != nil {
+		return err
+	}
+	if s.rows++; s.rows >= duneBatch {
+		return s.flush()
+	}
+	return nil
+}
+
+// WriteBuckets inserts one row per bucket into the buckets table.
+func (s *duneSink) WriteBuckets(dates []string, results map[string]*aggregate.Result) error {
+	if len(dates) == 0 {
+		return nil
+	}
+	now := time.Now().UTC().Format(duneTime)
+	var rows bytes.Buffer
+	enc := json.NewEncoder(&rows)
+	for _, k := range dates {
+		r := results[k]
+		row := duneBucket{
+			Bucket:                  k,
+			CalldataCostEth:         inUnits(r.CalldataCost, 1),
+			AvgCalldataGasPriceGwei: inUnits(r.AvgCallDataGasPrice, 1),
+			CalldataGasUsed:         r.TotalCalldataGasUsed,
+			BlobGasUsed:             r.TotalBlobGasUsed,
+			TxCount:                 r.TxCount,
+			BlobTxCount:             r.BlobTxCount,
+			RevertedTxCount:         r.RevertedTxCount,
+			InsertedAt:              now,
+		}
+		if r.BlobPriceMissing == 0 {
+			cost, blob, price := inUnits(r.Cost, 1), inUnits(r.BlobCost, 1), inUnits(r.AvgBlobGasPrice, 1)
+			row.CostEth, row.BlobCostEth, row.AvgBlobGasPriceGwei = &cost, &blob, &price
+		}
+		if err := enc.Encode(row); err != nil {
+			return err
+		}
+	}
+	return s.insert(s.dayTable, rows.Bytes())
+}
+
+// flush inserts the buffered transactions.
+func (s *duneSink) flush() error {
+	if s.rows == 0 {
+		return nil
+	}
+	err := s.insert(s.txTable, s.batch.Bytes())
+	s.batch.Reset()
+	s.rows = 0
+	return err
+}
+
+// Flush inserts the pending transactions.
+func (s *duneSink) Flush() error {
+	return s.flush()
+}
+
+func (s *duneSink) Close() error {
+	return s.flush()
+}
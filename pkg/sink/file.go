@@ -0,0 +1,40 @@
+package sink
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+)
+
+func init() {
+	Register("csv", func(dsn string) (Sink, error) {
+		w, err := output.CreateCSVTx(dsn, true)
+		if err != nil {
+			return nil, err
+		}
+		return &csvSink{CSVTxWriter: w, path: dsn}, nil
+	})
+	Register("jsonl", func(dsn string) (Sink, error) {
+		w, err := output.CreateJSONL(dsn, true)
+		if err != nil {
+			return nil, err
+		}
+		return w, nil
+	})
+}
+
+// csvSink appends the rows of the transactions to a CSV file, and writes the
+// per-bucket results of the run next to it, in <name>.daily.csv. Unlike a
+// database, the file keeps the duplicates of overlapping runs.
+type csvSink struct {
+	*output.CSVTxWriter
+	path string
+}
+
+// WriteBuckets replaces the per-bucket table with the results of the run.
+func (s *csvSink) WriteBuckets(dates []string, results map[string]*aggregate.Result) error {
+	base := strings.TrimSuffix(s.path, filepath.Ext(s.path))
+	return output.WriteCSV(base+".daily.csv", dates, results, aggregate.Total(results))
+}
@@ -0,0 +1,140 @@
+//go:build !nokafka
+
+package sink
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+)
+
+func init() {
+	Register("kafka", func(dsn string) (Sink, error) {
+		s, err := openKafka(dsn)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	})
+}
+
+// kafkaBatch is the number of messages produced per request.
+const kafkaBatch = 1000
+
+// kafkaSink publishes the JSON Lines objects of the transactions and of the
+// buckets to two topics. Messages with the same key go to the same partition,
+// as with the Java client, so consumers see them in order.
+type kafkaSink struct {
+	writer    *kafka.Writer
+	txTopic   string
+	dayTopic  string
+	keyByDate bool // key transactions by bucket rather than by hash
+	pending   []kafka.Message
+}
+
+// openKafka connects to the brokers of dsn, given as
+// [kafka://]host:port[,host:port...][?tx_topic=...&day_topic=...&key=hash|date].
+func openKafka(dsn string) (*kafkaSink, error) {
+	brokers, rawQuery, _ := strings.Cut(strings.TrimPrefix(dsn, "kafka://"), "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("kafka dsn: %w", err)
+	}
+	s := &kafkaSink{
+		txTopic:  "gas-tracker.transactions",
+		dayTopic: "gas-tracker.daily",
+	}
+	if topic := query.Get("tx_topic"); topic != "" {
+		s.txTopic = topic
+	}
+	if topic := query.Get("day_topic"); topic != "" {
+		s.dayTopic = topic
+	}
+	switch key := query.Get("key"); key {
+	case "", "hash":
+	case "date":
+		s.keyByDate = true
+	default:
+		return nil, fmt.Errorf("kafka dsn: unknown key %q, want hash or date", key)
+	}
+
+	addrs := strings.Split(brokers, ",")
+	// The writer connects lazily; dial once so that a wrong address fails
+	// before the run rather than after it.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	conn, err := kafka.DialContext(ctx, "tcp", addrs[0])
+	if err != nil {
+		return nil, fmt.Errorf("kafka: %w", err)
+	}
+	conn.Close()
+
+	s.writer = &kafka.Writer{
+		Addr:                   kafka.TCP(addrs...),
+		Balancer:               kafka.Murmur2Balancer{},
+		RequiredAcks:           kafka.RequireAll,
+		BatchSize:              kafkaBatch,
+		BatchTimeout:           100 * time.Millisecond,
+		AllowAutoTopicCreation: true,
+	}
+	return s, nil
+}
+
+func (s *kafkaSink) WriteTx(tx aggregate.Tx) error {
+	value, err := output.EncodeTx(tx)
+	if err != nil {
+		return err
+	}
+	key := tx.Hash.Hex()
+	if s.keyByDate {
+		key = tx.Bucket
+	}
+	s.pending = append(s.pending, kafka.Message{Topic: s.txTopic, Key: []byte(key), Value: value})
+	if len(s.pending) >= kafkaBatch {
+		return s.flush()
+	}
+	return nil
+}
+
+// WriteBuckets publishes one message per bucket, keyed by bucket.
+func (s *kafkaSink) WriteBuckets(dates []string, results map[string]*aggregate.Result) error {
+	for _, k := range dates {
+		value, err := output.EncodeBucket(k, results[k])
+		if err != nil {
+			return err
+		}
+		s.pending = append(s.pending, kafka.Message{Topic: s.dayTopic, Key: []byte(k), Value: value})
+	}
+	return s.flush()
+}
+
+func (s *kafkaSink) flush() error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	err := s.writer.WriteMessages(ctx, s.pending...)
+	s.pending = s.pending[:0]
+	return err
+}
+
+// Flush publishes the pending messages.
+func (s *kafkaSink) Flush() error {
+	return s.flush()
+}
+
+func (s *kafkaSink) Close() error {
+	err := s.flush()
+	if closeErr := s.writer.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
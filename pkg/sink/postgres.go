@@ -0,0 +1,60 @@
+package sink
+
+import (
+	"strconv"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+func init() {
+	Register("postgres", func(dsn string) (Sink, error) {
+		s, err := openSQL(postgres, dsn)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	})
+}
+
+// postgres stores wei amounts as NUMERIC(38, 0), so that they can be summed
+// exactly in SQL, e.g. by Grafana queries.
+var postgres = dialect{
+	driver:      "pgx",
+	placeholder: func(n int) string { return "$" + strconv.Itoa(n) },
+	migrations: []string{
+		`CREATE TABLE IF NOT EXISTS transactions (
+			hash               TEXT PRIMARY KEY,
+			block              BIGINT NOT NULL,
+			time               TIMESTAMPTZ NOT NULL,
+			day                DATE NOT NULL,
+			type               SMALLINT NOT NULL,
+			gas_used           BIGINT NOT NULL,
+			gas_price_wei      NUMERIC(38, 0) NOT NULL,
+			blob_gas_used      BIGINT NOT NULL,
+			blob_gas_price_wei NUMERIC(38, 0),
+			cost_wei           NUMERIC(38, 0) NOT NULL,
+			calldata_cost_wei  NUMERIC(38, 0) NOT NULL,
+			blob_cost_wei      NUMERIC(38, 0) NOT NULL,
+			cost_eth           DOUBLE PRECISION NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS transactions_day ON transactions (day)`,
+		`CREATE TABLE IF NOT EXISTS daily_costs (
+			day                         DATE PRIMARY KEY,
+			cost_wei                    NUMERIC(38, 0),
+			cost_eth                    DOUBLE PRECISION,
+			calldata_cost_wei           NUMERIC(38, 0) NOT NULL,
+			calldata_cost_eth           DOUBLE PRECISION NOT NULL,
+			blob_cost_wei               NUMERIC(38, 0),
+			blob_cost_eth               DOUBLE PRECISION,
+			avg_calldata_gas_price_gwei DOUBLE PRECISION NOT NULL,
+			avg_blob_gas_price_gwei     DOUBLE PRECISION,
+			blended_gas_price_gwei      DOUBLE PRECISION,
+			calldata_gas_used           BIGINT NOT NULL,
+			blob_gas_used               BIGINT NOT NULL,
+			gas_used                    BIGINT NOT NULL,
+			tx_count                    BIGINT NOT NULL,
+			blob_price_missing          BIGINT NOT NULL,
+			updated_at                  TIMESTAMPTZ NOT NULL
+		)`,
+	},
+}
@@ -0,0 +1,91 @@
+// Package sink persists the costs of every transaction and the daily
+// aggregates derived from them into a database, so that the history of
+// repeated and overlapping runs accumulates without duplicates.
+package sink
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+)
+
+// Sink receives the transactions of a run. Close writes the pending ones and
+// brings the daily aggregates of the days they belong to up to date.
+type Sink interface {
+	WriteTx(aggregate.Tx) error
+	Close() error
+}
+
+// BucketWriter is implemented by sinks that also publish the per-bucket
+// results of a run, which are given before Close.
+type BucketWriter interface {
+	WriteBuckets(dates []string, results map[string]*aggregate.Result) error
+}
+
+// Flusher is implemented by sinks that can store what they received so far
+// without closing, bringing the daily aggregates up to date as Close would.
+// A long-running daemon flushes after every block it scans.
+type Flusher interface {
+	Flush() error
+}
+
+// Opener opens a sink of one kind from its dsn and brings its schema up to
+// date.
+type Opener func(dsn string) (Sink, error)
+
+var (
+	openersMu sync.RWMutex
+	openers   = make(map[string]Opener)
+)
+
+// Register makes a kind of sink available to Open. It is meant to be called
+// from the init function of the package implementing the sink, which can be
+// compiled in behind a build tag or loaded as a Go plugin. Register panics if
+// open is nil or the kind is already registered, as database/sql does for
+// drivers.
+func Register(kind string, open Opener) {
+	openersMu.Lock()
+	defer openersMu.Unlock()
+	if open == nil {
+		panic("sink: Register opener is nil")
+	}
+	if _, dup := openers[kind]; dup {
+		panic("sink: Register called twice for " + kind)
+	}
+	openers[kind] = open
+}
+
+// Kinds returns the registered kinds of sink in order.
+func Kinds() []string {
+	openersMu.RLock()
+	defer openersMu.RUnlock()
+	kinds := make([]string, 0, len(openers))
+	for kind := range openers {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// Open opens a sink of the given kind and brings its schema up to date. For
+// "sqlite", dsn is the path of the database file, which is created if needed;
+// for "postgres" it is a connection URL or keyword/value string as accepted
+// by libpq; for "clickhouse" it is the URL of the HTTP interface with the
+// database as its path; for "kafka" it lists the brokers, optionally followed
+// by the topics and the key of the transactions, as in
+// broker1:9092,broker2:9092?tx_topic=txs&day_topic=days&key=date; for "dune"
+// it is the namespace of the tables and their prefix, as in
+// my_team/thanos_batcher; for "csv" and "jsonl" it is the path of the file
+// the transactions are appended to. Other kinds are those given to Register.
+func Open(kind, dsn string) (Sink, error) {
+	openersMu.RLock()
+	open, ok := openers[kind]
+	openersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown sink %q, want one of %s", kind, strings.Join(Kinds(), ", "))
+	}
+	return open(dsn)
+}
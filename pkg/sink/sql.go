@@ -0,0 +1,292 @@
+package sink
+
+import (
+	"database/sql"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/input"
+)
+
+// dialect holds what differs between the SQL databases. The statements
+// themselves use ON CONFLICT upserts, which all of them understand.
+type dialect struct {
+	driver      string
+	placeholder func(n int) string // the n-th (1-based) parameter
+	// migrations create and evolve the schema. They are applied in order,
+	// each once, and only ever appended to; schema_migrations records how
+	// many a database has seen.
+	migrations []string
+}
+
+// txColumns and dayColumns are the columns written by the upserts, in the
+// order of their parameters. The first one is the primary key.
+var (
+	txColumns = []string{
+		"hash", "block", "time", "day", "type", "gas_used", "gas_price_wei",
+		"blob_gas_used", "blob_gas_price_wei", "cost_wei", "calldata_cost_wei",
+		"blob_cost_wei", "cost_eth",
+	}
+	dayColumns = []string{
+		"day", "cost_wei", "cost_eth", "calldata_cost_wei", "calldata_cost_eth",
+		"blob_cost_wei", "blob_cost_eth", "avg_calldata_gas_price_gwei",
+		"avg_blob_gas_price_gwei", "blended_gas_price_gwei", "calldata_gas_used",
+		"blob_gas_used", "gas_used", "tx_count", "blob_price_missing", "updated_at",
+	}
+)
+
+// commitEvery is the number of transactions written per database
+// transaction, so that an interrupted run keeps most of its rows.
+const commitEvery = 500
+
+// sqlSink upserts transactions by hash and recomputes the daily aggregates
+// of the days it touched from all the transactions stored for them, so that a
+// day covered partly by one run and partly by another adds up.
+type sqlSink struct {
+	db       *sql.DB
+	d        dialect
+	upsertTx string
+	tx       *sql.Tx
+	pending  int
+	days     map[string]bool
+}
+
+func openSQL(d dialect, dsn string) (*sqlSink, error) {
+	db, err := sql.Open(d.driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrate(db, d); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s schema: %w", d.driver, err)
+	}
+	return &sqlSink{
+		db:       db,
+		d:        d,
+		upsertTx: upsert(d, "transactions", txColumns),
+		days:     make(map[string]bool),
+	}, nil
+}
+
+// migrate applies the migrations of d that db has not seen yet.
+func migrate(db *sql.DB, d dialect) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TIMESTAMP NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+	var version int
+	if err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version); err != nil {
+		return err
+	}
+	if version > len(d.migrations) {
+		return fmt.Errorf("database schema version %d is newer than this tool's %d", version, len(d.migrations))
+	}
+	for ; version < len(d.migrations); version++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(d.migrations[version]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", version+1, err)
+		}
+		record := fmt.Sprintf("INSERT INTO schema_migrations (version, applied_at) VALUES (%s, %s)", d.placeholder(1), d.placeholder(2))
+		if _, err := tx.Exec(record, version+1, time.Now().UTC()); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", version+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// upsert returns an INSERT of columns into table that overwrites the row with
+// the same primary key, the first column.
+func upsert(d dialect, table string, columns []string) string {
+	placeholders := make([]string, len(columns))
+	updates := make([]string, 0, len(columns)-1)
+	for i, column := range columns {
+		placeholders[i] = d.placeholder(i + 1)
+		if i > 0 {
+			updates = append(updates, column+" = excluded."+column)
+		}
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) DO UPDATE SET %s",
+		table, strings.Join(columns, ", "), strings.Join(placeholders, ", "), columns[0], strings.Join(updates, ", "))
+}
+
+func (s *sqlSink) WriteTx(tx aggregate.Tx) error {
+	if s.tx == nil {
+		var err error
+		if s.tx, err = s.db.Begin(); err != nil {
+			return err
+		}
+	}
+	day := tx.Time.UTC().Format("2006-01-02")
+	var blobGasPrice *string
+	if tx.BlobGasPrice != nil {
+		price := tx.BlobGasPrice.String()
+		blobGasPrice = &price
+	}
+	_, err := s.tx.Exec(s.upsertTx,
+		tx.Hash.Hex(), int64(tx.Block), tx.Time.UTC(), day, int64(tx.Type), int64(tx.GasUsed),
+		tx.GasPrice.String(), int64(tx.BlobGasUsed), blobGasPrice, tx.Cost.String(),
+		tx.CalldataCost.String(), tx.BlobCost.String(), inUnits(new(big.Float).SetInt(tx.Cost), params.Ether))
+	if err != nil {
+		return err
+	}
+	s.days[day] = true
+	if s.pending++; s.pending >= commitEvery {
+		return s.commit()
+	}
+	return nil
+}
+
+func (s *sqlSink) commit() error {
+	if s.tx == nil {
+		return nil
+	}
+	err := s.tx.Commit()
+	s.tx, s.pending = nil, 0
+	return err
+}
+
+// Flush commits the pending transactions and refreshes the aggregates of the
+// days touched since the last flush.
+func (s *sqlSink) Flush() error {
+	if err := s.commit(); err != nil {
+		return err
+	}
+	if err := s.refresh(); err != nil {
+		return err
+	}
+	s.days = make(map[string]bool)
+	return nil
+}
+
+func (s *sqlSink) Close() error {
+	err := s.Flush()
+	if closeErr := s.db.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// refresh recomputes the daily aggregates of the touched days.
+func (s *sqlSink) refresh() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	upsertDay := upsert(s.d, "daily_costs", dayColumns)
+	for day := range s.days {
+		values, err := s.aggregateDay(tx, day)
+		if err != nil {
+			return fmt.Errorf("day %s: %w", day, err)
+		}
+		if values == nil {
+			continue
+		}
+		if _, err := tx.Exec(upsertDay, values...); err != nil {
+			return fmt.Errorf("day %s: %w", day, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// aggregateDay aggregates the stored transactions of day like a run would and
+// returns the daily_costs row, or nil when there are none. Costs are summed
+// exactly in wei.
+func (s *sqlSink) aggregateDay(tx *sql.Tx, day string) ([]any, error) {
+	t, err := time.Parse("2006-01-02", day)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query(fmt.Sprintf(
+		"SELECT hash, type, gas_used, gas_price_wei, blob_gas_used, blob_gas_price_wei, cost_wei, calldata_cost_wei, blob_cost_wei FROM transactions WHERE day = %s",
+		s.d.placeholder(1)), day)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	agg := aggregate.New("day")
+	cost, calldataCost, blobCost := new(big.Int), new(big.Int), new(big.Int)
+	for rows.Next() {
+		var (
+			hash                                    string
+			txType, gasUsed, blobGasUsed            int64
+			gasPrice, costWei, calldataWei, blobWei string
+			blobGasPrice                            sql.NullString
+		)
+		if err := rows.Scan(&hash, &txType, &gasUsed, &gasPrice, &blobGasUsed, &blobGasPrice, &costWei, &calldataWei, &blobWei); err != nil {
+			return nil, err
+		}
+		// Reverted transactions are not told apart in the table.
+		receipt := &types.Receipt{
+			Status:            types.ReceiptStatusSuccessful,
+			Type:              uint8(txType),
+			GasUsed:           uint64(gasUsed),
+			EffectiveGasPrice: parseWei(gasPrice),
+			BlobGasUsed:       uint64(blobGasUsed),
+		}
+		if blobGasPrice.Valid {
+			receipt.BlobGasPrice = parseWei(blobGasPrice.String)
+		}
+		agg.Add(input.Row{Hash: common.HexToHash(hash), Time: t}, receipt)
+		cost.Add(cost, parseWei(costWei))
+		calldataCost.Add(calldataCost, parseWei(calldataWei))
+		blobCost.Add(blobCost, parseWei(blobWei))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	dates, _ := agg.Finalize()
+	if len(dates) == 0 {
+		return nil, nil
+	}
+	r := agg.Results[dates[0]]
+
+	// Columns that depend on a missing blob gas price are null.
+	var costWeiCol, costEthCol, blobWeiCol, blobEthCol, avgBlobCol, blendedCol any
+	if r.BlobPriceMissing == 0 {
+		costWeiCol, costEthCol = cost.String(), inUnits(new(big.Float).SetInt(cost), params.Ether)
+		blobWeiCol, blobEthCol = blobCost.String(), inUnits(new(big.Float).SetInt(blobCost), params.Ether)
+		avgBlobCol = inUnits(r.AvgBlobGasPrice, 1)
+		blendedCol = inUnits(r.BlendedGasPrice, 1)
+	}
+	return []any{
+		day, costWeiCol, costEthCol,
+		calldataCost.String(), inUnits(new(big.Float).SetInt(calldataCost), params.Ether),
+		blobWeiCol, blobEthCol, inUnits(r.AvgCallDataGasPrice, 1), avgBlobCol, blendedCol,
+		int64(r.TotalCalldataGasUsed), int64(r.TotalBlobGasUsed), int64(r.TotalGasUsed),
+		int64(r.TxCount), int64(r.BlobPriceMissing), time.Now().UTC(),
+	}, nil
+}
+
+// inUnits returns value divided by unit as a float64.
+func inUnits(value *big.Float, unit float64) float64 {
+	f, _ := new(big.Float).Quo(value, big.NewFloat(unit)).Float64()
+	return f
+}
+
+func parseWei(s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return new(big.Int)
+	}
+	return n
+}
@@ -0,0 +1,72 @@
+package sink
+
+import (
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+func init() {
+	Register("sqlite", func(dsn string) (Sink, error) {
+		s, err := openSQL(sqlite, sqliteDSN(dsn))
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	})
+}
+
+// sqliteDSN makes the driver store times in the format of SQLite's date and
+// time functions.
+func sqliteDSN(path string) string {
+	if strings.Contains(path, "_time_format=") {
+		return path
+	}
+	if strings.Contains(path, "?") {
+		return path + "&_time_format=sqlite"
+	}
+	return path + "?_time_format=sqlite"
+}
+
+// sqlite stores wei amounts as decimal text, since SQLite integers stop at
+// 2^63; the sums are computed in Go.
+var sqlite = dialect{
+	driver:      "sqlite",
+	placeholder: func(int) string { return "?" },
+	migrations: []string{
+		`CREATE TABLE IF NOT EXISTS transactions (
+			hash               TEXT PRIMARY KEY,
+			block              INTEGER NOT NULL,
+			time               TIMESTAMP NOT NULL,
+			day                TEXT NOT NULL,
+			type               INTEGER NOT NULL,
+			gas_used           INTEGER NOT NULL,
+			gas_price_wei      TEXT NOT NULL,
+			blob_gas_used      INTEGER NOT NULL,
+			blob_gas_price_wei TEXT,
+			cost_wei           TEXT NOT NULL,
+			calldata_cost_wei  TEXT NOT NULL,
+			blob_cost_wei      TEXT NOT NULL,
+			cost_eth           REAL NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS transactions_day ON transactions (day)`,
+		`CREATE TABLE IF NOT EXISTS daily_costs (
+			day                         TEXT PRIMARY KEY,
+			cost_wei                    TEXT,
+			cost_eth                    REAL,
+			calldata_cost_wei           TEXT NOT NULL,
+			calldata_cost_eth           REAL NOT NULL,
+			blob_cost_wei               TEXT,
+			blob_cost_eth               REAL,
+			avg_calldata_gas_price_gwei REAL NOT NULL,
+			avg_blob_gas_price_gwei     REAL,
+			blended_gas_price_gwei      REAL,
+			calldata_gas_used           INTEGER NOT NULL,
+			blob_gas_used               INTEGER NOT NULL,
+			gas_used                    INTEGER NOT NULL,
+			tx_count                    INTEGER NOT NULL,
+			blob_price_missing          INTEGER NOT NULL,
+			updated_at                  TIMESTAMP NOT NULL
+		)`,
+	},
+}
@@ -0,0 +1,203 @@
+package sink
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+)
+
+var (
+	// ErrNotFound is returned by Store.Tx for a transaction that is not
+	// stored.
+	ErrNotFound = errors.New("not found")
+	// ErrInvalidDay is returned by Store.Days for a bound that is not a
+	// YYYY-MM-DD day.
+	ErrInvalidDay = errors.New("invalid day")
+)
+
+// Store reads back what an SQL sink wrote.
+type Store struct {
+	db *sql.DB
+	d  dialect
+}
+
+// OpenStore opens the database of a "sqlite" or "postgres" sink, with dsn as
+// for Open. Unlike Open, it does not create a missing SQLite file.
+func OpenStore(kind, dsn string) (*Store, error) {
+	var d dialect
+	switch kind {
+	case "sqlite":
+		path, _, _ := strings.Cut(dsn, "?")
+		if _, err := os.Stat(strings.TrimPrefix(path, "file:")); err != nil {
+			return nil, err
+		}
+		d, dsn = sqlite, sqliteDSN(dsn)
+	case "postgres":
+		d = postgres
+	default:
+		return nil, fmt.Errorf("cannot query a %q sink", kind)
+	}
+	db, err := sql.Open(d.driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrate(db, d); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s schema: %w", d.driver, err)
+	}
+	return &Store{db: db, d: d}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Days returns the stored daily aggregates from day from to day to, both
+// YYYY-MM-DD and inclusive, in ascending order. An empty bound is open.
+func (s *Store) Days(ctx context.Context, from, to string) ([]string, map[string]*aggregate.Result, error) {
+	where, args, err := s.dayRange(from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT CAST(day AS TEXT), CAST(cost_wei AS TEXT), CAST(calldata_cost_wei AS TEXT), CAST(blob_cost_wei AS TEXT),
+		avg_calldata_gas_price_gwei, avg_blob_gas_price_gwei, blended_gas_price_gwei,
+		calldata_gas_used, blob_gas_used, gas_used, tx_count, blob_price_missing
+		FROM daily_costs`+where+" ORDER BY day", args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var dates []string
+	results := make(map[string]*aggregate.Result)
+	for rows.Next() {
+		var (
+			day, calldataWei                              string
+			costWei, blobWei                              sql.NullString
+			avgCalldata                                   float64
+			avgBlob, blended                              sql.NullFloat64
+			calldataGas, blobGas, gas, count, blobMissing int64
+		)
+		if err := rows.Scan(&day, &costWei, &calldataWei, &blobWei, &avgCalldata, &avgBlob, &blended,
+			&calldataGas, &blobGas, &gas, &count, &blobMissing); err != nil {
+			return nil, nil, err
+		}
+		// Values unavailable for lack of a blob gas price are null and stay
+		// zero; BlobPriceMissing marks them.
+		r := aggregate.NewResult()
+		r.CostWei, r.CalldataCostWei, r.BlobCostWei = parseWei(costWei.String), parseWei(calldataWei), parseWei(blobWei.String)
+		r.Cost = ether(costWei.String)
+		r.CalldataCost = ether(calldataWei)
+		r.BlobCost = ether(blobWei.String)
+		r.AvgCallDataGasPrice.SetFloat64(avgCalldata)
+		r.AvgBlobGasPrice.SetFloat64(avgBlob.Float64)
+		r.BlendedGasPrice.SetFloat64(blended.Float64)
+		r.TotalCalldataGasUsed = uint64(calldataGas)
+		r.TotalBlobGasUsed = uint64(blobGas)
+		r.TotalGasUsed = uint64(gas)
+		r.TxCount = uint64(count)
+		r.BlobPriceMissing = uint64(blobMissing)
+		day = day[:len("2006-01-02")]
+		dates = append(dates, day)
+		results[day] = r
+	}
+	return dates, results, rows.Err()
+}
+
+// UpdatedAt returns when the aggregate of each stored day from day from on,
+// or of every day if from is empty, was last written.
+func (s *Store) UpdatedAt(ctx context.Context, from string) (map[string]time.Time, error) {
+	where, args, err := s.dayRange(from, "")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, "SELECT CAST(day AS TEXT), updated_at FROM daily_costs"+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	updated := make(map[string]time.Time)
+	for rows.Next() {
+		var day string
+		var t time.Time
+		if err := rows.Scan(&day, &t); err != nil {
+			return nil, err
+		}
+		updated[day[:len("2006-01-02")]] = t
+	}
+	return updated, rows.Err()
+}
+
+// dayRange returns the WHERE clause, if any, and the parameters selecting the
+// days from from to to, both inclusive.
+func (s *Store) dayRange(from, to string) (string, []any, error) {
+	var where []string
+	var args []any
+	for _, bound := range []struct{ op, day string }{{">=", from}, {"<=", to}} {
+		if bound.day == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", bound.day); err != nil {
+			return "", nil, fmt.Errorf("%w %q", ErrInvalidDay, bound.day)
+		}
+		args = append(args, bound.day)
+		where = append(where, fmt.Sprintf("day %s %s", bound.op, s.d.placeholder(len(args))))
+	}
+	if len(where) == 0 {
+		return "", nil, nil
+	}
+	return " WHERE " + strings.Join(where, " AND "), args, nil
+}
+
+// Tx returns the stored transaction with the given hash, or ErrNotFound.
+func (s *Store) Tx(ctx context.Context, hash common.Hash) (aggregate.Tx, error) {
+	var (
+		t                                      time.Time
+		block, txType, gasUsed, blobGasUsed    int64
+		gasPrice, cost, calldataCost, blobCost string
+		blobGasPrice                           sql.NullString
+	)
+	err := s.db.QueryRowContext(ctx, fmt.Sprintf(`SELECT block, time, type, gas_used, CAST(gas_price_wei AS TEXT),
+		blob_gas_used, CAST(blob_gas_price_wei AS TEXT), CAST(cost_wei AS TEXT), CAST(calldata_cost_wei AS TEXT), CAST(blob_cost_wei AS TEXT)
+		FROM transactions WHERE hash = %s`, s.d.placeholder(1)), hash.Hex()).
+		Scan(&block, &t, &txType, &gasUsed, &gasPrice, &blobGasUsed, &blobGasPrice, &cost, &calldataCost, &blobCost)
+	if errors.Is(err, sql.ErrNoRows) {
+		return aggregate.Tx{}, ErrNotFound
+	}
+	if err != nil {
+		return aggregate.Tx{}, err
+	}
+	tx := aggregate.Tx{
+		Hash:         hash,
+		Time:         t.UTC(),
+		Block:        uint64(block),
+		Bucket:       t.UTC().Format("2006-01-02"),
+		Type:         uint8(txType),
+		GasUsed:      uint64(gasUsed),
+		GasPrice:     parseWei(gasPrice),
+		BlobGasUsed:  uint64(blobGasUsed),
+		Cost:         parseWei(cost),
+		CalldataCost: parseWei(calldataCost),
+		BlobCost:     parseWei(blobCost),
+	}
+	if blobGasPrice.Valid {
+		tx.BlobGasPrice = parseWei(blobGasPrice.String)
+	}
+	return tx, nil
+}
+
+// ether converts a wei amount to ETH, as in aggregate.Result. An empty
+// amount is zero.
+func ether(wei string) *big.Float {
+	return new(big.Float).Quo(new(big.Float).SetInt(parseWei(wei)), big.NewFloat(params.Ether))
+}
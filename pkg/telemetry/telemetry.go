@@ -0,0 +1,72 @@
+// Package telemetry exports the traces and metrics of the fetch and
+// aggregation pipeline over OTLP, so that long runs and the daemon can be
+// followed in a tracing stack. Without Setup, the instrumentation of the
+// other packages records nothing.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Name is the instrumentation scope of the spans and metrics.
+const Name = "github.com/ohbyeongmin/batcher-gas-tracker"
+
+// Tracer returns the tracer of the pipeline, a no-op one until Setup.
+func Tracer() trace.Tracer {
+	return otel.Tracer(Name)
+}
+
+// Meter returns the meter of the pipeline, a no-op one until Setup.
+func Meter() metric.Meter {
+	return otel.Meter(Name)
+}
+
+// Setup exports the spans and metrics to the OTLP/HTTP collector at endpoint,
+// e.g. http://localhost:4318, as service, and returns the function flushing
+// and stopping the exporters. The OTEL_EXPORTER_OTLP_* variables, such as the
+// headers, apply as well.
+func Setup(ctx context.Context, endpoint, service string, interval time.Duration) (func(context.Context) error, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q, want e.g. http://localhost:4318", endpoint)
+	}
+	traceOpts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(u.Host)}
+	metricOpts := []otlpmetrichttp.Option{otlpmetrichttp.WithEndpoint(u.Host)}
+	if u.Scheme == "http" {
+		traceOpts = append(traceOpts, otlptracehttp.WithInsecure())
+		metricOpts = append(metricOpts, otlpmetrichttp.WithInsecure())
+	}
+	traces, err := otlptracehttp.New(ctx, traceOpts...)
+	if err != nil {
+		return nil, err
+	}
+	metrics, err := otlpmetrichttp.New(ctx, metricOpts...)
+	if err != nil {
+		return nil, err
+	}
+	res := resource.NewSchemaless(attribute.String("service.name", service))
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(traces), sdktrace.WithResource(res))
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metrics, sdkmetric.WithInterval(interval))),
+		sdkmetric.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetMeterProvider(mp)
+	return func(ctx context.Context) error {
+		return errors.Join(tp.Shutdown(ctx), mp.Shutdown(ctx))
+	}, nil
+}
@@ -0,0 +1,163 @@
+package tracker
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"io"
+	"math/big"
+	"os"
+	"sort"
+	"time"
+)
+
+// blobSpillRun is the number of blob transactions kept in memory for the
+// replay of the blob schedules, past which they are sorted by block and
+// written to a temporary file as a run.
+const blobSpillRun = 100_000
+
+// blobTxSize is the size of a blob transaction in the temporary file: its
+// time in Unix nanoseconds, block, blob gas used and 32-byte blob gas price.
+const blobTxSize = 8 + 8 + 8 + 32
+
+// blobSpill collects the blob transactions whose blob fees are replayed, in
+// sorted runs spilled to a temporary file in dir, so that they take bounded
+// memory however many there are.
+type blobSpill struct {
+	dir      string
+	txs      []blobTx
+	file     *os.File
+	runs     []int // number of transactions of every run in file
+	count    int
+	from, to uint64
+}
+
+// add adds tx, spilling the transactions collected to a new run once there
+// are blobSpillRun of them.
+func (s *blobSpill) add(tx blobTx) error {
+	if s.count == 0 || tx.block < s.from {
+		s.from = tx.block
+	}
+	s.to = max(s.to, tx.block)
+	s.count++
+	s.txs = append(s.txs, tx)
+	if len(s.txs) < blobSpillRun {
+		return nil
+	}
+	if s.file == nil {
+		var err error
+		if s.file, err = os.CreateTemp(s.dir, "blob-txs-*.tmp"); err != nil {
+			return err
+		}
+	}
+	s.sortTxs()
+	w := bufio.NewWriter(s.file)
+	buf := make([]byte, blobTxSize)
+	for _, tx := range s.txs {
+		binary.BigEndian.PutUint64(buf[0:], uint64(tx.time.UnixNano()))
+		binary.BigEndian.PutUint64(buf[8:], tx.block)
+		binary.BigEndian.PutUint64(buf[16:], tx.blobGasUsed)
+		tx.blobGasPrice.FillBytes(buf[24:])
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	s.runs = append(s.runs, len(s.txs))
+	s.txs = s.txs[:0]
+	return nil
+}
+
+// sortTxs sorts the transactions kept in memory by block.
+func (s *blobSpill) sortTxs() {
+	sort.SliceStable(s.txs, func(i, j int) bool { return s.txs[i].block < s.txs[j].block })
+}
+
+// each calls fn with every transaction added, in ascending block order,
+// merging the runs spilled with those still in memory.
+func (s *blobSpill) each(fn func(blobTx) error) error {
+	s.sortTxs()
+	var sources blobSources
+	var offset int64
+	for _, n := range s.runs {
+		run := &blobRun{r: bufio.NewReader(io.NewSectionReader(s.file, offset, int64(n)*blobTxSize)), left: n}
+		offset += int64(n) * blobTxSize
+		if err := run.advance(); err != nil {
+			return err
+		}
+		sources = append(sources, run)
+	}
+	if len(s.txs) > 0 {
+		sources = append(sources, &blobRun{txs: s.txs[1:], head: s.txs[0]})
+	}
+	heap.Init(&sources)
+	for len(sources) > 0 {
+		run := sources[0]
+		if err := fn(run.head); err != nil {
+			return err
+		}
+		if run.left == 0 && len(run.txs) == 0 {
+			heap.Pop(&sources)
+			continue
+		}
+		if err := run.advance(); err != nil {
+			return err
+		}
+		heap.Fix(&sources, 0)
+	}
+	return nil
+}
+
+// close removes the temporary file, if any.
+func (s *blobSpill) close() {
+	if s.file != nil {
+		s.file.Close()
+		os.Remove(s.file.Name())
+	}
+}
+
+// blobRun is a sorted run of blob transactions, read from the temporary file
+// or kept in memory, with head its next transaction.
+type blobRun struct {
+	r    io.Reader
+	left int // transactions left to read from r
+	txs  []blobTx
+	head blobTx
+	buf  [blobTxSize]byte
+}
+
+// advance moves head to the next transaction of the run.
+func (r *blobRun) advance() error {
+	if r.left == 0 {
+		r.head, r.txs = r.txs[0], r.txs[1:]
+		return nil
+	}
+	buf := r.buf[:]
+	if _, err := io.ReadFull(r.r, buf); err != nil {
+		return err
+	}
+	r.left--
+	r.head = blobTx{
+		time:         time.Unix(0, int64(binary.BigEndian.Uint64(buf[0:]))).UTC(),
+		block:        binary.BigEndian.Uint64(buf[8:]),
+		blobGasUsed:  binary.BigEndian.Uint64(buf[16:]),
+		blobGasPrice: new(big.Int).SetBytes(buf[24:]),
+	}
+	return nil
+}
+
+// blobSources is a heap of runs by the block of their head.
+type blobSources []*blobRun
+
+func (h blobSources) Len() int           { return len(h) }
+func (h blobSources) Less(i, j int) bool { return h[i].head.block < h[j].head.block }
+func (h blobSources) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *blobSources) Push(x any)        { *h = append(*h, x.(*blobRun)) }
+func (h *blobSources) Pop() any {
+	old := *h
+	run := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return run
+}
@@ -0,0 +1,133 @@
+package tracker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+)
+
+// checkpoint is the state of an interrupted run: the transactions already
+// aggregated and the partial per-bucket sums they produced.
+type checkpoint struct {
+	Granularity string                       `json:"granularity"`
+	Timezone    string                       `json:"timezone,omitempty"` // empty for UTC
+	Processed   []common.Hash                `json:"processed"`
+	Results     map[string]*aggregate.Result `json:"results"`
+}
+
+// loadCheckpoint reads the checkpoint at path. A missing file yields an empty
+// checkpoint so that -resume also works for a run that never got far.
+func loadCheckpoint(path, granularity, timezone string) (*checkpoint, error) {
+	// Timezone is left empty, as in checkpoints written before it existed.
+	cp := &checkpoint{Granularity: granularity, Results: make(map[string]*aggregate.Result)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		cp.Timezone = timezone
+		return cp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, cp); err != nil {
+		return nil, fmt.Errorf("checkpoint %s: %w", path, err)
+	}
+	for _, r := range cp.Results {
+		// Checkpoints written before the means existed summed the prices in
+		// the averages, which Finalize now derives from the costs.
+		if r.MeanCallDataGasPrice == nil {
+			r.MeanCallDataGasPrice, r.AvgCallDataGasPrice = r.AvgCallDataGasPrice, new(big.Float)
+		}
+		if r.MeanBlobGasPrice == nil {
+			r.MeanBlobGasPrice, r.AvgBlobGasPrice = r.AvgBlobGasPrice, new(big.Float)
+		}
+		// Nor did they split the fees.
+		if r.BaseFeeCost == nil {
+			r.BaseFeeCost, r.PriorityFeeCost = new(big.Float), new(big.Float)
+			r.BaseFeeMissing = r.TxCount
+		}
+		if r.BlobTxCost == nil {
+			r.BlobTxCost = new(big.Float)
+		}
+		if r.RevertedCost == nil {
+			r.RevertedCost = new(big.Float)
+		}
+		if r.WhatIfCost == nil {
+			r.WhatIfCost, r.WhatIfActualCost = new(big.Float), new(big.Float)
+		}
+		if r.Overpayment == nil {
+			r.Overpayment = new(big.Float)
+		}
+		if r.FloorExtraCost == nil {
+			r.FloorExtraCost = new(big.Float)
+		}
+		if r.ScheduleActualCost == nil {
+			r.ScheduleActualCost = new(big.Float)
+		}
+		// Nor the exact costs in wei, which are rounded from ETH.
+		if r.CostWei == nil {
+			r.CostWei, r.CalldataCostWei = aggregate.Wei(r.Cost), aggregate.Wei(r.CalldataCost)
+			r.BlobCostWei, r.RevertedCostWei = aggregate.Wei(r.BlobCost), aggregate.Wei(r.RevertedCost)
+		}
+		if r.BaseFeeCostWei == nil {
+			r.BaseFeeCostWei, r.PriorityFeeCostWei = aggregate.Wei(r.BaseFeeCost), aggregate.Wei(r.PriorityFeeCost)
+			r.BlobTxCostWei = aggregate.Wei(r.BlobTxCost)
+			r.WhatIfCostWei, r.WhatIfActualCostWei = aggregate.Wei(r.WhatIfCost), aggregate.Wei(r.WhatIfActualCost)
+			r.OverpaymentWei, r.FloorExtraCostWei = aggregate.Wei(r.Overpayment), aggregate.Wei(r.FloorExtraCost)
+			r.ScheduleActualCostWei = aggregate.Wei(r.ScheduleActualCost)
+			for name, cost := range r.ScheduleBlobCost {
+				if r.ScheduleBlobCostWei == nil {
+					r.ScheduleBlobCostWei = make(map[string]*big.Int, len(r.ScheduleBlobCost))
+				}
+				r.ScheduleBlobCostWei[name] = aggregate.Wei(cost)
+			}
+			for _, shares := range []map[string]*aggregate.Share{r.Roles, r.Methods, r.Senders} {
+				for _, share := range shares {
+					share.CostWei = aggregate.Wei(share.Cost)
+				}
+			}
+		}
+	}
+	if cp.Granularity != granularity {
+		return nil, fmt.Errorf("checkpoint %s was written with granularity %q, not %q", path, cp.Granularity, granularity)
+	}
+	if cp.Timezone != timezone {
+		return nil, fmt.Errorf("checkpoint %s was written with time zone %q, not %q", path, zoneOrUTC(cp.Timezone), zoneOrUTC(timezone))
+	}
+	return cp, nil
+}
+
+// saveCheckpoint atomically replaces the checkpoint at path with the current
+// state of agg.
+func saveCheckpoint(path string, agg *aggregate.Aggregator, processed []common.Hash) error {
+	data, err := json.Marshal(checkpoint{
+		Granularity: agg.Granularity,
+		Timezone:    zoneName(agg.Location),
+		Processed:   processed,
+		Results:     agg.Results,
+	})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// zoneName returns the name of loc recorded in checkpoints, empty for UTC.
+func zoneName(loc *time.Location) string {
+	if loc == nil || loc == time.UTC {
+		return ""
+	}
+	return loc.String()
+}
+
+func zoneOrUTC(name string) string {
+	if name == "" {
+		return "UTC"
+	}
+	return name
+}
@@ -0,0 +1,91 @@
+package tracker
+
+import (
+	"context"
+	"time"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/fetch"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/input"
+)
+
+// DryRun is what a run with Config.DryRun found it would do.
+type DryRun struct {
+	// Fetch estimates the requests of the transactions of the input, or
+	// after scanning.
+	Fetch fetch.Plan
+	// Scan is how the transactions are listed when scanning: "blocks",
+	// "etherscan" or "bigquery". FromBlock and ToBlock are the block range a
+	// scan of blocks covers, and ScanBatches the batches of blocks it
+	// requests. The transactions a scan finds, and their requests, are only
+	// known once it has run.
+	Scan               string
+	FromBlock, ToBlock uint64
+	ScanBatches        int
+	// Latency is the time an RPC endpoint took to answer the chain ID, zero
+	// without RPC.
+	Latency time.Duration
+	// Duration estimates the time of the run from Latency, the concurrency
+	// and the rate limit.
+	Duration time.Duration
+}
+
+// Calls returns the estimated number of RPC requests of the run, a batch
+// counting as one.
+func (d *DryRun) Calls() int {
+	return d.ScanBatches + d.Fetch.Calls()
+}
+
+// dryRunScan resolves the block range of a scan without scanning it.
+func dryRunScan(ctx context.Context, cfg Config, pool *fetch.Pool) (*DryRun, error) {
+	// The transactions of an indexer are listed at run time, a dry run
+	// not querying it.
+	switch {
+	case cfg.BigQuery:
+		return &DryRun{Scan: "bigquery"}, nil
+	case cfg.Etherscan:
+		return &DryRun{Scan: "etherscan"}, nil
+	}
+	s := &fetch.Scanner{RPC: pool, Retry: cfg.Retry, Concurrency: cfg.Concurrency, BatchSize: cfg.BatchSize, Logger: cfg.Logger}
+	from, to, err := s.BlockRange(ctx, cfg.FromBlock, cfg.ToBlock, cfg.FromDate, cfg.ToDate)
+	if err != nil {
+		return nil, err
+	}
+	batchSize := uint64(max(cfg.BatchSize, 1))
+	d := &DryRun{Scan: "blocks", FromBlock: from, ToBlock: to, ScanBatches: int((to - from + batchSize) / batchSize)}
+	if err := d.estimate(ctx, cfg, pool); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// estimate measures the latency of pool and sets the duration of d.
+func (d *DryRun) estimate(ctx context.Context, cfg Config, pool *fetch.Pool) error {
+	if pool == nil {
+		return nil
+	}
+	start := time.Now()
+	if _, err := pool.ChainID(ctx); err != nil {
+		return err
+	}
+	d.Latency = time.Since(start)
+	calls := float64(d.Calls())
+	d.Duration = time.Duration(calls * float64(d.Latency) / float64(max(cfg.Concurrency, 1)))
+	if cfg.RPS > 0 {
+		d.Duration = max(d.Duration, time.Duration(calls/cfg.RPS*float64(time.Second)))
+	}
+	return nil
+}
+
+// dryRun plans the fetching of rows by f.
+func dryRun(ctx context.Context, cfg Config, f *fetch.Fetcher, pool *fetch.Pool, rows []input.Row) (*DryRun, error) {
+	plan, err := f.Plan(rows, cfg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	d := &DryRun{Fetch: plan}
+	if err := d.estimate(ctx, cfg, pool); err != nil {
+		return nil, err
+	}
+	cfg.Logger.Info("dry run", "transactions", plan.Rows, "cached", plan.Cached, "fetched", plan.Fetched, "rpcCalls", d.Calls())
+	return d, nil
+}
@@ -0,0 +1,288 @@
+package tracker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/fetch"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/input"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/telemetry"
+)
+
+// followChunk is the number of blocks scanned at a time while catching up,
+// so that the state advances during a long catch-up.
+const followChunk = 1000
+
+// FollowConfig describes a live run, which follows the chain head instead of
+// covering a fixed input.
+type FollowConfig struct {
+	// RPC is a comma-separated list of L1 JSON-RPC endpoints, as in Config.
+	RPC string
+	// WS is a WebSocket endpoint whose new heads trigger the scans. Without
+	// it, the head is polled every PollInterval.
+	WS           string
+	PollInterval time.Duration
+
+	Senders    []common.Address
+	Recipients []common.Address
+	// FromBlock is the first block to scan, 0 for the head at start. A saved
+	// state takes precedence.
+	FromBlock uint64
+	// Confirmations is the number of blocks that must be built on a block
+	// before it is scanned, so that reorgs seldom undo what was stored.
+	Confirmations uint64
+
+	Concurrency      int
+	BatchSize        int
+	BlockReceiptsMin int
+	Retry            fetch.RetryPolicy
+	RequestTimeout   time.Duration
+	RPS              float64 // L1 RPC calls per second, 0 for no limit
+
+	// StatePath, if set, is where the last scanned block is saved, so that a
+	// restarted daemon catches up from there.
+	StatePath string
+
+	// OnTx is called with every matching transaction, in block order.
+	OnTx func(aggregate.Tx)
+	// OnBlocks, if set, is called once the blocks up to number have been
+	// scanned and their transactions passed to OnTx, with the finalized daily
+	// aggregates of the transactions seen since the start. An error stops
+	// Follow before the state is saved.
+	OnBlocks func(number uint64, dates []string, results map[string]*aggregate.Result) error
+	// OnHead, if set, is called with the header of every new head, e.g. to
+	// follow the blob base fee. A head whose header cannot be fetched is
+	// skipped.
+	OnHead func(number uint64, header fetch.Header)
+	// OnError, if set, is called when the header of a head or the scan of
+	// blocks fails, before they are retried at the next head.
+	OnError func(err error)
+}
+
+// followState is the content of FollowConfig.StatePath.
+type followState struct {
+	Block uint64 `json:"block"` // last scanned block
+}
+
+// Follow scans every new block for the transactions of cfg until ctx is
+// done, which is not an error. Blocks whose scan or receipts fail are scanned
+// again at the next head.
+func Follow(ctx context.Context, cfg FollowConfig) error {
+	if len(cfg.Senders) == 0 && len(cfg.Recipients) == 0 {
+		return errors.New("following needs at least one sender or recipient address")
+	}
+	pool, err := fetch.Dial(cfg.RPC)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+	pool.Timeout = cfg.RequestTimeout
+	pool.SetRateLimit(cfg.RPS)
+	s := &fetch.Scanner{RPC: pool, Retry: cfg.Retry, Concurrency: cfg.Concurrency, BatchSize: cfg.BatchSize}
+	f := &fetch.Fetcher{Source: pool, Retry: cfg.Retry, BlockReceiptsMin: cfg.BlockReceiptsMin}
+	filter := fetch.NewScanFilter(cfg.Senders, cfg.Recipients)
+
+	next := cfg.FromBlock
+	if cfg.StatePath != "" {
+		data, err := os.ReadFile(cfg.StatePath)
+		switch {
+		case err == nil:
+			var state followState
+			if err := json.Unmarshal(data, &state); err != nil {
+				return fmt.Errorf("%s: %w", cfg.StatePath, err)
+			}
+			next = state.Block + 1
+		case !errors.Is(err, os.ErrNotExist):
+			return err
+		}
+	}
+	if next == 0 {
+		head, err := s.LatestBlock(ctx)
+		if err != nil {
+			return err
+		}
+		next = max(head, cfg.Confirmations) - cfg.Confirmations + 1
+	}
+	slog.Info("following the chain", "fromBlock", next, "confirmations", cfg.Confirmations, "from", cfg.Senders, "to", cfg.Recipients)
+
+	heads := make(chan uint64, 1)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go watchHeads(ctx, cfg, s, heads)
+
+	agg := aggregate.New("day")
+	for {
+		var head uint64
+		select {
+		case <-ctx.Done():
+			return nil
+		case head = <-heads:
+		}
+		if cfg.OnHead != nil {
+			if headers, err := pool.BlockHeaders(ctx, []uint64{head}); err == nil {
+				cfg.OnHead(head, headers[0])
+			} else if ctx.Err() == nil {
+				slog.Debug("head header failed", "block", head, "err", err)
+				if cfg.OnError != nil {
+					cfg.OnError(fmt.Errorf("header of block %d: %w", head, err))
+				}
+			}
+		}
+		if head < cfg.Confirmations {
+			continue
+		}
+		for last := head - cfg.Confirmations; next <= last; {
+			to := min(next+followChunk-1, last)
+			found, err := followBlocks(ctx, s, f, filter, next, to, cfg)
+			if ctx.Err() != nil {
+				return nil
+			}
+			if err != nil {
+				slog.Warn("scan failed, retrying at the next head", "fromBlock", next, "toBlock", to, "err", err)
+				if cfg.OnError != nil {
+					cfg.OnError(fmt.Errorf("scan of blocks %d-%d: %w", next, to, err))
+				}
+				break
+			}
+			for _, tx := range found {
+				agg.Add(tx.row, tx.receipt)
+				count(ctx, "aggregated")
+				if cfg.OnTx != nil {
+					cfg.OnTx(agg.NewTx(tx.row, tx.receipt))
+				}
+			}
+			if cfg.OnBlocks != nil {
+				dates, results := agg.Snapshot()
+				if err := cfg.OnBlocks(to, dates, results); err != nil {
+					return err
+				}
+			}
+			if cfg.StatePath != "" {
+				data, _ := json.Marshal(followState{Block: to})
+				if err := writeFileAtomic(cfg.StatePath, data); err != nil {
+					return err
+				}
+			}
+			if len(found) > 0 {
+				slog.Info("transactions found", "fromBlock", next, "toBlock", to, "transactions", len(found))
+			}
+			next = to + 1
+		}
+	}
+}
+
+type foundTx struct {
+	row     input.Row
+	receipt *types.Receipt
+}
+
+// followBlocks returns the matching transactions of blocks from..to with their
+// receipts, or an error if any of them could not be resolved.
+func followBlocks(ctx context.Context, s *fetch.Scanner, f *fetch.Fetcher, filter fetch.ScanFilter, from, to uint64, cfg FollowConfig) ([]foundTx, error) {
+	ctx, span := telemetry.Tracer().Start(ctx, "tracker.followBlocks", trace.WithAttributes(
+		attribute.Int64("fromBlock", int64(from)),
+		attribute.Int64("toBlock", int64(to)),
+	))
+	defer span.End()
+	rows, err := s.Scan(ctx, from, to, filter)
+	if err != nil {
+		return nil, err
+	}
+	var found []foundTx
+	var firstErr error
+	fetch.All(ctx, rows, cfg.Concurrency, cfg.BatchSize, f.Receipts, func(row input.Row, receipt *types.Receipt, err error) {
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", row.Hash, err)
+			}
+			return
+		}
+		found = append(found, foundTx{row, receipt})
+	})
+	return found, firstErr
+}
+
+// watchHeads sends the number of every new head to heads, replacing one that
+// was not received yet. It subscribes to cfg.WS, resubscribing after errors,
+// or polls the head.
+func watchHeads(ctx context.Context, cfg FollowConfig, s *fetch.Scanner, heads chan uint64) {
+	send := func(number uint64) {
+		select {
+		case <-heads:
+		default:
+		}
+		heads <- number
+	}
+	if cfg.WS == "" {
+		ticker := time.NewTicker(cfg.PollInterval)
+		defer ticker.Stop()
+		for {
+			if head, err := s.LatestBlock(ctx); err == nil {
+				send(head)
+			} else if ctx.Err() == nil {
+				slog.Warn("polling the head failed", "err", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}
+
+	ws := fetch.EndpointHost(cfg.WS)
+	for attempt := 0; ctx.Err() == nil; attempt++ {
+		if attempt > 0 {
+			delay := cfg.Retry.Backoff(attempt)
+			slog.Warn("resubscribing to new heads", "ws", ws, "in", delay)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+		}
+		client, err := ethclient.DialContext(ctx, cfg.WS)
+		if err != nil {
+			slog.Warn("dial failed", "ws", ws, "err", err)
+			continue
+		}
+		ch := make(chan *types.Header)
+		sub, err := client.SubscribeNewHead(ctx, ch)
+		if err != nil {
+			client.Close()
+			slog.Warn("subscribing to new heads failed", "ws", ws, "err", err)
+			continue
+		}
+		attempt = 0
+		// Blocks produced while resubscribing are covered by the next head.
+		if head, err := s.LatestBlock(ctx); err == nil {
+			send(head)
+		}
+	receive:
+		for {
+			select {
+			case <-ctx.Done():
+				break receive
+			case err := <-sub.Err():
+				slog.Warn("new heads subscription failed", "ws", ws, "err", err)
+				break receive
+			case header := <-ch:
+				send(header.Number.Uint64())
+			}
+		}
+		sub.Unsubscribe()
+		client.Close()
+	}
+}
@@ -0,0 +1,129 @@
+package tracker
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// progress counts processed rows. The counters are safe for concurrent use.
+type progress struct {
+	total     atomic.Int64
+	processed atomic.Int64
+	failed    atomic.Int64
+	start     time.Time
+	// rpcCalls returns the number of RPC calls made so far, if set.
+	rpcCalls func() int64
+	logger   *slog.Logger
+}
+
+type progressSnapshot struct {
+	Processed      int64     `json:"processed"`
+	Total          int64     `json:"total"`
+	Failed         int64     `json:"failed"`
+	ElapsedSeconds float64   `json:"elapsedSeconds"`
+	ETASeconds     float64   `json:"etaSeconds"`
+	RPCCalls       int64     `json:"rpcCalls"`
+	Done           bool      `json:"done"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+func newProgress(total int, logger *slog.Logger) *progress {
+	p := &progress{start: time.Now(), logger: logger}
+	p.total.Store(int64(total))
+	return p
+}
+
+func (p *progress) snapshot() progressSnapshot {
+	now := time.Now()
+	snap := progressSnapshot{
+		Processed:      p.processed.Load(),
+		Total:          p.total.Load(),
+		Failed:         p.failed.Load(),
+		ElapsedSeconds: now.Sub(p.start).Seconds(),
+		UpdatedAt:      now.UTC(),
+	}
+	if p.rpcCalls != nil {
+		snap.RPCCalls = p.rpcCalls()
+	}
+	if snap.Processed > 0 && snap.Total > snap.Processed {
+		rate := float64(snap.Processed) / snap.ElapsedSeconds
+		snap.ETASeconds = float64(snap.Total-snap.Processed) / rate
+	}
+	return snap
+}
+
+// run writes the progress file every interval until ctx is cancelled, then
+// writes a final snapshot marked as done.
+func (p *progress) run(ctx context.Context, path string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			snap := p.snapshot()
+			snap.Done = true
+			if err := writeProgressFile(path, snap); err != nil {
+				p.logger.Warn("progress file failed", "err", err)
+			}
+			return
+		case <-ticker.C:
+			if err := writeProgressFile(path, p.snapshot()); err != nil {
+				p.logger.Warn("progress file failed", "err", err)
+			}
+		}
+	}
+}
+
+// log logs the progress every interval until ctx is cancelled: counts, the
+// RPC call rate over the last interval and the estimated time remaining.
+func (p *progress) log(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	last := p.snapshot()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			snap := p.snapshot()
+			rate := float64(snap.RPCCalls-last.RPCCalls) / (snap.ElapsedSeconds - last.ElapsedSeconds)
+			p.logger.Info("progress",
+				"processed", snap.Processed, "total", snap.Total, "failed", snap.Failed,
+				"rpcPerSecond", math.Round(rate*10)/10,
+				"eta", time.Duration(snap.ETASeconds*float64(time.Second)).Round(time.Second).String())
+			last = snap
+		}
+	}
+}
+
+func writeProgressFile(path string, snap progressSnapshot) error {
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// writeFileAtomic replaces path through a rename so that readers never observe
+// a partially written file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
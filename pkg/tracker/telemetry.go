@@ -0,0 +1,21 @@
+package tracker
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/telemetry"
+)
+
+// transactions counts the processed transactions by outcome. It records
+// nothing until telemetry.Setup.
+var transactions, _ = telemetry.Meter().Int64Counter("transactions",
+	metric.WithDescription("Processed transactions, by outcome"))
+
+// count records one transaction of outcome: aggregated, failed, out_of_range
+// or unexpected.
+func count(ctx context.Context, outcome string) {
+	transactions.Add(ctx, 1, metric.WithAttributes(attribute.String("outcome", outcome)))
+}
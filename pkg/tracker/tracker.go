@@ -0,0 +1,903 @@
+// Package tracker runs a complete gas cost analysis: it reads or discovers the
+// transactions, fetches their receipts and aggregates the costs per bucket.
+package tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/batch"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/fetch"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/input"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/telemetry"
+)
+
+// Config describes one run. The zero values of optional fields disable the
+// corresponding feature.
+type Config struct {
+	// RPC is a comma-separated list of L1 JSON-RPC endpoints.
+	RPC string
+	// Name, if set, names the report instead of the input files or the
+	// scanned range.
+	Name string
+	// Source, if set, serves the receipts instead of RPC, e.g. a
+	// fetchtest.Fixture. RPC is then only dialed for scanning.
+	Source fetch.ReceiptSource
+
+	// Input is a comma-separated list of files and glob patterns, "-" for
+	// stdin. It is ignored when Scan is set or Senders is not empty.
+	Input       string
+	InputFormat string // csv, hashes, json or jsonl; empty for the extension
+	CSV         input.CSVOptions
+	// Stream fetches the transactions of Input as they are read rather than
+	// once all are, so that inputs of millions of rows are processed with
+	// only their hashes kept in memory. The total of the progress then grows
+	// as the input is read, OnStart is passed 0 transactions and the
+	// TrustCSVSample rows are the first ones.
+	Stream bool
+
+	// Scan discovers the transactions of the block range sent by Senders and
+	// to Recipients instead of reading Input. Senders alone also selects it.
+	Scan       bool
+	Senders    []common.Address
+	Recipients []common.Address
+	FromBlock  uint64
+	ToBlock    uint64 // 0 for the chain head
+	FromDate   string // YYYY-MM-DD, UTC; overrides FromBlock
+	ToDate     string // YYYY-MM-DD, UTC; overrides ToBlock
+
+	// Etherscan lists the transactions of Senders through the Etherscan API
+	// instead of scanning blocks.
+	Etherscan    bool
+	EtherscanURL string
+	EtherscanKey string
+	EtherscanRPS float64
+	// IndexerFallback fetches the receipts that the L1 endpoints do not
+	// have, e.g. old receipts of a pruned node, through the proxy module of
+	// the Etherscan API.
+	IndexerFallback bool
+
+	// BigQuery lists the transactions of Senders and Recipients in the days
+	// from FromDate by querying BigQueryTable, fetch.BigQueryTable if empty,
+	// in BigQueryProject, the project of the credentials if empty.
+	BigQuery        bool
+	BigQueryProject string
+	BigQueryTable   string
+
+	// DuneQuery, if not zero, reads the transactions from the results of this
+	// saved Dune query instead of Input. DuneParams are its parameters; they,
+	// like DuneExecute, run the query again instead of reading its latest
+	// results.
+	DuneQuery   int
+	DuneURL     string
+	DuneKey     string
+	DuneParams  map[string]string
+	DuneExecute bool
+
+	Granularity string // block, hour, day, week or month
+	// Location is the time zone of the buckets, nil for UTC. FromDate and
+	// ToDate remain UTC days.
+	Location *time.Location
+	// From and To, if not zero, keep the transactions from From on and
+	// before To. Rows without a time in the input are fetched and filtered by
+	// their block time.
+	From, To time.Time
+
+	Concurrency      int
+	BatchSize        int
+	BlockReceiptsMin int
+	Retry            fetch.RetryPolicy
+	RequestTimeout   time.Duration
+	RPS              float64 // L1 RPC calls per second, 0 for no limit
+	CachePath        string
+	// DryRun reads and checks the input, or resolves the block range of a
+	// scan, and estimates the requests of the run into Report.DryRun without
+	// fetching anything.
+	DryRun bool
+	// Offline resolves every receipt from the cache at CachePath, e.g. one
+	// imported from an archive, without dialing RPC; the transactions it
+	// lacks fail. The chain is the one the cache recorded.
+	Offline bool
+
+	TrustCSV       bool
+	TrustCSVSample int
+	// BaseFees fetches the block base fee of every transaction to split its
+	// execution fee into base fee and priority tip.
+	BaseFees bool
+	// Beacon is the URL of a beacon node REST API from which the blobs of
+	// blob transactions are read to measure their utilization.
+	Beacon string
+	// Roles maps recipient addresses to roles, such as the batch inbox or the
+	// output oracle, by which the results split the transactions.
+	Roles map[common.Address]string
+	// Deposits are the L1 bridge contracts whose deposits the results count
+	// under aggregate.DepositRole, with Roles.
+	Deposits map[common.Address]bool
+	// Disputes counts the transactions that play dispute games under
+	// aggregate.DisputeRole. It needs Roles.
+	Disputes bool
+	// Methods splits the results by the method the transactions call.
+	Methods bool
+	// BySender splits the results by the sender of the transactions, such as
+	// rotated batcher keys and the proposer.
+	BySender bool
+	// PerSender, with BySender, also aggregates the transactions of every
+	// sender on their own into the Senders of the report.
+	PerSender bool
+	// ExpectedSenders, if set, are the only senders whose transactions are
+	// aggregated. The others, e.g. rows of another account mixed into the
+	// input or sent with a compromised key, are left out of the results and
+	// listed in the Unexpected of the report.
+	ExpectedSenders []common.Address
+	// Nonces sets the sender and the nonce of every transaction passed to
+	// OnTx, fetching the transactions that the input lacks them for.
+	Nonces bool
+	// Frames decodes the frames that the transactions submit to count the L2
+	// blocks and transactions of every batcher transaction passed to OnTx,
+	// and sums the size of their data, compressed and decompressed, into the
+	// results. Blob transactions need Beacon.
+	Frames bool
+	// Channels, with Frames, lists the channels that the aggregated
+	// transactions complete in the Channels of the report, with the cost of
+	// the transactions that carried their frames.
+	Channels bool
+	// WhatIf prices the data of every blob transaction as calldata and, with
+	// Frames, the frames of every calldata transaction in blobs, into the
+	// results.
+	WhatIf bool
+	// OracleBlocks, if not zero, compares the tip of every transaction with
+	// the one a fee oracle would have suggested, the median over the
+	// OracleBlocks blocks before it of their OraclePercentile-th percentile
+	// tip, summing the overpayment into the results. It needs BaseFees.
+	OracleBlocks     int
+	OraclePercentile float64
+	// MarketTips compares the tip of every transaction with the median tip
+	// of its block into the results. It needs BaseFees.
+	MarketTips bool
+	// CalldataFloor computes the EIP-7623 floor gas of every calldata
+	// transaction, counting those charged it and what it would have added to
+	// those before Prague into the results.
+	CalldataFloor bool
+	// BlobSchedules replays the blob gas used by the blocks of the blob
+	// transactions under these alternative blob schedules once they are
+	// fetched, summing the blob fees they would have paid into the results.
+	// The transactions of an interrupted run are left out.
+	BlobSchedules []fetch.BlobSchedule
+	// SpillDir holds the temporary files in which the blob transactions
+	// of BlobSchedules wait for the replay; the system temporary directory
+	// when empty.
+	SpillDir string
+	// DataSizes measures the data that the transactions post into the
+	// results, assuming the blobs that Beacon does not serve to be full.
+	DataSizes bool
+
+	// OutDir is where the checkpoint is kept unless CheckpointPath is set.
+	OutDir          string
+	CheckpointPath  string
+	CheckpointEvery int
+	Resume          bool
+	// Append keeps the results in a state file, StatePath or
+	// output-<name>.state in OutDir, to which the next Append run adds its
+	// transactions, skipping those already counted, so that incremental runs
+	// maintain one report.
+	Append    bool
+	StatePath string
+
+	// ProgressFile is updated every ProgressInterval, 5s when zero.
+	ProgressFile     string
+	ProgressInterval time.Duration
+	ProgressLog      time.Duration
+	// Logger receives the messages of the run; nil for slog.Default().
+	Logger *slog.Logger
+
+	// OnStart, if set, is called with the report name and the number of
+	// transactions to fetch once the input is known. An error aborts the run.
+	OnStart func(name string, rows int) error
+	// OnTx, if set, is called with every aggregated transaction in input
+	// order, as soon as it is aggregated.
+	OnTx func(aggregate.Tx)
+}
+
+// Report is the outcome of a run.
+type Report struct {
+	// Name is the suggested file name of the report, derived from the input
+	// files or the scanned range, e.g. "export.csv".
+	Name string
+	// ChainID is the chain of the L1 endpoints and Network its name, e.g.
+	// "sepolia". Reports of other chains than mainnet carry the network in
+	// their Name.
+	ChainID uint64
+	Network string
+	Dates   []string
+	Results map[string]*aggregate.Result
+	Total   *aggregate.Result
+
+	// Rows is the number of transactions fetched by this run, excluding those
+	// restored from a checkpoint.
+	Rows int
+	// OutOfRange counts the transactions left out for being outside From
+	// and To.
+	OutOfRange int
+	Skipped    []input.Skipped
+	Failures   []fetch.Result
+	// Unexpected are the transactions left out for not being sent by any of
+	// the ExpectedSenders, or by an unknown sender.
+	Unexpected []aggregate.Tx
+	// Channels are the batcher channels completed by the aggregated
+	// transactions when Config.Channels is set, in the order of completion.
+	Channels []batch.Channel
+	// Senders are the results of the transactions of every sender on their
+	// own when Config.PerSender is set. They only cover the transactions
+	// processed by this run.
+	Senders map[common.Address]SenderReport
+	// Interrupted counts the transactions left unprocessed because ctx was
+	// done. The checkpoint then allows to resume the run. Unread is set when
+	// a streamed input was left unread from then on, its rest uncounted.
+	Interrupted    int
+	Unread         bool
+	CheckpointPath string
+	StatePath      string
+
+	// DryRun is the estimate of a Config.DryRun run, which fetched nothing.
+	DryRun *DryRun
+
+	// Trust-CSV statistics.
+	CSVResolved int64
+	// IndexerResolved counts the receipts fetched through Etherscan for
+	// lack of them on the L1 endpoints.
+	IndexerResolved int64
+	Verified        int64
+	Mismatched      int64
+}
+
+// WasInterrupted reports whether ctx was done before all the transactions
+// were processed.
+func (r Report) WasInterrupted() bool {
+	return r.Interrupted > 0 || r.Unread
+}
+
+// SenderReport holds the results of the transactions of one sender.
+type SenderReport struct {
+	Dates   []string
+	Results map[string]*aggregate.Result
+	Total   *aggregate.Result
+}
+
+// errUnread stops the reading of a streamed input once ctx is done.
+var errUnread = errors.New("input left unread")
+
+// Run performs the analysis described by cfg. Failed transactions and an
+// interruption through ctx do not make it fail; they are recorded in the
+// report, which then covers the remaining transactions.
+func Run(ctx context.Context, cfg Config) (Report, error) {
+	switch cfg.Granularity {
+	case "block", "hour", "day", "week", "month":
+	default:
+		return Report{}, fmt.Errorf("unknown granularity %q", cfg.Granularity)
+	}
+	ctx, span := telemetry.Tracer().Start(ctx, "tracker.Run")
+	defer span.End()
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	cfg.CSV.Logger = cfg.Logger
+
+	scan := cfg.Scan || len(cfg.Senders) > 0
+	if cfg.Offline {
+		if scan {
+			return Report{}, errors.New("scanning needs an RPC endpoint, not an offline run")
+		}
+		if cfg.CachePath == "" {
+			return Report{}, errors.New("an offline run needs a receipt cache")
+		}
+		if cfg.Source == nil {
+			cfg.Source = fetch.Offline{}
+		}
+	}
+	var pool *fetch.Pool
+	if cfg.Source == nil || scan {
+		var err error
+		if pool, err = fetch.Dial(cfg.RPC); err != nil {
+			return Report{}, err
+		}
+		defer pool.Close()
+		pool.Timeout = cfg.RequestTimeout
+		pool.Logger = cfg.Logger
+		pool.SetRateLimit(cfg.RPS)
+	}
+	source := cfg.Source
+	if source == nil {
+		source = pool
+	}
+
+	var err error
+	var cache *fetch.Cache
+	if cfg.CachePath != "" {
+		cache, err = fetch.OpenCache(cfg.CachePath)
+		if err != nil {
+			return Report{}, err
+		}
+		defer cache.Close()
+	}
+
+	report := Report{}
+	var (
+		rows  []input.Row
+		files []string
+	)
+	// A dry run reads the whole input to check it.
+	stream := cfg.Stream && !scan && cfg.DuneQuery == 0 && !cfg.DryRun
+	switch {
+	case scan && cfg.DryRun:
+		report.DryRun, err = dryRunScan(ctx, cfg, pool)
+	case scan:
+		rows, report.Name, err = discover(ctx, cfg, pool)
+	case cfg.DuneQuery != 0:
+		rows, report.Name, err = dune(ctx, cfg)
+	case stream:
+		if files, err = input.Files(cfg.Input); err == nil {
+			report.Name = inputName(files)
+		}
+	default:
+		rows, report.Skipped, report.Name, err = read(cfg)
+	}
+	if err != nil {
+		return Report{}, err
+	}
+	if cfg.Name != "" {
+		report.Name = cfg.Name + ".csv"
+	}
+	if pool != nil {
+		if report.ChainID, err = pool.ChainID(ctx); err != nil {
+			return Report{}, err
+		}
+		// A cache shared by several chains keeps the first one, for
+		// offline runs.
+		if cache != nil {
+			if err := cache.SetChainID(report.ChainID); err != nil {
+				cfg.Logger.Warn("cache chain not recorded", "cache", cfg.CachePath, "err", err)
+			}
+		}
+	} else if cfg.Offline {
+		if report.ChainID, err = cache.ChainID(); err != nil {
+			return Report{}, err
+		}
+	}
+	if report.ChainID != 0 {
+		report.Network = fetch.NetworkName(report.ChainID)
+		if report.ChainID != 1 {
+			report.Name = withNetwork(report.Name, report.Network)
+		}
+		cfg.Logger.Info("network detected", "network", report.Network, "chainId", report.ChainID)
+	}
+	if report.DryRun != nil {
+		return report, nil
+	}
+
+	if !cfg.From.IsZero() || !cfg.To.IsZero() {
+		rows = slices.DeleteFunc(rows, func(row input.Row) bool {
+			if row.Time.IsZero() || cfg.inRange(row.Time) {
+				return false
+			}
+			report.OutOfRange++
+			return true
+		})
+	}
+
+	report.CheckpointPath = cfg.CheckpointPath
+	if report.CheckpointPath == "" && cfg.OutDir != "" {
+		report.CheckpointPath = filepath.Join(cfg.OutDir, "output-"+report.Name+".checkpoint")
+	}
+
+	report.StatePath = cfg.StatePath
+	if report.StatePath == "" && cfg.OutDir != "" {
+		report.StatePath = filepath.Join(cfg.OutDir, "output-"+report.Name+".state")
+	}
+
+	agg := aggregate.New(cfg.Granularity)
+	agg.Logger = cfg.Logger
+	agg.Location = cfg.Location
+	agg.Roles = cfg.Roles
+	agg.Deposits = cfg.Deposits
+	agg.Disputes = cfg.Disputes
+	agg.Methods = cfg.Methods
+	agg.Senders = cfg.BySender
+	senderAggs := make(map[common.Address]*aggregate.Aggregator)
+	var processed []common.Hash
+	skipDone := func() int {
+		done := make(map[common.Hash]bool, len(processed))
+		for _, hash := range processed {
+			done[hash] = true
+		}
+		n := len(rows)
+		rows = slices.DeleteFunc(rows, func(row input.Row) bool { return done[row.Hash] })
+		return n - len(rows)
+	}
+	if cfg.Append && report.StatePath != "" {
+		state, err := loadCheckpoint(report.StatePath, cfg.Granularity, zoneName(cfg.Location))
+		if err != nil {
+			return Report{}, err
+		}
+		agg.Results = state.Results
+		processed = state.Processed
+		attrs := []any{"state", report.StatePath, "buckets", len(agg.Results), "counted", len(processed)}
+		// A streamed input is filtered as it is read.
+		if !stream {
+			attrs = append(attrs, "alreadyCounted", skipDone())
+		}
+		cfg.Logger.Info("appending to state", attrs...)
+	}
+	if cfg.Resume && report.CheckpointPath != "" {
+		cp, err := loadCheckpoint(report.CheckpointPath, cfg.Granularity, zoneName(cfg.Location))
+		if err != nil {
+			return Report{}, err
+		}
+		// The checkpoint of an interrupted Append run holds the state it
+		// started from, so only an empty one leaves the state as it is.
+		if !cfg.Append || len(cp.Processed) > 0 {
+			agg.Results = cp.Results
+			processed = cp.Processed
+			skipDone()
+		}
+		attrs := []any{"checkpoint", report.CheckpointPath, "processed", len(processed)}
+		if !stream {
+			attrs = append(attrs, "remaining", len(rows))
+		}
+		cfg.Logger.Info("resuming from checkpoint", attrs...)
+	}
+	report.Rows = len(rows)
+
+	f := &fetch.Fetcher{
+		Source:           source,
+		Cache:            cache,
+		BlockReceiptsMin: cfg.BlockReceiptsMin,
+		Retry:            cfg.Retry,
+		TrustCSV:         cfg.TrustCSV,
+		BaseFees:         cfg.BaseFees,
+		Recipients:       cfg.Roles != nil,
+		Methods:          cfg.Methods || cfg.Deposits != nil || cfg.Disputes,
+		Senders:          cfg.BySender || len(cfg.ExpectedSenders) > 0 || cfg.Nonces,
+		Nonces:           cfg.Nonces,
+		Frames:           cfg.Frames,
+		WhatIf:           cfg.WhatIf,
+		OracleBlocks:     cfg.OracleBlocks,
+		OraclePercentile: cfg.OraclePercentile,
+		MarketTips:       cfg.MarketTips,
+		CalldataFloor:    cfg.CalldataFloor,
+		DataSizes:        cfg.DataSizes,
+		Logger:           cfg.Logger,
+	}
+	if cfg.Beacon != "" {
+		f.Beacon = &fetch.BeaconClient{
+			BaseURL: cfg.Beacon,
+			Retry:   cfg.Retry,
+			HTTP:    &http.Client{Timeout: cfg.RequestTimeout},
+		}
+	}
+	if cfg.IndexerFallback {
+		f.Indexer = cfg.etherscanClient()
+	}
+	if cfg.TrustCSV && cfg.TrustCSVSample > 0 {
+		f.SampleStride = max(len(rows)/cfg.TrustCSVSample, 1)
+		f.SampleLimit = cfg.TrustCSVSample
+	}
+	if cfg.DryRun {
+		report.DryRun, err = dryRun(ctx, cfg, f, pool, rows)
+		return report, err
+	}
+	if cfg.OnStart != nil {
+		if err := cfg.OnStart(report.Name, len(rows)); err != nil {
+			return Report{}, err
+		}
+	}
+
+	prog := newProgress(len(processed)+len(rows), cfg.Logger)
+	prog.processed.Add(int64(len(processed)))
+	if pool != nil {
+		prog.rpcCalls = pool.Calls
+	}
+	if cfg.ProgressLog > 0 {
+		ctx, stopLog := context.WithCancel(ctx)
+		go prog.log(ctx, cfg.ProgressLog)
+		defer stopLog()
+	}
+	if cfg.ProgressFile != "" {
+		if cfg.ProgressInterval <= 0 {
+			cfg.ProgressInterval = 5 * time.Second
+		}
+		// The final snapshot is written even when ctx is already done.
+		ctx, stopProgress := context.WithCancel(context.WithoutCancel(ctx))
+		progressDone := make(chan struct{})
+		go func() {
+			prog.run(ctx, cfg.ProgressFile, cfg.ProgressInterval)
+			close(progressDone)
+		}()
+		defer func() {
+			stopProgress()
+			<-progressDone
+		}()
+	}
+
+	save := func() error {
+		if report.CheckpointPath == "" {
+			return nil
+		}
+		return saveCheckpoint(report.CheckpointPath, agg, processed)
+	}
+	bank := batch.NewBank()
+	var invalidChannel sync.Once
+	blobs := &blobSpill{dir: cfg.SpillDir}
+	defer blobs.close()
+	var spillErr error
+	handle := func(row input.Row, receipt *types.Receipt, err error) {
+		if err != nil && ctx.Err() != nil {
+			// Not a failure: the row is left for a resumed run.
+			report.Interrupted++
+			return
+		}
+		if err == nil {
+			err = aggregate.Check(receipt)
+		}
+		if err != nil {
+			report.Failures = append(report.Failures, fetch.Result{Row: row, Err: err})
+			prog.failed.Add(1)
+			count(ctx, "failed")
+			return
+		}
+		// Out-of-range transactions may still carry frames of channels
+		// completed in range.
+		var (
+			counts    *batch.Counts
+			completed []batch.Channel
+		)
+		if frames := f.TakeFrames(row.Hash); frames != nil {
+			block := row.Block
+			if receipt.BlockNumber != nil {
+				block = receipt.BlockNumber.Uint64()
+			}
+			var err error
+			completed, err = bank.Add(block, aggregate.Cost(receipt), frames)
+			if err != nil {
+				invalidChannel.Do(func() {
+					cfg.Logger.Warn("channel not decoded; its L2 blocks and transactions are not counted", "tx", row.Hash, "err", err)
+				})
+			}
+			var c batch.Counts
+			for _, ch := range completed {
+				c.Add(ch.Counts)
+			}
+			counts = &c
+			row.ChannelBytes, row.RawBytes = c.ChannelBytes, c.RawBytes
+			row.L2Blocks, row.L2Txs = c.Blocks, c.Txs
+		}
+		switch {
+		case !cfg.inRange(row.Time):
+			report.OutOfRange++
+			count(ctx, "out_of_range")
+		case !cfg.expectedSender(row):
+			report.Unexpected = append(report.Unexpected, agg.NewTx(row, receipt))
+			count(ctx, "unexpected")
+		default:
+			agg.Add(row, receipt)
+			count(ctx, "aggregated")
+			if cfg.PerSender && row.From != nil {
+				sa := senderAggs[*row.From]
+				if sa == nil {
+					sa = aggregate.New(cfg.Granularity)
+					sa.Logger = cfg.Logger
+					sa.Location = cfg.Location
+					sa.Roles = cfg.Roles
+					sa.Deposits = cfg.Deposits
+					sa.Disputes = cfg.Disputes
+					sa.Methods = cfg.Methods
+					senderAggs[*row.From] = sa
+				}
+				sa.Add(row, receipt)
+			}
+			if cfg.Channels {
+				report.Channels = append(report.Channels, completed...)
+			}
+			if len(cfg.BlobSchedules) > 0 && receipt.Type == types.BlobTxType && receipt.BlockNumber != nil && receipt.BlobGasPrice != nil {
+				if err := blobs.add(blobTx{row.Time, receipt.BlockNumber.Uint64(), receipt.BlobGasUsed, receipt.BlobGasPrice}); err != nil && spillErr == nil {
+					spillErr = err
+				}
+			}
+			if cfg.OnTx != nil {
+				tx := agg.NewTx(row, receipt)
+				if counts != nil {
+					tx.L2Blocks, tx.L2Txs = &counts.Blocks, &counts.Txs
+				}
+				cfg.OnTx(tx)
+			}
+		}
+		prog.processed.Add(1)
+		// The state of Append only lists the transactions counted, so that
+		// those out of range are counted by a later run covering them.
+		if !cfg.Append || cfg.inRange(row.Time) {
+			processed = append(processed, row.Hash)
+		}
+		if cfg.CheckpointEvery > 0 && len(processed)%cfg.CheckpointEvery == 0 {
+			if err := save(); err != nil {
+				cfg.Logger.Warn("checkpoint failed", "err", err)
+			}
+		}
+	}
+	if stream {
+		// The rows are filtered like those read at once above.
+		done := make(map[common.Hash]bool, len(processed))
+		for _, hash := range processed {
+			done[hash] = true
+		}
+		var (
+			ch                                = make(chan input.Row)
+			readErr                           error
+			streamed, outOfRange, alreadyDone int
+		)
+		go func() {
+			defer close(ch)
+			report.Skipped, readErr = input.Stream(files, cfg.InputFormat, cfg.CSV, func(row input.Row) error {
+				switch {
+				case !row.Time.IsZero() && !cfg.inRange(row.Time):
+					outOfRange++
+				case done[row.Hash]:
+					alreadyDone++
+				default:
+					// Once ctx is done, the rest of the input is left
+					// for a resumed run rather than read to its end.
+					select {
+					case ch <- row:
+					case <-ctx.Done():
+						return errUnread
+					}
+					streamed++
+					prog.total.Add(1)
+				}
+				return nil
+			})
+		}()
+		fetch.Stream(ctx, ch, cfg.Concurrency, cfg.BatchSize, f.Receipts, handle)
+		if errors.Is(readErr, errUnread) {
+			report.Unread, readErr = true, nil
+		}
+		if readErr != nil {
+			return Report{}, readErr
+		}
+		report.OutOfRange += outOfRange
+		report.Rows = streamed
+		if alreadyDone > 0 {
+			cfg.Logger.Info("skipped the transactions already counted", "transactions", alreadyDone)
+		}
+	} else {
+		fetch.All(ctx, rows, cfg.Concurrency, cfg.BatchSize, f.Receipts, handle)
+	}
+	if spillErr != nil {
+		cfg.Logger.Warn("blob schedules not replayed", "err", spillErr)
+	} else if blobs.count > 0 && ctx.Err() == nil {
+		replay := &fetch.BlobReplay{Source: source, Retry: cfg.Retry, Concurrency: cfg.Concurrency, BatchSize: cfg.BatchSize, Logger: cfg.Logger}
+		if err := replayBlobSchedules(ctx, replay, cfg.BlobSchedules, agg, blobs); err != nil {
+			cfg.Logger.Warn("blob schedules not replayed", "err", err)
+		}
+	}
+
+	report.CSVResolved = f.CSVResolved.Load()
+	report.IndexerResolved = f.IndexerResolved.Load()
+	report.Verified = f.Verified.Load()
+	report.Mismatched = f.Mismatched.Load()
+
+	switch {
+	case report.WasInterrupted():
+		if err := save(); err != nil {
+			return Report{}, fmt.Errorf("interrupted, checkpoint failed: %w", err)
+		}
+	case len(report.Failures) > 0:
+		if err := save(); err != nil {
+			cfg.Logger.Warn("checkpoint failed", "err", err)
+		} else if report.CheckpointPath != "" {
+			cfg.Logger.Info("saved checkpoint; resume to retry the failed transactions", "checkpoint", report.CheckpointPath)
+		}
+	case report.CheckpointPath != "":
+		if err := os.Remove(report.CheckpointPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			cfg.Logger.Warn("checkpoint failed", "err", err)
+		}
+	}
+
+	// The state is saved before Finalize turns the sums into averages.
+	if cfg.Append && report.StatePath != "" && !report.WasInterrupted() {
+		if err := saveCheckpoint(report.StatePath, agg, processed); err != nil {
+			return Report{}, fmt.Errorf("state: %w", err)
+		}
+	}
+
+	report.Dates, report.Total = agg.Finalize()
+	report.Results = agg.Results
+	if cfg.PerSender {
+		report.Senders = make(map[common.Address]SenderReport, len(senderAggs))
+		for sender, sa := range senderAggs {
+			dates, total := sa.Finalize()
+			report.Senders[sender] = SenderReport{Dates: dates, Results: sa.Results, Total: total}
+		}
+	}
+	span.SetAttributes(
+		attribute.Int("rows", report.Rows),
+		attribute.Int("failures", len(report.Failures)),
+		attribute.Int("interrupted", report.Interrupted),
+	)
+	return report, nil
+}
+
+// etherscanClient returns a client of the Etherscan API of cfg.
+func (cfg Config) etherscanClient() *fetch.EtherscanClient {
+	return &fetch.EtherscanClient{
+		BaseURL:  cfg.EtherscanURL,
+		APIKey:   cfg.EtherscanKey,
+		Interval: time.Duration(float64(time.Second) / cfg.EtherscanRPS),
+		Retry:    cfg.Retry,
+		HTTP:     &http.Client{Timeout: cfg.RequestTimeout},
+	}
+}
+
+// expectedSender reports whether row was sent by one of cfg.ExpectedSenders,
+// or whether there are none.
+func (cfg Config) expectedSender(row input.Row) bool {
+	if len(cfg.ExpectedSenders) == 0 {
+		return true
+	}
+	return row.From != nil && slices.Contains(cfg.ExpectedSenders, *row.From)
+}
+
+// inRange reports whether t is within cfg.From and cfg.To.
+func (cfg Config) inRange(t time.Time) bool {
+	return (cfg.From.IsZero() || !t.Before(cfg.From)) && (cfg.To.IsZero() || t.Before(cfg.To))
+}
+
+// read reads the input files of cfg.
+func read(cfg Config) ([]input.Row, []input.Skipped, string, error) {
+	files, err := input.Files(cfg.Input)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	rows, skipped, err := input.Read(files, cfg.InputFormat, cfg.CSV)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return rows, skipped, inputName(files), nil
+}
+
+// dune reads the transactions from the results of the Dune query of cfg.
+func dune(ctx context.Context, cfg Config) ([]input.Row, string, error) {
+	c := &fetch.DuneClient{
+		BaseURL: cfg.DuneURL,
+		APIKey:  cfg.DuneKey,
+		Retry:   cfg.Retry,
+		HTTP:    &http.Client{Timeout: cfg.RequestTimeout},
+		Logger:  cfg.Logger,
+	}
+	rows, err := fetch.DuneInput(ctx, c, cfg.DuneQuery, cfg.DuneParams, cfg.DuneExecute)
+	if err != nil {
+		return nil, "", err
+	}
+	cfg.Logger.Info("dune transactions listed", "transactions", len(rows), "query", cfg.DuneQuery)
+	return rows, fmt.Sprintf("dune-%d.csv", cfg.DuneQuery), nil
+}
+
+// inputName returns the name of the report of the input files.
+func inputName(files []string) string {
+	// The report is named after the file, without its directories.
+	name := filepath.Base(files[0])
+	switch {
+	case len(files) > 1:
+		name = "combined.csv"
+	case files[0] == "-":
+		name = "stdin.csv"
+	case filepath.Ext(files[0]) != ".csv":
+		trimmed := input.TrimCompression(name)
+		name = strings.TrimSuffix(trimmed, filepath.Ext(trimmed)) + ".csv"
+	}
+	return name
+}
+
+// withNetwork inserts network before the extension of the report name name,
+// e.g. "export-sepolia.csv".
+func withNetwork(name, network string) string {
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + "-" + network + ext
+}
+
+// discover lists the transactions of the configured addresses by scanning
+// blocks, through Etherscan or by querying BigQuery.
+func discover(ctx context.Context, cfg Config, pool *fetch.Pool) ([]input.Row, string, error) {
+	addresses := append(slices.Clone(cfg.Senders), cfg.Recipients...)
+	if len(addresses) == 0 {
+		return nil, "", errors.New("scanning needs at least one sender or recipient address")
+	}
+	var (
+		rows     []input.Row
+		from, to uint64
+		err      error
+	)
+	switch {
+	case cfg.BigQuery:
+		if cfg.FromDate == "" {
+			return nil, "", errors.New("bigquery needs a date range")
+		}
+		c, err := fetch.NewBigQueryClient(ctx, cfg.BigQueryProject, cfg.BigQueryTable, cfg.RequestTimeout)
+		if err != nil {
+			return nil, "", err
+		}
+		c.Retry = cfg.Retry
+		rows, processed, err := fetch.BigQueryInput(ctx, c, cfg.Senders, cfg.Recipients, cfg.FromDate, cfg.ToDate)
+		if err != nil {
+			return nil, "", err
+		}
+		cfg.Logger.Info("bigquery transactions listed", "transactions", len(rows), "from", cfg.Senders, "to", cfg.Recipients, "fromDate", cfg.FromDate, "toDate", cfg.ToDate, "bytesProcessed", processed)
+		toDate := cfg.ToDate
+		if toDate == "" {
+			toDate = "latest"
+		}
+		return rows, fmt.Sprintf("scan-%s-%s-%s.csv", addresses[0].Hex(), cfg.FromDate, toDate), nil
+	case cfg.Etherscan:
+		if len(cfg.Senders) == 0 || len(cfg.Recipients) > 0 {
+			return nil, "", errors.New("etherscan lists transactions by sender only")
+		}
+		rows, from, to, err = fetch.EtherscanInput(ctx, cfg.etherscanClient(), cfg.Senders, cfg.FromBlock, cfg.ToBlock, cfg.FromDate, cfg.ToDate)
+		if err != nil {
+			return nil, "", err
+		}
+		cfg.Logger.Info("etherscan transactions listed", "transactions", len(rows), "senders", cfg.Senders, "fromBlock", from, "toBlock", to)
+	default:
+		s := &fetch.Scanner{RPC: pool, Retry: cfg.Retry, Concurrency: cfg.Concurrency, BatchSize: cfg.BatchSize, Logger: cfg.Logger}
+		from, to, err = s.BlockRange(ctx, cfg.FromBlock, cfg.ToBlock, cfg.FromDate, cfg.ToDate)
+		if err != nil {
+			return nil, "", err
+		}
+		cfg.Logger.Info("scanning blocks", "fromBlock", from, "toBlock", to, "from", cfg.Senders, "to", cfg.Recipients)
+		if rows, err = s.Scan(ctx, from, to, fetch.NewScanFilter(cfg.Senders, cfg.Recipients)); err != nil {
+			return nil, "", err
+		}
+	}
+	return rows, fmt.Sprintf("scan-%s-%d-%d.csv", addresses[0].Hex(), from, to), nil
+}
+
+// blobTx is a blob transaction whose blob fees are replayed under the
+// alternative blob schedules.
+type blobTx struct {
+	time         time.Time
+	block        uint64
+	blobGasUsed  uint64
+	blobGasPrice *big.Int
+}
+
+// replayBlobSchedules adds to agg the blob fees that the transactions of txs
+// would have paid under schedules.
+func replayBlobSchedules(ctx context.Context, replay *fetch.BlobReplay, schedules []fetch.BlobSchedule, agg *aggregate.Aggregator, txs *blobSpill) error {
+	replay.Logger.Info("replaying blob schedules", "schedules", len(schedules), "transactions", txs.count)
+	replayer := replay.Start(schedules, txs.from, txs.to)
+	return txs.each(func(tx blobTx) error {
+		fees, err := replayer.Fees(ctx, tx.block)
+		if err != nil {
+			return err
+		}
+		gas := new(big.Int).SetUint64(tx.blobGasUsed)
+		costs := make(map[string]*big.Int, len(schedules))
+		for j, s := range schedules {
+			costs[s.Name] = new(big.Int).Mul(gas, fees[j])
+		}
+		agg.AddBlobSchedules(tx.time, tx.block, new(big.Int).Mul(gas, tx.blobGasPrice), costs)
+		return nil
+	})
+}
@@ -0,0 +1,57 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"golang.org/x/oauth2/google"
+)
+
+// gcsStore uploads objects through the JSON API of Cloud Storage.
+type gcsStore struct {
+	bucket string
+	http   *http.Client // authenticated
+}
+
+func newGCS(ctx context.Context, bucket string) (*gcsStore, error) {
+	client, err := google.DefaultClient(ctx, "https://www.googleapis.com/auth/devstorage.read_write")
+	if err != nil {
+		return nil, fmt.Errorf("gcs: %w", err)
+	}
+	return &gcsStore{bucket: bucket, http: client}, nil
+}
+
+func (s *gcsStore) Upload(ctx context.Context, key, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	endpoint := "https://storage.googleapis.com/upload/storage/v1/b/" + url.PathEscape(s.bucket) +
+		"/o?" + url.Values{"uploadType": {"media"}, "name": {key}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, f)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = info.Size()
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("gcs: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
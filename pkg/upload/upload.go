@@ -0,0 +1,66 @@
+// Package upload copies the files of a run to S3 or Google Cloud Storage, so
+// that jobs in ephemeral containers keep their reports.
+package upload
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Uploader stores a local file under a key of a bucket.
+type Uploader interface {
+	Upload(ctx context.Context, key, file string) error
+}
+
+// Destination is a bucket and key prefix given as s3://bucket/prefix or
+// gs://bucket/prefix.
+type Destination struct {
+	Uploader
+	Prefix string
+}
+
+// Open parses dest and connects to its store. S3 credentials and region are
+// read from the standard AWS_* environment variables, GCS credentials from
+// the application default credentials.
+func Open(ctx context.Context, dest string) (*Destination, error) {
+	u, err := url.Parse(dest)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("%q has no bucket", dest)
+	}
+	d := &Destination{Prefix: strings.Trim(u.Path, "/")}
+	switch u.Scheme {
+	case "s3":
+		d.Uploader, err = newS3(u.Host)
+	case "gs":
+		d.Uploader, err = newGCS(ctx, u.Host)
+	default:
+		return nil, fmt.Errorf("%q: want an s3:// or gs:// URL", dest)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// UploadRun uploads files under <prefix>/<date>/<time>/<file name>, stamped
+// with the start of the run in UTC, and returns the keys.
+func (d *Destination) UploadRun(ctx context.Context, start time.Time, files []string) ([]string, error) {
+	stamp := start.UTC().Format("2006-01-02/150405")
+	keys := make([]string, 0, len(files))
+	for _, file := range files {
+		key := path.Join(d.Prefix, stamp, filepath.Base(file))
+		if err := d.Upload(ctx, key, file); err != nil {
+			return keys, fmt.Errorf("%s: %w", file, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
@@ -0,0 +1,92 @@
+// Package webhook delivers results to an HTTP endpoint as signed JSON POSTs,
+// so that downstream services need not poll for report files.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers of a delivery. The signature is the hex HMAC-SHA256, keyed with the
+// shared secret, of the timestamp, a dot and the body; receivers recompute it
+// and reject stale timestamps to stop replays.
+const (
+	EventHeader     = "X-Tracker-Event"
+	TimestampHeader = "X-Tracker-Timestamp"
+	SignatureHeader = "X-Tracker-Signature"
+)
+
+// attempts is the number of deliveries tried before giving up. Responses other
+// than 2xx are retried, except client errors other than 408 and 429.
+const attempts = 3
+
+// Webhook posts events to URL.
+type Webhook struct {
+	URL    string
+	Secret string // signs the deliveries if not empty
+	HTTP   *http.Client
+}
+
+// New returns a webhook posting to url, signed with secret.
+func New(url, secret string) *Webhook {
+	return &Webhook{URL: url, Secret: secret, HTTP: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Post delivers body, a JSON document, as an event of the given name.
+func (w *Webhook) Post(ctx context.Context, event string, body []byte) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		var retry bool
+		if retry, err = w.post(ctx, event, body); err == nil || !retry || attempt >= attempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt) * time.Second):
+		}
+	}
+}
+
+// post makes one delivery and reports whether a failure may be retried.
+func (w *Webhook) post(ctx context.Context, event string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(TimestampHeader, timestamp)
+	if w.Secret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(w.Secret, timestamp, body))
+	}
+	resp, err := w.HTTP.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests
+		return retry, fmt.Errorf("webhook: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return false, nil
+}
+
+// Sign returns the hex signature of a delivery with the given timestamp and
+// body.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
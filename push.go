@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/tracker"
+)
+
+// pushJob is the job under which -push-gateway groups the metrics.
+const pushJob = "batcher-gas-tracker"
+
+// gaugeValues returns the values of the /metrics gauges for the bucket r,
+// by gauge name. Values unavailable for lack of a blob gas price are left
+// out, as they have no sample in /metrics.
+func gaugeValues(r *aggregate.Result) map[string]float64 {
+	float := func(v *big.Float) float64 {
+		f, _ := v.Float64()
+		return f
+	}
+	values := map[string]float64{
+		"l1_avg_calldata_gas_price_gwei": float(r.AvgCallDataGasPrice),
+		"l1_total_calldata_gas_used":     float64(r.TotalCalldataGasUsed),
+		"l1_total_blob_gas_used":         float64(r.TotalBlobGasUsed),
+		"l1_daily_tx_count":              float64(r.TxCount),
+	}
+	if r.BlobPriceMissing == 0 {
+		values["l1_daily_cost_eth"] = float(r.Cost)
+		values["l1_avg_blob_gas_price_gwei"] = float(r.AvgBlobGasPrice)
+		values["l1_blended_gas_price_gwei"] = float(r.BlendedGasPrice)
+	}
+	return values
+}
+
+// pushGateway replaces the metrics of the group of labels at the Pushgateway
+// at url with the gauges of the last day of report, those that serve exposes
+// at /metrics.
+func pushGateway(url string, labels reportLabels, loc *time.Location, report tracker.Report, log *slog.Logger) error {
+	if len(report.Dates) == 0 {
+		return nil
+	}
+	day := report.Dates[len(report.Dates)-1]
+	start, _, err := bucketRange(day, "day", loc)
+	if err != nil {
+		return err
+	}
+	values := gaugeValues(report.Results[day])
+	var body bytes.Buffer
+	for _, g := range gauges {
+		if v, ok := values[g.name]; ok {
+			fmt.Fprintf(&body, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n", g.name, g.help, g.name, g.name, strconv.FormatFloat(v, 'g', -1, 64))
+		}
+	}
+	fmt.Fprintf(&body, "# HELP l1_report_day_timestamp_seconds Start of the latest day of the reports, as a Unix timestamp.\n# TYPE l1_report_day_timestamp_seconds gauge\nl1_report_day_timestamp_seconds %d\n", start.Unix())
+
+	// The grouping key labels the series, as the chain and batcher labels of
+	// /metrics.
+	path := "/metrics/job/" + pushJob + "/chain/" + groupingValue(labels.chain)
+	if labels.batcher != "" {
+		path += "/batcher/" + groupingValue(labels.batcher)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, strings.TrimSuffix(url, "/")+path, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := doPush(req); err != nil {
+		return err
+	}
+	log.Info("metrics pushed", "to", url, "day", day)
+	return nil
+}
+
+// groupingValue encodes a label value of a Pushgateway grouping key for the
+// URL path, in base64 when it has a slash or is empty.
+func groupingValue(v string) string {
+	if v == "" || strings.Contains(v, "/") {
+		return "@base64/" + base64.RawURLEncoding.EncodeToString([]byte(v))
+	}
+	return v
+}
+
+// remoteWrite sends the gauges of every day of report to the Prometheus
+// remote-write endpoint at url, timestamped at the start of their day in
+// loc, so that the history of the report appears as series.
+func remoteWrite(url string, labels reportLabels, loc *time.Location, report tracker.Report, log *slog.Logger) error {
+	if len(report.Dates) == 0 {
+		return nil
+	}
+	samples := make(map[string][]remoteSample, len(gauges))
+	for _, k := range report.Dates {
+		start, _, err := bucketRange(k, "day", loc)
+		if err != nil {
+			return err
+		}
+		for name, v := range gaugeValues(report.Results[k]) {
+			samples[name] = append(samples[name], remoteSample{v, start.UnixMilli()})
+		}
+	}
+	var request []byte
+	for _, g := range gauges {
+		if len(samples[g.name]) == 0 {
+			continue
+		}
+		// The labels of a series are sorted by name.
+		series := [][2]string{{"__name__", g.name}}
+		if labels.batcher != "" {
+			series = append(series, [2]string{"batcher", labels.batcher})
+		}
+		series = append(series, [2]string{"chain", labels.chain})
+		request = protowire.AppendTag(request, 1, protowire.BytesType)
+		request = protowire.AppendBytes(request, encodeTimeSeries(series, samples[g.name]))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(snappy.Encode(nil, request)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	if err := doPush(req); err != nil {
+		return err
+	}
+	log.Info("metrics written", "to", url, "days", len(report.Dates))
+	return nil
+}
+
+// remoteSample is a sample of a remote-write series, at a Unix time in
+// milliseconds.
+type remoteSample struct {
+	value     float64
+	timestamp int64
+}
+
+// encodeTimeSeries encodes a prometheus.TimeSeries message of the
+// remote-write protocol: its labels, then its samples.
+func encodeTimeSeries(labels [][2]string, samples []remoteSample) []byte {
+	var b []byte
+	for _, l := range labels {
+		var label []byte
+		label = protowire.AppendTag(label, 1, protowire.BytesType)
+		label = protowire.AppendString(label, l[0])
+		label = protowire.AppendTag(label, 2, protowire.BytesType)
+		label = protowire.AppendString(label, l[1])
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, label)
+	}
+	for _, s := range samples {
+		var sample []byte
+		sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+		sample = protowire.AppendFixed64(sample, math.Float64bits(s.value))
+		sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+		sample = protowire.AppendVarint(sample, uint64(s.timestamp))
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		b = protowire.AppendBytes(b, sample)
+	}
+	return b
+}
+
+// doPush sends req and fails on a status other than 2xx.
+func doPush(req *http.Request) error {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
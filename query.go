@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+)
+
+// queryMetric is a value that query reports for every group of days.
+type queryMetric struct {
+	name   string
+	header string
+	value  func(r *aggregate.Result) string
+}
+
+// queryMetrics are the metrics a query can ask for, by name.
+var queryMetrics = []queryMetric{
+	{"cost", "Cost(ETH)", func(r *aggregate.Result) string { return r.BlobDependent(r.Cost) }},
+	{"calldata-cost", "Calldata Cost(ETH)", func(r *aggregate.Result) string { return r.CalldataCost.String() }},
+	{"blob-cost", "Blob Cost(ETH)", func(r *aggregate.Result) string { return r.BlobDependent(r.BlobCost) }},
+	{"txs", "Txs", func(r *aggregate.Result) string { return strconv.FormatUint(r.TxCount, 10) }},
+	{"gas", "Gas Used", func(r *aggregate.Result) string { return strconv.FormatUint(r.TotalGasUsed, 10) }},
+	{"calldata-gas", "Calldata Gas Used", func(r *aggregate.Result) string { return strconv.FormatUint(r.TotalCalldataGasUsed, 10) }},
+	{"blob-gas", "Blob Gas Used", func(r *aggregate.Result) string { return strconv.FormatUint(r.TotalBlobGasUsed, 10) }},
+	{"gas-price", "Avg Calldata Gas Price(Gwei)", func(r *aggregate.Result) string { return r.AvgCallDataGasPrice.String() }},
+	{"blob-price", "Avg Blob Gas Price(Gwei)", func(r *aggregate.Result) string { return r.BlobDependent(r.AvgBlobGasPrice) }},
+	{"blended-price", "Blended Gas Price(Gwei)", func(r *aggregate.Result) string { return r.BlobDependent(r.BlendedGasPrice) }},
+}
+
+// queryGroups are the groupings of the days a query can ask for.
+var queryGroups = []string{"day", "week", "month", "total"}
+
+// query is a parsed question about the stored days.
+type query struct {
+	metrics  []queryMetric
+	from, to string // YYYY-MM-DD, inclusive; empty is open
+	group    string // day, week, month or total
+}
+
+// parseQuery parses a question such as "cost, txs between 2024-06-01 and
+// 2024-06-30 group by week": metrics, then optionally the days, as "between A
+// and B", "from A [to B]", "since A", "until B", "on A" or "last N days",
+// then optionally "group by day|week|month|total". today anchors "last".
+func parseQuery(text string, today time.Time) (query, error) {
+	q := query{group: "day"}
+	words := strings.Fields(strings.ToLower(strings.ReplaceAll(text, ",", " ")))
+	i := 0
+	next := func(what string) (string, error) {
+		if i >= len(words) {
+			return "", fmt.Errorf("missing %s", what)
+		}
+		i++
+		return words[i-1], nil
+	}
+	day := func() (string, error) {
+		w, err := next("day")
+		if err != nil {
+			return "", err
+		}
+		if _, err := time.Parse(time.DateOnly, w); err != nil {
+			return "", fmt.Errorf("%q is not a YYYY-MM-DD day", w)
+		}
+		return w, nil
+	}
+	for i < len(words) {
+		w := words[i]
+		i++
+		var err error
+		switch w {
+		case "between":
+			if q.from, err = day(); err != nil {
+				return q, err
+			}
+			if w, err = next(`"and"`); err != nil || w != "and" {
+				return q, errors.New(`between needs "and"`)
+			}
+			q.to, err = day()
+		case "from", "since":
+			q.from, err = day()
+		case "to", "until":
+			q.to, err = day()
+		case "on":
+			q.from, err = day()
+			q.to = q.from
+		case "last":
+			var n string
+			if n, err = next("number of days"); err != nil {
+				break
+			}
+			days, convErr := strconv.Atoi(n)
+			if convErr != nil || days < 1 {
+				return q, fmt.Errorf("%q is not a number of days", n)
+			}
+			if i < len(words) && (words[i] == "days" || words[i] == "day") {
+				i++
+			}
+			q.from = today.AddDate(0, 0, 1-days).Format(time.DateOnly)
+			q.to = today.Format(time.DateOnly)
+		case "group":
+			if i < len(words) && words[i] == "by" {
+				i++
+			}
+			if q.group, err = next("group"); err == nil && !slices.Contains(queryGroups, q.group) {
+				err = fmt.Errorf("cannot group by %q, want day, week, month or total", q.group)
+			}
+		default:
+			m := slices.IndexFunc(queryMetrics, func(m queryMetric) bool { return m.name == w })
+			if m < 0 {
+				return q, fmt.Errorf("unknown word %q", w)
+			}
+			q.metrics = append(q.metrics, queryMetrics[m])
+		}
+		if err != nil {
+			return q, err
+		}
+	}
+	if len(q.metrics) == 0 {
+		q.metrics = []queryMetric{queryMetrics[0], queryMetrics[3]}
+	}
+	return q, nil
+}
+
+// runQuery answers a question about the days stored by a -sink database,
+// given as words or flags, without a SQL client.
+func runQuery(args []string) error {
+	fs := flag.NewFlagSet("query", flag.ContinueOnError)
+	dbPath := fs.String("db", "", "-sink database to query: the path of the SQLite file or a postgres:// URL")
+	from := fs.String("from", "", "first day, YYYY-MM-DD; overrides the query")
+	to := fs.String("to", "", "last day, YYYY-MM-DD; overrides the query")
+	group := fs.String("group", "", "group the days by day, week, month or total; overrides the query")
+	format := fs.String("format", "table", "output format: table, csv or json")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s query -db database [flags] [query]\n\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "A query lists metrics, then optionally the days and a grouping, e.g.\n  cost, txs between 2024-06-01 and 2024-06-30 group by week\n  blob-price last 7 days\n\nMetrics:")
+		for _, m := range queryMetrics {
+			fmt.Fprintf(fs.Output(), " %s", m.name)
+		}
+		fmt.Fprintf(fs.Output(), "\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if *dbPath == "" {
+		return errors.New("query needs -db")
+	}
+	switch *format {
+	case "table", "csv", "json":
+	default:
+		return fmt.Errorf("-format: unknown format %q", *format)
+	}
+	q, err := parseQuery(strings.Join(fs.Args(), " "), time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+	if *from != "" {
+		q.from = *from
+	}
+	if *to != "" {
+		q.to = *to
+	}
+	if *group != "" {
+		if !slices.Contains(queryGroups, *group) {
+			return fmt.Errorf("-group: cannot group by %q, want day, week, month or total", *group)
+		}
+		q.group = *group
+	}
+	store, err := openStore(*dbPath)
+	if err != nil {
+		return fmt.Errorf("-db: %w", err)
+	}
+	defer store.Close()
+	dates, results, err := store.Days(context.Background(), q.from, q.to)
+	if err != nil {
+		return err
+	}
+	if len(dates) == 0 {
+		return errors.New("no stored day in the range")
+	}
+
+	keys, groups, err := groupDays(q.group, dates, results)
+	if err != nil {
+		return err
+	}
+	// A total row follows several groups.
+	if len(keys) > 1 {
+		period, total := summarize(dates, results)
+		keys = append(keys, "Total "+period)
+		groups["Total "+period] = total
+	}
+	return writeQuery(os.Stdout, *format, q.metrics, keys, groups)
+}
+
+// groupDays merges the stored days by group.
+func groupDays(group string, dates []string, results map[string]*aggregate.Result) ([]string, map[string]*aggregate.Result, error) {
+	switch group {
+	case "day":
+		return dates, results, nil
+	case "total":
+		period, total := summarize(dates, results)
+		return []string{period}, map[string]*aggregate.Result{period: total}, nil
+	}
+	var keyErr error
+	keys, groups := aggregate.Rollup(results, func(day string) string {
+		t, err := time.Parse(time.DateOnly, day)
+		if err != nil {
+			keyErr = err
+		}
+		return aggregate.BucketKey(t, group)
+	})
+	return keys, groups, keyErr
+}
+
+// writeQuery writes the metrics of every group to w in format.
+func writeQuery(w io.Writer, format string, metrics []queryMetric, keys []string, groups map[string]*aggregate.Result) error {
+	switch format {
+	case "json":
+		rows := make([]map[string]any, 0, len(keys))
+		for _, k := range keys {
+			row := map[string]any{"bucket": k}
+			for _, m := range metrics {
+				row[m.name] = jsonNumber(m.value(groups[k]))
+			}
+			rows = append(rows, row)
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	case "csv":
+		writer := csv.NewWriter(w)
+		header := []string{"Bucket"}
+		for _, m := range metrics {
+			header = append(header, m.header)
+		}
+		writer.Write(header)
+		for _, k := range keys {
+			record := []string{k}
+			for _, m := range metrics {
+				record = append(record, m.value(groups[k]))
+			}
+			writer.Write(record)
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "Bucket\t")
+	for _, m := range metrics {
+		fmt.Fprintf(tw, "%s\t", m.header)
+	}
+	fmt.Fprintln(tw)
+	for _, k := range keys {
+		fmt.Fprintf(tw, "%s\t", k)
+		for _, m := range metrics {
+			fmt.Fprintf(tw, "%s\t", m.value(groups[k]))
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+// jsonNumber returns v as a JSON number, or as a string when it is not one,
+// such as an unavailable value.
+func jsonNumber(v string) any {
+	if _, ok := new(big.Float).SetString(v); ok {
+		return json.Number(v)
+	}
+	return v
+}
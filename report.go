@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+)
+
+// summedColumns are the report columns whose values add up across buckets.
+var summedColumns = map[string]bool{
+	"Total Cost(ETH)":                 true,
+	"Total Calldata Gas Used":         true,
+	"Total Blob Gas Used":             true,
+	"Total Gas Used(calldata + blob)": true,
+	"Transaction Count":               true,
+	"Total Cost(wei)":                 true,
+	"Calldata Cost(ETH)":              true,
+	"Calldata Cost(wei)":              true,
+	"Blob Cost(ETH)":                  true,
+	"Blob Cost(wei)":                  true,
+}
+
+// runReport prints reports written by analyze as aligned tables, each
+// followed by the totals of its summable columns, and optionally charts them
+// or renders them as PDF.
+func runReport(args []string) error {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	outDir := fs.String("out", "./outputs", "directory searched when no report file is given")
+	charts := fs.Bool("charts", false, "also render line charts of the cost and the average gas prices next to each report")
+	chartFormat := fs.String("chart-format", "png", "image format of -charts: png or svg")
+	pdf := fs.Bool("pdf", false, "also write a paginated PDF of each report, with its totals, charts and buckets, next to it, e.g. for monthly cost reviews")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s report [flags] [output.csv ...]\n\nFlags:\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if *chartFormat != "png" && *chartFormat != "svg" {
+		return fmt.Errorf("unknown -chart-format %q", *chartFormat)
+	}
+
+	files := fs.Args()
+	if len(files) == 0 {
+		matches, err := filepath.Glob(filepath.Join(*outDir, "output-*.csv"))
+		if err != nil {
+			return err
+		}
+		if len(matches) == 0 {
+			return fmt.Errorf("no reports in %s", *outDir)
+		}
+		files = matches
+	}
+	for i, file := range files {
+		if i > 0 {
+			fmt.Println()
+		}
+		records, err := readReport(file)
+		if err != nil {
+			return err
+		}
+		if err := printReport(file, records); err != nil {
+			return err
+		}
+		if *charts {
+			if err := chartReport(file, records, *chartFormat); err != nil {
+				return fmt.Errorf("%s: %w", file, err)
+			}
+		}
+		if *pdf {
+			if err := pdfReport(file, records); err != nil {
+				return fmt.Errorf("%s: %w", file, err)
+			}
+		}
+	}
+	return nil
+}
+
+func readReport(path string) ([][]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s: empty report", path)
+	}
+	// Reports written since they have a total row end with it.
+	if last := records[len(records)-1]; len(records) > 1 && last[0] == "Total" {
+		records = records[:len(records)-1]
+	}
+	return records, nil
+}
+
+func printReport(path string, records [][]string) error {
+	fmt.Println(path)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	for _, record := range append(records, reportTotals(records)) {
+		fmt.Fprintln(w, strings.Join(record, "\t")+"\t")
+	}
+	return w.Flush()
+}
+
+// reportTotals returns the total row of the report records: the sums of its
+// summable columns, empty for the others.
+func reportTotals(records [][]string) []string {
+	headers := records[0]
+	totals := make([]*big.Float, len(headers))
+	for i, header := range headers {
+		if summedColumns[header] {
+			totals[i] = new(big.Float)
+		}
+	}
+	for _, record := range records[1:] {
+		for i, value := range record {
+			if i >= len(totals) || totals[i] == nil {
+				continue
+			}
+			// Precise enough for the sums in wei to stay exact.
+			v, ok := new(big.Float).SetPrec(256).SetString(value)
+			if !ok {
+				// An aggregate.Unavailable bucket value makes the total unavailable too.
+				totals[i] = nil
+				continue
+			}
+			totals[i].Add(totals[i], v)
+		}
+	}
+	totalRow := make([]string, len(headers))
+	totalRow[0] = "Total"
+	for i := 1; i < len(headers); i++ {
+		switch {
+		case totals[i] != nil && strings.HasSuffix(headers[i], "(wei)"):
+			totalRow[i] = totals[i].Text('f', 0)
+		case totals[i] != nil:
+			totalRow[i] = totals[i].Text('g', 10)
+		case summedColumns[headers[i]]:
+			totalRow[i] = aggregate.Unavailable
+		}
+	}
+	return totalRow
+}
+
+// chartReport renders the cost and the average gas prices of the report at
+// path to <path without .csv>.cost.<format> and .gas-price.<format>.
+func chartReport(path string, records [][]string, format string) error {
+	keys, cost, calldata, blob := chartSeries(records)
+	base := strings.TrimSuffix(path, ".csv")
+	costPath, pricePath := base+".cost."+format, base+".gas-price."+format
+	if err := output.WriteChart(costPath, "Total cost", "ETH", keys, cost); err != nil {
+		return err
+	}
+	if err := output.WriteChart(pricePath, "Average gas price", "Gwei", keys, calldata, blob); err != nil {
+		return err
+	}
+	fmt.Println("charts:", costPath, pricePath)
+	return nil
+}
+
+// pdfReport renders the report at path, with its totals and charts, to
+// <path without .csv>.pdf.
+func pdfReport(path string, records [][]string) error {
+	keys, cost, calldata, blob := chartSeries(records)
+	var charts [][]byte
+	if len(keys) > 0 {
+		costChart, err := output.ChartPNG("Total cost", "ETH", keys, cost)
+		if err != nil {
+			return err
+		}
+		priceChart, err := output.ChartPNG("Average gas price", "Gwei", keys, calldata, blob)
+		if err != nil {
+			return err
+		}
+		charts = [][]byte{costChart, priceChart}
+	}
+	name := strings.TrimPrefix(strings.TrimSuffix(filepath.Base(path), ".csv"), "output-")
+	pdfPath := strings.TrimSuffix(path, ".csv") + ".pdf"
+	if err := output.WritePDF(pdfPath, "L1 costs of "+name, records, reportTotals(records), charts...); err != nil {
+		return err
+	}
+	fmt.Println("pdf:", pdfPath)
+	return nil
+}
+
+// chartSeries returns the buckets of the report records with their cost and
+// their average calldata and blob gas prices.
+func chartSeries(records [][]string) (keys []string, cost, calldata, blob output.Series) {
+	column := func(header string) output.Series {
+		s := output.Series{Name: header, Values: make([]float64, len(records)-1)}
+		i := slices.Index(records[0], header)
+		for j, record := range records[1:] {
+			s.Values[j] = math.NaN()
+			if i >= 0 && i < len(record) {
+				if v, err := strconv.ParseFloat(record[i], 64); err == nil {
+					s.Values[j] = v
+				}
+			}
+		}
+		return s
+	}
+	keys = make([]string, len(records)-1)
+	for j, record := range records[1:] {
+		keys[j] = record[0]
+	}
+	calldata, blob = column("Avg Calldata gas price(Gwei)"), column("Avg Blob Gas Price(Gwei)")
+	calldata.Name, blob.Name = "Calldata", "Blob"
+	return keys, column("Total Cost(ETH)"), calldata, blob
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+)
+
+// parseRoles parses a comma-separated list of address=role pairs, e.g.
+// "0xff00...0010=batch-inbox,0x9b3c...=output-oracle", into the role of every
+// address and the role names in the order they first appear.
+func parseRoles(list string) (map[common.Address]string, []string, error) {
+	var names []string
+	roles := make(map[common.Address]string)
+	for _, pair := range strings.Split(list, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		address, role, ok := strings.Cut(pair, "=")
+		address, role = strings.TrimSpace(address), strings.TrimSpace(role)
+		if !ok || role == "" {
+			return nil, nil, fmt.Errorf("%q is not address=role", pair)
+		}
+		if !common.IsHexAddress(address) {
+			return nil, nil, fmt.Errorf("invalid address %q", address)
+		}
+		if role == aggregate.OtherRole {
+			return nil, nil, fmt.Errorf("role %q is reserved for the other transactions", role)
+		}
+		if role == aggregate.DepositRole {
+			return nil, nil, fmt.Errorf("role %q is reserved for the deposits to -bridge-contracts", role)
+		}
+		if role == aggregate.DisputeRole {
+			return nil, nil, fmt.Errorf("role %q is reserved for the dispute game transactions of -disputes", role)
+		}
+		roles[common.HexToAddress(address)] = role
+		if !slices.Contains(names, role) {
+			names = append(names, role)
+		}
+	}
+	if len(roles) == 0 {
+		return nil, nil, nil
+	}
+	return roles, names, nil
+}
+
+// bridgeRole is the role of the calls to the bridge contracts other than
+// deposits, such as the proofs and finalizations of withdrawals.
+const bridgeRole = "bridge"
+
+// depositRoles returns the set of the bridge contracts whose deposits count
+// under the deposit role, and gives them the bridge role in roles and names
+// unless -roles already gives them one.
+func depositRoles(contracts []common.Address, roles map[common.Address]string, names []string) (map[common.Address]bool, map[common.Address]string, []string) {
+	if len(contracts) == 0 {
+		return nil, roles, names
+	}
+	if roles == nil {
+		roles = make(map[common.Address]string)
+	}
+	deposits := make(map[common.Address]bool, len(contracts))
+	for _, contract := range contracts {
+		deposits[contract] = true
+		if _, ok := roles[contract]; ok {
+			continue
+		}
+		roles[contract] = bridgeRole
+		if !slices.Contains(names, bridgeRole) {
+			names = append(names, bridgeRole)
+		}
+	}
+	return deposits, roles, append(names, aggregate.DepositRole)
+}
+
+// disputeRoles adds the dispute role to roles and names, so that the
+// transactions playing dispute games split from those of their recipients.
+func disputeRoles(roles map[common.Address]string, names []string) (map[common.Address]string, []string) {
+	if roles == nil {
+		roles = make(map[common.Address]string)
+	}
+	return roles, append(names, aggregate.DisputeRole)
+}
+
+// printRoleSummary prints the transactions of role over the whole report,
+// labeled label, and their share of its L1 cost.
+func printRoleSummary(w io.Writer, label, role string, total *aggregate.Result) {
+	share := total.Roles[role]
+	if share == nil {
+		fmt.Fprintf(w, "%s: none\n", label)
+		return
+	}
+	fmt.Fprintf(w, "%s: %d txs, %s ETH", label, share.TxCount, total.BlobDependent(share.Cost))
+	if total.Cost.Sign() > 0 {
+		part, _ := new(big.Float).Quo(share.Cost, total.Cost).Float64()
+		fmt.Fprintf(w, ", %.1f%% of the L1 cost", 100*part)
+	}
+	fmt.Fprintln(w)
+}
+
+// roleColumns returns the report columns of the transaction count and the
+// cost of every role, in the order of names, and of the other transactions.
+func roleColumns(names []string, results map[string]*aggregate.Result) []output.Column {
+	var columns []output.Column
+	for _, name := range append(slices.Clip(names), aggregate.OtherRole) {
+		name := name
+		columns = append(columns, shareColumns(name, results, func(r *aggregate.Result) *aggregate.Share { return r.Roles[name] })...)
+	}
+	return columns
+}
+
+// shareColumns returns the columns, headed by label, of the transaction count
+// and the cost of the share that share picks from every bucket.
+func shareColumns(label string, results map[string]*aggregate.Result, share func(*aggregate.Result) *aggregate.Share) []output.Column {
+	count := output.Column{Header: label + " Txs", Values: make(map[string]string, len(results))}
+	cost := output.Column{Header: label + " Cost(ETH)", Values: make(map[string]string, len(results))}
+	costWei := output.Column{Header: label + " Cost(wei)", Values: make(map[string]string, len(results))}
+	for k, r := range results {
+		s := share(r)
+		if s == nil {
+			count.Values[k], cost.Values[k], costWei.Values[k] = "0", "0", "0"
+			continue
+		}
+		count.Values[k], cost.Values[k] = strconv.FormatUint(s.TxCount, 10), r.BlobDependent(s.Cost)
+		costWei.Values[k] = r.BlobDependentWei(s.CostWei)
+	}
+	return []output.Column{count, cost, costWei}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/alert"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/fetch"
+)
+
+// runway is the balance of the senders of a report and how many days it
+// lasts at their recent daily spend.
+type runway struct {
+	Time     time.Time
+	Senders  []common.Address
+	Balances []*big.Int // wei, per sender
+	Balance  *big.Int   // wei, of all the senders
+	Days     int        // days averaged
+	AvgDaily *big.Float // ETH per day
+	Runway   float64    // days, +Inf without spend
+}
+
+// readRunway reads the balances of senders at the latest block, and divides
+// them by the average daily cost of the last window complete days of the
+// report, those before today.
+func readRunway(rpcURLs string, timeout time.Duration, senders []common.Address, dates []string, results map[string]*aggregate.Result, window int, today string) (runway, error) {
+	rw := runway{Time: time.Now().UTC(), Senders: senders, Balance: new(big.Int), AvgDaily: new(big.Float)}
+	pool, err := fetch.Dial(rpcURLs)
+	if err != nil {
+		return rw, err
+	}
+	defer pool.Close()
+	pool.Timeout = timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	for _, sender := range senders {
+		balance, err := pool.Balance(ctx, sender)
+		if err != nil {
+			return rw, err
+		}
+		rw.Balances = append(rw.Balances, balance)
+		rw.Balance.Add(rw.Balance, balance)
+	}
+
+	// The days without transactions are missing from the report but count
+	// in the average.
+	var complete []string
+	for _, day := range dates {
+		if day < today {
+			complete = append(complete, day)
+		}
+	}
+	rw.Runway = math.Inf(1)
+	if len(complete) == 0 {
+		return rw, nil
+	}
+	end, err := time.Parse(time.DateOnly, complete[len(complete)-1])
+	if err != nil {
+		return rw, err
+	}
+	first, err := time.Parse(time.DateOnly, complete[0])
+	if err != nil {
+		return rw, err
+	}
+	start := end.AddDate(0, 0, 1-window)
+	if first.After(start) {
+		start = first
+	}
+	rw.Days = int(end.Sub(start).Hours()/24) + 1
+	total := new(big.Float)
+	for _, day := range complete {
+		if day >= start.Format(time.DateOnly) {
+			total.Add(total, results[day].Cost)
+		}
+	}
+	rw.AvgDaily.Quo(total, big.NewFloat(float64(rw.Days)))
+	if rw.AvgDaily.Sign() > 0 {
+		days, _ := new(big.Float).Quo(ether(rw.Balance), rw.AvgDaily).Float64()
+		rw.Runway = days
+	}
+	return rw, nil
+}
+
+// runwayText returns the runway in days, or "unlimited" without spend.
+func (rw runway) runwayText() string {
+	if math.IsInf(rw.Runway, 1) {
+		return "unlimited"
+	}
+	return strconv.FormatFloat(rw.Runway, 'f', 1, 64)
+}
+
+// printRunwaySummary prints the balance of the senders and their runway.
+func printRunwaySummary(w io.Writer, rw runway) {
+	fmt.Fprintf(w, "Runway: %s ETH", ether(rw.Balance).Text('f', 6))
+	if len(rw.Senders) > 1 {
+		balances := make([]string, len(rw.Senders))
+		for i, sender := range rw.Senders {
+			balances[i] = fmt.Sprintf("%s %s", sender.Hex(), ether(rw.Balances[i]).Text('f', 6))
+		}
+		fmt.Fprintf(w, " (%s)", strings.Join(balances, ", "))
+	}
+	fmt.Fprintf(w, " at %s ETH per day over the last %d days: %s days left\n", rw.AvgDaily.Text('f', 6), rw.Days, rw.runwayText())
+}
+
+// appendRunway appends the runway to the history at path, with a header when
+// the file is new, so that scheduled runs record the balance over time.
+func appendRunway(path string, rw runway) error {
+	outFile, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+
+	writer := csv.NewWriter(outFile)
+	if info, err := outFile.Stat(); err == nil && info.Size() == 0 {
+		header := []string{"Time", "Senders", "Balance(wei)", "Balance(ETH)", "Avg Daily Cost(ETH)", "Days Averaged", "Runway(days)"}
+		if err := writer.Write(header); err != nil {
+			return err
+		}
+	}
+	senders := make([]string, len(rw.Senders))
+	for i, sender := range rw.Senders {
+		senders[i] = sender.Hex()
+	}
+	record := []string{
+		rw.Time.Format(time.RFC3339),
+		strings.Join(senders, " "),
+		rw.Balance.String(),
+		ether(rw.Balance).Text('f', 18),
+		rw.AvgDaily.Text('f', 18),
+		strconv.Itoa(rw.Days),
+		rw.runwayText(),
+	}
+	if err := writer.Write(record); err != nil {
+		return err
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return outFile.Close()
+}
+
+// runwayState is the day of the last runway alert, kept so that a report
+// run several times a day alerts once.
+type runwayState struct {
+	Day string `json:"day"`
+}
+
+// checkRunway alerts through notifier when the runway of rw is below
+// threshold days, unless the state at statePath says it alerted today.
+func checkRunway(notifier *alert.Notifier, name string, rw runway, threshold float64, today, statePath string, log *slog.Logger) error {
+	if rw.Runway >= threshold {
+		return nil
+	}
+	var state runwayState
+	data, err := os.ReadFile(statePath)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &state); err != nil {
+			return fmt.Errorf("%s: %w", statePath, err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return err
+	}
+	if state.Day == today {
+		return nil
+	}
+	text := fmt.Sprintf("%s: the batcher balance of %s ETH lasts %s days at %s ETH per day, below the runway of %g days",
+		name, ether(rw.Balance).Text('f', 4), rw.runwayText(), rw.AvgDaily.Text('f', 6), threshold)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	if err := notifier.Send(ctx, text); err != nil {
+		return fmt.Errorf("alerts: %w", err)
+	}
+	log.Info("runway alert sent", "runwayDays", rw.Runway, "threshold", threshold)
+	data, err = json.Marshal(runwayState{Day: today})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(statePath, data, 0o644)
+}
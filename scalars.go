@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+)
+
+// scalarDecimals is the fixed-point precision of the Ecotone fee scalars.
+const scalarDecimals = 1_000_000
+
+// recommendedScalars returns the Ecotone baseFeeScalar and blobBaseFeeScalar
+// at which the L1 fees of the L2 transactions cover the batches of r, and
+// false without decoded frames. The L1 fee of a transaction is
+//
+//	size * (16*baseFeeScalar*l1BaseFee + blobBaseFeeScalar*l1BlobBaseFee) / 1e6
+//
+// so that, summed over the compressed size of the batched transactions, the
+// fees break even with the calldata and blob gas of the batches when the
+// scalars are the gas used per byte of frame data, in millionths.
+func recommendedScalars(r *aggregate.Result) (baseFee, blobBaseFee uint64, ok bool) {
+	if r.FrameBytes == 0 {
+		return 0, 0, false
+	}
+	baseFee = ceilDiv(r.FrameCalldataGas*scalarDecimals, 16*r.FrameBytes)
+	blobBaseFee = ceilDiv(r.FrameBlobGas*scalarDecimals, r.FrameBytes)
+	return baseFee, blobBaseFee, true
+}
+
+func ceilDiv(a, b uint64) uint64 {
+	return (a + b - 1) / b
+}
+
+// scalarColumns returns the recommended scalars of every bucket, empty for
+// buckets without decoded frames.
+func scalarColumns(results map[string]*aggregate.Result) []output.Column {
+	base := output.Column{Header: "Recommended Base Fee Scalar", Values: make(map[string]string, len(results))}
+	blob := output.Column{Header: "Recommended Blob Base Fee Scalar", Values: make(map[string]string, len(results))}
+	for k, r := range results {
+		if b, bb, ok := recommendedScalars(r); ok {
+			base.Values[k], blob.Values[k] = strconv.FormatUint(b, 10), strconv.FormatUint(bb, 10)
+		}
+	}
+	return []output.Column{base, blob}
+}
+
+// printScalarSummary prints the scalars recommended over the whole report.
+func printScalarSummary(w io.Writer, total *aggregate.Result) {
+	b, bb, ok := recommendedScalars(total)
+	if !ok {
+		fmt.Fprintln(w, "Recommended scalars: no batcher frames decoded")
+		return
+	}
+	fmt.Fprintf(w, "Recommended scalars: baseFeeScalar %d, blobBaseFeeScalar %d (%d bytes of frame data)\n", b, bb, total.FrameBytes)
+}
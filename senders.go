@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"math/big"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/fetch"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/tracker"
+)
+
+// senderLabelPattern matches the labels of senders, which name their files.
+var senderLabelPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// splitLabels splits a comma-separated list of addresses, each optionally
+// labeled as address=label, e.g. "0x04b9...=batcher-2024,0x6887...=proposer",
+// into the list of the addresses alone and their labels, keyed by the hex
+// address that the results split the senders by.
+func splitLabels(list string) (string, map[string]string, error) {
+	var addresses []string
+	labels := make(map[string]string)
+	for _, entry := range strings.Split(list, ",") {
+		address, label, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		address, label = strings.TrimSpace(address), strings.TrimSpace(label)
+		if address == "" {
+			continue
+		}
+		addresses = append(addresses, address)
+		if !ok {
+			continue
+		}
+		if !senderLabelPattern.MatchString(label) {
+			return "", nil, fmt.Errorf("%q is not address=label, with a label of letters, digits, dots, dashes and underscores", entry)
+		}
+		if !common.IsHexAddress(address) {
+			return "", nil, fmt.Errorf("invalid address %q", address)
+		}
+		labels[common.HexToAddress(address).Hex()] = label
+	}
+	return strings.Join(addresses, ","), labels, nil
+}
+
+// senderLabels names the batcher and the proposer of the system config cfg,
+// keyed by the hex address that the results split the senders by, unless
+// given is the label of the address.
+func senderLabels(cfg fetch.SystemConfig, given map[string]string) map[string]string {
+	labels := make(map[string]string)
+	for _, s := range []struct {
+		address common.Address
+		name    string
+	}{
+		{cfg.Batcher, "batcher"},
+		{cfg.Proposer, "proposer"},
+	} {
+		if s.address != (common.Address{}) {
+			labels[s.address.Hex()] = s.name
+		}
+	}
+	maps.Copy(labels, given)
+	return labels
+}
+
+// senderLabel returns the address of sender, followed by its name when
+// labels has one.
+func senderLabel(labels map[string]string, sender string) string {
+	if name, ok := labels[sender]; ok {
+		return sender + " (" + name + ")"
+	}
+	return sender
+}
+
+// senderColumns returns the report columns of the transaction count and the
+// cost of every sender, the most expensive over the report first.
+func senderColumns(labels map[string]string, results map[string]*aggregate.Result) []output.Column {
+	var columns []output.Column
+	for _, sender := range byCost(results, func(r *aggregate.Result) map[string]*aggregate.Share { return r.Senders }) {
+		sender := sender
+		columns = append(columns, shareColumns(senderLabel(labels, sender), results, func(r *aggregate.Result) *aggregate.Share { return r.Senders[sender] })...)
+	}
+	return columns
+}
+
+// printSenderSummary prints the transaction count and the cost of every
+// sender over the whole report, and its part of the total cost.
+func printSenderSummary(w io.Writer, labels map[string]string, total *aggregate.Result) {
+	fmt.Fprintln(w, "Senders:")
+	totals := map[string]*aggregate.Result{"": total}
+	for _, sender := range byCost(totals, func(r *aggregate.Result) map[string]*aggregate.Share { return r.Senders }) {
+		share := total.Senders[sender]
+		fmt.Fprintf(w, "  %s: %d txs, cost %s ETH", senderLabel(labels, sender), share.TxCount, total.BlobDependent(share.Cost))
+		if total.Cost.Sign() > 0 {
+			part := new(big.Float).Quo(share.Cost, total.Cost)
+			f, _ := part.Float64()
+			fmt.Fprintf(w, " (%.1f%%)", 100*f)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// writeSenderReports writes the report of every sender on its own to a CSV
+// file next to the report, base.<label>.csv, or base.<address>.csv for
+// senders without a label, and returns their paths.
+func writeSenderReports(base string, labels map[string]string, senders map[common.Address]tracker.SenderReport) ([]string, error) {
+	addresses := make([]common.Address, 0, len(senders))
+	for sender := range senders {
+		addresses = append(addresses, sender)
+	}
+	slices.SortFunc(addresses, func(a, b common.Address) int { return a.Cmp(b) })
+	var paths []string
+	for _, sender := range addresses {
+		name, ok := labels[sender.Hex()]
+		if !ok {
+			name = strings.ToLower(sender.Hex())
+		}
+		r := senders[sender]
+		path := base + "." + name + ".csv"
+		if err := output.WriteCSV(path, r.Dates, r.Results, r.Total); err != nil {
+			return paths, fmt.Errorf("%s: %w", senderLabel(labels, sender.Hex()), err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// runServe serves the report directory over HTTP so that reports written by
+// scheduled runs can be fetched without access to the host, and exposes their
+// latest day to Prometheus.
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", ":8080", "listen address")
+	outDir := fs.String("out", "./outputs", "directory of the reports to serve")
+	dbPath := fs.String("db", "", "also serve the data of a -sink database as JSON under /api/v1/: the path of the SQLite file or a postgres:// URL")
+	grpcAddr := fs.String("grpc-addr", "", "also serve the -db data over gRPC on this address, e.g. :9090")
+	graphQL := fs.Bool("graphql", false, "also serve the -db data at /graphql")
+	metricsLabels := fs.String("metrics-labels", "", "comma-separated report=chain:batcher entries labeling the daily reports in /metrics, where report is the file name without output- and .csv (default: the report name as chain)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s serve [flags]\n\nFlags:\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	labels, err := parseMetricsLabels(*metricsLabels)
+	if err != nil {
+		return err
+	}
+
+	if (*grpcAddr != "" || *graphQL) && *dbPath == "" {
+		return errors.New("-grpc-addr and -graphql require -db")
+	}
+
+	mux := http.NewServeMux()
+	errc := make(chan error, 2)
+	if *dbPath != "" {
+		store, err := openStore(*dbPath)
+		if err != nil {
+			return fmt.Errorf("-db: %w", err)
+		}
+		defer store.Close()
+		mux.Handle("/api/v1/", apiHandler(store))
+		if *graphQL {
+			schema, err := newGraphQLSchema(store)
+			if err != nil {
+				return err
+			}
+			mux.Handle("/graphql", graphqlHandler(schema))
+		}
+		if *grpcAddr != "" {
+			lis, err := net.Listen("tcp", *grpcAddr)
+			if err != nil {
+				return fmt.Errorf("-grpc-addr: %w", err)
+			}
+			grpcServer := newGRPCServer(store)
+			defer grpcServer.Stop()
+			slog.Info("serving gRPC", "addr", *grpcAddr)
+			go func() { errc <- grpcServer.Serve(lis) }()
+		}
+	}
+	mux.Handle("/reports/", http.StripPrefix("/reports/", http.FileServer(http.Dir(*outDir))))
+	mux.Handle("/metrics", metricsHandler(*outDir, labels))
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	server := &http.Server{Addr: *addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	slog.Info("serving reports", "dir", *outDir, "addr", *addr, "path", "/reports/", "metrics", "/metrics")
+	go func() { errc <- server.ListenAndServe() }()
+	return <-errc
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/fetch"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/simulate"
+)
+
+// slotTime is the time between L1 blocks, to find the block of a time.
+const slotTime = 12 * time.Second
+
+// postedTx is a transaction of a -per-tx table, as simulate reads it.
+type postedTx struct {
+	block        uint64
+	time         time.Time
+	blob         bool
+	gasUsed      uint64
+	gasPrice     *big.Int
+	blobGasUsed  uint64
+	blobGasPrice *big.Int // nil without blobs
+	cost         *big.Int
+}
+
+// runSimulate replays the data of a -per-tx table under other submission
+// policies and compares what they would have cost with the actual spend.
+func runSimulate(args []string) error {
+	fs := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	policyList := fs.String("policy", "every=30m,every=2h,size=6blobs", "comma-separated submission policies replayed besides the actual submissions: every=<duration>, below=<price> in gwei or with a wei suffix, size=<bytes> or size=<n>blobs")
+	maxWait := fs.Duration("max-wait", 12*time.Hour, "longest time data waits under a policy before it is posted regardless, like the batcher's channel timeout (0 disables)")
+	maxCalldata := fs.Uint64("max-calldata", 120000, "bytes of calldata per transaction, as the batcher's max L1 tx size")
+	maxBlobs := fs.Uint64("max-blobs", 6, "blobs per transaction")
+	rpcURLs := fs.String("rpc", "", "comma-separated L1 JSON-RPC endpoints to sample the base fee and blob base fee every -step between the transactions (default: only the prices the transactions paid)")
+	step := fs.Duration("step", 5*time.Minute, "interval of the -rpc price samples")
+	tip := fs.Float64("tip", -1, "priority fee in gwei added to the sampled base fees (default: the median tip of the transactions)")
+	format := fs.String("format", "table", "output format: table or csv")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s simulate [flags] transactions.csv\n\nThe transactions are a -per-tx table written by analyze.\n\nFlags:\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("simulate takes a single -per-tx table")
+	}
+	if *format != "table" && *format != "csv" {
+		return fmt.Errorf("-format: unknown format %q", *format)
+	}
+	if *maxCalldata == 0 || *maxBlobs == 0 {
+		return errors.New("-max-calldata and -max-blobs must be positive")
+	}
+	if *rpcURLs != "" && *step <= 0 {
+		return errors.New("-step must be positive")
+	}
+	policies := []simulate.Policy{{Name: "actual", Actual: true}}
+	for _, s := range strings.Split(*policyList, ",") {
+		if s = strings.TrimSpace(s); s == "" || s == "actual" {
+			continue
+		}
+		p, err := simulate.ParsePolicy(s, *maxWait)
+		if err != nil {
+			return fmt.Errorf("-policy: %w", err)
+		}
+		policies = append(policies, p)
+	}
+
+	path := fs.Arg(0)
+	txs, err := readPostedTxs(path)
+	if err != nil {
+		return err
+	}
+	prices := &simulate.Prices{}
+	var payloads []simulate.Payload
+	spent := new(big.Int)
+	var bytes uint64
+	for _, tx := range txs {
+		prices.Add(tx.time, tx.gasPrice, tx.blobGasPrice)
+		spent.Add(spent, tx.cost)
+		p := simulate.Payload{Time: tx.time, Blob: tx.blob, Bytes: tx.blobGasUsed}
+		if !tx.blob {
+			// Calldata is priced at 16 gas per byte over the intrinsic gas.
+			p.Bytes = (max(tx.gasUsed, params.TxGas) - params.TxGas) / 16
+		}
+		if p.Bytes > 0 {
+			payloads = append(payloads, p)
+			bytes += p.Bytes
+		}
+	}
+	if *rpcURLs != "" {
+		tipWei := big.NewInt(-1)
+		if *tip >= 0 {
+			tipWei, _ = new(big.Float).Mul(big.NewFloat(*tip), big.NewFloat(params.GWei)).Int(nil)
+		}
+		samples, err := sampleFees(context.Background(), *rpcURLs, txs, *step, tipWei, prices)
+		if err != nil {
+			return fmt.Errorf("-rpc: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "%d fee samples every %s\n", samples, *step)
+	}
+
+	limits := simulate.Limits{MaxCalldata: *maxCalldata, MaxBlobs: *maxBlobs}
+	results := make([]simulate.Result, 0, len(policies))
+	for _, p := range policies {
+		r, err := simulate.Run(payloads, prices, p, limits)
+		if err != nil {
+			return fmt.Errorf("policy %s: %w", p.Name, err)
+		}
+		results = append(results, r)
+	}
+	if *format == "table" {
+		fmt.Printf("%s: %d transactions posting %d bytes, %s ETH spent\n", path, len(txs), bytes, ether(spent).Text('f', 6))
+	}
+	return writeSimulation(os.Stdout, *format, spent, results)
+}
+
+// readPostedTxs reads the transactions of the -per-tx table at path, oldest
+// first.
+func readPostedTxs(path string) ([]postedTx, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader := csv.NewReader(file)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	columns := make(map[string]int)
+	for i, h := range header {
+		columns[h] = i
+	}
+	needed := []string{"Block", "Time", "Type", "Gas Used", "Effective Gas Price(wei)", "Blob Gas Used", "Blob Gas Price(wei)", "Cost(wei)"}
+	for _, h := range needed {
+		if _, ok := columns[h]; !ok {
+			return nil, fmt.Errorf("%s: no %q column; simulate reads a -per-tx table", path, h)
+		}
+	}
+	var txs []postedTx
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		value := func(h string) string { return record[columns[h]] }
+		tx := postedTx{}
+		var errs []error
+		parseUint := func(h string) uint64 {
+			v, err := strconv.ParseUint(value(h), 10, 64)
+			errs = append(errs, err)
+			return v
+		}
+		parseWei := func(h string) *big.Int {
+			v, ok := new(big.Int).SetString(value(h), 10)
+			if !ok {
+				errs = append(errs, fmt.Errorf("%s: %q is not an amount", h, value(h)))
+			}
+			return v
+		}
+		tx.block = parseUint("Block")
+		tx.time, err = time.Parse(time.RFC3339, value("Time"))
+		errs = append(errs, err)
+		tx.blob = parseUint("Type") == types.BlobTxType
+		tx.gasUsed = parseUint("Gas Used")
+		tx.gasPrice = parseWei("Effective Gas Price(wei)")
+		tx.blobGasUsed = parseUint("Blob Gas Used")
+		if value("Blob Gas Price(wei)") != "" {
+			tx.blobGasPrice = parseWei("Blob Gas Price(wei)")
+		}
+		tx.cost = parseWei("Cost(wei)")
+		if err := errors.Join(errs...); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		txs = append(txs, tx)
+	}
+	if len(txs) == 0 {
+		return nil, fmt.Errorf("%s: no transactions", path)
+	}
+	slices.SortStableFunc(txs, func(a, b postedTx) int { return a.time.Compare(b.time) })
+	return txs, nil
+}
+
+// sampleFees adds to prices the base fee plus tip and the blob base fee of a
+// block every step over the time of txs, and returns the number of samples.
+// A negative tip is replaced by the median tip that txs paid.
+func sampleFees(ctx context.Context, rpcURLs string, txs []postedTx, step time.Duration, tip *big.Int, prices *simulate.Prices) (int, error) {
+	pool, err := fetch.Dial(rpcURLs)
+	if err != nil {
+		return 0, err
+	}
+	defer pool.Close()
+	headers := func(numbers []uint64) ([]fetch.Header, error) {
+		var all []fetch.Header
+		for len(numbers) > 0 {
+			chunk := numbers[:min(len(numbers), 100)]
+			numbers = numbers[len(chunk):]
+			h, err := pool.BlockHeaders(ctx, chunk)
+			if err != nil {
+				return nil, err
+			}
+			all = append(all, h...)
+		}
+		return all, nil
+	}
+
+	if tip.Sign() < 0 {
+		var numbers []uint64
+		for _, tx := range txs {
+			numbers = append(numbers, tx.block)
+		}
+		slices.Sort(numbers)
+		numbers = slices.Compact(numbers)
+		hs, err := headers(numbers)
+		if err != nil {
+			return 0, err
+		}
+		baseFees := make(map[uint64]*big.Int, len(numbers))
+		for i, h := range hs {
+			baseFees[numbers[i]] = h.BaseFee
+		}
+		var tips []*big.Int
+		for _, tx := range txs {
+			if baseFee := baseFees[tx.block]; baseFee != nil {
+				tips = append(tips, new(big.Int).Sub(tx.gasPrice, baseFee))
+			}
+		}
+		tip = new(big.Int)
+		if len(tips) > 0 {
+			slices.SortFunc(tips, (*big.Int).Cmp)
+			tip = tips[len(tips)/2]
+		}
+	}
+
+	// The block of a time is estimated from the latest transaction before
+	// it, a block per slot.
+	var numbers []uint64
+	i := 0
+	for t := txs[0].time.Truncate(step); !t.After(txs[len(txs)-1].time); t = t.Add(step) {
+		for i+1 < len(txs) && !txs[i+1].time.After(t) {
+			i++
+		}
+		slots := int64(t.Sub(txs[i].time) / slotTime)
+		if slots < 0 && uint64(-slots) > txs[i].block {
+			continue
+		}
+		numbers = append(numbers, uint64(int64(txs[i].block)+slots))
+	}
+	slices.Sort(numbers)
+	numbers = slices.Compact(numbers)
+	hs, err := headers(numbers)
+	if err != nil {
+		return 0, err
+	}
+	for _, h := range hs {
+		if h.BaseFee == nil {
+			continue
+		}
+		var blobPrice *big.Int
+		if h.ExcessBlobGas != nil {
+			blobPrice = fetch.BlobBaseFee(*h.ExcessBlobGas, h.Time)
+		}
+		prices.Add(h.Time, new(big.Int).Add(h.BaseFee, tip), blobPrice)
+	}
+	return len(hs), nil
+}
+
+// writeSimulation writes the results of the policies and how they compare
+// with spent, in wei, to w in format.
+func writeSimulation(w io.Writer, format string, spent *big.Int, results []simulate.Result) error {
+	header := []string{"Policy", "Submissions", "Txs", "Blobs", "Calldata Gas", "Blob Gas", "Cost(ETH)", "vs Actual(ETH)", "vs Actual(%)", "Avg Delay", "Max Delay"}
+	records := [][]string{header}
+	for _, r := range results {
+		diff := new(big.Int).Sub(r.Cost, spent)
+		percent := ""
+		if spent.Sign() > 0 {
+			ratio, _ := new(big.Float).Quo(new(big.Float).SetInt(diff), new(big.Float).SetInt(spent)).Float64()
+			percent = strconv.FormatFloat(100*ratio, 'f', 1, 64)
+		}
+		records = append(records, []string{
+			r.Policy,
+			strconv.FormatUint(r.Submissions, 10),
+			strconv.FormatUint(r.Txs, 10),
+			strconv.FormatUint(r.Blobs, 10),
+			strconv.FormatUint(r.CalldataGas, 10),
+			strconv.FormatUint(r.BlobGas, 10),
+			ether(r.Cost).Text('f', 6),
+			ether(diff).Text('f', 6),
+			percent,
+			r.AvgDelay.Round(time.Second).String(),
+			r.MaxDelay.Round(time.Second).String(),
+		})
+	}
+	if format == "csv" {
+		writer := csv.NewWriter(w)
+		writer.WriteAll(records)
+		return writer.Error()
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	for _, record := range records {
+		fmt.Fprintln(tw, strings.Join(record, "\t")+"\t")
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"plugin"
+	"strings"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/sink"
+)
+
+// sinkKindsHelp lists the kinds of sink compiled in, for the help of -sink.
+func sinkKindsHelp() string {
+	return strings.Join(sink.Kinds(), ", ")
+}
+
+// loadSinkPlugins opens the Go plugins of the comma-separated list of paths.
+// A plugin adds its kinds of sink by calling sink.Register from its init
+// function, which runs when it is opened.
+func loadSinkPlugins(list string) error {
+	for _, path := range strings.Split(list, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if _, err := plugin.Open(path); err != nil {
+			return fmt.Errorf("-sink-plugin: %w", err)
+		}
+	}
+	return nil
+}
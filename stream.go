@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+)
+
+// streamBuffer is the number of events queued for a client before it is
+// dropped as too slow.
+const streamBuffer = 256
+
+// streamKeepAlive is how often an idle stream sends a comment, so that
+// proxies do not close it.
+const streamKeepAlive = 15 * time.Second
+
+// streamEvent is a server-sent event.
+type streamEvent struct {
+	name string
+	data []byte
+}
+
+// streamHub pushes the records of the daemon to the clients of /stream as
+// server-sent events: tx for every transaction stored, day for the running
+// aggregate of a day that new transactions changed, and final-day for a day
+// that a backfill finalized. The data of an event is the object of the JSON
+// Lines report, as served by /api/v1/.
+type streamHub struct {
+	mu      sync.Mutex
+	clients map[chan streamEvent]struct{}
+}
+
+func newStreamHub() *streamHub {
+	return &streamHub{clients: make(map[chan streamEvent]struct{})}
+}
+
+// Tx publishes a stored transaction.
+func (h *streamHub) Tx(tx aggregate.Tx) {
+	data, err := output.EncodeTx(tx)
+	if err != nil {
+		slog.Warn("encoding stream event", "tx", tx.Hash, "err", err)
+		return
+	}
+	h.publish(streamEvent{name: "tx", data: data})
+}
+
+// Day publishes the aggregate of day, as final-day when a backfill finalized
+// it.
+func (h *streamHub) Day(day string, r *aggregate.Result, final bool) {
+	data, err := output.EncodeBucket(day, r)
+	if err != nil {
+		slog.Warn("encoding stream event", "day", day, "err", err)
+		return
+	}
+	name := "day"
+	if final {
+		name = "final-day"
+	}
+	h.publish(streamEvent{name: name, data: data})
+}
+
+// publish queues e for every client. A client whose queue is full is
+// disconnected rather than holding up the daemon; it reconnects and reads
+// the days it missed from the database.
+func (h *streamHub) publish(e streamEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.clients {
+		select {
+		case c <- e:
+		default:
+			delete(h.clients, c)
+			close(c)
+			slog.Warn("stream client too slow, disconnected")
+		}
+	}
+}
+
+func (h *streamHub) subscribe() chan streamEvent {
+	c := make(chan streamEvent, streamBuffer)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clients[c] = struct{}{}
+	return c
+}
+
+func (h *streamHub) unsubscribe(c chan streamEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[c]; ok {
+		delete(h.clients, c)
+		close(c)
+	}
+}
+
+// handler serves /stream, which sends the events published from the time a
+// client connects until it disconnects.
+func (h *streamHub) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		c := h.subscribe()
+		defer h.unsubscribe(c)
+		fmt.Fprint(w, ": connected\n\n")
+		flusher.Flush()
+
+		keepAlive := time.NewTicker(streamKeepAlive)
+		defer keepAlive.Stop()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-keepAlive.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+			case e, ok := <-c:
+				if !ok {
+					return
+				}
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.name, e.data)
+			}
+			flusher.Flush()
+		}
+	})
+	return mux
+}
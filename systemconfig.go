@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/fetch"
+)
+
+// discoverSenders reads the batcher and the proposer of an OP Stack chain
+// from its SystemConfig contract at address and adds them to senders. It
+// returns the SystemConfig read.
+func discoverSenders(rpcURLs, address string, timeout time.Duration, senders []common.Address, log *slog.Logger) ([]common.Address, fetch.SystemConfig, error) {
+	if !common.IsHexAddress(address) {
+		return nil, fetch.SystemConfig{}, fmt.Errorf("invalid address %q", address)
+	}
+	pool, err := fetch.Dial(rpcURLs)
+	if err != nil {
+		return nil, fetch.SystemConfig{}, err
+	}
+	defer pool.Close()
+	pool.Timeout = timeout
+	pool.Logger = log
+	cfg, err := pool.SystemConfig(context.Background(), common.HexToAddress(address))
+	if err != nil {
+		return nil, cfg, err
+	}
+	log.Info("read system config", "batcher", cfg.Batcher, "batchInbox", cfg.BatchInbox, "proposer", cfg.Proposer,
+		"l2OutputOracle", cfg.L2OutputOracle, "disputeGameFactory", cfg.DisputeGameFactory,
+		"optimismPortal", cfg.OptimismPortal, "l1StandardBridge", cfg.L1StandardBridge,
+		"l1CrossDomainMessenger", cfg.L1CrossDomainMessenger, "l1ERC721Bridge", cfg.L1ERC721Bridge)
+	for _, sender := range []common.Address{cfg.Batcher, cfg.Proposer} {
+		if sender != (common.Address{}) && !slices.Contains(senders, sender) {
+			senders = append(senders, sender)
+		}
+	}
+	return senders, cfg, nil
+}
+
+// systemConfigRoles adds the roles of the batch inbox, the L2OutputOracle and
+// the DisputeGameFactory of cfg to roles and names, unless -roles already
+// gives the address a role.
+func systemConfigRoles(cfg fetch.SystemConfig, roles map[common.Address]string, names []string) (map[common.Address]string, []string) {
+	if roles == nil {
+		roles = make(map[common.Address]string)
+	}
+	for _, r := range []struct {
+		address common.Address
+		name    string
+	}{
+		{cfg.BatchInbox, "batch-inbox"},
+		{cfg.L2OutputOracle, "output-oracle"},
+		{cfg.DisputeGameFactory, "dispute-game"},
+	} {
+		if _, ok := roles[r.address]; ok || r.address == (common.Address{}) {
+			continue
+		}
+		roles[r.address] = r.name
+		if !slices.Contains(names, r.name) {
+			names = append(names, r.name)
+		}
+	}
+	return roles, names
+}
+
+// bridgeContracts returns the bridge contracts of cfg that it names.
+func bridgeContracts(cfg fetch.SystemConfig) []common.Address {
+	var contracts []common.Address
+	for _, contract := range []common.Address{cfg.OptimismPortal, cfg.L1StandardBridge, cfg.L1CrossDomainMessenger, cfg.L1ERC721Bridge} {
+		if contract != (common.Address{}) {
+			contracts = append(contracts, contract)
+		}
+	}
+	return contracts
+}
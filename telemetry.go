@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/telemetry"
+)
+
+// startTelemetry exports the traces and metrics of the command to the OTLP
+// collector at endpoint, if any, and returns the function flushing them on
+// exit.
+func startTelemetry(endpoint, service string, interval time.Duration) (func(), error) {
+	if endpoint == "" {
+		return func() {}, nil
+	}
+	shutdown, err := telemetry.Setup(context.Background(), endpoint, service, interval)
+	if err != nil {
+		return nil, fmt.Errorf("-otlp-endpoint: %w", err)
+	}
+	slog.Info("exporting traces and metrics", "endpoint", endpoint)
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			slog.Warn("telemetry export failed", "err", err)
+		}
+	}, nil
+}
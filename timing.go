@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/fetch"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+)
+
+// minFeeCost returns what the transactions of r would have cost in ETH had
+// they all landed at the lowest base fee and blob base fee of m: their gas
+// at those fees, plus the priority tips they paid when they are known, since
+// timing does not save those. It is false when the fees of m do not cover
+// the gas of r.
+func minFeeCost(r *aggregate.Result, m fetch.BlobMarket) (*big.Float, bool) {
+	if m.MinBaseFee == nil || r.TotalBlobGasUsed > 0 && m.Min == nil {
+		return nil, false
+	}
+	wei := new(big.Int).Mul(new(big.Int).SetUint64(r.TotalCalldataGasUsed), m.MinBaseFee)
+	if r.TotalBlobGasUsed > 0 {
+		wei.Add(wei, new(big.Int).Mul(new(big.Int).SetUint64(r.TotalBlobGasUsed), m.Min))
+	}
+	if r.BaseFeeMissing == 0 && r.PriorityFeeCostWei != nil {
+		wei.Add(wei, r.PriorityFeeCostWei)
+	}
+	return ether(wei), true
+}
+
+// timingColumns returns the cost of every bucket at its lowest fees, and the
+// timing inefficiency: how much more the bucket cost, in ETH and as a
+// percentage of its cost, which better timed submissions could have saved.
+func timingColumns(results map[string]*aggregate.Result, markets map[string]fetch.BlobMarket) []output.Column {
+	minimum := output.Column{Header: "Min-Fee Cost(ETH)", Values: make(map[string]string, len(results))}
+	delta := output.Column{Header: "Timing Inefficiency(ETH)", Values: make(map[string]string, len(results))}
+	share := output.Column{Header: "Timing Inefficiency(%)", Values: make(map[string]string, len(results))}
+	for k, r := range results {
+		least, ok := minFeeCost(r, markets[k])
+		if !ok {
+			continue
+		}
+		minimum.Values[k] = least.Text('f', 18)
+		if r.BlobPriceMissing > 0 {
+			delta.Values[k], share.Values[k] = aggregate.Unavailable, aggregate.Unavailable
+			continue
+		}
+		extra := new(big.Float).Sub(r.Cost, least)
+		delta.Values[k] = extra.Text('f', 18)
+		if r.Cost.Sign() > 0 {
+			pct, _ := new(big.Float).Quo(extra, r.Cost).Float64()
+			share.Values[k] = strconv.FormatFloat(100*pct, 'f', 2, 64)
+		}
+	}
+	return []output.Column{minimum, delta, share}
+}
+
+// printTimingSummary prints the timing inefficiency of the whole report, the
+// sum over its buckets, and the bucket with the largest one.
+func printTimingSummary(w io.Writer, dates []string, results map[string]*aggregate.Result, markets map[string]fetch.BlobMarket) {
+	cost, least := new(big.Float), new(big.Float)
+	var worst string
+	var worstExtra *big.Float
+	for _, k := range dates {
+		r := results[k]
+		m, ok := minFeeCost(r, markets[k])
+		if !ok || r.BlobPriceMissing > 0 {
+			continue
+		}
+		cost.Add(cost, r.Cost)
+		least.Add(least, m)
+		if extra := new(big.Float).Sub(r.Cost, m); worstExtra == nil || extra.Cmp(worstExtra) > 0 {
+			worst, worstExtra = k, extra
+		}
+	}
+	if worstExtra == nil {
+		fmt.Fprintln(w, "Timing: no bucket with known fees")
+		return
+	}
+	extra := new(big.Float).Sub(cost, least)
+	fmt.Fprintf(w, "Timing: %s ETH at the lowest fees of every bucket instead of %s ETH, %s ETH more",
+		least.Text('f', 6), cost.Text('f', 6), extra.Text('f', 6))
+	if cost.Sign() > 0 {
+		pct, _ := new(big.Float).Quo(extra, cost).Float64()
+		fmt.Fprintf(w, " (%.1f%%)", 100*pct)
+	}
+	fmt.Fprintf(w, "; the most in %s, %s ETH\n", worst, worstExtra.Text('f', 6))
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+)
+
+// tipMarketColumns returns, for every bucket, the median and 90th percentile
+// of the tips paid as multiples of the median tip of their blocks, the number
+// of transactions that paid threshold times the market or more, and a flag
+// for the buckets whose median reaches threshold. Buckets without block
+// median tips are left empty.
+func tipMarketColumns(results map[string]*aggregate.Result, threshold float64) []output.Column {
+	median := output.Column{Header: "Median Tip/Market", Values: make(map[string]string, len(results))}
+	p90 := output.Column{Header: "P90 Tip/Market", Values: make(map[string]string, len(results))}
+	above := output.Column{Header: fmt.Sprintf("Txs Above %gx Market", threshold), Values: make(map[string]string, len(results))}
+	flag := output.Column{Header: "Far Above Market", Values: make(map[string]string, len(results))}
+	for k, r := range results {
+		m, ok := r.TipRatios.Quantile(50)
+		if !ok {
+			continue
+		}
+		p, _ := r.TipRatios.Quantile(90)
+		median.Values[k] = strconv.FormatFloat(m, 'f', 2, 64)
+		p90.Values[k] = strconv.FormatFloat(p, 'f', 2, 64)
+		above.Values[k] = strconv.FormatUint(r.TipRatios.Above(threshold), 10)
+		if m >= threshold {
+			flag.Values[k] = "yes"
+		}
+	}
+	return []output.Column{median, p90, above, flag}
+}
+
+// printTipMarketSummary prints the distribution of the tips paid against the
+// median tips of their blocks over the whole report, and the buckets whose
+// median ratio reaches threshold.
+func printTipMarketSummary(w io.Writer, dates []string, results map[string]*aggregate.Result, total *aggregate.Result, threshold float64) {
+	ratios := &total.TipRatios
+	if ratios.Count == 0 {
+		fmt.Fprintln(w, "Tip vs market: no transaction with a known block median tip")
+		return
+	}
+	var parts []string
+	for _, p := range []int{10, 50, 90, 99} {
+		v, _ := ratios.Quantile(p)
+		parts = append(parts, fmt.Sprintf("p%d %.2fx", p, v))
+	}
+	fmt.Fprintf(w, "Tip vs market: %s the block median tip; %d of %d txs paid %gx or more\n",
+		strings.Join(parts, ", "), ratios.Above(threshold), ratios.Count, threshold)
+	var far []string
+	for _, k := range dates {
+		if m, ok := results[k].TipRatios.Quantile(50); ok && m >= threshold {
+			far = append(far, fmt.Sprintf("%s (%.2fx)", k, m))
+		}
+	}
+	if len(far) > 0 {
+		fmt.Fprintf(w, "Far above market: %s\n", strings.Join(far, ", "))
+	}
+}
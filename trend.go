@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+)
+
+// rollingWindows are the days over which the costs of a daily report are
+// averaged.
+var rollingWindows = []int{7, 30}
+
+// trendColumns returns the report columns of the rolling average costs and
+// the day-over-day change of the cost of a daily report. With eras, days are
+// only averaged with and compared to days of their own era.
+func trendColumns(dates []string, results map[string]*aggregate.Result, eras map[string]string) []output.Column {
+	var columns []output.Column
+	for _, days := range rollingWindows {
+		columns = append(columns, rollingColumn(days, dates, results, eras))
+	}
+	columns = append(columns, changeColumn(dates, results, eras))
+	return append(columns, feeShareColumns(dates, results, eras)...)
+}
+
+// cumulativeColumn gives the running total of the cost up to every bucket,
+// unavailable from the first bucket whose cost is.
+func cumulativeColumn(dates []string, results map[string]*aggregate.Result) output.Column {
+	c := output.Column{Header: "Cumulative Cost(ETH)", Values: make(map[string]string, len(dates))}
+	sum := new(big.Float)
+	for _, k := range dates {
+		r := results[k]
+		if r.BlobPriceMissing > 0 {
+			sum = nil
+		}
+		if sum == nil {
+			c.Values[k] = aggregate.Unavailable
+			continue
+		}
+		sum.Add(sum, r.Cost)
+		c.Values[k] = sum.String()
+	}
+	return c
+}
+
+// rollingColumn averages the cost of every day and of the days-1 days before
+// it, counting the days without transactions as zero. The window starts no
+// earlier than the report, so that its first days average fewer days, nor,
+// with eras, than the era of the day. The average is unavailable when the
+// cost of one of the days is.
+func rollingColumn(days int, dates []string, results map[string]*aggregate.Result, eras map[string]string) output.Column {
+	c := output.Column{Header: fmt.Sprintf("%d-day Avg Cost(ETH)", days), Values: make(map[string]string, len(dates))}
+	for _, day := range dates {
+		t, err := time.Parse(time.DateOnly, day)
+		if err != nil {
+			continue
+		}
+		var sum float64
+		n := 0
+		for i := 0; i < days; i++ {
+			before := t.AddDate(0, 0, -i).Format(time.DateOnly)
+			if before < dates[0] {
+				break
+			}
+			if eras != nil && eras[before] != eras[day] {
+				break
+			}
+			n++
+			r := results[before]
+			if r == nil {
+				continue
+			}
+			if r.BlobPriceMissing > 0 {
+				n = 0
+				break
+			}
+			cost, _ := r.Cost.Float64()
+			sum += cost
+		}
+		if n == 0 {
+			c.Values[day] = aggregate.Unavailable
+			continue
+		}
+		c.Values[day] = strconv.FormatFloat(sum/float64(n), 'g', 10, 64)
+	}
+	return c
+}
+
+// changeColumn gives the change in percent of the cost of every day from the
+// day before, empty when that day has no transactions or no cost, or is of
+// another era with eras.
+func changeColumn(dates []string, results map[string]*aggregate.Result, eras map[string]string) output.Column {
+	c := output.Column{Header: "Day-over-day Change(%)", Values: make(map[string]string, len(dates))}
+	for _, day := range dates {
+		t, err := time.Parse(time.DateOnly, day)
+		if err != nil {
+			continue
+		}
+		yesterday := t.AddDate(0, 0, -1).Format(time.DateOnly)
+		r, prev := results[day], results[yesterday]
+		if prev == nil || eras != nil && eras[yesterday] != eras[day] {
+			continue
+		}
+		if r.BlobPriceMissing > 0 || prev.BlobPriceMissing > 0 {
+			c.Values[day] = aggregate.Unavailable
+			continue
+		}
+		cost, _ := r.Cost.Float64()
+		before, _ := prev.Cost.Float64()
+		if before > 0 {
+			c.Values[day] = strconv.FormatFloat(100*(cost-before)/before, 'f', 2, 64)
+		}
+	}
+	return c
+}
+
+// feeShareWindow is the number of days of the trailing blob fee share.
+const feeShareWindow = 7
+
+// feeShareColumns give the shares of the cost of every day paid in blob fees
+// and in execution fees, those of the calldata gas of every transaction, the
+// blob fee share of the trailing feeShareWindow days, weighted by their cost,
+// and its change in percentage points from feeShareWindow days before, so
+// that shifts of the cost structure stand out from daily noise. Shares are
+// empty for days without cost and unavailable when a blob cost is. With
+// eras, windows keep to the era of the day.
+func feeShareColumns(dates []string, results map[string]*aggregate.Result, eras map[string]string) []output.Column {
+	blob := output.Column{Header: "Blob Fee Share(%)", Values: make(map[string]string, len(dates))}
+	execution := output.Column{Header: "Execution Fee Share(%)", Values: make(map[string]string, len(dates))}
+	trailing := output.Column{Header: fmt.Sprintf("%d-day Blob Fee Share(%%)", feeShareWindow), Values: make(map[string]string, len(dates))}
+	change := output.Column{Header: fmt.Sprintf("%d-day Blob Fee Share Change(pp)", feeShareWindow), Values: make(map[string]string, len(dates))}
+
+	// share returns the blob fee share of the window ending at end, whether
+	// its costs are available, and whether it has any.
+	share := func(end time.Time, era string) (float64, bool, bool) {
+		var cost, blobCost float64
+		for i := 0; i < feeShareWindow; i++ {
+			day := end.AddDate(0, 0, -i).Format(time.DateOnly)
+			r := results[day]
+			if r == nil || eras != nil && eras[day] != era {
+				continue
+			}
+			if r.BlobPriceMissing > 0 {
+				return 0, false, false
+			}
+			c, _ := r.Cost.Float64()
+			b, _ := r.BlobCost.Float64()
+			cost += c
+			blobCost += b
+		}
+		if cost <= 0 {
+			return 0, true, false
+		}
+		return 100 * blobCost / cost, true, true
+	}
+	percent := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+
+	for _, day := range dates {
+		t, err := time.Parse(time.DateOnly, day)
+		if err != nil {
+			continue
+		}
+		r := results[day]
+		if r.BlobPriceMissing > 0 {
+			for _, c := range []output.Column{blob, execution, trailing, change} {
+				c.Values[day] = aggregate.Unavailable
+			}
+			continue
+		}
+		if cost, _ := r.Cost.Float64(); cost > 0 {
+			b, _ := r.BlobCost.Float64()
+			blob.Values[day] = percent(100 * b / cost)
+			execution.Values[day] = percent(100 * (cost - b) / cost)
+		}
+		now, available, ok := share(t, eras[day])
+		switch {
+		case !available:
+			trailing.Values[day] = aggregate.Unavailable
+			change.Values[day] = aggregate.Unavailable
+			continue
+		case !ok:
+			continue
+		}
+		trailing.Values[day] = percent(now)
+		before, available, ok := share(t.AddDate(0, 0, -feeShareWindow), eras[day])
+		switch {
+		case !available:
+			change.Values[day] = aggregate.Unavailable
+		case ok:
+			change.Values[day] = percent(now - before)
+		}
+	}
+	return []output.Column{blob, execution, trailing, change}
+}
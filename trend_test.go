@@ -0,0 +1,124 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+)
+
+// trendDates are the days of a daily report around the activation of Cancun
+// on mainnet on 2024-03-13, without transactions on the 12th and the 15th.
+var trendDates = []string{"2024-03-10", "2024-03-11", "2024-03-13", "2024-03-14", "2024-03-16", "2024-03-17"}
+
+// trendResults gives the days of trendDates the costs 1, 2, 4, 6, 8 and 10,
+// with the blob gas price of the day missing, if any.
+func trendResults(missing string) map[string]*aggregate.Result {
+	results := make(map[string]*aggregate.Result, len(trendDates))
+	for i, day := range trendDates {
+		r := aggregate.NewResult()
+		r.TxCount = 1
+		r.Cost = big.NewFloat([]float64{1, 2, 4, 6, 8, 10}[i])
+		if day == missing {
+			r.BlobPriceMissing = 1
+		}
+		results[day] = r
+	}
+	return results
+}
+
+func TestTrendColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		missing string
+		eras    bool
+		rolling []string // 7-day averages of trendDates
+		change  []string
+	}{
+		{
+			name:    "gaps",
+			rolling: []string{"1", "1.5", "1.75", "2.6", "3", "4.285714286"},
+			change:  []string{"", "100.00", "", "50.00", "", "25.00"},
+		},
+		{
+			// The 12th and the 15th count in the windows of their era only:
+			// the 13th, the transition, averages no other day.
+			name:    "eras",
+			eras:    true,
+			rolling: []string{"1", "1.5", "4", "6", "4.666666667", "6"},
+			change:  []string{"", "100.00", "", "", "", "25.00"},
+		},
+		{
+			name:    "unavailable",
+			missing: "2024-03-13",
+			rolling: []string{"1", "1.5", "n/a", "n/a", "n/a", "n/a"},
+			change:  []string{"", "100.00", "", "n/a", "", "25.00"},
+		},
+		{
+			name:    "unavailable in another era",
+			missing: "2024-03-13",
+			eras:    true,
+			rolling: []string{"1", "1.5", "n/a", "6", "4.666666667", "6"},
+			change:  []string{"", "100.00", "", "", "", "25.00"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := trendResults(tt.missing)
+			var eras map[string]string
+			if tt.eras {
+				var err error
+				if eras, err = bucketEras(1, "day", time.UTC, trendDates, results); err != nil {
+					t.Fatal(err)
+				}
+			}
+			rolling := rollingColumn(7, trendDates, results, eras)
+			change := changeColumn(trendDates, results, eras)
+			for i, day := range trendDates {
+				if got := rolling.Values[day]; got != tt.rolling[i] {
+					t.Errorf("%s: 7-day average %q, want %q", day, got, tt.rolling[i])
+				}
+				if got := change.Values[day]; got != tt.change[i] {
+					t.Errorf("%s: change %q, want %q", day, got, tt.change[i])
+				}
+			}
+		})
+	}
+}
+
+// TestBucketErasGaps checks that the days without transactions of a daily
+// report get the era of their date.
+func TestBucketErasGaps(t *testing.T) {
+	results := trendResults("")
+	eras, err := bucketEras(1, "day", time.UTC, trendDates, results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"2024-03-10": eraCalldata, "2024-03-11": eraCalldata, "2024-03-12": eraCalldata,
+		"2024-03-13": eraTransition, "2024-03-14": eraBlob, "2024-03-15": eraBlob,
+		"2024-03-16": eraBlob, "2024-03-17": eraBlob,
+	}
+	for day, era := range want {
+		if eras[day] != era {
+			t.Errorf("%s: era %q, want %q", day, eras[day], era)
+		}
+	}
+
+	// Without a known activation, the first day with a blob transaction
+	// starts the blob era and the days before it stay in the calldata era.
+	results["2024-03-14"].BlobTxCount = 1
+	eras, err = bucketEras(0, "day", time.UTC, trendDates, results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, day := range []string{"2024-03-12", "2024-03-13"} {
+		if eras[day] != eraCalldata {
+			t.Errorf("%s: era %q without an activation, want %q", day, eras[day], eraCalldata)
+		}
+	}
+	if eras["2024-03-15"] != eraBlob {
+		t.Errorf("2024-03-15: era %q without an activation, want %q", eras["2024-03-15"], eraBlob)
+	}
+}
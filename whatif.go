@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+)
+
+// whatIfColumns returns, for every bucket, the number of transactions priced
+// in the other posting mode, what they would have cost so and what posting
+// them as they were saved against it.
+func whatIfColumns(results map[string]*aggregate.Result) []output.Column {
+	txs := output.Column{Header: "What-if Txs", Values: make(map[string]string, len(results))}
+	cost := output.Column{Header: "What-if Cost(ETH)", Values: make(map[string]string, len(results))}
+	savings := output.Column{Header: "What-if Savings(ETH)", Values: make(map[string]string, len(results))}
+	costWei := output.Column{Header: "What-if Cost(wei)", Values: make(map[string]string, len(results))}
+	savingsWei := output.Column{Header: "What-if Savings(wei)", Values: make(map[string]string, len(results))}
+	for k, r := range results {
+		txs.Values[k] = strconv.FormatUint(r.WhatIfTxCount, 10)
+		cost.Values[k] = r.WhatIfCost.String()
+		costWei.Values[k] = r.WhatIfCostWei.String()
+		saved := new(big.Int).Sub(r.WhatIfCostWei, r.WhatIfActualCostWei)
+		savings.Values[k] = r.BlobDependent(ether(saved))
+		savingsWei.Values[k] = r.BlobDependentWei(saved)
+	}
+	return []output.Column{txs, cost, savings, costWei, savingsWei}
+}
+
+// printWhatIfSummary prints what the transactions would have cost in the
+// other posting mode over the whole report.
+func printWhatIfSummary(w io.Writer, total *aggregate.Result) {
+	fmt.Fprintf(w, "What-if: %d txs would have cost %s ETH in the other posting mode instead of %s ETH, saving %s ETH\n",
+		total.WhatIfTxCount, total.WhatIfCost.String(), total.BlobDependent(total.WhatIfActualCost),
+		total.BlobDependent(ether(new(big.Int).Sub(total.WhatIfCostWei, total.WhatIfActualCostWei))))
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/aggregate"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/fetch"
+	"github.com/ohbyeongmin/batcher-gas-tracker/pkg/output"
+)
+
+// feeWithdrawals reads the fee vault withdrawals that recipient received on
+// L1 over the buckets of dates, in the zone loc, and sums them by bucket.
+func feeWithdrawals(ctx context.Context, s *fetch.Scanner, cfg fetch.SystemConfig, recipient common.Address, granularity string, loc *time.Location, dates []string, log *slog.Logger) (map[string]*big.Int, error) {
+	if len(dates) == 0 {
+		return nil, nil
+	}
+	if cfg.OptimismPortal == (common.Address{}) && cfg.L1StandardBridge == (common.Address{}) {
+		return nil, fmt.Errorf("the system config names neither the OptimismPortal nor the L1StandardBridge")
+	}
+	from, _, err := bucketRange(dates[0], granularity, loc)
+	if err != nil {
+		return nil, err
+	}
+	_, to, err := bucketRange(dates[len(dates)-1], granularity, loc)
+	if err != nil {
+		return nil, err
+	}
+	withdrawals, err := s.FeeWithdrawals(ctx, from, to, cfg.OptimismPortal, cfg.L1StandardBridge, recipient)
+	if err != nil {
+		return nil, err
+	}
+	received := make(map[string]*big.Int)
+	for _, w := range withdrawals {
+		k := aggregate.BucketKey(w.Time.In(loc), granularity)
+		if received[k] == nil {
+			received[k] = new(big.Int)
+		}
+		received[k].Add(received[k], w.Amount)
+		log.Info("fee vault withdrawal", "tx", w.Hash, "vault", w.Vault, "amount", ether(w.Amount).String(), "bucket", k)
+	}
+	return received, nil
+}
+
+// withdrawalColumns returns the fee vault withdrawals received in every
+// bucket and the net flow, what was received less the L1 cost.
+func withdrawalColumns(results map[string]*aggregate.Result, received map[string]*big.Int) []output.Column {
+	in := output.Column{Header: "Fee Withdrawals(ETH)", Values: make(map[string]string, len(results))}
+	net := output.Column{Header: "Net Flow(ETH)", Values: make(map[string]string, len(results))}
+	for k, r := range results {
+		amount := new(big.Int)
+		if received[k] != nil {
+			amount = received[k]
+		}
+		in.Values[k] = ether(amount).String()
+		net.Values[k] = r.BlobDependent(new(big.Float).Sub(ether(amount), r.Cost))
+	}
+	return []output.Column{in, net}
+}
+
+// printWithdrawalSummary prints the fee vault withdrawals received over the
+// report and the net flow.
+func printWithdrawalSummary(w io.Writer, total *aggregate.Result, received map[string]*big.Int) {
+	sum := new(big.Int)
+	for _, amount := range received {
+		sum.Add(sum, amount)
+	}
+	fmt.Fprintf(w, "Fee withdrawals: %s ETH received, net flow %s ETH\n",
+		ether(sum).String(), total.BlobDependent(new(big.Float).Sub(ether(sum), total.Cost)))
+}